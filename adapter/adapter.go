@@ -1,6 +1,7 @@
 package adapter
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/perfect-panel/server/internal/model/node"
@@ -16,6 +17,9 @@ type Adapter struct {
 	OutputFormat   string            // 输出格式，默认是 base64
 	SubscribeName  string            // 订阅名称
 	Params         map[string]string // 其他参数
+	ExtraRules     string            // 自定义规则
+	Protocols      []string          // 客户端支持的协议，为空表示全部支持
+	Priority       []string          // 同一服务器优先输出的协议，为空保持节点顺序
 }
 
 type Option func(*Adapter)
@@ -61,6 +65,27 @@ func WithSubscribeName(name string) Option {
 	}
 }
 
+// WithExtraRules 设置自定义规则
+func WithExtraRules(rules string) Option {
+	return func(opts *Adapter) {
+		opts.ExtraRules = rules
+	}
+}
+
+// WithSupportedProtocols 设置客户端支持的协议
+func WithSupportedProtocols(protocols []string) Option {
+	return func(opts *Adapter) {
+		opts.Protocols = protocols
+	}
+}
+
+// WithProtocolPriority 设置同一服务器协议的输出优先级
+func WithProtocolPriority(protocols []string) Option {
+	return func(opts *Adapter) {
+		opts.Priority = protocols
+	}
+}
+
 func NewAdapter(tpl string, opts ...Option) *Adapter {
 	adapter := &Adapter{
 		Servers:        []*node.Node{},
@@ -85,6 +110,7 @@ func (adapter *Adapter) Client() (*Client, error) {
 		Proxies:        []Proxy{},
 		UserInfo:       adapter.UserInfo,
 		Params:         adapter.Params,
+		ExtraRules:     adapter.ExtraRules,
 	}
 
 	proxies, err := adapter.Proxies(adapter.Servers)
@@ -95,17 +121,85 @@ func (adapter *Adapter) Client() (*Client, error) {
 	return client, nil
 }
 
+// supports reports whether the client can handle the given protocol.
+func (adapter *Adapter) supports(protocol string) bool {
+	if len(adapter.Protocols) == 0 {
+		return true
+	}
+	for _, item := range adapter.Protocols {
+		if strings.EqualFold(item, protocol) {
+			return true
+		}
+	}
+	return false
+}
+
+// rank returns the position of the protocol in the priority list, protocols not listed rank last.
+func (adapter *Adapter) rank(protocol string) int {
+	for i, item := range adapter.Priority {
+		if strings.EqualFold(item, protocol) {
+			return i
+		}
+	}
+	return len(adapter.Priority)
+}
+
+// orderByPriority orders the nodes of each server by the protocol priority. The nodes of a server
+// are swapped among the positions they already take, so the server order stays and no node is
+// dropped or duplicated. Protocols of the same rank keep their order.
+func (adapter *Adapter) orderByPriority(servers []*node.Node) []*node.Node {
+	if len(adapter.Priority) == 0 {
+		return servers
+	}
+	positions := make(map[int64][]int)
+	for i, item := range servers {
+		if item.ServerId != 0 {
+			positions[item.ServerId] = append(positions[item.ServerId], i)
+		}
+	}
+	ordered := make([]*node.Node, len(servers))
+	copy(ordered, servers)
+	for _, indexes := range positions {
+		if len(indexes) < 2 {
+			continue
+		}
+		group := make([]*node.Node, len(indexes))
+		for j, i := range indexes {
+			group[j] = servers[i]
+		}
+		sort.SliceStable(group, func(a, b int) bool {
+			return adapter.rank(group[a].Protocol) < adapter.rank(group[b].Protocol)
+		})
+		for j, i := range indexes {
+			ordered[i] = group[j]
+		}
+	}
+	return ordered
+}
+
 func (adapter *Adapter) Proxies(servers []*node.Node) ([]Proxy, error) {
+	servers = adapter.orderByPriority(servers)
 	var proxies []Proxy
+	var skipped int
+	defer func() {
+		if skipped > 0 {
+			logger.Debugf("[Adapter] skipped %d nodes with malformed server data", skipped)
+		}
+	}()
 
 	for _, item := range servers {
+		if !adapter.supports(item.Protocol) {
+			continue
+		}
 		if item.Server == nil {
 			logger.Errorf("[Adapter] Server is nil for node ID: %d", item.Id)
+			skipped++
 			continue
 		}
 		protocols, err := item.Server.UnmarshalProtocols()
 		if err != nil {
 			logger.Errorf("[Adapter] Unmarshal Protocols error: %s; server id : %d", err.Error(), item.ServerId)
+			skipped++
 			continue
 		}
 		for _, protocol := range protocols {
@@ -156,7 +250,7 @@ func (adapter *Adapter) Proxies(servers []*node.Node) ([]Proxy, error) {
 						EncryptionPrivateKey:    protocol.EncryptionPrivateKey,
 						EncryptionClientPadding: protocol.EncryptionClientPadding,
 						EncryptionPassword:      protocol.EncryptionPassword,
-						Ratio:                   protocol.Ratio,
+						Ratio:                   trafficRatio(protocol.Ratio, item.Rate()),
 						CertMode:                protocol.CertMode,
 						CertDNSProvider:         protocol.CertDNSProvider,
 						CertDNSEnv:              protocol.CertDNSEnv,
@@ -168,3 +262,15 @@ func (adapter *Adapter) Proxies(servers []*node.Node) ([]Proxy, error) {
 
 	return proxies, nil
 }
+
+// trafficRatio combines the protocol ratio with the traffic rate of the node. Nodes at the default rate
+// keep the protocol ratio as it is, so formats that leave an unset ratio out still do.
+func trafficRatio(ratio, rate float64) float64 {
+	if rate == 1 {
+		return ratio
+	}
+	if ratio <= 0 {
+		ratio = 1
+	}
+	return ratio * rate
+}
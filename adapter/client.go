@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/base64"
 	"reflect"
+	"strings"
 	"text/template"
 	"time"
 
@@ -89,6 +90,7 @@ type User struct {
 	Download     int64
 	Upload       int64
 	Traffic      int64
+	DeviceLimit  int64 // 0 means unlimited, templates should omit it
 	SubscribeURL string
 }
 
@@ -96,10 +98,11 @@ type Client struct {
 	SiteName       string            // Name of the site
 	SubscribeName  string            // Name of the subscription
 	ClientTemplate string            // Template for the entire client configuration
-	OutputFormat   string            // json, yaml, etc.
+	OutputFormat   string            // json, yaml, surge, etc.
 	Proxies        []Proxy           // List of proxy configurations
 	UserInfo       User              // User information
 	Params         map[string]string // Additional parameters
+	ExtraRules     string            // Custom rules merged into the output
 }
 
 func (c *Client) Build() ([]byte, error) {
@@ -127,12 +130,18 @@ func (c *Client) Build() ([]byte, error) {
 	}
 
 	result := buf.String()
+	if c.ExtraRules != "" {
+		result = string(mergeExtraRules(buf.Bytes(), c.OutputFormat, c.ExtraRules))
+	}
+	if strings.EqualFold(c.OutputFormat, OutputFormatSurge) {
+		result = withSurgeManagedConfig(result, c.UserInfo.SubscribeURL)
+	}
 	if c.OutputFormat == "base64" {
 		encoded := base64.StdEncoding.EncodeToString([]byte(result))
 		return []byte(encoded), nil
 	}
 
-	return buf.Bytes(), nil
+	return []byte(result), nil
 }
 
 func StructToMap(obj interface{}) map[string]interface{} {
@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+func TestProxiesProtocolPriority(t *testing.T) {
+	first := &node.Server{Id: 1}
+	if err := first.MarshalProtocols([]node.Protocol{
+		{Type: "shadowsocks", Port: 443, Cipher: "aes-256-gcm"},
+		{Type: "vless", Port: 8443},
+		{Type: "trojan", Port: 9443},
+	}); err != nil {
+		t.Fatalf("marshal protocols: %v", err)
+	}
+	second := &node.Server{Id: 2}
+	if err := second.MarshalProtocols([]node.Protocol{
+		{Type: "shadowsocks", Port: 443, Cipher: "aes-256-gcm"},
+	}); err != nil {
+		t.Fatalf("marshal protocols: %v", err)
+	}
+	servers := []*node.Node{
+		{Id: 1, Name: "a-ss", Protocol: "shadowsocks", ServerId: 1, Server: first},
+		{Id: 2, Name: "b-ss", Protocol: "shadowsocks", ServerId: 2, Server: second},
+		{Id: 3, Name: "a-trojan", Protocol: "trojan", ServerId: 1, Server: first},
+		{Id: 4, Name: "a-vless", Protocol: "vless", ServerId: 1, Server: first},
+	}
+
+	names := func(proxies []Proxy) []string {
+		var list []string
+		for _, proxy := range proxies {
+			list = append(list, proxy.Name)
+		}
+		return list
+	}
+	cases := []struct {
+		name     string
+		priority []string
+		want     []string
+	}{
+		{"no priority keeps the node order", nil, []string{"a-ss", "b-ss", "a-trojan", "a-vless"}},
+		{"listed protocols first", []string{"VLESS"}, []string{"a-vless", "b-ss", "a-ss", "a-trojan"}},
+		{"full order", []string{"trojan", "vless", "shadowsocks"}, []string{"a-trojan", "b-ss", "a-vless", "a-ss"}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			proxies, err := NewAdapter("", WithProtocolPriority(c.priority)).Proxies(servers)
+			if err != nil {
+				t.Fatalf("proxies: %v", err)
+			}
+			got := names(proxies)
+			if len(got) != len(c.want) {
+				t.Fatalf("expected %v, got %v", c.want, got)
+			}
+			for i := range got {
+				if got[i] != c.want[i] {
+					t.Fatalf("expected %v, got %v", c.want, got)
+				}
+			}
+		})
+	}
+	if servers[0].Name != "a-ss" || servers[3].Name != "a-vless" {
+		t.Fatalf("the node list of the caller must not be reordered")
+	}
+}
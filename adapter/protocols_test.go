@@ -0,0 +1,45 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+func TestProxiesSupportedProtocols(t *testing.T) {
+	server := &node.Server{Id: 1}
+	if err := server.MarshalProtocols([]node.Protocol{
+		{Type: "shadowsocks", Port: 443, Cipher: "aes-256-gcm"},
+		{Type: "vless", Port: 8443},
+	}); err != nil {
+		t.Fatalf("marshal protocols: %v", err)
+	}
+	servers := []*node.Node{
+		{Id: 1, Name: "ss", Protocol: "shadowsocks", ServerId: 1, Server: server},
+		{Id: 2, Name: "vless", Protocol: "vless", ServerId: 1, Server: server},
+	}
+
+	all, err := NewAdapter("").Proxies(servers)
+	if err != nil {
+		t.Fatalf("proxies: %v", err)
+	}
+	if len(all) != 2 {
+		t.Fatalf("expected 2 proxies without filter, got %d", len(all))
+	}
+
+	filtered, err := NewAdapter("", WithSupportedProtocols([]string{"Shadowsocks"})).Proxies(servers)
+	if err != nil {
+		t.Fatalf("proxies: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Type != "shadowsocks" {
+		t.Fatalf("expected only shadowsocks proxy, got %+v", filtered)
+	}
+
+	none, err := NewAdapter("", WithSupportedProtocols([]string{"hysteria2"})).Proxies(servers)
+	if err != nil {
+		t.Fatalf("proxies: %v", err)
+	}
+	if len(none) != 0 {
+		t.Fatalf("expected unsupported nodes to be omitted, got %d", len(none))
+	}
+}
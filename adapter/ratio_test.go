@@ -0,0 +1,37 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+func TestProxiesTrafficRate(t *testing.T) {
+	server := &node.Server{Id: 1}
+	if err := server.MarshalProtocols([]node.Protocol{
+		{Type: "shadowsocks", Port: 443, Cipher: "aes-256-gcm"},
+		{Type: "trojan", Port: 9443, Ratio: 1.5},
+	}); err != nil {
+		t.Fatalf("marshal protocols: %v", err)
+	}
+	servers := []*node.Node{
+		{Id: 1, Name: "ss", Protocol: "shadowsocks", ServerId: 1, Server: server},
+		{Id: 2, Name: "ss-premium", Protocol: "shadowsocks", ServerId: 1, Server: server, TrafficRate: 2},
+		{Id: 3, Name: "trojan", Protocol: "trojan", ServerId: 1, Server: server, TrafficRate: 1},
+		{Id: 4, Name: "trojan-premium", Protocol: "trojan", ServerId: 1, Server: server, TrafficRate: 2},
+	}
+	proxies, err := NewAdapter("").Proxies(servers)
+	if err != nil {
+		t.Fatalf("proxies: %v", err)
+	}
+	// rows without a rate and nodes at the default rate keep the protocol ratio as it is
+	want := map[string]float64{"ss": 0, "ss-premium": 2, "trojan": 1.5, "trojan-premium": 3}
+	if len(proxies) != len(want) {
+		t.Fatalf("expected %d proxies, got %d", len(want), len(proxies))
+	}
+	for _, proxy := range proxies {
+		if proxy.Ratio != want[proxy.Name] {
+			t.Errorf("%s: expected ratio %v, got %v", proxy.Name, want[proxy.Name], proxy.Ratio)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// RawURIs returns the share link of every proxy, one per line, without rendering any client template.
+// Proxies of a protocol without a share link format are left out.
+func RawURIs(proxies []Proxy, password string) []byte {
+	lines := make([]string, 0, len(proxies))
+	for _, p := range proxies {
+		if uri := ShareURI(p, password); uri != "" {
+			lines = append(lines, uri)
+		}
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// ShareURI returns the share link of the proxy for the user password, empty for protocols without one.
+func ShareURI(p Proxy, password string) string {
+	host := net.JoinHostPort(p.Server, strconv.Itoa(int(p.Port)))
+	sni := p.SNI
+	if sni == "" {
+		sni = p.Host
+	}
+	tls := p.Security == "tls" || p.Security == "reality"
+	query := url.Values{}
+
+	switch p.Type {
+	case "shadowsocks":
+		userInfo := base64.StdEncoding.EncodeToString([]byte(p.Method + ":" + shadowsocksPassword(p, password)))
+		return "ss://" + userInfo + "@" + host + "#" + url.PathEscape(p.Name)
+	case "vmess":
+		tlsValue := ""
+		if tls {
+			tlsValue = "tls"
+		}
+		network := p.Transport
+		if network == "" {
+			network = "tcp"
+		}
+		data, _ := json.Marshal(map[string]string{
+			"v":    "2",
+			"ps":   p.Name,
+			"add":  p.Server,
+			"port": strconv.Itoa(int(p.Port)),
+			"id":   password,
+			"aid":  "0",
+			"net":  network,
+			"type": "none",
+			"host": p.Host,
+			"path": p.Path,
+			"tls":  tlsValue,
+			"sni":  sni,
+		})
+		return "vmess://" + base64.StdEncoding.EncodeToString(data)
+	case "vless":
+		query.Set("encryption", "none")
+		setQuery(query, "flow", p.Flow)
+		setTransport(query, p)
+		if tls {
+			query.Set("security", p.Security)
+		}
+		setQuery(query, "sni", sni)
+		setQuery(query, "fp", p.Fingerprint)
+		if p.Security == "reality" {
+			setQuery(query, "pbk", p.RealityPublicKey)
+			setQuery(query, "sid", p.RealityShortId)
+		}
+		if p.AllowInsecure {
+			query.Set("allowInsecure", "1")
+		}
+	case "trojan":
+		setTransport(query, p)
+		if tls {
+			setQuery(query, "sni", sni)
+		}
+		if p.AllowInsecure {
+			query.Set("allowInsecure", "1")
+		}
+	case "hysteria2":
+		setQuery(query, "sni", sni)
+		if p.ObfsPassword != "" {
+			query.Set("obfs", "salamander")
+			query.Set("obfs-password", p.ObfsPassword)
+		}
+		setQuery(query, "mport", p.HopPorts)
+		if p.AllowInsecure {
+			query.Set("insecure", "1")
+		}
+	case "tuic":
+		setQuery(query, "congestion_control", p.CongestionController)
+		setQuery(query, "udp_relay_mode", p.UDPRelayMode)
+		if !p.DisableSNI {
+			setQuery(query, "sni", sni)
+		}
+		if p.AllowInsecure {
+			query.Set("allow_insecure", "1")
+		}
+		u := url.URL{Scheme: "tuic", User: url.UserPassword(password, password), Host: host, RawQuery: query.Encode(), Fragment: p.Name}
+		return u.String()
+	case "anytls":
+		setQuery(query, "sni", sni)
+		if p.AllowInsecure {
+			query.Set("insecure", "1")
+		}
+	default:
+		return ""
+	}
+	u := url.URL{Scheme: p.Type, User: url.User(password), Host: host, RawQuery: query.Encode(), Fragment: p.Name}
+	return u.String()
+}
+
+// shadowsocksPassword returns the password of a shadowsocks proxy, the 2022 ciphers take the server key
+// and the user key joined by a colon.
+func shadowsocksPassword(p Proxy, password string) string {
+	if p.ServerKey == "" || !strings.HasPrefix(p.Method, "2022-blake3-") {
+		return password
+	}
+	keyLen := 32
+	if strings.HasSuffix(p.Method, "128-gcm") {
+		keyLen = 16
+	}
+	if len(password) > keyLen {
+		password = password[:keyLen]
+	}
+	return base64.StdEncoding.EncodeToString([]byte(p.ServerKey)) + ":" + base64.StdEncoding.EncodeToString([]byte(password))
+}
+
+func setTransport(query url.Values, p Proxy) {
+	setQuery(query, "type", p.Transport)
+	switch p.Transport {
+	case "ws", "httpupgrade", "xhttp":
+		setQuery(query, "host", p.Host)
+		setQuery(query, "path", p.Path)
+	case "grpc":
+		setQuery(query, "serviceName", p.ServiceName)
+	}
+}
+
+func setQuery(query url.Values, key, value string) {
+	if value != "" {
+		query.Set(key, value)
+	}
+}
@@ -0,0 +1,47 @@
+package adapter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRawURIs(t *testing.T) {
+	proxies := []Proxy{
+		{Name: "ss node", Server: "1.1.1.1", Port: 443, Type: "shadowsocks", Method: "aes-256-gcm"},
+		{Name: "vless", Server: "example.com", Port: 443, Type: "vless", Security: "reality", SNI: "sni.example.com", RealityPublicKey: "pbk", Transport: "grpc", ServiceName: "svc"},
+		{Name: "trojan", Server: "2001:db8::1", Port: 8443, Type: "trojan", Security: "tls", AllowInsecure: true},
+		{Name: "vmess", Server: "example.com", Port: 80, Type: "vmess", Transport: "ws", Path: "/ws"},
+		{Name: "mieru", Server: "example.com", Port: 80, Type: "mieru"},
+	}
+	lines := strings.Split(string(RawURIs(proxies, "uuid")), "\n")
+	if len(lines) != 4 {
+		t.Fatalf("expected 4 links, got %d: %v", len(lines), lines)
+	}
+
+	ss := "ss://" + base64.StdEncoding.EncodeToString([]byte("aes-256-gcm:uuid")) + "@1.1.1.1:443#ss%20node"
+	if lines[0] != ss {
+		t.Errorf("shadowsocks: expected %s, got %s", ss, lines[0])
+	}
+	for _, part := range []string{"vless://uuid@example.com:443?", "security=reality", "pbk=pbk", "sni=sni.example.com", "type=grpc", "serviceName=svc", "#vless"} {
+		if !strings.Contains(lines[1], part) {
+			t.Errorf("vless: %s misses %s", lines[1], part)
+		}
+	}
+	if !strings.HasPrefix(lines[2], "trojan://uuid@[2001:db8::1]:8443?") || !strings.Contains(lines[2], "allowInsecure=1") {
+		t.Errorf("trojan: unexpected link %s", lines[2])
+	}
+
+	data, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(lines[3], "vmess://"))
+	if err != nil {
+		t.Fatalf("vmess: decode: %v", err)
+	}
+	var vmess map[string]string
+	if err = json.Unmarshal(data, &vmess); err != nil {
+		t.Fatalf("vmess: unmarshal: %v", err)
+	}
+	if vmess["id"] != "uuid" || vmess["net"] != "ws" || vmess["path"] != "/ws" || vmess["port"] != "80" {
+		t.Errorf("vmess: unexpected config %v", vmess)
+	}
+}
@@ -0,0 +1,123 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/perfect-panel/server/pkg/logger"
+	"gopkg.in/yaml.v3"
+)
+
+// mergeExtraRules 将自定义规则合并到最终配置中，规则放在模板规则之前以保证优先匹配。
+// 仅支持 yaml (Clash) 与 json (sing-box) 输出，规则解析失败时记录警告并返回原配置。
+func mergeExtraRules(config []byte, format, rules string) []byte {
+	if strings.TrimSpace(rules) == "" {
+		return config
+	}
+	var merged []byte
+	var err error
+	switch strings.ToLower(format) {
+	case "yaml":
+		merged, err = mergeYAMLRules(config, rules)
+	case "json":
+		merged, err = mergeJSONRules(config, rules)
+	default:
+		logger.Infof("[Adapter] Extra rules are not supported for output format: %s", format)
+		return config
+	}
+	if err != nil {
+		logger.Errorf("[Adapter] Skip extra rules: %s", err.Error())
+		return config
+	}
+	return merged
+}
+
+// mergeYAMLRules 合并 Clash 规则，自定义规则格式为字符串列表，例如 `- DOMAIN-SUFFIX,example.com,DIRECT`
+func mergeYAMLRules(config []byte, rules string) ([]byte, error) {
+	var extra []string
+	if err := yaml.Unmarshal([]byte(rules), &extra); err != nil {
+		return nil, fmt.Errorf("parse yaml rules error: %w", err)
+	}
+	if len(extra) == 0 {
+		return config, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(config, &doc); err != nil {
+		return nil, fmt.Errorf("parse yaml config error: %w", err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("yaml config is not a mapping")
+	}
+	root := doc.Content[0]
+
+	var target *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "rules" {
+			target = root.Content[i+1]
+			break
+		}
+	}
+	if target == nil {
+		target = &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "rules"}, target)
+	}
+	if target.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("yaml config rules is not a list")
+	}
+
+	nodes := make([]*yaml.Node, 0, len(extra)+len(target.Content))
+	for _, rule := range extra {
+		nodes = append(nodes, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: rule})
+	}
+	target.Content = append(nodes, target.Content...)
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mergeJSONRules 合并 sing-box 路由规则，自定义规则格式为规则对象数组，例如 `[{"domain_suffix":["example.com"],"outbound":"direct"}]`
+func mergeJSONRules(config []byte, rules string) ([]byte, error) {
+	var extra []map[string]interface{}
+	if err := json.Unmarshal([]byte(rules), &extra); err != nil {
+		return nil, fmt.Errorf("parse json rules error: %w", err)
+	}
+	if len(extra) == 0 {
+		return config, nil
+	}
+
+	var doc map[string]interface{}
+	if err := json.Unmarshal(config, &doc); err != nil {
+		return nil, fmt.Errorf("parse json config error: %w", err)
+	}
+	route, ok := doc["route"].(map[string]interface{})
+	if !ok {
+		if doc["route"] != nil {
+			return nil, fmt.Errorf("json config route is not an object")
+		}
+		route = map[string]interface{}{}
+	}
+	existing, ok := route["rules"].([]interface{})
+	if !ok && route["rules"] != nil {
+		return nil, fmt.Errorf("json config route rules is not an array")
+	}
+
+	merged := make([]interface{}, 0, len(extra)+len(existing))
+	for _, rule := range extra {
+		merged = append(merged, rule)
+	}
+	route["rules"] = append(merged, existing...)
+	doc["route"] = route
+
+	return json.MarshalIndent(doc, "", "  ")
+}
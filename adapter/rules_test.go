@@ -0,0 +1,46 @@
+package adapter
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+)
+
+func TestMergeExtraRules_YAML(t *testing.T) {
+	config := []byte("proxies: []\nrules:\n  - MATCH,PROXY\n")
+	merged := mergeExtraRules(config, "yaml", "- DOMAIN-SUFFIX,example.com,DIRECT\n")
+
+	var v struct {
+		Rules []string `yaml:"rules"`
+	}
+	if err := yaml.Unmarshal(merged, &v); err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, []string{"DOMAIN-SUFFIX,example.com,DIRECT", "MATCH,PROXY"}, v.Rules)
+}
+
+func TestMergeExtraRules_JSON(t *testing.T) {
+	config := []byte(`{"outbounds":[],"route":{"rules":[{"protocol":"dns","outbound":"dns-out"}]}}`)
+	merged := mergeExtraRules(config, "json", `[{"domain_suffix":["example.com"],"outbound":"direct"}]`)
+
+	var v struct {
+		Route struct {
+			Rules []map[string]interface{} `json:"rules"`
+		} `json:"route"`
+	}
+	if err := json.Unmarshal(merged, &v); err != nil {
+		t.Fatal(err)
+	}
+	assert.Len(t, v.Route.Rules, 2)
+	assert.Equal(t, "direct", v.Route.Rules[0]["outbound"])
+	assert.Equal(t, "dns-out", v.Route.Rules[1]["outbound"])
+}
+
+func TestMergeExtraRules_Invalid(t *testing.T) {
+	config := []byte("rules:\n  - MATCH,PROXY\n")
+	assert.Equal(t, config, mergeExtraRules(config, "yaml", "{invalid"))
+	assert.Equal(t, config, mergeExtraRules(config, "json", "- DOMAIN,example.com,DIRECT"))
+	assert.Equal(t, config, mergeExtraRules(config, "base64", "- DOMAIN,example.com,DIRECT"))
+}
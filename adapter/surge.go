@@ -0,0 +1,31 @@
+package adapter
+
+import (
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// OutputFormatSurge is the output format of Surge clients. The config is a conf file that Surge
+// manages itself, it is refreshed from the URL on its #!MANAGED-CONFIG line.
+const OutputFormatSurge = "surge"
+
+// SurgeManagedConfigInterval is how often Surge refreshes a managed config, in seconds
+const SurgeManagedConfigInterval = 86400
+
+// SurgeTemplate is a sample client template for the surge output format
+//
+//go:embed templates/surge.conf
+var SurgeTemplate string
+
+const surgeManagedConfigPrefix = "#!MANAGED-CONFIG"
+
+// withSurgeManagedConfig puts the managed config line on top of a Surge config, templates that
+// already write one keep their own.
+func withSurgeManagedConfig(config, subscribeURL string) string {
+	if strings.HasPrefix(strings.TrimLeft(config, " \t\r\n"), surgeManagedConfigPrefix) {
+		return config
+	}
+	line := fmt.Sprintf("%s %s interval=%d strict=false", surgeManagedConfigPrefix, subscribeURL, SurgeManagedConfigInterval)
+	return line + "\n\n" + strings.TrimLeft(config, "\r\n")
+}
@@ -0,0 +1,60 @@
+package adapter
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSurgeConfig(t *testing.T) {
+	server := &node.Server{Id: 1}
+	if err := server.MarshalProtocols([]node.Protocol{
+		{Type: "shadowsocks", Port: 443, Cipher: "aes-256-gcm"},
+		{Type: "trojan", Port: 8443, SNI: "trojan.example.com"},
+	}); err != nil {
+		t.Fatalf("marshal protocols: %v", err)
+	}
+	servers := []*node.Node{
+		{Id: 1, Name: "HK-SS", Address: "hk.example.com", Port: 443, Protocol: "shadowsocks", ServerId: 1, Server: server},
+		{Id: 2, Name: "JP-Trojan", Address: "jp.example.com", Port: 8443, Protocol: "trojan", ServerId: 1, Server: server},
+	}
+	subscribeURL := "https://example.com/api/subscribe?token=abc"
+	c, err := NewAdapter(SurgeTemplate,
+		WithServers(servers),
+		WithSiteName("PerfectPanel"),
+		WithOutputFormat(OutputFormatSurge),
+		WithUserInfo(User{Password: "uuid-1", SubscribeURL: subscribeURL}),
+	).Client()
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	output, err := c.Build()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	config := string(output)
+
+	// Surge refreshes the config from the first line
+	firstLine := strings.SplitN(config, "\n", 2)[0]
+	assert.Equal(t, "#!MANAGED-CONFIG "+subscribeURL+" interval=86400 strict=false", firstLine)
+	assert.Contains(t, config, "HK-SS = ss, hk.example.com, 443, encrypt-method=aes-256-gcm, password=uuid-1")
+	assert.Contains(t, config, "JP-Trojan = trojan, jp.example.com, 8443, password=uuid-1, sni=trojan.example.com")
+	assert.Contains(t, config, "PerfectPanel = select, Auto, HK-SS, JP-Trojan")
+	assert.NoError(t, ValidateTemplate(SurgeTemplate, OutputFormatSurge))
+}
+
+func TestSurgeManagedConfigKept(t *testing.T) {
+	c := &Client{
+		ClientTemplate: "#!MANAGED-CONFIG {{ .UserInfo.SubscribeURL }} interval=3600\n\n[Proxy]\n",
+		OutputFormat:   OutputFormatSurge,
+		UserInfo:       User{SubscribeURL: "https://example.com/sub"},
+	}
+	output, err := c.Build()
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	assert.Equal(t, 1, strings.Count(string(output), "#!MANAGED-CONFIG"))
+	assert.True(t, strings.HasPrefix(string(output), "#!MANAGED-CONFIG https://example.com/sub interval=3600"))
+}
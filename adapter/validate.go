@@ -0,0 +1,67 @@
+package adapter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidateTemplate renders the client template with sample data and checks that the output
+// parses under the given output format. The returned error points at the offending line.
+func ValidateTemplate(tpl, outputFormat string) error {
+	format := strings.ToLower(outputFormat)
+	c := &Client{
+		SiteName:       "PerfectPanel",
+		SubscribeName:  "Test Subscribe",
+		ClientTemplate: tpl,
+		// the rendered output is checked before any encoding
+		OutputFormat: format,
+		Proxies: []Proxy{
+			{Sort: 1, Name: "Test Node", Server: "example.com", Port: 443, Type: "vless", Tags: []string{"test"}, Security: "tls", SNI: "example.com", Transport: "tcp"},
+		},
+		UserInfo: User{
+			Password:     "test-password",
+			ExpiredAt:    time.Now().AddDate(1, 0, 0),
+			Traffic:      1000,
+			DeviceLimit:  3,
+			SubscribeURL: "https://example.com/subscribe",
+		},
+		Params: map[string]string{},
+	}
+	if format == "base64" {
+		c.OutputFormat = ""
+	}
+	output, err := c.Build()
+	if err != nil {
+		// text/template errors already carry the template line, e.g. "template: client:12: ..."
+		return fmt.Errorf("render template error: %w", err)
+	}
+
+	switch format {
+	case "yaml":
+		var v interface{}
+		if err = yaml.Unmarshal(output, &v); err != nil {
+			return fmt.Errorf("rendered output is not valid yaml: %w", err)
+		}
+	case "json":
+		var v interface{}
+		if err = json.Unmarshal(output, &v); err != nil {
+			var syntaxErr *json.SyntaxError
+			if errors.As(err, &syntaxErr) {
+				line := bytes.Count(output[:syntaxErr.Offset], []byte("\n")) + 1
+				return fmt.Errorf("rendered output is not valid json: line %d: %w", line, err)
+			}
+			return fmt.Errorf("rendered output is not valid json: %w", err)
+		}
+	case OutputFormatSurge:
+		if !bytes.Contains(output, []byte("[Proxy]")) {
+			return errors.New("rendered output has no [Proxy] section")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package adapter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateTemplate(t *testing.T) {
+	yamlTpl := "proxies:\n{{- range .Proxies }}\n  - name: {{ .Name }}\n    server: {{ .Server }}\n{{- end }}\n"
+	assert.NoError(t, ValidateTemplate(yamlTpl, "yaml"))
+
+	jsonTpl := `{"outbounds":[{{- range $i, $p := .Proxies }}{{ if $i }},{{ end }}{"tag":"{{ $p.Name }}"}{{- end }}]}`
+	assert.NoError(t, ValidateTemplate(jsonTpl, "json"))
+
+	assert.NoError(t, ValidateTemplate("{{ range .Proxies }}{{ .Name }}\n{{ end }}", "base64"))
+}
+
+func TestValidateTemplateErrors(t *testing.T) {
+	// template syntax error on the second line
+	err := ValidateTemplate("proxies:\n{{ range .Proxies }\n", "yaml")
+	assert.ErrorContains(t, err, "client:2")
+
+	err = ValidateTemplate("proxies:\n  - a\n b: [\n", "yaml")
+	assert.ErrorContains(t, err, "line")
+
+	err = ValidateTemplate("{\n\"a\": 1,\n\"b\": }\n", "json")
+	assert.ErrorContains(t, err, "line 3")
+}
@@ -19,16 +19,22 @@ func Currency(ctx *svc.ServiceContext) {
 	}
 	// Parse currency configuration
 	configs := struct {
-		CurrencyUnit   string
-		CurrencySymbol string
-		AccessKey      string
+		CurrencyUnit      string
+		CurrencySymbol    string
+		AccessKey         string
+		RoundingIncrement int64
+		MinRecharge       int64
+		MaxRecharge       int64
 	}{}
 	tool.SystemConfigSliceReflectToStruct(currency, &configs)
 	ctx.ExchangeRate = 0 // Default exchange rate to 0
 	ctx.Config.Currency = config.Currency{
-		Unit:      configs.CurrencyUnit,
-		Symbol:    configs.CurrencySymbol,
-		AccessKey: configs.AccessKey,
+		Unit:              configs.CurrencyUnit,
+		Symbol:            configs.CurrencySymbol,
+		AccessKey:         configs.AccessKey,
+		RoundingIncrement: configs.RoundingIncrement,
+		MinRecharge:       configs.MinRecharge,
+		MaxRecharge:       configs.MaxRecharge,
 	}
 	logger.Infof("[INIT] Currency configuration: %v", ctx.Config.Currency)
 }
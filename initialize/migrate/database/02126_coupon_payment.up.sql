@@ -0,0 +1,4 @@
+ALTER TABLE `coupon`
+    ADD COLUMN `payment` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Payment Limit'
+  AFTER `subscribe`;
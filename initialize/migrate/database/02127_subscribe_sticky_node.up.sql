@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `sticky_node` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Sticky node: 0 all nodes, 1 one stable node per tag group' AFTER `node_tags`;
@@ -0,0 +1,2 @@
+ALTER TABLE `payment`
+DROP COLUMN `discount_percent`;
@@ -0,0 +1,4 @@
+ALTER TABLE `payment`
+    ADD COLUMN `discount_percent` INT NOT NULL DEFAULT 0
+  COMMENT 'Payment Discount Percentage'
+  AFTER `fee_amount`;
@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `extra_rules` TEXT NULL COMMENT 'Extra routing rules merged into the subscription config' AFTER `sticky_node`;
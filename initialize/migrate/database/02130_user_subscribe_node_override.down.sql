@@ -0,0 +1,3 @@
+ALTER TABLE `user_subscribe`
+DROP COLUMN `exclude_nodes`,
+DROP COLUMN `include_nodes`;
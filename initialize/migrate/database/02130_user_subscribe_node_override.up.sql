@@ -0,0 +1,3 @@
+ALTER TABLE `user_subscribe`
+    ADD COLUMN `exclude_nodes` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Excluded Node Ids' AFTER `status`,
+    ADD COLUMN `include_nodes` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Force Included Node Ids' AFTER `exclude_nodes`;
@@ -0,0 +1,3 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'MaxGiftDeductionPercent', '100', 'int', 'Max Gift Deduction Percent', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
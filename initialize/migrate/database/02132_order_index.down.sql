@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+    DROP INDEX `idx_user_id`,
+    DROP INDEX `idx_created_at`,
+    DROP INDEX `idx_status_created_at`;
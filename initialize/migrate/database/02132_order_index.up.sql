@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+    ADD INDEX `idx_user_id` (`user_id`),
+    ADD INDEX `idx_created_at` (`created_at`),
+    ADD INDEX `idx_status_created_at` (`status`, `created_at`);
@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+    ADD COLUMN `payment_discount` INT NOT NULL DEFAULT 0
+  COMMENT 'Payment Method Discount Amount'
+  AFTER `coupon_discount`;
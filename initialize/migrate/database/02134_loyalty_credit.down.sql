@@ -0,0 +1,5 @@
+DELETE FROM `system` WHERE `category` = 'subscribe' AND `key` IN ('LoyaltyCreditPercent', 'MaxLoyaltyCreditPercent');
+ALTER TABLE `order`
+DROP COLUMN `loyalty_credit`;
+ALTER TABLE `user`
+DROP COLUMN `loyalty_credit`;
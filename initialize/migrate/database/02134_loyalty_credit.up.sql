@@ -0,0 +1,12 @@
+ALTER TABLE `user`
+    ADD COLUMN `loyalty_credit` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'User Loyalty Credit'
+  AFTER `gift_amount`;
+ALTER TABLE `order`
+    ADD COLUMN `loyalty_credit` INT NOT NULL DEFAULT 0
+  COMMENT 'Loyalty Credit Deduction'
+  AFTER `gift_amount`;
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'LoyaltyCreditPercent', '0', 'int', 'Loyalty Credit Percent', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'MaxLoyaltyCreditPercent', '100', 'int', 'Max Loyalty Credit Percent', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `max_renewal_stack` INT NOT NULL DEFAULT 0
+  COMMENT 'Max Renewal Periods Banked: 0: Unlimited'
+  AFTER `renewal_reset`;
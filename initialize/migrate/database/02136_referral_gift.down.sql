@@ -0,0 +1 @@
+DELETE FROM `system` WHERE `category` = 'invite' AND `key` = 'ReferralGiftPercentage';
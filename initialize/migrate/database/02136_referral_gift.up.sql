@@ -0,0 +1,3 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('invite', 'ReferralGiftPercentage', '0', 'int', 'Referral Gift Percentage', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
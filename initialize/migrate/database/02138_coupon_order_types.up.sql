@@ -0,0 +1,4 @@
+ALTER TABLE `coupon`
+    ADD COLUMN `order_types` TINYINT NOT NULL DEFAULT 3
+  COMMENT 'Applicable Order Types: 1: Purchase 2: Renewal 3: Both'
+  AFTER `payment`;
@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe_application`
+    ADD COLUMN `supported_protocols` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Supported Protocols, empty means all'
+  AFTER `output_format`;
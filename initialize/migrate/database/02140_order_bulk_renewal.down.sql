@@ -0,0 +1,3 @@
+ALTER TABLE `order`
+    DROP INDEX `idx_bulk_order_no`,
+    DROP COLUMN `bulk_order_no`;
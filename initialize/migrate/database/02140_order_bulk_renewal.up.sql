@@ -0,0 +1,5 @@
+ALTER TABLE `order`
+    ADD COLUMN `bulk_order_no` VARCHAR(255) DEFAULT NULL
+  COMMENT 'Bulk Renewal Order No'
+  AFTER `is_new`,
+    ADD INDEX `idx_bulk_order_no` (`bulk_order_no`);
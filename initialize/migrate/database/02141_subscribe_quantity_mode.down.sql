@@ -0,0 +1,3 @@
+ALTER TABLE `subscribe`
+DROP COLUMN `duration`,
+DROP COLUMN `quantity_mode`;
@@ -0,0 +1,7 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `quantity_mode` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Quantity Mode: 0: Duration, 1: Count'
+  AFTER `max_renewal_stack`,
+    ADD COLUMN `duration` INT NOT NULL DEFAULT 1
+  COMMENT 'Unit Time Periods per Order in Count Mode'
+  AFTER `quantity_mode`;
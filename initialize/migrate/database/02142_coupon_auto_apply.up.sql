@@ -0,0 +1,4 @@
+ALTER TABLE `coupon`
+    ADD COLUMN `auto_apply` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Offered as best coupon without its code'
+  AFTER `used_count`;
@@ -0,0 +1,3 @@
+ALTER TABLE `order`
+    DROP COLUMN `plan_discount`,
+    DROP COLUMN `unit_price`;
@@ -0,0 +1,7 @@
+ALTER TABLE `order`
+    ADD COLUMN `unit_price` INT NOT NULL DEFAULT 0
+  COMMENT 'Plan Unit Price Snapshot'
+  AFTER `price`,
+    ADD COLUMN `plan_discount` TEXT DEFAULT NULL
+  COMMENT 'Plan Discount Snapshot'
+  AFTER `unit_price`;
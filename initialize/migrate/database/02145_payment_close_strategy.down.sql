@@ -0,0 +1,3 @@
+ALTER TABLE `payment`
+    DROP COLUMN `hold_minutes`,
+    DROP COLUMN `close_strategy`;
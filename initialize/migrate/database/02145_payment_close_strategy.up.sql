@@ -0,0 +1,7 @@
+ALTER TABLE `payment`
+    ADD COLUMN `close_strategy` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Unpaid Order Close Strategy: 0: Cancel 1: Hold'
+  AFTER `discount_percent`,
+    ADD COLUMN `hold_minutes` INT NOT NULL DEFAULT 0
+  COMMENT 'Unpaid Order Hold Minutes'
+  AFTER `close_strategy`;
@@ -0,0 +1,2 @@
+ALTER TABLE `coupon`
+DROP COLUMN `users`;
@@ -0,0 +1,4 @@
+ALTER TABLE `coupon`
+    ADD COLUMN `users` TEXT DEFAULT NULL
+  COMMENT 'User Limit, empty for everyone'
+  AFTER `payment`;
@@ -0,0 +1,4 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'Maintenance', 'false', 'bool', 'Maintenance Mode', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'MaintenanceNotice', 'Under Maintenance', 'string', 'Maintenance Notice Node Name', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+    ADD COLUMN `metadata` TEXT DEFAULT NULL
+  COMMENT 'Informational Metadata'
+  AFTER `bulk_order_no`;
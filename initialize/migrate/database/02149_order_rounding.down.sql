@@ -0,0 +1,3 @@
+DELETE FROM `system` WHERE `category` = 'currency' AND `key` = 'RoundingIncrement';
+ALTER TABLE `order`
+DROP COLUMN `rounding_adjustment`;
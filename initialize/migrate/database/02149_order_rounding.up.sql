@@ -0,0 +1,6 @@
+ALTER TABLE `order`
+    ADD COLUMN `rounding_adjustment` INT NOT NULL DEFAULT 0
+  COMMENT 'Rounding Adjustment'
+  AFTER `fee_amount`;
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES ('currency', 'RoundingIncrement', '0', 'int', 'Order Total Rounding Increment', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
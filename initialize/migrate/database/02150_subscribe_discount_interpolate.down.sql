@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe`
+DROP COLUMN `discount_interpolate`;
@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `discount_interpolate` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Interpolate Discount Between Tiers'
+  AFTER `discount`;
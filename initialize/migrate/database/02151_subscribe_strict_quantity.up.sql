@@ -0,0 +1,3 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'StrictQuantity', 'false', 'bool', 'Reject Order Quantity Below One', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,6 @@
+DELETE FROM `system` WHERE `category` = 'subscribe' AND `key` = 'PromoCredit';
+ALTER TABLE `order`
+DROP COLUMN `promo_credit`;
+ALTER TABLE `user`
+DROP COLUMN `promo_credit_expired_at`,
+DROP COLUMN `promo_credit`;
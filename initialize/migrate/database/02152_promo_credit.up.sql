@@ -0,0 +1,14 @@
+ALTER TABLE `user`
+    ADD COLUMN `promo_credit` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'User Promo Credit'
+  AFTER `loyalty_credit`,
+    ADD COLUMN `promo_credit_expired_at` DATETIME(3) DEFAULT NULL
+  COMMENT 'Promo Credit Expire Time'
+  AFTER `promo_credit`;
+ALTER TABLE `order`
+    ADD COLUMN `promo_credit` INT NOT NULL DEFAULT 0
+  COMMENT 'Promo Credit Part of the Gift Amount'
+  AFTER `loyalty_credit`;
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'PromoCredit', 'false', 'bool', 'Spend Promo Credit Before Gift Amount', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `subscribe_template` TEXT NULL COMMENT 'Subscribe template used instead of the client template' AFTER `extra_rules`;
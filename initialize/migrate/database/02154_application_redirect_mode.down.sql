@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe_application`
+DROP COLUMN `redirect_mode`;
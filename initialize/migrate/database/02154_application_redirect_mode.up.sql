@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe_application`
+    ADD COLUMN `redirect_mode` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Redirect to the uploaded config'
+  AFTER `supported_protocols`;
@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe`
+DROP COLUMN `datacenter_policy`;
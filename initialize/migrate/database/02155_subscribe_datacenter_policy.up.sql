@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `datacenter_policy` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Datacenter Fetch Policy: 0: Allow, 1: Flag, 2: Block'
+  AFTER `sticky_node`;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `order_payment_attempt` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Primary Key',
+    `order_no` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Order No',
+    `payment_id` BIGINT NOT NULL DEFAULT 0 COMMENT 'Payment Method Id',
+    `method` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Payment Method',
+    `amount` INT NOT NULL DEFAULT 0 COMMENT 'Attempt Amount',
+    `fee_amount` INT NOT NULL DEFAULT 0 COMMENT 'Fee Amount',
+    `rounding_adjustment` INT NOT NULL DEFAULT 0 COMMENT 'Rounding Adjustment',
+    `status` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Attempt Status: 1: Pending, 2: Paid, 3: Cancelled, 4: Refunded, 5: Duplicate',
+    `created_at` DATETIME(3) NOT NULL COMMENT 'Create Time',
+    `updated_at` DATETIME(3) NOT NULL COMMENT 'Update Time',
+    PRIMARY KEY (`id`),
+    KEY `idx_order_no` (`order_no`)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
@@ -0,0 +1,4 @@
+DELETE FROM `system` WHERE `category` = 'currency' AND `key` IN ('MinRecharge', 'MaxRecharge');
+ALTER TABLE `payment`
+DROP COLUMN `max_recharge`,
+DROP COLUMN `min_recharge`;
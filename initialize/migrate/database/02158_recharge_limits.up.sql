@@ -0,0 +1,11 @@
+ALTER TABLE `payment`
+    ADD COLUMN `min_recharge` INT NOT NULL DEFAULT 0
+  COMMENT 'Minimum Recharge Amount'
+  AFTER `hold_minutes`,
+    ADD COLUMN `max_recharge` INT NOT NULL DEFAULT 0
+  COMMENT 'Maximum Recharge Amount'
+  AFTER `min_recharge`;
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('currency', 'MinRecharge', '0', 'int', 'Minimum Recharge Amount', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('currency', 'MaxRecharge', '0', 'int', 'Maximum Recharge Amount', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
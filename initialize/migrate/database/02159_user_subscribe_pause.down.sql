@@ -0,0 +1,5 @@
+DELETE FROM `system` WHERE `category` = 'subscribe' AND `key` IN ('MaxPauses', 'MaxPauseDays');
+ALTER TABLE `user_subscribe`
+DROP COLUMN `pause_count`,
+DROP COLUMN `paused_for`,
+DROP COLUMN `paused_at`;
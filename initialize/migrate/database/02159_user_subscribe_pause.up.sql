@@ -0,0 +1,14 @@
+ALTER TABLE `user_subscribe`
+    ADD COLUMN `paused_at` DATETIME(3) DEFAULT NULL
+  COMMENT 'Paused Time'
+  AFTER `note`,
+    ADD COLUMN `paused_for` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Remaining Seconds Kept While Paused'
+  AFTER `paused_at`,
+    ADD COLUMN `pause_count` INT NOT NULL DEFAULT 0
+  COMMENT 'Pause Count'
+  AFTER `paused_for`;
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'MaxPauses', '0', 'int', 'Pauses Allowed Per Subscription', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'MaxPauseDays', '30', 'int', 'Longest Pause Before Automatic Resume', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,4 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'BuildConcurrency', '0', 'int', 'Concurrent Subscribe Config Builds', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'BuildQueueWait', '500', 'int', 'Subscribe Build Queue Wait In Milliseconds', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,3 @@
+ALTER TABLE `user_subscribe`
+DROP COLUMN `bonus_expire`,
+DROP COLUMN `bonus_nodes`;
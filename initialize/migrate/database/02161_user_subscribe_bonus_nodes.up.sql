@@ -0,0 +1,7 @@
+ALTER TABLE `user_subscribe`
+    ADD COLUMN `bonus_nodes` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Bonus Node Ids'
+  AFTER `pause_count`,
+    ADD COLUMN `bonus_expire` DATETIME(3) DEFAULT NULL
+  COMMENT 'Bonus Nodes Expire Time'
+  AFTER `bonus_nodes`;
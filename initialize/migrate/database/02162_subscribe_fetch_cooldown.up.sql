@@ -0,0 +1,4 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'FetchCooldown', 'false', 'bool', 'Serve Repeated Fetches From The Last Config', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'FetchCooldownInterval', '60', 'int', 'Subscribe Fetch Cooldown In Seconds', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+DROP COLUMN `bundle_credit`,
+DROP COLUMN `bundle_id`;
+DROP TABLE IF EXISTS `subscribe_bundle`;
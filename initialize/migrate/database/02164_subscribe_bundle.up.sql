@@ -0,0 +1,21 @@
+CREATE TABLE IF NOT EXISTS `subscribe_bundle` (
+    `id` BIGINT NOT NULL AUTO_INCREMENT COMMENT 'Primary Key',
+    `name` VARCHAR(255) NOT NULL DEFAULT '' COMMENT 'Bundle Name',
+    `description` TEXT COMMENT 'Bundle Description',
+    `subscribe_id` BIGINT NOT NULL DEFAULT 0 COMMENT 'Subscribe Id',
+    `quantity` BIGINT NOT NULL DEFAULT 1 COMMENT 'Subscribe Quantity',
+    `price` INT NOT NULL DEFAULT 0 COMMENT 'Bundle Price',
+    `credit` INT NOT NULL DEFAULT 0 COMMENT 'Gift Amount Credited on Payment',
+    `sell` TINYINT(1) NOT NULL DEFAULT 0 COMMENT 'Sell',
+    `sort` INT NOT NULL DEFAULT 0 COMMENT 'Sort',
+    `created_at` DATETIME(3) NOT NULL COMMENT 'Create Time',
+    `updated_at` DATETIME(3) NOT NULL COMMENT 'Update Time',
+    PRIMARY KEY (`id`)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
+ALTER TABLE `order`
+    ADD COLUMN `bundle_id` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Subscribe Bundle Id'
+  AFTER `bulk_order_no`,
+    ADD COLUMN `bundle_credit` INT NOT NULL DEFAULT 0
+  COMMENT 'Bundle Gift Amount Credit'
+  AFTER `bundle_id`;
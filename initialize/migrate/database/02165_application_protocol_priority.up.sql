@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe_application`
+    ADD COLUMN `protocol_priority` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Protocols Listed First, empty keeps the node order'
+  AFTER `supported_protocols`;
@@ -0,0 +1,4 @@
+ALTER TABLE `user_subscribe`
+    ADD COLUMN `alias` VARCHAR(64) NOT NULL DEFAULT ''
+  COMMENT 'User Set Subscription Name'
+  AFTER `note`;
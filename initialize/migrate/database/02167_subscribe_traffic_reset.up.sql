@@ -0,0 +1,4 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `traffic_reset` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Allow Paid Traffic Reset'
+  AFTER `replacement`;
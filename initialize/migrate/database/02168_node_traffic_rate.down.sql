@@ -0,0 +1,2 @@
+ALTER TABLE `nodes`
+DROP COLUMN `traffic_rate`;
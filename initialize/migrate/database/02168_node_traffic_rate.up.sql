@@ -0,0 +1,4 @@
+ALTER TABLE `nodes`
+    ADD COLUMN `traffic_rate` DECIMAL(6,2) NOT NULL DEFAULT 1.00
+  COMMENT 'Traffic Rate'
+  AFTER `protocol`;
@@ -0,0 +1,4 @@
+-- Revert the Surge client to the conf output format
+UPDATE `subscribe_application`
+SET `output_format` = 'conf'
+WHERE `user_agent` = 'Surge' AND `output_format` = 'surge';
@@ -0,0 +1,4 @@
+-- Serve the Surge client with the surge output format, its config is a managed conf file
+UPDATE `subscribe_application`
+SET `output_format` = 'surge'
+WHERE `user_agent` = 'Surge' AND `output_format` = 'conf';
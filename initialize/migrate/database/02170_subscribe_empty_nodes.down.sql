@@ -0,0 +1 @@
+DELETE FROM `system` WHERE `category` = 'subscribe' AND `key` IN ('EmptyNodes', 'EmptyNodesNotice');
@@ -0,0 +1,4 @@
+INSERT IGNORE INTO `system` (`category`, `key`, `value`, `type`, `desc`, `created_at`, `updated_at`)
+VALUES
+    ('subscribe', 'EmptyNodes', 'placeholder', 'string', 'Answer To A Subscription Without Nodes', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637'),
+    ('subscribe', 'EmptyNodesNotice', 'No Nodes Configured', 'string', 'Notice Node Name Of A Subscription Without Nodes', '2025-04-22 14:25:16.637', '2025-04-22 14:25:16.637');
@@ -0,0 +1,4 @@
+ALTER TABLE `payment`
+DROP COLUMN `available_hours`,
+DROP COLUMN `max_amount`,
+DROP COLUMN `min_amount`;
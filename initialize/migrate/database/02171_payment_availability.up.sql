@@ -0,0 +1,10 @@
+ALTER TABLE `payment`
+    ADD COLUMN `min_amount` INT NOT NULL DEFAULT 0
+  COMMENT 'Minimum Order Amount'
+  AFTER `max_recharge`,
+    ADD COLUMN `max_amount` INT NOT NULL DEFAULT 0
+  COMMENT 'Maximum Order Amount'
+  AFTER `min_amount`,
+    ADD COLUMN `available_hours` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Enabled Time Windows'
+  AFTER `max_amount`;
@@ -0,0 +1,3 @@
+ALTER TABLE `subscribe`
+DROP COLUMN `default_quantity`,
+DROP COLUMN `min_quantity`;
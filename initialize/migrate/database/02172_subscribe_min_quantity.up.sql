@@ -0,0 +1,7 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `min_quantity` INT NOT NULL DEFAULT 0
+  COMMENT 'Minimum Order Quantity: 0: No Minimum'
+  AFTER `duration`,
+    ADD COLUMN `default_quantity` INT NOT NULL DEFAULT 0
+  COMMENT 'Order Quantity When None Given: 0: Minimum'
+  AFTER `min_quantity`;
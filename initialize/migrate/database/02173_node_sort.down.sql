@@ -0,0 +1,2 @@
+ALTER TABLE `subscribe` DROP COLUMN `node_sort`;
+ALTER TABLE `nodes` DROP COLUMN `weight`;
@@ -0,0 +1,8 @@
+ALTER TABLE `nodes`
+    ADD COLUMN `weight` INT NOT NULL DEFAULT 0
+  COMMENT 'Subscription Sort Weight'
+  AFTER `traffic_rate`;
+ALTER TABLE `subscribe`
+    ADD COLUMN `node_sort` VARCHAR(16) NOT NULL DEFAULT ''
+  COMMENT 'Node Order In Configs: name, tag, weight or empty for the node sort'
+  AFTER `datacenter_policy`;
@@ -0,0 +1,2 @@
+ALTER TABLE `coupon` MODIFY COLUMN `type` TINYINT(1) NOT NULL DEFAULT 1 COMMENT 'Coupon Type: 1: Percentage 2: Fixed Amount';
+ALTER TABLE `order` DROP COLUMN `bonus_days`;
@@ -0,0 +1,7 @@
+ALTER TABLE `order`
+    ADD COLUMN `bonus_days` INT NOT NULL DEFAULT 0
+  COMMENT 'Coupon Bonus Days'
+  AFTER `coupon_discount`;
+ALTER TABLE `coupon`
+    MODIFY COLUMN `type` TINYINT(1) NOT NULL DEFAULT 1
+  COMMENT 'Coupon Type: 1: Percentage 2: Fixed Amount 3: Bonus Days';
@@ -0,0 +1 @@
+ALTER TABLE `coupon` DROP COLUMN `tiers`;
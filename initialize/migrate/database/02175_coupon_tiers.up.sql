@@ -0,0 +1,4 @@
+ALTER TABLE `coupon`
+    ADD COLUMN `tiers` TEXT NULL
+  COMMENT 'Quantity Discount Tiers'
+  AFTER `discount`;
@@ -0,0 +1,3 @@
+ALTER TABLE `subscribe`
+DROP COLUMN `top_up_price`,
+DROP COLUMN `traffic_top_up`;
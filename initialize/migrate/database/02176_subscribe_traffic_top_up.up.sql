@@ -0,0 +1,7 @@
+ALTER TABLE `subscribe`
+    ADD COLUMN `traffic_top_up` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Allow Traffic Top-Up'
+  AFTER `traffic_reset`,
+    ADD COLUMN `top_up_price` INT NOT NULL DEFAULT 0
+  COMMENT 'Traffic Top-Up Price per GB'
+  AFTER `traffic_top_up`;
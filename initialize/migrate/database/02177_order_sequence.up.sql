@@ -0,0 +1,5 @@
+CREATE TABLE IF NOT EXISTS `order_sequence` (
+    `day` VARCHAR(8) NOT NULL COMMENT 'Day, YYYYMMDD',
+    `value` BIGINT NOT NULL DEFAULT 0 COMMENT 'Last Sequence Of The Day',
+    PRIMARY KEY (`day`)
+    ) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4;
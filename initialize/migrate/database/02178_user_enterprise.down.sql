@@ -0,0 +1,4 @@
+ALTER TABLE `user`
+DROP COLUMN `outstanding_balance`,
+DROP COLUMN `credit_limit`,
+DROP COLUMN `enterprise`;
@@ -0,0 +1,10 @@
+ALTER TABLE `user`
+    ADD COLUMN `enterprise` TINYINT(1) NOT NULL DEFAULT 0
+  COMMENT 'Enterprise Account, May Pay By Invoice'
+  AFTER `promo_credit_expired_at`,
+    ADD COLUMN `credit_limit` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Enterprise Credit Limit'
+  AFTER `enterprise`,
+    ADD COLUMN `outstanding_balance` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Enterprise Invoiced Amount Not Yet Paid'
+  AFTER `credit_limit`;
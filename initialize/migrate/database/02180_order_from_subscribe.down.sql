@@ -0,0 +1 @@
+ALTER TABLE `order` DROP COLUMN `from_subscribe_id`;
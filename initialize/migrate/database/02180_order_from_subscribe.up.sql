@@ -0,0 +1,4 @@
+ALTER TABLE `order`
+    ADD COLUMN `from_subscribe_id` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Plan Switched From By The Renewal'
+  AFTER `subscribe_id`;
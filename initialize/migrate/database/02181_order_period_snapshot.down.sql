@@ -0,0 +1,3 @@
+ALTER TABLE `order`
+DROP COLUMN `periods`,
+DROP COLUMN `unit_time`;
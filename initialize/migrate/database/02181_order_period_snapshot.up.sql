@@ -0,0 +1,7 @@
+ALTER TABLE `order`
+    ADD COLUMN `unit_time` VARCHAR(255) NOT NULL DEFAULT ''
+  COMMENT 'Plan Unit Time Snapshot'
+  AFTER `plan_discount`,
+    ADD COLUMN `periods` BIGINT NOT NULL DEFAULT 0
+  COMMENT 'Unit Time Periods Added Snapshot'
+  AFTER `unit_time`;
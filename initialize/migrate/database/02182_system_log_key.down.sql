@@ -0,0 +1,3 @@
+ALTER TABLE `system_logs`
+DROP INDEX `idx_type_object_key`,
+DROP COLUMN `log_key`;
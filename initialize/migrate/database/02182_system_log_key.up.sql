@@ -0,0 +1,5 @@
+ALTER TABLE `system_logs`
+    ADD COLUMN `log_key` VARCHAR(255) DEFAULT NULL
+  COMMENT 'Unique Key of Logs Written Once'
+  AFTER `content`,
+    ADD UNIQUE INDEX `idx_type_object_key` (`type`, `object_id`, `log_key`);
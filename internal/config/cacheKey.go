@@ -12,6 +12,12 @@ const SiteConfigKey = "system:site_config"
 // SubscribeConfigKey Subscribe Config Key
 const SubscribeConfigKey = "system:subscribe_config"
 
+// SubscribeRedirectKey caches the uploaded config URL of redirect mode clients
+const SubscribeRedirectKey = "subscribe:redirect"
+
+// SubscribeRedirectVersionKey is bumped when nodes, plans or clients change, so cached uploads are rebuilt
+const SubscribeRedirectVersionKey = "subscribe:redirect:version"
+
 // RegisterConfigKey Register Config Key
 const RegisterConfigKey = "system:register_config"
 
@@ -59,3 +65,21 @@ const SendIntervalKeyPrefix = "send:interval:"
 
 // SendCountLimitKeyPrefix Send Count Limit Key Prefix eg. send:limit:register:email:xxx@ppanel.dev
 const SendCountLimitKeyPrefix = "send:limit:"
+
+// SubscribeGeoWindowKeyPrefix Subscribe Geo Window Key Prefix, countries a token was fetched from
+const SubscribeGeoWindowKeyPrefix = "subscribe:geo:window:"
+
+// SubscribeGeoAlertKeyPrefix Subscribe Geo Alert Key Prefix, suppresses duplicate anomaly alerts
+const SubscribeGeoAlertKeyPrefix = "subscribe:geo:alert:"
+
+// SubscribeLowStockKeyPrefix Subscribe Low Stock Key Prefix, suppresses repeated low stock webhooks until restocked
+const SubscribeLowStockKeyPrefix = "subscribe:low_stock:"
+
+// SubscribeDemoKeyPrefix Subscribe Demo Key Prefix, the unused demo links
+const SubscribeDemoKeyPrefix = "subscribe:demo:"
+
+// SubscribeCooldownKey caches the last built config of a token during the fetch cooldown
+const SubscribeCooldownKey = "subscribe:cooldown"
+
+// OrderCloseDeadlineKeyPrefix Order Close Deadline Key Prefix, the extended payment window of a resent order
+const OrderCloseDeadlineKeyPrefix = "order:close:deadline:"
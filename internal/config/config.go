@@ -2,6 +2,7 @@ package config
 
 import (
 	"encoding/json"
+	"os"
 
 	"github.com/perfect-panel/server/pkg/logger"
 	"github.com/perfect-panel/server/pkg/orm"
@@ -30,6 +31,18 @@ type Config struct {
 	Telegram      Telegram        `yaml:"Telegram"`
 	Log           Log             `yaml:"Log"`
 	Currency      Currency        `yaml:"Currency"`
+	Queue         QueueConfig     `yaml:"Queue"`
+	GeoIP         GeoIPConfig     `yaml:"GeoIP"`
+	ExpiredNode   ExpiredNode     `yaml:"ExpiredNode"`
+	Inventory     InventoryConfig `yaml:"Inventory"`
+	Sandbox       SandboxConfig   `yaml:"Sandbox"`
+	ObjectStore   ObjectStore     `yaml:"ObjectStore"`
+	Refund        RefundConfig    `yaml:"Refund"`
+	CloseNotify   CloseNotify     `yaml:"CloseNotify"`
+	SignedURL     SignedURL       `yaml:"SignedURL"`
+	Demo          DemoConfig      `yaml:"Demo"`
+	PaymentCheck  PaymentCheck    `yaml:"PaymentCheck"`
+	TradeNo       TradeNo         `yaml:"TradeNo"`
 	Administrator struct {
 		Email    string `yaml:"Email" default:"admin@ppanel.dev"`
 		Password string `yaml:"Password" default:"password"`
@@ -56,12 +69,168 @@ type Verify struct {
 }
 
 type SubscribeConfig struct {
-	SingleModel     bool   `yaml:"SingleModel" default:"false"`
-	SubscribePath   string `yaml:"SubscribePath" default:"/v1/subscribe/config"`
-	SubscribeDomain string `yaml:"SubscribeDomain" default:""`
-	PanDomain       bool   `yaml:"PanDomain" default:"false"`
-	UserAgentLimit  bool   `yaml:"UserAgentLimit" default:"false"`
-	UserAgentList   string `yaml:"UserAgentList" default:""`
+	SingleModel             bool   `yaml:"SingleModel" default:"false"`
+	MaxSubscriptions        int64  `yaml:"MaxSubscriptions" default:"0"` // active subscriptions per user when SingleModel is off, 0 means unlimited
+	SubscribePath           string `yaml:"SubscribePath" default:"/v1/subscribe/config"`
+	SubscribeDomain         string `yaml:"SubscribeDomain" default:""`
+	PanDomain               bool   `yaml:"PanDomain" default:"false"`
+	DedupNodes              bool   `yaml:"DedupNodes" default:"false"`  // serve one node per address, port and protocol
+	Maintenance             bool   `yaml:"Maintenance" default:"false"` // serve only the maintenance notice node to every subscription
+	MaintenanceNotice       string `yaml:"MaintenanceNotice" default:"Under Maintenance"`
+	StrictQuantity          bool   `yaml:"StrictQuantity" default:"false"`        // reject an order quantity below 1 instead of ordering 1
+	NewOrderWindow          int64  `yaml:"NewOrderWindow" default:"0"`            // days without a paid order after which a user orders as new again, 0 counts all time
+	DiscountStacking        string `yaml:"DiscountStacking" default:"sequential"` // how the plan discount and the coupon combine: sequential, best or original
+	MaxPauses               int64  `yaml:"MaxPauses" default:"0"`                 // pauses allowed per subscription, 0 disables pausing
+	MaxPauseDays            int64  `yaml:"MaxPauseDays" default:"30"`             // a longer pause is resumed automatically, 0 never resumes
+	BuildConcurrency        int64  `yaml:"BuildConcurrency" default:"0"`          // config builds resolving nodes at once, 0 means unlimited
+	BuildQueueWait          int64  `yaml:"BuildQueueWait" default:"500"`          // milliseconds a build waits for a free slot before it is rejected
+	FetchCooldown           bool   `yaml:"FetchCooldown" default:"false"`         // serve repeated fetches of a token from the last config
+	FetchCooldownInterval   int64  `yaml:"FetchCooldownInterval" default:"60"`    // seconds, far below the refresh interval of regular clients
+	UserAgentLimit          bool   `yaml:"UserAgentLimit" default:"false"`
+	UserAgentList           string `yaml:"UserAgentList" default:""`
+	MaxGiftDeductionPercent int64  `yaml:"MaxGiftDeductionPercent" default:"100"`
+	InstantZeroAmount       bool   `yaml:"InstantZeroAmount" default:"true"`      // pay an order the deductions covered completely on creation, no checkout needed
+	PromoCredit             bool   `yaml:"PromoCredit" default:"false"`           // spend expiring promo credit before the gift amount
+	LoyaltyCreditPercent    int64  `yaml:"LoyaltyCreditPercent" default:"0"`      // credit granted per paid purchase/renewal, 0 disables
+	MaxLoyaltyCreditPercent int64  `yaml:"MaxLoyaltyCreditPercent" default:"100"` // share of a renewal that loyalty credit may cover
+	EmptyNodes              string `yaml:"EmptyNodes" default:"placeholder"`      // what a subscription without nodes is served: placeholder, error or empty
+	EmptyNodesNotice        string `yaml:"EmptyNodesNotice" default:"No Nodes Configured"`
+	StatusCheck             string `yaml:"StatusCheck" default:"lenient"` // how a token of a subscription that is not active resolves: lenient or strict
+}
+
+// Answers to a subscription that matches no nodes, see SubscribeConfig.EmptyNodes
+const (
+	EmptyNodesPlaceholder = "placeholder" // a notice node named after EmptyNodesNotice
+	EmptyNodesError       = "error"       // the fetch fails with SubscribeNoNodes
+	EmptyNodesEmpty       = "empty"       // a config without proxies
+)
+
+// Handling of subscriptions that are not active when their token is resolved, see SubscribeConfig.StatusCheck
+const (
+	StatusCheckLenient = "lenient" // the token resolves, the fetch gets the placeholder nodes of an expired or paused subscription
+	StatusCheckStrict  = "strict"  // the fetch fails with SubscribeSuspended for any status past active
+)
+
+// Stacking rules of the plan discount and the coupon, see SubscribeConfig.DiscountStacking
+const (
+	DiscountStackingSequential = "sequential" // the coupon applies to the price after the plan discount
+	DiscountStackingBestOf     = "best"       // only the larger of the plan discount and the coupon applies
+	DiscountStackingOriginal   = "original"   // both apply to the list price
+)
+
+type QueueConfig struct {
+	CloseOrderMaxRetry      int   `yaml:"CloseOrderMaxRetry" default:"3"`
+	CloseOrderRetryDelay    int64 `yaml:"CloseOrderRetryDelay" default:"10"`     // first retry delay in seconds, doubled on every retry
+	CloseOrderMaxRetryDelay int64 `yaml:"CloseOrderMaxRetryDelay" default:"600"` // upper bound of the retry delay in seconds
+	HoldOrderMinutes        int64 `yaml:"HoldOrderMinutes" default:"1440"`       // hold of unpaid orders of hold strategy payment methods without their own
+	SubscribeLogBatchSize   int   `yaml:"SubscribeLogBatchSize" default:"0"`     // buffered subscribe log rows per insert, 0 writes every log synchronously
+	SubscribeLogFlushDelay  int64 `yaml:"SubscribeLogFlushDelay" default:"5"`    // seconds a buffered subscribe log waits at most before it is written
+	ResendExtendMinutes     int64 `yaml:"ResendExtendMinutes" default:"15"`      // payment window of an order after its payment link is resent, 0 never extends it
+	ResendExtendLimit       int64 `yaml:"ResendExtendLimit" default:"3"`         // times the payment window of an order can be extended
+}
+
+// ObjectStore is the bucket configs of redirect mode clients are uploaded to, see objectstore.Config.
+type ObjectStore struct {
+	Endpoint      string `yaml:"Endpoint" default:""` // empty disables redirect mode
+	PublicURL     string `yaml:"PublicURL" default:""`
+	Authorization string `yaml:"Authorization" default:""`
+	CacheTTL      int64  `yaml:"CacheTTL" default:"600"` // seconds an uploaded config is redirected to before it is rebuilt
+}
+
+// ExpiredNode is the placeholder node served in place of the real nodes once a subscription has expired.
+type ExpiredNode struct {
+	Name     string `yaml:"Name" default:"Subscribe Expired"`
+	Protocol string `yaml:"Protocol" default:"shadowsocks"`
+	Cipher   string `yaml:"Cipher" default:"aes-256-gcm"`
+	Address  string `yaml:"Address" default:"127.0.0.1"`
+	Port     int    `yaml:"Port" default:"18080"`
+	HideHost bool   `yaml:"HideHost" default:"false"` // drop the second placeholder named after the panel host
+}
+
+type GeoIPConfig struct {
+	ASNDatabase      string   `yaml:"ASNDatabase" default:""`       // optional GeoLite2-ASN database path
+	DatacenterList   string   `yaml:"DatacenterList" default:""`    // optional file of datacenter CIDRs and AS numbers, plans may flag or block fetches from them
+	AnomalyCountries int64    `yaml:"AnomalyCountries" default:"0"` // alert when a token is fetched from more distinct countries than this, 0 disables
+	AnomalyWindow    int64    `yaml:"AnomalyWindow" default:"3600"` // sliding window in seconds
+	AllowedCountries []string `yaml:"AllowedCountries"`             // ISO codes of the only countries subscriptions can be fetched from, empty allows all
+	BlockedCountries []string `yaml:"BlockedCountries"`             // ISO codes of countries subscription fetches are refused from
+}
+
+// TradeNo how the numbers of new orders are issued
+type TradeNo struct {
+	Generator string `yaml:"Generator" default:"random"` // random or daily, see TradeNoRandom and TradeNoDaily
+	Prefix    string `yaml:"Prefix" default:""`          // leads daily numbers, e.g. the document type of the merchant's ERP
+}
+
+// Order number generators, see TradeNo.Generator
+const (
+	TradeNoRandom = "random" // the time down to the nanosecond and four random digits
+	TradeNoDaily  = "daily"  // the prefix, the date, the six digit sequence of the day and a Luhn check digit
+)
+
+// SandboxConfig developer only switches, never enable them on a production deployment
+type SandboxConfig struct {
+	Payment bool `yaml:"Payment" default:"false"` // allow the "test" payment platform that marks orders paid at checkout
+}
+
+// SandboxEnvironment the PPANEL_MODE value a sandbox switch additionally requires
+const SandboxEnvironment = "dev"
+
+// PaymentSandboxEnabled reports whether the "test" payment platform may be used. Besides Sandbox.Payment
+// it requires a non prod Model and PPANEL_MODE=dev, so a copied config alone cannot turn it on.
+func (c Config) PaymentSandboxEnabled() bool {
+	return c.Sandbox.Payment && c.Model != "prod" && os.Getenv("PPANEL_MODE") == SandboxEnvironment
+}
+
+type InventoryConfig struct {
+	SyncSecret        string `yaml:"SyncSecret" default:""`         // secret of the external inventory sync endpoint, empty disables it
+	LowStockThreshold int64  `yaml:"LowStockThreshold" default:"0"` // notify when a plan's inventory drops below this, 0 disables
+	LowStockWebhook   string `yaml:"LowStockWebhook" default:""`    // URL receiving the low stock notification
+}
+
+// RefundConfig goodwill coupon issued to the user of a refunded order
+type RefundConfig struct {
+	GoodwillCoupon         bool  `yaml:"GoodwillCoupon" default:"false"`
+	GoodwillCouponType     uint8 `yaml:"GoodwillCouponType" default:"2"` // 1: percentage 2: fixed amount
+	GoodwillCouponDiscount int64 `yaml:"GoodwillCouponDiscount" default:"0"`
+	GoodwillCouponDays     int64 `yaml:"GoodwillCouponDays" default:"30"` // days the coupon stays valid, 0 never expires
+}
+
+// CloseNotify is the notice the user gets when an unpaid order is closed, no channel disables it.
+// The templates are text/template strings, the re-purchase URL is one too and may use {{.SubscribeId}}.
+type CloseNotify struct {
+	Channels         []string `yaml:"Channels"` // email, telegram
+	EmailSubject     string   `yaml:"EmailSubject" default:"Order Closed"`
+	EmailTemplate    string   `yaml:"EmailTemplate" default:""`    // empty uses email.DefaultOrderClosedEmailTemplate
+	TelegramTemplate string   `yaml:"TelegramTemplate" default:""` // empty uses telegram.OrderClosedNotify
+	RepurchaseURL    string   `yaml:"RepurchaseURL" default:""`    // e.g. https://example.com/purchase?id={{.SubscribeId}}, empty leaves the link out
+}
+
+// SignedURL makes subscription URLs carry an expiry and an HMAC signature of the token, a fetch without
+// a valid signature is rejected. The update URL embedded in client configs gets the longer UpdateTTL so
+// clients keep refreshing on their own.
+type SignedURL struct {
+	Enable    bool   `yaml:"Enable" default:"false"`
+	Secret    string `yaml:"Secret" default:""`           // empty signs with the JWT access secret
+	TTL       int64  `yaml:"TTL" default:"604800"`        // seconds a link handed out to the user stays valid
+	UpdateTTL int64  `yaml:"UpdateTTL" default:"2592000"` // seconds the embedded update URL stays valid
+}
+
+// DemoConfig single-fetch preview links for prospects without an account. The config is built for the nodes
+// below with the uuid of the demo subscription, which has to be active on them for the config to connect.
+type DemoConfig struct {
+	UserSubscribeId int64   `yaml:"UserSubscribeId" default:"0"` // demo subscription, 0 disables demo links
+	Nodes           []int64 `yaml:"Nodes"`                       // node ids a demo config contains
+	TokenTTL        int64   `yaml:"TokenTTL" default:"3600"`     // seconds an unused demo link stays valid
+	Duration        int64   `yaml:"Duration" default:"86400"`    // seconds to the expiry the demo config reports
+}
+
+// PaymentCheck compares the amount a gateway reports paid with the amount charged for the order, in the gateway
+// currency. A difference within the tolerance counts as paid in full.
+type PaymentCheck struct {
+	Tolerance    int64  `yaml:"Tolerance" default:"1"`        // cents of the gateway currency
+	Underpayment string `yaml:"Underpayment" default:"hold"`  // hold: the order waits in the underpaid status for an admin, accept: it is paid anyway
+	Overpayment  string `yaml:"Overpayment" default:"ignore"` // ignore: keep the excess, gift: credit it to the user's gift amount
 }
 
 type RegisterConfig struct {
@@ -201,9 +370,10 @@ type File struct {
 }
 
 type InviteConfig struct {
-	ForcedInvite       bool  `yaml:"ForcedInvite" default:"false"`
-	ReferralPercentage int64 `yaml:"ReferralPercentage" default:"0"`
-	OnlyFirstPurchase  bool  `yaml:"OnlyFirstPurchase" default:"false"`
+	ForcedInvite           bool  `yaml:"ForcedInvite" default:"false"`
+	ReferralPercentage     int64 `yaml:"ReferralPercentage" default:"0"`
+	OnlyFirstPurchase      bool  `yaml:"OnlyFirstPurchase" default:"false"`
+	ReferralGiftPercentage int64 `yaml:"ReferralGiftPercentage" default:"0"` // gift amount credited to the referrer on a referred user's first paid order, 0 disables
 }
 
 type Telegram struct {
@@ -228,8 +398,9 @@ type VerifyCode struct {
 }
 
 type Log struct {
-	AutoClear bool  `yaml:"AutoClear" default:"true"`
-	ClearDays int64 `yaml:"ClearDays" default:"7"`
+	AutoClear bool   `yaml:"AutoClear" default:"true"`
+	ClearDays int64  `yaml:"ClearDays" default:"7"`
+	Timezone  string `yaml:"Timezone" default:"UTC"` // timezone of the date that system logs are keyed by, e.g. Asia/Shanghai
 }
 
 type NodeDBConfig struct {
@@ -247,4 +418,9 @@ type Currency struct {
 	Unit      string `yaml:"Unit" default:"CNY"`
 	Symbol    string `yaml:"Symbol" default:"USD"`
 	AccessKey string `yaml:"AccessKey" default:""`
+	// RoundingIncrement rounds the payable order total after the fee to a multiple of it, 0 or 1 disables it
+	RoundingIncrement int64 `yaml:"RoundingIncrement" default:"0"`
+	// MinRecharge and MaxRecharge bound a balance recharge, 0 leaves the bound open
+	MinRecharge int64 `yaml:"MinRecharge" default:"0"`
+	MaxRecharge int64 `yaml:"MaxRecharge" default:"0"`
 }
@@ -0,0 +1,26 @@
+package application
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/application"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Match subscribe application by user agent
+func MatchSubscribeApplicationHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.MatchSubscribeApplicationRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := application.NewMatchSubscribeApplicationLogic(c.Request.Context(), svcCtx)
+		resp, err := l.MatchSubscribeApplication(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,26 @@
+package log
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Filter admin audit log
+func FilterAdminAuditLogHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.FilterAdminAuditLogRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := log.NewFilterAdminAuditLogLogic(c.Request.Context(), svcCtx)
+		resp, err := l.FilterAdminAuditLog(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,26 @@
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Create an order paid through the sandbox payment method
+func CreateSandboxOrderHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.CreateSandboxOrderRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := order.NewCreateSandboxOrderLogic(c.Request.Context(), svcCtx)
+		resp, err := l.CreateSandboxOrder(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,26 @@
+package order
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Mark the invoice of an enterprise order paid
+func MarkInvoicePaidHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.MarkInvoicePaidRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := order.NewMarkInvoicePaidLogic(c.Request.Context(), svcCtx)
+		resp, err := l.MarkInvoicePaid(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
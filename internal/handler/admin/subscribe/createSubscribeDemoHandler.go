@@ -0,0 +1,18 @@
+package subscribe
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Create a single-fetch demo subscribe link
+func CreateSubscribeDemoHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := subscribe.NewCreateSubscribeDemoLogic(c.Request.Context(), svcCtx)
+		resp, err := l.CreateSubscribeDemo(c.Request.Host)
+		result.HttpResult(c, resp, err)
+	}
+}
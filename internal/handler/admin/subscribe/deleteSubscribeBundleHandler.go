@@ -0,0 +1,26 @@
+package subscribe
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Delete subscribe bundle
+func DeleteSubscribeBundleHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.DeleteSubscribeBundleRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := subscribe.NewDeleteSubscribeBundleLogic(c.Request.Context(), svcCtx)
+		err := l.DeleteSubscribeBundle(&req)
+		result.HttpResult(c, nil, err)
+	}
+}
@@ -0,0 +1,18 @@
+package subscribe
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get subscribe bundle list
+func GetSubscribeBundleListHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := subscribe.NewGetSubscribeBundleListLogic(c.Request.Context(), svcCtx)
+		resp, err := l.GetSubscribeBundleList()
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,18 @@
+package system
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/system"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get subscribe maintenance mode
+func GetMaintenanceConfigHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := system.NewGetMaintenanceConfigLogic(c.Request.Context(), svcCtx)
+		resp, err := l.GetMaintenanceConfig()
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,26 @@
+package system
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/system"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Toggle subscribe maintenance mode
+func UpdateMaintenanceConfigHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.MaintenanceConfig
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := system.NewUpdateMaintenanceConfigLogic(c.Request.Context(), svcCtx)
+		err := l.UpdateMaintenanceConfig(&req)
+		result.HttpResult(c, nil, err)
+	}
+}
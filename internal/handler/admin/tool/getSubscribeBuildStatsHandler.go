@@ -0,0 +1,18 @@
+package tool
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/admin/tool"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get Subscribe Build Stats
+func GetSubscribeBuildStatsHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := tool.NewGetSubscribeBuildStatsLogic(c.Request.Context(), svcCtx)
+		resp, err := l.GetSubscribeBuildStats()
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,26 @@
+package inventory
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/inventory"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Sync subscribe inventory from an external system
+func SyncSubscribeInventoryHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.SyncSubscribeInventoryRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := inventory.NewSyncSubscribeInventoryLogic(c.Request.Context(), svcCtx)
+		resp, err := l.SyncSubscribeInventory(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -4,15 +4,23 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/perfect-panel/server/internal/logic/public/payment"
 	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/result"
 )
 
 // Get available payment methods
 func GetAvailablePaymentMethodsHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
 	return func(c *gin.Context) {
+		var req types.GetAvailablePaymentMethodsRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
 
 		l := payment.NewGetAvailablePaymentMethodsLogic(c.Request.Context(), svcCtx)
-		resp, err := l.GetAvailablePaymentMethods()
+		resp, err := l.GetAvailablePaymentMethods(&req)
 		result.HttpResult(c, resp, err)
 	}
 }
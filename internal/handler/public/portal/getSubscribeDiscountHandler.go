@@ -0,0 +1,26 @@
+package portal
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get subscribe discount tiers
+func GetSubscribeDiscountHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.GetSubscribeDiscountRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := portal.NewGetSubscribeDiscountLogic(c.Request.Context(), svcCtx)
+		resp, err := l.GetSubscribeDiscount(&req)
+		result.HttpResult(c, resp, err)
+	}
+}
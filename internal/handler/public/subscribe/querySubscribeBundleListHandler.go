@@ -0,0 +1,18 @@
+package subscribe
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/public/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get subscribe bundle list
+func QuerySubscribeBundleListHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := subscribe.NewQuerySubscribeBundleListLogic(c.Request.Context(), svcCtx)
+		resp, err := l.QuerySubscribeBundleList()
+		result.HttpResult(c, resp, err)
+	}
+}
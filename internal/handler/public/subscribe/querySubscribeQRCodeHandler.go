@@ -0,0 +1,42 @@
+package subscribe
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/public/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get user subscribe QR code
+func QuerySubscribeQRCodeHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.QuerySubscribeQRCodeRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := subscribe.NewQuerySubscribeQRCodeLogic(c.Request.Context(), svcCtx)
+		png, err := l.QuerySubscribeQRCode(&req, c.Request.Host)
+		if err != nil {
+			result.HttpResult(c, nil, err)
+			return
+		}
+		// the image embeds the token, keep it out of shared caches and revalidate so a reset token shows up at once
+		etag := fmt.Sprintf(`"%x"`, sha256.Sum256(png))
+		c.Header("Cache-Control", "private, no-cache")
+		c.Header("ETag", etag)
+		if c.GetHeader("If-None-Match") == etag {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		c.Data(http.StatusOK, "image/png", png)
+	}
+}
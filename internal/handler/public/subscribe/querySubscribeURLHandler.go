@@ -0,0 +1,26 @@
+package subscribe
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/public/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Get user subscribe URL
+func QuerySubscribeURLHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.QuerySubscribeURLRequest
+		_ = c.ShouldBind(&req)
+		validateErr := svcCtx.Validate(&req)
+		if validateErr != nil {
+			result.ParamErrorResult(c, validateErr)
+			return
+		}
+
+		l := subscribe.NewQuerySubscribeURLLogic(c.Request.Context(), svcCtx)
+		resp, err := l.QuerySubscribeURL(&req, c.Request.Host)
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -0,0 +1,18 @@
+package user
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/logic/public/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/result"
+)
+
+// Query User Spending Summary
+func QueryUserSpendingSummaryHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+
+		l := user.NewQueryUserSpendingSummaryLogic(c.Request.Context(), svcCtx)
+		resp, err := l.QueryUserSpendingSummary()
+		result.HttpResult(c, resp, err)
+	}
+}
@@ -25,6 +25,7 @@ import (
 	auth "github.com/perfect-panel/server/internal/handler/auth"
 	authOauth "github.com/perfect-panel/server/internal/handler/auth/oauth"
 	common "github.com/perfect-panel/server/internal/handler/common"
+	inventory "github.com/perfect-panel/server/internal/handler/inventory"
 	publicAnnouncement "github.com/perfect-panel/server/internal/handler/public/announcement"
 	publicDocument "github.com/perfect-panel/server/internal/handler/public/document"
 	publicOrder "github.com/perfect-panel/server/internal/handler/public/order"
@@ -86,6 +87,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Create subscribe application
 		adminApplicationGroupRouter.POST("/", adminApplication.CreateSubscribeApplicationHandler(serverCtx))
 
+		// Match subscribe application by user agent
+		adminApplicationGroupRouter.GET("/match", adminApplication.MatchSubscribeApplicationHandler(serverCtx))
+
 		// Preview Template
 		adminApplicationGroupRouter.GET("/preview", adminApplication.PreviewSubscribeTemplateHandler(serverCtx))
 
@@ -97,6 +101,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 
 		// Get subscribe application list
 		adminApplicationGroupRouter.GET("/subscribe_application_list", adminApplication.GetSubscribeApplicationListHandler(serverCtx))
+
+		// Update subscribe application template
+		adminApplicationGroupRouter.PUT("/template", adminApplication.UpdateSubscribeApplicationTemplateHandler(serverCtx))
 	}
 
 	adminAuthMethodGroupRouter := router.Group("/v1/admin/auth-method")
@@ -189,6 +196,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 	adminLogGroupRouter.Use(middleware.AuthMiddleware(serverCtx))
 
 	{
+		// Filter admin audit log
+		adminLogGroupRouter.GET("/audit/list", adminLog.FilterAdminAuditLogHandler(serverCtx))
+
 		// Filter balance log
 		adminLogGroupRouter.GET("/balance/list", adminLog.FilterBalanceLogHandler(serverCtx))
 
@@ -271,9 +281,33 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Create order
 		adminOrderGroupRouter.POST("/", adminOrder.CreateOrderHandler(serverCtx))
 
+		// Close all pending orders of a user
+		adminOrderGroupRouter.POST("/close_pending", adminOrder.ClosePendingOrdersHandler(serverCtx))
+
+		// Mark the invoice of an enterprise order paid
+		adminOrderGroupRouter.POST("/invoice/paid", adminOrder.MarkInvoicePaidHandler(serverCtx))
+
 		// Get order list
 		adminOrderGroupRouter.GET("/list", adminOrder.GetOrderListHandler(serverCtx))
 
+		// Refund bundle order
+		adminOrderGroupRouter.POST("/refund/bundle", adminOrder.RefundBundleOrderHandler(serverCtx))
+
+		// Refund renewal order
+		adminOrderGroupRouter.POST("/refund/renewal", adminOrder.RefundRenewalOrderHandler(serverCtx))
+
+		// Create an order paid through the sandbox payment method
+		adminOrderGroupRouter.POST("/sandbox", adminOrder.CreateSandboxOrderHandler(serverCtx))
+
+		// Cancel a sandbox order
+		adminOrderGroupRouter.POST("/sandbox/cancel", adminOrder.CancelSandboxOrderHandler(serverCtx))
+
+		// Pay a sandbox order
+		adminOrderGroupRouter.POST("/sandbox/pay", adminOrder.PaySandboxOrderHandler(serverCtx))
+
+		// Search order list with filters and sums
+		adminOrderGroupRouter.GET("/search", adminOrder.ListOrdersHandler(serverCtx))
+
 		// Update order status
 		adminOrderGroupRouter.PUT("/status", adminOrder.UpdateOrderStatusHandler(serverCtx))
 	}
@@ -358,6 +392,21 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Batch delete subscribe
 		adminSubscribeGroupRouter.DELETE("/batch", adminSubscribe.BatchDeleteSubscribeHandler(serverCtx))
 
+		// Create subscribe bundle
+		adminSubscribeGroupRouter.POST("/bundle", adminSubscribe.CreateSubscribeBundleHandler(serverCtx))
+
+		// Update subscribe bundle
+		adminSubscribeGroupRouter.PUT("/bundle", adminSubscribe.UpdateSubscribeBundleHandler(serverCtx))
+
+		// Delete subscribe bundle
+		adminSubscribeGroupRouter.DELETE("/bundle", adminSubscribe.DeleteSubscribeBundleHandler(serverCtx))
+
+		// Get subscribe bundle list
+		adminSubscribeGroupRouter.GET("/bundle/list", adminSubscribe.GetSubscribeBundleListHandler(serverCtx))
+
+		// Create a single-fetch demo subscribe link
+		adminSubscribeGroupRouter.POST("/demo", adminSubscribe.CreateSubscribeDemoHandler(serverCtx))
+
 		// Get subscribe details
 		adminSubscribeGroupRouter.GET("/details", adminSubscribe.GetSubscribeDetailsHandler(serverCtx))
 
@@ -405,6 +454,12 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Update invite config
 		adminSystemGroupRouter.PUT("/invite_config", adminSystem.UpdateInviteConfigHandler(serverCtx))
 
+		// Get subscribe maintenance mode
+		adminSystemGroupRouter.GET("/maintenance_config", adminSystem.GetMaintenanceConfigHandler(serverCtx))
+
+		// Toggle subscribe maintenance mode
+		adminSystemGroupRouter.PUT("/maintenance_config", adminSystem.UpdateMaintenanceConfigHandler(serverCtx))
+
 		// Get Module Config
 		adminSystemGroupRouter.GET("/module", adminSystem.GetModuleConfigHandler(serverCtx))
 
@@ -496,6 +551,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Restart System
 		adminToolGroupRouter.GET("/restart", adminTool.RestartSystemHandler(serverCtx))
 
+		// Get Subscribe Build Stats
+		adminToolGroupRouter.GET("/subscribe/build", adminTool.GetSubscribeBuildStatsHandler(serverCtx))
+
 		// Get Version
 		adminToolGroupRouter.GET("/version", adminTool.GetVersionHandler(serverCtx))
 	}
@@ -543,6 +601,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// kick offline user device
 		adminUserGroupRouter.PUT("/device/kick_offline", adminUser.KickOfflineByUserDeviceHandler(serverCtx))
 
+		// Update user enterprise account
+		adminUserGroupRouter.PUT("/enterprise", adminUser.UpdateUserEnterpriseHandler(serverCtx))
+
 		// Get user list
 		adminUserGroupRouter.GET("/list", adminUser.GetUserListHandler(serverCtx))
 
@@ -552,6 +613,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Update user notify setting
 		adminUserGroupRouter.PUT("/notify", adminUser.UpdateUserNotifySettingHandler(serverCtx))
 
+		// Get user spending summary
+		adminUserGroupRouter.GET("/spending", adminUser.GetUserSpendingSummaryHandler(serverCtx))
+
 		// Get user subcribe
 		adminUserGroupRouter.GET("/subscribe", adminUser.GetUserSubscribeHandler(serverCtx))
 
@@ -564,6 +628,12 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Delete user subcribe
 		adminUserGroupRouter.DELETE("/subscribe", adminUser.DeleteUserSubscribeHandler(serverCtx))
 
+		// Grant user subcribe bonus nodes
+		adminUserGroupRouter.POST("/subscribe/bonus", adminUser.GrantUserSubscribeBonusHandler(serverCtx))
+
+		// Extend the expiry of matching user subscribes
+		adminUserGroupRouter.POST("/subscribe/compensate", adminUser.CompensateUserSubscribeHandler(serverCtx))
+
 		// Get user subcribe by id
 		adminUserGroupRouter.GET("/subscribe/detail", adminUser.GetUserSubscribeByIdHandler(serverCtx))
 
@@ -573,6 +643,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Get user subcribe logs
 		adminUserGroupRouter.GET("/subscribe/logs", adminUser.GetUserSubscribeLogsHandler(serverCtx))
 
+		// Update user subcribe node overrides
+		adminUserGroupRouter.PUT("/subscribe/nodes", adminUser.UpdateUserSubscribeNodesHandler(serverCtx))
+
 		// Get user subcribe reset traffic logs
 		adminUserGroupRouter.GET("/subscribe/reset/logs", adminUser.GetUserSubscribeResetTrafficLogsHandler(serverCtx))
 
@@ -587,6 +660,9 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 
 		// Get user subcribe traffic logs
 		adminUserGroupRouter.GET("/subscribe/traffic_logs", adminUser.GetUserSubscribeTrafficLogsHandler(serverCtx))
+
+		// Transfer user subscribe to another user
+		adminUserGroupRouter.POST("/subscribe/transfer", adminUser.TransferSubscriptionHandler(serverCtx))
 	}
 
 	authGroupRouter := router.Group("/v1/auth")
@@ -669,6 +745,14 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		commonGroupRouter.GET("/site/tos", common.GetTosHandler(serverCtx))
 	}
 
+	inventoryGroupRouter := router.Group("/v1/inventory")
+	inventoryGroupRouter.Use(middleware.InventoryMiddleware(serverCtx))
+
+	{
+		// Sync subscribe inventory from an external system
+		inventoryGroupRouter.POST("/sync", inventory.SyncSubscribeInventoryHandler(serverCtx))
+	}
+
 	publicAnnouncementGroupRouter := router.Group("/v1/public/announcement")
 	publicAnnouncementGroupRouter.Use(middleware.AuthMiddleware(serverCtx), middleware.DeviceMiddleware(serverCtx))
 
@@ -695,6 +779,12 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Close order
 		publicOrderGroupRouter.POST("/close", publicOrder.CloseOrderHandler(serverCtx))
 
+		// Query coupon usage
+		publicOrderGroupRouter.GET("/coupon", publicOrder.QueryCouponUsageHandler(serverCtx))
+
+		// Query the best auto-apply coupon
+		publicOrderGroupRouter.GET("/coupon/best", publicOrder.QueryBestCouponHandler(serverCtx))
+
 		// Get order
 		publicOrderGroupRouter.GET("/detail", publicOrder.QueryOrderDetailHandler(serverCtx))
 
@@ -710,11 +800,23 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Recharge
 		publicOrderGroupRouter.POST("/recharge", publicOrder.RechargeHandler(serverCtx))
 
+		// Query recharge amount limits
+		publicOrderGroupRouter.GET("/recharge/limit", publicOrder.QueryRechargeLimitHandler(serverCtx))
+
 		// Renewal Subscription
 		publicOrderGroupRouter.POST("/renewal", publicOrder.RenewalHandler(serverCtx))
 
+		// Bulk renewal of several subscriptions
+		publicOrderGroupRouter.POST("/renewal/bulk", publicOrder.BulkRenewalHandler(serverCtx))
+
+		// Resend the payment link of a pending order
+		publicOrderGroupRouter.POST("/resend", publicOrder.ResendPaymentHandler(serverCtx))
+
 		// Reset traffic
 		publicOrderGroupRouter.POST("/reset", publicOrder.ResetTrafficHandler(serverCtx))
+
+		// Top up the traffic of a subscription
+		publicOrderGroupRouter.POST("/traffic", publicOrder.TrafficTopUpHandler(serverCtx))
 	}
 
 	publicPaymentGroupRouter := router.Group("/v1/public/payment")
@@ -746,17 +848,29 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 
 		// Get Subscription
 		publicPortalGroupRouter.GET("/subscribe", publicPortal.GetSubscriptionHandler(serverCtx))
+
+		// Get subscribe discount tiers
+		publicPortalGroupRouter.GET("/subscribe/discount", publicPortal.GetSubscribeDiscountHandler(serverCtx))
 	}
 
 	publicSubscribeGroupRouter := router.Group("/v1/public/subscribe")
 	publicSubscribeGroupRouter.Use(middleware.AuthMiddleware(serverCtx), middleware.DeviceMiddleware(serverCtx))
 
 	{
+		// Get subscribe bundle list
+		publicSubscribeGroupRouter.GET("/bundle/list", publicSubscribe.QuerySubscribeBundleListHandler(serverCtx))
+
 		// Get subscribe list
 		publicSubscribeGroupRouter.GET("/list", publicSubscribe.QuerySubscribeListHandler(serverCtx))
 
 		// Get user subscribe node info
 		publicSubscribeGroupRouter.GET("/node/list", publicSubscribe.QueryUserSubscribeNodeListHandler(serverCtx))
+
+		// Get user subscribe QR code
+		publicSubscribeGroupRouter.GET("/qrcode", publicSubscribe.QuerySubscribeQRCodeHandler(serverCtx))
+
+		// Get user subscribe URL
+		publicSubscribeGroupRouter.GET("/url", publicSubscribe.QuerySubscribeURLHandler(serverCtx))
 	}
 
 	publicTicketGroupRouter := router.Group("/v1/public/ticket")
@@ -831,12 +945,27 @@ func RegisterHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 		// Update User Password
 		publicUserGroupRouter.PUT("/password", publicUser.UpdateUserPasswordHandler(serverCtx))
 
+		// Query User Referral Earnings
+		publicUserGroupRouter.GET("/referral/earnings", publicUser.QueryUserReferralEarningsHandler(serverCtx))
+
 		// Update User Rules
 		publicUserGroupRouter.PUT("/rules", publicUser.UpdateUserRulesHandler(serverCtx))
 
+		// Query User Spending Summary
+		publicUserGroupRouter.GET("/spending", publicUser.QueryUserSpendingSummaryHandler(serverCtx))
+
 		// Query User Subscribe
 		publicUserGroupRouter.GET("/subscribe", publicUser.QueryUserSubscribeHandler(serverCtx))
 
+		// Pause User Subscribe
+		publicUserGroupRouter.PUT("/subscribe/pause", publicUser.PauseUserSubscribeHandler(serverCtx))
+
+		// Resume User Subscribe
+		publicUserGroupRouter.PUT("/subscribe/resume", publicUser.ResumeUserSubscribeHandler(serverCtx))
+
+		// Update User Subscribe Alias
+		publicUserGroupRouter.PUT("/subscribe_alias", publicUser.UpdateUserSubscribeAliasHandler(serverCtx))
+
 		// Get Subscribe Log
 		publicUserGroupRouter.GET("/subscribe_log", publicUser.GetSubscribeLogHandler(serverCtx))
 
@@ -9,7 +9,10 @@ import (
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/result"
 	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
 )
 
 func SubscribeHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
@@ -24,6 +27,8 @@ func SubscribeHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
 		req.UA = c.Request.Header.Get("User-Agent")
 		req.Flag = c.Query("flag")
 		req.Type = c.Query("type")
+		req.Format = c.Param("format")
+		req.IfNoneMatch = c.GetHeader("If-None-Match")
 		// 获取所有查询参数
 		req.Params = getQueryMap(c.Request)
 
@@ -84,20 +89,103 @@ func SubscribeHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
 		l := subscribe.NewSubscribeLogic(c, svcCtx)
 		resp, err := l.Handler(&req)
 		if err != nil {
+			var e *xerr.CodeError
+			if errors.As(errors.Cause(err), &e) {
+				switch e.GetErrCode() {
+				case xerr.SubscribeDatacenterBlocked:
+					c.String(http.StatusForbidden, "Access denied from datacenter network")
+					return
+				case xerr.SubscribeRegionBlocked:
+					c.String(http.StatusUnavailableForLegalReasons, "Access denied from your region")
+					return
+				case xerr.SubscribeSuspended:
+					c.String(http.StatusForbidden, "Subscription is not active")
+					return
+				case xerr.SubscribeFormatUnknown:
+					c.String(http.StatusNotFound, "Unknown subscribe format: %s", req.Format)
+					return
+				case xerr.SubscribeLinkExpired:
+					c.String(http.StatusGone, "Subscribe link has expired")
+					return
+				case xerr.SubscribeLinkInvalid:
+					c.String(http.StatusForbidden, "Access denied")
+					return
+				case xerr.SubscribeBuildBusy:
+					c.Header("Retry-After", "1")
+					c.String(http.StatusServiceUnavailable, "Service Unavailable")
+					return
+				}
+			}
 			c.String(http.StatusInternalServerError, "Internal Server")
 			return
 		}
 		c.Header("subscription-userinfo", resp.Header)
+		if resp.NotModified {
+			c.Status(http.StatusNotModified)
+			return
+		}
+		if resp.RedirectURL != "" {
+			c.Redirect(http.StatusFound, resp.RedirectURL)
+			return
+		}
 		c.String(200, "%s", string(resp.Config))
 	}
 }
 
+// SubscribeHealthHandler lets uptime monitors check a token, the HTTP status carries the outcome for HEAD requests
+// and the body carries the error code like every other endpoint.
+func SubscribeHealthHandler(svcCtx *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		var req types.SubscribeRequest
+		if c.Request.Header.Get("token") != "" {
+			req.Token = c.Request.Header.Get("token")
+		} else {
+			req.Token = c.Query("token")
+		}
+		req.Params = getQueryMap(c.Request)
+
+		l := subscribe.NewSubscribeLogic(c, svcCtx)
+		resp, err := l.Health(&req)
+		if err == nil {
+			c.JSON(http.StatusOK, result.Success(resp))
+			return
+		}
+		code, msg := uint32(xerr.ERROR), "Internal Server Error"
+		var e *xerr.CodeError
+		if errors.As(errors.Cause(err), &e) {
+			code, msg = e.GetErrCode(), e.GetErrMsg()
+		}
+		c.JSON(healthStatus(code), result.Error(code, msg))
+	}
+}
+
+// healthStatus maps the health check error codes to the HTTP status monitors alert on.
+func healthStatus(code uint32) int {
+	switch code {
+	case xerr.SubscribeNotAvailable:
+		return http.StatusNotFound
+	case xerr.SubscribeExpired:
+		return http.StatusGone
+	case xerr.SubscribeSuspended:
+		return http.StatusForbidden
+	case xerr.SubscribeNoNodes:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
 func RegisterSubscribeHandlers(router *gin.Engine, serverCtx *svc.ServiceContext) {
 	path := serverCtx.Config.Subscribe.SubscribePath
 	if path == "" {
 		path = "/v1/subscribe/config"
 	}
 	router.GET(path, SubscribeHandler(serverCtx))
+	// explicit client routes for fetches without a client user agent, e.g. <path>/clash
+	router.GET(strings.TrimSuffix(path, "/")+"/:format", SubscribeHandler(serverCtx))
+	healthPath := strings.TrimSuffix(path, "/") + "/health"
+	router.GET(healthPath, SubscribeHealthHandler(serverCtx))
+	router.HEAD(healthPath, SubscribeHealthHandler(serverCtx))
 }
 
 // GetQueryMap 将 http.Request 的查询参数转换为 map[string]string
@@ -44,8 +44,11 @@ func (l *CreateSubscribeApplicationLogic) CreateSubscribeApplication(req *types.
 		IsDefault:         req.IsDefault,
 		SubscribeTemplate: req.SubscribeTemplate,
 		OutputFormat:      req.OutputFormat,
+		RedirectMode:      req.RedirectMode,
 		DownloadLink:      string(linkData),
 	}
+	data.SetSupportedProtocols(req.SupportedProtocols)
+	data.SetProtocolPriority(req.ProtocolPriority)
 
 	err = l.svcCtx.ClientModel.Insert(l.ctx, data)
 	if err != nil {
@@ -56,6 +59,8 @@ func (l *CreateSubscribeApplicationLogic) CreateSubscribeApplication(req *types.
 	resp = &types.SubscribeApplication{}
 	tool.DeepCopy(resp, data)
 	resp.DownloadLink = req.DownloadLink
+	resp.SupportedProtocols = data.GetSupportedProtocols()
+	resp.ProtocolPriority = data.GetProtocolPriority()
 
 	return
 }
@@ -39,18 +39,21 @@ func (l *GetSubscribeApplicationListLogic) GetSubscribeApplicationList(req *type
 			_ = json.Unmarshal([]byte(item.DownloadLink), &temp)
 		}
 		list = append(list, types.SubscribeApplication{
-			Id:                item.Id,
-			Name:              item.Name,
-			Description:       item.Description,
-			Icon:              item.Icon,
-			Scheme:            item.Scheme,
-			UserAgent:         item.UserAgent,
-			IsDefault:         item.IsDefault,
-			SubscribeTemplate: item.SubscribeTemplate,
-			OutputFormat:      item.OutputFormat,
-			DownloadLink:      temp,
-			CreatedAt:         item.CreatedAt.UnixMilli(),
-			UpdatedAt:         item.UpdatedAt.UnixMilli(),
+			Id:                 item.Id,
+			Name:               item.Name,
+			Description:        item.Description,
+			Icon:               item.Icon,
+			Scheme:             item.Scheme,
+			UserAgent:          item.UserAgent,
+			IsDefault:          item.IsDefault,
+			SubscribeTemplate:  item.SubscribeTemplate,
+			OutputFormat:       item.OutputFormat,
+			SupportedProtocols: item.GetSupportedProtocols(),
+			ProtocolPriority:   item.GetProtocolPriority(),
+			RedirectMode:       item.RedirectMode,
+			DownloadLink:       temp,
+			CreatedAt:          item.CreatedAt.UnixMilli(),
+			UpdatedAt:          item.UpdatedAt.UnixMilli(),
 		})
 	}
 	resp = &types.GetSubscribeApplicationListResponse{
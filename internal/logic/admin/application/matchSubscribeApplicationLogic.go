@@ -0,0 +1,59 @@
+package application
+
+import (
+	"context"
+	"strings"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type MatchSubscribeApplicationLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewMatchSubscribeApplicationLogic Match subscribe application by user agent
+func NewMatchSubscribeApplicationLogic(ctx context.Context, svcCtx *svc.ServiceContext) *MatchSubscribeApplicationLogic {
+	return &MatchSubscribeApplicationLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// MatchSubscribeApplication resolves the client a subscription fetch with the user agent would be served
+// as, with the same matching as the subscribe handler, and how every client fared.
+func (l *MatchSubscribeApplicationLogic) MatchSubscribeApplication(req *types.MatchSubscribeApplicationRequest) (resp *types.MatchSubscribeApplicationResponse, err error) {
+	clients, err := l.svcCtx.ClientModel.List(l.ctx)
+	if err != nil {
+		l.Errorf("Failed to get subscribe application list: %v", err)
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Failed to get subscribe application list")
+	}
+	target, fallback, evaluation := subscribeLogic.MatchClient(clients, req.UserAgent)
+	resp = &types.MatchSubscribeApplicationResponse{
+		Fallback:   fallback,
+		Evaluation: make([]types.SubscribeApplicationEvaluation, 0, len(evaluation)),
+	}
+	if target != nil {
+		resp.Id = target.Id
+		resp.Name = target.Name
+		resp.OutputFormat = strings.ToLower(target.OutputFormat)
+	}
+	for _, item := range evaluation {
+		resp.Evaluation = append(resp.Evaluation, types.SubscribeApplicationEvaluation{
+			Id:        item.Client.Id,
+			Name:      item.Client.Name,
+			UserAgent: item.Client.UserAgent,
+			IsDefault: item.Client.IsDefault,
+			Matched:   item.Matched,
+			Reason:    item.Reason,
+		})
+	}
+	return
+}
@@ -51,6 +51,8 @@ func (l *PreviewSubscribeTemplateLogic) PreviewSubscribeTemplate(req *types.Prev
 		adapter.WithSiteName("PerfectPanel"),
 		adapter.WithSubscribeName("Test Subscribe"),
 		adapter.WithOutputFormat(data.OutputFormat),
+		adapter.WithSupportedProtocols(data.GetSupportedProtocols()),
+		adapter.WithProtocolPriority(data.GetProtocolPriority()),
 		adapter.WithUserInfo(adapter.User{
 			Password:     "test-password",
 			ExpiredAt:    time.Now().AddDate(1, 0, 0),
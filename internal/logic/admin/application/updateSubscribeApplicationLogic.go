@@ -3,6 +3,7 @@ package application
 import (
 	"context"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/client"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -49,14 +50,20 @@ func (l *UpdateSubscribeApplicationLogic) UpdateSubscribeApplication(req *types.
 	data.IsDefault = req.IsDefault
 	data.SubscribeTemplate = req.SubscribeTemplate
 	data.OutputFormat = req.OutputFormat
+	data.RedirectMode = req.RedirectMode
+	data.SetSupportedProtocols(req.SupportedProtocols)
+	data.SetProtocolPriority(req.ProtocolPriority)
 	data.DownloadLink = string(linkData)
 	err = l.svcCtx.ClientModel.Update(l.ctx, data)
 	if err != nil {
 		l.Errorf("Failed to update subscribe application with ID %d: %v", req.Id, err)
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "Failed to update subscribe application with ID %d", req.Id)
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	resp = &types.SubscribeApplication{}
 	tool.DeepCopy(&resp, data)
 	resp.DownloadLink = req.DownloadLink
+	resp.SupportedProtocols = data.GetSupportedProtocols()
+	resp.ProtocolPriority = data.GetProtocolPriority()
 	return
 }
@@ -0,0 +1,79 @@
+package application
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/perfect-panel/server/adapter"
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type UpdateSubscribeApplicationTemplateLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewUpdateSubscribeApplicationTemplateLogic Update subscribe application template
+func NewUpdateSubscribeApplicationTemplateLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateSubscribeApplicationTemplateLogic {
+	return &UpdateSubscribeApplicationTemplateLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdateSubscribeApplicationTemplateLogic) UpdateSubscribeApplicationTemplate(req *types.UpdateSubscribeApplicationTemplateRequest) (resp *types.SubscribeApplication, err error) {
+	data, err := l.svcCtx.ClientModel.FindOne(l.ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCodeMsg(xerr.InvalidParams, "subscribe application not found"), "subscribe application not found: %d", req.Id)
+		}
+		l.Errorf("Failed to find subscribe application with ID %d: %v", req.Id, err)
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Failed to find subscribe application with ID %d", req.Id)
+	}
+
+	// The template must render for the application's output format before it is saved
+	if err = adapter.ValidateTemplate(req.SubscribeTemplate, data.OutputFormat); err != nil {
+		l.Infow("[UpdateSubscribeApplicationTemplate] Invalid template", logger.Field("id", req.Id), logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCodeMsg(xerr.InvalidParams, err.Error()), "invalid template: %v", err.Error())
+	}
+
+	data.SubscribeTemplate = req.SubscribeTemplate
+	if err = l.svcCtx.ClientModel.Update(l.ctx, data); err != nil {
+		l.Errorf("Failed to update subscribe application with ID %d: %v", req.Id, err)
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "Failed to update subscribe application with ID %d", req.Id)
+	}
+	// Subscriptions load the applications from the database on every request,
+	// only the uploads of redirect mode clients have to be rebuilt.
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+
+	var link types.DownloadLink
+	if data.DownloadLink != "" {
+		_ = json.Unmarshal([]byte(data.DownloadLink), &link)
+	}
+	resp = &types.SubscribeApplication{
+		Id:                 data.Id,
+		Name:               data.Name,
+		Description:        data.Description,
+		Icon:               data.Icon,
+		Scheme:             data.Scheme,
+		UserAgent:          data.UserAgent,
+		IsDefault:          data.IsDefault,
+		SubscribeTemplate:  data.SubscribeTemplate,
+		OutputFormat:       data.OutputFormat,
+		SupportedProtocols: data.GetSupportedProtocols(),
+		ProtocolPriority:   data.GetProtocolPriority(),
+		RedirectMode:       data.RedirectMode,
+		DownloadLink:       link,
+		CreatedAt:          data.CreatedAt.UnixMilli(),
+		UpdatedAt:          data.UpdatedAt.UnixMilli(),
+	}
+	return
+}
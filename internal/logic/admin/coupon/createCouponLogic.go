@@ -2,15 +2,11 @@ package coupon
 
 import (
 	"context"
-	"math/rand"
-	"time"
 
 	"github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
-	"github.com/perfect-panel/server/pkg/random"
-	"github.com/perfect-panel/server/pkg/snowflake"
 	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
 	"github.com/pkg/errors"
@@ -32,15 +28,20 @@ func NewCreateCouponLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Crea
 }
 
 func (l *CreateCouponLogic) CreateCoupon(req *types.CreateCouponRequest) error {
+	tiers, err := marshalTiers(req.Type, req.Discount, req.Tiers)
+	if err != nil {
+		return err
+	}
 	if req.Code == "" {
-		rand.NewSource(time.Now().UnixNano())
-		sid := snowflake.GetID()
-		req.Code = random.KeyNew(4, 2) + "-" + random.StrToDashedString(random.EncodeBase36(sid))
+		req.Code = coupon.NewCode()
 	}
 	couponInfo := &coupon.Coupon{}
 	tool.DeepCopy(couponInfo, req)
 	couponInfo.Subscribe = tool.Int64SliceToString(req.Subscribe)
-	err := l.svcCtx.CouponModel.Insert(l.ctx, couponInfo)
+	couponInfo.Payment = tool.Int64SliceToString(req.Payment)
+	couponInfo.Users = tool.Int64SliceToString(req.Users)
+	couponInfo.Tiers = tiers
+	err = l.svcCtx.CouponModel.Insert(l.ctx, couponInfo)
 	if err != nil {
 		l.Errorw("[CreateCoupon] Database Error", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "create coupon error: %v", err.Error())
@@ -40,6 +40,12 @@ func (l *GetCouponListLogic) GetCouponList(req *types.GetCouponListRequest) (res
 		couponInfo := types.Coupon{}
 		tool.DeepCopy(&couponInfo, coupon)
 		couponInfo.Subscribe = tool.StringToInt64Slice(coupon.Subscribe)
+		couponInfo.Payment = tool.StringToInt64Slice(coupon.Payment)
+		couponInfo.Users = tool.StringToInt64Slice(coupon.Users)
+		couponInfo.Tiers = make([]types.CouponTier, 0)
+		for _, tier := range coupon.ParseTiers() {
+			couponInfo.Tiers = append(couponInfo.Tiers, types.CouponTier{Quantity: tier.Quantity, Discount: tier.Discount})
+		}
 		resp.List = append(resp.List, couponInfo)
 	}
 	return
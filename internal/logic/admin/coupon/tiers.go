@@ -0,0 +1,39 @@
+package coupon
+
+import (
+	"encoding/json"
+
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// marshalTiers checks the quantity tiers of a coupon and returns them as stored. The thresholds have to rise
+// and the discount must not drop from one tier to the next, a percentage stays within 100. No tiers keeps
+// the coupon flat.
+func marshalTiers(couponType uint8, discount int64, tiers []types.CouponTier) (string, error) {
+	if len(tiers) == 0 {
+		return "", nil
+	}
+	if couponType == coupon.TypeBonusDays {
+		return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "bonus days coupons take no quantity tiers")
+	}
+	prev := types.CouponTier{Quantity: 1, Discount: discount}
+	list := make([]coupon.Tier, 0, len(tiers))
+	for _, tier := range tiers {
+		if tier.Quantity <= prev.Quantity {
+			return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "tier quantity %d does not rise above %d", tier.Quantity, prev.Quantity)
+		}
+		if tier.Discount < prev.Discount {
+			return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "tier discount %d at quantity %d is below the %d before it", tier.Discount, tier.Quantity, prev.Discount)
+		}
+		if couponType == coupon.TypePercentage && tier.Discount > 100 {
+			return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "tier discount %d%% exceeds 100%%", tier.Discount)
+		}
+		list = append(list, coupon.Tier{Quantity: tier.Quantity, Discount: tier.Discount})
+		prev = tier
+	}
+	data, _ := json.Marshal(list)
+	return string(data), nil
+}
@@ -30,11 +30,18 @@ func NewUpdateCouponLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Upda
 
 func (l *UpdateCouponLogic) UpdateCoupon(req *types.UpdateCouponRequest) error {
 	fmt.Printf("req Subscribe: %v\n", req.Subscribe)
+	tiers, err := marshalTiers(req.Type, req.Discount, req.Tiers)
+	if err != nil {
+		return err
+	}
 	couponInfo := &coupon.Coupon{}
 	// update coupon
 	tool.DeepCopy(couponInfo, req)
 	couponInfo.Subscribe = tool.Int64SliceToString(req.Subscribe)
-	err := l.svcCtx.CouponModel.Update(l.ctx, couponInfo)
+	couponInfo.Payment = tool.Int64SliceToString(req.Payment)
+	couponInfo.Users = tool.Int64SliceToString(req.Users)
+	couponInfo.Tiers = tiers
+	err = l.svcCtx.CouponModel.Update(l.ctx, couponInfo)
 	if err != nil {
 		l.Errorw("[UpdateCoupon] Database Error", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update coupon error: %v", err.Error())
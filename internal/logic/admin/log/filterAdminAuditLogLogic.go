@@ -0,0 +1,73 @@
+package log
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type FilterAdminAuditLogLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Filter admin audit log
+func NewFilterAdminAuditLogLogic(ctx context.Context, svcCtx *svc.ServiceContext) *FilterAdminAuditLogLogic {
+	return &FilterAdminAuditLogLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *FilterAdminAuditLogLogic) FilterAdminAuditLog(req *types.FilterAdminAuditLogRequest) (resp *types.FilterAdminAuditLogResponse, err error) {
+	data, total, err := l.svcCtx.LogModel.FilterSystemLog(l.ctx, &log.FilterParams{
+		Page:     req.Page,
+		Size:     req.Size,
+		Type:     log.TypeAdminAudit.Uint8(),
+		ObjectID: req.ActorId,
+		Data:     req.Date,
+		Search:   req.Search,
+	})
+
+	if err != nil {
+		l.Errorf("[FilterAdminAuditLog] failed to filter system log: %v", err.Error())
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "failed to filter system log: %v", err.Error())
+	}
+
+	var list []types.AdminAuditLog
+	for _, datum := range data {
+		var content log.AdminAudit
+		err = content.Unmarshal([]byte(datum.Content))
+		if err != nil {
+			l.Errorf("[FilterAdminAuditLog] failed to unmarshal content: %v", err.Error())
+			continue
+		}
+		list = append(list, types.AdminAuditLog{
+			Action:          content.Action,
+			ActorId:         datum.ObjectID,
+			OrderNo:         content.OrderNo,
+			UserId:          content.UserId,
+			TargetUserId:    content.TargetUserId,
+			UserSubscribeId: content.UserSubscribeId,
+			AmountBefore:    content.AmountBefore,
+			AmountAfter:     content.AmountAfter,
+			StatusBefore:    content.StatusBefore,
+			StatusAfter:     content.StatusAfter,
+			Coupon:          content.Coupon,
+			Remark:          content.Remark,
+			Timestamp:       content.Timestamp,
+		})
+	}
+
+	return &types.FilterAdminAuditLogResponse{
+		Total: total,
+		List:  list,
+	}, nil
+}
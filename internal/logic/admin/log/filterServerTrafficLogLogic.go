@@ -28,13 +28,13 @@ func NewFilterServerTrafficLogLogic(ctx context.Context, svcCtx *svc.ServiceCont
 	}
 }
 func (l *FilterServerTrafficLogLogic) FilterServerTrafficLog(req *types.FilterServerTrafficLogRequest) (resp *types.FilterServerTrafficLogResponse, err error) {
-	today := time.Now().Format("2006-01-02")
+	today := log.Date(time.Now())
 	var list []types.ServerTrafficLog
 	var total int64
 
 	if req.Date == today || req.Date == "" {
-		now := time.Now()
-		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		now := time.Now().In(log.Location())
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		end := start.Add(24 * time.Hour).Add(-time.Nanosecond)
 
 		var serverTraffic []log.ServerTraffic
@@ -36,13 +36,13 @@ func (l *FilterUserSubscribeTrafficLogLogic) FilterUserSubscribeTrafficLog(req *
 		req.Page = 1
 	}
 
-	today := time.Now().Format("2006-01-02")
+	today := log.Date(time.Now())
 	var list []types.UserSubscribeTrafficLog
 	var total int64
 
 	if req.Date == today || req.Date == "" {
-		now := time.Now()
-		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+		now := time.Now().In(log.Location())
+		start := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
 		end := start.Add(24 * time.Hour).Add(-time.Nanosecond)
 
 		var userTraffic []types.UserSubscribeTrafficLog
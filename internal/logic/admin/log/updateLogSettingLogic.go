@@ -57,6 +57,7 @@ func (l *UpdateLogSettingLogic) UpdateLogSetting(req *types.LogSetting) error {
 	l.svcCtx.Config.Log = config.Log{
 		AutoClear: *req.AutoClear,
 		ClearDays: req.ClearDays,
+		Timezone:  l.svcCtx.Config.Log.Timezone,
 	}
 
 	return nil
@@ -0,0 +1,60 @@
+package order
+
+import (
+	"context"
+
+	orderLogic "github.com/perfect-panel/server/internal/logic/public/order"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+type CancelSandboxOrderLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Cancel a sandbox order
+func NewCancelSandboxOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CancelSandboxOrderLogic {
+	return &CancelSandboxOrderLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// CancelSandboxOrder closes the order through the regular close path, which restores the inventory, the
+// coupon use and the gift amount. An order paid or closed already keeps its status.
+func (l *CancelSandboxOrderLogic) CancelSandboxOrder(req *types.SandboxOrderRequest) (*types.SandboxOrderResponse, error) {
+	if err := checkSandbox(l.svcCtx); err != nil {
+		return nil, err
+	}
+	orderInfo, err := findSandboxOrder(l.ctx, l.svcCtx, req.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.SandboxOrderResponse{OrderNo: orderInfo.OrderNo}
+	recordStatus(resp, orderInfo.Status)
+
+	closed, err := orderLogic.NewCloseOrderLogic(l.ctx, l.svcCtx).ForceCloseOrder(orderInfo.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	l.Infow("[CancelSandboxOrder] Sandbox order cancelled",
+		logger.Field("admin", auditActor(l.ctx)),
+		logger.Field("order_no", orderInfo.OrderNo),
+		logger.Field("closed", closed),
+	)
+	if closed {
+		if err = auditSandboxOrder(l.ctx, l.svcCtx, log.AdminAuditSandboxCancel, orderInfo, orderInfo.OrderNo, ""); err != nil {
+			l.Errorw("[CancelSandboxOrder] Create audit log error", logger.Field("error", err.Error()), logger.Field("order_no", orderInfo.OrderNo))
+		}
+	}
+
+	if err = watchOrder(l.ctx, l.svcCtx, resp, 0); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -0,0 +1,82 @@
+package order
+
+import (
+	"context"
+
+	orderLogic "github.com/perfect-panel/server/internal/logic/public/order"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type ClosePendingOrdersLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Close all pending orders of a user
+func NewClosePendingOrdersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ClosePendingOrdersLogic {
+	return &ClosePendingOrdersLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// ClosePendingOrders closes every unpaid order of the user through the regular close path, which restores
+// the inventory, the coupon use and the deducted gift amount. The close tasks still queued for the orders
+// find them closed and do nothing. An order that fails is reported and the others are closed all the same.
+func (l *ClosePendingOrdersLogic) ClosePendingOrders(req *types.ClosePendingOrdersRequest) (*types.ClosePendingOrdersResponse, error) {
+	var list []*order.Order
+	// renewal orders of a bulk renewal are closed by their bulk order
+	err := l.svcCtx.DB.WithContext(l.ctx).Model(&order.Order{}).
+		Where("user_id = ? AND status IN ? AND bulk_order_no = ''", req.UserId, []uint8{order.StatusPending, order.StatusHold}).
+		Order("id ASC").Find(&list).Error
+	if err != nil {
+		l.Errorw("[ClosePendingOrders] Find pending orders error", logger.Field("error", err.Error()), logger.Field("user_id", req.UserId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find pending orders error: %v", err.Error())
+	}
+
+	resp := &types.ClosePendingOrdersResponse{
+		Failures: make([]types.ClosePendingOrderFailure, 0),
+	}
+	actor := auditActor(l.ctx)
+	for _, item := range list {
+		closed, err := orderLogic.NewCloseOrderLogic(l.ctx, l.svcCtx).ForceCloseOrder(item.OrderNo)
+		if err != nil {
+			l.Errorw("[ClosePendingOrders] Close order error", logger.Field("error", err.Error()), logger.Field("order_no", item.OrderNo))
+			resp.Failures = append(resp.Failures, types.ClosePendingOrderFailure{OrderNo: item.OrderNo, Error: err.Error()})
+			continue
+		}
+		// paid or closed since it was listed
+		if !closed {
+			resp.Skipped++
+			continue
+		}
+		resp.Closed++
+		if err = log.CreateAdminAudit(l.svcCtx.DB.WithContext(l.ctx), actor, &log.AdminAudit{
+			Action:       log.AdminAuditOrderForceClose,
+			OrderNo:      item.OrderNo,
+			UserId:       item.UserId,
+			AmountBefore: item.Amount,
+			AmountAfter:  item.Amount,
+			StatusBefore: item.Status,
+			StatusAfter:  order.StatusClose,
+		}); err != nil {
+			l.Errorw("[ClosePendingOrders] Create audit log error", logger.Field("error", err.Error()), logger.Field("order_no", item.OrderNo))
+		}
+	}
+	l.Infow("[ClosePendingOrders] Pending orders closed",
+		logger.Field("user_id", req.UserId),
+		logger.Field("admin", actor),
+		logger.Field("closed", resp.Closed),
+		logger.Field("skipped", resp.Skipped),
+		logger.Field("failed", len(resp.Failures)),
+	)
+	return resp, nil
+}
@@ -3,13 +3,14 @@ package order
 import (
 	"context"
 
+	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
-	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 type CreateOrderLogic struct {
@@ -34,9 +35,14 @@ func (l *CreateOrderLogic) CreateOrder(req *types.CreateOrderRequest) error {
 		return errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "PaymentMethod not found: %v", err.Error())
 	}
 
-	err = l.svcCtx.OrderModel.Insert(l.ctx, &order.Order{
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Logger.Error("[CreateOrder] Generate order number error", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
+	orderInfo := &order.Order{
 		UserId:         req.UserId,
-		OrderNo:        tool.GenerateTradeNo(),
+		OrderNo:        orderNo,
 		Type:           req.Type,
 		Quantity:       req.Quantity,
 		Price:          req.Price,
@@ -50,6 +56,18 @@ func (l *CreateOrderLogic) CreateOrder(req *types.CreateOrderRequest) error {
 		TradeNo:        req.TradeNo,
 		Status:         req.Status,
 		SubscribeId:    req.SubscribeId,
+	}
+	err = l.svcCtx.OrderModel.Transaction(l.ctx, func(db *gorm.DB) error {
+		if err := l.svcCtx.OrderModel.Insert(l.ctx, orderInfo, db); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(db, auditActor(l.ctx), &log.AdminAudit{
+			Action:      log.AdminAuditOrderCreate,
+			OrderNo:     orderInfo.OrderNo,
+			UserId:      orderInfo.UserId,
+			AmountAfter: orderInfo.Amount,
+			StatusAfter: orderInfo.Status,
+		})
 	})
 	if err != nil {
 		l.Logger.Error("[CreateOrder] Database Error", logger.Field("error", err.Error()))
@@ -0,0 +1,107 @@
+package order
+
+import (
+	"context"
+
+	orderLogic "github.com/perfect-panel/server/internal/logic/public/order"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type CreateSandboxOrderLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Create an order paid through the sandbox payment method
+func NewCreateSandboxOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreateSandboxOrderLogic {
+	return &CreateSandboxOrderLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// CreateSandboxOrder creates the order the way the user would, through the public order logic with the
+// sandbox payment method, so every check and deduction of the real path applies.
+func (l *CreateSandboxOrderLogic) CreateSandboxOrder(req *types.CreateSandboxOrderRequest) (*types.SandboxOrderResponse, error) {
+	if err := checkSandbox(l.svcCtx); err != nil {
+		return nil, err
+	}
+	pay, err := sandboxPayment(l.ctx, l.svcCtx)
+	if err != nil {
+		return nil, err
+	}
+	userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, req.UserId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserNotExist), "user %d not found", req.UserId)
+		}
+		l.Errorw("[CreateSandboxOrder] Find user error", logger.Field("error", err.Error()), logger.Field("user_id", req.UserId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user error: %v", err.Error())
+	}
+
+	ctx := context.WithValue(l.ctx, constant.CtxKeyUser, userInfo)
+	var orderNo string
+	switch req.Type {
+	case sandboxOrderPurchase:
+		result, err := orderLogic.NewPurchaseLogic(ctx, l.svcCtx).Purchase(&types.PurchaseOrderRequest{
+			SubscribeId: req.SubscribeId,
+			Quantity:    req.Quantity,
+			Payment:     pay.Id,
+			Coupon:      req.Coupon,
+		})
+		if err != nil {
+			return nil, err
+		}
+		orderNo = result.OrderNo
+	case sandboxOrderRenewal:
+		result, err := orderLogic.NewRenewalLogic(ctx, l.svcCtx).Renewal(&types.RenewalOrderRequest{
+			UserSubscribeID: req.UserSubscribeId,
+			Quantity:        req.Quantity,
+			Payment:         pay.Id,
+			Coupon:          req.Coupon,
+		})
+		if err != nil {
+			return nil, err
+		}
+		orderNo = result.OrderNo
+	case sandboxOrderRecharge:
+		result, err := orderLogic.NewRechargeLogic(ctx, l.svcCtx).Recharge(&types.RechargeOrderRequest{
+			Amount:  req.Amount,
+			Payment: pay.Id,
+		})
+		if err != nil {
+			return nil, err
+		}
+		orderNo = result.OrderNo
+	default:
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "unknown order type: %s", req.Type)
+	}
+	l.Infow("[CreateSandboxOrder] Sandbox order created",
+		logger.Field("admin", auditActor(l.ctx)),
+		logger.Field("user_id", userInfo.Id),
+		logger.Field("type", req.Type),
+		logger.Field("order_no", orderNo),
+	)
+	// the order exists now, a failed audit entry does not undo it
+	if err = auditSandboxOrder(l.ctx, l.svcCtx, log.AdminAuditSandboxCreate, nil, orderNo, req.Type); err != nil {
+		l.Errorw("[CreateSandboxOrder] Create audit log error", logger.Field("error", err.Error()), logger.Field("order_no", orderNo))
+	}
+
+	resp := &types.SandboxOrderResponse{
+		OrderNo:     orderNo,
+		Transitions: make([]types.SandboxOrderTransition, 0),
+	}
+	if err = watchOrder(l.ctx, l.svcCtx, resp, 0); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
@@ -3,6 +3,7 @@ package order
 import (
 	"context"
 
+	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -35,6 +36,9 @@ func (l *GetOrderListLogic) GetOrderList(req *types.GetOrderListRequest) (resp *
 	resp = &types.GetOrderListResponse{}
 	resp.List = make([]types.Order, 0)
 	tool.DeepCopy(&resp.List, list)
+	for i, item := range list {
+		resp.List[i].Metadata = order.ParseMetadata(item.Metadata)
+	}
 	resp.Total = total
 	return
 }
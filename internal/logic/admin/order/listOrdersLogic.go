@@ -0,0 +1,67 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type ListOrdersLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewListOrdersLogic Search order list with filters and sums
+func NewListOrdersLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ListOrdersLogic {
+	return &ListOrdersLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *ListOrdersLogic) ListOrders(req *types.ListOrdersRequest) (resp *types.ListOrdersResponse, err error) {
+	params := &order.FilterParams{
+		Page:      int(req.Page),
+		Size:      int(req.Size),
+		UserId:    req.UserId,
+		Type:      req.Type,
+		Status:    req.Status,
+		MinAmount: req.MinAmount,
+		MaxAmount: req.MaxAmount,
+	}
+	if req.StartTime > 0 {
+		start := time.UnixMilli(req.StartTime)
+		params.StartTime = &start
+	}
+	if req.EndTime > 0 {
+		end := time.UnixMilli(req.EndTime)
+		params.EndTime = &end
+	}
+
+	summary, list, err := l.svcCtx.OrderModel.FilterOrderList(l.ctx, params)
+	if err != nil {
+		l.Errorw("[ListOrders] Database Error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FilterOrderList error: %v", err.Error())
+	}
+	resp = &types.ListOrdersResponse{
+		Total:               summary.Total,
+		TotalAmount:         summary.TotalAmount,
+		TotalFee:            summary.TotalFee,
+		TotalCouponDiscount: summary.TotalCouponDiscount,
+		List:                make([]types.Order, 0),
+	}
+	tool.DeepCopy(&resp.List, list)
+	for i, item := range list {
+		resp.List[i].Metadata = order.ParseMetadata(item.Metadata)
+	}
+	return
+}
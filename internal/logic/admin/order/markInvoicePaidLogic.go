@@ -0,0 +1,96 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hibiken/asynq"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type MarkInvoicePaidLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Mark the invoice of an enterprise order paid
+func NewMarkInvoicePaidLogic(ctx context.Context, svcCtx *svc.ServiceContext) *MarkInvoicePaidLogic {
+	return &MarkInvoicePaidLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// MarkInvoicePaid finishes an invoiced order and takes its amount off the outstanding balance of the
+// enterprise account, the subscription was provisioned at checkout already. The commission and rewards
+// of the order are granted now.
+func (l *MarkInvoicePaidLogic) MarkInvoicePaid(req *types.MarkInvoicePaidRequest) (*types.MarkInvoicePaidResponse, error) {
+	orderInfo, err := l.svcCtx.OrderModel.FindOneByOrderNo(l.ctx, req.OrderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", req.OrderNo)
+		}
+		l.Errorw("[MarkInvoicePaid] Find order error", logger.Field("error", err.Error()), logger.Field("orderNo", req.OrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	// an order still awaiting its activation has nothing provisioned to settle yet
+	if orderInfo.Status != order.StatusInvoiced {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status %d is not invoiced", orderInfo.Status)
+	}
+	userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, orderInfo.UserId)
+	if err != nil {
+		l.Errorw("[MarkInvoicePaid] Find user error", logger.Field("error", err.Error()), logger.Field("userId", orderInfo.UserId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user error: %v", err.Error())
+	}
+
+	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
+		// a concurrent request settling the same invoice fails here before touching the balance
+		if err := l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, orderInfo.OrderNo, order.StatusInvoiced, order.StatusFinished, db); err != nil {
+			return err
+		}
+		if err := l.svcCtx.UserModel.SettleOutstandingBalance(l.ctx, userInfo, orderInfo.Amount, db); err != nil {
+			return err
+		}
+		if err := log.CreateAdminAudit(db, auditActor(l.ctx), &log.AdminAudit{
+			Action:       log.AdminAuditInvoicePaid,
+			OrderNo:      orderInfo.OrderNo,
+			UserId:       orderInfo.UserId,
+			AmountBefore: orderInfo.Amount,
+			AmountAfter:  orderInfo.Amount,
+			StatusBefore: order.StatusInvoiced,
+			StatusAfter:  order.StatusFinished,
+		}); err != nil {
+			return err
+		}
+		// the commission and rewards of the order were held back until now
+		payload, _ := json.Marshal(queue.ForthwithInvoicePaidPayload{OrderNo: orderInfo.OrderNo})
+		_, err := l.svcCtx.Queue.EnqueueContext(l.ctx, asynq.NewTask(queue.ForthwithInvoicePaid, payload))
+		return err
+	})
+	if errors.Is(err, order.ErrOrderStatusChanged) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status changed: %v", req.OrderNo)
+	}
+	if err != nil {
+		l.Errorw("[MarkInvoicePaid] Settle invoice error", logger.Field("error", err.Error()), logger.Field("orderNo", req.OrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "settle invoice error: %v", err.Error())
+	}
+	l.Infow("[MarkInvoicePaid] Invoice paid",
+		logger.Field("orderNo", orderInfo.OrderNo),
+		logger.Field("userId", userInfo.Id),
+		logger.Field("outstandingBalance", userInfo.OutstandingBalance))
+	return &types.MarkInvoicePaidResponse{
+		OrderNo:            orderInfo.OrderNo,
+		OutstandingBalance: userInfo.OutstandingBalance,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+type PaySandboxOrderLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Pay a sandbox order
+func NewPaySandboxOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *PaySandboxOrderLogic {
+	return &PaySandboxOrderLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// PaySandboxOrder checks the order out through the sandbox payment method, which pays it and queues its
+// activation. With a wait the response follows the order until the activation finishes it.
+func (l *PaySandboxOrderLogic) PaySandboxOrder(req *types.SandboxOrderRequest) (*types.SandboxOrderResponse, error) {
+	if err := checkSandbox(l.svcCtx); err != nil {
+		return nil, err
+	}
+	orderInfo, err := findSandboxOrder(l.ctx, l.svcCtx, req.OrderNo)
+	if err != nil {
+		return nil, err
+	}
+	resp := &types.SandboxOrderResponse{OrderNo: orderInfo.OrderNo}
+	recordStatus(resp, orderInfo.Status)
+
+	if _, err = portal.NewPurchaseCheckoutLogic(l.ctx, l.svcCtx).PurchaseCheckout(&types.CheckoutOrderRequest{
+		OrderNo: orderInfo.OrderNo,
+	}); err != nil {
+		return nil, err
+	}
+	l.Infow("[PaySandboxOrder] Sandbox order paid", logger.Field("admin", auditActor(l.ctx)), logger.Field("order_no", orderInfo.OrderNo))
+	if err = auditSandboxOrder(l.ctx, l.svcCtx, log.AdminAuditSandboxPay, orderInfo, orderInfo.OrderNo, ""); err != nil {
+		l.Errorw("[PaySandboxOrder] Create audit log error", logger.Field("error", err.Error()), logger.Field("order_no", orderInfo.OrderNo))
+	}
+
+	if err = watchOrder(l.ctx, l.svcCtx, resp, time.Duration(req.Wait)*time.Second); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
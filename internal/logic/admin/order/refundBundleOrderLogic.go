@@ -0,0 +1,203 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundBundleOrderLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Refund bundle order
+func NewRefundBundleOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RefundBundleOrderLogic {
+	return &RefundBundleOrderLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// RefundBundleOrder ends the subscription the bundle provisioned, takes the bundle credit back from the
+// gift amount and returns the paid amount to the balance and the deducted gift amount to the gift balance.
+// Credit the user has spent already is kept from the refunded amount. The goodwill coupon is issued like
+// for renewal refunds.
+func (l *RefundBundleOrderLogic) RefundBundleOrder(req *types.RefundBundleOrderRequest) (*types.RefundBundleOrderResponse, error) {
+	orderInfo, err := l.svcCtx.OrderModel.FindOne(l.ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", req.Id)
+		}
+		l.Errorw("[RefundBundleOrder] Find order error", logger.Field("error", err.Error()), logger.Field("id", req.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	if orderInfo.Type != order.TypeBundle {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order is not a bundle order")
+	}
+	// Only finished orders have provisioned the subscription and credited the gift amount
+	if orderInfo.Status != order.StatusFinished {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status %d can not be refunded", orderInfo.Status)
+	}
+
+	now := time.Now()
+	var goodwill *coupon.Coupon
+	if l.svcCtx.Config.Refund.GoodwillCoupon && !req.Fraud {
+		goodwill = goodwillCoupon(l.ctx, l.svcCtx.Config.Refund, orderInfo, now)
+	}
+	var userInfo user.User
+	var userSub user.Subscribe
+	var refund, removed int64
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the order first, a concurrent refund of the same order fails here
+		if err := l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, orderInfo.OrderNo, order.StatusFinished, order.StatusRefunded, tx); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.User{}).Where("id = ?", orderInfo.UserId).First(&userInfo).Error; err != nil {
+			return err
+		}
+		// the subscription may have been deleted by hand, the amounts are refunded all the same
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.Subscribe{}).Where("order_id = ?", orderInfo.Id).First(&userSub).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		if err == nil {
+			if userSub.ExpireTime.Unix() == 0 || userSub.ExpireTime.After(now) {
+				userSub.ExpireTime = now
+			}
+			userSub.Status = 3
+			if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, &userSub, tx); err != nil {
+				return err
+			}
+		}
+
+		userInfo.GiftAmount += orderInfo.GiftAmount - orderInfo.PromoCredit
+		userInfo.PromoCredit += orderInfo.PromoCredit
+		userInfo.LoyaltyCredit += orderInfo.LoyaltyCredit
+		removed = min(orderInfo.BundleCredit, userInfo.GiftAmount)
+		userInfo.GiftAmount -= removed
+		refund = max(0, orderInfo.Amount-(orderInfo.BundleCredit-removed))
+		userInfo.Balance += refund
+		if err := l.svcCtx.UserModel.Update(l.ctx, &userInfo, tx); err != nil {
+			return err
+		}
+
+		if refund > 0 {
+			balanceLog := log.Balance{
+				Type:      log.BalanceTypeRefund,
+				Amount:    refund,
+				OrderNo:   orderInfo.OrderNo,
+				Balance:   userInfo.Balance,
+				Timestamp: now.UnixMilli(),
+			}
+			content, _ := balanceLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeBalance.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		giftLogs := []log.Gift{
+			{Type: log.GiftTypeIncrease, Amount: orderInfo.PromoCredit, Balance: userInfo.PromoCredit, Bucket: log.GiftBucketPromo},
+			{Type: log.GiftTypeIncrease, Amount: orderInfo.GiftAmount - orderInfo.PromoCredit, Balance: userInfo.GiftAmount + removed},
+			{Type: log.GiftTypeReduce, Amount: removed, Balance: userInfo.GiftAmount},
+		}
+		for _, giftLog := range giftLogs {
+			if giftLog.Amount <= 0 {
+				continue
+			}
+			giftLog.OrderNo = orderInfo.OrderNo
+			giftLog.SubscribeId = userSub.Id
+			giftLog.Remark = "Bundle order refund"
+			giftLog.Timestamp = now.UnixMilli()
+			content, _ := giftLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeGift.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		if orderInfo.LoyaltyCredit > 0 {
+			creditLog := log.LoyaltyCredit{
+				Type:      log.LoyaltyCreditTypeIncrease,
+				OrderNo:   orderInfo.OrderNo,
+				Amount:    orderInfo.LoyaltyCredit,
+				Balance:   userInfo.LoyaltyCredit,
+				Remark:    "Bundle order refund",
+				Timestamp: now.UnixMilli(),
+			}
+			content, _ := creditLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeLoyaltyCredit.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		audit := &log.AdminAudit{
+			Action:       log.AdminAuditOrderRefund,
+			OrderNo:      orderInfo.OrderNo,
+			UserId:       orderInfo.UserId,
+			AmountBefore: orderInfo.Amount,
+			AmountAfter:  refund,
+			StatusBefore: order.StatusFinished,
+			StatusAfter:  order.StatusRefunded,
+			Timestamp:    now.UnixMilli(),
+		}
+		if req.Fraud {
+			audit.Remark = "Fraud"
+		}
+		if goodwill != nil {
+			if err := tx.Model(&coupon.Coupon{}).Create(goodwill).Error; err != nil {
+				return err
+			}
+			audit.Coupon = goodwill.Code
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), audit)
+	})
+	if errors.Is(err, order.ErrOrderStatusChanged) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order has already been refunded: %v", orderInfo.OrderNo)
+	}
+	if err != nil {
+		l.Errorw("[RefundBundleOrder] Transaction error", logger.Field("error", err.Error()), logger.Field("order_no", orderInfo.OrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "refund bundle order error: %v", err.Error())
+	}
+
+	if err = l.svcCtx.UserModel.UpdateUserCache(l.ctx, &userInfo); err != nil {
+		l.Errorw("[RefundBundleOrder] Update user cache error", logger.Field("error", err.Error()), logger.Field("user_id", userInfo.Id))
+	}
+	// The subscription has ended, nodes must stop serving it
+	if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, orderInfo.SubscribeId); err != nil {
+		l.Errorw("[RefundBundleOrder] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribe_id", orderInfo.SubscribeId))
+	}
+	resp := &types.RefundBundleOrderResponse{
+		Amount:        refund,
+		CreditRemoved: removed,
+	}
+	if goodwill != nil {
+		resp.Coupon = goodwill.Code
+	}
+	return resp, nil
+}
@@ -0,0 +1,312 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundRenewalOrderLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Refund renewal order
+func NewRefundRenewalOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *RefundRenewalOrderLogic {
+	return &RefundRenewalOrderLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// RefundRenewalOrder removes the renewed duration from the user subscription,
+// returns the paid amount to the user balance and the deducted gift amount to the gift balance,
+// takes back the loyalty credit the order rewarded and marks the order as refunded. A renewal that
+// switched plans moves the subscription back to the previous plan. With the goodwill coupon enabled
+// the user gets a single-use coupon unless the refund is flagged as fraud.
+func (l *RefundRenewalOrderLogic) RefundRenewalOrder(req *types.RefundRenewalOrderRequest) (*types.RefundRenewalOrderResponse, error) {
+	orderInfo, err := l.svcCtx.OrderModel.FindOne(l.ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", req.Id)
+		}
+		l.Errorw("[RefundRenewalOrder] Find order error", logger.Field("error", err.Error()), logger.Field("id", req.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	if orderInfo.Type != 2 {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order is not a renewal order")
+	}
+	// Only finished orders have extended the subscription, a paid order is still waiting for activation
+	if orderInfo.Status != order.StatusFinished {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status %d can not be refunded", orderInfo.Status)
+	}
+
+	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, orderInfo.SubscribeId)
+	if err != nil {
+		l.Errorw("[RefundRenewalOrder] Find subscribe error", logger.Field("error", err.Error()), logger.Field("subscribe_id", orderInfo.SubscribeId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+	}
+
+	// a renewal that switched plans moves the subscription back to the plan it came from
+	var previous *subscribe.Subscribe
+	if orderInfo.FromSubscribeId != 0 {
+		if previous, err = l.svcCtx.SubscribeModel.FindOne(l.ctx, orderInfo.FromSubscribeId); err != nil {
+			l.Errorw("[RefundRenewalOrder] Find subscribe error", logger.Field("error", err.Error()), logger.Field("subscribe_id", orderInfo.FromSubscribeId))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+		}
+	}
+
+	now := time.Now()
+	var goodwill *coupon.Coupon
+	if l.svcCtx.Config.Refund.GoodwillCoupon && !req.Fraud {
+		goodwill = goodwillCoupon(l.ctx, l.svcCtx.Config.Refund, orderInfo, now)
+	}
+	var userInfo user.User
+	var userSub user.Subscribe
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the order first, a concurrent refund of the same order fails here
+		if err := l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, orderInfo.OrderNo, order.StatusFinished, order.StatusRefunded, tx); err != nil {
+			return err
+		}
+		// Re-read the user and the subscription under lock so balances and expiry are changed on current data
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.User{}).Where("id = ?", orderInfo.UserId).First(&userInfo).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.Subscribe{}).Where("token = ?", orderInfo.SubscribeToken).First(&userSub).Error; err != nil {
+			return err
+		}
+
+		expireBefore := userSub.ExpireTime
+		// the time the order added, as snapshotted on it, orders from before the snapshot fall back to the plan
+		unit, periods := orderInfo.UnitTime, orderInfo.Periods
+		if unit == "" {
+			unit, periods = sub.UnitTime, sub.Periods(orderInfo.Quantity)
+		}
+		expireAfter, ended := rollbackRenewalExpireTime(expireBefore, unit, periods, orderInfo.BonusDays, now)
+		userSub.ExpireTime = expireAfter
+		if ended {
+			userSub.Status = 3
+		}
+		if previous != nil && userSub.SubscribeId == sub.Id {
+			if !ended && expireAfter.Unix() != 0 {
+				// the time left is converted back at the value it was converted with
+				userSub.ExpireTime = now.Add(sub.ConvertRemaining(expireAfter.Sub(now), previous, now))
+			}
+			userSub.SubscribeId = previous.Id
+			userSub.Traffic = previous.Traffic
+			// the seat of the new plan is given back, the user takes theirs of the previous plan again
+			// and keeps it when the plan sold out since
+			if err := l.svcCtx.SubscribeModel.IncreaseInventory(l.ctx, sub.Id, tx); err != nil {
+				return err
+			}
+			if err := l.svcCtx.SubscribeModel.DecreaseInventory(l.ctx, previous.Id, tx); err != nil && !errors.Is(err, subscribe.ErrOutOfStock) {
+				return err
+			}
+		}
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, &userSub, tx); err != nil {
+			return err
+		}
+
+		// the credit the order rewarded is taken back, as far as the user has not spent it
+		rewarded, err := log.OrderLoyaltyCredit(tx, userInfo.Id, orderInfo.OrderNo, log.LoyaltyCreditTypeIncrease)
+		if err != nil {
+			return err
+		}
+		creditBalance := userInfo.LoyaltyCredit + orderInfo.LoyaltyCredit
+		clawback := min(rewarded, creditBalance)
+		if orderInfo.Amount > 0 || orderInfo.GiftAmount > 0 || orderInfo.LoyaltyCredit > 0 || clawback > 0 {
+			userInfo.Balance += orderInfo.Amount
+			userInfo.GiftAmount += orderInfo.GiftAmount - orderInfo.PromoCredit
+			userInfo.PromoCredit += orderInfo.PromoCredit
+			userInfo.LoyaltyCredit = creditBalance - clawback
+			if err := l.svcCtx.UserModel.Update(l.ctx, &userInfo, tx); err != nil {
+				return err
+			}
+		}
+		if orderInfo.Amount > 0 {
+			balanceLog := log.Balance{
+				Type:      log.BalanceTypeRefund,
+				Amount:    orderInfo.Amount,
+				OrderNo:   orderInfo.OrderNo,
+				Balance:   userInfo.Balance,
+				Timestamp: now.UnixMilli(),
+			}
+			content, _ := balanceLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeBalance.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		giftLogs := []log.Gift{
+			{Amount: orderInfo.PromoCredit, Balance: userInfo.PromoCredit, Bucket: log.GiftBucketPromo},
+			{Amount: orderInfo.GiftAmount - orderInfo.PromoCredit, Balance: userInfo.GiftAmount},
+		}
+		for _, giftLog := range giftLogs {
+			if giftLog.Amount <= 0 {
+				continue
+			}
+			giftLog.Type = log.GiftTypeIncrease
+			giftLog.OrderNo = orderInfo.OrderNo
+			giftLog.SubscribeId = userSub.Id
+			giftLog.Remark = "Renewal order refund"
+			giftLog.Timestamp = now.UnixMilli()
+			content, _ := giftLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeGift.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+		creditLogs := []log.LoyaltyCredit{
+			{Type: log.LoyaltyCreditTypeIncrease, Amount: orderInfo.LoyaltyCredit, Balance: creditBalance, Remark: "Renewal order refund"},
+			{Type: log.LoyaltyCreditTypeReduce, Amount: clawback, Balance: userInfo.LoyaltyCredit, Remark: "Renewal order refund reward"},
+		}
+		for _, creditLog := range creditLogs {
+			if creditLog.Amount <= 0 {
+				continue
+			}
+			creditLog.OrderNo = orderInfo.OrderNo
+			creditLog.Timestamp = now.UnixMilli()
+			content, _ := creditLog.Marshal()
+			if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeLoyaltyCredit.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: userInfo.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		refundLog := log.RenewalRefund{
+			OrderNo:         orderInfo.OrderNo,
+			UserSubscribeId: userSub.Id,
+			ExpireBefore:    expireBefore.UnixMilli(),
+			ExpireAfter:     userSub.ExpireTime.UnixMilli(),
+			Amount:          orderInfo.Amount,
+			GiftAmount:      orderInfo.GiftAmount,
+			LoyaltyCredit:   orderInfo.LoyaltyCredit,
+			Timestamp:       now.UnixMilli(),
+		}
+		content, _ := refundLog.Marshal()
+		if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeRenewalRefund.Uint8(),
+			Date:     log.Date(now),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		}).Error; err != nil {
+			return err
+		}
+		audit := &log.AdminAudit{
+			Action:       log.AdminAuditOrderRefund,
+			OrderNo:      orderInfo.OrderNo,
+			UserId:       orderInfo.UserId,
+			AmountBefore: orderInfo.Amount,
+			AmountAfter:  orderInfo.Amount,
+			StatusBefore: order.StatusFinished,
+			StatusAfter:  order.StatusRefunded,
+			Timestamp:    now.UnixMilli(),
+		}
+		if req.Fraud {
+			audit.Remark = "Fraud"
+		}
+		if goodwill != nil {
+			if err := tx.Model(&coupon.Coupon{}).Create(goodwill).Error; err != nil {
+				return err
+			}
+			audit.Coupon = goodwill.Code
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), audit)
+	})
+	if errors.Is(err, order.ErrOrderStatusChanged) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order has already been refunded: %v", orderInfo.OrderNo)
+	}
+	if err != nil {
+		l.Errorw("[RefundRenewalOrder] Transaction error", logger.Field("error", err.Error()), logger.Field("order_no", orderInfo.OrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "refund renewal order error: %v", err.Error())
+	}
+
+	if err = l.svcCtx.UserModel.UpdateUserCache(l.ctx, &userInfo); err != nil {
+		l.Errorw("[RefundRenewalOrder] Update user cache error", logger.Field("error", err.Error()), logger.Field("user_id", userInfo.Id))
+	}
+	// The subscription may have ended, nodes must stop serving it
+	if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, sub.Id); err != nil {
+		l.Errorw("[RefundRenewalOrder] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+	}
+	if previous != nil {
+		if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, previous.Id); err != nil {
+			l.Errorw("[RefundRenewalOrder] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribe_id", previous.Id))
+		}
+	}
+	resp := &types.RefundRenewalOrderResponse{}
+	if goodwill != nil {
+		resp.Coupon = goodwill.Code
+	}
+	return resp, nil
+}
+
+// goodwillCoupon builds the single-use coupon offered to the user of the refunded order,
+// nil when no discount is configured.
+func goodwillCoupon(ctx context.Context, cfg config.RefundConfig, orderInfo *order.Order, now time.Time) *coupon.Coupon {
+	if cfg.GoodwillCouponDiscount <= 0 {
+		logger.WithContext(ctx).Infow("[RefundOrder] Goodwill coupon has no discount configured, skipped", logger.Field("order_no", orderInfo.OrderNo))
+		return nil
+	}
+	var expire int64
+	if cfg.GoodwillCouponDays > 0 {
+		expire = now.AddDate(0, 0, int(cfg.GoodwillCouponDays)).Unix()
+	}
+	enable := true
+	return &coupon.Coupon{
+		Name:       "Refund " + orderInfo.OrderNo,
+		Code:       coupon.NewCode(),
+		Count:      1,
+		Type:       cfg.GoodwillCouponType,
+		Discount:   cfg.GoodwillCouponDiscount,
+		StartTime:  now.Unix(),
+		ExpireTime: expire,
+		UserLimit:  1,
+		Users:      tool.Int64SliceToString([]int64{orderInfo.UserId}),
+		OrderTypes: coupon.OrderTypeAll,
+		Enable:     &enable,
+	}
+}
+
+// rollbackRenewalExpireTime subtracts the renewed duration and the coupon's bonus days from the current expiry.
+// Later renewals were added on top of this one, so subtracting from the current expiry keeps them intact.
+// Unlimited subscriptions (expire time 0) and NoLimit plans have no renewed duration to remove.
+// When the renewed time has already been used up the subscription ends now instead of in the past.
+func rollbackRenewalExpireTime(expire time.Time, unit string, quantity, bonusDays int64, now time.Time) (time.Time, bool) {
+	if expire.Unix() == 0 || unit == "NoLimit" {
+		return expire, false
+	}
+	// undone in the reverse order activation added them in
+	expireAfter := tool.AddTime(unit, -quantity, expire.AddDate(0, 0, -int(bonusDays)))
+	if expireAfter.Before(now) {
+		return now, true
+	}
+	return expireAfter, false
+}
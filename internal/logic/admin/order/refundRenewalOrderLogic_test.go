@@ -0,0 +1,83 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRollbackRenewalExpireTime(t *testing.T) {
+	now := time.Date(2025, 6, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		expire   time.Time
+		unit     string
+		quantity int64
+		bonus    int64
+		want     time.Time
+		ended    bool
+	}{
+		{
+			name:     "renewed time still ahead",
+			expire:   time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC),
+			unit:     "Month",
+			quantity: 1,
+			want:     time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			// the user renewed again after this order, only this order's months are removed
+			name:     "further renewed",
+			expire:   time.Date(2026, 6, 15, 12, 0, 0, 0, time.UTC),
+			unit:     "Month",
+			quantity: 3,
+			want:     time.Date(2026, 3, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "with coupon bonus days",
+			expire:   time.Date(2025, 9, 22, 12, 0, 0, 0, time.UTC),
+			unit:     "Month",
+			quantity: 1,
+			bonus:    7,
+			want:     time.Date(2025, 8, 15, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "renewed time used up",
+			expire:   time.Date(2025, 6, 20, 12, 0, 0, 0, time.UTC),
+			unit:     "Day",
+			quantity: 30,
+			want:     now,
+			ended:    true,
+		},
+		{
+			name:     "renewed time used up exactly",
+			expire:   now.Add(-time.Second).AddDate(0, 1, 0),
+			unit:     "Month",
+			quantity: 1,
+			want:     now,
+			ended:    true,
+		},
+		{
+			name:     "unlimited subscription",
+			expire:   time.Unix(0, 0),
+			unit:     "Month",
+			quantity: 1,
+			want:     time.Unix(0, 0),
+		},
+		{
+			name:     "no limit plan",
+			expire:   time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC),
+			unit:     "NoLimit",
+			quantity: 1,
+			want:     time.Date(2025, 9, 15, 12, 0, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ended := rollbackRenewalExpireTime(tt.expire, tt.unit, tt.quantity, tt.bonus, now)
+			assert.True(t, tt.want.Equal(got), "want %v, got %v", tt.want, got)
+			assert.Equal(t, tt.ended, ended)
+		})
+	}
+}
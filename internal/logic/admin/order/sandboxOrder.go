@@ -0,0 +1,124 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Sandbox orders
+//
+// Integration tests drive the order pipeline end to end through the sandbox endpoints: the order is created
+// by the public Purchase, Renewal or Recharge logic acting as the user, paid through the checkout of the
+// sandbox payment method and closed through the regular close path. The endpoints are available only
+// where the sandbox payment method is, see config.PaymentSandboxEnabled.
+
+const (
+	sandboxOrderPurchase = "purchase"
+	sandboxOrderRenewal  = "renewal"
+	sandboxOrderRecharge = "recharge"
+
+	// sandboxPollInterval how often the order is read again while waiting for its activation
+	sandboxPollInterval = 200 * time.Millisecond
+)
+
+func checkSandbox(svcCtx *svc.ServiceContext) error {
+	if !svcCtx.Config.PaymentSandboxEnabled() {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "payment sandbox is disabled")
+	}
+	return nil
+}
+
+// sandboxPayment returns the enabled payment method of the sandbox platform
+func sandboxPayment(ctx context.Context, svcCtx *svc.ServiceContext) (*payment.Payment, error) {
+	list, err := svcCtx.PaymentModel.FindAvailableMethods(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment methods error: %v", err.Error())
+	}
+	for _, item := range list {
+		if paymentPlatform.ParsePlatform(item.Platform) == paymentPlatform.Test {
+			return item, nil
+		}
+	}
+	return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "no sandbox payment method enabled")
+}
+
+// findSandboxOrder returns the order, only orders of the sandbox payment method can be driven here
+func findSandboxOrder(ctx context.Context, svcCtx *svc.ServiceContext, orderNo string) (*order.Order, error) {
+	orderInfo, err := svcCtx.OrderModel.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", orderNo)
+		}
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	if paymentPlatform.ParsePlatform(orderInfo.Method) != paymentPlatform.Test {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order %v is not a sandbox order", orderNo)
+	}
+	return orderInfo, nil
+}
+
+// recordStatus appends the status to the transitions when it differs from the last one
+func recordStatus(resp *types.SandboxOrderResponse, status uint8) {
+	if len(resp.Transitions) > 0 && resp.Status == status {
+		return
+	}
+	resp.Status = status
+	resp.Transitions = append(resp.Transitions, types.SandboxOrderTransition{
+		Status: status,
+		Time:   time.Now().UnixMilli(),
+	})
+}
+
+// watchOrder records the current status of the order. A paid order is read again until its activation
+// settles it or the wait is over.
+func watchOrder(ctx context.Context, svcCtx *svc.ServiceContext, resp *types.SandboxOrderResponse, wait time.Duration) error {
+	deadline := time.Now().Add(wait)
+	for {
+		orderInfo, err := svcCtx.OrderModel.FindOneByOrderNo(ctx, resp.OrderNo)
+		if err != nil {
+			return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+		}
+		recordStatus(resp, orderInfo.Status)
+		if orderInfo.Status != order.StatusPaid || !time.Now().Before(deadline) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(sandboxPollInterval):
+		}
+	}
+}
+
+// auditSandboxOrder records the sandbox action in the admin audit log with the order as it is after the action,
+// before is the order read ahead of it, nil for a created order.
+func auditSandboxOrder(ctx context.Context, svcCtx *svc.ServiceContext, action uint16, before *order.Order, orderNo, remark string) error {
+	after, err := svcCtx.OrderModel.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	audit := &log.AdminAudit{
+		Action:      action,
+		OrderNo:     after.OrderNo,
+		UserId:      after.UserId,
+		AmountAfter: after.Amount,
+		StatusAfter: after.Status,
+		Coupon:      after.Coupon,
+		Remark:      remark,
+	}
+	if before != nil {
+		audit.AmountBefore = before.Amount
+		audit.StatusBefore = before.Status
+	}
+	return log.CreateAdminAudit(svcCtx.DB.WithContext(ctx), auditActor(ctx), audit)
+}
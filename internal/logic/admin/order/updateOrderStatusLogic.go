@@ -9,6 +9,8 @@ import (
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -36,6 +38,10 @@ func (l *UpdateOrderStatusLogic) UpdateOrderStatus(req *types.UpdateOrderStatusR
 		l.Errorw("[UpdateOrderStatus] FindOne error", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOne error: %v", err.Error())
 	}
+	// the order was activated at checkout, its invoice is settled through MarkInvoicePaid
+	if info.Status == order.StatusAwaitingInvoice || info.Status == order.StatusInvoiced {
+		return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order %s is awaiting its invoice", info.OrderNo)
+	}
 
 	if req.PaymentId != 0 {
 		paymentMethod, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.PaymentId)
@@ -58,6 +64,17 @@ func (l *UpdateOrderStatusLogic) UpdateOrderStatus(req *types.UpdateOrderStatusR
 		if err := l.svcCtx.OrderModel.UpdateOrderStatus(l.ctx, info.OrderNo, req.Status, db); err != nil {
 			return err
 		}
+		if err := log.CreateAdminAudit(db, auditActor(l.ctx), &log.AdminAudit{
+			Action:       log.AdminAuditOrderStatus,
+			OrderNo:      info.OrderNo,
+			UserId:       info.UserId,
+			AmountBefore: info.Amount,
+			AmountAfter:  info.Amount,
+			StatusBefore: info.Status,
+			StatusAfter:  req.Status,
+		}); err != nil {
+			return err
+		}
 		// If order status is 2, create user subscription
 		if req.Status == 2 {
 			payload := queue.ForthwithActivateOrderPayload{
@@ -40,19 +40,40 @@ func (l *CreatePaymentMethodLogic) CreatePaymentMethod(req *types.CreatePaymentM
 		l.Errorw("unsupported payment platform", logger.Field("mark", req.Platform))
 		return nil, errors.Wrapf(xerr.NewErrCodeMsg(400, "UNSUPPORTED_PAYMENT_PLATFORM"), "unsupported payment platform: %s", req.Platform)
 	}
+	if payment.ParsePlatform(req.Platform) == payment.Test && !l.svcCtx.Config.PaymentSandboxEnabled() {
+		l.Errorw("payment sandbox is disabled", logger.Field("mark", req.Platform))
+		return nil, errors.Wrapf(xerr.NewErrCodeMsg(400, "UNSUPPORTED_PAYMENT_PLATFORM"), "payment sandbox is disabled: %s", req.Platform)
+	}
+	if req.MinRecharge > 0 && req.MaxRecharge > 0 && req.MinRecharge > req.MaxRecharge {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min recharge %d exceeds max recharge %d", req.MinRecharge, req.MaxRecharge)
+	}
+	if req.MinAmount > 0 && req.MaxAmount > 0 && req.MinAmount > req.MaxAmount {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min amount %d exceeds max amount %d", req.MinAmount, req.MaxAmount)
+	}
+	if _, err = paymentModel.ParseHours(req.AvailableHours); err != nil {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "invalid available hours %q: %v", req.AvailableHours, err.Error())
+	}
 	config := parsePaymentPlatformConfig(l.ctx, payment.ParsePlatform(req.Platform), req.Config)
 	var paymentMethod = &paymentModel.Payment{
-		Name:        req.Name,
-		Platform:    req.Platform,
-		Icon:        req.Icon,
-		Domain:      req.Domain,
-		Description: req.Description,
-		Config:      config,
-		FeeMode:     req.FeeMode,
-		FeePercent:  req.FeePercent,
-		FeeAmount:   req.FeeAmount,
-		Enable:      req.Enable,
-		Token:       random.KeyNew(8, 1),
+		Name:            req.Name,
+		Platform:        req.Platform,
+		Icon:            req.Icon,
+		Domain:          req.Domain,
+		Description:     req.Description,
+		Config:          config,
+		FeeMode:         req.FeeMode,
+		FeePercent:      req.FeePercent,
+		FeeAmount:       req.FeeAmount,
+		DiscountPercent: req.DiscountPercent,
+		CloseStrategy:   req.CloseStrategy,
+		HoldMinutes:     req.HoldMinutes,
+		MinRecharge:     req.MinRecharge,
+		MaxRecharge:     req.MaxRecharge,
+		MinAmount:       req.MinAmount,
+		MaxAmount:       req.MaxAmount,
+		AvailableHours:  req.AvailableHours,
+		Enable:          req.Enable,
+		Token:           random.KeyNew(8, 1),
 	}
 	err = l.svcCtx.PaymentModel.Transaction(l.ctx, func(tx *gorm.DB) error {
 		if req.Platform == "Stripe" {
@@ -72,18 +72,26 @@ func (l *GetPaymentMethodListLogic) GetPaymentMethodList(req *types.GetPaymentMe
 			}
 		}
 		resp.List[i] = types.PaymentMethodDetail{
-			Id:          v.Id,
-			Name:        v.Name,
-			Platform:    v.Platform,
-			Icon:        v.Icon,
-			Domain:      v.Domain,
-			Config:      config,
-			FeeMode:     v.FeeMode,
-			FeePercent:  v.FeePercent,
-			FeeAmount:   v.FeeAmount,
-			Enable:      *v.Enable,
-			NotifyURL:   notifyUrl,
-			Description: v.Description,
+			Id:              v.Id,
+			Name:            v.Name,
+			Platform:        v.Platform,
+			Icon:            v.Icon,
+			Domain:          v.Domain,
+			Config:          config,
+			FeeMode:         v.FeeMode,
+			FeePercent:      v.FeePercent,
+			FeeAmount:       v.FeeAmount,
+			DiscountPercent: v.DiscountPercent,
+			CloseStrategy:   v.CloseStrategy,
+			HoldMinutes:     v.HoldMinutes,
+			MinRecharge:     v.MinRecharge,
+			MaxRecharge:     v.MaxRecharge,
+			MinAmount:       v.MinAmount,
+			MaxAmount:       v.MaxAmount,
+			AvailableHours:  v.AvailableHours,
+			Enable:          *v.Enable,
+			NotifyURL:       notifyUrl,
+			Description:     v.Description,
 		}
 	}
 	return
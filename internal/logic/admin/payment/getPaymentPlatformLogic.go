@@ -28,5 +28,8 @@ func (l *GetPaymentPlatformLogic) GetPaymentPlatform() (resp *types.PlatformResp
 	resp = &types.PlatformResponse{
 		List: payment.GetSupportedPlatforms(),
 	}
+	if l.svcCtx.Config.PaymentSandboxEnabled() {
+		resp.List = append(resp.List, payment.GetSandboxPlatform())
+	}
 	return
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	paymentModel "github.com/perfect-panel/server/internal/model/payment"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -33,6 +34,19 @@ func (l *UpdatePaymentMethodLogic) UpdatePaymentMethod(req *types.UpdatePaymentM
 		l.Errorw("unsupported payment platform", logger.Field("mark", req.Platform))
 		return nil, errors.Wrapf(xerr.NewErrCodeMsg(400, "UNSUPPORTED_PAYMENT_PLATFORM"), "unsupported payment platform: %s", req.Platform)
 	}
+	if payment.ParsePlatform(req.Platform) == payment.Test && !l.svcCtx.Config.PaymentSandboxEnabled() {
+		l.Errorw("payment sandbox is disabled", logger.Field("mark", req.Platform))
+		return nil, errors.Wrapf(xerr.NewErrCodeMsg(400, "UNSUPPORTED_PAYMENT_PLATFORM"), "payment sandbox is disabled: %s", req.Platform)
+	}
+	if req.MinRecharge > 0 && req.MaxRecharge > 0 && req.MinRecharge > req.MaxRecharge {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min recharge %d exceeds max recharge %d", req.MinRecharge, req.MaxRecharge)
+	}
+	if req.MinAmount > 0 && req.MaxAmount > 0 && req.MinAmount > req.MaxAmount {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min amount %d exceeds max amount %d", req.MinAmount, req.MaxAmount)
+	}
+	if _, err = paymentModel.ParseHours(req.AvailableHours); err != nil {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "invalid available hours %q: %v", req.AvailableHours, err.Error())
+	}
 	method, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Id)
 	if err != nil {
 		l.Errorw("find payment method error", logger.Field("id", req.Id), logger.Field("error", err.Error()))
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/node"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -29,19 +30,31 @@ func NewCreateNodeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Create
 
 func (l *CreateNodeLogic) CreateNode(req *types.CreateNodeRequest) error {
 	data := node.Node{
-		Name:     req.Name,
-		Tags:     tool.StringSliceToString(req.Tags),
-		Enabled:  req.Enabled,
-		Port:     req.Port,
-		Address:  req.Address,
-		ServerId: req.ServerId,
-		Protocol: req.Protocol,
+		Name:        req.Name,
+		Tags:        tool.StringSliceToString(req.Tags),
+		Enabled:     req.Enabled,
+		Port:        req.Port,
+		Address:     req.Address,
+		ServerId:    req.ServerId,
+		Protocol:    req.Protocol,
+		TrafficRate: nodeTrafficRate(req.TrafficRate),
+		Weight:      req.Weight,
 	}
 	err := l.svcCtx.NodeModel.InsertNode(l.ctx, &data)
 	if err != nil {
 		l.Errorw("[CreateNode] Insert Database Error: ", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "[CreateNode] Insert Database Error")
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 
 	return nil
 }
+
+// nodeTrafficRate returns the rate a node is stored with, requests without one keep traffic at its size.
+// Negative rates are rejected by the request validation.
+func nodeTrafficRate(rate float64) float64 {
+	if rate <= 0 {
+		return 1
+	}
+	return rate
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/node"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -36,6 +37,7 @@ func (l *DeleteNodeLogic) DeleteNode(req *types.DeleteNodeRequest) error {
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseDeletedError), "[DeleteNode] Delete Database Error")
 	}
 
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return l.svcCtx.NodeModel.ClearNodeCache(l.ctx, &node.FilterNodeParams{
 		Page:     1,
 		Size:     1000,
@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/node"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -32,6 +33,7 @@ func (l *DeleteServerLogic) DeleteServer(req *types.DeleteServerRequest) error {
 		l.Errorw("[DeleteServer] Delete Server Error: ", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseDeletedError), "[DeleteServer] Delete Server Error")
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return l.svcCtx.NodeModel.ClearNodeCache(l.ctx, &node.FilterNodeParams{
 		Page:     1,
 		Size:     1000,
@@ -43,17 +43,19 @@ func (l *FilterNodeListLogic) FilterNodeList(req *types.FilterNodeListRequest) (
 	list := make([]types.Node, 0)
 	for _, datum := range data {
 		list = append(list, types.Node{
-			Id:        datum.Id,
-			Name:      datum.Name,
-			Tags:      tool.RemoveDuplicateElements(strings.Split(datum.Tags, ",")...),
-			Port:      datum.Port,
-			Address:   datum.Address,
-			ServerId:  datum.ServerId,
-			Protocol:  datum.Protocol,
-			Enabled:   datum.Enabled,
-			Sort:      datum.Sort,
-			CreatedAt: datum.CreatedAt.UnixMilli(),
-			UpdatedAt: datum.UpdatedAt.UnixMilli(),
+			Id:          datum.Id,
+			Name:        datum.Name,
+			Tags:        tool.RemoveDuplicateElements(strings.Split(datum.Tags, ",")...),
+			Port:        datum.Port,
+			Address:     datum.Address,
+			ServerId:    datum.ServerId,
+			Protocol:    datum.Protocol,
+			TrafficRate: datum.Rate(),
+			Weight:      datum.Weight,
+			Enabled:     datum.Enabled,
+			Sort:        datum.Sort,
+			CreatedAt:   datum.CreatedAt.UnixMilli(),
+			UpdatedAt:   datum.UpdatedAt.UnixMilli(),
 		})
 	}
 
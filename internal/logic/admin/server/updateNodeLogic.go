@@ -3,6 +3,7 @@ package server
 import (
 	"context"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/node"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -39,12 +40,15 @@ func (l *UpdateNodeLogic) UpdateNode(req *types.UpdateNodeRequest) error {
 	data.Port = req.Port
 	data.Address = req.Address
 	data.Protocol = req.Protocol
+	data.TrafficRate = nodeTrafficRate(req.TrafficRate)
+	data.Weight = req.Weight
 	data.Enabled = req.Enabled
 	err = l.svcCtx.NodeModel.UpdateNode(l.ctx, data)
 	if err != nil {
 		l.Errorw("[UpdateNode] Update Database Error: ", logger.Field("error", err.Error()))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "[UpdateNode] Update Database Error")
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return l.svcCtx.NodeModel.ClearNodeCache(l.ctx, &node.FilterNodeParams{
 		Page:     1,
 		Size:     1000,
@@ -4,6 +4,7 @@ import (
 	"context"
 	"strings"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/node"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -110,6 +111,7 @@ func (l *UpdateServerLogic) UpdateServer(req *types.UpdateServerRequest) error {
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update server error: %v", err.Error())
 	}
 
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return l.svcCtx.NodeModel.ClearNodeCache(l.ctx, &node.FilterNodeParams{
 		Page:     1,
 		Size:     1000,
@@ -0,0 +1,60 @@
+package subscribe
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type CreateSubscribeBundleLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Create subscribe bundle
+func NewCreateSubscribeBundleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreateSubscribeBundleLogic {
+	return &CreateSubscribeBundleLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *CreateSubscribeBundleLogic) CreateSubscribeBundle(req *types.CreateSubscribeBundleRequest) error {
+	if err := checkBundleSubscribe(l.ctx, l.svcCtx, req.SubscribeId); err != nil {
+		return err
+	}
+	err := l.svcCtx.DB.Model(&subscribe.Bundle{}).Create(&subscribe.Bundle{
+		Name:        req.Name,
+		Description: req.Description,
+		SubscribeId: req.SubscribeId,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Credit:      req.Credit,
+		Sell:        req.Sell,
+		Sort:        req.Sort,
+	}).Error
+	if err != nil {
+		l.Logger.Error("[CreateSubscribeBundleLogic] create subscribe bundle failed: ", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "create subscribe bundle failed: %v", err.Error())
+	}
+	return nil
+}
+
+// checkBundleSubscribe verifies the plan a bundle sells exists.
+func checkBundleSubscribe(ctx context.Context, svcCtx *svc.ServiceContext, subscribeId int64) error {
+	if _, err := svcCtx.SubscribeModel.FindOne(ctx, subscribeId); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "subscribe not exist: %d", subscribeId)
+		}
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+	}
+	return nil
+}
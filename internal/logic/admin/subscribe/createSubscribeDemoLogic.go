@@ -0,0 +1,42 @@
+package subscribe
+
+import (
+	"context"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+type CreateSubscribeDemoLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Create a single-fetch demo subscribe link
+func NewCreateSubscribeDemoLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CreateSubscribeDemoLogic {
+	return &CreateSubscribeDemoLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// CreateSubscribeDemo mints a demo link for showing a working config to a prospect. The link serves the
+// configured demo nodes once and creates no account, order or subscription.
+func (l *CreateSubscribeDemoLogic) CreateSubscribeDemo(host string) (resp *types.CreateSubscribeDemoResponse, err error) {
+	token, expiredAt, err := subscribeLogic.MintDemoToken(l.ctx, l.svcCtx)
+	if err != nil {
+		l.Errorw("[CreateSubscribeDemo] Mint demo token failed", logger.Field("error", err.Error()))
+		return nil, err
+	}
+	link, _ := subscribeLogic.UserSubscribeURL(l.svcCtx, host, token)
+	l.Infow("[CreateSubscribeDemo] Demo link created", logger.Field("expired_at", expiredAt.UnixMilli()))
+	return &types.CreateSubscribeDemoResponse{
+		Token:     token,
+		Url:       link,
+		ExpiredAt: expiredAt.UnixMilli(),
+	}, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/perfect-panel/server/adapter"
 	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -29,35 +30,60 @@ func NewCreateSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *C
 }
 
 func (l *CreateSubscribeLogic) CreateSubscribe(req *types.CreateSubscribeRequest) error {
+	if err := checkQuantityRange(req.MinQuantity, req.DefaultQuantity); err != nil {
+		l.Infow("[CreateSubscribe] Invalid quantity range", logger.Field("min_quantity", req.MinQuantity), logger.Field("default_quantity", req.DefaultQuantity))
+		return err
+	}
+	// the output format depends on the client, so only rendering is checked here
+	if req.SubscribeTemplate != "" {
+		if err := adapter.ValidateTemplate(req.SubscribeTemplate, ""); err != nil {
+			l.Infow("[CreateSubscribe] Invalid subscribe template", logger.Field("error", err.Error()))
+			return errors.Wrapf(xerr.NewErrCodeMsg(xerr.InvalidParams, err.Error()), "invalid template: %v", err.Error())
+		}
+	}
 	discount := ""
 	if len(req.Discount) > 0 {
 		val, _ := json.Marshal(req.Discount)
 		discount = string(val)
 	}
 	sub := &subscribe.Subscribe{
-		Id:                0,
-		Name:              req.Name,
-		Language:          req.Language,
-		Description:       req.Description,
-		UnitPrice:         req.UnitPrice,
-		UnitTime:          req.UnitTime,
-		Discount:          discount,
-		Replacement:       req.Replacement,
-		Inventory:         req.Inventory,
-		Traffic:           req.Traffic,
-		SpeedLimit:        req.SpeedLimit,
-		DeviceLimit:       req.DeviceLimit,
-		Quota:             req.Quota,
-		Nodes:             tool.Int64SliceToString(req.Nodes),
-		NodeTags:          tool.StringSliceToString(req.NodeTags),
-		Show:              req.Show,
-		Sell:              req.Sell,
-		Sort:              0,
-		DeductionRatio:    req.DeductionRatio,
-		AllowDeduction:    req.AllowDeduction,
-		ResetCycle:        req.ResetCycle,
-		RenewalReset:      req.RenewalReset,
-		ShowOriginalPrice: req.ShowOriginalPrice,
+		Id:                  0,
+		Name:                req.Name,
+		Language:            req.Language,
+		Description:         req.Description,
+		UnitPrice:           req.UnitPrice,
+		UnitTime:            req.UnitTime,
+		Discount:            discount,
+		DiscountInterpolate: req.DiscountInterpolate,
+		Replacement:         req.Replacement,
+		TrafficReset:        req.TrafficReset,
+		TrafficTopUp:        req.TrafficTopUp,
+		TopUpPrice:          req.TopUpPrice,
+		Inventory:           req.Inventory,
+		Traffic:             req.Traffic,
+		SpeedLimit:          req.SpeedLimit,
+		DeviceLimit:         req.DeviceLimit,
+		Quota:               req.Quota,
+		Nodes:               tool.Int64SliceToString(req.Nodes),
+		NodeTags:            tool.StringSliceToString(req.NodeTags),
+		StickyNode:          req.StickyNode,
+		DatacenterPolicy:    req.DatacenterPolicy,
+		NodeSort:            req.NodeSort,
+		ExtraRules:          req.ExtraRules,
+		SubscribeTemplate:   req.SubscribeTemplate,
+		Show:                req.Show,
+		Sell:                req.Sell,
+		Sort:                0,
+		DeductionRatio:      req.DeductionRatio,
+		AllowDeduction:      req.AllowDeduction,
+		ResetCycle:          req.ResetCycle,
+		RenewalReset:        req.RenewalReset,
+		MaxRenewalStack:     req.MaxRenewalStack,
+		QuantityMode:        req.QuantityMode,
+		Duration:            req.Duration,
+		MinQuantity:         req.MinQuantity,
+		DefaultQuantity:     req.DefaultQuantity,
+		ShowOriginalPrice:   req.ShowOriginalPrice,
 	}
 	err := l.svcCtx.SubscribeModel.Insert(l.ctx, sub)
 	if err != nil {
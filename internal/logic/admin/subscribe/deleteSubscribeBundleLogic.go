@@ -0,0 +1,37 @@
+package subscribe
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type DeleteSubscribeBundleLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Delete subscribe bundle
+func NewDeleteSubscribeBundleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *DeleteSubscribeBundleLogic {
+	return &DeleteSubscribeBundleLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// DeleteSubscribeBundle removes the bundle from sale, orders keep the credit they were created with.
+func (l *DeleteSubscribeBundleLogic) DeleteSubscribeBundle(req *types.DeleteSubscribeBundleRequest) error {
+	err := l.svcCtx.DB.Model(&subscribe.Bundle{}).Where("id = ?", req.Id).Delete(&subscribe.Bundle{}).Error
+	if err != nil {
+		l.Logger.Error("[DeleteSubscribeBundleLogic] delete subscribe bundle failed: ", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseDeletedError), "delete subscribe bundle failed: %v", err.Error())
+	}
+	return nil
+}
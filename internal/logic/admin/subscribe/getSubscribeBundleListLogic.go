@@ -0,0 +1,44 @@
+package subscribe
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type GetSubscribeBundleListLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Get subscribe bundle list
+func NewGetSubscribeBundleListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetSubscribeBundleListLogic {
+	return &GetSubscribeBundleListLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetSubscribeBundleListLogic) GetSubscribeBundleList() (resp *types.GetSubscribeBundleListResponse, err error) {
+	var list []*subscribe.Bundle
+	var total int64
+	err = l.svcCtx.DB.Model(&subscribe.Bundle{}).Count(&total).Order("sort ASC, id ASC").Find(&list).Error
+	if err != nil {
+		l.Logger.Error("[GetSubscribeBundleListLogic] get subscribe bundle list failed: ", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "get subscribe bundle list failed: %v", err.Error())
+	}
+	bundleList := make([]types.SubscribeBundle, 0)
+	tool.DeepCopy(&bundleList, list)
+	return &types.GetSubscribeBundleListResponse{
+		Total: total,
+		List:  bundleList,
+	}, nil
+}
@@ -0,0 +1,56 @@
+package subscribe
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+)
+
+// backfillAddedNodes queues the backfill of the plan's subscriptions for the nodes the update added to it, by
+// id or by tag. The update itself is not failed when the backfill cannot be queued.
+func (l *UpdateSubscribeLogic) backfillAddedNodes(old, sub *subscribe.Subscribe) {
+	before, err := l.svcCtx.NodeModel.FindPlanNodes(l.ctx, tool.StringToInt64Slice(old.Nodes), tool.StringMergeAndRemoveDuplicates(old.NodeTags))
+	if err != nil {
+		l.Errorw("[UpdateSubscribe] Find plan nodes failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		return
+	}
+	after, err := l.svcCtx.NodeModel.FindPlanNodes(l.ctx, tool.StringToInt64Slice(sub.Nodes), tool.StringMergeAndRemoveDuplicates(sub.NodeTags))
+	if err != nil {
+		l.Errorw("[UpdateSubscribe] Find plan nodes failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		return
+	}
+	known := make(map[int64]bool, len(before))
+	for _, n := range before {
+		known[n.Id] = true
+	}
+	var added []int64
+	for _, n := range after {
+		if !known[n.Id] {
+			added = append(added, n.Id)
+		}
+	}
+	if len(added) == 0 {
+		return
+	}
+	sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+
+	val, _ := json.Marshal(queue.ForthwithSubscribeNodeBackfillPayload{SubscribeId: sub.Id, NodeIds: added})
+	// the same nodes added again while a backfill is still queued are covered by it
+	taskId := fmt.Sprintf("node_backfill:%d:%s", sub.Id, tool.Int64SliceToString(added))
+	_, err = l.svcCtx.Queue.EnqueueContext(l.ctx, asynq.NewTask(queue.ForthwithSubscribeNodeBackfill, val, asynq.MaxRetry(5), asynq.TaskID(taskId)))
+	switch {
+	case err == nil:
+		l.Infow("[UpdateSubscribe] Node backfill queued", logger.Field("subscribe_id", sub.Id), logger.Field("node_ids", added))
+	case errors.Is(err, asynq.ErrTaskIDConflict):
+		l.Infow("[UpdateSubscribe] Node backfill already queued", logger.Field("subscribe_id", sub.Id), logger.Field("node_ids", added))
+	default:
+		l.Errorw("[UpdateSubscribe] Enqueue node backfill failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id), logger.Field("node_ids", added))
+	}
+}
@@ -0,0 +1,22 @@
+package subscribe
+
+import (
+	orderLogic "github.com/perfect-panel/server/internal/logic/public/order"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// checkQuantityRange rejects a minimum no order could meet and a default outside the quantities an order may have.
+// 0 leaves either unset.
+func checkQuantityRange(minQuantity, defaultQuantity int64) error {
+	if minQuantity > orderLogic.MaxQuantity {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min quantity %d exceeds the maximum of %d", minQuantity, orderLogic.MaxQuantity)
+	}
+	if defaultQuantity == 0 {
+		return nil
+	}
+	if defaultQuantity < minQuantity || defaultQuantity > orderLogic.MaxQuantity {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "default quantity %d is outside %d to %d", defaultQuantity, max(minQuantity, 1), orderLogic.MaxQuantity)
+	}
+	return nil
+}
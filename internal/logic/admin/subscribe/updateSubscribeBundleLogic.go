@@ -0,0 +1,49 @@
+package subscribe
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type UpdateSubscribeBundleLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Update subscribe bundle
+func NewUpdateSubscribeBundleLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateSubscribeBundleLogic {
+	return &UpdateSubscribeBundleLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdateSubscribeBundleLogic) UpdateSubscribeBundle(req *types.UpdateSubscribeBundleRequest) error {
+	if err := checkBundleSubscribe(l.ctx, l.svcCtx, req.SubscribeId); err != nil {
+		return err
+	}
+	err := l.svcCtx.DB.Model(&subscribe.Bundle{}).Where("id = ?", req.Id).Save(&subscribe.Bundle{
+		Id:          req.Id,
+		Name:        req.Name,
+		Description: req.Description,
+		SubscribeId: req.SubscribeId,
+		Quantity:    req.Quantity,
+		Price:       req.Price,
+		Credit:      req.Credit,
+		Sell:        req.Sell,
+		Sort:        req.Sort,
+	}).Error
+	if err != nil {
+		l.Logger.Error("[UpdateSubscribeBundle] update subscribe bundle failed", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update subscribe bundle failed: %v", err.Error())
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/perfect-panel/server/pkg/device"
 
+	"github.com/perfect-panel/server/adapter"
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -32,46 +34,73 @@ func NewUpdateSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *U
 
 func (l *UpdateSubscribeLogic) UpdateSubscribe(req *types.UpdateSubscribeRequest) error {
 	// Query the database to get the subscribe information
-	_, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.Id)
+	old, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.Id)
 	if err != nil {
 		l.Logger.Error("[UpdateSubscribe] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.Id))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "get subscribe error: %v", err.Error())
 	}
+	if err := checkQuantityRange(req.MinQuantity, req.DefaultQuantity); err != nil {
+		l.Infow("[UpdateSubscribe] Invalid quantity range", logger.Field("min_quantity", req.MinQuantity), logger.Field("default_quantity", req.DefaultQuantity))
+		return err
+	}
+	// the output format depends on the client, so only rendering is checked here
+	if req.SubscribeTemplate != "" {
+		if err := adapter.ValidateTemplate(req.SubscribeTemplate, ""); err != nil {
+			l.Infow("[UpdateSubscribe] Invalid subscribe template", logger.Field("error", err.Error()))
+			return errors.Wrapf(xerr.NewErrCodeMsg(xerr.InvalidParams, err.Error()), "invalid template: %v", err.Error())
+		}
+	}
 	discount := ""
 	if len(req.Discount) > 0 {
 		val, _ := json.Marshal(req.Discount)
 		discount = string(val)
 	}
 	sub := &subscribe.Subscribe{
-		Id:                req.Id,
-		Name:              req.Name,
-		Language:          req.Language,
-		Description:       req.Description,
-		UnitPrice:         req.UnitPrice,
-		UnitTime:          req.UnitTime,
-		Discount:          discount,
-		Replacement:       req.Replacement,
-		Inventory:         req.Inventory,
-		Traffic:           req.Traffic,
-		SpeedLimit:        req.SpeedLimit,
-		DeviceLimit:       req.DeviceLimit,
-		Quota:             req.Quota,
-		Nodes:             tool.Int64SliceToString(req.Nodes),
-		NodeTags:          tool.StringSliceToString(req.NodeTags),
-		Show:              req.Show,
-		Sell:              req.Sell,
-		Sort:              req.Sort,
-		DeductionRatio:    req.DeductionRatio,
-		AllowDeduction:    req.AllowDeduction,
-		ResetCycle:        req.ResetCycle,
-		RenewalReset:      req.RenewalReset,
-		ShowOriginalPrice: req.ShowOriginalPrice,
+		Id:                  req.Id,
+		Name:                req.Name,
+		Language:            req.Language,
+		Description:         req.Description,
+		UnitPrice:           req.UnitPrice,
+		UnitTime:            req.UnitTime,
+		Discount:            discount,
+		DiscountInterpolate: req.DiscountInterpolate,
+		Replacement:         req.Replacement,
+		TrafficReset:        req.TrafficReset,
+		TrafficTopUp:        req.TrafficTopUp,
+		TopUpPrice:          req.TopUpPrice,
+		Inventory:           req.Inventory,
+		Traffic:             req.Traffic,
+		SpeedLimit:          req.SpeedLimit,
+		DeviceLimit:         req.DeviceLimit,
+		Quota:               req.Quota,
+		Nodes:               tool.Int64SliceToString(req.Nodes),
+		NodeTags:            tool.StringSliceToString(req.NodeTags),
+		StickyNode:          req.StickyNode,
+		DatacenterPolicy:    req.DatacenterPolicy,
+		NodeSort:            req.NodeSort,
+		ExtraRules:          req.ExtraRules,
+		SubscribeTemplate:   req.SubscribeTemplate,
+		Show:                req.Show,
+		Sell:                req.Sell,
+		Sort:                req.Sort,
+		DeductionRatio:      req.DeductionRatio,
+		AllowDeduction:      req.AllowDeduction,
+		ResetCycle:          req.ResetCycle,
+		RenewalReset:        req.RenewalReset,
+		MaxRenewalStack:     req.MaxRenewalStack,
+		QuantityMode:        req.QuantityMode,
+		Duration:            req.Duration,
+		MinQuantity:         req.MinQuantity,
+		DefaultQuantity:     req.DefaultQuantity,
+		ShowOriginalPrice:   req.ShowOriginalPrice,
 	}
 	err = l.svcCtx.SubscribeModel.Update(l.ctx, sub)
 	if err != nil {
 		l.Logger.Error("[UpdateSubscribe] update subscribe failed", logger.Field("error", err.Error()), logger.Field("subscribe", sub))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update subscribe error: %v", err.Error())
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	l.svcCtx.DeviceManager.Broadcast(device.SubscribeUpdate)
+	l.backfillAddedNodes(old, sub)
 	return nil
 }
@@ -0,0 +1,39 @@
+package system
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type GetMaintenanceConfigLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewGetMaintenanceConfigLogic Get subscribe maintenance mode
+func NewGetMaintenanceConfigLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetMaintenanceConfigLogic {
+	return &GetMaintenanceConfigLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetMaintenanceConfigLogic) GetMaintenanceConfig() (resp *types.MaintenanceConfig, err error) {
+	resp = &types.MaintenanceConfig{}
+	// the maintenance mode is stored with the subscribe config
+	subscribeConfigs, err := l.svcCtx.SystemModel.GetSubscribeConfig(l.ctx)
+	if err != nil {
+		l.Errorw("[GetMaintenanceConfig] Database query error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "get maintenance config failed: %v", err.Error())
+	}
+	tool.SystemConfigSliceReflectToStruct(subscribeConfigs, resp)
+	return resp, nil
+}
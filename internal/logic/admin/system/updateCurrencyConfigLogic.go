@@ -33,6 +33,9 @@ func NewUpdateCurrencyConfigLogic(ctx context.Context, svcCtx *svc.ServiceContex
 }
 
 func (l *UpdateCurrencyConfigLogic) UpdateCurrencyConfig(req *types.CurrencyConfig) error {
+	if req.MinRecharge > 0 && req.MaxRecharge > 0 && req.MinRecharge > req.MaxRecharge {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "min recharge %d exceeds max recharge %d", req.MinRecharge, req.MaxRecharge)
+	}
 	v := reflect.ValueOf(*req)
 	// Get the reflection type of the structure
 	t := v.Type()
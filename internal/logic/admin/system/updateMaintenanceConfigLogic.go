@@ -0,0 +1,64 @@
+package system
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/perfect-panel/server/initialize"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/system"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type UpdateMaintenanceConfigLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewUpdateMaintenanceConfigLogic Toggle subscribe maintenance mode
+func NewUpdateMaintenanceConfigLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateMaintenanceConfigLogic {
+	return &UpdateMaintenanceConfigLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// UpdateMaintenanceConfig switches the maintenance mode without a restart, the subscribe config is reloaded in place.
+func (l *UpdateMaintenanceConfigLogic) UpdateMaintenanceConfig(req *types.MaintenanceConfig) error {
+	values := map[string]string{
+		"Maintenance":       "false",
+		"MaintenanceNotice": req.MaintenanceNotice,
+	}
+	if req.Maintenance {
+		values["Maintenance"] = "true"
+	}
+	err := l.svcCtx.SystemModel.Transaction(l.ctx, func(db *gorm.DB) error {
+		for key, value := range values {
+			if err := db.Model(&system.System{}).Where("`category` = 'subscribe' and `key` = ?", key).Update("value", value).Error; err != nil {
+				return err
+			}
+		}
+		if err := log.CreateAdminAudit(db, auditActor(l.ctx), &log.AdminAudit{
+			Action: log.AdminAuditMaintenance,
+			Remark: fmt.Sprintf("maintenance %s: %s", values["Maintenance"], req.MaintenanceNotice),
+		}); err != nil {
+			return err
+		}
+		return l.svcCtx.Redis.Del(l.ctx, config.SubscribeConfigKey, config.GlobalConfigKey).Err()
+	})
+	if err != nil {
+		l.Errorw("[UpdateMaintenanceConfig] update maintenance config error", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update maintenance config error: %v", err.Error())
+	}
+	initialize.Subscribe(l.svcCtx)
+	l.Infow("[UpdateMaintenanceConfig] maintenance mode updated", logger.Field("maintenance", req.Maintenance))
+	return nil
+}
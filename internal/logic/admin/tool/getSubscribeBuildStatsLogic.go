@@ -0,0 +1,35 @@
+package tool
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+type GetSubscribeBuildStatsLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewGetSubscribeBuildStatsLogic Get Subscribe Build Stats
+func NewGetSubscribeBuildStatsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetSubscribeBuildStatsLogic {
+	return &GetSubscribeBuildStatsLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GetSubscribeBuildStats reports the subscribe config builds in flight on this instance against the configured limit.
+func (l *GetSubscribeBuildStatsLogic) GetSubscribeBuildStats() (resp *types.SubscribeBuildStatsResponse, err error) {
+	inFlight, rejected := subscribe.BuildStats()
+	return &types.SubscribeBuildStatsResponse{
+		InFlight: inFlight,
+		Limit:    l.svcCtx.Config.Subscribe.BuildConcurrency,
+		Rejected: rejected,
+	}, nil
+}
@@ -0,0 +1,16 @@
+package user
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/constant"
+)
+
+// auditActor returns the id of the admin performing the request, recorded in the admin audit log
+func auditActor(ctx context.Context) int64 {
+	if u, ok := ctx.Value(constant.CtxKeyUser).(*user.User); ok {
+		return u.Id
+	}
+	return 0
+}
@@ -0,0 +1,243 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+const (
+	// ExtendFromExpiry an expired subscription is extended from its expire time, it may stay expired
+	ExtendFromExpiry = "expiry"
+	// ExtendFromNow an expired subscription is extended from now and becomes active again
+	ExtendFromNow = "now"
+
+	// compensateBatchSize subscriptions extended in one transaction
+	compensateBatchSize = 200
+)
+
+type CompensateUserSubscribeLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Extend the expiry of matching user subscribes
+func NewCompensateUserSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CompensateUserSubscribeLogic {
+	return &CompensateUserSubscribeLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// CompensateUserSubscribe extends the user subscriptions matching the filter by the duration in seconds, e.g.
+// after an outage. Pending, active, expired and paused subscriptions match, unlimited ones and those out of
+// traffic do not. A paused subscription gets the duration added to the time it has left. With DryRun only
+// the matching subscriptions are counted.
+func (l *CompensateUserSubscribeLogic) CompensateUserSubscribe(req *types.CompensateUserSubscribeRequest) (resp *types.CompensateUserSubscribeResponse, err error) {
+	if req.ExpireFrom > 0 && req.ExpireTo > 0 && req.ExpireFrom > req.ExpireTo {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "expire from %d is after expire to %d", req.ExpireFrom, req.ExpireTo)
+	}
+	policy := req.ExpiredPolicy
+	if policy == "" {
+		policy = ExtendFromExpiry
+	}
+	filter, err := l.filter(req)
+	if err != nil {
+		l.Errorw("[CompensateUserSubscribe] Find tagged subscribes error", logger.Field("error", err.Error()), logger.Field("tag", req.Tag))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find tagged subscribes error: %v", err.Error())
+	}
+
+	resp = &types.CompensateUserSubscribeResponse{}
+	if err = filter(l.svcCtx.DB.WithContext(l.ctx)).Count(&resp.Matched).Error; err != nil {
+		l.Errorw("[CompensateUserSubscribe] Count user subscribes error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "count user subscribes error: %v", err.Error())
+	}
+	if req.DryRun || resp.Matched == 0 {
+		return resp, nil
+	}
+
+	now := time.Now()
+	actor := auditActor(l.ctx)
+	duration := time.Duration(req.Duration) * time.Second
+	reactivated := make(map[int64]struct{})
+	var lastId int64
+	for {
+		var list []*user.Subscribe
+		err = filter(l.svcCtx.DB.WithContext(l.ctx)).Where("id > ?", lastId).Order("id ASC").Limit(compensateBatchSize).Find(&list).Error
+		if err != nil {
+			l.Errorw("[CompensateUserSubscribe] Find user subscribes error", logger.Field("error", err.Error()), logger.Field("last_id", lastId))
+			return resp, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribes error: %v", err.Error())
+		}
+		if len(list) == 0 {
+			break
+		}
+		lastId = list[len(list)-1].Id
+
+		var updated, activated []*user.Subscribe
+		err = l.svcCtx.DB.WithContext(l.ctx).Transaction(func(tx *gorm.DB) error {
+			updated, activated = updated[:0], activated[:0]
+			for _, sub := range list {
+				before, status, pausedFor := sub.ExpireTime, sub.Status, sub.PausedFor
+				fields := extendSubscribe(sub, duration, policy, now)
+				// skip a subscription that changed since it was read
+				result := tx.Model(&user.Subscribe{}).
+					Where("id = ? AND status = ? AND expire_time = ? AND paused_for = ?", sub.Id, status, before, pausedFor).
+					Updates(fields)
+				if result.Error != nil {
+					return result.Error
+				}
+				if result.RowsAffected == 0 {
+					l.Infow("[CompensateUserSubscribe] User subscribe changed meanwhile, skipped", logger.Field("user_subscribe_id", sub.Id))
+					continue
+				}
+				content, _ := (&log.SubscribeExtend{
+					UserSubscribeId: sub.Id,
+					Duration:        req.Duration,
+					ExpireBefore:    before.UnixMilli(),
+					ExpireAfter:     fields["expire_time"].(time.Time).UnixMilli(),
+					Reason:          req.Reason,
+					Operator:        actor,
+					Timestamp:       now.UnixMilli(),
+				}).Marshal()
+				if err := tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+					Type:     log.TypeSubscribeExtend.Uint8(),
+					Date:     log.Date(now),
+					ObjectID: sub.UserId,
+					Content:  string(content),
+				}).Error; err != nil {
+					return err
+				}
+				if err := log.CreateAdminAudit(tx, actor, &log.AdminAudit{
+					Action:          log.AdminAuditSubscribeExtend,
+					UserId:          sub.UserId,
+					UserSubscribeId: sub.Id,
+					StatusBefore:    status,
+					StatusAfter:     sub.Status,
+					Remark:          req.Reason,
+				}); err != nil {
+					return err
+				}
+				if sub.Status != status {
+					activated = append(activated, sub)
+				}
+				updated = append(updated, sub)
+			}
+			return nil
+		})
+		if err != nil {
+			l.Errorw("[CompensateUserSubscribe] Extend user subscribes error",
+				logger.Field("error", err.Error()),
+				logger.Field("first_id", list[0].Id),
+				logger.Field("last_id", lastId),
+			)
+			resp.Failed += int64(len(list))
+			continue
+		}
+		resp.Updated += int64(len(updated))
+		for _, sub := range activated {
+			reactivated[sub.SubscribeId] = struct{}{}
+		}
+		if len(updated) > 0 {
+			if err = l.svcCtx.UserModel.ClearSubscribeCache(l.ctx, updated...); err != nil {
+				l.Errorw("[CompensateUserSubscribe] Clear user subscribe cache error", logger.Field("error", err.Error()), logger.Field("last_id", lastId))
+			}
+		}
+		if len(list) < compensateBatchSize {
+			break
+		}
+	}
+	// reactivated subscriptions join the user lists of the nodes again
+	for id := range reactivated {
+		if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, id); err != nil {
+			l.Errorw("[CompensateUserSubscribe] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribe_id", id))
+		}
+	}
+	l.Infow("[CompensateUserSubscribe] User subscribes extended",
+		logger.Field("admin", actor),
+		logger.Field("duration", req.Duration),
+		logger.Field("policy", policy),
+		logger.Field("reason", req.Reason),
+		logger.Field("matched", resp.Matched),
+		logger.Field("updated", resp.Updated),
+		logger.Field("failed", resp.Failed),
+	)
+	if resp.Updated > 0 {
+		subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	}
+	return resp, nil
+}
+
+// filter returns the conditions selecting the user subscriptions to extend
+func (l *CompensateUserSubscribeLogic) filter(req *types.CompensateUserSubscribeRequest) (func(db *gorm.DB) *gorm.DB, error) {
+	subscribeIds := req.SubscribeIds
+	var tagged []int64
+	if req.Tag != "" {
+		err := l.svcCtx.DB.WithContext(l.ctx).Model(&subscribe.Subscribe{}).
+			Scopes(subscribe.InSet("node_tags", []string{req.Tag})).Pluck("id", &tagged).Error
+		if err != nil {
+			return nil, err
+		}
+		// no plan serves the tag, nothing matches
+		if len(tagged) == 0 {
+			tagged = []int64{0}
+		}
+	}
+	return func(db *gorm.DB) *gorm.DB {
+		db = db.Model(&user.Subscribe{}).
+			Where("status IN ? AND expire_time > ?", []uint8{0, 1, 3, user.SubscribeStatusPaused}, time.UnixMilli(0))
+		if len(subscribeIds) > 0 {
+			db = db.Where("subscribe_id IN ?", subscribeIds)
+		}
+		if len(tagged) > 0 {
+			db = db.Where("subscribe_id IN ?", tagged)
+		}
+		if req.ExpireFrom > 0 {
+			db = db.Where("expire_time >= ?", time.UnixMilli(req.ExpireFrom))
+		}
+		if req.ExpireTo > 0 {
+			db = db.Where("expire_time <= ?", time.UnixMilli(req.ExpireTo))
+		}
+		return db
+	}, nil
+}
+
+// extendSubscribe extends the subscription by the duration and returns the changed columns. An expired
+// subscription is extended from now or from its expire time as the policy says and becomes active when
+// the new expire time is still ahead.
+func extendSubscribe(sub *user.Subscribe, duration time.Duration, policy string, now time.Time) map[string]interface{} {
+	if sub.Status == user.SubscribeStatusPaused {
+		sub.PausedFor += int64(duration.Seconds())
+		return map[string]interface{}{
+			"paused_for":  sub.PausedFor,
+			"expire_time": sub.ExpireTime,
+		}
+	}
+	base := sub.ExpireTime
+	expired := sub.Status == 3 || !base.After(now)
+	if expired && policy == ExtendFromNow {
+		base = now
+	}
+	sub.ExpireTime = base.Add(duration)
+	fields := map[string]interface{}{
+		"expire_time": sub.ExpireTime,
+	}
+	if sub.Status == 3 && sub.ExpireTime.After(now) {
+		sub.Status = 1
+		sub.FinishedAt = nil
+		fields["status"] = sub.Status
+		fields["finished_at"] = nil
+	}
+	return fields
+}
@@ -32,5 +32,8 @@ func (l *GetUserDetailLogic) GetUserDetail(req *types.GetDetailRequest) (*types.
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "get user detail error: %v", err.Error())
 	}
 	tool.DeepCopy(&resp, userInfo)
+	if userInfo.PromoCreditExpiredAt != nil {
+		resp.PromoCreditExpiredAt = userInfo.PromoCreditExpiredAt.UnixMilli()
+	}
 	return &resp, nil
 }
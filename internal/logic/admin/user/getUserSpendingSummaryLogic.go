@@ -0,0 +1,40 @@
+package user
+
+import (
+	"context"
+
+	userLogic "github.com/perfect-panel/server/internal/logic/public/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type GetUserSpendingSummaryLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Get user spending summary
+func NewGetUserSpendingSummaryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetUserSpendingSummaryLogic {
+	return &GetUserSpendingSummaryLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetUserSpendingSummaryLogic) GetUserSpendingSummary(req *types.GetDetailRequest) (resp *types.UserSpendingSummary, err error) {
+	userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, req.Id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserNotExist), "user %d not found", req.Id)
+		}
+		l.Errorw("[GetUserSpendingSummary] Find user error", logger.Field("error", err.Error()), logger.Field("user_id", req.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user error: %v", err.Error())
+	}
+	return userLogic.SpendingSummary(l.ctx, l.svcCtx, userInfo)
+}
@@ -34,5 +34,11 @@ func (l *GetUserSubscribeByIdLogic) GetUserSubscribeById(req *types.GetUserSubsc
 	}
 	var subscribeDetails types.UserSubscribeDetail
 	tool.DeepCopy(&subscribeDetails, sub)
+	subscribeDetails.ExcludeNodes = tool.StringToInt64Slice(sub.ExcludeNodes)
+	subscribeDetails.IncludeNodes = tool.StringToInt64Slice(sub.IncludeNodes)
+	subscribeDetails.BonusNodes = tool.StringToInt64Slice(sub.BonusNodes)
+	if sub.BonusExpire != nil {
+		subscribeDetails.BonusExpire = sub.BonusExpire.UnixMilli()
+	}
 	return &subscribeDetails, nil
 }
@@ -0,0 +1,81 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type GrantUserSubscribeBonusLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewGrantUserSubscribeBonusLogic Grant user subcribe bonus nodes
+func NewGrantUserSubscribeBonusLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GrantUserSubscribeBonusLogic {
+	return &GrantUserSubscribeBonusLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// GrantUserSubscribeBonus adds nodes to the user subscribe until the expire time. A bonus granted while an
+// earlier one is still running adds its nodes to it and both last until the later expire time.
+func (l *GrantUserSubscribeBonusLogic) GrantUserSubscribeBonus(req *types.GrantUserSubscribeBonusRequest) error {
+	now := time.Now()
+	expire := time.UnixMilli(req.ExpireTime)
+	if !expire.After(now) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "bonus expire time %d is in the past", req.ExpireTime)
+	}
+	userSub, err := l.svcCtx.UserModel.FindOneSubscribe(l.ctx, req.UserSubscribeId)
+	if err != nil {
+		l.Errorw("FindOneUserSubscribe failed:", logger.Field("error", err.Error()), logger.Field("userSubscribeId", req.UserSubscribeId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOneUserSubscribe failed: %v", err.Error())
+	}
+
+	nodeIds := req.NodeIds
+	if active := userSub.ActiveBonusNodes(now); len(active) > 0 {
+		nodeIds = append(active, nodeIds...)
+		if userSub.BonusExpire.After(expire) {
+			expire = *userSub.BonusExpire
+		}
+	}
+	userSub.BonusNodes = tool.Int64SliceToString(tool.RemoveDuplicateElements(nodeIds...))
+	userSub.BonusExpire = &expire
+
+	err = l.svcCtx.DB.WithContext(l.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), &log.AdminAudit{
+			Action:          log.AdminAuditSubscribeBonus,
+			UserId:          userSub.UserId,
+			UserSubscribeId: userSub.Id,
+			Remark:          fmt.Sprintf("nodes %s until %s", userSub.BonusNodes, expire.In(log.Location()).Format(time.DateTime)),
+		})
+	})
+	if err != nil {
+		l.Errorw("UpdateSubscribe failed:", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "UpdateSubscribe failed: %v", err.Error())
+	}
+	if err = l.svcCtx.UserModel.ClearSubscribeCache(l.ctx, userSub); err != nil {
+		l.Errorw("ClearSubscribeCache failed:", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "ClearSubscribeCache failed: %v", err.Error())
+	}
+	l.Infow("[GrantUserSubscribeBonus] bonus nodes granted", logger.Field("userSubscribeId", userSub.Id),
+		logger.Field("nodes", userSub.BonusNodes), logger.Field("expire", expire))
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	return nil
+}
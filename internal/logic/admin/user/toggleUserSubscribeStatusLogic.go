@@ -3,11 +3,14 @@ package user
 import (
 	"context"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
 	"github.com/perfect-panel/server/pkg/xerr"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
 type ToggleUserSubscribeStatusLogic struct {
@@ -32,6 +35,7 @@ func (l *ToggleUserSubscribeStatusLogic) ToggleUserSubscribeStatus(req *types.To
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), " FindOneSubscribe error: %v", err.Error())
 	}
 
+	before := userSub.Status
 	switch userSub.Status {
 	case 2: // active
 		userSub.Status = 5 // set status to stopped
@@ -42,7 +46,18 @@ func (l *ToggleUserSubscribeStatusLogic) ToggleUserSubscribeStatus(req *types.To
 		return errors.Wrapf(xerr.NewErrCodeMsg(xerr.ERROR, "invalid subscribe status"), "invalid user subscribe status: %d", userSub.Status)
 	}
 
-	err = l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub)
+	err = l.svcCtx.DB.WithContext(l.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), &log.AdminAudit{
+			Action:          log.AdminAuditSubscribeStatus,
+			UserId:          userSub.UserId,
+			UserSubscribeId: userSub.Id,
+			StatusBefore:    before,
+			StatusAfter:     userSub.Status,
+		})
+	})
 	if err != nil {
 		l.Errorw("UpdateSubscribe error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", req.UserSubscribeId))
 		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), " UpdateSubscribe error: %v", err.Error())
@@ -59,5 +74,6 @@ func (l *ToggleUserSubscribeStatusLogic) ToggleUserSubscribeStatus(req *types.To
 		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "failed to clear subscribe cache: %v", err.Error())
 	}
 
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return nil
 }
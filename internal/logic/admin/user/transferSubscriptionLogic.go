@@ -0,0 +1,120 @@
+package user
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/uuidx"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type TransferSubscriptionLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewTransferSubscriptionLogic Transfer user subscribe to another user
+func NewTransferSubscriptionLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TransferSubscriptionLogic {
+	return &TransferSubscriptionLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// TransferSubscription moves a user subscription to another user, e.g. when accounts are merged or a
+// subscription is gifted. The token and UUID are rotated so the clients of the previous owner stop
+// working, the orders of the subscription follow the new token and keep the user who paid for them.
+func (l *TransferSubscriptionLogic) TransferSubscription(req *types.TransferSubscriptionRequest) error {
+	userSub, err := l.svcCtx.UserModel.FindOneSubscribe(l.ctx, req.UserSubscribeId)
+	if err != nil {
+		l.Errorw("[TransferSubscription] FindOneSubscribe error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", req.UserSubscribeId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOneSubscribe error: %v", err.Error())
+	}
+	if userSub.UserId == req.UserId {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "subscription already belongs to user %d", req.UserId)
+	}
+	if !req.Force {
+		if isExpired(userSub) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeExpired), "subscription %d is expired", userSub.Id)
+		}
+		if isExhausted(userSub) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeTrafficExhausted), "subscription %d has no traffic left", userSub.Id)
+		}
+	}
+
+	target, err := l.svcCtx.UserModel.FindOne(l.ctx, req.UserId)
+	if err != nil {
+		l.Errorw("[TransferSubscription] FindOne error", logger.Field("error", err.Error()), logger.Field("userId", req.UserId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.UserNotExist), "FindOne error: %v", err.Error())
+	}
+	if l.svcCtx.Config.Subscribe.SingleModel {
+		subs, err := l.svcCtx.UserModel.QueryUserSubscribe(l.ctx, target.Id)
+		if err != nil {
+			l.Errorw("[TransferSubscription] QueryUserSubscribe error", logger.Field("error", err.Error()), logger.Field("userId", target.Id))
+			return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "QueryUserSubscribe error: %v", err.Error())
+		}
+		if len(subs) >= 1 {
+			return errors.Wrapf(xerr.NewErrCode(xerr.SingleSubscribeModeExceedsLimit), "Single subscribe mode exceeds limit")
+		}
+	}
+
+	var remark string
+	if req.Force && (isExpired(userSub) || isExhausted(userSub)) {
+		remark = "forced transfer of an expired or exhausted subscription"
+	}
+	sourceUserId, oldToken := userSub.UserId, userSub.Token
+	userSub.UserId = target.Id
+	userSub.Token = uuidx.SubscribeToken(fmt.Sprintf("AdminTransfer:%d:%d", userSub.Id, time.Now().UnixMilli()))
+	userSub.UUID = uuidx.NewUUID().String()
+
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		if err := l.svcCtx.OrderModel.RelinkSubscribeToken(l.ctx, oldToken, userSub.Token, tx); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), &log.AdminAudit{
+			Action:          log.AdminAuditSubscribeTransfer,
+			UserId:          sourceUserId,
+			TargetUserId:    target.Id,
+			UserSubscribeId: userSub.Id,
+			Remark:          remark,
+		})
+	})
+	if err != nil {
+		l.Errorw("[TransferSubscription] transfer error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "transfer subscription error: %v", err.Error())
+	}
+	// Clear the subscribe cache, the node user lists now carry the new UUID
+	if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, userSub.SubscribeId); err != nil {
+		l.Errorw("failed to clear subscribe cache", logger.Field("error", err.Error()), logger.Field("subscribeId", userSub.SubscribeId))
+	}
+	l.Infow("[TransferSubscription] subscription transferred",
+		logger.Field("userSubscribeId", userSub.Id),
+		logger.Field("from", sourceUserId),
+		logger.Field("to", target.Id))
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	return nil
+}
+
+// isExpired reports whether the subscription has run out of time, a zero expire time never expires
+func isExpired(userSub *user.Subscribe) bool {
+	return userSub.ExpireTime.Unix() != 0 && userSub.ExpireTime.Before(time.Now())
+}
+
+// isExhausted reports whether the subscription used up its traffic, a zero traffic is unlimited
+func isExhausted(userSub *user.Subscribe) bool {
+	return userSub.Traffic > 0 && userSub.Download+userSub.Upload >= userSub.Traffic
+}
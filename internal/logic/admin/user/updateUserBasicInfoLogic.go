@@ -56,7 +56,7 @@ func (l *UpdateUserBasicInfoLogic) UpdateUserBasicInfo(req *types.UpdateUserBasi
 
 		err = l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 			Type:     log.TypeBalance.Uint8(),
-			Date:     time.Now().Format(time.DateOnly),
+			Date:     log.Date(time.Now()),
 			ObjectID: userInfo.Id,
 			Content:  string(content),
 		})
@@ -87,7 +87,7 @@ func (l *UpdateUserBasicInfoLogic) UpdateUserBasicInfo(req *types.UpdateUserBasi
 			// Add gift amount change log
 			err = l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
+				Date:     log.Date(time.Now()),
 				ObjectID: userInfo.Id,
 				Content:  string(content),
 			})
@@ -99,6 +99,40 @@ func (l *UpdateUserBasicInfoLogic) UpdateUserBasicInfo(req *types.UpdateUserBasi
 		}
 	}
 
+	if userInfo.PromoCredit != req.PromoCredit {
+		changeType := log.GiftTypeIncrease
+		if req.PromoCredit < userInfo.PromoCredit {
+			changeType = log.GiftTypeReduce
+		}
+		giftLog := log.Gift{
+			Type:      changeType,
+			Amount:    req.PromoCredit - userInfo.PromoCredit,
+			Balance:   req.PromoCredit,
+			Bucket:    log.GiftBucketPromo,
+			Remark:    "Admin adjustment",
+			Timestamp: time.Now().UnixMilli(),
+		}
+		content, _ := giftLog.Marshal()
+		// Add promo credit change log
+		err = l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
+			Type:     log.TypeGift.Uint8(),
+			Date:     log.Date(time.Now()),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		})
+		if err != nil {
+			l.Errorw("[UpdateUserBasicInfoLogic] Insert Promo Credit Log Error:", logger.Field("err", err.Error()), logger.Field("userId", req.UserId))
+			return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "Insert Promo Credit Log Error")
+		}
+		userInfo.PromoCredit = req.PromoCredit
+	}
+	// a promo credit without expiry time never expires
+	userInfo.PromoCreditExpiredAt = nil
+	if req.PromoCreditExpiredAt > 0 {
+		expiredAt := time.UnixMilli(req.PromoCreditExpiredAt)
+		userInfo.PromoCreditExpiredAt = &expiredAt
+	}
+
 	if req.Commission != userInfo.Commission {
 
 		commentLog := log.Commission{
@@ -110,7 +144,7 @@ func (l *UpdateUserBasicInfoLogic) UpdateUserBasicInfo(req *types.UpdateUserBasi
 		content, _ := commentLog.Marshal()
 		err = l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 			Type:     log.TypeCommission.Uint8(),
-			Date:     time.Now().Format(time.DateOnly),
+			Date:     log.Date(time.Now()),
 			ObjectID: userInfo.Id,
 			Content:  string(content),
 		})
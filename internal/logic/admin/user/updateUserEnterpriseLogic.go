@@ -0,0 +1,59 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type UpdateUserEnterpriseLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewUpdateUserEnterpriseLogic Update user enterprise account
+func NewUpdateUserEnterpriseLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateUserEnterpriseLogic {
+	return &UpdateUserEnterpriseLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// UpdateUserEnterprise flags the account for invoice payment and sets its credit limit. The outstanding
+// balance is kept, a limit below it only blocks further invoiced orders.
+func (l *UpdateUserEnterpriseLogic) UpdateUserEnterprise(req *types.UpdateUserEnterpriseRequest) error {
+	userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, req.UserId)
+	if err != nil {
+		l.Errorw("[UpdateUserEnterpriseLogic] Find User Error:", logger.Field("err", err.Error()), logger.Field("userId", req.UserId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Find User Error")
+	}
+	creditLimit := userInfo.CreditLimit
+	userInfo.Enterprise = &req.Enterprise
+	userInfo.CreditLimit = req.CreditLimit
+	err = l.svcCtx.UserModel.Transaction(l.ctx, func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.Update(l.ctx, userInfo, tx); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), &log.AdminAudit{
+			Action:       log.AdminAuditEnterprise,
+			UserId:       userInfo.Id,
+			AmountBefore: creditLimit,
+			AmountAfter:  userInfo.CreditLimit,
+			Remark:       fmt.Sprintf("enterprise %t", req.Enterprise),
+		})
+	})
+	if err != nil {
+		l.Errorw("[UpdateUserEnterpriseLogic] Update User Error:", logger.Field("err", err.Error()), logger.Field("userId", req.UserId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "Update User Error")
+	}
+	return nil
+}
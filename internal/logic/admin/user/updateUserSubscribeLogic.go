@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -41,18 +42,25 @@ func (l *UpdateUserSubscribeLogic) UpdateUserSubscribe(req *types.UpdateUserSubs
 	}
 
 	err = l.svcCtx.UserModel.UpdateSubscribe(l.ctx, &user.Subscribe{
-		Id:          userSub.Id,
-		UserId:      userSub.UserId,
-		OrderId:     userSub.OrderId,
-		SubscribeId: req.SubscribeId,
-		StartTime:   userSub.StartTime,
-		ExpireTime:  time.UnixMilli(req.ExpiredAt),
-		Traffic:     req.Traffic,
-		Download:    req.Download,
-		Upload:      req.Upload,
-		Token:       userSub.Token,
-		UUID:        userSub.UUID,
-		Status:      userSub.Status,
+		Id:           userSub.Id,
+		UserId:       userSub.UserId,
+		OrderId:      userSub.OrderId,
+		SubscribeId:  req.SubscribeId,
+		StartTime:    userSub.StartTime,
+		ExpireTime:   time.UnixMilli(req.ExpiredAt),
+		Traffic:      req.Traffic,
+		Download:     req.Download,
+		Upload:       req.Upload,
+		Token:        userSub.Token,
+		UUID:         userSub.UUID,
+		Status:       userSub.Status,
+		ExcludeNodes: userSub.ExcludeNodes,
+		IncludeNodes: userSub.IncludeNodes,
+		Note:         userSub.Note,
+		Alias:        userSub.Alias,
+		PauseCount:   userSub.PauseCount,
+		BonusNodes:   userSub.BonusNodes,
+		BonusExpire:  userSub.BonusExpire,
 	})
 
 	if err != nil {
@@ -69,5 +77,6 @@ func (l *UpdateUserSubscribeLogic) UpdateUserSubscribe(req *types.UpdateUserSubs
 		l.Errorw("failed to clear subscribe cache", logger.Field("error", err.Error()), logger.Field("subscribeId", userSub.SubscribeId))
 		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "failed to clear subscribe cache: %v", err.Error())
 	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
 	return nil
 }
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+	"fmt"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type UpdateUserSubscribeNodesLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewUpdateUserSubscribeNodesLogic Update user subscribe node overrides
+func NewUpdateUserSubscribeNodesLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateUserSubscribeNodesLogic {
+	return &UpdateUserSubscribeNodesLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *UpdateUserSubscribeNodesLogic) UpdateUserSubscribeNodes(req *types.UpdateUserSubscribeNodesRequest) error {
+	userSub, err := l.svcCtx.UserModel.FindOneSubscribe(l.ctx, req.UserSubscribeId)
+	if err != nil {
+		l.Errorw("FindOneUserSubscribe failed:", logger.Field("error", err.Error()), logger.Field("userSubscribeId", req.UserSubscribeId))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOneUserSubscribe failed: %v", err.Error())
+	}
+	userSub.ExcludeNodes = tool.Int64SliceToString(tool.RemoveDuplicateElements(req.ExcludeNodes...))
+	userSub.IncludeNodes = tool.Int64SliceToString(tool.RemoveDuplicateElements(req.IncludeNodes...))
+
+	err = l.svcCtx.DB.WithContext(l.ctx).Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		return log.CreateAdminAudit(tx, auditActor(l.ctx), &log.AdminAudit{
+			Action:          log.AdminAuditSubscribeNodes,
+			UserId:          userSub.UserId,
+			UserSubscribeId: userSub.Id,
+			Remark:          fmt.Sprintf("exclude %s include %s", userSub.ExcludeNodes, userSub.IncludeNodes),
+		})
+	})
+	if err != nil {
+		l.Errorw("UpdateSubscribe failed:", logger.Field("error", err.Error()))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "UpdateSubscribe failed: %v", err.Error())
+	}
+	// Clear user subscribe cache
+	if err = l.svcCtx.UserModel.ClearSubscribeCache(l.ctx, userSub); err != nil {
+		l.Errorw("ClearSubscribeCache failed:", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "ClearSubscribeCache failed: %v", err.Error())
+	}
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	return nil
+}
@@ -54,7 +54,7 @@ func (l *DeviceLoginLogic) DeviceLogin(req *types.DeviceLoginRequest) (resp *typ
 			content, _ := loginLog.Marshal()
 			if err := l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 				Type:     log.TypeLogin.Uint8(),
-				Date:     time.Now().Format("2006-01-02"),
+				Date:     log.Date(time.Now()),
 				ObjectID: userInfo.Id,
 				Content:  string(content),
 			}); err != nil {
@@ -230,7 +230,7 @@ func (l *DeviceLoginLogic) registerUserAndDevice(req *types.DeviceLoginRequest)
 
 	if err := l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 		Type:     log.TypeRegister.Uint8(),
-		Date:     time.Now().Format("2006-01-02"),
+		Date:     log.Date(time.Now()),
 		ObjectID: userInfo.Id,
 		Content:  string(content),
 	}); err != nil {
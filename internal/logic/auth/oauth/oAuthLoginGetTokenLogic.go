@@ -436,7 +436,7 @@ func (l *OAuthLoginGetTokenLogic) register(email, avatar, method, openid, reques
 
 	err = l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 		Type:     log.TypeRegister.Uint8(),
-		Date:     time.Now().Format("2006-01-02"),
+		Date:     log.Date(time.Now()),
 		ObjectID: userInfo.Id,
 		Content:  string(content),
 	})
@@ -525,7 +525,7 @@ func (l *OAuthLoginGetTokenLogic) recordLoginStatus(loginStatus bool, userInfo *
 		content, _ := loginLog.Marshal()
 		if err := l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 			Type:     log.TypeLogin.Uint8(),
-			Date:     time.Now().Format("2006-01-02"),
+			Date:     log.Date(time.Now()),
 			ObjectID: userInfo.Id,
 			Content:  string(content),
 		}); err != nil {
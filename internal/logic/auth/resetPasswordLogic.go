@@ -55,7 +55,7 @@ func (l *ResetPasswordLogic) ResetPassword(req *types.ResetPasswordRequest) (res
 			if err := l.svcCtx.LogModel.Insert(l.ctx, &log.SystemLog{
 				Id:       0,
 				Type:     log.TypeLogin.Uint8(),
-				Date:     time.Now().Format("2006-01-02"),
+				Date:     log.Date(time.Now()),
 				ObjectID: userInfo.Id,
 				Content:  string(content),
 			}); err != nil {
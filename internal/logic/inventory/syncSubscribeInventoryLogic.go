@@ -0,0 +1,73 @@
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type SyncSubscribeInventoryLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Sync subscribe inventory from an external system
+func NewSyncSubscribeInventoryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *SyncSubscribeInventoryLogic {
+	return &SyncSubscribeInventoryLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *SyncSubscribeInventoryLogic) SyncSubscribeInventory(req *types.SyncSubscribeInventoryRequest) (resp *types.SyncSubscribeInventoryResponse, err error) {
+	switch req.Mode {
+	case "set":
+		if req.Inventory < -1 {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "inventory must be -1 or greater")
+		}
+		err = l.svcCtx.SubscribeModel.SetInventory(l.ctx, req.SubscribeId, req.Inventory)
+	default:
+		// increment goes through the same conditional update as purchases, so neither side loses an update
+		err = l.svcCtx.SubscribeModel.AddInventory(l.ctx, req.SubscribeId, req.Inventory)
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe not found")
+	}
+	if errors.Is(err, subscribe.ErrOutOfStock) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeOutOfStock), "subscribe is unlimited or has not enough stock")
+	}
+	if err != nil {
+		l.Errorw("[SyncSubscribeInventory] Database update error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update inventory error: %v", err.Error())
+	}
+
+	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.SubscribeId)
+	if err != nil {
+		l.Errorw("[SyncSubscribeInventory] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+	}
+	l.Infow("[SyncSubscribeInventory] Inventory synced", logger.Field("subscribe_id", sub.Id), logger.Field("mode", req.Mode), logger.Field("inventory", sub.Inventory))
+
+	if l.svcCtx.Config.Inventory.LowStockThreshold > 0 {
+		val, _ := json.Marshal(queue.ForthwithSubscribeLowStockPayload{SubscribeId: sub.Id})
+		if _, err = l.svcCtx.Queue.Enqueue(asynq.NewTask(queue.ForthwithSubscribeLowStock, val)); err != nil {
+			l.Errorw("[SyncSubscribeInventory] Enqueue low stock task error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		}
+	}
+
+	return &types.SyncSubscribeInventoryResponse{
+		SubscribeId: sub.Id,
+		Inventory:   sub.Inventory,
+	}, nil
+}
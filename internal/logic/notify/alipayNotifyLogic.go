@@ -63,16 +63,15 @@ func (l *AlipayNotifyLogic) AlipayNotify(r *http.Request) error {
 			return errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", notify.OrderNo)
 		}
 
-		if orderInfo.Status == 5 {
-			return nil
-		}
-
-		// Update order status
-		err = l.svcCtx.OrderModel.UpdateOrderStatus(l.ctx, notify.OrderNo, 2)
+		// Update order status, only the first confirmed payment attempt pays the order
+		paid, err := confirmPayment(l.ctx, l.svcCtx, orderInfo, data, notify.Amount)
 		if err != nil {
 			l.Logger.Error("[AlipayNotify] Update order status failed", logger.Field("error", err.Error()), logger.Field("orderNo", notify.OrderNo))
 			return err
 		}
+		if !paid {
+			return nil
+		}
 		l.Logger.Info("[AlipayNotify] Notify status success", logger.Field("orderNo", notify.OrderNo))
 		payload := types.ForthwithActivateOrderPayload{
 			OrderNo: notify.OrderNo,
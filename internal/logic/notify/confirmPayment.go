@@ -0,0 +1,157 @@
+package notify
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// confirmPayment applies a gateway confirmation of the order through the payment method. The first
+// confirmed attempt pays the order and cancels the other pending attempts, a confirmation of another
+// attempt after that is a duplicate payment and refunded to the user's balance. Orders checked out
+// before attempts were recorded have none and are paid as before. The paid amount the gateway reports,
+// unknownAmount when it reports none, is checked against the amount charged first.
+// It reports whether the order is paid through this method and its activation has to be queued.
+func confirmPayment(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, pay *payment.Payment, paid int64) (bool, error) {
+	attempt, err := svcCtx.OrderModel.FindPaymentAttempt(ctx, orderInfo.OrderNo, pay.Id)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, err
+		}
+		attempt = nil
+	}
+
+	if orderInfo.Status != order.StatusPaid && orderInfo.Status != order.StatusFinished {
+		charged := orderInfo.Amount
+		if attempt != nil {
+			charged = attempt.Amount
+		}
+		amount := checkPaidAmount(ctx, svcCtx, orderInfo, charged, paid)
+		if amount.diff < 0 {
+			if svcCtx.Config.PaymentCheck.Underpayment == underpaymentHold {
+				err = holdUnderpaidOrder(ctx, svcCtx, orderInfo, amount)
+				if errors.Is(err, order.ErrOrderStatusChanged) {
+					return false, nil
+				}
+				return false, err
+			}
+			notifyPaymentMismatch(ctx, svcCtx, orderInfo, amount, "已接受")
+		}
+		if attempt == nil {
+			err = svcCtx.OrderModel.UpdateOrderStatusFrom(ctx, orderInfo.OrderNo, orderInfo.Status, order.StatusPaid)
+		} else {
+			err = svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+				if e := svcCtx.OrderModel.PayOrderWithAttempt(ctx, orderInfo.OrderNo, orderInfo.Status, attempt, tx); e != nil {
+					return e
+				}
+				return svcCtx.OrderModel.CancelPaymentAttempts(ctx, orderInfo.OrderNo, attempt.Id, tx)
+			})
+		}
+		if err == nil {
+			if amount.diff > 0 && svcCtx.Config.PaymentCheck.Overpayment == overpaymentGift {
+				// the order is paid, a failed credit is left to an admin
+				if e := creditOverpayment(ctx, svcCtx, orderInfo, amount); e != nil {
+					logger.WithContext(ctx).Error("[PaymentNotify] Credit overpayment failed",
+						logger.Field("error", e.Error()),
+						logger.Field("orderNo", orderInfo.OrderNo),
+					)
+				}
+			}
+			return true, nil
+		}
+		if !errors.Is(err, order.ErrOrderStatusChanged) {
+			return false, err
+		}
+		// another confirmation paid the order first
+		if orderInfo, err = svcCtx.OrderModel.FindOneByOrderNo(ctx, orderInfo.OrderNo); err != nil {
+			return false, err
+		}
+		if attempt != nil {
+			if attempt, err = svcCtx.OrderModel.FindPaymentAttempt(ctx, orderInfo.OrderNo, pay.Id); err != nil {
+				return false, err
+			}
+		}
+	}
+
+	// the order is paid already, a repeated notification of the winning attempt only queues the activation again
+	if attempt == nil || attempt.Status == order.AttemptStatusPaid {
+		return orderInfo.Status == order.StatusPaid, nil
+	}
+	if attempt.Status == order.AttemptStatusRefunded || attempt.Status == order.AttemptStatusDuplicate {
+		return false, nil
+	}
+	return false, refundDuplicatePayment(ctx, svcCtx, orderInfo, attempt)
+}
+
+// refundDuplicatePayment credits the amount of a duplicate payment attempt to the user's balance.
+// Orders without a user cannot be credited, the attempt is flagged for a refund by hand.
+func refundDuplicatePayment(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, attempt *order.PaymentAttempt) error {
+	if orderInfo.UserId == 0 || attempt.Amount <= 0 {
+		logger.WithContext(ctx).Error("[PaymentNotify] Duplicate payment has to be refunded by hand",
+			logger.Field("orderNo", orderInfo.OrderNo),
+			logger.Field("attempt", attempt.Id),
+			logger.Field("amount", attempt.Amount),
+		)
+		err := svcCtx.OrderModel.UpdatePaymentAttemptStatusFrom(ctx, attempt.Id, attempt.Status, order.AttemptStatusDuplicate)
+		if errors.Is(err, order.ErrOrderStatusChanged) {
+			return nil
+		}
+		return err
+	}
+
+	now := time.Now()
+	var userInfo user.User
+	err := svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		// Claim the attempt first, a concurrent notification of the same payment fails here
+		if err := svcCtx.OrderModel.UpdatePaymentAttemptStatusFrom(ctx, attempt.Id, attempt.Status, order.AttemptStatusRefunded, tx); err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.User{}).Where("id = ?", orderInfo.UserId).First(&userInfo).Error; err != nil {
+			return err
+		}
+		userInfo.Balance += attempt.Amount
+		if err := svcCtx.UserModel.Update(ctx, &userInfo, tx); err != nil {
+			return err
+		}
+		balanceLog := log.Balance{
+			Type:      log.BalanceTypeRefund,
+			Amount:    attempt.Amount,
+			OrderNo:   orderInfo.OrderNo,
+			Balance:   userInfo.Balance,
+			Timestamp: now.UnixMilli(),
+		}
+		content, _ := balanceLog.Marshal()
+		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeBalance.Uint8(),
+			Date:     log.Date(now),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		}).Error
+	})
+	if errors.Is(err, order.ErrOrderStatusChanged) {
+		return nil
+	}
+	if err != nil {
+		logger.WithContext(ctx).Error("[PaymentNotify] Refund duplicate payment failed",
+			logger.Field("error", err.Error()),
+			logger.Field("orderNo", orderInfo.OrderNo),
+			logger.Field("attempt", attempt.Id),
+		)
+		return err
+	}
+	logger.WithContext(ctx).Info("[PaymentNotify] Duplicate payment refunded to balance",
+		logger.Field("orderNo", orderInfo.OrderNo),
+		logger.Field("attempt", attempt.Id),
+		logger.Field("amount", attempt.Amount),
+	)
+	return nil
+}
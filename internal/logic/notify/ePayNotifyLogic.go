@@ -2,7 +2,9 @@ package notify
 
 import (
 	"encoding/json"
+	"math"
 	"net/url"
+	"strconv"
 
 	"github.com/perfect-panel/server/pkg/constant"
 
@@ -66,15 +68,15 @@ func (l *EPayNotifyLogic) EPayNotify(req *types.EPayNotifyRequest) error {
 		l.Logger.Error("[EPayNotify] Trade status is not success", logger.Field("orderNo", req.OutTradeNo), logger.Field("tradeStatus", req.TradeStatus))
 		return nil
 	}
-	if orderInfo.Status == 5 {
-		return nil
-	}
-	// Update order status
-	err = l.svcCtx.OrderModel.UpdateOrderStatus(l.ctx, req.OutTradeNo, 2)
+	// Update order status, only the first confirmed payment attempt pays the order
+	paid, err := confirmPayment(l.ctx, l.svcCtx, orderInfo, data, epayPaidAmount(req.Money))
 	if err != nil {
 		l.Logger.Error("[EPayNotify] Update order status failed", logger.Field("error", err.Error()), logger.Field("orderNo", req.OutTradeNo))
 		return err
 	}
+	if !paid {
+		return nil
+	}
 	// Create activate order task
 	payload := queueType.ForthwithActivateOrderPayload{
 		OrderNo: req.OutTradeNo,
@@ -104,3 +106,12 @@ func urlParamsToMap(query string) map[string]string {
 	}
 	return params
 }
+
+// epayPaidAmount converts the money of an EPay notification to cents, unknownAmount when it carries none
+func epayPaidAmount(money string) int64 {
+	value, err := strconv.ParseFloat(money, 64)
+	if err != nil {
+		return unknownAmount
+	}
+	return int64(math.Round(value * 100))
+}
@@ -0,0 +1,205 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/perfect-panel/server/internal/logic/telegram"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Paid amount check
+//
+// The checkout charges the order converted to the gateway currency, a confirmation reporting another amount
+// than charged is a mismatch. An underpaid order is held for an admin or paid anyway, the excess of an
+// overpaid one is kept or credited to the user's gift amount, as PaymentCheck in the config says.
+
+const (
+	// underpaymentHold leaves an underpaid order in StatusUnderpaid
+	underpaymentHold = "hold"
+	// overpaymentGift credits the overpaid amount to the user's gift amount
+	overpaymentGift = "gift"
+)
+
+// unknownAmount is passed for gateways whose confirmation carries no amount, those are not checked
+const unknownAmount int64 = -1
+
+// paidAmount a gateway confirmation compared with the amount charged, in cents of the gateway currency
+type paidAmount struct {
+	expected int64
+	paid     int64
+	// diff paid minus expected, 0 within the tolerance
+	diff int64
+	rate float64
+}
+
+// compareAmount compares the paid amount with the charged order amount converted at the rate, differences
+// within the tolerance count as none.
+func compareAmount(charged, paid int64, rate float64, tolerance int64) paidAmount {
+	result := paidAmount{
+		expected: int64(math.Round(float64(charged) * rate)),
+		paid:     paid,
+		rate:     rate,
+	}
+	if diff := paid - result.expected; diff > tolerance || -diff > tolerance {
+		result.diff = diff
+	}
+	return result
+}
+
+// excess returns the overpaid amount in cents of the site currency
+func (a paidAmount) excess() int64 {
+	if a.diff <= 0 || a.rate <= 0 {
+		return 0
+	}
+	return int64(math.Floor(float64(a.diff) / a.rate))
+}
+
+// gatewayRate returns the rate the checkout converted the order amount to the gateway currency at, false when
+// this process does not know it and the amounts cannot be compared.
+func gatewayRate(svcCtx *svc.ServiceContext) (float64, bool) {
+	switch {
+	case svcCtx.Config.Currency.Unit == "CNY":
+		return 1, true
+	case svcCtx.ExchangeRate != 0:
+		return svcCtx.ExchangeRate, true
+	case svcCtx.Config.Currency.AccessKey == "":
+		// without an exchange rate API the checkout charges the amount unconverted
+		return 1, true
+	}
+	return 0, false
+}
+
+// checkPaidAmount compares the paid amount of a confirmation with the amount charged for the order and logs a
+// mismatch. The zero result means the payment matches or cannot be checked.
+func checkPaidAmount(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, charged, paid int64) paidAmount {
+	if paid == unknownAmount {
+		return paidAmount{}
+	}
+	rate, ok := gatewayRate(svcCtx)
+	if !ok {
+		logger.WithContext(ctx).Infow("[PaymentNotify] Exchange rate unknown, paid amount not checked",
+			logger.Field("orderNo", orderInfo.OrderNo),
+			logger.Field("paid", paid),
+		)
+		return paidAmount{}
+	}
+	result := compareAmount(charged, paid, rate, svcCtx.Config.PaymentCheck.Tolerance)
+	if result.diff != 0 {
+		logger.WithContext(ctx).Errorw("[PaymentNotify] Paid amount mismatch",
+			logger.Field("orderNo", orderInfo.OrderNo),
+			logger.Field("method", orderInfo.Method),
+			logger.Field("amount", charged),
+			logger.Field("expected", result.expected),
+			logger.Field("paid", paid),
+			logger.Field("rate", rate),
+		)
+	}
+	return result
+}
+
+// holdUnderpaidOrder moves an underpaid order to StatusUnderpaid and notifies the admins. A repeated
+// confirmation of an order held already changes nothing.
+func holdUnderpaidOrder(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, amount paidAmount) error {
+	if orderInfo.Status == order.StatusUnderpaid {
+		return nil
+	}
+	err := svcCtx.OrderModel.UpdateOrderStatusFrom(ctx, orderInfo.OrderNo, orderInfo.Status, order.StatusUnderpaid)
+	if err != nil {
+		return err
+	}
+	notifyPaymentMismatch(ctx, svcCtx, orderInfo, amount, "待处理")
+	return nil
+}
+
+// creditOverpayment credits the overpaid amount to the user's gift amount. Orders without a user are left as
+// they are, the mismatch is logged already.
+func creditOverpayment(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, amount paidAmount) error {
+	excess := amount.excess()
+	if orderInfo.UserId == 0 || excess <= 0 {
+		return nil
+	}
+	now := time.Now()
+	var userInfo user.User
+	err := svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.User{}).Where("id = ?", orderInfo.UserId).First(&userInfo).Error; err != nil {
+			return err
+		}
+		userInfo.GiftAmount += excess
+		if err := svcCtx.UserModel.Update(ctx, &userInfo, tx); err != nil {
+			return err
+		}
+		giftLog := log.Gift{
+			Type:      log.GiftTypeIncrease,
+			OrderNo:   orderInfo.OrderNo,
+			Amount:    excess,
+			Balance:   userInfo.GiftAmount,
+			Remark:    "Overpayment",
+			Timestamp: now.UnixMilli(),
+		}
+		content, _ := giftLog.Marshal()
+		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeGift.Uint8(),
+			Date:     log.Date(now),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		}).Error
+	})
+	if err != nil {
+		return err
+	}
+	logger.WithContext(ctx).Info("[PaymentNotify] Overpayment credited to gift amount",
+		logger.Field("orderNo", orderInfo.OrderNo),
+		logger.Field("amount", excess),
+	)
+	return nil
+}
+
+// notifyPaymentMismatch sends the mismatch to the admins through the Telegram bot
+func notifyPaymentMismatch(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order, amount paidAmount, result string) {
+	if svcCtx.TelegramBot == nil {
+		return
+	}
+	text, err := tool.RenderTemplateToString(telegram.AdminPaymentMismatchNotify, map[string]string{
+		"OrderNo":       orderInfo.OrderNo,
+		"Expected":      fmt.Sprintf("%.2f", float64(amount.expected)/100),
+		"Paid":          fmt.Sprintf("%.2f", float64(amount.paid)/100),
+		"Result":        result,
+		"PaymentMethod": orderInfo.Method,
+	})
+	if err != nil {
+		return
+	}
+	admins, err := svcCtx.UserModel.QueryAdminUsers(ctx)
+	if err != nil {
+		logger.WithContext(ctx).Error("[PaymentNotify] Query admin users failed", logger.Field("error", err.Error()))
+		return
+	}
+	for _, admin := range admins {
+		for _, item := range admin.AuthMethods {
+			if item.AuthType != "telegram" {
+				continue
+			}
+			chatId, err := strconv.ParseInt(item.AuthIdentifier, 10, 64)
+			if err != nil {
+				continue
+			}
+			msg := tgbotapi.NewMessage(chatId, text)
+			msg.ParseMode = "markdown"
+			if _, err = svcCtx.TelegramBot.Send(msg); err != nil {
+				logger.WithContext(ctx).Error("[PaymentNotify] Send telegram admin message failed", logger.Field("error", err.Error()))
+			}
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package notify
+
+import "testing"
+
+func TestCompareAmount(t *testing.T) {
+	cases := []struct {
+		name      string
+		charged   int64
+		paid      int64
+		rate      float64
+		tolerance int64
+		diff      int64
+		excess    int64
+	}{
+		{name: "exact", charged: 1000, paid: 1000, rate: 1, tolerance: 1},
+		{name: "within tolerance", charged: 1000, paid: 999, rate: 1, tolerance: 1},
+		{name: "underpaid", charged: 1000, paid: 900, rate: 1, tolerance: 1, diff: -100},
+		{name: "overpaid", charged: 1000, paid: 1200, rate: 1, tolerance: 1, diff: 200, excess: 200},
+		{name: "converted", charged: 1000, paid: 7250, rate: 7.25, tolerance: 1},
+		{name: "converted overpaid", charged: 1000, paid: 7975, rate: 7.25, tolerance: 1, diff: 725, excess: 100},
+		{name: "no tolerance", charged: 1000, paid: 999, rate: 1, diff: -1},
+	}
+	for _, c := range cases {
+		got := compareAmount(c.charged, c.paid, c.rate, c.tolerance)
+		if got.diff != c.diff {
+			t.Errorf("%s: expected diff %d, got %d", c.name, c.diff, got.diff)
+		}
+		if got.excess() != c.excess {
+			t.Errorf("%s: expected excess %d, got %d", c.name, c.excess, got.excess())
+		}
+	}
+}
+
+func TestEpayPaidAmount(t *testing.T) {
+	want := map[string]int64{"12.30": 1230, "0.29": 29, "5": 500, "": unknownAmount, "abc": unknownAmount}
+	for money, amount := range want {
+		if got := epayPaidAmount(money); got != amount {
+			t.Errorf("%q: expected %d, got %d", money, amount, got)
+		}
+	}
+}
@@ -68,14 +68,14 @@ func (l *StripeNotifyLogic) StripeNotify(r *http.Request, w http.ResponseWriter)
 		return errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", notify.OrderNo)
 	}
 	if notify.EventType == "payment_intent.succeeded" {
-		if orderInfo.Status == 5 {
-			return nil
-		}
-		// update order status
-		err = l.svcCtx.OrderModel.UpdateOrderStatus(l.ctx, notify.OrderNo, 2)
+		// update order status, only the first confirmed payment attempt pays the order
+		paid, err := confirmPayment(l.ctx, l.svcCtx, orderInfo, stripeConfig, notify.Amount)
 		if err != nil {
 			return err
 		}
+		if !paid {
+			return nil
+		}
 		// create ActivateOrder task
 		payload := types.ForthwithActivateOrderPayload{
 			OrderNo: notify.OrderNo,
@@ -0,0 +1,15 @@
+package order
+
+import "github.com/perfect-panel/server/internal/model/user"
+
+// activeSubscriptions counts the pending, active and paused subscriptions, finished or expired ones don't take
+// a slot. A paused subscription keeps its slot, it would go over the limit when it is resumed otherwise.
+func activeSubscriptions(subs []*user.SubscribeDetails) int64 {
+	var count int64
+	for _, sub := range subs {
+		if sub.Status <= user.SubscribeStatusActive || sub.Status == user.SubscribeStatusPaused {
+			count++
+		}
+	}
+	return count
+}
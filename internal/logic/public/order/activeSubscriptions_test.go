@@ -0,0 +1,25 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestActiveSubscriptions(t *testing.T) {
+	var subs []*user.SubscribeDetails
+	for _, status := range []uint8{
+		user.SubscribeStatusPending,
+		user.SubscribeStatusActive,
+		user.SubscribeStatusFinished,
+		user.SubscribeStatusExpired,
+		user.SubscribeStatusDeducted,
+		user.SubscribeStatusStopped,
+		user.SubscribeStatusPaused,
+	} {
+		subs = append(subs, &user.SubscribeDetails{Status: status})
+	}
+	// the paused subscription keeps its slot for when it is resumed
+	assert.Equal(t, int64(3), activeSubscriptions(subs))
+}
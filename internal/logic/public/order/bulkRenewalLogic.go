@@ -0,0 +1,362 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type BulkRenewalLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// bulkRenewalItem is the per subscription part of a bulk renewal
+type bulkRenewalItem struct {
+	userSubscribe *user.SubscribeDetails
+	unitPrice     int64
+	planDiscount  string
+	unitTime      string
+	periods       int64
+	quantity      int64
+	price         int64
+	amount        int64
+}
+
+// NewBulkRenewalLogic Bulk renewal of several subscriptions
+func NewBulkRenewalLogic(ctx context.Context, svcCtx *svc.ServiceContext) *BulkRenewalLogic {
+	return &BulkRenewalLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// BulkRenewal renews several subscriptions of the user under one payment.
+// Every subscription is priced like a single renewal, including its plan discount, the coupon,
+// payment discount, loyalty credit, gift amount and fee are then applied once to the total.
+// The payable order has type order.TypeBulkRenewal, one pending renewal order per subscription
+// references it through BulkOrderNo and carries its share of the amounts, so the remaining
+// amount of each subscription is still computed from its own renewal orders. A bonus days coupon
+// adds its days to every renewed subscription.
+func (l *BulkRenewalLogic) BulkRenewal(req *types.BulkRenewalOrderRequest) (resp *types.RenewalOrderResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	// Validate quantity limit
+	if err = CheckQuantity(&req.Quantity, nil, l.svcCtx.Config.Subscribe.StrictQuantity); err != nil {
+		l.Errorw("[BulkRenewal] Invalid quantity", logger.Field("quantity", req.Quantity), logger.Field("max", MaxQuantity))
+		return nil, err
+	}
+	ids := tool.RemoveDuplicateElements(req.UserSubscribeIDs...)
+	if len(ids) == 0 || len(ids) > MaxBulkRenewal {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "bulk renewal takes 1 to %d subscriptions", MaxBulkRenewal)
+	}
+
+	// find payment method
+	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
+	if err != nil {
+		l.Errorw("[BulkRenewal] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment error: %v", err.Error())
+	}
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[BulkRenewal] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
+
+	var couponInfo *couponModel.Coupon
+	var price, amount int64
+	items := make([]bulkRenewalItem, 0, len(ids))
+	for _, id := range ids {
+		userSubscribe, err := l.svcCtx.UserModel.FindOneUserSubscribe(l.ctx, id)
+		if err != nil {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribe error: %v", err.Error())
+		}
+		if userSubscribe.UserId != u.Id {
+			l.Errorw("[BulkRenewal] User subscribe does not belong to the user", logger.Field("user_subscribe_id", id), logger.Field("user_id", u.Id))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "user subscribe %d not found", id)
+		}
+		if userSubscribe.Status == user.SubscribeStatusPaused {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribePaused), "user subscribe %d is paused", id)
+		}
+		sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, userSubscribe.SubscribeId)
+		if err != nil {
+			l.Errorw("[BulkRenewal] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", userSubscribe.SubscribeId))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+		}
+		if !*sub.Sell {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "subscribe not sell")
+		}
+		// the quantity is shared, it has to meet the minimum of every renewed plan
+		if err = CheckQuantity(&req.Quantity, sub, l.svcCtx.Config.Subscribe.StrictQuantity); err != nil {
+			l.Errorw("[BulkRenewal] Invalid quantity", logger.Field("quantity", req.Quantity), logger.Field("subscribe_id", sub.Id), logger.Field("min", sub.MinQuantity))
+			return nil, err
+		}
+		pending, err := l.svcCtx.OrderModel.FindUnappliedRenewals(l.ctx, userSubscribe.Token)
+		if err != nil {
+			l.Errorw("[BulkRenewal] Database query error", logger.Field("error", err.Error()), logger.Field("user_subscribe_id", userSubscribe.Id))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find pending renewals error: %v", err.Error())
+		}
+		banked := bankedExpire(userSubscribe.ExpireTime, pending, sub, time.Now())
+		if exceedsRenewalStack(banked, sub.UnitTime, sub.Periods(req.Quantity), sub.MaxRenewalStack, time.Now()) {
+			l.Infow("[BulkRenewal] Renewal exceeds the maximum banked time",
+				logger.Field("user_subscribe_id", userSubscribe.Id),
+				logger.Field("expire_time", userSubscribe.ExpireTime),
+				logger.Field("banked_expire_time", banked),
+				logger.Field("max_renewal_stack", sub.MaxRenewalStack))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeRenewalStackLimit), "renewal exceeds %d banked periods", sub.MaxRenewalStack)
+		}
+		// the coupon has to be valid for every renewed plan
+		if req.Coupon != "" {
+			couponInfo, _, err = findApplicableCoupon(l.ctx, l.svcCtx, req.Coupon, couponModel.OrderTypeRenewal, u.Id, sub.Id, payment.Id)
+			if err != nil {
+				return nil, err
+			}
+		}
+		item := bulkRenewalItem{
+			userSubscribe: userSubscribe,
+			unitPrice:     sub.UnitPrice,
+			planDiscount:  sub.Discount,
+			unitTime:      sub.UnitTime,
+			periods:       sub.Periods(req.Quantity),
+			quantity:      req.Quantity,
+		}
+		// a renewal extends the one subscription, in count mode it is charged as a single copy
+		if sub.QuantityMode == subscribe.QuantityModeCount {
+			item.quantity = 1
+		}
+		item.price, item.amount = planPrice(sub.UnitPrice, sub.Discount, sub.DiscountInterpolate, item.quantity)
+		price += item.price
+		amount += item.amount
+		// Validate amount to prevent overflow
+		if amount > MaxOrderAmount {
+			l.Errorw("[BulkRenewal] Order amount exceeds maximum limit",
+				logger.Field("amount", amount),
+				logger.Field("max", MaxOrderAmount),
+				logger.Field("user_id", u.Id))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "order amount exceeds maximum limit")
+		}
+		items = append(items, item)
+	}
+	discountAmount, coupon := portal.StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	if discountAmount == 0 {
+		// the coupon replaced the plan discounts, the renewals are weighted by their list prices
+		for i := range items {
+			items[i].amount = items[i].price
+		}
+	}
+	amount = price - discountAmount - coupon
+	// Calculate the payment method discount
+	paymentDiscount := portal.CalculatePaymentDiscount(amount, payment)
+	amount -= paymentDiscount
+
+	var loyaltyCredit int64
+	// Loyalty credit is only usable on renewals and is consumed before the gift amount
+	if u.LoyaltyCredit > 0 {
+		loyaltyCredit = min(u.LoyaltyCredit, maxGiftDeduction(amount, l.svcCtx.Config.Subscribe.MaxLoyaltyCreditPercent))
+		amount -= loyaltyCredit
+		u.LoyaltyCredit -= loyaltyCredit
+	}
+
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment
+	deductionAmount, promoCredit := deductGift(u, amount, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount -= deductionAmount
+	if err = portal.CheckPaymentAvailable(payment, amount, time.Now()); err != nil {
+		l.Infow("[BulkRenewal] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", amount), logger.Field("user_id", u.Id))
+		return nil, err
+	}
+
+	var feeAmount int64
+	if amount > 0 {
+		feeAmount = portal.CalculateFee(amount, payment)
+	}
+	amount += feeAmount
+	amount, roundingAdjustment := portal.RoundAmount(amount, l.svcCtx.Config.Currency.RoundingIncrement)
+
+	// Final validation after adding fee
+	if amount > MaxOrderAmount {
+		l.Errorw("[BulkRenewal] Final order amount exceeds maximum limit after fee",
+			logger.Field("amount", amount),
+			logger.Field("max", MaxOrderAmount),
+			logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "order amount exceeds maximum limit")
+	}
+
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[BulkRenewal] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
+	orderInfo := order.Order{
+		UserId:             u.Id,
+		OrderNo:            orderNo,
+		Type:               order.TypeBulkRenewal,
+		Quantity:           req.Quantity,
+		Price:              price,
+		Amount:             amount,
+		GiftAmount:         deductionAmount,
+		PromoCredit:        promoCredit,
+		LoyaltyCredit:      loyaltyCredit,
+		Discount:           discountAmount,
+		Coupon:             req.Coupon,
+		CouponDiscount:     coupon,
+		BonusDays:          couponInfo.BonusDays(),
+		PaymentDiscount:    paymentDiscount,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		Status:             initialStatus(l.svcCtx.Config.Subscribe.InstantZeroAmount, amount),
+	}
+	weights := make([]int64, len(items))
+	for i, item := range items {
+		weights[i] = item.amount
+	}
+	amounts := splitAmount(orderInfo.Amount, weights)
+	// the promo credit and the rest of the gift amount are split apart, so each renewal gives back its own buckets
+	promos := splitAmount(orderInfo.PromoCredit, weights)
+	gifts := splitAmount(orderInfo.GiftAmount-orderInfo.PromoCredit, weights)
+	coupons := splitCoupon(orderInfo.CouponDiscount, weights)
+	paymentDiscounts := splitAmount(orderInfo.PaymentDiscount, weights)
+	credits := splitAmount(orderInfo.LoyaltyCredit, weights)
+	fees := splitAmount(orderInfo.FeeAmount, weights)
+	roundings := splitAmount(orderInfo.RoundingAdjustment, weights)
+	renewals := make([]*order.Order, len(items))
+	for i, item := range items {
+		renewals[i] = &order.Order{
+			UserId:             u.Id,
+			ParentId:           item.userSubscribe.OrderId,
+			OrderNo:            fmt.Sprintf("%s-%d", orderInfo.OrderNo, i+1),
+			Type:               2,
+			Quantity:           item.quantity,
+			Price:              item.price,
+			UnitPrice:          item.unitPrice,
+			PlanDiscount:       item.planDiscount,
+			UnitTime:           item.unitTime,
+			Periods:            item.periods,
+			Amount:             amounts[i],
+			GiftAmount:         promos[i] + gifts[i],
+			PromoCredit:        promos[i],
+			LoyaltyCredit:      credits[i],
+			Discount:           item.price - item.amount,
+			CouponDiscount:     coupons[i],
+			BonusDays:          orderInfo.BonusDays,
+			PaymentDiscount:    paymentDiscounts[i],
+			PaymentId:          payment.Id,
+			Method:             payment.Platform,
+			FeeAmount:          fees[i],
+			RoundingAdjustment: roundings[i],
+			Status:             orderInfo.Status,
+			SubscribeId:        item.userSubscribe.SubscribeId,
+			SubscribeToken:     item.userSubscribe.Token,
+			BulkOrderNo:        orderInfo.OrderNo,
+		}
+	}
+
+	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
+		// Pre deduction, returned when the order is closed
+		if orderInfo.GiftAmount > 0 || orderInfo.LoyaltyCredit > 0 {
+			// take the deductions atomically, a concurrent order may have spent the balances since they were read
+			if err := l.svcCtx.UserModel.DeductBalance(l.ctx, u, orderInfo.GiftAmount-orderInfo.PromoCredit, orderInfo.PromoCredit, orderInfo.LoyaltyCredit, db); err != nil {
+				l.Errorw("[BulkRenewal] Database update error", logger.Field("error", err.Error()), logger.Field("user", u))
+				return err
+			}
+		}
+		if orderInfo.LoyaltyCredit > 0 {
+			creditLog := log.LoyaltyCredit{
+				Type:      log.LoyaltyCreditTypeReduce,
+				OrderNo:   orderInfo.OrderNo,
+				Amount:    orderInfo.LoyaltyCredit,
+				Balance:   u.LoyaltyCredit,
+				Remark:    "Bulk renewal order deduction",
+				Timestamp: time.Now().UnixMilli(),
+			}
+			content, _ := creditLog.Marshal()
+			if err := db.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeLoyaltyCredit.Uint8(),
+				Date:     log.Date(time.Now()),
+				ObjectID: u.Id,
+				Content:  string(content),
+			}).Error; err != nil {
+				l.Errorw("[BulkRenewal] Database insert error", logger.Field("error", err.Error()), logger.Field("loyaltyCreditLog", creditLog))
+				return err
+			}
+		}
+		if orderInfo.GiftAmount > 0 {
+			// create a deduction record per bucket
+			for _, giftLog := range giftDeductionLogs(u, orderInfo.OrderNo, orderInfo.GiftAmount, orderInfo.PromoCredit, "Bulk renewal order deduction", time.Now()) {
+				if err := log.CreateOrderGift(db, u.Id, &giftLog); err != nil {
+					l.Errorw("[BulkRenewal] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", giftLog))
+					return err
+				}
+			}
+		}
+		if orderInfo.Coupon != "" {
+			// the bulk order takes one coupon use for all its renewals, given back when it is closed
+			if err := l.svcCtx.CouponModel.IncreaseUsedCount(l.ctx, orderInfo.Coupon, db); err != nil {
+				l.Errorw("[BulkRenewal] Database update error", logger.Field("error", err.Error()), logger.Field("coupon", orderInfo.Coupon))
+				return err
+			}
+		}
+		if err := db.Model(&order.Order{}).Create(&orderInfo).Error; err != nil {
+			return err
+		}
+		return db.Model(&order.Order{}).Create(&renewals).Error
+	})
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "gift amount or loyalty credit spent by another order")
+	}
+	if errors.Is(err, couponModel.ErrCouponExhausted) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used up")
+	}
+	if err != nil {
+		l.Errorw("[BulkRenewal] Database insert error", logger.Field("error", err.Error()), logger.Field("order", orderInfo))
+		return nil, errors.Wrapf(err, "insert order error: %v", err.Error())
+	}
+	if orderInfo.Status == order.StatusPaid {
+		// nothing left to pay, the subscriptions are renewed right away
+		if err = activateCreatedPaid(l.ctx, l.svcCtx, &orderInfo); err != nil {
+			l.Errorw("[BulkRenewal] Activate order error", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.QueueEnqueueError), "activate order error: %v", err.Error())
+		}
+	}
+	if orderInfo.Status != order.StatusPaid {
+		// Deferred task, closing the bulk order also closes its renewal orders
+		payload := queue.DeferCloseOrderPayload{
+			OrderNo: orderInfo.OrderNo,
+		}
+		val, err := json.Marshal(payload)
+		if err != nil {
+			l.Errorw("[BulkRenewal] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
+		}
+		task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+		taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
+		if err != nil {
+			l.Errorw("[BulkRenewal] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
+		} else {
+			l.Infow("[BulkRenewal] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+		}
+	}
+	return renewalOrderResponse(&orderInfo), nil
+}
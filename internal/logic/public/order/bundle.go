@@ -0,0 +1,33 @@
+package order
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// findBundle resolves the bundle of a purchase request, nil without one. A bundle fixes the plan
+// and the quantity of the request, the order is priced by the bundle price instead of the plan.
+func findBundle(ctx context.Context, svcCtx *svc.ServiceContext, req *types.PurchaseOrderRequest) (*subscribe.Bundle, error) {
+	if req.BundleId == 0 {
+		return nil, nil
+	}
+	var bundle subscribe.Bundle
+	if err := svcCtx.DB.WithContext(ctx).Model(&subscribe.Bundle{}).Where("id = ?", req.BundleId).First(&bundle).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeBundleNotAvailable), "bundle not exist: %d", req.BundleId)
+		}
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find bundle error: %v", err.Error())
+	}
+	if !bundle.Sell {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeBundleNotAvailable), "bundle not sell: %d", req.BundleId)
+	}
+	req.SubscribeId = bundle.SubscribeId
+	req.Quantity = bundle.Quantity
+	return &bundle, nil
+}
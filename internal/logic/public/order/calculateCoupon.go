@@ -1,13 +1,46 @@
 package order
 
-import (
-	"github.com/perfect-panel/server/internal/model/coupon"
-)
+import "sort"
 
-func calculateCoupon(amount int64, couponInfo *coupon.Coupon) int64 {
-	if couponInfo.Type == 1 {
-		return int64(float64(amount) * (float64(couponInfo.Discount) / float64(100)))
-	} else {
-		return min(couponInfo.Discount, amount)
+// splitCoupon distributes the coupon deduction of a multi-item order over its line amounts proportionally.
+// Parts are rounded down and the units left over go to the lines with the largest remainders,
+// so they always add up to the coupon and no line is discounted beyond its amount.
+func splitCoupon(coupon int64, amounts []int64) []int64 {
+	parts := make([]int64, len(amounts))
+	var sum int64
+	for _, a := range amounts {
+		sum += max(a, 0)
 	}
+	if sum <= 0 || coupon <= 0 {
+		return parts
+	}
+	coupon = min(coupon, sum)
+	remainders := make([]int64, len(amounts))
+	rest := coupon
+	for i, a := range amounts {
+		if a <= 0 {
+			continue
+		}
+		// integer math keeps the split exact for every amount below MaxOrderAmount
+		parts[i] = coupon * a / sum
+		remainders[i] = coupon * a % sum
+		rest -= parts[i]
+	}
+	idx := make([]int, len(amounts))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		return remainders[idx[a]] > remainders[idx[b]]
+	})
+	for _, i := range idx {
+		if rest == 0 {
+			break
+		}
+		if parts[i] < amounts[i] {
+			parts[i]++
+			rest--
+		}
+	}
+	return parts
 }
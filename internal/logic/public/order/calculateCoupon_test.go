@@ -0,0 +1,40 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCoupon(t *testing.T) {
+	tests := []struct {
+		name    string
+		coupon  int64
+		amounts []int64
+		want    []int64
+	}{
+		{name: "empty", coupon: 100, amounts: nil, want: []int64{}},
+		{name: "single", coupon: 100, amounts: []int64{1000}, want: []int64{100}},
+		{name: "proportional", coupon: 300, amounts: []int64{1000, 2000}, want: []int64{100, 200}},
+		{name: "largest remainder", coupon: 100, amounts: []int64{100, 100, 100}, want: []int64{34, 33, 33}},
+		{name: "remainder to the larger share", coupon: 10, amounts: []int64{100, 200, 400}, want: []int64{1, 3, 6}},
+		{name: "capped by the lines", coupon: 500, amounts: []int64{100, 200}, want: []int64{100, 200}},
+		{name: "free line", coupon: 50, amounts: []int64{0, 100}, want: []int64{0, 50}},
+		{name: "no amount", coupon: 50, amounts: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "no coupon", coupon: 0, amounts: []int64{10, 20}, want: []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitCoupon(tt.coupon, tt.amounts)
+			assert.Equal(t, tt.want, got)
+			var sum int64
+			for i, part := range got {
+				sum += part
+				assert.LessOrEqual(t, part, tt.amounts[i])
+			}
+			if len(tt.amounts) > 0 && sum > 0 {
+				assert.Equal(t, min(tt.coupon, sum), sum)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// findApplicableCoupon looks up a coupon by its exact code and runs the checks shared by the order
+// preview, purchase, renewal and the coupon usage query. A subscribeId, paymentId or orderType of 0
+// skips that restriction. orderType is one of the coupon.OrderType bits.
+// Coupons scoped to users are only found for those users. The per user limit still applies to them.
+// It also returns how many orders the user has already placed with the coupon.
+// Disabled coupons are reported as not existing so their codes are not revealed.
+func findApplicableCoupon(ctx context.Context, svcCtx *svc.ServiceContext, code string, orderType uint8, userId, subscribeId, paymentId int64) (*coupon.Coupon, int64, error) {
+	couponInfo, err := svcCtx.CouponModel.FindOneByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
+		}
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
+	}
+	if couponInfo.Enable != nil && !*couponInfo.Enable {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
+	}
+	now := time.Now().Unix()
+	if couponInfo.StartTime > 0 && now < couponInfo.StartTime {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not started")
+	}
+	if couponInfo.ExpireTime > 0 && now > couponInfo.ExpireTime {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponExpired), "coupon expired")
+	}
+	if couponInfo.Count > 0 && couponInfo.Count <= couponInfo.UsedCount {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used")
+	}
+	if !couponInfo.AllowsUser(userId) {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not available to this user")
+	}
+	if orderType != 0 && !couponInfo.Applicable(orderType) {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not applicable to this order type")
+	}
+	couponSub := tool.StringToInt64Slice(couponInfo.Subscribe)
+	if subscribeId != 0 && len(couponSub) > 0 && !tool.Contains(couponSub, subscribeId) {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
+	}
+	couponPayment := tool.StringToInt64Slice(couponInfo.Payment)
+	if paymentId != 0 && len(couponPayment) > 0 && !tool.Contains(couponPayment, paymentId) {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match payment method")
+	}
+	var count int64
+	err = couponUsageQuery(svcCtx.DB.WithContext(ctx), userId, code).Count(&count).Error
+	if err != nil {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
+	}
+	if couponInfo.UserLimit > 0 && count >= couponInfo.UserLimit {
+		return nil, 0, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon limit exceeded")
+	}
+	return couponInfo, count, nil
+}
+
+// couponReleasedStatuses are the order statuses that give a coupon use back to the user,
+// an abandoned or failed checkout must not count toward the coupon UserLimit.
+var couponReleasedStatuses = []uint8{order.StatusClose, order.StatusFailed}
+
+// couponUsageQuery selects the orders of a user that count toward the coupon UserLimit.
+func couponUsageQuery(db *gorm.DB, userId int64, code string) *gorm.DB {
+	return db.Model(&order.Order{}).Where("user_id = ? and coupon = ? and status NOT IN ?", userId, code, couponReleasedStatuses)
+}
@@ -0,0 +1,73 @@
+package order
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestCouponUsageQueryExcludesCancelledOrders(t *testing.T) {
+	db, err := gorm.Open(mysql.New(mysql.Config{
+		DSN:                       "user:pass@tcp(127.0.0.1:3306)/ppanel",
+		SkipInitializeWithVersion: true,
+	}), &gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	require.NoError(t, err)
+
+	var count int64
+	stmt := couponUsageQuery(db.Session(&gorm.Session{DryRun: true}), 1, "WELCOME").Count(&count).Statement
+	assert.Contains(t, stmt.SQL.String(), "status NOT IN")
+	assert.Equal(t, []interface{}{int64(1), "WELCOME", couponReleasedStatuses}, stmt.Vars)
+
+	assert.NotContains(t, couponReleasedStatuses, order.StatusPaid)
+	assert.NotContains(t, couponReleasedStatuses, order.StatusFinished)
+}
+
+// TestCancelledOrderReleasesCouponUserLimit closes the pending order holding the only use a user has of
+// a coupon, the coupon has to be applicable to the user again afterwards.
+func TestCancelledOrderReleasesCouponUserLimit(t *testing.T) {
+	svcCtx := newOrderTestContext(t)
+	ctx := context.Background()
+
+	sub := newTestSubscribe(t, svcCtx, -1)
+	couponInfo := &coupon.Coupon{
+		Name:       t.Name(),
+		Code:       coupon.NewCode(),
+		Type:       coupon.TypeFixedAmount,
+		Discount:   100,
+		UserLimit:  1,
+		UsedCount:  1,
+		ExpireTime: time.Now().Add(time.Hour).Unix(),
+	}
+	require.NoError(t, svcCtx.CouponModel.Insert(ctx, couponInfo))
+	t.Cleanup(func() { _ = svcCtx.CouponModel.Delete(ctx, couponInfo.Id) })
+
+	orderInfo := newTestOrder(t, svcCtx, &order.Order{
+		UserId:      time.Now().UnixNano(),
+		SubscribeId: sub.Id,
+		Coupon:      couponInfo.Code,
+		Status:      order.StatusPending,
+	})
+
+	// the pending order holds the coupon
+	_, _, err := findApplicableCoupon(ctx, svcCtx, couponInfo.Code, 0, orderInfo.UserId, 0, 0)
+	assert.Equal(t, xerr.CouponInsufficientUsage, errCode(err))
+
+	// closing the abandoned checkout hands the coupon back
+	require.NoError(t, NewCloseOrderLogic(ctx, svcCtx).CloseOrder(&types.CloseOrderRequest{OrderNo: orderInfo.OrderNo}))
+	_, used, err := findApplicableCoupon(ctx, svcCtx, couponInfo.Code, 0, orderInfo.UserId, 0, 0)
+	require.NoError(t, err)
+	assert.Zero(t, used)
+
+	couponInfo, err = svcCtx.CouponModel.FindOne(ctx, couponInfo.Id)
+	require.NoError(t, err)
+	assert.Zero(t, couponInfo.UsedCount)
+}
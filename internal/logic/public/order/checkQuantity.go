@@ -0,0 +1,36 @@
+package order
+
+import (
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// CheckQuantity validates the quantity of an order of the plan in place. A quantity of 0 or less is
+// ordered as the plan's DefaultQuantity, or its minimum when none is set; in strict mode it fails with
+// QuantityBelowMinimum instead. A quantity below the plan's MinQuantity fails with QuantityBelowMinimum
+// carrying the minimum, it is never raised to it. MaxQuantity caps every plan and is checked last, the
+// admin cannot save a minimum or default above it. The errors carry the bound so clients can render a
+// localized message with it. sub may be nil when no plan rule applies, the minimum is 1 then.
+func CheckQuantity(quantity *int64, sub *subscribe.Subscribe, strict bool) error {
+	minimum := int64(1)
+	if sub != nil {
+		minimum = sub.MinOrderQuantity()
+	}
+	if *quantity <= 0 {
+		if strict {
+			return errors.Wrapf(xerr.NewErrCodeData(xerr.QuantityBelowMinimum, map[string]int64{"min": minimum}), "quantity %d is below the minimum of %d", *quantity, minimum)
+		}
+		*quantity = minimum
+		if sub != nil {
+			*quantity = sub.DefaultOrderQuantity()
+		}
+	}
+	if *quantity < minimum {
+		return errors.Wrapf(xerr.NewErrCodeData(xerr.QuantityBelowMinimum, map[string]int64{"min": minimum}), "quantity %d is below the minimum of %d", *quantity, minimum)
+	}
+	if *quantity > MaxQuantity {
+		return errors.Wrapf(xerr.NewErrCodeData(xerr.QuantityExceedsLimit, map[string]int64{"limit": MaxQuantity}), "quantity exceeds maximum limit of %d", MaxQuantity)
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckQuantity(t *testing.T) {
+	quantity := int64(0)
+	assert.NoError(t, CheckQuantity(&quantity, nil, false))
+	assert.Equal(t, int64(1), quantity)
+
+	quantity = 0
+	var e *xerr.CodeError
+	assert.True(t, errors.As(errors.Cause(CheckQuantity(&quantity, nil, true)), &e))
+	assert.Equal(t, xerr.QuantityBelowMinimum, e.GetErrCode())
+	assert.Equal(t, int64(0), quantity)
+
+	quantity = MaxQuantity
+	assert.NoError(t, CheckQuantity(&quantity, nil, true))
+
+	quantity = MaxQuantity + 1
+	assert.True(t, errors.As(errors.Cause(CheckQuantity(&quantity, nil, false)), &e))
+	assert.Equal(t, xerr.QuantityExceedsLimit, e.GetErrCode())
+	assert.Equal(t, map[string]int64{"limit": MaxQuantity}, e.GetErrData())
+}
+
+func TestCheckQuantityPlan(t *testing.T) {
+	sub := &subscribe.Subscribe{MinQuantity: 3, DefaultQuantity: 6}
+
+	quantity := int64(0)
+	assert.NoError(t, CheckQuantity(&quantity, sub, false))
+	assert.Equal(t, int64(6), quantity)
+
+	// without a default the minimum is ordered
+	quantity = 0
+	assert.NoError(t, CheckQuantity(&quantity, &subscribe.Subscribe{MinQuantity: 3}, false))
+	assert.Equal(t, int64(3), quantity)
+
+	quantity = 2
+	var e *xerr.CodeError
+	assert.True(t, errors.As(errors.Cause(CheckQuantity(&quantity, sub, false)), &e))
+	assert.Equal(t, xerr.QuantityBelowMinimum, e.GetErrCode())
+	assert.Equal(t, map[string]int64{"min": 3}, e.GetErrData())
+	assert.Equal(t, int64(2), quantity)
+
+	quantity = 0
+	assert.True(t, errors.As(errors.Cause(CheckQuantity(&quantity, sub, true)), &e))
+	assert.Equal(t, map[string]int64{"min": 3}, e.GetErrData())
+
+	quantity = 3
+	assert.NoError(t, CheckQuantity(&quantity, sub, true))
+}
@@ -0,0 +1,75 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// Extended payment windows
+//
+// The close task of an order is enqueued with the order and cannot be moved. Extending the payment
+// window stores the new deadline and enqueues another close task carrying it, close tasks carrying
+// another deadline are skipped.
+
+// extendClose extends the payment window of a pending order by the configured minutes.
+func (l *ResendPaymentLogic) extendClose(orderInfo *order.Order) error {
+	minutes := l.svcCtx.Config.Queue.ResendExtendMinutes
+	if minutes <= 0 || orderInfo.BulkOrderNo != "" {
+		return nil
+	}
+	key := config.OrderCloseDeadlineKeyPrefix + orderInfo.OrderNo
+	count, err := l.svcCtx.Redis.HGet(l.ctx, key, "count").Int64()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		l.Errorw("[ResendPayment] Get close deadline failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "get close deadline error: %v", err.Error())
+	}
+	if limit := l.svcCtx.Config.Queue.ResendExtendLimit; limit > 0 && count >= limit {
+		return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "payment window extended %d times already", count)
+	}
+
+	window := time.Duration(minutes) * time.Minute
+	deadline := time.Now().Add(window).Unix()
+	// enqueue the new close first, the stored deadline skips the earlier ones
+	val, _ := json.Marshal(queue.DeferCloseOrderPayload{OrderNo: orderInfo.OrderNo, Deadline: deadline})
+	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+	if _, err = l.svcCtx.Queue.EnqueueContext(l.ctx, task, asynq.ProcessIn(window)); err != nil {
+		l.Errorw("[ResendPayment] Enqueue close failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "enqueue close error: %v", err.Error())
+	}
+	// kept past the deadline so retries of the close still find it
+	pipe := l.svcCtx.Redis.TxPipeline()
+	pipe.HSet(l.ctx, key, "deadline", deadline)
+	pipe.HIncrBy(l.ctx, key, "count", 1)
+	pipe.Expire(l.ctx, key, window+24*time.Hour)
+	if _, err = pipe.Exec(l.ctx); err != nil {
+		l.Errorw("[ResendPayment] Store close deadline failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+		return errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "store close deadline error: %v", err.Error())
+	}
+	l.Infow("[ResendPayment] Payment window extended", logger.Field("orderNo", orderInfo.OrderNo), logger.Field("deadline", deadline))
+	return nil
+}
+
+// CloseSuperseded reports whether the payment window of the order was extended past the close task's deadline.
+func CloseSuperseded(ctx context.Context, svcCtx *svc.ServiceContext, payload *queue.DeferCloseOrderPayload) bool {
+	val, err := svcCtx.Redis.HGet(ctx, config.OrderCloseDeadlineKeyPrefix+payload.OrderNo, "deadline").Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.WithContext(ctx).Error("[DeferCloseOrder] Get close deadline failed", logger.Field("error", err.Error()), logger.Field("orderNo", payload.OrderNo))
+		}
+		return false
+	}
+	deadline, _ := strconv.ParseInt(val, 10, 64)
+	return deadline != payload.Deadline
+}
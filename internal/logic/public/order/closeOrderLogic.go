@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/hibiken/asynq"
 	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/pkg/payment/stripe"
@@ -12,10 +13,14 @@ import (
 
 	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
 	"github.com/perfect-panel/server/pkg/payment/alipay"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
 )
 
 type CloseOrderLogic struct {
@@ -33,7 +38,25 @@ func NewCloseOrderLogic(ctx context.Context, svcCtx *svc.ServiceContext) *CloseO
 	}
 }
 
+// CloseOrder closes an unpaid order the user cancelled.
 func (l *CloseOrderLogic) CloseOrder(req *types.CloseOrderRequest) error {
+	_, err := l.closeOrder(req, queue.OrderCloseReasonCancel)
+	return err
+}
+
+// CloseExpiredOrder closes an unpaid order whose payment window ran out.
+func (l *CloseOrderLogic) CloseExpiredOrder(req *types.CloseOrderRequest) error {
+	_, err := l.closeOrder(req, queue.OrderCloseReasonTimeout)
+	return err
+}
+
+// ForceCloseOrder closes an unpaid order on behalf of an admin and reports whether it was closed,
+// orders that are paid, closed already or part of a bulk renewal are left as they are.
+func (l *CloseOrderLogic) ForceCloseOrder(orderNo string) (bool, error) {
+	return l.closeOrder(&types.CloseOrderRequest{OrderNo: orderNo}, queue.OrderCloseReasonAdmin)
+}
+
+func (l *CloseOrderLogic) closeOrder(req *types.CloseOrderRequest, reason string) (bool, error) {
 	// Find order information by order number
 	orderInfo, err := l.svcCtx.OrderModel.FindOneByOrderNo(l.ctx, req.OrderNo)
 	if err != nil {
@@ -41,29 +64,48 @@ func (l *CloseOrderLogic) CloseOrder(req *types.CloseOrderRequest) error {
 			logger.Field("error", err.Error()),
 			logger.Field("orderNo", req.OrderNo),
 		)
-		return nil
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
 	}
-	// If the order status is not 1, it means that the order has been closed or paid
-	if orderInfo.Status != 1 {
-		l.Infow("[CloseOrder] Order status is not 1",
+	// Only pending and held orders are still unpaid, the others have been closed or paid
+	if orderInfo.Status != order.StatusPending && orderInfo.Status != order.StatusHold {
+		l.Infow("[CloseOrder] Order is not unpaid",
 			logger.Field("orderNo", req.OrderNo),
 			logger.Field("status", orderInfo.Status),
 		)
-		return nil
+		return false, nil
 	}
 
-	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, orderInfo.SubscribeId)
-	if err != nil {
-		l.Errorw("[CloseOrder] Find subscribe info failed",
-			logger.Field("error", err.Error()),
-			logger.Field("subscribeId", orderInfo.SubscribeId),
+	// Renewal orders of a bulk renewal are only closed together with their bulk order
+	if orderInfo.BulkOrderNo != "" {
+		l.Infow("[CloseOrder] Order belongs to a bulk renewal",
+			logger.Field("orderNo", req.OrderNo),
+			logger.Field("bulkOrderNo", orderInfo.BulkOrderNo),
 		)
-		return nil
+		return false, nil
+	}
+
+	// A bulk renewal order has no subscribe of its own and takes no inventory
+	var sub *subscribe.Subscribe
+	if orderInfo.Type != order.TypeBulkRenewal {
+		sub, err = l.svcCtx.SubscribeModel.FindOne(l.ctx, orderInfo.SubscribeId)
+		if err != nil {
+			l.Errorw("[CloseOrder] Find subscribe info failed",
+				logger.Field("error", err.Error()),
+				logger.Field("subscribeId", orderInfo.SubscribeId),
+			)
+			return false, nil
+		}
 	}
 
 	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
-		// update order status
-		err := tx.Model(&order.Order{}).Where("order_no = ?", req.OrderNo).Update("status", 3).Error
+		// Claim the order first, a payment or another close that changed it since it was read fails here
+		err := l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, req.OrderNo, orderInfo.Status, order.StatusClose, tx)
+		if errors.Is(err, order.ErrOrderStatusChanged) {
+			return err
+		}
 		if err != nil {
 			l.Errorw("[CloseOrder] Update order status failed",
 				logger.Field("error", err.Error()),
@@ -71,6 +113,25 @@ func (l *CloseOrderLogic) CloseOrder(req *types.CloseOrderRequest) error {
 			)
 			return err
 		}
+		if orderInfo.Type == order.TypeBulkRenewal {
+			if err = l.svcCtx.OrderModel.UpdateBulkItemsStatus(l.ctx, orderInfo.OrderNo, order.StatusPending, order.StatusClose, tx); err != nil {
+				l.Errorw("[CloseOrder] Close bulk renewal orders failed",
+					logger.Field("error", err.Error()),
+					logger.Field("orderNo", req.OrderNo),
+				)
+				return err
+			}
+		}
+		// give back the coupon use taken when the order was created
+		if orderInfo.Coupon != "" {
+			if err = l.svcCtx.CouponModel.DecreaseUsedCount(l.ctx, orderInfo.Coupon, tx); err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				l.Errorw("[CloseOrder] Restore coupon usage failed",
+					logger.Field("error", err.Error()),
+					logger.Field("coupon", orderInfo.Coupon),
+				)
+				return err
+			}
+		}
 		// If User ID is 0, it means that the order is a guest order and does not need to be refunded, the order can be deleted directly
 		if orderInfo.UserId == 0 {
 			err = tx.Model(&order.Order{}).Where("order_no = ?", req.OrderNo).Delete(&order.Order{}).Error
@@ -93,50 +154,116 @@ func (l *CloseOrderLogic) CloseOrder(req *types.CloseOrderRequest) error {
 				)
 				return err
 			}
-			deduction := userInfo.GiftAmount + orderInfo.GiftAmount
-			err = tx.Model(&user.User{}).Where("id = ?", orderInfo.UserId).Update("gift_amount", deduction).Error
+			// each bucket gets back what the order took from it, an expired promo credit is zeroed again by the expiry task
+			buckets := []struct {
+				column, bucket  string
+				amount, balance int64
+			}{
+				{column: "promo_credit", bucket: log.GiftBucketPromo, amount: orderInfo.PromoCredit, balance: userInfo.PromoCredit},
+				{column: "gift_amount", amount: orderInfo.GiftAmount - orderInfo.PromoCredit, balance: userInfo.GiftAmount},
+			}
+			for _, b := range buckets {
+				if b.amount <= 0 {
+					continue
+				}
+				// release the reservation atomically, pending orders of the same user may pick it up at checkout
+				err = tx.Model(&user.User{}).Where("id = ?", orderInfo.UserId).UpdateColumn(b.column, gorm.Expr(b.column+" + ?", b.amount)).Error
+				if err != nil {
+					l.Errorw("[CloseOrder] Refund deduction amount failed",
+						logger.Field("error", err.Error()),
+						logger.Field("uid", orderInfo.UserId),
+						logger.Field("deduction", b.amount),
+					)
+					return err
+				}
+				// Record the deduction refund log
+				giftLog := log.Gift{
+					Type:        log.GiftTypeIncrease,
+					OrderNo:     orderInfo.OrderNo,
+					SubscribeId: 0,
+					Amount:      b.amount,
+					Balance:     b.balance + b.amount,
+					Bucket:      b.bucket,
+					Remark:      "Order cancellation refund",
+					Timestamp:   time.Now().UnixMilli(),
+				}
+				content, _ := giftLog.Marshal()
+
+				err = tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+					Id:       0,
+					Type:     log.TypeGift.Uint8(),
+					Date:     log.Date(time.Now()),
+					ObjectID: userInfo.Id,
+					Content:  string(content),
+				}).Error
+				if err != nil {
+					l.Errorw("[CloseOrder] Record cancellation refund log failed",
+						logger.Field("error", err.Error()),
+						logger.Field("uid", orderInfo.UserId),
+						logger.Field("deduction", b.amount),
+					)
+					return err
+				}
+			}
+			// update user cache
+			if err = l.svcCtx.UserModel.UpdateUserCache(l.ctx, userInfo); err != nil {
+				return err
+			}
+		}
+		// refund loyalty credit consumed by a renewal
+		if orderInfo.LoyaltyCredit > 0 {
+			userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, orderInfo.UserId)
+			if err != nil {
+				l.Errorw("[CloseOrder] Find user info failed",
+					logger.Field("error", err.Error()),
+					logger.Field("user_id", orderInfo.UserId),
+				)
+				return err
+			}
+			// release the reservation atomically, a reward granted since the user was read must not be overwritten
+			err = tx.Model(&user.User{}).Where("id = ?", orderInfo.UserId).UpdateColumn("loyalty_credit", gorm.Expr("loyalty_credit + ?", orderInfo.LoyaltyCredit)).Error
 			if err != nil {
-				l.Errorw("[CloseOrder] Refund deduction amount failed",
+				l.Errorw("[CloseOrder] Refund loyalty credit failed",
 					logger.Field("error", err.Error()),
 					logger.Field("uid", orderInfo.UserId),
-					logger.Field("deduction", orderInfo.GiftAmount),
+					logger.Field("loyalty_credit", orderInfo.LoyaltyCredit),
 				)
 				return err
 			}
-			// Record the deduction refund log
-
-			giftLog := log.Gift{
-				Type:        log.GiftTypeIncrease,
-				OrderNo:     orderInfo.OrderNo,
-				SubscribeId: 0,
-				Amount:      orderInfo.GiftAmount,
-				Balance:     deduction,
-				Remark:      "Order cancellation refund",
-				Timestamp:   time.Now().UnixMilli(),
+			var credit int64
+			if err = tx.Model(&user.User{}).Where("id = ?", orderInfo.UserId).Pluck("loyalty_credit", &credit).Error; err != nil {
+				return err
 			}
-			content, _ := giftLog.Marshal()
-
+			creditLog := log.LoyaltyCredit{
+				Type:      log.LoyaltyCreditTypeIncrease,
+				OrderNo:   orderInfo.OrderNo,
+				Amount:    orderInfo.LoyaltyCredit,
+				Balance:   credit,
+				Remark:    "Order cancellation refund",
+				Timestamp: time.Now().UnixMilli(),
+			}
+			content, _ := creditLog.Marshal()
 			err = tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
-				Id:       0,
-				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
+				Type:     log.TypeLoyaltyCredit.Uint8(),
+				Date:     log.Date(time.Now()),
 				ObjectID: userInfo.Id,
 				Content:  string(content),
 			}).Error
 			if err != nil {
-				l.Errorw("[CloseOrder] Record cancellation refund log failed",
+				l.Errorw("[CloseOrder] Record loyalty credit refund log failed",
 					logger.Field("error", err.Error()),
 					logger.Field("uid", orderInfo.UserId),
-					logger.Field("deduction", orderInfo.GiftAmount),
+					logger.Field("loyalty_credit", orderInfo.LoyaltyCredit),
 				)
 				return err
 			}
-			// update user cache
-			return l.svcCtx.UserModel.UpdateUserCache(l.ctx, userInfo)
+			userInfo.LoyaltyCredit = credit
+			if err = l.svcCtx.UserModel.UpdateUserCache(l.ctx, userInfo); err != nil {
+				return err
+			}
 		}
-		if sub.Inventory != -1 {
-			sub.Inventory++
-			if e := l.svcCtx.SubscribeModel.Update(l.ctx, sub, tx); e != nil {
+		if sub != nil && sub.Inventory != -1 {
+			if e := l.svcCtx.SubscribeModel.IncreaseInventory(l.ctx, sub.Id, tx); e != nil {
 				l.Errorw("[CloseOrder] Restore subscribe inventory failed",
 					logger.Field("error", e.Error()),
 					logger.Field("subscribeId", sub.Id),
@@ -147,11 +274,32 @@ func (l *CloseOrderLogic) CloseOrder(req *types.CloseOrderRequest) error {
 
 		return nil
 	})
+	if errors.Is(err, order.ErrOrderStatusChanged) {
+		l.Infow("[CloseOrder] Order was paid or closed in the meantime", logger.Field("orderNo", req.OrderNo))
+		return false, nil
+	}
 	if err != nil {
 		logger.Errorf("[CloseOrder] Transaction failed: %v", err.Error())
-		return err
+		return false, err
+	}
+	l.notifyClosed(orderInfo, reason)
+	return true, nil
+}
+
+// notifyClosed enqueues the closed order notice. The order is closed already, a failure is only logged.
+func (l *CloseOrderLogic) notifyClosed(orderInfo *order.Order, reason string) {
+	// guest orders are deleted on close and have no account to notify, admins close orders while
+	// investigating the account and the user is not told
+	if len(l.svcCtx.Config.CloseNotify.Channels) == 0 || orderInfo.UserId == 0 || reason == queue.OrderCloseReasonAdmin {
+		return
+	}
+	val, _ := json.Marshal(queue.ForthwithOrderClosedNotifyPayload{OrderNo: orderInfo.OrderNo, Reason: reason})
+	if _, err := l.svcCtx.Queue.EnqueueContext(l.ctx, asynq.NewTask(queue.ForthwithOrderClosedNotify, val, asynq.MaxRetry(3))); err != nil {
+		l.Errorw("[CloseOrder] Enqueue closed notify failed",
+			logger.Field("error", err.Error()),
+			logger.Field("orderNo", orderInfo.OrderNo),
+		)
 	}
-	return nil
 }
 
 // confirmationPayment Determine whether the payment is successful
@@ -0,0 +1,79 @@
+package order
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// The close tests are opt-in integration tests, the order status claims need a real MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/logic/public/order/
+func newOrderTestContext(t *testing.T) *svc.ServiceContext {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&order.Order{}, &coupon.Coupon{}, &subscribe.Subscribe{}, &user.User{}, &payment.Payment{}, &log.SystemLog{}))
+	mr := miniredis.RunT(t)
+	rds := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	return &svc.ServiceContext{
+		DB:             db,
+		Redis:          rds,
+		OrderModel:     order.NewModel(db, rds),
+		CouponModel:    coupon.NewModel(db, rds),
+		SubscribeModel: subscribe.NewModel(db, rds),
+		UserModel:      user.NewModel(db, rds),
+		PaymentModel:   payment.NewModel(db, rds),
+	}
+}
+
+// newTestSubscribe inserts a plan with the given inventory, -1 is unlimited.
+func newTestSubscribe(t *testing.T, svcCtx *svc.ServiceContext, inventory int64) *subscribe.Subscribe {
+	ctx := context.Background()
+	sub := &subscribe.Subscribe{Name: t.Name(), Inventory: inventory, UnitPrice: 1000, UnitTime: "Month"}
+	require.NoError(t, svcCtx.SubscribeModel.Insert(ctx, sub))
+	t.Cleanup(func() { _ = svcCtx.SubscribeModel.Delete(ctx, sub.Id) })
+	return sub
+}
+
+// newTestOrder inserts the order under a new order number.
+func newTestOrder(t *testing.T, svcCtx *svc.ServiceContext, data *order.Order) *order.Order {
+	ctx := context.Background()
+	data.OrderNo = tool.GenerateTradeNo()
+	require.NoError(t, svcCtx.OrderModel.Insert(ctx, data))
+	t.Cleanup(func() { _ = svcCtx.DB.Unscoped().Where("order_no = ?", data.OrderNo).Delete(&order.Order{}).Error })
+	return data
+}
+
+func errCode(err error) uint32 {
+	var e *xerr.CodeError
+	if errors.As(errors.Cause(err), &e) {
+		return e.GetErrCode()
+	}
+	return 0
+}
+
+// orderStatus reads the order status from the database.
+func orderStatus(t *testing.T, svcCtx *svc.ServiceContext, orderNo string) uint8 {
+	var status uint8
+	require.NoError(t, svcCtx.DB.Model(&order.Order{}).Where("order_no = ?", orderNo).Pluck("status", &status).Error)
+	return status
+}
@@ -11,4 +11,5 @@ const (
 	MaxOrderAmount    = 2147483647 // int32 max value (2.1 billion)
 	MaxRechargeAmount = 2000000000 // 2 billion, slightly lower for safety
 	MaxQuantity       = 1000       // Maximum quantity per order
+	MaxBulkRenewal    = 50         // Maximum subscriptions per bulk renewal order
 )
@@ -0,0 +1,53 @@
+package order
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+)
+
+// deductGift takes the gift deduction of an order from the user balances and returns the total deducted
+// and the promo credit part of it. With promo enabled the unexpired promo credit is spent first,
+// both buckets together stay within maxGiftDeduction of the amount.
+func deductGift(u *user.User, amount, percent int64, promo bool, now time.Time) (total, promoCredit int64) {
+	limit := maxGiftDeduction(amount, percent)
+	if promo {
+		promoCredit = min(u.AvailablePromoCredit(now), limit)
+		u.PromoCredit -= promoCredit
+		limit -= promoCredit
+	}
+	var gift int64
+	if u.GiftAmount > 0 {
+		gift = min(u.GiftAmount, limit)
+		u.GiftAmount -= gift
+	}
+	return promoCredit + gift, promoCredit
+}
+
+// giftDeductionLogs returns one reduce log per bucket the order deducted from, each with the balance left in it.
+func giftDeductionLogs(u *user.User, orderNo string, total, promoCredit int64, remark string, now time.Time) []log.Gift {
+	var logs []log.Gift
+	if promoCredit > 0 {
+		logs = append(logs, log.Gift{
+			Type:      log.GiftTypeReduce,
+			OrderNo:   orderNo,
+			Amount:    promoCredit,
+			Balance:   u.PromoCredit,
+			Bucket:    log.GiftBucketPromo,
+			Remark:    remark,
+			Timestamp: now.UnixMilli(),
+		})
+	}
+	if gift := total - promoCredit; gift > 0 {
+		logs = append(logs, log.Gift{
+			Type:      log.GiftTypeReduce,
+			OrderNo:   orderNo,
+			Amount:    gift,
+			Balance:   u.GiftAmount,
+			Remark:    remark,
+			Timestamp: now.UnixMilli(),
+		})
+	}
+	return logs
+}
@@ -0,0 +1,49 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeductGift(t *testing.T) {
+	now := time.Now()
+	future := now.Add(time.Hour)
+	past := now.Add(-time.Hour)
+	tests := []struct {
+		name      string
+		user      user.User
+		amount    int64
+		percent   int64
+		promo     bool
+		wantTotal int64
+		wantPromo int64
+		wantGift  int64 // gift amount left
+		wantLeft  int64 // promo credit left
+	}{
+		{name: "promo first", user: user.User{GiftAmount: 500, PromoCredit: 300, PromoCreditExpiredAt: &future}, amount: 1000, percent: 100, promo: true, wantTotal: 800, wantPromo: 300, wantGift: 0, wantLeft: 0},
+		{name: "promo covers all", user: user.User{GiftAmount: 500, PromoCredit: 1500}, amount: 1000, percent: 100, promo: true, wantTotal: 1000, wantPromo: 1000, wantGift: 500, wantLeft: 500},
+		{name: "shared cap", user: user.User{GiftAmount: 500, PromoCredit: 300, PromoCreditExpiredAt: &future}, amount: 1000, percent: 50, promo: true, wantTotal: 500, wantPromo: 300, wantGift: 300, wantLeft: 0},
+		{name: "expired promo", user: user.User{GiftAmount: 500, PromoCredit: 300, PromoCreditExpiredAt: &past}, amount: 1000, percent: 100, promo: true, wantTotal: 500, wantPromo: 0, wantGift: 0, wantLeft: 300},
+		{name: "disabled", user: user.User{GiftAmount: 500, PromoCredit: 300}, amount: 1000, percent: 100, promo: false, wantTotal: 500, wantPromo: 0, wantGift: 0, wantLeft: 300},
+		{name: "no deduction", user: user.User{GiftAmount: 500, PromoCredit: 300}, amount: 1000, percent: 0, promo: true, wantTotal: 0, wantPromo: 0, wantGift: 500, wantLeft: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.user
+			total, promoCredit := deductGift(&u, tt.amount, tt.percent, tt.promo, now)
+			assert.Equal(t, tt.wantTotal, total)
+			assert.Equal(t, tt.wantPromo, promoCredit)
+			assert.Equal(t, tt.wantGift, u.GiftAmount)
+			assert.Equal(t, tt.wantLeft, u.PromoCredit)
+			logs := giftDeductionLogs(&u, "no", total, promoCredit, "test", now)
+			var sum int64
+			for _, l := range logs {
+				sum += l.Amount
+			}
+			assert.Equal(t, total, sum)
+		})
+	}
+}
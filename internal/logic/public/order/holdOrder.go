@@ -0,0 +1,75 @@
+package order
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// holdDuration returns how long an unpaid order of the payment method is held once its payment window is over,
+// 0 closes it right away. Hold strategy methods without their own duration use the configured default.
+func holdDuration(method *payment.Payment, defaultMinutes int64) time.Duration {
+	if method == nil || method.CloseStrategy != payment.CloseStrategyHold {
+		return 0
+	}
+	minutes := method.HoldMinutes
+	if minutes <= 0 {
+		minutes = defaultMinutes
+	}
+	if minutes <= 0 {
+		return 0
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// HoldOrder moves a pending order of a hold strategy payment method to the hold status and schedules its final close.
+// It reports false when the order has to be closed now. Guest orders are never held, their
+// temporary order data only lives for the payment window.
+func (l *CloseOrderLogic) HoldOrder(orderNo string) (bool, error) {
+	orderInfo, err := l.svcCtx.OrderModel.FindOneByOrderNo(l.ctx, orderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		l.Errorw("[HoldOrder] Find order info failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderNo))
+		return false, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	if orderInfo.Status != order.StatusPending || orderInfo.BulkOrderNo != "" || orderInfo.UserId == 0 {
+		return false, nil
+	}
+	method, err := l.svcCtx.PaymentModel.FindOne(l.ctx, orderInfo.PaymentId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		l.Errorw("[HoldOrder] Find payment method failed", logger.Field("error", err.Error()), logger.Field("payment", orderInfo.PaymentId))
+		return false, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
+	}
+	hold := holdDuration(method, l.svcCtx.Config.Queue.HoldOrderMinutes)
+	if hold == 0 {
+		return false, nil
+	}
+
+	// schedule the final close first, a hold without one would never be closed
+	val, _ := json.Marshal(queue.DeferCloseOrderPayload{OrderNo: orderNo, Final: true})
+	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+	if _, err = l.svcCtx.Queue.EnqueueContext(l.ctx, task, asynq.ProcessIn(hold), asynq.TaskID("hold:"+orderNo)); err != nil && !errors.Is(err, asynq.ErrTaskIDConflict) {
+		l.Errorw("[HoldOrder] Enqueue final close failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderNo))
+		return false, err
+	}
+	err = l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, orderNo, order.StatusPending, order.StatusHold)
+	if err != nil && !errors.Is(err, order.ErrOrderStatusChanged) {
+		l.Errorw("[HoldOrder] Update order status failed", logger.Field("error", err.Error()), logger.Field("orderNo", orderNo))
+		return false, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "hold order error: %v", err.Error())
+	}
+	l.Infow("[HoldOrder] Order held", logger.Field("orderNo", orderNo), logger.Field("hold", hold.String()))
+	return true, nil
+}
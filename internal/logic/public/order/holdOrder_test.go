@@ -0,0 +1,29 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHoldDuration(t *testing.T) {
+	tests := []struct {
+		name   string
+		method *payment.Payment
+		want   time.Duration
+	}{
+		{name: "no payment method", method: nil, want: 0},
+		{name: "cancel strategy", method: &payment.Payment{CloseStrategy: payment.CloseStrategyCancel, HoldMinutes: 60}, want: 0},
+		{name: "hold with own duration", method: &payment.Payment{CloseStrategy: payment.CloseStrategyHold, HoldMinutes: 60}, want: time.Hour},
+		{name: "hold with default duration", method: &payment.Payment{CloseStrategy: payment.CloseStrategyHold}, want: 1440 * time.Minute},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, holdDuration(tt.method, 1440))
+		})
+	}
+	// without any duration a hold strategy falls back to cancelling
+	assert.Zero(t, holdDuration(&payment.Payment{CloseStrategy: payment.CloseStrategyHold}, 0))
+}
@@ -0,0 +1,13 @@
+package order
+
+// maxGiftDeduction returns the part of the order amount that may be paid with gift amount.
+// A percent of 0 disables gift deduction, 100 or more lets the gift amount cover the whole order.
+func maxGiftDeduction(amount, percent int64) int64 {
+	if percent <= 0 {
+		return 0
+	}
+	if percent >= 100 {
+		return amount
+	}
+	return int64(float64(amount) * (float64(percent) / float64(100)))
+}
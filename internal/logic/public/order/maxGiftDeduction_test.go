@@ -0,0 +1,29 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMaxGiftDeduction(t *testing.T) {
+	tests := []struct {
+		name    string
+		amount  int64
+		percent int64
+		want    int64
+	}{
+		{name: "disabled", amount: 1000, percent: 0, want: 0},
+		{name: "negative", amount: 1000, percent: -10, want: 0},
+		{name: "half", amount: 1000, percent: 50, want: 500},
+		{name: "half rounds down", amount: 999, percent: 50, want: 499},
+		{name: "full", amount: 1000, percent: 100, want: 1000},
+		{name: "above full", amount: 1000, percent: 150, want: 1000},
+		{name: "zero amount", amount: 0, percent: 50, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, maxGiftDeduction(tt.amount, tt.percent))
+		})
+	}
+}
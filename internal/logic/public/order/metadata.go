@@ -0,0 +1,40 @@
+package order
+
+import (
+	"encoding/json"
+	"unicode/utf8"
+
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// Order metadata limits, the metadata is informational only and never read by the order flow
+const (
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 512
+)
+
+// encodeMetadata validates the metadata attached to a new order and encodes it for storage,
+// empty metadata is stored as an empty string.
+func encodeMetadata(metadata map[string]string) (string, error) {
+	if len(metadata) == 0 {
+		return "", nil
+	}
+	if len(metadata) > MaxMetadataKeys {
+		return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "metadata exceeds %d keys", MaxMetadataKeys)
+	}
+	for key, value := range metadata {
+		if key == "" || utf8.RuneCountInString(key) > MaxMetadataKeyLength {
+			return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "metadata key must be 1 to %d characters", MaxMetadataKeyLength)
+		}
+		if utf8.RuneCountInString(value) > MaxMetadataValueLength {
+			return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "metadata value of %s exceeds %d characters", key, MaxMetadataValueLength)
+		}
+	}
+	content, err := json.Marshal(metadata)
+	if err != nil {
+		return "", errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "marshal metadata error: %v", err.Error())
+	}
+	return string(content), nil
+}
@@ -0,0 +1,32 @@
+package order
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEncodeMetadata(t *testing.T) {
+	content, err := encodeMetadata(nil)
+	assert.NoError(t, err)
+	assert.Empty(t, content)
+
+	metadata := map[string]string{"source": "newsletter", "ticket": "T-1024"}
+	content, err = encodeMetadata(metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, metadata, order.ParseMetadata(content))
+
+	_, err = encodeMetadata(map[string]string{"": "empty key"})
+	assert.Error(t, err)
+	_, err = encodeMetadata(map[string]string{"note": strings.Repeat("x", MaxMetadataValueLength+1)})
+	assert.Error(t, err)
+
+	tooMany := make(map[string]string)
+	for i := 0; i <= MaxMetadataKeys; i++ {
+		tooMany[strings.Repeat("k", i+1)] = "v"
+	}
+	_, err = encodeMetadata(tooMany)
+	assert.Error(t, err)
+}
@@ -0,0 +1,12 @@
+package order
+
+import "time"
+
+// newOrderWindow returns how far back paid orders count against a user being new,
+// 0 counts every order the user ever paid.
+func newOrderWindow(days int64) time.Duration {
+	if days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
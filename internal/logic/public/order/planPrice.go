@@ -0,0 +1,22 @@
+package order
+
+import (
+	"encoding/json"
+
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/types"
+)
+
+// planPrice returns the list price and the tier discounted amount of a quantity of a plan.
+// Orders store the unit price and discount tiers they were priced with, so passing those
+// instead of the live plan reproduces what the user was quoted after the plan is edited.
+func planPrice(unitPrice int64, discounts string, interpolate bool, quantity int64) (price, amount int64) {
+	var discount float64 = 1
+	if discounts != "" {
+		var dis []types.SubscribeDiscount
+		_ = json.Unmarshal([]byte(discounts), &dis)
+		discount = portal.GetDiscount(dis, quantity, interpolate)
+	}
+	price = unitPrice * quantity
+	return price, int64(float64(price) * discount)
+}
@@ -0,0 +1,41 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPlanPrice(t *testing.T) {
+	price, amount := planPrice(1000, `[{"quantity":3,"discount":90},{"quantity":12,"discount":80}]`, false, 3)
+	assert.Equal(t, int64(3000), price)
+	assert.Equal(t, int64(2700), amount)
+
+	price, amount = planPrice(1000, "", false, 2)
+	assert.Equal(t, int64(2000), price)
+	assert.Equal(t, int64(2000), amount)
+}
+
+func TestPlanPriceSnapshotSurvivesPlanEdit(t *testing.T) {
+	plan := &subscribe.Subscribe{UnitPrice: 1000, Discount: `[{"quantity":3,"discount":90}]`}
+	price, amount := planPrice(plan.UnitPrice, plan.Discount, false, 3)
+	pending := &order.Order{
+		Quantity:     3,
+		Price:        price,
+		Amount:       amount,
+		UnitPrice:    plan.UnitPrice,
+		PlanDiscount: plan.Discount,
+	}
+
+	// the admin raises the price and drops the tier while the order is pending
+	plan.UnitPrice = 1500
+	plan.Discount = ""
+
+	price, amount = planPrice(pending.UnitPrice, pending.PlanDiscount, false, pending.Quantity)
+	assert.Equal(t, pending.Price, price)
+	assert.Equal(t, pending.Amount, amount)
+	_, live := planPrice(plan.UnitPrice, plan.Discount, false, pending.Quantity)
+	assert.NotEqual(t, pending.Amount, live)
+}
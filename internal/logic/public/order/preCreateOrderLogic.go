@@ -2,20 +2,20 @@ package order
 
 import (
 	"context"
-	"encoding/json"
+	"time"
 
-	"github.com/perfect-panel/server/internal/model/order"
-	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/internal/model/payment"
 
 	"github.com/perfect-panel/server/pkg/constant"
 
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
 	"github.com/perfect-panel/server/pkg/xerr"
 	"github.com/pkg/errors"
-	"gorm.io/gorm"
 )
 
 type PreCreateOrderLogic struct {
@@ -44,94 +44,92 @@ func (l *PreCreateOrderLogic) PreCreateOrder(req *types.PurchaseOrderRequest) (r
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
 	}
 
-	if req.Quantity <= 0 {
-		l.Debugf("[PreCreateOrder] Quantity is less than or equal to 0, setting to 1")
-		req.Quantity = 1
+	bundle, err := findBundle(l.ctx, l.svcCtx, req)
+	if err != nil {
+		return nil, err
 	}
-
 	// find subscribe plan
 	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.SubscribeId)
 	if err != nil {
 		l.Errorw("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
 	}
-	var discount float64 = 1
-	if sub.Discount != "" {
-		var dis []types.SubscribeDiscount
-		_ = json.Unmarshal([]byte(sub.Discount), &dis)
-		discount = getDiscount(dis, req.Quantity)
+	// the preview prices what the purchase would order, the limits are left to the purchase
+	if req.Quantity <= 0 {
+		l.Debugf("[PreCreateOrder] Quantity is less than or equal to 0, setting to the plan default")
+		req.Quantity = sub.DefaultOrderQuantity()
+	}
+	price, amount := planPrice(sub.UnitPrice, sub.Discount, sub.DiscountInterpolate, req.Quantity)
+	if bundle != nil {
+		price, amount = bundle.Price, bundle.Price
 	}
-	price := sub.UnitPrice * req.Quantity
-
-	amount := int64(float64(price) * discount)
-	discountAmount := price - amount
-	var couponAmount int64
-	if req.Coupon != "" {
-		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
-			}
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
-		}
-		if couponInfo.Count > 0 && couponInfo.Count <= couponInfo.UsedCount {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponAlreadyUsed), "coupon used")
-		}
-		var count int64
-		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
-			return tx.Model(&order.Order{}).Where("user_id = ? and coupon = ?", u.Id, req.Coupon).Count(&count).Error
-		})
 
+	// find payment method, the preview can be requested before a payment method is selected
+	var paymentInfo *payment.Payment
+	if req.Payment != 0 {
+		paymentInfo, err = l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
 		if err != nil {
-			l.Errorw("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id), logger.Field("coupon", req.Coupon))
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
+			l.Errorw("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
 		}
+	}
 
-		if couponInfo.UserLimit > 0 && count >= couponInfo.UserLimit {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon limit exceeded")
+	var couponInfo *couponModel.Coupon
+	if req.Coupon != "" {
+		var paymentId int64
+		if paymentInfo != nil {
+			paymentId = paymentInfo.Id
 		}
-
-		couponSub := tool.StringToInt64Slice(couponInfo.Subscribe)
-		if len(couponSub) > 0 && !tool.Contains(couponSub, req.SubscribeId) {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
+		couponInfo, _, err = findApplicableCoupon(l.ctx, l.svcCtx, req.Coupon, couponModel.OrderTypePurchase, u.Id, req.SubscribeId, paymentId)
+		if err != nil {
+			return nil, err
 		}
-		couponAmount = calculateCoupon(amount, couponInfo)
 	}
-	amount -= couponAmount
+	// the preview prices by the same stacking rule as the purchase
+	discountAmount, couponAmount := portal.StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	amount = price - discountAmount - couponAmount
 
-	var deductionAmount int64
-	// Check user deduction amount
-	if u.GiftAmount > 0 {
-		if u.GiftAmount >= amount {
-			deductionAmount = amount
-			amount = 0
-		} else {
-			deductionAmount = u.GiftAmount
-			amount -= u.GiftAmount
-		}
+	var paymentDiscount int64
+	if paymentInfo != nil {
+		// Calculate the payment method discount
+		paymentDiscount = portal.CalculatePaymentDiscount(amount, paymentInfo)
+		amount -= paymentDiscount
 	}
+
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment.
+	// The preview deducts from a copy, the user's balances are only taken by the purchase.
+	balances := *u
+	deductionAmount, _ := deductGift(&balances, amount, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount -= deductionAmount
 	var feeAmount int64
-	if req.Payment != 0 {
-		payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
-		if err != nil {
-			l.Errorw("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
-		}
+	if paymentInfo != nil {
 		// Calculate the handling fee
 		if amount > 0 {
-			feeAmount = calculateFee(amount, payment)
+			feeAmount = portal.CalculateFee(amount, paymentInfo)
 		}
 		amount += feeAmount
 	}
+	amount, roundingAdjustment := portal.RoundAmount(amount, l.svcCtx.Config.Currency.RoundingIncrement)
+
+	// tell the client whether the order would count as the user's first purchase
+	isNew, err := l.svcCtx.OrderModel.IsUserEligibleForNewOrder(l.ctx, u.Id, newOrderWindow(l.svcCtx.Config.Subscribe.NewOrderWindow))
+	if err != nil {
+		l.Errorw("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user order error: %v", err.Error())
+	}
 
 	resp = &types.PreOrderResponse{
-		Price:          price,
-		Amount:         amount,
-		Discount:       discountAmount,
-		GiftAmount:     deductionAmount,
-		Coupon:         req.Coupon,
-		CouponDiscount: couponAmount,
-		FeeAmount:      feeAmount,
+		Price:              price,
+		Amount:             amount,
+		Discount:           discountAmount,
+		GiftAmount:         deductionAmount,
+		Coupon:             req.Coupon,
+		CouponDiscount:     couponAmount,
+		BonusDays:          couponInfo.BonusDays(),
+		PaymentDiscount:    paymentDiscount,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		IsNew:              isNew,
 	}
 	return
 }
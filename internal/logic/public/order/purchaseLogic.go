@@ -5,18 +5,20 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/perfect-panel/server/internal/logic/public/portal"
 	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/pkg/constant"
 
 	"github.com/hibiken/asynq"
 	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/model/user"
-	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
 	queue "github.com/perfect-panel/server/queue/types"
 	"github.com/pkg/errors"
 	"gorm.io/gorm"
 
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -53,15 +55,14 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
 	}
 
-	if req.Quantity <= 0 {
-		l.Debugf("[Purchase] Quantity is less than or equal to 0, setting to 1")
-		req.Quantity = 1
+	// a bundle fixes the plan and the quantity
+	bundle, err := findBundle(l.ctx, l.svcCtx, req)
+	if err != nil {
+		return nil, err
 	}
-
-	// Validate quantity limit
-	if req.Quantity > MaxQuantity {
-		l.Errorw("[Purchase] Quantity exceeds maximum limit", logger.Field("quantity", req.Quantity), logger.Field("max", MaxQuantity))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "quantity exceeds maximum limit of %d", MaxQuantity)
+	metadata, err := encodeMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	// find user subscription
@@ -70,10 +71,8 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		l.Errorw("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscription error: %v", err.Error())
 	}
-	if l.svcCtx.Config.Subscribe.SingleModel {
-		if len(userSub) > 0 {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserSubscribeExist), "user has subscription")
-		}
+	if l.svcCtx.Config.Subscribe.SingleModel && len(userSub) > 0 {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserSubscribeExist), "user has subscription")
 	}
 
 	// find subscribe plan
@@ -83,10 +82,28 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		l.Errorw("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
 	}
+	// Validate quantity limit, the quantity of a bundle is fixed by the bundle rather than the plan
+	planRule := sub
+	if bundle != nil {
+		planRule = nil
+	}
+	if err = CheckQuantity(&req.Quantity, planRule, l.svcCtx.Config.Subscribe.StrictQuantity); err != nil {
+		l.Errorw("[Purchase] Invalid quantity", logger.Field("quantity", req.Quantity), logger.Field("min", sub.MinQuantity), logger.Field("max", MaxQuantity))
+		return nil, err
+	}
 	// check subscribe plan status
 	if !*sub.Sell {
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "subscribe not sell")
 	}
+	// a count mode plan provisions a subscription per copy, every copy takes a slot
+	copies := sub.Copies(req.Quantity)
+	if l.svcCtx.Config.Subscribe.SingleModel {
+		if copies > 1 {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserSubscribeLimit), "single subscription mode allows one copy")
+		}
+	} else if limit := l.svcCtx.Config.Subscribe.MaxSubscriptions; limit > 0 && activeSubscriptions(userSub)+copies > limit {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.UserSubscribeLimit), "user subscription limit %d reached", limit)
+	}
 
 	// check subscribe plan inventory
 	if sub.Inventory == 0 {
@@ -101,21 +118,20 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 				count += 1
 			}
 		}
-		if count >= sub.Quota {
+		if count+copies > sub.Quota {
 			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeQuotaLimit), "quota limit")
 		}
 	}
 
-	var discount float64 = 1
-	if sub.Discount != "" {
-		var dis []types.SubscribeDiscount
-		_ = json.Unmarshal([]byte(sub.Discount), &dis)
-		discount = getDiscount(dis, req.Quantity)
+	price, amount := planPrice(sub.UnitPrice, sub.Discount, sub.DiscountInterpolate, req.Quantity)
+	orderType, unitPrice, planDiscount := uint8(1), sub.UnitPrice, sub.Discount
+	var bundleId, bundleCredit int64
+	if bundle != nil {
+		// the bundle is priced as one total, coupon, gift amount and fee apply to it like to a plan price
+		price, amount = bundle.Price, bundle.Price
+		orderType, unitPrice, planDiscount = order.TypeBundle, 0, ""
+		bundleId, bundleCredit = bundle.Id, bundle.Credit
 	}
-	price := sub.UnitPrice * req.Quantity
-	// discount amount
-	amount := int64(float64(price) * discount)
-	discountAmount := price - amount
 
 	// Validate amount to prevent overflow
 	if amount > MaxOrderAmount {
@@ -127,63 +143,43 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "order amount exceeds maximum limit")
 	}
 
-	var coupon int64 = 0
-	// Calculate the coupon deduction
-	if req.Coupon != "" {
-		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
-			}
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
-		}
-		if couponInfo.Count != 0 && couponInfo.Count <= couponInfo.UsedCount {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used")
-		}
-		couponSub := tool.StringToInt64Slice(couponInfo.Subscribe)
-		if len(couponSub) > 0 && !tool.Contains(couponSub, req.SubscribeId) {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
-		}
-		var count int64
-		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
-			return tx.Model(&order.Order{}).Where("user_id = ? and coupon = ?", u.Id, req.Coupon).Count(&count).Error
-		})
-
-		if err != nil {
-			l.Errorw("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id), logger.Field("coupon", req.Coupon))
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
-		}
-		if count >= couponInfo.UserLimit {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon limit exceeded")
-		}
-		coupon = calculateCoupon(amount, couponInfo)
-	}
-	// Calculate the handling fee
-	amount -= coupon
-	var deductionAmount int64
-	// Check user deduction amount
-	if u.GiftAmount > 0 {
-		if u.GiftAmount >= amount {
-			deductionAmount = amount
-			amount = 0
-			u.GiftAmount -= deductionAmount
-		} else {
-			deductionAmount = u.GiftAmount
-			amount -= u.GiftAmount
-			u.GiftAmount = 0
-		}
-	}
 	// find payment method
 	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
 	if err != nil {
 		l.Errorw("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
 	}
-	var feeAmount int64
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[Purchase] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
+
+	var couponInfo *couponModel.Coupon
+	if req.Coupon != "" {
+		couponInfo, _, err = findApplicableCoupon(l.ctx, l.svcCtx, req.Coupon, couponModel.OrderTypePurchase, u.Id, req.SubscribeId, payment.Id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Calculate the plan discount and the coupon deduction by the stacking rule
+	discountAmount, coupon := portal.StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	amount = price - discountAmount - coupon
+	// Calculate the payment method discount
+	paymentDiscount := portal.CalculatePaymentDiscount(amount, payment)
+	amount -= paymentDiscount
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment
+	deductionAmount, promoCredit := deductGift(u, amount, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount -= deductionAmount
+	if err = portal.CheckPaymentAvailable(payment, amount, time.Now()); err != nil {
+		l.Infow("[Purchase] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", amount), logger.Field("user_id", u.Id))
+		return nil, err
+	}
+	var feeAmount, roundingAdjustment int64
 	// Calculate the handling fee
 	if amount > 0 {
-		feeAmount = calculateFee(amount, payment)
+		feeAmount = portal.CalculateFee(amount, payment)
 		amount += feeAmount
+		amount, roundingAdjustment = portal.RoundAmount(amount, l.svcCtx.Config.Currency.RoundingIncrement)
 
 		// Final validation after adding fee
 		if amount > MaxOrderAmount {
@@ -195,71 +191,76 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		}
 	}
 	// query user is new purchase or renewal
-	isNew, err := l.svcCtx.OrderModel.IsUserEligibleForNewOrder(l.ctx, u.Id)
+	isNew, err := l.svcCtx.OrderModel.IsUserEligibleForNewOrder(l.ctx, u.Id, newOrderWindow(l.svcCtx.Config.Subscribe.NewOrderWindow))
 	if err != nil {
 		l.Errorw("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user order error: %v", err.Error())
 	}
 	// create order
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[Purchase] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
 	orderInfo := &order.Order{
-		UserId:         u.Id,
-		OrderNo:        tool.GenerateTradeNo(),
-		Type:           1,
-		Quantity:       req.Quantity,
-		Price:          price,
-		Amount:         amount,
-		Discount:       discountAmount,
-		GiftAmount:     deductionAmount,
-		Coupon:         req.Coupon,
-		CouponDiscount: coupon,
-		PaymentId:      payment.Id,
-		Method:         payment.Platform,
-		FeeAmount:      feeAmount,
-		Status:         1,
-		IsNew:          isNew,
-		SubscribeId:    req.SubscribeId,
+		UserId:             u.Id,
+		OrderNo:            orderNo,
+		Type:               orderType,
+		Quantity:           req.Quantity,
+		Price:              price,
+		UnitPrice:          unitPrice,
+		PlanDiscount:       planDiscount,
+		Amount:             amount,
+		Discount:           discountAmount,
+		GiftAmount:         deductionAmount,
+		PromoCredit:        promoCredit,
+		Coupon:             req.Coupon,
+		CouponDiscount:     coupon,
+		BonusDays:          couponInfo.BonusDays(),
+		PaymentDiscount:    paymentDiscount,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		Status:             initialStatus(l.svcCtx.Config.Subscribe.InstantZeroAmount, amount),
+		IsNew:              isNew,
+		SubscribeId:        req.SubscribeId,
+		BundleId:           bundleId,
+		BundleCredit:       bundleCredit,
+		Metadata:           metadata,
 	}
 	// Database transaction
 	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
 		// update user deduction && Pre deduction ,Return after canceling the order
 		if orderInfo.GiftAmount > 0 {
-			// update user deduction && Pre deduction ,Return after canceling the order
-			if e := l.svcCtx.UserModel.Update(l.ctx, u, db); e != nil {
+			// take the deduction atomically, a concurrent order may have spent the balance since it was read
+			if e := l.svcCtx.UserModel.DeductBalance(l.ctx, u, orderInfo.GiftAmount-orderInfo.PromoCredit, orderInfo.PromoCredit, 0, db); e != nil {
 				l.Errorw("[Purchase] Database update error", logger.Field("error", e.Error()), logger.Field("user", u))
 				return e
 			}
-			// create deduction record
-			giftLog := log.Gift{
-				Type:        log.GiftTypeReduce,
-				OrderNo:     orderInfo.OrderNo,
-				SubscribeId: 0,
-				Amount:      orderInfo.GiftAmount,
-				Balance:     u.GiftAmount,
-				Remark:      "Purchase order deduction",
-				Timestamp:   time.Now().UnixMilli(),
-			}
-			content, _ := giftLog.Marshal()
-
-			if e := db.Model(&log.SystemLog{}).Create(&log.SystemLog{
-				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
-				ObjectID: u.Id,
-				Content:  string(content),
-			}).Error; e != nil {
-				l.Errorw("[Purchase] Database insert error",
-					logger.Field("error", e.Error()),
-					logger.Field("deductionLog", giftLog),
-				)
-				return e
+			// create a deduction record per bucket
+			for _, giftLog := range giftDeductionLogs(u, orderInfo.OrderNo, orderInfo.GiftAmount, orderInfo.PromoCredit, "Purchase order deduction", time.Now()) {
+				if e := log.CreateOrderGift(db, u.Id, &giftLog); e != nil {
+					l.Errorw("[Purchase] Database insert error",
+						logger.Field("error", e.Error()),
+						logger.Field("deductionLog", giftLog),
+					)
+					return e
+				}
 			}
 		}
 
 		if sub.Inventory != -1 {
 			// decrease subscribe plan stock
-			sub.Inventory -= 1
-			// update subscribe plan stock
-			if err = l.svcCtx.SubscribeModel.Update(l.ctx, sub, db); err != nil {
-				l.Errorw("[Purchase] Database update error", logger.Field("error", err.Error()), logger.Field("subscribe", sub))
+			if err = l.svcCtx.SubscribeModel.DecreaseInventory(l.ctx, sub.Id, db); err != nil {
+				l.Errorw("[Purchase] Database update error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+				return err
+			}
+		}
+		if orderInfo.Coupon != "" {
+			// take a coupon use, given back when the order is closed
+			if err = l.svcCtx.CouponModel.IncreaseUsedCount(l.ctx, orderInfo.Coupon, db); err != nil {
+				l.Errorw("[Purchase] Database update error", logger.Field("error", err.Error()), logger.Field("coupon", orderInfo.Coupon))
 				return err
 			}
 		}
@@ -267,28 +268,60 @@ func (l *PurchaseLogic) Purchase(req *types.PurchaseOrderRequest) (resp *types.P
 		// insert order
 		return db.WithContext(l.ctx).Model(&order.Order{}).Create(&orderInfo).Error
 	})
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "gift amount spent by another order")
+	}
+	if errors.Is(err, subscribe.ErrOutOfStock) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeOutOfStock), "subscribe out of stock")
+	}
+	if errors.Is(err, couponModel.ErrCouponExhausted) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used up")
+	}
 	if err != nil {
 		l.Errorw("[Purchase] Database insert error", logger.Field("error", err.Error()), logger.Field("orderInfo", orderInfo))
 
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "insert order error: %v", err.Error())
 	}
-	// Deferred task
-	payload := queue.DeferCloseOrderPayload{
-		OrderNo: orderInfo.OrderNo,
-	}
-	val, err := json.Marshal(payload)
-	if err != nil {
-		l.Errorw("[Purchase] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
+	if orderInfo.Status == order.StatusPaid {
+		// nothing left to pay, the order is provisioned right away and has no close task to give its deductions back
+		if err = activateCreatedPaid(l.ctx, l.svcCtx, orderInfo); err != nil {
+			l.Errorw("[Purchase] Activate order error", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.QueueEnqueueError), "activate order error: %v", err.Error())
+		}
 	}
-	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(3))
-	taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
-	if err != nil {
-		l.Errorw("[Purchase] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
-	} else {
-		l.Infow("[Purchase] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+	if orderInfo.Status != order.StatusPaid {
+		// Deferred task, also for balance payments: the order stays pending until checkout deducts the balance,
+		// so an abandoned balance order still has to be closed and its inventory, coupon and gift amount restored.
+		payload := queue.DeferCloseOrderPayload{
+			OrderNo: orderInfo.OrderNo,
+		}
+		val, err := json.Marshal(payload)
+		if err != nil {
+			l.Errorw("[Purchase] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
+		}
+		task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+		taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
+		if err != nil {
+			l.Errorw("[Purchase] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
+		} else {
+			l.Infow("[Purchase] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+		}
 	}
 
+	// the amounts as stored, so the confirmation matches the order without querying it again
 	return &types.PurchaseOrderResponse{
-		OrderNo: orderInfo.OrderNo,
+		OrderNo:            orderInfo.OrderNo,
+		Inventory:          portal.RemainingInventory(l.ctx, l.svcCtx, sub),
+		Price:              orderInfo.Price,
+		Amount:             orderInfo.Amount,
+		Discount:           orderInfo.Discount,
+		GiftAmount:         orderInfo.GiftAmount,
+		CouponDiscount:     orderInfo.CouponDiscount,
+		BonusDays:          orderInfo.BonusDays,
+		PaymentDiscount:    orderInfo.PaymentDiscount,
+		FeeAmount:          orderInfo.FeeAmount,
+		RoundingAdjustment: orderInfo.RoundingAdjustment,
+		IsNew:              orderInfo.IsNew,
+		Status:             orderInfo.Status,
 	}, nil
 }
@@ -0,0 +1,70 @@
+package order
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QueryBestCouponLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewQueryBestCouponLogic Query the best auto-apply coupon
+func NewQueryBestCouponLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QueryBestCouponLogic {
+	return &QueryBestCouponLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// QueryBestCoupon returns the auto-apply coupon with the largest deduction on a purchase of the plan.
+// Every candidate goes through the same checks as placing the order, coupons without AutoApply are
+// never offered so private codes stay private. An empty code means no coupon applies.
+func (l *QueryBestCouponLogic) QueryBestCoupon(req *types.QueryBestCouponRequest) (resp *types.QueryBestCouponResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.SubscribeId)
+	if err != nil {
+		l.Errorw("[QueryBestCoupon] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+	}
+	if err = CheckQuantity(&req.Quantity, sub, l.svcCtx.Config.Subscribe.StrictQuantity); err != nil {
+		return nil, err
+	}
+	price, amount := planPrice(sub.UnitPrice, sub.Discount, sub.DiscountInterpolate, req.Quantity)
+
+	candidates, err := l.svcCtx.CouponModel.FindAutoApplyCoupons(l.ctx)
+	if err != nil {
+		l.Errorw("[QueryBestCoupon] Database query error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
+	}
+	resp = &types.QueryBestCouponResponse{}
+	for _, candidate := range candidates {
+		couponInfo, _, err := findApplicableCoupon(l.ctx, l.svcCtx, candidate.Code, couponModel.OrderTypePurchase, u.Id, sub.Id, req.Payment)
+		if err != nil {
+			l.Debugf("[QueryBestCoupon] coupon %s not applicable: %v", candidate.Code, err.Error())
+			continue
+		}
+		// a coupon that loses to the plan discount under the best of rule deducts nothing
+		if _, deduction := portal.StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo); deduction > resp.Discount {
+			resp.Code = couponInfo.Code
+			resp.Discount = deduction
+		}
+	}
+	return resp, nil
+}
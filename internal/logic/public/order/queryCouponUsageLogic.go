@@ -0,0 +1,56 @@
+package order
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QueryCouponUsageLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewQueryCouponUsageLogic Query coupon usage
+func NewQueryCouponUsageLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QueryCouponUsageLogic {
+	return &QueryCouponUsageLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// QueryCouponUsage returns the remaining uses of a coupon for the current user.
+// It runs the same checks as placing an order, a remaining value of -1 means unlimited.
+func (l *QueryCouponUsageLogic) QueryCouponUsage(req *types.QueryCouponUsageRequest) (resp *types.QueryCouponUsageResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	couponInfo, used, err := findApplicableCoupon(l.ctx, l.svcCtx, req.Code, 0, u.Id, req.SubscribeId, 0)
+	if err != nil {
+		return nil, err
+	}
+	resp = &types.QueryCouponUsageResponse{
+		Code:          couponInfo.Code,
+		Remaining:     -1,
+		UserUsed:      used,
+		UserLimit:     couponInfo.UserLimit,
+		UserRemaining: -1,
+	}
+	if couponInfo.Count > 0 {
+		resp.Remaining = couponInfo.Count - couponInfo.UsedCount
+	}
+	if couponInfo.UserLimit > 0 {
+		resp.UserRemaining = couponInfo.UserLimit - used
+	}
+	return resp, nil
+}
@@ -2,7 +2,9 @@ package order
 
 import (
 	"context"
+	"encoding/json"
 
+	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -34,6 +36,13 @@ func (l *QueryOrderDetailLogic) QueryOrderDetail(req *types.QueryOrderDetailRequ
 	}
 	resp = &types.OrderDetail{}
 	tool.DeepCopy(resp, orderInfo)
+	resp.Metadata = order.ParseMetadata(orderInfo.Metadata)
+	// show the plan pricing the order was created with rather than the current one
+	if orderInfo.UnitPrice > 0 || orderInfo.PlanDiscount != "" {
+		resp.Subscribe.UnitPrice = orderInfo.UnitPrice
+		resp.Subscribe.Discount = nil
+		_ = json.Unmarshal([]byte(orderInfo.PlanDiscount), &resp.Subscribe.Discount)
+	}
 	// Prevent commission amount leakage
 	resp.Commission = 0
 	return
@@ -5,6 +5,7 @@ import (
 
 	"github.com/perfect-panel/server/pkg/constant"
 
+	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
@@ -47,6 +48,7 @@ func (l *QueryOrderListLogic) QueryOrderList(req *types.QueryOrderListRequest) (
 	for _, item := range data {
 		var orderInfo types.OrderDetail
 		tool.DeepCopy(&orderInfo, item)
+		orderInfo.Metadata = order.ParseMetadata(item.Metadata)
 		// Prevent commission amount leakage
 		orderInfo.Commission = 0
 		resp.List = append(resp.List, orderInfo)
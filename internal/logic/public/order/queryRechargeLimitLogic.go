@@ -0,0 +1,45 @@
+package order
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QueryRechargeLimitLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Query recharge amount limits
+func NewQueryRechargeLimitLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QueryRechargeLimitLogic {
+	return &QueryRechargeLimitLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// QueryRechargeLimit returns the recharge bounds the UI constrains its input to, those of the
+// payment method when one is given and the global ones otherwise.
+func (l *QueryRechargeLimitLogic) QueryRechargeLimit(req *types.QueryRechargeLimitRequest) (resp *types.QueryRechargeLimitResponse, err error) {
+	var method *payment.Payment
+	if req.Payment != 0 {
+		method, err = l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
+		if err != nil {
+			l.Errorw("[QueryRechargeLimit] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "find payment method error: %v", err.Error())
+		}
+	}
+	minAmount, maxAmount := rechargeLimits(l.svcCtx.Config.Currency, method)
+	return &types.QueryRechargeLimitResponse{
+		MinAmount: minAmount,
+		MaxAmount: maxAmount,
+	}, nil
+}
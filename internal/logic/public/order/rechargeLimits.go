@@ -0,0 +1,26 @@
+package order
+
+import (
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/payment"
+)
+
+// rechargeLimits returns the smallest and largest recharge accepted through the payment method.
+// A bound set on the payment method overrides the global one of the currency config, an open
+// minimum still requires 1 and an open maximum falls back to MaxRechargeAmount.
+func rechargeLimits(currency config.Currency, method *payment.Payment) (minAmount, maxAmount int64) {
+	minAmount, maxAmount = currency.MinRecharge, currency.MaxRecharge
+	if method != nil {
+		if method.MinRecharge > 0 {
+			minAmount = method.MinRecharge
+		}
+		if method.MaxRecharge > 0 {
+			maxAmount = method.MaxRecharge
+		}
+	}
+	minAmount = max(minAmount, 1)
+	if maxAmount <= 0 || maxAmount > MaxRechargeAmount {
+		maxAmount = MaxRechargeAmount
+	}
+	return minAmount, maxAmount
+}
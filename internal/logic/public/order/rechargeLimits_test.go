@@ -0,0 +1,32 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRechargeLimits(t *testing.T) {
+	tests := []struct {
+		name     string
+		currency config.Currency
+		method   *payment.Payment
+		wantMin  int64
+		wantMax  int64
+	}{
+		{name: "open bounds", wantMin: 1, wantMax: MaxRechargeAmount},
+		{name: "global bounds", currency: config.Currency{MinRecharge: 500, MaxRecharge: 100000}, wantMin: 500, wantMax: 100000},
+		{name: "payment overrides global", currency: config.Currency{MinRecharge: 500, MaxRecharge: 100000}, method: &payment.Payment{MinRecharge: 1000, MaxRecharge: 50000}, wantMin: 1000, wantMax: 50000},
+		{name: "payment overrides one bound", currency: config.Currency{MinRecharge: 500, MaxRecharge: 100000}, method: &payment.Payment{MaxRecharge: 20000}, wantMin: 500, wantMax: 20000},
+		{name: "max capped", currency: config.Currency{MaxRecharge: MaxRechargeAmount + 1}, wantMin: 1, wantMax: MaxRechargeAmount},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotMin, gotMax := rechargeLimits(tt.currency, tt.method)
+			assert.Equal(t, tt.wantMin, gotMin)
+			assert.Equal(t, tt.wantMax, gotMax)
+		})
+	}
+}
@@ -9,12 +9,13 @@ import (
 	"github.com/perfect-panel/server/pkg/xerr"
 
 	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/logic/public/portal"
 	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
-	"github.com/perfect-panel/server/pkg/tool"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
 	queue "github.com/perfect-panel/server/queue/types"
 	"github.com/pkg/errors"
 )
@@ -46,13 +47,9 @@ func (l *RechargeLogic) Recharge(req *types.RechargeOrderRequest) (resp *types.R
 		l.Errorw("[Recharge] Invalid recharge amount", logger.Field("amount", req.Amount), logger.Field("user_id", u.Id))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "recharge amount must be greater than 0")
 	}
-
-	if req.Amount > MaxRechargeAmount {
-		l.Errorw("[Recharge] Recharge amount exceeds maximum limit",
-			logger.Field("amount", req.Amount),
-			logger.Field("max", MaxRechargeAmount),
-			logger.Field("user_id", u.Id))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "recharge amount exceeds maximum limit")
+	metadata, err := encodeMetadata(req.Metadata)
+	if err != nil {
+		return nil, err
 	}
 
 	// find payment method
@@ -61,9 +58,32 @@ func (l *RechargeLogic) Recharge(req *types.RechargeOrderRequest) (resp *types.R
 		l.Errorw("[Recharge] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
 		return nil, errors.Wrapf(err, "find payment error: %v", err.Error())
 	}
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[Recharge] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
+	// a recharge paid on invoice would credit the balance before any money is received
+	if paymentPlatform.ParsePlatform(payment.Platform) == paymentPlatform.Enterprise {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.EnterprisePaymentDenied), "recharge orders can't be paid on invoice")
+	}
+	// the payment method bounds override the global ones
+	minAmount, maxAmount := rechargeLimits(l.svcCtx.Config.Currency, payment)
+	if req.Amount < minAmount || req.Amount > maxAmount {
+		l.Infow("[Recharge] Recharge amount out of range",
+			logger.Field("amount", req.Amount),
+			logger.Field("min", minAmount),
+			logger.Field("max", maxAmount),
+			logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCodeData(xerr.RechargeOutOfRange, map[string]int64{"min": minAmount, "max": maxAmount}),
+			"recharge amount %d is out of range %d-%d", req.Amount, minAmount, maxAmount)
+	}
+	if err = portal.CheckPaymentAvailable(payment, req.Amount, time.Now()); err != nil {
+		l.Infow("[Recharge] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", req.Amount), logger.Field("user_id", u.Id))
+		return nil, err
+	}
 	// Calculate the handling fee
-	feeAmount := calculateFee(req.Amount, payment)
-	totalAmount := req.Amount + feeAmount
+	feeAmount := portal.CalculateFee(req.Amount, payment)
+	totalAmount, roundingAdjustment := portal.RoundAmount(req.Amount+feeAmount, l.svcCtx.Config.Currency.RoundingIncrement)
 
 	// Validate total amount after adding fee
 	if totalAmount > MaxOrderAmount {
@@ -75,22 +95,29 @@ func (l *RechargeLogic) Recharge(req *types.RechargeOrderRequest) (resp *types.R
 	}
 
 	// query user is new purchase or renewal
-	isNew, err := l.svcCtx.OrderModel.IsUserEligibleForNewOrder(l.ctx, u.Id)
+	isNew, err := l.svcCtx.OrderModel.IsUserEligibleForNewOrder(l.ctx, u.Id, newOrderWindow(l.svcCtx.Config.Subscribe.NewOrderWindow))
 	if err != nil {
 		l.Errorw("[Recharge] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
 		return nil, errors.Wrapf(err, "query user error: %v", err.Error())
 	}
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[Recharge] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
 	orderInfo := order.Order{
-		UserId:    u.Id,
-		OrderNo:   tool.GenerateTradeNo(),
-		Type:      4,
-		Price:     req.Amount,
-		Amount:    totalAmount,
-		FeeAmount: feeAmount,
-		PaymentId: payment.Id,
-		Method:    payment.Platform,
-		Status:    1,
-		IsNew:     isNew,
+		UserId:             u.Id,
+		OrderNo:            orderNo,
+		Type:               4,
+		Price:              req.Amount,
+		Amount:             totalAmount,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		Status:             1,
+		IsNew:              isNew,
+		Metadata:           metadata,
 	}
 	err = l.svcCtx.OrderModel.Insert(l.ctx, &orderInfo)
 	if err != nil {
@@ -105,7 +132,7 @@ func (l *RechargeLogic) Recharge(req *types.RechargeOrderRequest) (resp *types.R
 	if err != nil {
 		l.Errorw("[Recharge] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
 	}
-	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(3))
+	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
 	taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
 	if err != nil {
 		l.Errorw("[Recharge] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
@@ -113,6 +140,10 @@ func (l *RechargeLogic) Recharge(req *types.RechargeOrderRequest) (resp *types.R
 		l.Infow("[Recharge] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
 	}
 	return &types.RechargeOrderResponse{
-		OrderNo: orderInfo.OrderNo,
+		OrderNo:            orderInfo.OrderNo,
+		Amount:             orderInfo.Amount,
+		FeeAmount:          orderInfo.FeeAmount,
+		RoundingAdjustment: orderInfo.RoundingAdjustment,
+		IsNew:              orderInfo.IsNew,
 	}, nil
 }
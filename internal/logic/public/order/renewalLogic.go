@@ -5,18 +5,20 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/perfect-panel/server/internal/logic/public/portal"
 	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/pkg/constant"
 
 	"gorm.io/gorm"
 
 	"github.com/hibiken/asynq"
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
-	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
 	queue "github.com/perfect-panel/server/queue/types"
 	"github.com/pkg/errors"
@@ -45,42 +47,63 @@ func (l *RenewalLogic) Renewal(req *types.RenewalOrderRequest) (resp *types.Rene
 		logger.Error("current user is not found in context")
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
 	}
-	if req.Quantity <= 0 {
-		l.Debugf("[Renewal] Quantity is less than or equal to 0, setting to 1")
-		req.Quantity = 1
-	}
-
-	// Validate quantity limit
-	if req.Quantity > MaxQuantity {
-		l.Errorw("[Renewal] Quantity exceeds maximum limit", logger.Field("quantity", req.Quantity), logger.Field("max", MaxQuantity))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "quantity exceeds maximum limit of %d", MaxQuantity)
-	}
-
-	orderNo := tool.GenerateTradeNo()
 	// find user subscribe
 	userSubscribe, err := l.svcCtx.UserModel.FindOneUserSubscribe(l.ctx, req.UserSubscribeID)
 	if err != nil {
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribe error: %v", err.Error())
 	}
+	if userSubscribe.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "subscribe does not belong to the user")
+	}
+	// a paused subscription keeps its time aside, it has to be resumed before it is extended
+	if userSubscribe.Status == user.SubscribeStatusPaused {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribePaused), "user subscribe %d is paused", userSubscribe.Id)
+	}
+	// A renewal into another plan is priced and checked against that plan, the subscription switches to it once paid
+	subscribeId := userSubscribe.SubscribeId
+	if req.TargetSubscribeId != 0 {
+		subscribeId = req.TargetSubscribeId
+	}
 	// find subscription
-	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, userSubscribe.SubscribeId)
+	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, subscribeId)
 	if err != nil {
-		l.Errorw("[Renewal] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", userSubscribe.SubscribeId))
+		l.Errorw("[Renewal] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", subscribeId))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
 	}
+	// Validate quantity limit against the plan renewed into
+	if err = CheckQuantity(&req.Quantity, sub, l.svcCtx.Config.Subscribe.StrictQuantity); err != nil {
+		l.Errorw("[Renewal] Invalid quantity", logger.Field("quantity", req.Quantity), logger.Field("min", sub.MinQuantity), logger.Field("max", MaxQuantity))
+		return nil, err
+	}
+	// a renewal extends the one subscription, in count mode it is charged as a single copy
+	if sub.QuantityMode == subscribe.QuantityModeCount {
+		req.Quantity = 1
+	}
 	// check subscribe plan status
 	if !*sub.Sell {
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "subscribe not sell")
 	}
-	var discount float64 = 1
-	if sub.Discount != "" {
-		var dis []types.SubscribeDiscount
-		_ = json.Unmarshal([]byte(sub.Discount), &dis)
-		discount = getDiscount(dis, req.Quantity)
+	// switching takes a seat of the target plan, renewing the current plan keeps the one it holds
+	switchPlan := sub.Id != userSubscribe.SubscribeId
+	if switchPlan && sub.Inventory == 0 {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeOutOfStock), "subscribe out of stock")
+	}
+	// Reject renewals that would bank more than MaxRenewalStack periods ahead, counting the renewals not activated yet
+	pending, err := l.svcCtx.OrderModel.FindUnappliedRenewals(l.ctx, userSubscribe.Token)
+	if err != nil {
+		l.Errorw("[Renewal] Database query error", logger.Field("error", err.Error()), logger.Field("user_subscribe_id", userSubscribe.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find pending renewals error: %v", err.Error())
+	}
+	banked := bankedExpire(userSubscribe.ExpireTime, pending, sub, time.Now())
+	if exceedsRenewalStack(banked, sub.UnitTime, sub.Periods(req.Quantity), sub.MaxRenewalStack, time.Now()) {
+		l.Infow("[Renewal] Renewal exceeds the maximum banked time",
+			logger.Field("user_subscribe_id", userSubscribe.Id),
+			logger.Field("expire_time", userSubscribe.ExpireTime),
+			logger.Field("banked_expire_time", banked),
+			logger.Field("max_renewal_stack", sub.MaxRenewalStack))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeRenewalStackLimit), "renewal exceeds %d banked periods", sub.MaxRenewalStack)
 	}
-	price := sub.UnitPrice * req.Quantity
-	amount := int64(float64(price) * discount)
-	discountAmount := price - amount
+	price, amount := planPrice(sub.UnitPrice, sub.Discount, sub.DiscountInterpolate, req.Quantity)
 
 	// Validate amount to prevent overflow
 	if amount > MaxOrderAmount {
@@ -92,64 +115,54 @@ func (l *RenewalLogic) Renewal(req *types.RenewalOrderRequest) (resp *types.Rene
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "order amount exceeds maximum limit")
 	}
 
-	var coupon int64 = 0
-	if req.Coupon != "" {
-		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
-		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
-			}
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
-		}
-		if couponInfo.Count != 0 && couponInfo.Count <= couponInfo.UsedCount {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used")
-		}
-		couponSub := tool.StringToInt64Slice(couponInfo.Subscribe)
-
-		if len(couponSub) > 0 && !tool.Contains(couponSub, sub.Id) {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
-		}
-		var count int64
-		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
-			return tx.Model(&order.Order{}).Where("user_id = ? and coupon = ?", u.Id, req.Coupon).Count(&count).Error
-		})
-		if err != nil {
-			l.Errorw("[Renewal] Database query error", logger.Field("error", err.Error()), logger.Field("user_id", u.Id), logger.Field("coupon", req.Coupon))
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
-		}
-		if count >= couponInfo.UserLimit {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon limit exceeded")
-		}
-		coupon = calculateCoupon(amount, couponInfo)
-	}
+	// find payment method
 	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
 	if err != nil {
 		l.Errorw("[Renewal] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment error: %v", err.Error())
 	}
-	amount -= coupon
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[Renewal] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
 
-	var deductionAmount int64
-	// Check user deduction amount
-	if u.GiftAmount > 0 {
-		if u.GiftAmount >= amount {
-			deductionAmount = amount
-			u.GiftAmount -= deductionAmount
-			amount = 0
-		} else {
-			deductionAmount = u.GiftAmount
-			amount -= u.GiftAmount
-			u.GiftAmount = 0
+	var couponInfo *couponModel.Coupon
+	if req.Coupon != "" {
+		couponInfo, _, err = findApplicableCoupon(l.ctx, l.svcCtx, req.Coupon, couponModel.OrderTypeRenewal, u.Id, sub.Id, payment.Id)
+		if err != nil {
+			return nil, err
 		}
 	}
+	discountAmount, coupon := portal.StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	amount = price - discountAmount - coupon
+	// Calculate the payment method discount
+	paymentDiscount := portal.CalculatePaymentDiscount(amount, payment)
+	amount -= paymentDiscount
+
+	var loyaltyCredit int64
+	// Loyalty credit is only usable on renewals and is consumed before the gift amount
+	if u.LoyaltyCredit > 0 {
+		loyaltyCredit = min(u.LoyaltyCredit, maxGiftDeduction(amount, l.svcCtx.Config.Subscribe.MaxLoyaltyCreditPercent))
+		amount -= loyaltyCredit
+		u.LoyaltyCredit -= loyaltyCredit
+	}
+
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment
+	deductionAmount, promoCredit := deductGift(u, amount, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount -= deductionAmount
+	if err = portal.CheckPaymentAvailable(payment, amount, time.Now()); err != nil {
+		l.Infow("[Renewal] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", amount), logger.Field("user_id", u.Id))
+		return nil, err
+	}
 
 	var feeAmount int64
 	// Calculate the handling fee
 	if amount > 0 {
-		feeAmount = calculateFee(amount, payment)
+		feeAmount = portal.CalculateFee(amount, payment)
 	}
 
 	amount += feeAmount
+	amount, roundingAdjustment := portal.RoundAmount(amount, l.svcCtx.Config.Currency.RoundingIncrement)
 
 	// Final validation after adding fee
 	if amount > MaxOrderAmount {
@@ -161,79 +174,151 @@ func (l *RenewalLogic) Renewal(req *types.RenewalOrderRequest) (resp *types.Rene
 	}
 
 	// create order
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[Renewal] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
 	orderInfo := order.Order{
-		UserId:         u.Id,
-		ParentId:       userSubscribe.OrderId,
-		OrderNo:        orderNo,
-		Type:           2,
-		Quantity:       req.Quantity,
-		Price:          price,
-		Amount:         amount,
-		GiftAmount:     deductionAmount,
-		Discount:       discountAmount,
-		Coupon:         req.Coupon,
-		CouponDiscount: coupon,
-		PaymentId:      payment.Id,
-		Method:         payment.Platform,
-		FeeAmount:      feeAmount,
-		Status:         1,
-		SubscribeId:    userSubscribe.SubscribeId,
-		SubscribeToken: userSubscribe.Token,
+		UserId:             u.Id,
+		ParentId:           userSubscribe.OrderId,
+		OrderNo:            orderNo,
+		Type:               2,
+		Quantity:           req.Quantity,
+		Price:              price,
+		UnitPrice:          sub.UnitPrice,
+		PlanDiscount:       sub.Discount,
+		UnitTime:           sub.UnitTime,
+		Periods:            sub.Periods(req.Quantity),
+		Amount:             amount,
+		GiftAmount:         deductionAmount,
+		PromoCredit:        promoCredit,
+		LoyaltyCredit:      loyaltyCredit,
+		Discount:           discountAmount,
+		Coupon:             req.Coupon,
+		CouponDiscount:     coupon,
+		BonusDays:          couponInfo.BonusDays(),
+		PaymentDiscount:    paymentDiscount,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		Status:             initialStatus(l.svcCtx.Config.Subscribe.InstantZeroAmount, amount),
+		SubscribeId:        sub.Id,
+		SubscribeToken:     userSubscribe.Token,
 	}
 	// Database transaction
 	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
 		// update user deduction && Pre deduction ,Return after canceling the order
-		if orderInfo.GiftAmount > 0 {
-			// update user deduction && Pre deduction ,Return after canceling the order
-			if err := l.svcCtx.UserModel.Update(l.ctx, u, db); err != nil {
+		if orderInfo.GiftAmount > 0 || orderInfo.LoyaltyCredit > 0 {
+			// take the deductions atomically, a concurrent order may have spent the balances since they were read
+			if err := l.svcCtx.UserModel.DeductBalance(l.ctx, u, orderInfo.GiftAmount-orderInfo.PromoCredit, orderInfo.PromoCredit, orderInfo.LoyaltyCredit, db); err != nil {
 				l.Errorw("[Renewal] Database update error", logger.Field("error", err.Error()), logger.Field("user", u))
 				return err
 			}
-			// create deduction record
-			giftLog := log.Gift{
-				Type:        log.GiftTypeReduce,
-				OrderNo:     orderInfo.OrderNo,
-				SubscribeId: 0,
-				Amount:      orderInfo.GiftAmount,
-				Balance:     u.GiftAmount,
-				Remark:      "Renewal order deduction",
-				Timestamp:   time.Now().UnixMilli(),
+		}
+		if orderInfo.LoyaltyCredit > 0 {
+			creditLog := log.LoyaltyCredit{
+				Type:      log.LoyaltyCreditTypeReduce,
+				OrderNo:   orderInfo.OrderNo,
+				Amount:    orderInfo.LoyaltyCredit,
+				Balance:   u.LoyaltyCredit,
+				Remark:    "Renewal order deduction",
+				Timestamp: time.Now().UnixMilli(),
 			}
-			content, _ := giftLog.Marshal()
+			content, _ := creditLog.Marshal()
 
 			if err := db.Model(&log.SystemLog{}).Create(&log.SystemLog{
-				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
+				Type:     log.TypeLoyaltyCredit.Uint8(),
+				Date:     log.Date(time.Now()),
 				ObjectID: u.Id,
 				Content:  string(content),
 			}).Error; err != nil {
-				l.Errorw("[Renewal] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", giftLog))
+				l.Errorw("[Renewal] Database insert error", logger.Field("error", err.Error()), logger.Field("loyaltyCreditLog", creditLog))
+				return err
+			}
+		}
+		if orderInfo.GiftAmount > 0 {
+			// create a deduction record per bucket
+			for _, giftLog := range giftDeductionLogs(u, orderInfo.OrderNo, orderInfo.GiftAmount, orderInfo.PromoCredit, "Renewal order deduction", time.Now()) {
+				if err := log.CreateOrderGift(db, u.Id, &giftLog); err != nil {
+					l.Errorw("[Renewal] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", giftLog))
+					return err
+				}
+			}
+		}
+		if switchPlan && sub.Inventory != -1 {
+			// decrease target plan stock, restored when the order is closed
+			if err := l.svcCtx.SubscribeModel.DecreaseInventory(l.ctx, sub.Id, db); err != nil {
+				l.Errorw("[Renewal] Database update error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+				return err
+			}
+		}
+		if orderInfo.Coupon != "" {
+			// take a coupon use, given back when the order is closed
+			if err := l.svcCtx.CouponModel.IncreaseUsedCount(l.ctx, orderInfo.Coupon, db); err != nil {
+				l.Errorw("[Renewal] Database update error", logger.Field("error", err.Error()), logger.Field("coupon", orderInfo.Coupon))
 				return err
 			}
 		}
 		// insert order
 		return db.Model(&order.Order{}).Create(&orderInfo).Error
 	})
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "gift amount or loyalty credit spent by another order")
+	}
+	if errors.Is(err, subscribe.ErrOutOfStock) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeOutOfStock), "subscribe out of stock")
+	}
+	if errors.Is(err, couponModel.ErrCouponExhausted) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used up")
+	}
 	if err != nil {
 		l.Errorw("[Renewal] Database insert error", logger.Field("error", err.Error()), logger.Field("order", orderInfo))
 		return nil, errors.Wrapf(err, "insert order error: %v", err.Error())
 	}
-	// Deferred task
-	payload := queue.DeferCloseOrderPayload{
-		OrderNo: orderInfo.OrderNo,
-	}
-	val, err := json.Marshal(payload)
-	if err != nil {
-		l.Errorw("[Renewal] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
+	if orderInfo.Status == order.StatusPaid {
+		// nothing left to pay, the order is provisioned right away and has no close task to give its deductions back
+		if err = activateCreatedPaid(l.ctx, l.svcCtx, &orderInfo); err != nil {
+			l.Errorw("[Renewal] Activate order error", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.QueueEnqueueError), "activate order error: %v", err.Error())
+		}
 	}
-	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(3))
-	taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
-	if err != nil {
-		l.Errorw("[Renewal] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
-	} else {
-		l.Infow("[Renewal] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+	if orderInfo.Status != order.StatusPaid {
+		// Deferred task, also for balance payments: the order stays pending until checkout deducts the balance,
+		// so an abandoned balance order still has to be closed and its inventory, coupon and gift amount restored.
+		payload := queue.DeferCloseOrderPayload{
+			OrderNo: orderInfo.OrderNo,
+		}
+		val, err := json.Marshal(payload)
+		if err != nil {
+			l.Errorw("[Renewal] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
+		}
+		task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+		taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
+		if err != nil {
+			l.Errorw("[Renewal] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
+		} else {
+			l.Infow("[Renewal] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+		}
 	}
+	return renewalOrderResponse(&orderInfo), nil
+}
+
+// renewalOrderResponse returns the amounts of the renewal order as stored, so the confirmation matches it
+func renewalOrderResponse(orderInfo *order.Order) *types.RenewalOrderResponse {
 	return &types.RenewalOrderResponse{
-		OrderNo: orderInfo.OrderNo,
-	}, nil
+		OrderNo:            orderInfo.OrderNo,
+		Price:              orderInfo.Price,
+		Amount:             orderInfo.Amount,
+		Discount:           orderInfo.Discount,
+		GiftAmount:         orderInfo.GiftAmount,
+		LoyaltyCredit:      orderInfo.LoyaltyCredit,
+		CouponDiscount:     orderInfo.CouponDiscount,
+		BonusDays:          orderInfo.BonusDays,
+		PaymentDiscount:    orderInfo.PaymentDiscount,
+		FeeAmount:          orderInfo.FeeAmount,
+		RoundingAdjustment: orderInfo.RoundingAdjustment,
+		Status:             orderInfo.Status,
+	}
 }
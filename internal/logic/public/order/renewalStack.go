@@ -0,0 +1,47 @@
+package order
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/pkg/tool"
+)
+
+// exceedsRenewalStack reports whether renewing quantity periods would push the expiry past
+// maxStack periods from now. The renewal is added to the current expiry, or to now when it has
+// already expired, the same way the order is applied on activation.
+// A maxStack of 0 disables the check, unlimited subscriptions and NoLimit plans are never rejected.
+func exceedsRenewalStack(expire time.Time, unit string, quantity, maxStack int64, now time.Time) bool {
+	if maxStack <= 0 || unit == "NoLimit" || expire.Unix() == 0 {
+		return false
+	}
+	base := expire
+	if base.Before(now) {
+		base = now
+	}
+	return tool.AddTime(unit, quantity, base).After(tool.AddTime(unit, maxStack, now))
+}
+
+// bankedExpire returns the expiry the subscription reaches once the renewal orders not activated yet are
+// applied, so renewals ordered one after another can't bank more than one of them could. Orders without
+// a period snapshot are counted in the periods of the plan. An unlimited expiry stays unlimited.
+func bankedExpire(expire time.Time, pending []*order.Order, sub *subscribe.Subscribe, now time.Time) time.Time {
+	if expire.Unix() == 0 {
+		return expire
+	}
+	if expire.Before(now) {
+		expire = now
+	}
+	for _, o := range pending {
+		unit, periods := o.UnitTime, o.Periods
+		if unit == "" {
+			unit, periods = sub.UnitTime, sub.Periods(o.Quantity)
+		}
+		if unit == "NoLimit" {
+			continue
+		}
+		expire = tool.AddTime(unit, periods, expire)
+	}
+	return expire
+}
@@ -0,0 +1,58 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExceedsRenewalStack(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		expire   time.Time
+		unit     string
+		quantity int64
+		maxStack int64
+		want     bool
+	}{
+		{"disabled", now.AddDate(5, 0, 0), "Month", 1, 0, false},
+		{"expired renews from now", now.AddDate(0, -3, 0), "Month", 2, 2, false},
+		{"expired over the limit", now.AddDate(0, -3, 0), "Month", 3, 2, true},
+		{"one month banked plus one", now.AddDate(0, 1, 0), "Month", 1, 2, false},
+		{"two months banked plus one", now.AddDate(0, 2, 0), "Month", 1, 2, true},
+		{"partial period banked", now.AddDate(0, 0, 10), "Month", 2, 2, true},
+		{"unlimited subscription", time.UnixMilli(0), "Month", 12, 1, false},
+		{"no limit plan", now.AddDate(1, 0, 0), "NoLimit", 1, 1, false},
+		{"days", now.AddDate(0, 0, 20), "Day", 10, 30, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, exceedsRenewalStack(tt.expire, tt.unit, tt.quantity, tt.maxStack, now))
+		})
+	}
+}
+
+func TestBankedExpire(t *testing.T) {
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	sub := &subscribe.Subscribe{UnitTime: "Month"}
+	pending := []*order.Order{
+		{Quantity: 1, UnitTime: "Month", Periods: 1},
+		// an order from before the snapshot counts in the plan periods
+		{Quantity: 2},
+		{Quantity: 1, UnitTime: "NoLimit", Periods: 1},
+	}
+	assert.Equal(t, now.AddDate(0, 4, 0), bankedExpire(now.AddDate(0, 1, 0), pending, sub, now))
+	// an expired subscription banks from now
+	assert.Equal(t, now.AddDate(0, 3, 0), bankedExpire(now.AddDate(0, -1, 0), pending, sub, now))
+	assert.Equal(t, now, bankedExpire(now.AddDate(0, -1, 0), nil, sub, now))
+	// unlimited stays unlimited
+	assert.Equal(t, time.UnixMilli(0), bankedExpire(time.UnixMilli(0), pending, sub, now))
+
+	// two renewals of one month each, ordered before either is paid, can't bank past a stack of two
+	banked := bankedExpire(now.AddDate(0, 1, 0), pending[:1], sub, now)
+	assert.True(t, exceedsRenewalStack(banked, "Month", 1, 2, now))
+}
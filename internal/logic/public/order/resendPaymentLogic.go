@@ -0,0 +1,66 @@
+package order
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ResendPaymentLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewResendPaymentLogic Resend the payment link of a pending order
+func NewResendPaymentLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ResendPaymentLogic {
+	return &ResendPaymentLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// ResendPayment checks out a pending order of the user again through its payment method, the stored
+// amount is charged so the price does not change. The payment window is extended on request.
+func (l *ResendPaymentLogic) ResendPayment(req *types.ResendPaymentRequest) (resp *types.CheckoutOrderResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	orderInfo, err := l.svcCtx.OrderModel.FindOneByOrderNo(l.ctx, req.OrderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", req.OrderNo)
+		}
+		l.Errorw("[ResendPayment] Find order failed", logger.Field("error", err.Error()), logger.Field("orderNo", req.OrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find order error: %v", err.Error())
+	}
+	if orderInfo.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderNotExist), "order not exist: %v", req.OrderNo)
+	}
+	if orderInfo.Status != order.StatusPending {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status error: %v", orderInfo.Status)
+	}
+
+	// extend first, a link handed out without the longer window could be closed while the user pays
+	if req.Extend {
+		if err = l.extendClose(orderInfo); err != nil {
+			return nil, err
+		}
+	}
+	return portal.NewPurchaseCheckoutLogic(l.ctx, l.svcCtx).PurchaseCheckout(&types.CheckoutOrderRequest{
+		OrderNo:   orderInfo.OrderNo,
+		ReturnUrl: req.ReturnUrl,
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"time"
 
+	"github.com/perfect-panel/server/internal/logic/public/portal"
 	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/pkg/constant"
 	"github.com/perfect-panel/server/pkg/xerr"
@@ -17,7 +18,6 @@ import (
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
-	"github.com/perfect-panel/server/pkg/tool"
 	queue "github.com/perfect-panel/server/queue/types"
 	"github.com/pkg/errors"
 )
@@ -47,88 +47,94 @@ func (l *ResetTrafficLogic) ResetTraffic(req *types.ResetTrafficOrderRequest) (r
 	userSubscribe, err := l.svcCtx.UserModel.FindOneUserSubscribe(l.ctx, req.UserSubscribeID)
 	if err != nil {
 		l.Errorw("[ResetTraffic] Database query error", logger.Field("error", err.Error()), logger.Field("UserSubscribeID", req.UserSubscribeID))
-		return nil, errors.Wrapf(err, "find user subscribe error: %v", err.Error())
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribe error: %v", err.Error())
+	}
+	if userSubscribe.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "user subscribe %d does not belong to the current user", userSubscribe.Id)
 	}
 	if userSubscribe.Subscribe == nil {
 		l.Errorw("[ResetTraffic] subscribe not found", logger.Field("UserSubscribeID", req.UserSubscribeID))
-		return nil, errors.New("subscribe not found")
-	}
-	amount := userSubscribe.Subscribe.Replacement
-	var deductionAmount int64
-	// Check user deduction amount
-	if u.GiftAmount > 0 {
-		if u.GiftAmount >= amount {
-			deductionAmount = amount
-			amount = 0
-			u.GiftAmount -= amount
-		} else {
-			deductionAmount = u.GiftAmount
-			amount -= u.GiftAmount
-			u.GiftAmount = 0
-		}
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe not found")
+	}
+	// only plans that enable it sell a reset, the Replacement fee is its price
+	if !userSubscribe.Subscribe.TrafficReset {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeTrafficResetDisabled), "subscribe %d does not allow traffic reset", userSubscribe.SubscribeId)
 	}
+	// the reset leaves the expire time untouched, an expired subscription gains nothing from it
+	if userSubscribe.ExpireTime.Unix() != 0 && userSubscribe.ExpireTime.Before(time.Now()) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeExpired), "user subscribe %d expired", userSubscribe.Id)
+	}
+	price := userSubscribe.Subscribe.Replacement
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment
+	deductionAmount, promoCredit := deductGift(u, price, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount := price - deductionAmount
 	// find payment method
 	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
 	if err != nil {
 		l.Errorw("[ResetTraffic] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
-		return nil, errors.Wrapf(err, "find payment error: %v", err.Error())
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment error: %v", err.Error())
+	}
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[ResetTraffic] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
+	if err = portal.CheckPaymentAvailable(payment, amount, time.Now()); err != nil {
+		l.Infow("[ResetTraffic] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", amount), logger.Field("user_id", u.Id))
+		return nil, err
 	}
-	var feeAmount int64
+	var feeAmount, roundingAdjustment int64
 	// Calculate the handling fee
 	if amount > 0 {
-		feeAmount = calculateFee(amount, payment)
+		feeAmount = portal.CalculateFee(amount, payment)
+		amount, roundingAdjustment = portal.RoundAmount(amount+feeAmount, l.svcCtx.Config.Currency.RoundingIncrement)
 	}
 	// create order
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[ResetTraffic] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
 	orderInfo := order.Order{
-		Id:             0,
-		ParentId:       userSubscribe.OrderId,
-		UserId:         u.Id,
-		OrderNo:        tool.GenerateTradeNo(),
-		Type:           3,
-		Price:          userSubscribe.Subscribe.Replacement,
-		Amount:         amount + feeAmount,
-		GiftAmount:     deductionAmount,
-		FeeAmount:      feeAmount,
-		PaymentId:      payment.Id,
-		Method:         payment.Platform,
-		Status:         1,
-		SubscribeId:    userSubscribe.SubscribeId,
-		SubscribeToken: userSubscribe.Token,
+		Id:                 0,
+		ParentId:           userSubscribe.OrderId,
+		UserId:             u.Id,
+		OrderNo:            orderNo,
+		Type:               order.TypeResetTraffic,
+		Price:              price,
+		Amount:             amount,
+		GiftAmount:         deductionAmount,
+		PromoCredit:        promoCredit,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		Status:             1,
+		SubscribeId:        userSubscribe.SubscribeId,
+		SubscribeToken:     userSubscribe.Token,
 	}
 	// Database transaction
 	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
 		// update user deduction && Pre deduction ,Return after canceling the order
 		if orderInfo.GiftAmount > 0 {
-			// update user deduction && Pre deduction ,Return after canceling the order
-			if err := l.svcCtx.UserModel.Update(l.ctx, u, db); err != nil {
+			// take the deduction atomically, a concurrent order may have spent the balance since it was read
+			if err := l.svcCtx.UserModel.DeductBalance(l.ctx, u, orderInfo.GiftAmount-orderInfo.PromoCredit, orderInfo.PromoCredit, 0, db); err != nil {
 				l.Errorw("[ResetTraffic] Database update error", logger.Field("error", err.Error()), logger.Field("user", u))
 				return err
 			}
-			// create deduction record
-			giftLog := log.Gift{
-				Type:        log.GiftTypeReduce,
-				OrderNo:     orderInfo.OrderNo,
-				SubscribeId: 0,
-				Amount:      orderInfo.GiftAmount,
-				Balance:     u.GiftAmount,
-				Remark:      "Renewal order deduction",
-				Timestamp:   time.Now().UnixMilli(),
-			}
-			content, _ := giftLog.Marshal()
-
-			if err = db.Model(&log.SystemLog{}).Create(&log.SystemLog{
-				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
-				ObjectID: u.Id,
-				Content:  string(content),
-			}).Error; err != nil {
-				l.Errorw("[ResetTraffic] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", content))
-				return err
+			// create a deduction record per bucket
+			for _, giftLog := range giftDeductionLogs(u, orderInfo.OrderNo, orderInfo.GiftAmount, orderInfo.PromoCredit, "Reset traffic order deduction", time.Now()) {
+				if err = log.CreateOrderGift(db, u.Id, &giftLog); err != nil {
+					l.Errorw("[ResetTraffic] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", giftLog))
+					return err
+				}
 			}
 		}
 		// insert order
 		return db.Model(&order.Order{}).Create(&orderInfo).Error
 	})
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "gift amount spent by another order")
+	}
 	if err != nil {
 		l.Errorw("[ResetTraffic] Database insert error", logger.Field("error", err.Error()), logger.Field("order", orderInfo))
 		return nil, errors.Wrapf(err, "insert order error: %v", err.Error())
@@ -141,7 +147,7 @@ func (l *ResetTrafficLogic) ResetTraffic(req *types.ResetTrafficOrderRequest) (r
 	if err != nil {
 		l.Errorw("[ResetTraffic] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
 	}
-	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(3))
+	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
 	taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
 	if err != nil {
 		l.Errorw("[ResetTraffic] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
@@ -0,0 +1,23 @@
+package order
+
+// splitAmount spreads total over the weights proportionally, the last part takes the rounding
+// remainder so the parts always add up to total. Without any weight the last part takes it all.
+func splitAmount(total int64, weights []int64) []int64 {
+	parts := make([]int64, len(weights))
+	if len(weights) == 0 {
+		return parts
+	}
+	var sum int64
+	for _, w := range weights {
+		sum += w
+	}
+	rest := total
+	if sum > 0 {
+		for i := 0; i < len(weights)-1; i++ {
+			parts[i] = int64(float64(total) * (float64(weights[i]) / float64(sum)))
+			rest -= parts[i]
+		}
+	}
+	parts[len(parts)-1] = rest
+	return parts
+}
@@ -0,0 +1,28 @@
+package order
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		total   int64
+		weights []int64
+		want    []int64
+	}{
+		{name: "empty", total: 100, weights: nil, want: []int64{}},
+		{name: "single", total: 100, weights: []int64{30}, want: []int64{100}},
+		{name: "proportional", total: 900, weights: []int64{1000, 2000}, want: []int64{300, 600}},
+		{name: "remainder on last", total: 100, weights: []int64{1, 1, 1}, want: []int64{33, 33, 34}},
+		{name: "no weight", total: 50, weights: []int64{0, 0}, want: []int64{0, 50}},
+		{name: "zero total", total: 0, weights: []int64{10, 20}, want: []int64{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, splitAmount(tt.total, tt.weights))
+		})
+	}
+}
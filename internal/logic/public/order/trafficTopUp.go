@@ -0,0 +1,25 @@
+package order
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// checkTrafficTopUp returns why the subscription can't be topped up, nil when it can. Only limited
+// subscriptions of plans that enable top-ups qualify, active or finished for their exhausted traffic and
+// not expired, as the top-up leaves the expire time untouched.
+func checkTrafficTopUp(userSub *user.SubscribeDetails, now time.Time) error {
+	if userSub.Subscribe == nil || !userSub.Subscribe.TrafficTopUp || userSub.Subscribe.TopUpPrice <= 0 {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeTrafficTopUpDisabled), "subscribe %d does not allow traffic top-up", userSub.SubscribeId)
+	}
+	if userSub.Traffic <= 0 {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeTrafficTopUpDisabled), "user subscribe %d has unlimited traffic", userSub.Id)
+	}
+	if userSub.Status > 2 || (userSub.ExpireTime.Unix() != 0 && userSub.ExpireTime.Before(now)) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeExpired), "user subscribe %d expired", userSub.Id)
+	}
+	return nil
+}
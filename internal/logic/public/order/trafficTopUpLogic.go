@@ -0,0 +1,158 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type TrafficTopUpLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Top up the traffic of a subscription
+func NewTrafficTopUpLogic(ctx context.Context, svcCtx *svc.ServiceContext) *TrafficTopUpLogic {
+	return &TrafficTopUpLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// TrafficTopUp orders req.Traffic GB of extra traffic for a subscription at the plan's TopUpPrice per GB.
+// The order goes through the regular deductions, fee and close task, on payment the activation raises
+// the subscription's traffic.
+func (l *TrafficTopUpLogic) TrafficTopUp(req *types.TrafficTopUpOrderRequest) (resp *types.TrafficTopUpOrderResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	userSubscribe, err := l.svcCtx.UserModel.FindOneUserSubscribe(l.ctx, req.UserSubscribeID)
+	if err != nil {
+		l.Errorw("[TrafficTopUp] Database query error", logger.Field("error", err.Error()), logger.Field("UserSubscribeID", req.UserSubscribeID))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribe error: %v", err.Error())
+	}
+	if userSubscribe.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "user subscribe %d does not belong to the current user", userSubscribe.Id)
+	}
+	if err = checkTrafficTopUp(userSubscribe, time.Now()); err != nil {
+		return nil, err
+	}
+	price := userSubscribe.Subscribe.TopUpPrice * req.Traffic
+	if price > MaxOrderAmount {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "order amount exceeds maximum limit")
+	}
+	// gift amount and promo credit cover at most MaxGiftDeductionPercent of the order, the rest goes through the payment
+	deductionAmount, promoCredit := deductGift(u, price, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent, l.svcCtx.Config.Subscribe.PromoCredit, time.Now())
+	amount := price - deductionAmount
+	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
+	if err != nil {
+		l.Errorw("[TrafficTopUp] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment error: %v", err.Error())
+	}
+	if err = portal.CheckPaymentPlatform(payment); err != nil {
+		l.Errorw("[TrafficTopUp] Unknown payment platform", logger.Field("payment", payment.Id), logger.Field("platform", payment.Platform))
+		return nil, err
+	}
+	if err = portal.CheckPaymentAvailable(payment, amount, time.Now()); err != nil {
+		l.Infow("[TrafficTopUp] Payment method not available", logger.Field("payment", payment.Id), logger.Field("amount", amount), logger.Field("user_id", u.Id))
+		return nil, err
+	}
+	var feeAmount, roundingAdjustment int64
+	// Calculate the handling fee
+	if amount > 0 {
+		feeAmount = portal.CalculateFee(amount, payment)
+		amount, roundingAdjustment = portal.RoundAmount(amount+feeAmount, l.svcCtx.Config.Currency.RoundingIncrement)
+	}
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[TrafficTopUp] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
+	orderInfo := order.Order{
+		ParentId:           userSubscribe.OrderId,
+		UserId:             u.Id,
+		OrderNo:            orderNo,
+		Type:               order.TypeTrafficTopUp,
+		Quantity:           req.Traffic,
+		Price:              price,
+		UnitPrice:          userSubscribe.Subscribe.TopUpPrice,
+		Amount:             amount,
+		GiftAmount:         deductionAmount,
+		PromoCredit:        promoCredit,
+		FeeAmount:          feeAmount,
+		RoundingAdjustment: roundingAdjustment,
+		PaymentId:          payment.Id,
+		Method:             payment.Platform,
+		Status:             initialStatus(l.svcCtx.Config.Subscribe.InstantZeroAmount, amount),
+		SubscribeId:        userSubscribe.SubscribeId,
+		SubscribeToken:     userSubscribe.Token,
+	}
+	err = l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
+		if orderInfo.GiftAmount > 0 {
+			// take the deduction atomically, a concurrent order may have spent the balance since it was read
+			if err := l.svcCtx.UserModel.DeductBalance(l.ctx, u, orderInfo.GiftAmount-orderInfo.PromoCredit, orderInfo.PromoCredit, 0, db); err != nil {
+				l.Errorw("[TrafficTopUp] Database update error", logger.Field("error", err.Error()), logger.Field("user", u))
+				return err
+			}
+			// create a deduction record per bucket
+			for _, giftLog := range giftDeductionLogs(u, orderInfo.OrderNo, orderInfo.GiftAmount, orderInfo.PromoCredit, "Traffic top-up order deduction", time.Now()) {
+				if err := log.CreateOrderGift(db, u.Id, &giftLog); err != nil {
+					l.Errorw("[TrafficTopUp] Database insert error", logger.Field("error", err.Error()), logger.Field("deductionLog", giftLog))
+					return err
+				}
+			}
+		}
+		return db.Model(&order.Order{}).Create(&orderInfo).Error
+	})
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "gift amount spent by another order")
+	}
+	if err != nil {
+		l.Errorw("[TrafficTopUp] Database insert error", logger.Field("error", err.Error()), logger.Field("order", orderInfo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "insert order error: %v", err.Error())
+	}
+	if orderInfo.Status == order.StatusPaid {
+		// nothing left to pay, the traffic is added right away
+		if err = activateCreatedPaid(l.ctx, l.svcCtx, &orderInfo); err != nil {
+			l.Errorw("[TrafficTopUp] Activate order error", logger.Field("error", err.Error()), logger.Field("orderNo", orderInfo.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.QueueEnqueueError), "activate order error: %v", err.Error())
+		}
+	}
+	if orderInfo.Status != order.StatusPaid {
+		// Deferred task, closing an unpaid top-up gives its gift amount back
+		val, _ := json.Marshal(queue.DeferCloseOrderPayload{OrderNo: orderInfo.OrderNo})
+		task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
+		taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
+		if err != nil {
+			l.Errorw("[TrafficTopUp] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", task))
+		} else {
+			l.Infow("[TrafficTopUp] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
+		}
+	}
+	return &types.TrafficTopUpOrderResponse{
+		OrderNo:    orderInfo.OrderNo,
+		Price:      orderInfo.Price,
+		Amount:     orderInfo.Amount,
+		GiftAmount: orderInfo.GiftAmount,
+		FeeAmount:  orderInfo.FeeAmount,
+		Status:     orderInfo.Status,
+	}, nil
+}
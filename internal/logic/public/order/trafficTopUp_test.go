@@ -0,0 +1,44 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCheckTrafficTopUp(t *testing.T) {
+	now := time.Now()
+	enabled := &subscribe.Subscribe{TrafficTopUp: true, TopUpPrice: 100}
+	tests := []struct {
+		name    string
+		userSub user.SubscribeDetails
+		code    uint32 // 0 when the top-up is allowed
+	}{
+		{name: "active", userSub: user.SubscribeDetails{Subscribe: enabled, Traffic: 1 << 30, Status: 1, ExpireTime: now.Add(time.Hour)}},
+		{name: "traffic exhausted", userSub: user.SubscribeDetails{Subscribe: enabled, Traffic: 1 << 30, Download: 1 << 30, Status: 2, ExpireTime: now.Add(time.Hour)}},
+		{name: "no expiry", userSub: user.SubscribeDetails{Subscribe: enabled, Traffic: 1 << 30, Status: 1, ExpireTime: time.UnixMilli(0)}},
+		{name: "plan disabled", userSub: user.SubscribeDetails{Subscribe: &subscribe.Subscribe{TopUpPrice: 100}, Traffic: 1 << 30, Status: 1}, code: xerr.SubscribeTrafficTopUpDisabled},
+		{name: "no price", userSub: user.SubscribeDetails{Subscribe: &subscribe.Subscribe{TrafficTopUp: true}, Traffic: 1 << 30, Status: 1}, code: xerr.SubscribeTrafficTopUpDisabled},
+		{name: "unlimited traffic", userSub: user.SubscribeDetails{Subscribe: enabled, Status: 1}, code: xerr.SubscribeTrafficTopUpDisabled},
+		{name: "expired", userSub: user.SubscribeDetails{Subscribe: enabled, Traffic: 1 << 30, Status: 1, ExpireTime: now.Add(-time.Hour)}, code: xerr.SubscribeExpired},
+		{name: "cancelled", userSub: user.SubscribeDetails{Subscribe: enabled, Traffic: 1 << 30, Status: 4, ExpireTime: now.Add(time.Hour)}, code: xerr.SubscribeExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkTrafficTopUp(&tt.userSub, now)
+			if tt.code == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var e *xerr.CodeError
+			if assert.True(t, errors.As(err, &e)) {
+				assert.Equal(t, tt.code, e.GetErrCode())
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	queue "github.com/perfect-panel/server/queue/types"
+	"gorm.io/gorm"
+)
+
+// initialStatus returns the status an order is created in. With instant on, an order the discounts and
+// deductions covered completely is created paid: no gateway has anything to collect, and its deductions
+// are final instead of being held until a checkout or the close task.
+func initialStatus(instant bool, amount int64) uint8 {
+	if instant && amount == 0 {
+		return order.StatusPaid
+	}
+	return order.StatusPending
+}
+
+// enqueueActivation queues the activation of an order created paid, in place of its deferred close task
+func enqueueActivation(ctx context.Context, svcCtx *svc.ServiceContext, orderNo string) error {
+	val, err := json.Marshal(queue.ForthwithActivateOrderPayload{OrderNo: orderNo})
+	if err != nil {
+		return err
+	}
+	_, err = svcCtx.Queue.EnqueueContext(ctx, asynq.NewTask(queue.ForthwithActivateOrder, val))
+	return err
+}
+
+// activateCreatedPaid queues the activation of an order created paid. When it can't be queued the order is
+// put back to pending, so a checkout or the close task queued for it settles it like any unpaid order.
+func activateCreatedPaid(ctx context.Context, svcCtx *svc.ServiceContext, orderInfo *order.Order) error {
+	err := enqueueActivation(ctx, svcCtx, orderInfo.OrderNo)
+	if err == nil {
+		return nil
+	}
+	logger.WithContext(ctx).Errorw("[Order] Enqueue activation error, order put back to pending",
+		logger.Field("error", err.Error()),
+		logger.Field("orderNo", orderInfo.OrderNo))
+	err = svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := svcCtx.OrderModel.UpdateOrderStatusFrom(ctx, orderInfo.OrderNo, order.StatusPaid, order.StatusPending, tx); err != nil {
+			return err
+		}
+		if orderInfo.Type == order.TypeBulkRenewal {
+			return svcCtx.OrderModel.UpdateBulkItemsStatus(ctx, orderInfo.OrderNo, order.StatusPaid, order.StatusPending, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	orderInfo.Status = order.StatusPending
+	return nil
+}
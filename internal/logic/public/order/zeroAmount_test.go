@@ -0,0 +1,33 @@
+package order
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInitialStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		user    user.User
+		amount  int64
+		percent int64
+		instant bool
+		want    uint8
+	}{
+		{name: "gift covers all", user: user.User{GiftAmount: 1500}, amount: 1000, percent: 100, instant: true, want: order.StatusPaid},
+		{name: "gift covers part", user: user.User{GiftAmount: 500}, amount: 1000, percent: 100, instant: true, want: order.StatusPending},
+		{name: "deduction capped", user: user.User{GiftAmount: 1500}, amount: 1000, percent: 50, instant: true, want: order.StatusPending},
+		{name: "instant disabled", user: user.User{GiftAmount: 1500}, amount: 1000, percent: 100, instant: false, want: order.StatusPending},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u := tt.user
+			total, _ := deductGift(&u, tt.amount, tt.percent, false, time.Now())
+			assert.Equal(t, tt.want, initialStatus(tt.instant, tt.amount-total))
+		})
+	}
+}
@@ -2,10 +2,16 @@ package payment
 
 import (
 	"context"
+	"time"
 
+	"github.com/perfect-panel/server/internal/model/log"
+	paymentModel "github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
 	"github.com/perfect-panel/server/pkg/logger"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
 	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
 	"github.com/pkg/errors"
@@ -26,7 +32,7 @@ func NewGetAvailablePaymentMethodsLogic(ctx context.Context, svcCtx *svc.Service
 	}
 }
 
-func (l *GetAvailablePaymentMethodsLogic) GetAvailablePaymentMethods() (resp *types.GetAvailablePaymentMethodsResponse, err error) {
+func (l *GetAvailablePaymentMethodsLogic) GetAvailablePaymentMethods(req *types.GetAvailablePaymentMethodsRequest) (resp *types.GetAvailablePaymentMethodsResponse, err error) {
 	data, err := l.svcCtx.PaymentModel.FindAvailableMethods(l.ctx)
 	if err != nil {
 		l.Errorw("[GetAvailablePaymentMethods] database error", logger.Field("error", err.Error()))
@@ -36,7 +42,27 @@ func (l *GetAvailablePaymentMethodsLogic) GetAvailablePaymentMethods() (resp *ty
 		List: make([]types.PaymentMethod, 0),
 	}
 
-	tool.DeepCopy(&resp.List, data)
+	// the sandbox platform is hidden unless the payment sandbox is enabled, methods outside their hours
+	// are hidden as well and so are the ones not taking the amount when the client sends it
+	sandbox := l.svcCtx.Config.PaymentSandboxEnabled()
+	// invoice payment is only offered to enterprise accounts
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	enterprise := ok && u.Enterprise != nil && *u.Enterprise
+	now := time.Now().In(log.Location())
+	methods := make([]*paymentModel.Payment, 0, len(data))
+	for _, v := range data {
+		if !sandbox && paymentPlatform.ParsePlatform(v.Platform) == paymentPlatform.Test {
+			continue
+		}
+		if !enterprise && paymentPlatform.ParsePlatform(v.Platform) == paymentPlatform.Enterprise {
+			continue
+		}
+		if !v.AvailableAt(now) || (req.Amount > 0 && !v.AvailableFor(req.Amount)) {
+			continue
+		}
+		methods = append(methods, v)
+	}
+	tool.DeepCopy(&resp.List, methods)
 
 	return
 }
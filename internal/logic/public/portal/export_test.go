@@ -0,0 +1,12 @@
+package portal
+
+import (
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+)
+
+// ApplyGiftTopUp exposes applyGiftTopUp to the tests of portal_test, they close orders through the order
+// package which imports portal.
+func (l *PurchaseCheckoutLogic) ApplyGiftTopUp(o *order.Order, pay *payment.Payment) error {
+	return l.applyGiftTopUp(o, pay)
+}
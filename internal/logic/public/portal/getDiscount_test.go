@@ -0,0 +1,44 @@
+package portal
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetDiscount(t *testing.T) {
+	// listed out of order on purpose, tiers are sorted before interpolating
+	tiers := []types.SubscribeDiscount{{Quantity: 13, Discount: 70}, {Quantity: 5, Discount: 90}}
+	tests := []struct {
+		name        string
+		discounts   []types.SubscribeDiscount
+		quantity    int64
+		interpolate bool
+		want        float64
+	}{
+		{name: "step below first tier", discounts: tiers, quantity: 3, want: 1},
+		{name: "step between tiers", discounts: tiers, quantity: 9, want: 0.9},
+		{name: "step above last tier", discounts: tiers, quantity: 20, want: 0.7},
+		{name: "no tiers", discounts: nil, quantity: 9, interpolate: true, want: 1},
+		{name: "full price at one", discounts: tiers, quantity: 1, interpolate: true, want: 1},
+		{name: "midpoint below first tier", discounts: tiers, quantity: 3, interpolate: true, want: 0.95},
+		{name: "on first tier", discounts: tiers, quantity: 5, interpolate: true, want: 0.9},
+		{name: "midpoint between tiers", discounts: tiers, quantity: 9, interpolate: true, want: 0.8},
+		{name: "three quarters between tiers", discounts: tiers, quantity: 11, interpolate: true, want: 0.75},
+		{name: "on last tier", discounts: tiers, quantity: 13, interpolate: true, want: 0.7},
+		{name: "above last tier", discounts: tiers, quantity: 20, interpolate: true, want: 0.7},
+		{
+			name:        "never above the step",
+			discounts:   []types.SubscribeDiscount{{Quantity: 2, Discount: 80}, {Quantity: 10, Discount: 90}},
+			quantity:    6,
+			interpolate: true,
+			want:        0.8,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, GetDiscount(tt.discounts, tt.quantity, tt.interpolate), 1e-9)
+		})
+	}
+}
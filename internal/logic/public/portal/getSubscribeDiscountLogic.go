@@ -0,0 +1,71 @@
+package portal
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type GetSubscribeDiscountLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewGetSubscribeDiscountLogic Get subscribe discount tiers
+func NewGetSubscribeDiscountLogic(ctx context.Context, svcCtx *svc.ServiceContext) *GetSubscribeDiscountLogic {
+	return &GetSubscribeDiscountLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *GetSubscribeDiscountLogic) GetSubscribeDiscount(req *types.GetSubscribeDiscountRequest) (resp *types.GetSubscribeDiscountResponse, err error) {
+	sub, err := l.svcCtx.SubscribeModel.FindOne(l.ctx, req.SubscribeId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe not found: %v", req.SubscribeId)
+		}
+		l.Errorw("[GetSubscribeDiscount] Database query error", logger.Field("error", err.Error()), logger.Field("subscribe_id", req.SubscribeId))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
+	}
+	// hidden or not for sale plans are not exposed to guests
+	if (sub.Show != nil && !*sub.Show) || (sub.Sell != nil && !*sub.Sell) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe not available: %v", req.SubscribeId)
+	}
+	if req.Quantity > MaxQuantity {
+		return nil, errors.Wrapf(xerr.NewErrCodeData(xerr.QuantityExceedsLimit, map[string]int64{"limit": MaxQuantity}), "quantity exceeds maximum limit of %d", MaxQuantity)
+	}
+
+	resp = &types.GetSubscribeDiscountResponse{
+		List: make([]types.SubscribeDiscount, 0),
+	}
+	if sub.Discount != "" {
+		if err = json.Unmarshal([]byte(sub.Discount), &resp.List); err != nil {
+			l.Errorw("[GetSubscribeDiscount] Unmarshal discount error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+			resp.List = make([]types.SubscribeDiscount, 0)
+		}
+		sort.Slice(resp.List, func(i, j int) bool {
+			return resp.List[i].Quantity < resp.List[j].Quantity
+		})
+	}
+
+	if req.Quantity > 0 {
+		// same calculation as the purchase order
+		price := sub.UnitPrice * req.Quantity
+		amount := int64(float64(price) * GetDiscount(resp.List, req.Quantity, sub.DiscountInterpolate))
+		resp.Quantity = req.Quantity
+		resp.Price = price
+		resp.Amount = amount
+		resp.UnitPrice = amount / req.Quantity
+	}
+	return
+}
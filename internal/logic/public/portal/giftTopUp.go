@@ -0,0 +1,84 @@
+package portal
+
+import (
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/pkg/logger"
+	"gorm.io/gorm"
+)
+
+// Gift amount and pending orders
+//
+// A purchase or renewal reserves gift amount when the order is created, so the amount shown to the user is
+// the amount the payment provider charges. Closing the order releases the reservation again. With several
+// pending orders the first one may have reserved everything, so at checkout a pending order picks up the
+// gift amount released since it was created, up to the same MaxGiftDeductionPercent cap. Every reservation
+// writes a GiftTypeReduce log and every release a GiftTypeIncrease log, the logs always sum up to the balance.
+
+// giftTopUp returns how much more gift amount a pending order can take from the available balance.
+// The cap is taken from the order amount before gift, fee and rounding, like the deduction at order creation.
+func giftTopUp(o *order.Order, available, percent int64) int64 {
+	if available <= 0 || percent <= 0 {
+		return 0
+	}
+	payable := o.Amount - o.FeeAmount - o.RoundingAdjustment
+	limit := payable + o.GiftAmount
+	if percent < 100 {
+		limit = int64(float64(limit) * (float64(percent) / float64(100)))
+	}
+	return max(0, min(available, limit-o.GiftAmount, payable))
+}
+
+// applyGiftTopUp moves gift amount released by closed orders onto a pending purchase or renewal before checkout.
+func (l *PurchaseCheckoutLogic) applyGiftTopUp(o *order.Order, pay *payment.Payment) error {
+	if o.UserId == 0 || (o.Type != 1 && o.Type != 2 && o.Type != order.TypeBundle) {
+		return nil
+	}
+	u, err := l.svcCtx.UserModel.FindOne(l.ctx, o.UserId)
+	if err != nil {
+		return err
+	}
+	extra := giftTopUp(o, u.GiftAmount, l.svcCtx.Config.Subscribe.MaxGiftDeductionPercent)
+	if extra <= 0 {
+		return nil
+	}
+	amount := o.Amount - o.FeeAmount - o.RoundingAdjustment - extra
+	var fee int64
+	if amount > 0 {
+		fee = CalculateFee(amount, pay)
+	}
+	total, rounding := RoundAmount(amount+fee, l.svcCtx.Config.Currency.RoundingIncrement)
+
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		// take the top-up atomically, another checkout may have spent the released amount since it was read
+		if e := l.svcCtx.UserModel.DeductBalance(l.ctx, u, extra, 0, 0, tx); e != nil {
+			return e
+		}
+		if e := l.svcCtx.OrderModel.UpdatePendingAmount(l.ctx, o.OrderNo, total, fee, rounding, o.GiftAmount+extra, tx); e != nil {
+			return e
+		}
+		// each top-up of the order is logged once, keyed by the order gift amount it leads to
+		return log.CreateOrderGift(tx, u.Id, &log.Gift{
+			Type:    log.GiftTypeReduce,
+			OrderNo: o.OrderNo,
+			Amount:  extra,
+			Balance: u.GiftAmount,
+			TopUp:   o.GiftAmount + extra,
+			Remark:  "Checkout gift top-up",
+		})
+	})
+	if err != nil {
+		l.Errorw("[PurchaseCheckout] Gift top-up failed", logger.Field("error", err.Error()), logger.Field("orderNo", o.OrderNo))
+		return err
+	}
+	if err = l.svcCtx.UserModel.UpdateUserCache(l.ctx, u); err != nil {
+		l.Errorw("[PurchaseCheckout] Update user cache failed", logger.Field("error", err.Error()), logger.Field("userId", u.Id))
+	}
+	l.Infow("[PurchaseCheckout] Gift top-up applied", logger.Field("orderNo", o.OrderNo), logger.Field("gift", extra))
+	o.Amount = total
+	o.FeeAmount = fee
+	o.RoundingAdjustment = rounding
+	o.GiftAmount += extra
+	return nil
+}
@@ -0,0 +1,106 @@
+package portal_test
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/perfect-panel/server/internal/config"
+	orderLogic "github.com/perfect-panel/server/internal/logic/public/order"
+	"github.com/perfect-panel/server/internal/logic/public/portal"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// TestGiftAcrossPendingOrders is an opt-in integration test, it needs a MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/logic/public/portal/
+//
+// The first of two pending orders reserved the whole gift amount, it is cancelled and the second one is
+// checked out. The second order gets the released gift amount and the gift logs add up to the balance.
+func TestGiftAcrossPendingOrders(t *testing.T) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&order.Order{}, &subscribe.Subscribe{}, &user.User{}, &log.SystemLog{}))
+	mr := miniredis.RunT(t)
+	rds := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	svcCtx := &svc.ServiceContext{
+		DB:             db,
+		Redis:          rds,
+		Config:         config.Config{Subscribe: config.SubscribeConfig{MaxGiftDeductionPercent: 100}},
+		OrderModel:     order.NewModel(db, rds),
+		SubscribeModel: subscribe.NewModel(db, rds),
+		UserModel:      user.NewModel(db, rds),
+	}
+	ctx := context.Background()
+
+	const initial = int64(1000)
+	sub := &subscribe.Subscribe{Name: t.Name(), Inventory: -1, UnitPrice: 1000, UnitTime: "Month"}
+	require.NoError(t, svcCtx.SubscribeModel.Insert(ctx, sub))
+	u := &user.User{Password: t.Name()}
+	require.NoError(t, svcCtx.UserModel.Insert(ctx, u))
+	t.Cleanup(func() {
+		_ = svcCtx.SubscribeModel.Delete(ctx, sub.Id)
+		db.Unscoped().Delete(&user.User{}, u.Id)
+		db.Where("`object_id` = ?", u.Id).Delete(&log.SystemLog{})
+	})
+
+	// the first order reserved everything when it was created
+	first := &order.Order{OrderNo: tool.GenerateTradeNo(), UserId: u.Id, Type: 1, SubscribeId: sub.Id, Quantity: 1, Amount: 0, GiftAmount: initial, Status: order.StatusPending}
+	second := &order.Order{OrderNo: tool.GenerateTradeNo(), UserId: u.Id, Type: 1, SubscribeId: sub.Id, Quantity: 1, Amount: 800, Status: order.StatusPending}
+	for _, o := range []*order.Order{first, second} {
+		require.NoError(t, svcCtx.OrderModel.Insert(ctx, o))
+	}
+	t.Cleanup(func() {
+		db.Unscoped().Where("order_no IN ?", []string{first.OrderNo, second.OrderNo}).Delete(&order.Order{})
+	})
+	require.NoError(t, log.CreateOrderGift(db, u.Id, &log.Gift{Type: log.GiftTypeReduce, OrderNo: first.OrderNo, Amount: initial, Balance: 0}))
+
+	// cancel the first order, the reservation goes back to the user
+	require.NoError(t, orderLogic.NewCloseOrderLogic(ctx, svcCtx).CloseOrder(&types.CloseOrderRequest{OrderNo: first.OrderNo}))
+
+	// checkout the second order, it picks up the released gift amount
+	require.NoError(t, portal.NewPurchaseCheckoutLogic(ctx, svcCtx).ApplyGiftTopUp(second, &payment.Payment{}))
+	assert.Equal(t, int64(800), second.GiftAmount)
+	assert.Equal(t, int64(0), second.Amount)
+
+	stored, err := svcCtx.OrderModel.FindOneByOrderNo(ctx, second.OrderNo)
+	require.NoError(t, err)
+	assert.Equal(t, int64(800), stored.GiftAmount)
+	assert.Equal(t, int64(0), stored.Amount)
+
+	var balance int64
+	require.NoError(t, db.Model(&user.User{}).Where("id = ?", u.Id).Pluck("gift_amount", &balance).Error)
+	assert.Equal(t, int64(200), balance)
+
+	var logs []log.SystemLog
+	require.NoError(t, db.Where("`type` = ? AND `object_id` = ?", log.TypeGift.Uint8(), u.Id).Find(&logs).Error)
+	sum := initial
+	for _, item := range logs {
+		var gift log.Gift
+		require.NoError(t, gift.Unmarshal([]byte(item.Content)))
+		switch gift.Type {
+		case log.GiftTypeReduce:
+			sum -= gift.Amount
+		case log.GiftTypeIncrease:
+			sum += gift.Amount
+		}
+	}
+	assert.Len(t, logs, 3)
+	assert.Equal(t, balance, sum, "gift logs must balance")
+}
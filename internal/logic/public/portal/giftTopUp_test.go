@@ -0,0 +1,32 @@
+package portal
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGiftTopUp(t *testing.T) {
+	tests := []struct {
+		name      string
+		order     order.Order
+		available int64
+		percent   int64
+		want      int64
+	}{
+		{name: "no balance", order: order.Order{Amount: 1000}, available: 0, percent: 100, want: 0},
+		{name: "disabled", order: order.Order{Amount: 1000}, available: 500, percent: 0, want: 0},
+		{name: "covers all", order: order.Order{Amount: 1000}, available: 5000, percent: 100, want: 1000},
+		{name: "fee excluded", order: order.Order{Amount: 1030, FeeAmount: 30}, available: 5000, percent: 100, want: 1000},
+		{name: "capped by percent", order: order.Order{Amount: 1000}, available: 5000, percent: 50, want: 500},
+		{name: "cap counts reserved gift", order: order.Order{Amount: 700, GiftAmount: 300}, available: 5000, percent: 50, want: 200},
+		{name: "cap already reached", order: order.Order{Amount: 500, GiftAmount: 500}, available: 5000, percent: 50, want: 0},
+		{name: "rounding excluded", order: order.Order{Amount: 1100, FeeAmount: 30, RoundingAdjustment: 70}, available: 5000, percent: 100, want: 1000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, giftTopUp(&tt.order, tt.available, tt.percent))
+		})
+	}
+}
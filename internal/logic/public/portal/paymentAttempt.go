@@ -0,0 +1,106 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/pkg/logger"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Payment attempts
+//
+// A pending order can be checked out again through another payment method, every gateway checkout is
+// recorded as a payment attempt with the amount charged through it. The payment notifications decide the
+// winner: the first confirmed attempt pays the order and cancels the others, a later confirmation of
+// another attempt is refunded to the user's balance.
+
+// switchPayment moves a pending order to another payment method before checkout. Only the fee is priced
+// again, a payment method discount or a coupon limited to other methods does not carry over.
+func (l *PurchaseCheckoutLogic) switchPayment(o *order.Order, pay *payment.Payment) error {
+	if pay.Enable == nil || !*pay.Enable {
+		return errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "payment method disabled: %d", pay.Id)
+	}
+	if err := CheckPaymentPlatform(pay); err != nil {
+		return err
+	}
+	if paymentPlatform.ParsePlatform(pay.Platform) == paymentPlatform.Test && !l.svcCtx.Config.PaymentSandboxEnabled() {
+		return errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "payment method not found")
+	}
+	if o.PaymentDiscount > 0 {
+		return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order discount is bound to payment method %d", o.PaymentId)
+	}
+	if err := CheckPaymentAvailable(pay, o.Amount-o.FeeAmount-o.RoundingAdjustment, time.Now()); err != nil {
+		return err
+	}
+	if o.Coupon != "" {
+		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, o.Coupon)
+		if err != nil {
+			l.Errorw("[PurchaseCheckout] Find coupon error", logger.Field("error", err.Error()), logger.Field("coupon", o.Coupon))
+			return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find coupon error: %v", err.Error())
+		}
+		couponPayment := tool.StringToInt64Slice(couponInfo.Payment)
+		if len(couponPayment) > 0 && !tool.Contains(couponPayment, pay.Id) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match payment method")
+		}
+	}
+
+	amount := o.Amount - o.FeeAmount - o.RoundingAdjustment
+	var fee int64
+	if amount > 0 {
+		fee = CalculateFee(amount, pay)
+	}
+	total, rounding := RoundAmount(amount+fee, l.svcCtx.Config.Currency.RoundingIncrement)
+	if err := l.svcCtx.OrderModel.UpdatePendingPayment(l.ctx, o.OrderNo, pay.Id, pay.Platform, total, fee, rounding); err != nil {
+		if errors.Is(err, order.ErrOrderStatusChanged) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status changed: %v", o.OrderNo)
+		}
+		l.Errorw("[PurchaseCheckout] Update order payment error", logger.Field("error", err.Error()), logger.Field("orderNo", o.OrderNo))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update order payment error: %v", err.Error())
+	}
+	l.Infow("[PurchaseCheckout] Order payment method switched",
+		logger.Field("orderNo", o.OrderNo),
+		logger.Field("from", o.PaymentId),
+		logger.Field("to", pay.Id),
+	)
+	o.PaymentId = pay.Id
+	o.Method = pay.Platform
+	o.Amount = total
+	o.FeeAmount = fee
+	o.RoundingAdjustment = rounding
+	return nil
+}
+
+// recordPaymentAttempt records the gateway checkout of the order, a pending attempt of the same
+// method and amount is reused when the user opens the checkout again.
+func (l *PurchaseCheckoutLogic) recordPaymentAttempt(o *order.Order) error {
+	attempt, err := l.svcCtx.OrderModel.FindPaymentAttempt(l.ctx, o.OrderNo, o.PaymentId)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil && attempt.Status == order.AttemptStatusPending && attempt.Amount == o.Amount {
+		return nil
+	}
+	return l.svcCtx.OrderModel.InsertPaymentAttempt(l.ctx, &order.PaymentAttempt{
+		OrderNo:            o.OrderNo,
+		PaymentId:          o.PaymentId,
+		Method:             o.Method,
+		Amount:             o.Amount,
+		FeeAmount:          o.FeeAmount,
+		RoundingAdjustment: o.RoundingAdjustment,
+		Status:             order.AttemptStatusPending,
+	})
+}
+
+// cancelPaymentAttempts cancels the gateway attempts of an order paid right away at checkout,
+// a gateway confirming one of them later is refunded.
+func (l *PurchaseCheckoutLogic) cancelPaymentAttempts(o *order.Order) {
+	if err := l.svcCtx.OrderModel.CancelPaymentAttempts(l.ctx, o.OrderNo, 0); err != nil {
+		l.Errorw("[PurchaseCheckout] Cancel payment attempts error", logger.Field("error", err.Error()), logger.Field("orderNo", o.OrderNo))
+	}
+}
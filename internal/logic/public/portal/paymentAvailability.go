@@ -0,0 +1,37 @@
+package portal
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/payment"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// CheckPaymentAvailable rejects an order the payment method does not take, either for the amount going
+// through it before the fee or at this hour of the site's timezone. An order the balances cover completely
+// never reaches the gateway and is always taken.
+func CheckPaymentAvailable(method *payment.Payment, amount int64, now time.Time) error {
+	if amount <= 0 {
+		return nil
+	}
+	if !method.AvailableFor(amount) || !method.AvailableAt(now.In(log.Location())) {
+		return errors.Wrapf(xerr.NewErrCodeData(xerr.PaymentMethodUnavailable, map[string]interface{}{
+			"min":   method.MinAmount,
+			"max":   method.MaxAmount,
+			"hours": method.AvailableHours,
+		}), "payment method %d is not available for amount %d at %s", method.Id, amount, now.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// CheckPaymentPlatform rejects a payment method whose platform is not among the known ones, an order created
+// with it could never be paid. Platforms added by plugins are known once registered with RegisterPlatform.
+func CheckPaymentPlatform(method *payment.Payment) error {
+	if paymentPlatform.ParsePlatform(method.Platform) == paymentPlatform.UNSUPPORTED {
+		return errors.Wrapf(xerr.NewErrCode(xerr.PaymentPlatformUnknown), "payment method %d has unknown platform %q", method.Id, method.Platform)
+	}
+	return nil
+}
@@ -6,6 +6,8 @@ import (
 
 	"github.com/perfect-panel/server/pkg/tool"
 
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/perfect-panel/server/internal/model/payment"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
@@ -40,14 +42,23 @@ func (l *PrePurchaseOrderLogic) PrePurchaseOrder(req *types.PrePurchaseOrderRequ
 	if sub.Discount != "" {
 		var dis []types.SubscribeDiscount
 		_ = json.Unmarshal([]byte(sub.Discount), &dis)
-		discount = getDiscount(dis, req.Quantity)
+		discount = GetDiscount(dis, req.Quantity, sub.DiscountInterpolate)
 	}
 	price := sub.UnitPrice * req.Quantity
 	amount := int64(float64(price) * discount)
-	discountAmount := price - amount
-	var coupon int64
+
+	var paymentInfo *payment.Payment
+	if req.Payment != 0 {
+		paymentInfo, err = l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
+		if err != nil {
+			l.Logger.Error("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
+		}
+	}
+
+	var couponInfo *couponModel.Coupon
 	if req.Coupon != "" {
-		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
+		couponInfo, err = l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
@@ -57,36 +68,46 @@ func (l *PrePurchaseOrderLogic) PrePurchaseOrder(req *types.PrePurchaseOrderRequ
 		if couponInfo.Count != 0 && couponInfo.Count <= couponInfo.UsedCount {
 			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used")
 		}
+		// guests have no account, so they never match a coupon scoped to users
+		if !couponInfo.AllowsUser(0) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not available to this user")
+		}
+		if !couponInfo.Applicable(couponModel.OrderTypePurchase) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not applicable to this order type")
+		}
 		subs := tool.StringToInt64Slice(couponInfo.Subscribe)
 
 		if len(subs) > 0 && !tool.Contains(subs, req.SubscribeId) {
 			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
 		}
-
-		coupon = calculateCoupon(amount, couponInfo)
+		couponPayment := tool.StringToInt64Slice(couponInfo.Payment)
+		if paymentInfo != nil && len(couponPayment) > 0 && !tool.Contains(couponPayment, paymentInfo.Id) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match payment method")
+		}
 	}
-	amount -= coupon
+	discountAmount, coupon := StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	amount = price - discountAmount - coupon
+	var paymentDiscount int64
 	var feeAmount int64
-	if req.Payment != 0 {
-		payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
-		if err != nil {
-			l.Logger.Error("[PreCreateOrder] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
-		}
+	if paymentInfo != nil {
+		// Calculate the payment method discount
+		paymentDiscount = CalculatePaymentDiscount(amount, paymentInfo)
+		amount -= paymentDiscount
 		// Calculate the handling fee
 		if amount > 0 {
-			feeAmount = calculateFee(amount, payment)
+			feeAmount = CalculateFee(amount, paymentInfo)
 		}
 		amount += feeAmount
 	}
 
 	resp = &types.PrePurchaseOrderResponse{
-		Price:          price,
-		Amount:         amount,
-		Discount:       discountAmount,
-		Coupon:         req.Coupon,
-		CouponDiscount: coupon,
-		FeeAmount:      feeAmount,
+		Price:           price,
+		Amount:          amount,
+		Discount:        discountAmount,
+		Coupon:          req.Coupon,
+		CouponDiscount:  coupon,
+		PaymentDiscount: paymentDiscount,
+		FeeAmount:       feeAmount,
 	}
 	return
 }
@@ -17,6 +17,7 @@ import (
 	"github.com/perfect-panel/server/internal/model/user"
 	queueType "github.com/perfect-panel/server/queue/types"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"github.com/perfect-panel/server/internal/model/order"
 	"github.com/perfect-panel/server/internal/model/payment"
@@ -65,14 +66,45 @@ func (l *PurchaseCheckoutLogic) PurchaseCheckout(req *types.CheckoutOrderRequest
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status error: %v", orderInfo.Status)
 	}
 
-	// Retrieve payment method configuration
-	paymentConfig, err := l.svcCtx.PaymentModel.FindOne(l.ctx, orderInfo.PaymentId)
+	// Renewal orders of a bulk renewal are paid through their bulk order
+	if orderInfo.BulkOrderNo != "" {
+		l.Logger.Error("[PurchaseCheckout] Order belongs to a bulk renewal", logger.Field("bulkOrderNo", orderInfo.BulkOrderNo))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order is paid through bulk order: %v", orderInfo.BulkOrderNo)
+	}
+
+	// Retrieve payment method configuration, the user may check out through another method than ordered
+	paymentId := orderInfo.PaymentId
+	if req.Payment != 0 {
+		paymentId = req.Payment
+	}
+	paymentConfig, err := l.svcCtx.PaymentModel.FindOne(l.ctx, paymentId)
 	if err != nil {
-		l.Logger.Error("[PurchaseCheckout] Database query error", logger.Field("error", err.Error()), logger.Field("payment", orderInfo.Method))
+		l.Logger.Error("[PurchaseCheckout] Database query error", logger.Field("error", err.Error()), logger.Field("payment", paymentId))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find payment method error: %v", err.Error())
 	}
+	if paymentConfig.Id != orderInfo.PaymentId {
+		if err = l.switchPayment(orderInfo, paymentConfig); err != nil {
+			return nil, err
+		}
+	}
+	// Pick up gift amount released by closed orders since this order was created
+	if err = l.applyGiftTopUp(orderInfo, paymentConfig); err != nil {
+		if errors.Is(err, order.ErrOrderStatusChanged) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status changed: %v", req.OrderNo)
+		}
+		// keep the amount reserved at creation
+		l.Errorw("[PurchaseCheckout] Apply gift top-up error", logger.Field("error", err.Error()), logger.Field("orderNo", req.OrderNo))
+	}
+	platform := paymentPlatform.ParsePlatform(orderInfo.Method)
+	if platform != paymentPlatform.Balance && platform != paymentPlatform.Test && platform != paymentPlatform.Enterprise {
+		// record the gateway checkout so its confirmation can be told apart from the other attempts
+		if err = l.recordPaymentAttempt(orderInfo); err != nil {
+			l.Errorw("[PurchaseCheckout] Record payment attempt error", logger.Field("error", err.Error()), logger.Field("orderNo", req.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "record payment attempt error: %v", err.Error())
+		}
+	}
 	// Route to appropriate payment handler based on payment platform
-	switch paymentPlatform.ParsePlatform(orderInfo.Method) {
+	switch platform {
 	case paymentPlatform.EPay:
 		// Process EPay payment - generates payment URL for redirect
 		url, err := l.epayPayment(paymentConfig, orderInfo, req.ReturnUrl)
@@ -137,11 +169,47 @@ func (l *PurchaseCheckoutLogic) PurchaseCheckout(req *types.CheckoutOrderRequest
 		if err = l.balancePayment(userInfo, orderInfo); err != nil {
 			return nil, err
 		}
+		l.cancelPaymentAttempts(orderInfo)
 
 		resp = &types.CheckoutOrderResponse{
 			Type: "balance", // Payment completed immediately
 		}
 
+	case paymentPlatform.Enterprise:
+		// Invoice payment - the order is charged to the enterprise account and provisioned right away
+		if orderInfo.UserId == 0 {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.EnterprisePaymentDenied), "guest orders can't be paid on invoice")
+		}
+		if orderInfo.Type == 4 {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.EnterprisePaymentDenied), "recharge orders can't be paid on invoice")
+		}
+		userInfo, err := l.svcCtx.UserModel.FindOne(l.ctx, orderInfo.UserId)
+		if err != nil {
+			l.Errorw("[PurchaseCheckout] FindOne User error", logger.Field("error", err.Error()))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOne error: %s", err.Error())
+		}
+		if err = l.invoicePayment(userInfo, orderInfo); err != nil {
+			return nil, err
+		}
+		l.cancelPaymentAttempts(orderInfo)
+		resp = &types.CheckoutOrderResponse{
+			Type: "invoice", // Provisioned now, paid later
+		}
+
+	case paymentPlatform.Test:
+		// Sandbox payment - the order is paid right away, only available to development setups
+		if !l.svcCtx.Config.PaymentSandboxEnabled() {
+			l.Errorw("[PurchaseCheckout] payment sandbox is disabled", logger.Field("orderNo", orderInfo.OrderNo))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "payment method not found")
+		}
+		if err = l.sandboxPayment(orderInfo); err != nil {
+			return nil, err
+		}
+		l.cancelPaymentAttempts(orderInfo)
+		resp = &types.CheckoutOrderResponse{
+			Type: "test", // Payment completed immediately
+		}
+
 	default:
 		l.Errorw("[PurchaseCheckout] payment method not found", logger.Field("method", orderInfo.Method))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "payment method not found")
@@ -434,7 +502,7 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 
 	err = l.svcCtx.UserModel.Transaction(l.ctx, func(db *gorm.DB) error {
 		// Retrieve latest user information with row-level locking
-		err := db.Model(&user.User{}).Where("id = ?", u.Id).First(&userInfo).Error
+		err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Model(&user.User{}).Where("id = ?", u.Id).First(&userInfo).Error
 		if err != nil {
 			return err
 		}
@@ -460,12 +528,8 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 			balanceUsed = remainingAmount - giftUsed
 		}
 
-		// Update user balances
-		userInfo.GiftAmount -= giftUsed
-		userInfo.Balance -= balanceUsed
-
-		// Save updated user information
-		err = l.svcCtx.UserModel.Update(l.ctx, &userInfo)
+		// Take the payment only from balances that still cover it
+		err = l.svcCtx.UserModel.PayFromBalance(l.ctx, &userInfo, giftUsed, balanceUsed, db)
 		if err != nil {
 			return err
 		}
@@ -484,7 +548,7 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 			err = db.Create(&log.SystemLog{
 				Type:     log.TypeGift.Uint8(),
 				ObjectID: userInfo.Id,
-				Date:     time.Now().Format(time.DateOnly),
+				Date:     log.Date(time.Now()),
 				Content:  string(content),
 			}).Error
 			if err != nil {
@@ -505,7 +569,7 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 			err = db.Create(&log.SystemLog{
 				Type:     log.TypeBalance.Uint8(),
 				ObjectID: userInfo.Id,
-				Date:     time.Now().Format(time.DateOnly),
+				Date:     log.Date(time.Now()),
 				Content:  string(content),
 			}).Error
 			if err != nil {
@@ -513,8 +577,8 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 			}
 		}
 
-		// Store gift amount used in order for potential refund tracking
-		o.GiftAmount = giftUsed
+		// Store gift amount used in order for potential refund tracking, on top of what was reserved at creation
+		o.GiftAmount += giftUsed
 		err = l.svcCtx.OrderModel.Update(l.ctx, o, db)
 		if err != nil {
 			return err
@@ -524,6 +588,9 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 		return l.svcCtx.OrderModel.UpdateOrderStatus(l.ctx, o.OrderNo, 2, db)
 	})
 
+	if errors.Is(err, user.ErrInsufficientBalance) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InsufficientBalance), "balance spent by another order")
+	}
 	if err != nil {
 		l.Errorw("[PurchaseCheckout] Balance payment transaction error",
 			logger.Field("error", err.Error()),
@@ -533,7 +600,78 @@ func (l *PurchaseCheckoutLogic) balancePayment(u *user.User, o *order.Order) err
 	}
 
 activation:
-	// Enqueue order activation task for immediate processing
+	if err = l.enqueueActivation(o); err != nil {
+		return err
+	}
+
+	l.Logger.Info("[PurchaseCheckout] Balance payment completed successfully",
+		logger.Field("orderNo", o.OrderNo),
+		logger.Field("userId", u.Id))
+	return nil
+}
+
+// sandboxPayment marks the order paid without any gateway and runs the normal activation,
+// the caller has to make sure the payment sandbox is enabled
+func (l *PurchaseCheckoutLogic) sandboxPayment(o *order.Order) error {
+	if err := l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, o.OrderNo, order.StatusPending, order.StatusPaid); err != nil {
+		if errors.Is(err, order.ErrOrderStatusChanged) {
+			return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status changed: %v", o.OrderNo)
+		}
+		l.Errorw("[PurchaseCheckout] Update order status error",
+			logger.Field("error", err.Error()),
+			logger.Field("orderNo", o.OrderNo))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Update order status error: %s", err.Error())
+	}
+	if err := l.enqueueActivation(o); err != nil {
+		return err
+	}
+	l.Logger.Info("[PurchaseCheckout] Sandbox payment completed", logger.Field("orderNo", o.OrderNo))
+	return nil
+}
+
+// invoicePayment charges the order to the outstanding balance of an enterprise account and activates it
+// without waiting for the money, the order awaits its invoice until an admin marks it paid. The close task
+// leaves it alone and the credit limit caps what the account may owe.
+func (l *PurchaseCheckoutLogic) invoicePayment(u *user.User, o *order.Order) error {
+	if u.Enterprise == nil || !*u.Enterprise {
+		l.Infow("[PurchaseCheckout] Invoice payment denied", logger.Field("orderNo", o.OrderNo), logger.Field("userId", u.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.EnterprisePaymentDenied), "user %d is not an enterprise account", u.Id)
+	}
+	err := l.svcCtx.DB.Transaction(func(db *gorm.DB) error {
+		if err := l.svcCtx.UserModel.AddOutstandingBalance(l.ctx, u, o.Amount, db); err != nil {
+			return err
+		}
+		return l.svcCtx.OrderModel.UpdateOrderStatusFrom(l.ctx, o.OrderNo, order.StatusPending, order.StatusAwaitingInvoice, db)
+	})
+	switch {
+	case errors.Is(err, user.ErrCreditLimitExceeded):
+		l.Infow("[PurchaseCheckout] Credit limit exceeded",
+			logger.Field("orderNo", o.OrderNo),
+			logger.Field("userId", u.Id),
+			logger.Field("amount", o.Amount),
+			logger.Field("creditLimit", u.CreditLimit))
+		return errors.Wrapf(xerr.NewErrCode(xerr.CreditLimitExceeded), "credit limit %d exceeded", u.CreditLimit)
+	case errors.Is(err, order.ErrOrderStatusChanged):
+		return errors.Wrapf(xerr.NewErrCode(xerr.OrderStatusError), "order status changed: %v", o.OrderNo)
+	case err != nil:
+		l.Errorw("[PurchaseCheckout] Invoice payment transaction error",
+			logger.Field("error", err.Error()),
+			logger.Field("orderNo", o.OrderNo),
+			logger.Field("userId", u.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "invoice payment error: %s", err.Error())
+	}
+	if err = l.enqueueActivation(o); err != nil {
+		return err
+	}
+	l.Logger.Info("[PurchaseCheckout] Invoice payment completed",
+		logger.Field("orderNo", o.OrderNo),
+		logger.Field("userId", u.Id),
+		logger.Field("outstandingBalance", u.OutstandingBalance))
+	return nil
+}
+
+// enqueueActivation enqueues the activation task of a paid order for immediate processing
+func (l *PurchaseCheckoutLogic) enqueueActivation(o *order.Order) error {
 	payload := queueType.ForthwithActivateOrderPayload{
 		OrderNo: o.OrderNo,
 	}
@@ -549,9 +687,5 @@ activation:
 		l.Errorw("[PurchaseCheckout] Enqueue activation task error", logger.Field("error", err.Error()))
 		return err
 	}
-
-	l.Logger.Info("[PurchaseCheckout] Balance payment completed successfully",
-		logger.Field("orderNo", o.OrderNo),
-		logger.Field("userId", u.Id))
 	return nil
 }
@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"time"
 
+	couponModel "github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/constant"
@@ -38,6 +40,7 @@ func NewPurchaseLogic(ctx context.Context, svcCtx *svc.ServiceContext) *Purchase
 
 const (
 	CloseOrderTimeMinutes = 15
+	MaxQuantity           = 1000 // Maximum quantity per order
 )
 
 func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.PortalPurchaseResponse, err error) {
@@ -69,17 +72,30 @@ func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.
 	if sub.Discount != "" {
 		var dis []types.SubscribeDiscount
 		_ = json.Unmarshal([]byte(sub.Discount), &dis)
-		discount = getDiscount(dis, req.Quantity)
+		discount = GetDiscount(dis, req.Quantity, sub.DiscountInterpolate)
 	}
 	price := sub.UnitPrice * req.Quantity
 	// discount amount
 	amount := int64(float64(price) * discount)
-	discountAmount := price - amount
 
-	var couponAmount int64 = 0
-	// Calculate the coupon deduction
+	// find payment method
+	paymentConfig, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
+	if err != nil {
+		l.Logger.Error("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "find payment method error: %v", err.Error())
+	}
+
+	if err = CheckPaymentPlatform(paymentConfig); err != nil {
+		l.Errorw("[Purchase] Unknown payment platform", logger.Field("payment", paymentConfig.Id), logger.Field("platform", paymentConfig.Platform))
+		return nil, err
+	}
+	if payment.ParsePlatform(paymentConfig.Platform) == payment.Balance {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "balance error")
+	}
+
+	var couponInfo *couponModel.Coupon
 	if req.Coupon != "" {
-		couponInfo, err := l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
+		couponInfo, err = l.svcCtx.CouponModel.FindOneByCode(l.ctx, req.Coupon)
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
 				return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotExist), "coupon not found")
@@ -95,48 +111,63 @@ func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.
 			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponExpired), "coupon expired")
 		}
 
+		// guests have no account, so they never match a coupon scoped to users
+		if !couponInfo.AllowsUser(0) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not available to this user")
+		}
+		if !couponInfo.Applicable(couponModel.OrderTypePurchase) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not applicable to this order type")
+		}
 		couponSub := tool.StringToInt64Slice(couponInfo.Subscribe)
 		if len(couponSub) > 0 && !tool.Contains(couponSub, req.SubscribeId) {
 			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match")
 		}
-
-		couponAmount = calculateCoupon(amount, couponInfo)
-	}
-	// Calculate the handling fee
-	amount -= couponAmount
-	// find payment method
-	paymentConfig, err := l.svcCtx.PaymentModel.FindOne(l.ctx, req.Payment)
-	if err != nil {
-		l.Logger.Error("[Purchase] Database query error", logger.Field("error", err.Error()), logger.Field("payment", req.Payment))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "find payment method error: %v", err.Error())
+		couponPayment := tool.StringToInt64Slice(couponInfo.Payment)
+		if len(couponPayment) > 0 && !tool.Contains(couponPayment, paymentConfig.Id) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponNotApplicable), "coupon not match payment method")
+		}
 	}
-
-	if payment.ParsePlatform(paymentConfig.Platform) == payment.Balance {
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.PaymentMethodNotFound), "balance error")
+	// Calculate the plan discount and the coupon deduction by the stacking rule
+	discountAmount, couponAmount := StackDiscounts(l.svcCtx.Config.Subscribe.DiscountStacking, price, amount, req.Quantity, couponInfo)
+	amount = price - discountAmount - couponAmount
+	// Calculate the payment method discount
+	paymentDiscount := CalculatePaymentDiscount(amount, paymentConfig)
+	amount -= paymentDiscount
+	if err = CheckPaymentAvailable(paymentConfig, amount, time.Now()); err != nil {
+		l.Infow("[Purchase] Payment method not available", logger.Field("payment", paymentConfig.Id), logger.Field("amount", amount))
+		return nil, err
 	}
-
 	var feeAmount int64
 	// Calculate the handling fee
 	if amount > 0 {
-		feeAmount = calculateFee(amount, paymentConfig)
+		feeAmount = CalculateFee(amount, paymentConfig)
 	}
 	// create order
+	orderNo, err := l.svcCtx.TradeNo.Generate(l.ctx)
+	if err != nil {
+		l.Errorw("[Purchase] Generate order number error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseInsertError), "generate order number error: %v", err.Error())
+	}
 	orderInfo := &order.Order{
-		OrderNo:        tool.GenerateTradeNo(),
-		Type:           1,
-		Quantity:       req.Quantity,
-		Price:          price,
-		Amount:         amount,
-		Discount:       discountAmount,
-		GiftAmount:     0,
-		Coupon:         req.Coupon,
-		CouponDiscount: couponAmount,
-		PaymentId:      req.Payment,
-		Method:         paymentConfig.Platform,
-		FeeAmount:      feeAmount,
-		Status:         1,
-		IsNew:          true,
-		SubscribeId:    req.SubscribeId,
+		OrderNo:         orderNo,
+		Type:            1,
+		Quantity:        req.Quantity,
+		Price:           price,
+		UnitPrice:       sub.UnitPrice,
+		PlanDiscount:    sub.Discount,
+		Amount:          amount,
+		Discount:        discountAmount,
+		GiftAmount:      0,
+		Coupon:          req.Coupon,
+		CouponDiscount:  couponAmount,
+		BonusDays:       couponInfo.BonusDays(),
+		PaymentDiscount: paymentDiscount,
+		PaymentId:       req.Payment,
+		Method:          paymentConfig.Platform,
+		FeeAmount:       feeAmount,
+		Status:          1,
+		IsNew:           true,
+		SubscribeId:     req.SubscribeId,
 	}
 	// save order
 	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
@@ -158,12 +189,18 @@ func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.
 
 		// Decrease subscribe plan stock
 		if sub.Inventory != -1 {
-			sub.Inventory--
-			if e := l.svcCtx.SubscribeModel.Update(l.ctx, sub, tx); e != nil {
+			if e := l.svcCtx.SubscribeModel.DecreaseInventory(l.ctx, sub.Id, tx); e != nil {
 				l.Errorw("[Purchase] Database update error", logger.Field("error", e.Error()), logger.Field("subscribe_id", sub.Id))
 				return e
 			}
 		}
+		if orderInfo.Coupon != "" {
+			// take a coupon use, given back when the order is closed
+			if e := l.svcCtx.CouponModel.IncreaseUsedCount(l.ctx, orderInfo.Coupon, tx); e != nil {
+				l.Errorw("[Purchase] Database update error", logger.Field("error", e.Error()), logger.Field("coupon", orderInfo.Coupon))
+				return e
+			}
+		}
 
 		// save guest order
 		if err = l.svcCtx.OrderModel.Insert(l.ctx, orderInfo, tx); err != nil {
@@ -171,6 +208,12 @@ func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.
 		}
 		return nil
 	})
+	if errors.Is(err, subscribe.ErrOutOfStock) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeOutOfStock), "subscribe out of stock")
+	}
+	if errors.Is(err, couponModel.ErrCouponExhausted) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.CouponInsufficientUsage), "coupon used up")
+	}
 	if err != nil {
 		l.Errorw("[Purchase] Database transaction error", logger.Field("error", err.Error()))
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "transaction error: %v", err.Error())
@@ -183,13 +226,13 @@ func (l *PurchaseLogic) Purchase(req *types.PortalPurchaseRequest) (resp *types.
 	if err != nil {
 		l.Errorw("[CloseOrder Task] Marshal payload error", logger.Field("error", err.Error()), logger.Field("payload", payload))
 	}
-	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(3))
+	task := asynq.NewTask(queue.DeferCloseOrder, val, asynq.MaxRetry(l.svcCtx.Config.Queue.CloseOrderMaxRetry))
 	taskInfo, err := l.svcCtx.Queue.Enqueue(task, asynq.ProcessIn(CloseOrderTimeMinutes*time.Minute))
 	if err != nil {
 		l.Errorw("[CloseOrder Task] Enqueue task error", logger.Field("error", err.Error()), logger.Field("task", taskInfo))
 	} else {
 		l.Infow("[CloseOrder Task] Enqueue task success", logger.Field("TaskID", taskInfo.ID))
 	}
-	resp = &types.PortalPurchaseResponse{OrderNo: orderInfo.OrderNo}
+	resp = &types.PortalPurchaseResponse{OrderNo: orderInfo.OrderNo, Inventory: RemainingInventory(l.ctx, l.svcCtx, sub)}
 	return resp, nil
 }
@@ -60,19 +60,20 @@ func (l *QueryPurchaseOrderLogic) QueryPurchaseOrder(req *types.QueryPurchaseOrd
 	}
 
 	return &types.QueryPurchaseOrderResponse{
-		OrderNo:        orderInfo.OrderNo,
-		Subscribe:      subscribeInfo,
-		Quantity:       orderInfo.Quantity,
-		Price:          orderInfo.Price,
-		Amount:         orderInfo.Amount,
-		Discount:       orderInfo.Discount,
-		Coupon:         orderInfo.Coupon,
-		CouponDiscount: orderInfo.CouponDiscount,
-		FeeAmount:      orderInfo.FeeAmount,
-		Payment:        paymentInfo,
-		Status:         orderInfo.Status,
-		CreatedAt:      orderInfo.CreatedAt.UnixMilli(),
-		Token:          token,
+		OrderNo:         orderInfo.OrderNo,
+		Subscribe:       subscribeInfo,
+		Quantity:        orderInfo.Quantity,
+		Price:           orderInfo.Price,
+		Amount:          orderInfo.Amount,
+		Discount:        orderInfo.Discount,
+		Coupon:          orderInfo.Coupon,
+		CouponDiscount:  orderInfo.CouponDiscount,
+		PaymentDiscount: orderInfo.PaymentDiscount,
+		FeeAmount:       orderInfo.FeeAmount,
+		Payment:         paymentInfo,
+		Status:          orderInfo.Status,
+		CreatedAt:       orderInfo.CreatedAt.UnixMilli(),
+		Token:           token,
 	}, nil
 }
 
@@ -153,6 +154,12 @@ func (l *QueryPurchaseOrderLogic) fetchOrderDetails(orderInfo *order.Order) (typ
 
 	var subscribeInfo types.Subscribe
 	tool.DeepCopy(&subscribeInfo, sub)
+	// show the plan pricing the order was created with rather than the current one
+	if orderInfo.UnitPrice > 0 || orderInfo.PlanDiscount != "" {
+		subscribeInfo.UnitPrice = orderInfo.UnitPrice
+		subscribeInfo.Discount = nil
+		_ = json.Unmarshal([]byte(orderInfo.PlanDiscount), &subscribeInfo.Discount)
+	}
 
 	payment, err := l.svcCtx.PaymentModel.FindOne(l.ctx, orderInfo.PaymentId)
 	if err != nil {
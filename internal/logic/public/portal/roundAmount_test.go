@@ -0,0 +1,36 @@
+package portal
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRoundAmount(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     int64
+		increment  int64
+		rounded    int64
+		adjustment int64
+	}{
+		{name: "disabled", amount: 1234, increment: 0, rounded: 1234, adjustment: 0},
+		{name: "increment of one", amount: 1234, increment: 1, rounded: 1234, adjustment: 0},
+		{name: "free order", amount: 0, increment: 100, rounded: 0, adjustment: 0},
+		{name: "round up", amount: 1263, increment: 100, rounded: 1300, adjustment: 37},
+		{name: "round down", amount: 1234, increment: 100, rounded: 1200, adjustment: -34},
+		{name: "half rounds up", amount: 1250, increment: 100, rounded: 1300, adjustment: 50},
+		{name: "already aligned", amount: 1200, increment: 100, rounded: 1200, adjustment: 0},
+		{name: "never zero", amount: 30, increment: 100, rounded: 100, adjustment: 70},
+		{name: "max order amount", amount: math.MaxInt32 - 10, increment: 1000, rounded: 2147483000, adjustment: -637},
+		{name: "nothing fits", amount: 30, increment: math.MaxInt32 + 1, rounded: 30, adjustment: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rounded, adjustment := RoundAmount(tt.amount, tt.increment)
+			assert.Equal(t, tt.rounded, rounded)
+			assert.Equal(t, tt.adjustment, adjustment)
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package portal
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/coupon"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStackDiscounts(t *testing.T) {
+	percent := &coupon.Coupon{Type: 1, Discount: 10}
+	fixed := &coupon.Coupon{Type: 2, Discount: 3000}
+	bonus := &coupon.Coupon{Type: coupon.TypeBonusDays, Discount: 30}
+
+	// price 10000 with a 20% plan discount
+	cases := []struct {
+		rule             string
+		couponInfo       *coupon.Coupon
+		discount, coupon int64
+	}{
+		{config.DiscountStackingSequential, percent, 2000, 800},
+		{"", percent, 2000, 800},
+		{config.DiscountStackingOriginal, percent, 2000, 1000},
+		{config.DiscountStackingBestOf, percent, 2000, 0},
+		{config.DiscountStackingBestOf, fixed, 0, 3000},
+		{config.DiscountStackingSequential, nil, 2000, 0},
+		{config.DiscountStackingBestOf, nil, 2000, 0},
+		// bonus days take nothing off the amount
+		{config.DiscountStackingSequential, bonus, 2000, 0},
+		{config.DiscountStackingBestOf, bonus, 2000, 0},
+	}
+	for _, c := range cases {
+		discount, couponAmount := StackDiscounts(c.rule, 10000, 8000, 1, c.couponInfo)
+		assert.Equal(t, c.discount, discount, c.rule)
+		assert.Equal(t, c.coupon, couponAmount, c.rule)
+	}
+
+	// the coupon on the list price never takes the order below zero
+	_, couponAmount := StackDiscounts(config.DiscountStackingOriginal, 10000, 2000, 1, fixed)
+	assert.Equal(t, int64(2000), couponAmount)
+
+	assert.Equal(t, int64(30), bonus.BonusDays())
+	assert.Equal(t, int64(0), fixed.BonusDays())
+}
+
+func TestCalculateCouponTiers(t *testing.T) {
+	tiered := &coupon.Coupon{Type: 1, Discount: 5, Tiers: `[{"quantity":6,"discount":10},{"quantity":12,"discount":20}]`}
+	want := map[int64]int64{1: 500, 5: 500, 6: 1000, 11: 1000, 12: 2000, 24: 2000}
+	for quantity, deduction := range want {
+		assert.Equal(t, deduction, CalculateCoupon(10000, quantity, tiered), "quantity %d", quantity)
+	}
+
+	// without tiers the coupon stays flat
+	flat := &coupon.Coupon{Type: 2, Discount: 3000}
+	assert.Equal(t, int64(3000), CalculateCoupon(10000, 12, flat))
+}
@@ -1,31 +1,120 @@
 package portal
 
 import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
 	"github.com/perfect-panel/server/internal/model/coupon"
 	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	paymentPlatform "github.com/perfect-panel/server/pkg/payment"
+	queue "github.com/perfect-panel/server/queue/types"
 )
 
-func getDiscount(discounts []types.SubscribeDiscount, inputMonths int64) float64 {
+// GetDiscount returns the price factor of the best tier the quantity reaches, 1 when none applies.
+// Tiers are matched against the order quantity in both plan quantity modes: for duration plans
+// they are length-of-term discounts (e.g. 12 periods), for count plans volume discounts on the
+// number of copies or devices. The duration of a count plan never affects the tier.
+//
+// With interpolate the factor moves linearly between adjacent tiers instead of stepping at each
+// threshold, see interpolateDiscount. It is never above the stepped factor, so interpolation only
+// smooths the price between tiers and never makes a reached tier more expensive.
+func GetDiscount(discounts []types.SubscribeDiscount, quantity int64, interpolate bool) float64 {
 	var finalDiscount float64 = 100
 
 	for _, discount := range discounts {
-		if inputMonths >= discount.Quantity && discount.Discount < finalDiscount {
+		if quantity >= discount.Quantity && discount.Discount < finalDiscount {
 			finalDiscount = discount.Discount
 		}
 	}
+	if interpolate {
+		finalDiscount = min(finalDiscount, interpolateDiscount(discounts, quantity))
+	}
+
 	return finalDiscount / float64(100)
 }
 
-func calculateCoupon(amount int64, couponInfo *coupon.Coupon) int64 {
+// interpolateDiscount returns the discount percentage on the line between the tiers around the quantity.
+// Below the first threshold it runs from full price at a quantity of 1 to the first tier, so there is
+// no cliff when the first tier is reached either. Above the last threshold it stays at the last tier.
+func interpolateDiscount(discounts []types.SubscribeDiscount, quantity int64) float64 {
+	tiers := slices.Clone(discounts)
+	slices.SortFunc(tiers, func(a, b types.SubscribeDiscount) int {
+		return cmp.Compare(a.Quantity, b.Quantity)
+	})
+	prevQuantity, prevDiscount := int64(1), float64(100)
+	for _, tier := range tiers {
+		if quantity < tier.Quantity {
+			progress := float64(quantity-prevQuantity) / float64(tier.Quantity-prevQuantity)
+			return prevDiscount + (tier.Discount-prevDiscount)*progress
+		}
+		prevQuantity, prevDiscount = tier.Quantity, tier.Discount
+	}
+	return prevDiscount
+}
+
+// CalculateCoupon returns the coupon deduction on the amount, a coupon with quantity tiers deducts the
+// discount of the tier the order quantity reaches.
+func CalculateCoupon(amount, quantity int64, couponInfo *coupon.Coupon) int64 {
+	if couponInfo.Type == coupon.TypeBonusDays {
+		return 0
+	}
+	discount := couponInfo.DiscountFor(quantity)
 	if couponInfo.Type == 1 {
-		return int64(float64(amount) * (float64(couponInfo.Discount) / float64(100)))
+		return int64(float64(amount) * (float64(discount) / float64(100)))
 	} else {
-		return min(couponInfo.Discount, amount)
+		return min(discount, amount)
+	}
+}
+
+// StackDiscounts returns the plan discount and the coupon deduction of an order by the stacking rule.
+// With planDiscount = price - planAmount and c(x) the coupon deduction on x:
+//
+//	sequential: discount = planDiscount, coupon = c(planAmount)
+//	best:       c(price) > planDiscount ? (0, c(price)) : (planDiscount, 0)
+//	original:   discount = planDiscount, coupon = min(c(price), planAmount)
+//
+// An unknown rule is sequential, without a coupon only the plan discount applies.
+func StackDiscounts(rule string, price, planAmount, quantity int64, couponInfo *coupon.Coupon) (discount, couponAmount int64) {
+	discount = price - planAmount
+	if couponInfo == nil {
+		return discount, 0
+	}
+	switch rule {
+	case config.DiscountStackingBestOf:
+		if c := CalculateCoupon(price, quantity, couponInfo); c > discount {
+			return 0, c
+		}
+		return discount, 0
+	case config.DiscountStackingOriginal:
+		return discount, min(CalculateCoupon(price, quantity, couponInfo), planAmount)
+	default:
+		return discount, CalculateCoupon(planAmount, quantity, couponInfo)
+	}
+}
+
+// CalculatePaymentDiscount returns the discount of the payment method on the amount.
+func CalculatePaymentDiscount(amount int64, config *payment.Payment) int64 {
+	if config.DiscountPercent <= 0 {
+		return 0
 	}
+	return int64(float64(amount) * (float64(min(config.DiscountPercent, 100)) / float64(100)))
 }
 
-func calculateFee(amount int64, config *payment.Payment) int64 {
+// CalculateFee returns the fee the payment method charges on the amount.
+func CalculateFee(amount int64, config *payment.Payment) int64 {
+	// the sandbox platform never charges a fee
+	if paymentPlatform.ParsePlatform(config.Platform) == paymentPlatform.Test {
+		return 0
+	}
 	var fee float64
 	switch config.FeeMode {
 	case 0:
@@ -41,3 +130,44 @@ func calculateFee(amount int64, config *payment.Payment) int64 {
 	}
 	return int64(fee)
 }
+
+// RoundAmount rounds the payable total after the fee half up to a multiple of increment and
+// returns the adjustment it made, which is negative when rounded down. A payable order is never
+// rounded down to nothing, and it falls back to the next lower multiple when rounding up would
+// pass the int32 order amount limit, the total is left alone when neither fits.
+func RoundAmount(amount, increment int64) (rounded, adjustment int64) {
+	if increment <= 1 || amount <= 0 {
+		return amount, 0
+	}
+	rounded = (amount + increment/2) / increment * increment
+	if rounded == 0 {
+		rounded = increment
+	}
+	if rounded > math.MaxInt32 {
+		rounded -= increment
+	}
+	if rounded <= 0 {
+		return amount, 0
+	}
+	return rounded, rounded - amount
+}
+
+// RemainingInventory re-reads the plan inventory after a purchase took its unit, -1 means unlimited.
+// It also queues the low stock webhook check when a threshold is configured.
+func RemainingInventory(ctx context.Context, svcCtx *svc.ServiceContext, sub *subscribe.Subscribe) int64 {
+	if sub.Inventory == -1 {
+		return -1
+	}
+	data, err := svcCtx.SubscribeModel.FindOne(ctx, sub.Id)
+	if err != nil {
+		logger.WithContext(ctx).Error("[Purchase] Find subscribe error", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		return sub.Inventory - 1
+	}
+	if threshold := svcCtx.Config.Inventory.LowStockThreshold; threshold > 0 && data.Inventory < threshold {
+		val, _ := json.Marshal(queue.ForthwithSubscribeLowStockPayload{SubscribeId: data.Id})
+		if _, err = svcCtx.Queue.Enqueue(asynq.NewTask(queue.ForthwithSubscribeLowStock, val)); err != nil {
+			logger.WithContext(ctx).Error("[Purchase] Enqueue low stock task error", logger.Field("error", err.Error()), logger.Field("subscribe_id", data.Id))
+		}
+	}
+	return data.Inventory
+}
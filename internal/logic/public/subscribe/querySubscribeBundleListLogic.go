@@ -0,0 +1,43 @@
+package subscribe
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QuerySubscribeBundleListLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Get subscribe bundle list
+func NewQuerySubscribeBundleListLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QuerySubscribeBundleListLogic {
+	return &QuerySubscribeBundleListLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// QuerySubscribeBundleList lists the bundles on sale.
+func (l *QuerySubscribeBundleListLogic) QuerySubscribeBundleList() (resp *types.QuerySubscribeBundleListResponse, err error) {
+	var list []*subscribe.Bundle
+	err = l.svcCtx.DB.Model(&subscribe.Bundle{}).Where("sell = ?", true).Order("sort ASC, id ASC").Find(&list).Error
+	if err != nil {
+		l.Logger.Error("[QuerySubscribeBundleListLogic] get subscribe bundle list failed: ", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "get subscribe bundle list failed: %v", err.Error())
+	}
+	bundleList := make([]types.SubscribeBundle, 0)
+	tool.DeepCopy(&bundleList, list)
+	return &types.QuerySubscribeBundleListResponse{
+		List: bundleList,
+	}, nil
+}
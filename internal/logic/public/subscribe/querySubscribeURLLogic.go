@@ -0,0 +1,52 @@
+package subscribe
+
+import (
+	"context"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QuerySubscribeURLLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Get user subscribe URL
+func NewQuerySubscribeURLLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QuerySubscribeURLLogic {
+	return &QuerySubscribeURLLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// QuerySubscribeURL returns the subscription URL of one of the user's subscriptions. Frontends cannot sign
+// links themselves, so with signed URLs enabled this is where users get a fresh one.
+func (l *QuerySubscribeURLLogic) QuerySubscribeURL(req *types.QuerySubscribeURLRequest, host string) (resp *types.QuerySubscribeURLResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	userSub, err := l.svcCtx.UserModel.FindOneSubscribe(l.ctx, req.Id)
+	if err != nil {
+		l.Errorw("FindOneSubscribe failed", logger.Field("error", err.Error()), logger.Field("reqId", req.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOneSubscribe failed: %v", err.Error())
+	}
+	if userSub.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "subscribe does not belong to the user")
+	}
+	link, expires := subscribeLogic.UserSubscribeURL(l.svcCtx, host, userSub.Token)
+	return &types.QuerySubscribeURLResponse{
+		Url:       link,
+		ExpiresAt: expires,
+	}, nil
+}
@@ -34,8 +34,8 @@ func CalculateRemainingAmount(ctx context.Context, svcCtx *svc.ServiceContext, u
 		logger.WithContext(ctx).Error("[PreUnsubscribe] FindOneDetails", logger.Field("err", err.Error()), logger.Field("id", userSubscribe.OrderId))
 		return 0, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "FindOneDetails failed, id: %d", userSubscribe.OrderId)
 	}
-	// Calculate Order Quantity
-	orderQuantity := orderDetails.Quantity
+	// Calculate Order Quantity, counted in UnitTime periods so count mode plans are prorated by time
+	orderQuantity := userSubscribe.Subscribe.Periods(orderDetails.Quantity)
 	// Calculate Order Amount
 	orderAmount := orderDetails.Amount + orderDetails.GiftAmount
 
@@ -43,10 +43,15 @@ func CalculateRemainingAmount(ctx context.Context, svcCtx *svc.ServiceContext, u
 		for _, subOrder := range orderDetails.SubOrders {
 			if subOrder.Status == 2 || subOrder.Status == 5 {
 				orderAmount += subOrder.Amount + subOrder.GiftAmount
-				orderQuantity += subOrder.Quantity
+				orderQuantity += userSubscribe.Subscribe.Periods(subOrder.Quantity)
 			}
 		}
 	}
+	// prorate with the unit price the user paid, orders created before price snapshots fall back to the plan
+	unitPrice := userSubscribe.Subscribe.UnitPrice
+	if orderDetails.UnitPrice > 0 {
+		unitPrice = orderDetails.UnitPrice
+	}
 	// Calculate Remaining Amount
 	remainingAmount, err := deduction.CalculateRemainingAmount(
 		deduction.Subscribe{
@@ -56,7 +61,7 @@ func CalculateRemainingAmount(ctx context.Context, svcCtx *svc.ServiceContext, u
 			Download:       userSubscribe.Download,
 			Upload:         userSubscribe.Upload,
 			UnitTime:       userSubscribe.Subscribe.UnitTime,
-			UnitPrice:      userSubscribe.Subscribe.UnitPrice,
+			UnitPrice:      unitPrice,
 			ResetCycle:     userSubscribe.Subscribe.ResetCycle,
 			DeductionRatio: userSubscribe.Subscribe.DeductionRatio,
 		},
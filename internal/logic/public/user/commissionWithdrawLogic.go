@@ -67,7 +67,7 @@ func (l *CommissionWithdrawLogic) CommissionWithdraw(req *types.CommissionWithdr
 
 	err = tx.Model(log.SystemLog{}).Create(&log.SystemLog{
 		Type:      log.TypeCommission.Uint8(),
-		Date:      time.Now().Format("2006-01-02"),
+		Date:      log.Date(time.Now()),
 		ObjectID:  u.Id,
 		Content:   string(b),
 		CreatedAt: time.Now(),
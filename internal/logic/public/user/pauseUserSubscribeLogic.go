@@ -0,0 +1,110 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type PauseUserSubscribeLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Pause User Subscribe
+func NewPauseUserSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *PauseUserSubscribeLogic {
+	return &PauseUserSubscribeLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// PauseUserSubscribe takes an active subscription out of service and keeps its remaining time,
+// at most MaxPauses times per subscription.
+func (l *PauseUserSubscribeLogic) PauseUserSubscribe(req *types.PauseUserSubscribeRequest) error {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	maxPauses := l.svcCtx.Config.Subscribe.MaxPauses
+	if maxPauses <= 0 {
+		return errors.Wrapf(xerr.NewErrCodeData(xerr.SubscribePauseLimit, map[string]int64{"limit": 0}), "subscription pausing is disabled")
+	}
+
+	userSub, err := findOwnSubscribe(l.ctx, l.svcCtx, u, req.UserSubscribeId)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	// only a running subscription with an expiry has time to keep
+	if userSub.Status != 1 || userSub.ExpireTime.Unix() == 0 || !userSub.ExpireTime.After(now) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "user subscribe %d can not be paused in status %d", userSub.Id, userSub.Status)
+	}
+	if userSub.PauseCount >= maxPauses {
+		return errors.Wrapf(xerr.NewErrCodeData(xerr.SubscribePauseLimit, map[string]int64{"limit": maxPauses}), "user subscribe %d was paused %d times", userSub.Id, userSub.PauseCount)
+	}
+
+	userSub.Pause(now)
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		return createPauseLog(tx, userSub, log.SubscribePauseTypePause, userSub.PausedFor, now)
+	})
+	if err != nil {
+		l.Errorw("[PauseUserSubscribe] Update user subscribe error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "pause user subscribe error: %v", err.Error())
+	}
+	// the nodes drop the user with the plan's user list
+	if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, userSub.SubscribeId); err != nil {
+		l.Errorw("[PauseUserSubscribe] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribeId", userSub.SubscribeId))
+	}
+	l.Infow("[PauseUserSubscribe] User subscribe paused", logger.Field("userSubscribeId", userSub.Id), logger.Field("remaining", userSub.PausedFor))
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	return nil
+}
+
+// findOwnSubscribe returns the user subscription when it belongs to the user
+func findOwnSubscribe(ctx context.Context, svcCtx *svc.ServiceContext, u *user.User, id int64) (*user.Subscribe, error) {
+	userSub, err := svcCtx.UserModel.FindOneSubscribe(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "user subscribe %d not found", id)
+		}
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find user subscribe error: %v", err.Error())
+	}
+	if userSub.UserId != u.Id {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "user subscribe %d does not belong to the current user", id)
+	}
+	return userSub, nil
+}
+
+// createPauseLog records a pause or resume of the subscription with the time it had left in seconds
+func createPauseLog(tx *gorm.DB, userSub *user.Subscribe, logType uint16, remaining int64, now time.Time) error {
+	pauseLog := log.SubscribePause{
+		Type:            logType,
+		UserSubscribeId: userSub.Id,
+		Remaining:       remaining,
+		Timestamp:       now.UnixMilli(),
+	}
+	content, _ := pauseLog.Marshal()
+	return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+		Type:     log.TypeSubscribePause.Uint8(),
+		Date:     log.Date(now),
+		ObjectID: userSub.UserId,
+		Content:  string(content),
+	}).Error
+}
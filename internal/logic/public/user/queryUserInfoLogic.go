@@ -39,6 +39,9 @@ func (l *QueryUserInfoLogic) QueryUserInfo() (resp *types.User, err error) {
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
 	}
 	tool.DeepCopy(resp, u)
+	if u.PromoCreditExpiredAt != nil {
+		resp.PromoCreditExpiredAt = u.PromoCreditExpiredAt.UnixMilli()
+	}
 
 	var userMethods []types.UserAuthMethod
 	for _, method := range resp.AuthMethods {
@@ -61,9 +64,32 @@ func (l *QueryUserInfoLogic) QueryUserInfo() (resp *types.User, err error) {
 	})
 
 	resp.AuthMethods = userMethods
+
+	subscribes, err := l.svcCtx.UserModel.QueryUserSubscribe(l.ctx, u.Id)
+	if err != nil {
+		l.Errorw("[QueryUserInfo] Query user subscribe failed", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "query user subscribe failed: %v", err.Error())
+	}
+	resp.DeviceLimit = deviceLimit(subscribes)
 	return resp, nil
 }
 
+// deviceLimit returns the device limit granted by the active subscriptions.
+// The largest plan limit applies, a plan without limit (0) makes the user unlimited.
+func deviceLimit(subscribes []*user.SubscribeDetails) int64 {
+	var limit int64
+	for _, sub := range subscribes {
+		if sub.Status > 1 || sub.Subscribe == nil {
+			continue
+		}
+		if sub.Subscribe.DeviceLimit == 0 {
+			return 0
+		}
+		limit = max(limit, sub.Subscribe.DeviceLimit)
+	}
+	return limit
+}
+
 // getAuthTypePriority 获取认证类型的排序优先级
 // email: 1 (第一位)
 // mobile: 2 (第二位)
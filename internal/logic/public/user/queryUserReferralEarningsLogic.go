@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QueryUserReferralEarningsLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Query User Referral Earnings
+func NewQueryUserReferralEarningsLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QueryUserReferralEarningsLogic {
+	return &QueryUserReferralEarningsLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *QueryUserReferralEarningsLogic) QueryUserReferralEarnings(req *types.QueryUserReferralEarningsRequest) (resp *types.QueryUserReferralEarningsResponse, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	// referral rewards are gift logs carrying the referred user
+	data, total, err := l.svcCtx.LogModel.FilterSystemLog(l.ctx, &log.FilterParams{
+		Page:     req.Page,
+		Size:     req.Size,
+		Type:     log.TypeGift.Uint8(),
+		ObjectID: u.Id,
+		Search:   log.ReferralGiftSearch,
+	})
+	if err != nil {
+		l.Errorw("Query User Referral Earnings failed", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Query User Referral Earnings failed: %v", err)
+	}
+	list := make([]types.ReferralEarning, 0, len(data))
+	for _, datum := range data {
+		var content log.Gift
+		if err = content.Unmarshal([]byte(datum.Content)); err != nil {
+			l.Errorf("unmarshal gift log content failed: %v", err.Error())
+			continue
+		}
+		list = append(list, types.ReferralEarning{
+			OrderNo:   content.OrderNo,
+			RefereeId: content.RefereeId,
+			Amount:    content.Amount,
+			Timestamp: content.Timestamp,
+		})
+	}
+
+	return &types.QueryUserReferralEarningsResponse{
+		List:  list,
+		Total: total,
+	}, nil
+}
@@ -0,0 +1,64 @@
+package user
+
+import (
+	"context"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+type QueryUserSpendingSummaryLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Query User Spending Summary
+func NewQueryUserSpendingSummaryLogic(ctx context.Context, svcCtx *svc.ServiceContext) *QueryUserSpendingSummaryLogic {
+	return &QueryUserSpendingSummaryLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+func (l *QueryUserSpendingSummaryLogic) QueryUserSpendingSummary() (resp *types.UserSpendingSummary, err error) {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	return SpendingSummary(l.ctx, l.svcCtx, u)
+}
+
+// SpendingSummary returns what the user spent in total, the current gift amount and the number of active
+// subscriptions. Closed and failed orders are not counted, refunded ones are reported apart.
+func SpendingSummary(ctx context.Context, svcCtx *svc.ServiceContext, u *user.User) (*types.UserSpendingSummary, error) {
+	spending, err := svcCtx.OrderModel.QueryUserSpending(ctx, u.Id)
+	if err != nil {
+		logger.WithContext(ctx).Errorw("[SpendingSummary] Query user spending failed", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "query user spending failed: %v", err.Error())
+	}
+	subs, err := svcCtx.UserModel.QueryUserSubscribe(ctx, u.Id)
+	if err != nil {
+		logger.WithContext(ctx).Errorw("[SpendingSummary] Query user subscribe failed", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "query user subscribe failed: %v", err.Error())
+	}
+	resp := &types.UserSpendingSummary{
+		TotalSpent: spending.Spent,
+		Refunded:   spending.Refunded,
+		GiftAmount: u.GiftAmount,
+	}
+	for _, sub := range subs {
+		// pending and active, the same subscriptions count against MaxSubscriptions
+		if sub.Status <= 1 {
+			resp.ActiveSubscriptions++
+		}
+	}
+	return resp, nil
+}
@@ -0,0 +1,68 @@
+package user
+
+import (
+	"context"
+	"time"
+
+	subscribeLogic "github.com/perfect-panel/server/internal/logic/subscribe"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+type ResumeUserSubscribeLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// Resume User Subscribe
+func NewResumeUserSubscribeLogic(ctx context.Context, svcCtx *svc.ServiceContext) *ResumeUserSubscribeLogic {
+	return &ResumeUserSubscribeLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// ResumeUserSubscribe puts a paused subscription back in service, it expires after the time it had left at its pause.
+func (l *ResumeUserSubscribeLogic) ResumeUserSubscribe(req *types.ResumeUserSubscribeRequest) error {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	userSub, err := findOwnSubscribe(l.ctx, l.svcCtx, u, req.UserSubscribeId)
+	if err != nil {
+		return err
+	}
+	if userSub.Status != user.SubscribeStatusPaused {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "user subscribe %d is not paused", userSub.Id)
+	}
+
+	now := time.Now()
+	remaining := userSub.PausedFor
+	userSub.Resume(now)
+	err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+		if err := l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub, tx); err != nil {
+			return err
+		}
+		return createPauseLog(tx, userSub, log.SubscribePauseTypeResume, remaining, now)
+	})
+	if err != nil {
+		l.Errorw("[ResumeUserSubscribe] Update user subscribe error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "resume user subscribe error: %v", err.Error())
+	}
+	if err = l.svcCtx.SubscribeModel.ClearCache(l.ctx, userSub.SubscribeId); err != nil {
+		l.Errorw("[ResumeUserSubscribe] Clear subscribe cache error", logger.Field("error", err.Error()), logger.Field("subscribeId", userSub.SubscribeId))
+	}
+	l.Infow("[ResumeUserSubscribe] User subscribe resumed", logger.Field("userSubscribeId", userSub.Id), logger.Field("expireTime", userSub.ExpireTime))
+	subscribeLogic.InvalidateRedirects(l.ctx, l.svcCtx)
+	return nil
+}
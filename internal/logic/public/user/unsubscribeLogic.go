@@ -109,7 +109,7 @@ func (l *UnsubscribeLogic) Unsubscribe(req *types.UnsubscribeRequest) error {
 
 			if err := db.Model(&log.SystemLog{}).Create(&log.SystemLog{
 				Type:     log.TypeBalance.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
+				Date:     log.Date(time.Now()),
 				ObjectID: u.Id,
 				Content:  string(content),
 			}).Error; err != nil {
@@ -132,7 +132,7 @@ func (l *UnsubscribeLogic) Unsubscribe(req *types.UnsubscribeRequest) error {
 
 			if err := db.Model(&log.SystemLog{}).Create(&log.SystemLog{
 				Type:     log.TypeGift.Uint8(),
-				Date:     time.Now().Format(time.DateOnly),
+				Date:     log.Date(time.Now()),
 				ObjectID: u.Id,
 				Content:  string(content),
 			}).Error; err != nil {
@@ -0,0 +1,77 @@
+package user
+
+import (
+	"context"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/constant"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// MaxSubscribeAliasLength is the longest alias in characters
+const MaxSubscribeAliasLength = 64
+
+type UpdateUserSubscribeAliasLogic struct {
+	logger.Logger
+	ctx    context.Context
+	svcCtx *svc.ServiceContext
+}
+
+// NewUpdateUserSubscribeAliasLogic Update User Subscribe Alias
+func NewUpdateUserSubscribeAliasLogic(ctx context.Context, svcCtx *svc.ServiceContext) *UpdateUserSubscribeAliasLogic {
+	return &UpdateUserSubscribeAliasLogic{
+		Logger: logger.WithContext(ctx),
+		ctx:    ctx,
+		svcCtx: svcCtx,
+	}
+}
+
+// UpdateUserSubscribeAlias sets the name the subscription config carries instead of the plan name,
+// an empty alias clears it.
+func (l *UpdateUserSubscribeAliasLogic) UpdateUserSubscribeAlias(req *types.UpdateUserSubscribeAliasRequest) error {
+	u, ok := l.ctx.Value(constant.CtxKeyUser).(*user.User)
+	if !ok {
+		logger.Error("current user is not found in context")
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidAccess), "Invalid Access")
+	}
+	alias := strings.TrimSpace(req.Alias)
+	if !validSubscribeAlias(alias) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.InvalidParams), "invalid subscribe alias: %q", req.Alias)
+	}
+
+	userSub, err := findOwnSubscribe(l.ctx, l.svcCtx, u, req.UserSubscribeId)
+	if err != nil {
+		return err
+	}
+	userSub.Alias = alias
+	if err = l.svcCtx.UserModel.UpdateSubscribe(l.ctx, userSub); err != nil {
+		l.Errorw("[UpdateUserSubscribeAlias] Update user subscribe error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+		return errors.Wrapf(xerr.NewErrCode(xerr.DatabaseUpdateError), "update user subscribe alias error: %v", err.Error())
+	}
+	if err = l.svcCtx.UserModel.ClearSubscribeCache(l.ctx, userSub); err != nil {
+		l.Errorw("[UpdateUserSubscribeAlias] Clear user subscribe cache error", logger.Field("error", err.Error()), logger.Field("userSubscribeId", userSub.Id))
+	}
+	return nil
+}
+
+// validSubscribeAlias reports whether the alias can be written into every output format. Letters, digits,
+// spaces and - _ . ( ) are allowed, quotes, line breaks and the separators of the URI and YAML formats are not.
+func validSubscribeAlias(alias string) bool {
+	if utf8.RuneCountInString(alias) > MaxSubscribeAliasLength {
+		return false
+	}
+	for _, r := range alias {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' || strings.ContainsRune("-_.()", r) {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+package subscribe
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// builds caps the config builds resolving nodes at once, a traffic spike queues here instead of
+// exhausting the database connections.
+var builds = newBuildLimiter()
+
+// buildLimiter is a semaphore whose size is read on every acquire, so a changed subscribe config
+// applies without a restart.
+type buildLimiter struct {
+	mu       sync.Mutex
+	inFlight int64
+	rejected int64
+	released chan struct{} // closed and replaced on every release to wake the waiting builds
+}
+
+func newBuildLimiter() *buildLimiter {
+	return &buildLimiter{released: make(chan struct{})}
+}
+
+// acquire takes a slot, waiting up to wait for one when limit builds are in flight. A limit of 0 only counts the build.
+func (b *buildLimiter) acquire(ctx context.Context, limit int64, wait time.Duration) bool {
+	var timeout <-chan time.Time
+	for {
+		b.mu.Lock()
+		if limit <= 0 || b.inFlight < limit {
+			b.inFlight++
+			b.mu.Unlock()
+			return true
+		}
+		released := b.released
+		b.mu.Unlock()
+
+		if timeout == nil {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case <-released:
+		case <-timeout:
+			b.reject()
+			return false
+		case <-ctx.Done():
+			b.reject()
+			return false
+		}
+	}
+}
+
+func (b *buildLimiter) reject() {
+	b.mu.Lock()
+	b.rejected++
+	b.mu.Unlock()
+}
+
+func (b *buildLimiter) release() {
+	b.mu.Lock()
+	b.inFlight--
+	close(b.released)
+	b.released = make(chan struct{})
+	b.mu.Unlock()
+}
+
+func (b *buildLimiter) stats() (inFlight, rejected int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.inFlight, b.rejected
+}
+
+// BuildStats reports the config builds in flight and the builds rejected since start.
+func BuildStats() (inFlight, rejected int64) {
+	return builds.stats()
+}
+
+// limitBuild runs fn in a build slot, it fails with SubscribeBuildBusy when no slot frees up in time.
+func (l *SubscribeLogic) limitBuild(fn func() (any, error)) (any, error) {
+	cfg := l.svc.Config.Subscribe
+	if !builds.acquire(l.ctx.Request.Context(), cfg.BuildConcurrency, time.Duration(cfg.BuildQueueWait)*time.Millisecond) {
+		l.Infow("[SubscribeLogic] build rejected, concurrency limit reached")
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeBuildBusy), "%d subscribe builds in flight", cfg.BuildConcurrency)
+	}
+	defer builds.release()
+	return fn()
+}
+
+// servesPlaceholder reports whether getServers answers with a placeholder node without touching the database.
+func (l *SubscribeLogic) servesPlaceholder(userSub *user.Subscribe) bool {
+	return l.svc.Config.Subscribe.Maintenance || userSub.Status == user.SubscribeStatusPaused || l.isSubscriptionExpired(userSub)
+}
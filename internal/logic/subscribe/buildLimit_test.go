@@ -0,0 +1,34 @@
+package subscribe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildLimiter(t *testing.T) {
+	b := newBuildLimiter()
+	ctx := context.Background()
+
+	assert.True(t, b.acquire(ctx, 1, 0))
+	assert.False(t, b.acquire(ctx, 1, 10*time.Millisecond))
+	inFlight, rejected := b.stats()
+	assert.Equal(t, int64(1), inFlight)
+	assert.Equal(t, int64(1), rejected)
+
+	// a waiting build takes the slot once it is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		b.release()
+	}()
+	assert.True(t, b.acquire(ctx, 1, time.Second))
+	b.release()
+
+	// without a limit the builds are only counted
+	assert.True(t, b.acquire(ctx, 0, 0))
+	assert.True(t, b.acquire(ctx, 0, 0))
+	inFlight, _ = b.stats()
+	assert.Equal(t, int64(2), inFlight)
+}
@@ -0,0 +1,53 @@
+package subscribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+// builtConfig is a built config with the entity tag of the inputs it was built from
+type builtConfig struct {
+	ETag   string
+	Config []byte
+}
+
+// cooldownCacheKey hashes the build key, so the cached configs do not reveal the token.
+func cooldownCacheKey(buildKey string) string {
+	sum := sha256.Sum256([]byte(buildKey))
+	return fmt.Sprintf("%s:%s", config.SubscribeCooldownKey, hex.EncodeToString(sum[:]))
+}
+
+// cooldownConfig returns the config built for the token within the cooldown and the time left,
+// nil when the fetch is outside of it. It is kept as a hash of the config and its tag.
+func (l *SubscribeLogic) cooldownConfig(cacheKey string) (*builtConfig, time.Duration) {
+	ctx := l.ctx.Request.Context()
+	fields, err := l.svc.Redis.HGetAll(ctx, cacheKey).Result()
+	if err != nil || len(fields) == 0 {
+		return nil, 0
+	}
+	ttl, err := l.svc.Redis.TTL(ctx, cacheKey).Result()
+	if err != nil || ttl <= 0 {
+		ttl = time.Second
+	}
+	return &builtConfig{ETag: fields["etag"], Config: []byte(fields["config"])}, ttl
+}
+
+// startCooldown keeps the built config and its tag for the cooldown interval.
+func (l *SubscribeLogic) startCooldown(cacheKey string, built *builtConfig) {
+	interval := time.Duration(l.svc.Config.Subscribe.FetchCooldownInterval) * time.Second
+	if interval <= 0 {
+		return
+	}
+	ctx := l.ctx.Request.Context()
+	pipe := l.svc.Redis.TxPipeline()
+	pipe.HSet(ctx, cacheKey, "etag", built.ETag, "config", built.Config)
+	pipe.Expire(ctx, cacheKey, interval)
+	if _, err := pipe.Exec(ctx); err != nil {
+		l.Errorw("[SubscribeLogic] Cache config for the fetch cooldown failed", logger.Field("error", err.Error()))
+	}
+}
@@ -0,0 +1,49 @@
+package subscribe
+
+import (
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// checkDatacenter applies the datacenter policy of the plan to the client IP. It fails open, without a
+// datacenter list or when the plan can't be loaded the fetch goes through.
+func (l *SubscribeLogic) checkDatacenter(userSub *user.Subscribe) error {
+	if l.svc.Datacenter == nil {
+		return nil
+	}
+	sub, err := l.svc.SubscribeModel.FindOne(l.ctx.Request.Context(), userSub.SubscribeId)
+	if err != nil {
+		l.Errorw("[SubscribeLogic] Find subscribe for datacenter check failed", logger.Field("error", err.Error()), logger.Field("subscribeId", userSub.SubscribeId))
+		return nil
+	}
+	if sub.DatacenterPolicy == subscribe.DatacenterPolicyAllow {
+		return nil
+	}
+	ip := l.ctx.ClientIP()
+	var asn uint
+	var organization string
+	if l.svc.GeoLookup != nil {
+		if info, _ := l.svc.GeoLookup.Lookup(ip); info != nil {
+			asn, organization = info.ASN, info.ASOrganization
+		}
+	}
+	if !l.svc.Datacenter.IsDatacenter(ip, asn) {
+		return nil
+	}
+	l.datacenter = true
+	fields := []logger.LogField{
+		logger.Field("user_subscribe_id", userSub.Id),
+		logger.Field("ip", ip),
+		logger.Field("asn", asn),
+		logger.Field("as_organization", organization),
+	}
+	if sub.DatacenterPolicy == subscribe.DatacenterPolicyBlock {
+		l.Infow("[SubscribeLogic] Blocked a fetch from a datacenter network", fields...)
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeDatacenterBlocked), "fetch from datacenter network %s (AS%d)", ip, asn)
+	}
+	l.Infow("[SubscribeLogic] Flagged a fetch from a datacenter network", fields...)
+	return nil
+}
@@ -0,0 +1,24 @@
+package subscribe
+
+import (
+	"strconv"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+// dedupNodes keeps the first node of every address, port and protocol endpoint.
+// Plans selecting nodes by several overlapping tags can reach the same endpoint through
+// differently named nodes, the first one keeps its name and tags.
+func dedupNodes(nodes []*node.Node) []*node.Node {
+	seen := make(map[string]struct{}, len(nodes))
+	result := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		key := n.Address + "|" + strconv.Itoa(int(n.Port)) + "|" + n.Protocol
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result = append(result, n)
+	}
+	return result
+}
@@ -0,0 +1,30 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDedupNodesOverlappingTags(t *testing.T) {
+	nodes := []*node.Node{
+		{Id: 1, Name: "HK 01", Tags: "hk", Address: "hk.example.com", Port: 443, Protocol: "vless"},
+		{Id: 2, Name: "HK Premium", Tags: "hk,premium", Address: "hk.example.com", Port: 443, Protocol: "vless"},
+		{Id: 3, Name: "HK Trojan", Tags: "premium", Address: "hk.example.com", Port: 443, Protocol: "trojan"},
+		{Id: 4, Name: "HK 02", Tags: "premium", Address: "hk.example.com", Port: 8443, Protocol: "vless"},
+		{Id: 5, Name: "US 01", Tags: "us,premium", Address: "us.example.com", Port: 443, Protocol: "vless"},
+		{Id: 6, Name: "US Premium", Tags: "premium", Address: "us.example.com", Port: 443, Protocol: "vless"},
+	}
+
+	result := dedupNodes(nodes)
+	assert.Equal(t, []int64{1, 3, 4, 5}, nodeIds(result))
+	// the first occurrence keeps its name and tags
+	assert.Equal(t, "HK 01", result[0].Name)
+	assert.Equal(t, "hk", result[0].Tags)
+	assert.Equal(t, "us,premium", result[3].Tags)
+}
+
+func TestDedupNodesEmpty(t *testing.T) {
+	assert.Empty(t, dedupNodes(nil))
+}
@@ -0,0 +1,119 @@
+package subscribe
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+// demoTokenPrefix marks the tokens of demo links, regular tokens are plain hex
+const demoTokenPrefix = "demo-"
+
+// demoToken is what an unused demo link resolves to, everything the fetch needs is copied at minting so
+// serving it reads nothing from the user tables.
+type demoToken struct {
+	UserSubscribeId int64  `json:"user_subscribe_id"`
+	SubscribeId     int64  `json:"subscribe_id"`
+	UUID            string `json:"uuid"`
+	ExpireAt        int64  `json:"expire_at"` // unix ms
+}
+
+func isDemoToken(token string) bool {
+	return strings.HasPrefix(token, demoTokenPrefix)
+}
+
+func demoCacheKey(token string) string {
+	return config.SubscribeDemoKeyPrefix + token
+}
+
+// MintDemoToken creates the token of a demo link for the configured demo subscription and returns it with
+// the time it expires unused. It is valid until then or until one fetch has been served with it.
+func MintDemoToken(ctx context.Context, svcCtx *svc.ServiceContext) (string, time.Time, error) {
+	cfg := svcCtx.Config.Demo
+	if cfg.UserSubscribeId == 0 || len(cfg.Nodes) == 0 || cfg.TokenTTL <= 0 {
+		return "", time.Time{}, errors.Wrapf(xerr.NewErrCodeMsg(xerr.InvalidParams, "demo links are not configured"), "demo links are not configured")
+	}
+	userSub, err := svcCtx.UserModel.FindOneSubscribe(ctx, cfg.UserSubscribeId)
+	if err != nil {
+		return "", time.Time{}, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find demo subscribe %d error: %v", cfg.UserSubscribeId, err.Error())
+	}
+	buf := make([]byte, 16)
+	if _, err = rand.Read(buf); err != nil {
+		return "", time.Time{}, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "generate demo token error: %v", err.Error())
+	}
+	token := demoTokenPrefix + hex.EncodeToString(buf)
+	data, _ := json.Marshal(demoToken{
+		UserSubscribeId: userSub.Id,
+		SubscribeId:     userSub.SubscribeId,
+		UUID:            userSub.UUID,
+		ExpireAt:        time.Now().Add(time.Duration(cfg.Duration) * time.Second).UnixMilli(),
+	})
+	ttl := time.Duration(cfg.TokenTTL) * time.Second
+	if err = svcCtx.Redis.Set(ctx, demoCacheKey(token), data, ttl).Err(); err != nil {
+		return "", time.Time{}, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "store demo token error: %v", err.Error())
+	}
+	return token, time.Now().Add(ttl), nil
+}
+
+// demoSubscribe resolves an unused demo link to a subscription that exists only for the fetch.
+func (l *SubscribeLogic) demoSubscribe(token string) (*user.Subscribe, error) {
+	data, err := l.svc.Redis.Get(l.ctx.Request.Context(), demoCacheKey(token)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "demo token used or expired")
+	}
+	if err != nil {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "get demo token error: %v", err.Error())
+	}
+	var demo demoToken
+	if err = json.Unmarshal(data, &demo); err != nil {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "invalid demo token: %v", err.Error())
+	}
+	l.demo = true
+	return &user.Subscribe{
+		Id:          demo.UserSubscribeId,
+		SubscribeId: demo.SubscribeId,
+		UUID:        demo.UUID,
+		Token:       token,
+		Status:      1,
+		ExpireTime:  time.UnixMilli(demo.ExpireAt),
+	}, nil
+}
+
+// consumeDemo invalidates the demo link once a fetch was served with it.
+func (l *SubscribeLogic) consumeDemo(token string) {
+	if err := l.svc.Redis.Del(context.WithoutCancel(l.ctx.Request.Context()), demoCacheKey(token)).Err(); err != nil {
+		l.Errorw("[SubscribeLogic] Invalidate demo token failed", logger.Field("error", err.Error()))
+		return
+	}
+	l.Infow("[SubscribeLogic] Demo link served", logger.Field("client_ip", l.ctx.ClientIP()), logger.Field("user_agent", l.ctx.Request.UserAgent()))
+}
+
+// demoServers returns the configured demo nodes that are enabled.
+func (l *SubscribeLogic) demoServers() ([]*node.Node, error) {
+	enable := true
+	_, nodes, err := l.svc.NodeModel.FilterNodeList(l.ctx.Request.Context(), &node.FilterNodeParams{
+		Page:    1,
+		Size:    len(l.svc.Config.Demo.Nodes),
+		NodeId:  l.svc.Config.Demo.Nodes,
+		Preload: true,
+		Enabled: &enable,
+	})
+	if err != nil {
+		l.Errorw("[Generate Subscribe]find demo nodes error", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find demo nodes error: %v", err.Error())
+	}
+	nodes, _ = validNodes(nodes)
+	return nodes, nil
+}
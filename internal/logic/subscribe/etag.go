@@ -0,0 +1,45 @@
+package subscribe
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/perfect-panel/server/internal/model/client"
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+)
+
+// configETag returns the entity tag of the config built from these inputs. The client and the plan
+// stand in for the template by their update time, so does every node and its server, editing any of
+// them changes the tag. The build key covers the client, the token and the request URI.
+func configETag(buildKey, siteName string, app *client.SubscribeApplication, sub *subscribe.Subscribe, userSub *user.Subscribe, servers []*node.Node) string {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%s|%s|%d|%d|%d\n", buildKey, siteName, app.UpdatedAt.UnixMilli(), sub.Id, sub.UpdatedAt.UnixMilli())
+	_, _ = fmt.Fprintf(h, "%d|%s|%s|%d|%d|%d|%d|%d|%d\n", userSub.Id, userSub.UUID, userSub.Alias, userSub.Status,
+		userSub.Upload, userSub.Download, userSub.Traffic, userSub.ExpireTime.Unix(), userSub.UpdatedAt.UnixMilli())
+	for _, n := range servers {
+		_, _ = fmt.Fprintf(h, "%d|%s|%d", n.Id, n.Name, n.UpdatedAt.UnixMilli())
+		if n.Server != nil {
+			_, _ = fmt.Fprintf(h, "|%d|%d", n.Server.Id, n.Server.UpdatedAt.UnixMilli())
+		}
+		_, _ = h.Write([]byte{'\n'})
+	}
+	return `"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches reports whether the If-None-Match header names the entity tag, weak tags compare by their value.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, item := range strings.Split(ifNoneMatch, ",") {
+		item = strings.TrimPrefix(strings.TrimSpace(item), "W/")
+		if item == "*" || item == etag {
+			return true
+		}
+	}
+	return false
+}
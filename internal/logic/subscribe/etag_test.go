@@ -0,0 +1,37 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/client"
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConfigETag(t *testing.T) {
+	app := &client.SubscribeApplication{Id: 1}
+	sub := &subscribe.Subscribe{Id: 2}
+	userSub := &user.Subscribe{Id: 3, UUID: "uuid"}
+	servers := []*node.Node{{Id: 1, Name: "a", Server: &node.Server{Id: 1}}}
+	etag := configETag("key", "site", app, sub, userSub, servers)
+	assert.Equal(t, etag, configETag("key", "site", app, sub, userSub, servers))
+
+	servers[0].Server.UpdatedAt = time.Now()
+	changed := configETag("key", "site", app, sub, userSub, servers)
+	assert.NotEqual(t, etag, changed)
+
+	userSub.Download = 1024
+	assert.NotEqual(t, changed, configETag("key", "site", app, sub, userSub, servers))
+}
+
+func TestETagMatches(t *testing.T) {
+	etag := `"abc"`
+	assert.True(t, etagMatches(`"abc"`, etag))
+	assert.True(t, etagMatches(`"x", W/"abc"`, etag))
+	assert.True(t, etagMatches("*", etag))
+	assert.False(t, etagMatches("", etag))
+	assert.False(t, etagMatches(`"abcd"`, etag))
+}
@@ -0,0 +1,33 @@
+package subscribe
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/perfect-panel/server/internal/model/client"
+)
+
+// formatClient returns the client application named by the format segment of a format route.
+// The segment matches the application name or user agent keyword ignoring case and punctuation,
+// so "singbox" finds the application with the "sing-box" user agent.
+func formatClient(clients []*client.SubscribeApplication, format string) *client.SubscribeApplication {
+	format = normalizeFormat(format)
+	if format == "" {
+		return nil
+	}
+	for _, item := range clients {
+		if normalizeFormat(item.Name) == format || normalizeFormat(item.UserAgent) == format {
+			return item
+		}
+	}
+	return nil
+}
+
+func normalizeFormat(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return unicode.ToLower(r)
+		}
+		return -1
+	}, s)
+}
@@ -0,0 +1,23 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatClient(t *testing.T) {
+	clients := []*client.SubscribeApplication{
+		{Id: 1, Name: "Default", UserAgent: "default", IsDefault: true},
+		{Id: 3, Name: "Clash", UserAgent: "Clash"},
+		{Id: 4, Name: "SingBox", UserAgent: "sing-box"},
+	}
+
+	assert.Equal(t, int64(3), formatClient(clients, "clash").Id)
+	assert.Equal(t, int64(4), formatClient(clients, "singbox").Id)
+	assert.Equal(t, int64(4), formatClient(clients, "Sing-Box").Id)
+	// an unknown format never falls back to the default application
+	assert.Nil(t, formatClient(clients, "surge"))
+	assert.Nil(t, formatClient(clients, "-"))
+}
@@ -0,0 +1,42 @@
+package subscribe
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// Health resolves the token and counts the nodes its config would contain without building it.
+// It is polled by uptime monitors, so no subscribe activity is logged.
+// The link is checked like a fetch, so a revoked or expired signed link fails the health check too.
+func (l *SubscribeLogic) Health(req *types.SubscribeRequest) (*types.SubscribeHealthResponse, error) {
+	if err := verifySubscribeSign(l.svc, req.Token, req.Params, time.Now()); err != nil {
+		l.Infow("[SubscribeHealth] Subscribe link rejected", logger.Field("error", err.Error()), logger.Field("token", req.Token))
+		return nil, err
+	}
+	userSubscribe, err := l.getUserSubscribe(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	if l.isSubscriptionExpired(userSubscribe) {
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeExpired), "subscribe expired at %v", userSubscribe.ExpireTime)
+	}
+	servers, err := l.getServers(userSubscribe, parseNodeFilter(req.Params))
+	if err != nil {
+		return nil, err
+	}
+	if len(servers) == 0 {
+		l.Debugw("[SubscribeHealth] subscribe has no nodes", logger.Field("user_subscribe_id", userSubscribe.Id))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNoNodes), "subscribe has no nodes")
+	}
+	return &types.SubscribeHealthResponse{
+		Nodes:      int64(len(servers)),
+		Upload:     userSubscribe.Upload,
+		Download:   userSubscribe.Download,
+		Traffic:    userSubscribe.Traffic,
+		ExpireTime: userSubscribe.ExpireTime.UnixMilli(),
+	}, nil
+}
@@ -0,0 +1,56 @@
+package subscribe
+
+import (
+	"strings"
+
+	"github.com/perfect-panel/server/internal/model/client"
+)
+
+// Reasons of a ClientMatch
+const (
+	MatchReasonMatched      = "matched"
+	MatchReasonNoKeyword    = "user agent keyword not found"
+	MatchReasonStash        = "stash user agent only matches a stash client"
+	MatchReasonNotEvaluated = "not evaluated, an earlier client matched"
+)
+
+// ClientMatch is the outcome of matching one client application against a user agent
+type ClientMatch struct {
+	Client  *client.SubscribeApplication
+	Matched bool
+	Reason  string
+}
+
+// MatchClient picks the client application serving a subscription fetch with the given user agent. The
+// first client whose user agent keyword is contained in it wins, except that a Stash user agent only
+// matches a Stash client, as Stash sends the keywords of the clients it is compatible with. Without a
+// match the default client is returned with fallback set, target is nil when there is none either.
+// The evaluation lists every client in order with the reason it won or lost.
+func MatchClient(clients []*client.SubscribeApplication, userAgent string) (target *client.SubscribeApplication, fallback bool, evaluation []ClientMatch) {
+	userAgent = strings.ToLower(userAgent)
+	var defaultApp *client.SubscribeApplication
+	evaluation = make([]ClientMatch, 0, len(clients))
+	for _, item := range clients {
+		if target != nil {
+			evaluation = append(evaluation, ClientMatch{Client: item, Reason: MatchReasonNotEvaluated})
+			continue
+		}
+		u := strings.ToLower(item.UserAgent)
+		if item.IsDefault {
+			defaultApp = item
+		}
+		switch {
+		case !strings.Contains(userAgent, u):
+			evaluation = append(evaluation, ClientMatch{Client: item, Reason: MatchReasonNoKeyword})
+		case strings.Contains(userAgent, "stash") && !strings.Contains(u, "stash"):
+			evaluation = append(evaluation, ClientMatch{Client: item, Reason: MatchReasonStash})
+		default:
+			target = item
+			evaluation = append(evaluation, ClientMatch{Client: item, Matched: true, Reason: MatchReasonMatched})
+		}
+	}
+	if target == nil && defaultApp != nil {
+		return defaultApp, true, evaluation
+	}
+	return target, false, evaluation
+}
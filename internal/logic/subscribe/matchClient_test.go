@@ -0,0 +1,44 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/client"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatchClient(t *testing.T) {
+	clients := []*client.SubscribeApplication{
+		{Id: 1, Name: "Default", UserAgent: "default", IsDefault: true},
+		{Id: 2, Name: "Clash", UserAgent: "Clash"},
+		{Id: 3, Name: "Stash", UserAgent: "Stash"},
+		{Id: 4, Name: "SingBox", UserAgent: "sing-box"},
+	}
+
+	target, fallback, evaluation := MatchClient(clients, "ClashX/1.95")
+	assert.Equal(t, int64(2), target.Id)
+	assert.False(t, fallback)
+	assert.Equal(t, []string{MatchReasonNoKeyword, MatchReasonMatched, MatchReasonNotEvaluated, MatchReasonNotEvaluated}, reasons(evaluation))
+
+	// stash announces clash compatibility but only matches the stash client
+	target, _, evaluation = MatchClient(clients, "Stash/2.4 Clash/1.9")
+	assert.Equal(t, int64(3), target.Id)
+	assert.Equal(t, []string{MatchReasonNoKeyword, MatchReasonStash, MatchReasonMatched, MatchReasonNotEvaluated}, reasons(evaluation))
+
+	target, fallback, evaluation = MatchClient(clients, "curl/8.0")
+	assert.Equal(t, int64(1), target.Id)
+	assert.True(t, fallback)
+	assert.Len(t, evaluation, 4)
+
+	target, fallback, _ = MatchClient(clients[1:], "curl/8.0")
+	assert.Nil(t, target)
+	assert.False(t, fallback)
+}
+
+func reasons(evaluation []ClientMatch) []string {
+	var list []string
+	for _, item := range evaluation {
+		list = append(list, item.Reason)
+	}
+	return list
+}
@@ -0,0 +1,80 @@
+package subscribe
+
+import (
+	"strings"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+// nodeFilter narrows the plan nodes to the tags and regions requested by the client.
+type nodeFilter struct {
+	tags    []string
+	regions []string
+	sort    string // node order requested by the client, ordering the nodes left by the filter
+}
+
+// parseNodeFilter reads the comma separated tag and region query params, matching is case-insensitive
+func parseNodeFilter(params map[string]string) nodeFilter {
+	return nodeFilter{
+		tags:    splitFilterValues(params["tag"]),
+		regions: splitFilterValues(params["region"]),
+		sort:    strings.ToLower(strings.TrimSpace(params["sort"])),
+	}
+}
+
+func splitFilterValues(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.ToLower(strings.TrimSpace(v)); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+func (f nodeFilter) empty() bool {
+	return len(f.tags) == 0 && len(f.regions) == 0
+}
+
+// apply keeps the nodes carrying a requested tag or located in a requested region.
+// It only ever narrows the given list, and a filter that matches nothing serves the whole list.
+func (f nodeFilter) apply(nodes []*node.Node) []*node.Node {
+	if f.empty() {
+		return nodes
+	}
+	result := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if f.match(n) {
+			result = append(result, n)
+		}
+	}
+	if len(result) == 0 {
+		return nodes
+	}
+	return result
+}
+
+func (f nodeFilter) match(n *node.Node) bool {
+	for _, t := range strings.Split(n.Tags, ",") {
+		if containsFold(f.tags, t) {
+			return true
+		}
+	}
+	if n.Server != nil && (containsFold(f.regions, n.Server.Country) || containsFold(f.regions, n.Server.City)) {
+		return true
+	}
+	return false
+}
+
+func containsFold(values []string, s string) bool {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return false
+	}
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
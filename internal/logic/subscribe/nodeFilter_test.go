@@ -0,0 +1,23 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNodeFilter(t *testing.T) {
+	nodes := []*node.Node{
+		{Id: 1, Tags: "hk,premium", Server: &node.Server{Country: "HK"}},
+		{Id: 2, Tags: "us", Server: &node.Server{Country: "US"}},
+		{Id: 3, Tags: "jp", Server: &node.Server{Country: "JP", City: "Tokyo"}},
+	}
+
+	assert.Equal(t, []int64{1}, nodeIds(parseNodeFilter(map[string]string{"tag": "Premium"}).apply(nodes)))
+	assert.Equal(t, []int64{2, 3}, nodeIds(parseNodeFilter(map[string]string{"region": "us, tokyo"}).apply(nodes)))
+	assert.Equal(t, []int64{1, 2}, nodeIds(parseNodeFilter(map[string]string{"tag": "hk", "region": "US"}).apply(nodes)))
+	// empty or unmatched filters serve the whole plan
+	assert.Len(t, parseNodeFilter(map[string]string{"tag": " , "}).apply(nodes), 3)
+	assert.Len(t, parseNodeFilter(map[string]string{"tag": "sg"}).apply(nodes), 3)
+}
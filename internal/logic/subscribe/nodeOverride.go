@@ -0,0 +1,72 @@
+package subscribe
+
+import (
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// excludeNodes removes the nodes excluded for the user subscribe
+func excludeNodes(nodes []*node.Node, exclude []int64) []*node.Node {
+	if len(exclude) == 0 {
+		return nodes
+	}
+	result := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if !tool.Contains(exclude, n.Id) {
+			result = append(result, n)
+		}
+	}
+	return result
+}
+
+// extraNodeIds returns the forced and the unexpired bonus nodes of the user subscribe that are
+// neither excluded nor served already
+func extraNodeIds(userSub *user.Subscribe, nodes []*node.Node, now time.Time) []int64 {
+	exclude := tool.StringToInt64Slice(userSub.ExcludeNodes)
+	extra := append(tool.StringToInt64Slice(userSub.IncludeNodes), userSub.ActiveBonusNodes(now)...)
+	var ids []int64
+	for _, id := range tool.RemoveDuplicateElements(extra...) {
+		if tool.Contains(exclude, id) {
+			continue
+		}
+		exists := false
+		for _, n := range nodes {
+			if n.Id == id {
+				exists = true
+				break
+			}
+		}
+		if !exists {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// includeNodes appends the enabled forced and bonus nodes of the user subscribe, even if the plan does not contain them
+func (l *SubscribeLogic) includeNodes(userSub *user.Subscribe, nodes []*node.Node) ([]*node.Node, error) {
+	ids := extraNodeIds(userSub, nodes, time.Now())
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+
+	enable := true
+	_, included, err := l.svc.NodeModel.FilterNodeList(l.ctx.Request.Context(), &node.FilterNodeParams{
+		Page:    1,
+		Size:    len(ids),
+		NodeId:  ids,
+		Preload: true,
+		Enabled: &enable,
+	})
+	if err != nil {
+		l.Errorw("[Generate Subscribe]find included server error: %v", logger.Field("error", err.Error()))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find included server error: %v", err.Error())
+	}
+	return append(nodes, included...), nil
+}
@@ -0,0 +1,32 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExtraNodeIdsBonusExpiry(t *testing.T) {
+	now := time.Now()
+	expire := now.Add(time.Hour)
+	userSub := &user.Subscribe{
+		ExcludeNodes: "4",
+		IncludeNodes: "2",
+		BonusNodes:   "3,4,5",
+		BonusExpire:  &expire,
+	}
+	nodes := []*node.Node{{Id: 1}, {Id: 5}}
+
+	// before the expiry the bonus nodes not excluded or served by the plan are added
+	assert.Equal(t, []int64{2, 3}, extraNodeIds(userSub, nodes, now))
+	// after it only the forced nodes remain, without touching the stored bonus
+	assert.Equal(t, []int64{2}, extraNodeIds(userSub, nodes, expire))
+	assert.Equal(t, []int64{2}, extraNodeIds(userSub, nodes, expire.Add(time.Minute)))
+	assert.Equal(t, "3,4,5", userSub.BonusNodes)
+
+	userSub.BonusExpire = nil
+	assert.Equal(t, []int64{2}, extraNodeIds(userSub, nodes, now))
+}
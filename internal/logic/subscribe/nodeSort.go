@@ -0,0 +1,56 @@
+package subscribe
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+)
+
+// nodeSortMode returns the node order of a fetch, the one requested by the client overrides the order of
+// the plan. Unknown values are ignored, an empty mode keeps the node sort of the admin.
+func nodeSortMode(requested, planSort string) string {
+	switch requested {
+	case subscribe.NodeSortName, subscribe.NodeSortTag, subscribe.NodeSortWeight:
+		return requested
+	}
+	return planSort
+}
+
+// sortNodes orders the nodes by the mode in place. The sort is stable, nodes that compare equal keep the
+// node sort of the admin they were queried in, so the same nodes are always served in the same order.
+func sortNodes(nodes []*node.Node, mode string) {
+	switch mode {
+	case subscribe.NodeSortName:
+		slices.SortStableFunc(nodes, func(a, b *node.Node) int {
+			return cmp.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+		})
+	case subscribe.NodeSortTag:
+		slices.SortStableFunc(nodes, func(a, b *node.Node) int {
+			ta, tb := firstTag(a), firstTag(b)
+			// untagged nodes go last
+			if (ta == "") != (tb == "") {
+				if ta == "" {
+					return 1
+				}
+				return -1
+			}
+			return cmp.Compare(ta, tb)
+		})
+	case subscribe.NodeSortWeight:
+		slices.SortStableFunc(nodes, func(a, b *node.Node) int {
+			return cmp.Compare(b.Weight, a.Weight)
+		})
+	}
+}
+
+func firstTag(n *node.Node) string {
+	for _, t := range strings.Split(n.Tags, ",") {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			return t
+		}
+	}
+	return ""
+}
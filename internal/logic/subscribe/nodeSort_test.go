@@ -0,0 +1,53 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/stretchr/testify/assert"
+)
+
+func sortTestNodes() []*node.Node {
+	return []*node.Node{
+		{Id: 1, Name: "tokyo", Tags: "jp", Weight: 1},
+		{Id: 2, Name: "Hong Kong", Tags: "", Weight: 5},
+		{Id: 3, Name: "osaka", Tags: "jp,premium", Weight: 5},
+		{Id: 4, Name: "hong kong", Tags: "HK", Weight: 0},
+	}
+}
+
+func TestSortNodes(t *testing.T) {
+	tests := []struct {
+		mode string
+		want []int64
+	}{
+		{mode: "", want: []int64{1, 2, 3, 4}},
+		// equal names keep their order
+		{mode: subscribe.NodeSortName, want: []int64{2, 4, 3, 1}},
+		{mode: subscribe.NodeSortTag, want: []int64{4, 1, 3, 2}},
+		{mode: subscribe.NodeSortWeight, want: []int64{2, 3, 1, 4}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			nodes := sortTestNodes()
+			sortNodes(nodes, tt.mode)
+			assert.Equal(t, tt.want, nodeIds(nodes))
+		})
+	}
+}
+
+func TestSortFilteredNodes(t *testing.T) {
+	nodes := parseNodeFilter(map[string]string{"tag": "jp"}).apply(sortTestNodes())
+	sortNodes(nodes, subscribe.NodeSortName)
+	assert.Equal(t, []int64{3, 1}, nodeIds(nodes))
+}
+
+func TestNodeSortMode(t *testing.T) {
+	sortParam := func(params map[string]string) string {
+		return parseNodeFilter(params).sort
+	}
+	assert.Equal(t, subscribe.NodeSortWeight, nodeSortMode(sortParam(map[string]string{}), subscribe.NodeSortWeight))
+	assert.Equal(t, subscribe.NodeSortName, nodeSortMode(sortParam(map[string]string{"sort": " Name"}), subscribe.NodeSortWeight))
+	assert.Equal(t, subscribe.NodeSortTag, nodeSortMode(sortParam(map[string]string{"sort": "latency"}), subscribe.NodeSortTag))
+}
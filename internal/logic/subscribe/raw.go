@@ -0,0 +1,75 @@
+package subscribe
+
+import (
+	"strings"
+
+	"github.com/perfect-panel/server/adapter"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// rawRequested reports whether the fetch asks for the plain node list with ?raw=1
+func rawRequested(params map[string]string) bool {
+	switch strings.ToLower(params["raw"]) {
+	case "1", "true":
+		return true
+	}
+	return false
+}
+
+// rawHandler answers a fetch with the share links of the nodes, one per line, whatever client made it.
+// The nodes are resolved as for a rendered config, so expired, paused and exhausted subscriptions get
+// their notice nodes, only the template of the client or the plan is not applied.
+func (l *SubscribeLogic) rawHandler(req *types.SubscribeRequest) (*types.SubscribeResponse, error) {
+	userSubscribe, err := l.getUserSubscribe(req.Token)
+	if err != nil {
+		l.Errorw("[SubscribeLogic] Get user subscribe failed", logger.Field("error", err.Error()), logger.Field("token", req.Token))
+		return nil, err
+	}
+	if err = l.checkDatacenter(userSubscribe); err != nil {
+		return nil, err
+	}
+	var subscribeStatus bool
+	defer func() {
+		l.logSubscribeActivity(subscribeStatus, userSubscribe, req)
+	}()
+
+	build := func() (any, error) {
+		servers, err := l.getServers(userSubscribe, parseNodeFilter(req.Params))
+		if err != nil {
+			return nil, err
+		}
+		if len(servers) == 0 {
+			if servers, err = l.emptyServers(userSubscribe); err != nil {
+				return nil, err
+			}
+		}
+		proxies, err := adapter.NewAdapter("").Proxies(servers)
+		if err != nil {
+			l.Errorw("[SubscribeLogic] Build proxies failed", logger.Field("error", err.Error()))
+			return nil, errors.Wrapf(xerr.NewErrCode(500), "build proxies failed: %v", err.Error())
+		}
+		return adapter.RawURIs(proxies, userSubscribe.UUID), nil
+	}
+	var val any
+	if l.servesPlaceholder(userSubscribe) {
+		val, err = build()
+	} else {
+		val, err = l.limitBuild(build)
+	}
+	if err != nil {
+		return nil, err
+	}
+	l.Infow("[SubscribeLogic] Raw node list served",
+		logger.Field("user_subscribe_id", userSubscribe.Id),
+		logger.Field("user_agent", req.UA),
+	)
+	l.ctx.Header("Content-Type", "text/plain; charset=UTF-8")
+	subscribeStatus = true
+	return &types.SubscribeResponse{
+		Config: val.([]byte),
+		Header: subscriptionUserInfo(userSubscribe, ""),
+	}, nil
+}
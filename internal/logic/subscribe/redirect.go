@@ -0,0 +1,68 @@
+package subscribe
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+// InvalidateRedirects makes redirect mode clients get freshly built configs after nodes, plans, clients or user
+// subscriptions changed.
+// Uploads of the previous version stay in the bucket until its lifecycle rules remove them.
+func InvalidateRedirects(ctx context.Context, svcCtx *svc.ServiceContext) {
+	if err := svcCtx.Redis.Incr(ctx, config.SubscribeRedirectVersionKey).Err(); err != nil {
+		logger.WithContext(ctx).Errorw("[SubscribeRedirect] Bump redirect version failed", logger.Field("error", err.Error()))
+	}
+}
+
+// redirectCacheKey hashes the build key, which holds the token, together with the current redirect version and
+// the state of the subscription that decides which nodes it gets. A cached config is never redirected to once the
+// subscription expired, was paused, stopped, transferred or edited, lost its bonus nodes or maintenance began.
+func (l *SubscribeLogic) redirectCacheKey(buildKey string, userSub *user.Subscribe) string {
+	version, _ := l.svc.Redis.Get(l.ctx.Request.Context(), config.SubscribeRedirectVersionKey).Int64()
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%d|%s|%s", version, buildKey, l.redirectState(userSub, time.Now()))))
+	return fmt.Sprintf("%s:%s", config.SubscribeRedirectKey, hex.EncodeToString(sum[:]))
+}
+
+func (l *SubscribeLogic) redirectState(userSub *user.Subscribe, now time.Time) string {
+	return fmt.Sprintf("%d|%d|%d|%d|%t|%t|%v", userSub.UserId, userSub.Status, userSub.ExpireTime.Unix(), userSub.UpdatedAt.UnixMilli(),
+		l.isSubscriptionExpired(userSub), l.svc.Config.Subscribe.Maintenance, userSub.ActiveBonusNodes(now))
+}
+
+func (l *SubscribeLogic) cachedRedirect(cacheKey string) string {
+	redirectURL, err := l.svc.Redis.Get(l.ctx.Request.Context(), cacheKey).Result()
+	if err != nil {
+		return ""
+	}
+	return redirectURL
+}
+
+// uploadConfig stores the config in the object store and caches its URL, an empty URL means the upload failed.
+func (l *SubscribeLogic) uploadConfig(cacheKey, outputFormat string, encoded bool, data []byte) string {
+	ext, contentType := configFile(outputFormat)
+	if outputFormat == "base64" || encoded {
+		ext, contentType = "txt", "text/plain; charset=UTF-8"
+	}
+	ctx := l.ctx.Request.Context()
+	// the cache key is already a hash of the token, the object name must not reveal it
+	name := fmt.Sprintf("subscribe/%s.%s", cacheKey[len(config.SubscribeRedirectKey)+1:], ext)
+	redirectURL, err := l.svc.ObjectStore.Put(ctx, name, data, contentType)
+	if err != nil {
+		l.Errorw("[SubscribeRedirect] Upload config failed, serving it inline", logger.Field("error", err.Error()))
+		return ""
+	}
+	ttl := time.Duration(l.svc.Config.ObjectStore.CacheTTL) * time.Second
+	if ttl > 0 {
+		if err = l.svc.Redis.Set(ctx, cacheKey, redirectURL, ttl).Err(); err != nil {
+			l.Errorw("[SubscribeRedirect] Cache config URL failed", logger.Field("error", err.Error()))
+		}
+	}
+	return redirectURL
+}
@@ -0,0 +1,56 @@
+package subscribe
+
+import (
+	"strings"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// regionBlocked reports whether fetches from the country are refused. A country on the block list is always
+// refused, with an allow list only the countries on it get through. Both lists empty turn the check off.
+func regionBlocked(cfg config.GeoIPConfig, countryCode string) bool {
+	for _, code := range cfg.BlockedCountries {
+		if strings.EqualFold(strings.TrimSpace(code), countryCode) {
+			return true
+		}
+	}
+	if len(cfg.AllowedCountries) == 0 {
+		return false
+	}
+	for _, code := range cfg.AllowedCountries {
+		if strings.EqualFold(strings.TrimSpace(code), countryCode) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkRegion applies the country lists of the GeoIP config to the client IP. It fails open, without a geo
+// lookup or when the country of the IP can't be resolved the fetch goes through.
+func (l *SubscribeLogic) checkRegion(token string) error {
+	cfg := l.svc.Config.GeoIP
+	if len(cfg.AllowedCountries) == 0 && len(cfg.BlockedCountries) == 0 {
+		return nil
+	}
+	if l.svc.GeoLookup == nil {
+		return nil
+	}
+	ip := l.ctx.ClientIP()
+	info, err := l.svc.GeoLookup.Lookup(ip)
+	if err != nil {
+		l.Errorw("[SubscribeLogic] Geo lookup for region check failed", logger.Field("error", err.Error()), logger.Field("ip", ip))
+		return nil
+	}
+	if info == nil || info.CountryCode == "" || !regionBlocked(cfg, info.CountryCode) {
+		return nil
+	}
+	l.Infow("[SubscribeLogic] Blocked a fetch from a restricted region",
+		logger.Field("token", token),
+		logger.Field("ip", ip),
+		logger.Field("country", info.CountryCode),
+	)
+	return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeRegionBlocked), "fetch from %s (%s)", ip, info.CountryCode)
+}
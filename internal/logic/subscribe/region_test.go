@@ -0,0 +1,31 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/config"
+)
+
+func TestRegionBlocked(t *testing.T) {
+	cases := []struct {
+		name    string
+		allowed []string
+		blocked []string
+		country string
+		want    bool
+	}{
+		{name: "off", country: "US"},
+		{name: "blocked", blocked: []string{"CN", "RU"}, country: "RU", want: true},
+		{name: "not blocked", blocked: []string{"CN"}, country: "US"},
+		{name: "case insensitive", blocked: []string{" cn "}, country: "CN", want: true},
+		{name: "allowed", allowed: []string{"US", "CA"}, country: "CA"},
+		{name: "not allowed", allowed: []string{"US"}, country: "DE", want: true},
+		{name: "block wins", allowed: []string{"US"}, blocked: []string{"US"}, country: "US", want: true},
+	}
+	for _, c := range cases {
+		cfg := config.GeoIPConfig{AllowedCountries: c.allowed, BlockedCountries: c.blocked}
+		if got := regionBlocked(cfg, c.country); got != c.want {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, got)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package subscribe
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+)
+
+// Query params of a signed subscription URL
+const (
+	signedURLExpires = "expires"
+	signedURLSign    = "sign"
+)
+
+// signedURLStep rounds the expiry up to the hour, links handed out within the same hour are identical
+// so QR codes and their ETag stay stable.
+const signedURLStep int64 = 3600
+
+// UserSubscribeURL returns the subscription URL handed out to the user for a token and the unix time it
+// expires at, 0 when signed URLs are disabled.
+func UserSubscribeURL(svcCtx *svc.ServiceContext, host, token string) (string, int64) {
+	path := svcCtx.Config.Subscribe.SubscribePath
+	if path == "" {
+		path = "/v1/subscribe/config"
+	}
+	uri, expires := SignSubscribeURI(svcCtx, path+"?token="+url.QueryEscape(token), token, svcCtx.Config.SignedURL.TTL)
+	return SubscribeURL(svcCtx, host, uri), expires
+}
+
+// SignSubscribeURI sets the expiry and the signature of the token on a subscription request URI when
+// signed URLs are enabled, replacing those of a signed one. ttl is in seconds.
+func SignSubscribeURI(svcCtx *svc.ServiceContext, uri, token string, ttl int64) (string, int64) {
+	if !svcCtx.Config.SignedURL.Enable {
+		return uri, 0
+	}
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri, 0
+	}
+	expires := time.Now().Unix() + ttl
+	if rest := expires % signedURLStep; rest != 0 {
+		expires += signedURLStep - rest
+	}
+	query := u.Query()
+	query.Set(signedURLExpires, strconv.FormatInt(expires, 10))
+	query.Set(signedURLSign, subscribeSign(signSecret(svcCtx), token, expires))
+	u.RawQuery = query.Encode()
+	return u.String(), expires
+}
+
+// verifySubscribeSign checks the signature of a fetch when signed URLs are enabled. An expired link gets
+// its own error, the user only has to copy the current link again.
+func verifySubscribeSign(svcCtx *svc.ServiceContext, token string, params map[string]string, now time.Time) error {
+	if !svcCtx.Config.SignedURL.Enable {
+		return nil
+	}
+	expires, err := strconv.ParseInt(params[signedURLExpires], 10, 64)
+	if err != nil {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeLinkInvalid), "subscribe link is not signed")
+	}
+	want := subscribeSign(signSecret(svcCtx), token, expires)
+	if !hmac.Equal([]byte(want), []byte(params[signedURLSign])) {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeLinkInvalid), "subscribe link signature mismatch")
+	}
+	if now.Unix() >= expires {
+		return errors.Wrapf(xerr.NewErrCode(xerr.SubscribeLinkExpired), "subscribe link expired at %d", expires)
+	}
+	return nil
+}
+
+func signSecret(svcCtx *svc.ServiceContext) string {
+	if secret := svcCtx.Config.SignedURL.Secret; secret != "" {
+		return secret
+	}
+	return svcCtx.Config.JwtAuth.AccessSecret
+}
+
+// subscribeSign returns the HMAC-SHA256 of the token and its expiry
+func subscribeSign(secret, token string, expires int64) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(token + ":" + strconv.FormatInt(expires, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,75 @@
+package subscribe
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/xerr"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedURLCode(err error) uint32 {
+	var e *xerr.CodeError
+	if errors.As(errors.Cause(err), &e) {
+		return e.GetErrCode()
+	}
+	return 0
+}
+
+func TestSignedSubscribeURL(t *testing.T) {
+	svcCtx := &svc.ServiceContext{Config: config.Config{
+		SignedURL: config.SignedURL{Enable: true, Secret: "secret", TTL: 3600},
+	}}
+	token := "user-token"
+	uri, expires := SignSubscribeURI(svcCtx, "/v1/subscribe/config?token="+token+"&flag=clash", token, 3600)
+	assert.Zero(t, expires%signedURLStep)
+	assert.GreaterOrEqual(t, expires, time.Now().Unix()+3600)
+
+	u, err := url.Parse(uri)
+	assert.NoError(t, err)
+	params := map[string]string{}
+	for k, v := range u.Query() {
+		params[k] = v[0]
+	}
+	assert.Equal(t, "clash", params["flag"])
+	assert.NoError(t, verifySubscribeSign(svcCtx, token, params, time.Now()))
+
+	// the signature binds the token and the expiry
+	assert.Equal(t, xerr.SubscribeLinkInvalid, signedURLCode(verifySubscribeSign(svcCtx, "other-token", params, time.Now())))
+	tampered := map[string]string{signedURLExpires: "9999999999", signedURLSign: params[signedURLSign]}
+	assert.Equal(t, xerr.SubscribeLinkInvalid, signedURLCode(verifySubscribeSign(svcCtx, token, tampered, time.Now())))
+	assert.Equal(t, xerr.SubscribeLinkInvalid, signedURLCode(verifySubscribeSign(svcCtx, token, map[string]string{}, time.Now())))
+
+	assert.Equal(t, xerr.SubscribeLinkExpired, signedURLCode(verifySubscribeSign(svcCtx, token, params, time.Unix(expires, 0))))
+
+	// re-signing replaces the previous signature
+	resigned, _ := SignSubscribeURI(svcCtx, uri, token, 7200)
+	u, _ = url.Parse(resigned)
+	assert.Len(t, u.Query()[signedURLSign], 1)
+}
+
+func TestSignedSubscribeURLDisabled(t *testing.T) {
+	svcCtx := &svc.ServiceContext{}
+	uri, expires := SignSubscribeURI(svcCtx, "/v1/subscribe/config?token=abc", "abc", 3600)
+	assert.Equal(t, "/v1/subscribe/config?token=abc", uri)
+	assert.Zero(t, expires)
+	assert.NoError(t, verifySubscribeSign(svcCtx, "abc", nil, time.Now()))
+}
+
+// TestHealthSignedURL rejects an unsigned link on the health check before the token is resolved.
+func TestHealthSignedURL(t *testing.T) {
+	svcCtx := &svc.ServiceContext{Config: config.Config{
+		SignedURL: config.SignedURL{Enable: true, Secret: "secret", TTL: 3600},
+	}}
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("GET", "/v1/subscribe/health?token=abc", nil)
+	_, err := NewSubscribeLogic(c, svcCtx).Health(&types.SubscribeRequest{Token: "abc"})
+	assert.Equal(t, xerr.SubscribeLinkInvalid, signedURLCode(err))
+}
@@ -0,0 +1,13 @@
+package subscribe
+
+import (
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/user"
+)
+
+// subscribeSuspended reports whether the token of a subscription in the status is rejected when it is
+// resolved. Only strict mode rejects, any status past active: finished, expired, deducted, stopped and
+// paused subscriptions. A pending one still resolves like in lenient mode.
+func subscribeSuspended(mode string, status uint8) bool {
+	return mode == config.StatusCheckStrict && status > user.SubscribeStatusActive
+}
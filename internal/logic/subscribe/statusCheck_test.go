@@ -0,0 +1,20 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSubscribeSuspended(t *testing.T) {
+	assert.False(t, subscribeSuspended(config.StatusCheckStrict, user.SubscribeStatusActive))
+	assert.False(t, subscribeSuspended(config.StatusCheckStrict, user.SubscribeStatusPending))
+	assert.True(t, subscribeSuspended(config.StatusCheckStrict, user.SubscribeStatusFinished))
+	assert.True(t, subscribeSuspended(config.StatusCheckStrict, user.SubscribeStatusStopped))
+	assert.True(t, subscribeSuspended(config.StatusCheckStrict, user.SubscribeStatusPaused))
+	// lenient and unset keep resolving every status
+	assert.False(t, subscribeSuspended(config.StatusCheckLenient, user.SubscribeStatusExpired))
+	assert.False(t, subscribeSuspended("", user.SubscribeStatusStopped))
+}
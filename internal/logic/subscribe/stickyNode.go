@@ -0,0 +1,88 @@
+package subscribe
+
+import (
+	"hash/fnv"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/node"
+)
+
+// stickyNodeOfflineAfter a node whose server has not reported within this window is treated as unhealthy
+const stickyNodeOfflineAfter = 5 * time.Minute
+
+// stickyNodes keeps one node per tag group for the user.
+// Nodes are grouped by the first plan tag they carry. Nodes selected by id without a plan tag are kept
+// as their own group so they are always served. The node of each group is picked with rendezvous
+// hashing on the user id, so the choice is stable across refreshes and only changes for users of a
+// node that goes away. Unhealthy nodes are skipped while the group still has a healthy candidate.
+func stickyNodes(nodes []*node.Node, tags []string, userId int64) []*node.Node {
+	var order []string
+	groups := make(map[string][]*node.Node)
+	for _, n := range nodes {
+		key := stickyGroupKey(n, tags)
+		if _, ok := groups[key]; !ok {
+			order = append(order, key)
+		}
+		groups[key] = append(groups[key], n)
+	}
+
+	result := make([]*node.Node, 0, len(order))
+	for _, key := range order {
+		if selected := stickySelect(groups[key], userId); selected != nil {
+			result = append(result, selected)
+		}
+	}
+	return result
+}
+
+// stickyGroupKey returns the first plan tag carried by the node, or a per node key when none matches
+func stickyGroupKey(n *node.Node, tags []string) string {
+	nodeTags := strings.Split(n.Tags, ",")
+	for _, tag := range tags {
+		for _, t := range nodeTags {
+			if strings.TrimSpace(t) == tag {
+				return tag
+			}
+		}
+	}
+	return "node:" + strconv.FormatInt(n.Id, 10)
+}
+
+// stickySelect returns the healthy node with the highest rendezvous weight for the user,
+// falling back to the whole group when no node is healthy.
+func stickySelect(candidates []*node.Node, userId int64) *node.Node {
+	var selected *node.Node
+	var weight uint64
+	for _, healthyOnly := range []bool{true, false} {
+		for _, n := range candidates {
+			if healthyOnly && !isNodeHealthy(n) {
+				continue
+			}
+			if w := stickyWeight(userId, n.Id); selected == nil || w > weight {
+				selected, weight = n, w
+			}
+		}
+		if selected != nil {
+			break
+		}
+	}
+	return selected
+}
+
+func stickyWeight(userId, nodeId int64) uint64 {
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(strconv.FormatInt(userId, 10) + ":" + strconv.FormatInt(nodeId, 10)))
+	return h.Sum64()
+}
+
+func isNodeHealthy(n *node.Node) bool {
+	if n.Enabled != nil && !*n.Enabled {
+		return false
+	}
+	if n.Server == nil || n.Server.LastReportedAt == nil {
+		return false
+	}
+	return time.Since(*n.Server.LastReportedAt) <= stickyNodeOfflineAfter
+}
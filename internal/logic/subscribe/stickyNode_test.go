@@ -0,0 +1,85 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func newStickyTestNode(id int64, tags string, healthy bool) *node.Node {
+	reported := time.Now()
+	if !healthy {
+		reported = reported.Add(-2 * stickyNodeOfflineAfter)
+	}
+	return &node.Node{
+		Id:     id,
+		Tags:   tags,
+		Server: &node.Server{LastReportedAt: &reported},
+	}
+}
+
+func nodeIds(nodes []*node.Node) []int64 {
+	ids := make([]int64, 0, len(nodes))
+	for _, n := range nodes {
+		ids = append(ids, n.Id)
+	}
+	return ids
+}
+
+func TestStickyNodesDeterministic(t *testing.T) {
+	nodes := []*node.Node{
+		newStickyTestNode(1, "hk", true),
+		newStickyTestNode(2, "hk", true),
+		newStickyTestNode(3, "hk", true),
+		newStickyTestNode(4, "us", true),
+		newStickyTestNode(5, "us", true),
+	}
+	tags := []string{"hk", "us"}
+
+	first := nodeIds(stickyNodes(nodes, tags, 42))
+	assert.Len(t, first, 2)
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, first, nodeIds(stickyNodes(nodes, tags, 42)))
+	}
+	// the input order must not change the choice
+	reversed := []*node.Node{nodes[4], nodes[3], nodes[2], nodes[1], nodes[0]}
+	assert.ElementsMatch(t, first, nodeIds(stickyNodes(reversed, tags, 42)))
+}
+
+func TestStickyNodesIdSelected(t *testing.T) {
+	nodes := []*node.Node{
+		newStickyTestNode(1, "hk", true),
+		newStickyTestNode(2, "hk", true),
+		newStickyTestNode(3, "", true),
+		newStickyTestNode(4, "other", true),
+	}
+	ids := nodeIds(stickyNodes(nodes, []string{"hk"}, 7))
+	// one node from the hk group plus every node selected by id
+	assert.Len(t, ids, 3)
+	assert.Contains(t, ids, int64(3))
+	assert.Contains(t, ids, int64(4))
+}
+
+func TestStickySelectHealth(t *testing.T) {
+	healthy := newStickyTestNode(1, "hk", true)
+	unhealthy := []*node.Node{
+		newStickyTestNode(2, "hk", false),
+		newStickyTestNode(3, "hk", false),
+	}
+	for userId := int64(1); userId <= 20; userId++ {
+		assert.Equal(t, healthy, stickySelect(append([]*node.Node{healthy}, unhealthy...), userId))
+	}
+
+	// no healthy node left, fall back to a stable choice among the unhealthy ones
+	selected := stickySelect(unhealthy, 9)
+	assert.NotNil(t, selected)
+	assert.Equal(t, selected, stickySelect(unhealthy, 9))
+
+	disabled := false
+	off := newStickyTestNode(4, "hk", true)
+	off.Enabled = &disabled
+	assert.False(t, isNodeHealthy(off))
+	assert.Nil(t, stickySelect(nil, 9))
+}
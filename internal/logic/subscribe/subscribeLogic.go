@@ -1,33 +1,49 @@
 package subscribe
 
 import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"math"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/perfect-panel/server/adapter"
+	"github.com/perfect-panel/server/internal/config"
 	"github.com/perfect-panel/server/internal/model/client"
 	"github.com/perfect-panel/server/internal/model/log"
 	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/subscribe"
 	"github.com/perfect-panel/server/internal/report"
 
 	"github.com/perfect-panel/server/internal/model/user"
 
 	"github.com/gin-gonic/gin"
+	"github.com/hibiken/asynq"
 	"github.com/perfect-panel/server/internal/svc"
 	"github.com/perfect-panel/server/internal/types"
 	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/syncx"
 	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/perfect-panel/server/pkg/xerr"
+	queue "github.com/perfect-panel/server/queue/types"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
 )
 
+// buildFlight shares one config build between concurrent requests for the same token and client
+var buildFlight = syncx.NewSingleFlight()
+
 //goland:noinspection GoNameStartsWithPackageName
 type SubscribeLogic struct {
 	ctx *gin.Context
 	svc *svc.ServiceContext
 	logger.Logger
+	datacenter bool // the fetch comes from a datacenter network and the plan flags those
+	demo       bool // the token is a demo link, the subscription exists only for the fetch
 }
 
 func NewSubscribeLogic(ctx *gin.Context, svc *svc.ServiceContext) *SubscribeLogic {
@@ -39,6 +55,16 @@ func NewSubscribeLogic(ctx *gin.Context, svc *svc.ServiceContext) *SubscribeLogi
 }
 
 func (l *SubscribeLogic) Handler(req *types.SubscribeRequest) (resp *types.SubscribeResponse, err error) {
+	if err = verifySubscribeSign(l.svc, req.Token, req.Params, time.Now()); err != nil {
+		l.Infow("[SubscribeLogic] Subscribe link rejected", logger.Field("error", err.Error()), logger.Field("token", req.Token))
+		return nil, err
+	}
+	if err = l.checkRegion(req.Token); err != nil {
+		return nil, err
+	}
+	if rawRequested(req.Params) {
+		return l.rawHandler(req)
+	}
 	// query client list
 	clients, err := l.svc.ClientModel.List(l.ctx.Request.Context())
 	if err != nil {
@@ -46,31 +72,21 @@ func (l *SubscribeLogic) Handler(req *types.SubscribeRequest) (resp *types.Subsc
 		return nil, err
 	}
 
-	userAgent := strings.ToLower(l.ctx.Request.UserAgent())
-
-	var targetApp, defaultApp *client.SubscribeApplication
-
-	for _, item := range clients {
-		u := strings.ToLower(item.UserAgent)
-		if item.IsDefault {
-			defaultApp = item
+	var targetApp *client.SubscribeApplication
+	if req.Format != "" {
+		targetApp = formatClient(clients, req.Format)
+		if targetApp == nil {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeFormatUnknown), "unknown subscribe format: %s", req.Format)
 		}
-
-		if strings.Contains(userAgent, u) {
-			// Special handling for Stash
-			if strings.Contains(userAgent, "stash") && !strings.Contains(u, "stash") {
-				continue
-			}
-			targetApp = item
-			break
+	} else {
+		var fallback bool
+		targetApp, fallback, _ = MatchClient(clients, l.ctx.Request.UserAgent())
+		if targetApp == nil {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "No matching client found for user agent: %s", strings.ToLower(l.ctx.Request.UserAgent()))
 		}
-	}
-	if targetApp == nil {
-		l.Debugf("[SubscribeLogic] No matching client found", logger.Field("userAgent", userAgent))
-		if defaultApp == nil {
-			return nil, errors.Wrapf(xerr.NewErrCode(xerr.ERROR), "No matching client found for user agent: %s", userAgent)
+		if fallback {
+			l.Debugf("[SubscribeLogic] No matching client found", logger.Field("userAgent", l.ctx.Request.UserAgent()))
 		}
-		targetApp = defaultApp
 	}
 	// Find user subscribe by token
 	userSubscribe, err := l.getUserSubscribe(req.Token)
@@ -78,41 +94,183 @@ func (l *SubscribeLogic) Handler(req *types.SubscribeRequest) (resp *types.Subsc
 		l.Errorw("[SubscribeLogic] Get user subscribe failed", logger.Field("error", err.Error()), logger.Field("token", req.Token))
 		return nil, err
 	}
+	if err = l.checkDatacenter(userSubscribe); err != nil {
+		return nil, err
+	}
 
 	var subscribeStatus = false
+	var throttled bool
 	defer func() {
-		l.logSubscribeActivity(subscribeStatus, userSubscribe, req)
+		// a fetch within the cooldown is not logged, polling clients would flood the log
+		l.logSubscribeActivity(subscribeStatus && !throttled, userSubscribe, req)
 	}()
+	// Concurrent fetches of the same token by the same client share one build, the key covers everything
+	// the config depends on. Only in-flight calls are shared, so a failed build is retried by the next request.
+	key := fmt.Sprintf("%d|%s|%s|%s|%s", targetApp.Id, targetApp.OutputFormat, req.Token, l.ctx.Request.Host, l.ctx.Request.RequestURI)
+	header := subscriptionUserInfo(userSubscribe, strings.ToLower(targetApp.OutputFormat))
+
+	// Redirect mode clients are sent to the uploaded config while it is cached
+	redirect := targetApp.RedirectMode && l.svc.ObjectStore != nil
+	var redirectKey string
+	if redirect {
+		redirectKey = l.redirectCacheKey(key, userSubscribe)
+		if redirectURL := l.cachedRedirect(redirectKey); redirectURL != "" {
+			subscribeStatus = true
+			return &types.SubscribeResponse{Header: header, RedirectURL: redirectURL}, nil
+		}
+	}
+
+	// Fetches of a token within the cooldown get the config built by the first one, nothing is loaded for them
+	var built *builtConfig
+	var cooldownKey string
+	if l.svc.Config.Subscribe.FetchCooldown {
+		cooldownKey = cooldownCacheKey(key)
+		if cached, wait := l.cooldownConfig(cooldownKey); cached != nil {
+			throttled = true
+			built = cached
+			l.ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+		}
+	}
+	if !throttled {
+		// the plan and the nodes are loaded within the shared and limited build, the tag is taken from them
+		val, err := buildFlight.Do(key, func() (any, error) {
+			build := func() (any, error) {
+				subscribeInfo, servers, err := l.configSource(req, userSubscribe)
+				if err != nil {
+					return nil, err
+				}
+				bytes, err := l.buildConfig(req, targetApp, userSubscribe, subscribeInfo, servers)
+				if err != nil {
+					return nil, err
+				}
+				return &builtConfig{
+					ETag:   configETag(key, l.svc.Config.Site.SiteName, targetApp, subscribeInfo, userSubscribe, servers),
+					Config: bytes,
+				}, nil
+			}
+			if l.servesPlaceholder(userSubscribe) {
+				return build()
+			}
+			return l.limitBuild(build)
+		})
+		if err != nil {
+			return nil, err
+		}
+		built = val.(*builtConfig)
+		if cooldownKey != "" {
+			l.startCooldown(cooldownKey, built)
+		}
+	}
+	if !redirect && built.ETag != "" {
+		l.ctx.Header("ETag", built.ETag)
+		// nothing is sent nor logged for a client that holds the current config
+		if etagMatches(req.IfNoneMatch, built.ETag) {
+			return &types.SubscribeResponse{Header: header, NotModified: true}, nil
+		}
+	}
+	bytes := built.Config
+
+	outputFormat := strings.ToLower(targetApp.OutputFormat)
+	// Legacy clients may request the whole body base64 encoded, skip it when the adapter already encoded it.
+	// The original format still names the attachment, only the content type changes.
+	encoded := strings.ToLower(req.Params["encode"]) == "base64" && outputFormat != "base64"
+	if encoded {
+		bytes = []byte(base64.StdEncoding.EncodeToString(bytes))
+	}
+
+	// a failed upload falls back to the inline config
+	if redirect {
+		if redirectURL := l.uploadConfig(redirectKey, outputFormat, encoded, bytes); redirectURL != "" {
+			subscribeStatus = true
+			return &types.SubscribeResponse{Header: header, RedirectURL: redirectURL}, nil
+		}
+	}
+
+	switch outputFormat {
+	case "json", "yaml", "conf", adapter.OutputFormatSurge:
+		ext, contentType := configFile(outputFormat)
+		l.ctx.Header("content-disposition", fmt.Sprintf("attachment;filename*=UTF-8''%s.%s", url.QueryEscape(l.svc.Config.Site.SiteName), ext))
+		l.ctx.Header("Content-Type", contentType)
+	}
+	if outputFormat == "base64" || encoded {
+		l.ctx.Header("Content-Type", "text/plain; charset=UTF-8")
+	}
+
+	resp = &types.SubscribeResponse{
+		Config: bytes,
+		Header: header,
+	}
+	subscribeStatus = true
+	return
+}
+
+// subscriptionUserInfo returns the subscription-userinfo header of a user subscription. Surge reads it
+// for the traffic of its managed config and takes an expire of 0 for a real date, it is left out there
+// for subscriptions that never expire.
+func subscriptionUserInfo(userSubscribe *user.Subscribe, outputFormat string) string {
+	header := fmt.Sprintf("upload=%d;download=%d;total=%d", userSubscribe.Upload, userSubscribe.Download, userSubscribe.Traffic)
+	expire := userSubscribe.ExpireTime.Unix()
+	if expire == 0 && outputFormat == adapter.OutputFormatSurge {
+		return header
+	}
+	return fmt.Sprintf("%s;expire=%d", header, expire)
+}
+
+// configFile returns the file extension and the content type a config of the output format is served with
+func configFile(outputFormat string) (ext, contentType string) {
+	if outputFormat == adapter.OutputFormatSurge {
+		// Surge imports its managed configs as plain text conf files
+		return "conf", "text/plain; charset=UTF-8"
+	}
+	return outputFormat, "application/octet-stream; charset=UTF-8"
+}
+
+// configSource returns the plan of a user subscription and the nodes its config is built from.
+func (l *SubscribeLogic) configSource(req *types.SubscribeRequest, userSubscribe *user.Subscribe) (*subscribe.Subscribe, []*node.Node, error) {
 	// find subscribe info
 	subscribeInfo, err := l.svc.SubscribeModel.FindOne(l.ctx.Request.Context(), userSubscribe.SubscribeId)
 	if err != nil {
 		l.Errorw("[SubscribeLogic] Find subscribe info failed", logger.Field("error", err.Error()), logger.Field("subscribeId", userSubscribe.SubscribeId))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Find subscribe info failed: %v", err.Error())
+		return nil, nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "Find subscribe info failed: %v", err.Error())
 	}
 
 	// Find server list by user subscribe
-	servers, err := l.getServers(userSubscribe)
+	servers, err := l.getServers(userSubscribe, parseNodeFilter(req.Params))
 	if err != nil {
-		return nil, err
+		return nil, nil, err
+	}
+	if len(servers) == 0 {
+		if servers, err = l.emptyServers(userSubscribe); err != nil {
+			return nil, nil, err
+		}
 	}
+	return subscribeInfo, servers, nil
+}
+
+// buildConfig renders the client config of a user subscription for the matched client application.
+func (l *SubscribeLogic) buildConfig(req *types.SubscribeRequest, targetApp *client.SubscribeApplication, userSubscribe *user.Subscribe, subscribeInfo *subscribe.Subscribe, servers []*node.Node) ([]byte, error) {
 	a := adapter.NewAdapter(
-		targetApp.SubscribeTemplate,
+		l.subscribeTemplate(subscribeInfo, targetApp),
 		adapter.WithServers(servers),
 		adapter.WithSiteName(l.svc.Config.Site.SiteName),
-		adapter.WithSubscribeName(subscribeInfo.Name),
+		adapter.WithSubscribeName(subscribeName(subscribeInfo, userSubscribe)),
 		adapter.WithOutputFormat(targetApp.OutputFormat),
+		adapter.WithSupportedProtocols(targetApp.GetSupportedProtocols()),
+		adapter.WithProtocolPriority(targetApp.GetProtocolPriority()),
 		adapter.WithUserInfo(adapter.User{
 			Password:     userSubscribe.UUID,
 			ExpiredAt:    userSubscribe.ExpireTime,
 			Download:     userSubscribe.Download,
 			Upload:       userSubscribe.Upload,
 			Traffic:      userSubscribe.Traffic,
-			SubscribeURL: l.getSubscribeV2URL(),
+			DeviceLimit:  subscribeInfo.DeviceLimit,
+			SubscribeURL: l.getSubscribeV2URL(userSubscribe.Token),
 		}),
 		adapter.WithParams(req.Params),
+		adapter.WithExtraRules(subscribeInfo.ExtraRules),
 	)
 
-	logger.Debugf("[SubscribeLogic] Building client config for user %d with URI %s", userSubscribe.UserId, l.getSubscribeV2URL())
+	logger.Debugf("[SubscribeLogic] Building client config for user %d with URI %s", userSubscribe.UserId, l.ctx.Request.RequestURI)
 
 	// Get client config
 	adapterClient, err := a.Client()
@@ -126,55 +284,74 @@ func (l *SubscribeLogic) Handler(req *types.SubscribeRequest) (resp *types.Subsc
 		return nil, errors.Wrapf(xerr.NewErrCode(500), "Build client config failed: %v", err.Error())
 	}
 
-	var formats = []string{"json", "yaml", "conf"}
-
-	for _, format := range formats {
-		if format == strings.ToLower(targetApp.OutputFormat) {
-			l.ctx.Header("content-disposition", fmt.Sprintf("attachment;filename*=UTF-8''%s.%s", url.QueryEscape(l.svc.Config.Site.SiteName), format))
-			l.ctx.Header("Content-Type", "application/octet-stream; charset=UTF-8")
+	return bytes, nil
+}
 
-		}
+// subscribeName returns the alias the user gave the subscription, the plan name without one.
+func subscribeName(subscribeInfo *subscribe.Subscribe, userSubscribe *user.Subscribe) string {
+	if userSubscribe.Alias != "" {
+		return userSubscribe.Alias
 	}
+	return subscribeInfo.Name
+}
 
-	resp = &types.SubscribeResponse{
-		Config: bytes,
-		Header: fmt.Sprintf(
-			"upload=%d;download=%d;total=%d;expire=%d",
-			userSubscribe.Upload, userSubscribe.Download, userSubscribe.Traffic, userSubscribe.ExpireTime.Unix(),
-		),
+// subscribeTemplate returns the template of the plan when it has one that renders for the output format
+// of the client, otherwise the template of the client.
+func (l *SubscribeLogic) subscribeTemplate(subscribeInfo *subscribe.Subscribe, targetApp *client.SubscribeApplication) string {
+	if subscribeInfo.SubscribeTemplate == "" {
+		l.Debugf("[SubscribeLogic] Using the template of client %d", targetApp.Id)
+		return targetApp.SubscribeTemplate
 	}
-	subscribeStatus = true
-	return
+	if err := adapter.ValidateTemplate(subscribeInfo.SubscribeTemplate, targetApp.OutputFormat); err != nil {
+		l.Errorw("[SubscribeLogic] Plan template does not fit the client output format, using the client template",
+			logger.Field("subscribeId", subscribeInfo.Id), logger.Field("clientId", targetApp.Id),
+			logger.Field("outputFormat", targetApp.OutputFormat), logger.Field("error", err.Error()))
+		return targetApp.SubscribeTemplate
+	}
+	l.Debugf("[SubscribeLogic] Using the template of plan %d", subscribeInfo.Id)
+	return subscribeInfo.SubscribeTemplate
 }
 
-func (l *SubscribeLogic) getSubscribeV2URL() string {
+// getSubscribeV2URL returns the URL of the current fetch for clients to update from, signed with the
+// longer update validity when signed URLs are enabled.
+func (l *SubscribeLogic) getSubscribeV2URL(token string) string {
+	uri, _ := SignSubscribeURI(l.svc, l.ctx.Request.RequestURI, token, l.svc.Config.SignedURL.UpdateTTL)
+	return SubscribeURL(l.svc, l.ctx.Request.Host, uri)
+}
 
-	uri := l.ctx.Request.RequestURI
+// SubscribeURL builds the public subscription URL for a request URI, honoring the gateway mode and the custom subscribe domain.
+func SubscribeURL(svcCtx *svc.ServiceContext, host, uri string) string {
 	// is gateway mode, add /sub prefix
 	if report.IsGatewayMode() {
 		uri = "/sub" + uri
 	}
 	// use custom domain if configured
-	if l.svc.Config.Subscribe.SubscribeDomain != "" {
-		domains := strings.Split(l.svc.Config.Subscribe.SubscribeDomain, "\n")
+	if svcCtx.Config.Subscribe.SubscribeDomain != "" {
+		domains := strings.Split(svcCtx.Config.Subscribe.SubscribeDomain, "\n")
 		return fmt.Sprintf("https://%s%s", domains[0], uri)
 	}
 	// use current request host
-	return fmt.Sprintf("https://%s%s", l.ctx.Request.Host, uri)
+	return fmt.Sprintf("https://%s%s", host, uri)
 }
 
 func (l *SubscribeLogic) getUserSubscribe(token string) (*user.Subscribe, error) {
+	if isDemoToken(token) {
+		return l.demoSubscribe(token)
+	}
 	userSub, err := l.svc.UserModel.FindOneSubscribeByToken(l.ctx.Request.Context(), token)
 	if err != nil {
 		l.Infow("[Generate Subscribe]find subscribe error: %v", logger.Field("error", err.Error()), logger.Field("token", token))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe token not found")
+		}
 		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find subscribe error: %v", err.Error())
 	}
 
-	//  Ignore expiration check
-	//if userSub.Status > 1 {
-	//	l.Infow("[Generate Subscribe]subscribe is not available", logger.Field("status", int(userSub.Status)), logger.Field("token", token))
-	//	return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNotAvailable), "subscribe is not available")
-	//}
+	// Lenient mode leaves subscriptions that are not active to getServers and its placeholder nodes
+	if subscribeSuspended(l.svc.Config.Subscribe.StatusCheck, userSub.Status) {
+		l.Infow("[Generate Subscribe]subscribe is not active", logger.Field("status", int(userSub.Status)), logger.Field("token", token))
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeSuspended), "subscribe status %d is not active", userSub.Status)
+	}
 
 	return userSub, nil
 }
@@ -183,6 +360,11 @@ func (l *SubscribeLogic) logSubscribeActivity(subscribeStatus bool, userSub *use
 	if !subscribeStatus {
 		return
 	}
+	// a demo fetch belongs to no user, it only uses up the link
+	if l.demo {
+		l.consumeDemo(req.Token)
+		return
+	}
 
 	subscribeLog := log.Subscribe{
 		Token:           req.Token,
@@ -190,13 +372,16 @@ func (l *SubscribeLogic) logSubscribeActivity(subscribeStatus bool, userSub *use
 		ClientIP:        l.ctx.ClientIP(),
 		UserSubscribeId: userSub.Id,
 	}
+	subscribeLog.Datacenter = l.datacenter
+	l.enrichSubscribeLog(&subscribeLog, userSub)
 
 	content, _ := subscribeLog.Marshal()
 
-	err := l.svc.LogModel.Insert(l.ctx.Request.Context(), &log.SystemLog{
+	// buffered when SubscribeLogBatchSize is set, the request context may be gone by the time it is written
+	err := l.svc.LogWriter.Write(context.WithoutCancel(l.ctx.Request.Context()), &log.SystemLog{
 		Type:     log.TypeSubscribe.Uint8(),
 		ObjectID: userSub.UserId, // log user id
-		Date:     time.Now().Format(time.DateOnly),
+		Date:     log.Date(time.Now()),
 		Content:  string(content),
 	})
 	if err != nil {
@@ -204,10 +389,59 @@ func (l *SubscribeLogic) logSubscribeActivity(subscribeStatus bool, userSub *use
 	}
 }
 
-func (l *SubscribeLogic) getServers(userSub *user.Subscribe) ([]*node.Node, error) {
+// enrichSubscribeLog fills the geo fields when a lookup is available and queues the multi-country check.
+func (l *SubscribeLogic) enrichSubscribeLog(subscribeLog *log.Subscribe, userSub *user.Subscribe) {
+	if l.svc.GeoLookup == nil {
+		return
+	}
+	info, err := l.svc.GeoLookup.Lookup(subscribeLog.ClientIP)
+	if err != nil {
+		l.Debugf("[Generate Subscribe]geo lookup error: %v", err.Error())
+	}
+	if info == nil {
+		return
+	}
+	subscribeLog.Country = info.Country
+	subscribeLog.CountryCode = info.CountryCode
+	subscribeLog.ASN = info.ASN
+	subscribeLog.ASOrganization = info.ASOrganization
+
+	if l.svc.Config.GeoIP.AnomalyCountries <= 0 || info.CountryCode == "" {
+		return
+	}
+	payload, _ := json.Marshal(queue.ForthwithSubscribeGeoCheckPayload{
+		Token:           subscribeLog.Token,
+		UserId:          userSub.UserId,
+		UserSubscribeId: userSub.Id,
+		CountryCode:     info.CountryCode,
+		Timestamp:       time.Now().Unix(),
+	})
+	if _, err = l.svc.Queue.EnqueueContext(l.ctx.Request.Context(), asynq.NewTask(queue.ForthwithSubscribeGeoCheck, payload)); err != nil {
+		l.Errorw("[Generate Subscribe]enqueue geo check error", logger.Field("error", err.Error()))
+	}
+}
+
+// getServers returns the nodes served to the user subscribe, the filter only narrows the nodes of the plan.
+func (l *SubscribeLogic) getServers(userSub *user.Subscribe, filter nodeFilter) ([]*node.Node, error) {
+	if l.svc.Config.Subscribe.Maintenance {
+		l.Infow("[Generate Subscribe]maintenance mode served the notice node", logger.Field("user_subscribe_id", userSub.Id))
+		return l.createMaintenanceServers(), nil
+	}
+	// the expire time of a paused subscribe is stale until it resumes
+	if userSub.Status == user.SubscribeStatusPaused {
+		l.Infow("[Generate Subscribe]subscribe is paused", logger.Field("user_subscribe_id", userSub.Id))
+		return l.createPlaceholderServers("Subscription Paused"), nil
+	}
 	if l.isSubscriptionExpired(userSub) {
 		return l.createExpiredServers(), nil
 	}
+	if l.demo {
+		nodes, err := l.demoServers()
+		if err != nil {
+			return nil, err
+		}
+		return filter.apply(nodes), nil
+	}
 
 	subDetails, err := l.svc.SubscribeModel.FindOne(l.ctx.Request.Context(), userSub.SubscribeId)
 	if err != nil {
@@ -219,28 +453,58 @@ func (l *SubscribeLogic) getServers(userSub *user.Subscribe) ([]*node.Node, erro
 	tags := tool.RemoveStringElement(strings.Split(subDetails.NodeTags, ","), "")
 
 	l.Debugf("[Generate Subscribe]nodes: %v, NodeTags: %v", len(nodeIds), len(tags))
+	var nodes []*node.Node
 	if len(nodeIds) == 0 && len(tags) == 0 {
 		logger.Infow("[Generate Subscribe]no subscribe nodes")
-		return []*node.Node{}, nil
+		nodes = []*node.Node{}
+	} else {
+		enable := true
+		_, nodes, err = l.svc.NodeModel.FilterNodeList(l.ctx.Request.Context(), &node.FilterNodeParams{
+			Page:    1,
+			Size:    1000,
+			NodeId:  nodeIds,
+			Tag:     tool.RemoveDuplicateElements(tags...),
+			Preload: true,
+			Enabled: &enable, // Only get enabled nodes
+		})
+
+		l.Debugf("[Query Subscribe]found servers: %v", len(nodes))
+
+		if err != nil {
+			l.Errorw("[Generate Subscribe]find server details error: %v", logger.Field("error", err.Error()))
+			return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find server details error: %v", err.Error())
+		}
+		logger.Debugf("[Generate Subscribe]found servers: %v", len(nodes))
 	}
-	enable := true
-	var nodes []*node.Node
-	_, nodes, err = l.svc.NodeModel.FilterNodeList(l.ctx.Request.Context(), &node.FilterNodeParams{
-		Page:    1,
-		Size:    1000,
-		NodeId:  nodeIds,
-		Tag:     tool.RemoveDuplicateElements(tags...),
-		Preload: true,
-		Enabled: &enable, // Only get enabled nodes
-	})
 
-	l.Debugf("[Query Subscribe]found servers: %v", len(nodes))
+	// per user subscribe node overrides
+	nodes = excludeNodes(nodes, tool.StringToInt64Slice(userSub.ExcludeNodes))
 
+	if subDetails.StickyNode {
+		nodes = stickyNodes(nodes, tags, userSub.UserId)
+		l.Debugf("[Generate Subscribe]sticky servers: %v", len(nodes))
+	}
+	nodes, err = l.includeNodes(userSub, nodes)
 	if err != nil {
-		l.Errorw("[Generate Subscribe]find server details error: %v", logger.Field("error", err.Error()))
-		return nil, errors.Wrapf(xerr.NewErrCode(xerr.DatabaseQueryError), "find server details error: %v", err.Error())
+		return nil, err
 	}
-	logger.Debugf("[Generate Subscribe]found servers: %v", len(nodes))
+
+	var skipped int
+	if nodes, skipped = validNodes(nodes); skipped > 0 {
+		l.Debugf("[Generate Subscribe]skipped servers with malformed data: %v", skipped)
+		if len(nodes) == 0 {
+			l.Errorw("[Generate Subscribe]every subscribe node has malformed data",
+				logger.Field("user_subscribe_id", userSub.Id), logger.Field("skipped", skipped))
+		}
+	}
+
+	nodes = filter.apply(nodes)
+
+	if l.svc.Config.Subscribe.DedupNodes {
+		nodes = dedupNodes(nodes)
+		l.Debugf("[Generate Subscribe]deduplicated servers: %v", len(nodes))
+	}
+	sortNodes(nodes, nodeSortMode(filter.sort, subDetails.NodeSort))
 	return nodes, nil
 }
 
@@ -249,37 +513,74 @@ func (l *SubscribeLogic) isSubscriptionExpired(userSub *user.Subscribe) bool {
 }
 
 func (l *SubscribeLogic) createExpiredServers() []*node.Node {
-	enable := true
-	host := l.getFirstHostLine()
+	return l.createPlaceholderServers(l.svc.Config.ExpiredNode.Name)
+}
 
-	return []*node.Node{
-		{
-			Name:    "Subscribe Expired",
-			Tags:    "",
-			Port:    18080,
-			Address: "127.0.0.1",
-			Server: &node.Server{
-				Id:        1,
-				Name:      "Subscribe Expired",
-				Protocols: "[{\"type\":\"shadowsocks\",\"cipher\":\"aes-256-gcm\",\"port\":1}]",
-			},
-			Protocol: "shadowsocks",
-			Enabled:  &enable,
-		},
-		{
-			Name:    host,
+// emptyServers answers a subscription that matches no nodes as EmptyNodes configures. An empty config
+// looks valid but silently fails in most clients, so the plan is logged for the admins to fix it.
+func (l *SubscribeLogic) emptyServers(userSub *user.Subscribe) ([]*node.Node, error) {
+	cfg := l.svc.Config.Subscribe
+	l.Errorw("[Generate Subscribe]subscribe matches no nodes",
+		logger.Field("subscribe_id", userSub.SubscribeId),
+		logger.Field("user_subscribe_id", userSub.Id),
+		logger.Field("mode", cfg.EmptyNodes),
+	)
+	switch cfg.EmptyNodes {
+	case config.EmptyNodesError:
+		return nil, errors.Wrapf(xerr.NewErrCode(xerr.SubscribeNoNodes), "subscribe %d has no nodes", userSub.SubscribeId)
+	case config.EmptyNodesEmpty:
+		return []*node.Node{}, nil
+	}
+	notice := cfg.EmptyNodesNotice
+	if notice == "" {
+		notice = "No Nodes Configured"
+	}
+	return l.createPlaceholderServers(notice), nil
+}
+
+// createMaintenanceServers returns the notice node served to every subscription in maintenance mode
+func (l *SubscribeLogic) createMaintenanceServers() []*node.Node {
+	notice := l.svc.Config.Subscribe.MaintenanceNotice
+	if notice == "" {
+		notice = "Under Maintenance"
+	}
+	return l.createPlaceholderServers(notice)
+}
+
+// createPlaceholderServers builds unusable nodes that only carry a message in their name,
+// the endpoint comes from the expired node config.
+func (l *SubscribeLogic) createPlaceholderServers(name string) []*node.Node {
+	placeholder := l.svc.Config.ExpiredNode
+	names := []string{name}
+	// the second placeholder surfaces the panel host to the user
+	if host := l.getFirstHostLine(); !placeholder.HideHost && host != "" {
+		names = append(names, host)
+	}
+	protocols, _ := json.Marshal([]node.Protocol{{
+		Type:   placeholder.Protocol,
+		Port:   uint16(placeholder.Port),
+		Enable: true,
+		Cipher: placeholder.Cipher,
+	}})
+
+	enable := true
+	servers := make([]*node.Node, 0, len(names))
+	for _, item := range names {
+		servers = append(servers, &node.Node{
+			Name:    item,
 			Tags:    "",
-			Port:    18080,
-			Address: "127.0.0.1",
+			Port:    uint16(placeholder.Port),
+			Address: placeholder.Address,
 			Server: &node.Server{
 				Id:        1,
-				Name:      "Subscribe Expired",
-				Protocols: "[{\"type\":\"shadowsocks\",\"cipher\":\"aes-256-gcm\",\"port\":1}]",
+				Name:      name,
+				Protocols: string(protocols),
 			},
-			Protocol: "shadowsocks",
+			Protocol: placeholder.Protocol,
 			Enabled:  &enable,
-		},
+		})
 	}
+	return servers
 }
 
 func (l *SubscribeLogic) getFirstHostLine() string {
@@ -0,0 +1,24 @@
+package subscribe
+
+import (
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+// validNodes drops the nodes whose server is missing or whose protocols are not valid JSON,
+// so one bad admin edit only hides its own nodes instead of failing the whole config build.
+func validNodes(nodes []*node.Node) ([]*node.Node, int) {
+	result := make([]*node.Node, 0, len(nodes))
+	for _, n := range nodes {
+		if n.Server == nil {
+			logger.Errorf("[Generate Subscribe]skip node %d: server not found", n.Id)
+			continue
+		}
+		if _, err := n.Server.UnmarshalProtocols(); err != nil {
+			logger.Errorf("[Generate Subscribe]skip node %d: malformed protocols of server %d: %s", n.Id, n.ServerId, err.Error())
+			continue
+		}
+		result = append(result, n)
+	}
+	return result, len(nodes) - len(result)
+}
@@ -0,0 +1,24 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidNodesSkipsMalformedProtocols(t *testing.T) {
+	nodes := []*node.Node{
+		{Id: 1, Server: &node.Server{Id: 1, Protocols: `[{"type":"vless","port":443}]`}},
+		{Id: 2, Server: &node.Server{Id: 2, Protocols: `[{"type":`}},
+		{Id: 3},
+		{Id: 4, Server: &node.Server{Id: 3}},
+	}
+	valid, skipped := validNodes(nodes)
+	assert.Equal(t, []int64{1, 4}, nodeIds(valid))
+	assert.Equal(t, 2, skipped)
+
+	valid, skipped = validNodes(nodes[1:3])
+	assert.Empty(t, valid)
+	assert.Equal(t, 2, skipped)
+}
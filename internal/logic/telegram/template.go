@@ -115,3 +115,37 @@ const ResetTrafficNotify = `📊 尊敬的用户，您好！
 
 新的流量额度已生效，感谢您的支持！
 如有任何问题，请随时联系客服，我们将竭诚为您服务！💬`
+
+// TrafficTopUpNotify 流量加购通知
+const TrafficTopUpNotify = `📊 尊敬的用户，您好！
+
+您加购的流量已到账：
+
+**套餐名称**：{{.SubscribeName}}
+**订单金额**：{{.OrderAmount}}
+**到期时间**：{{.ExpireTime}}
+
+新增流量已计入当前套餐，到期时间保持不变，感谢您的支持！
+如有任何问题，请随时联系客服，我们将竭诚为您服务！💬`
+
+// OrderClosedNotify 订单关闭通知
+const OrderClosedNotify = `🧾 **尊敬的用户，{{if eq .Reason "timeout"}}您的订单因超时未支付已关闭{{else}}您的订单已取消{{end}}**
+
+**订单编号**: {{.OrderNo}}
+**订阅名称**: {{.SubscribeName}}
+**订单金额**: **{{.OrderAmount}}**
+**关闭时间**: {{.CloseTime}}
+{{if .RepurchaseURL}}
+如仍需购买，请[重新下单]({{.RepurchaseURL}})。{{end}}
+如有疑问，请联系客服，我们将竭诚为您服务！💬`
+
+// AdminPaymentMismatchNotify 管理员支付金额异常通知
+const AdminPaymentMismatchNotify = `
+⚠️ **支付金额异常**
+
+🆔 **系统订单号**: {{.OrderNo}}
+💰 **应付金额**: **{{.Expected}}**
+💳 **实付金额**: **{{.Paid}}**
+📋 **处理结果**: **{{.Result}}**
+💳 **支付方式**: _{{.PaymentMethod}}_
+`
@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"crypto/subtle"
+
+	"github.com/gin-gonic/gin"
+	"github.com/perfect-panel/server/internal/svc"
+)
+
+// InventoryMiddleware authenticates external systems syncing plan inventory with the configured sync secret.
+func InventoryMiddleware(svc *svc.ServiceContext) func(c *gin.Context) {
+	return func(c *gin.Context) {
+		secret := svc.Config.Inventory.SyncSecret
+		key := c.GetHeader("X-Inventory-Secret")
+		if secret != "" && subtle.ConstantTimeCompare([]byte(key), []byte(secret)) == 1 {
+			c.Next()
+			return
+		}
+		c.String(403, "Forbidden")
+		c.Abort()
+	}
+}
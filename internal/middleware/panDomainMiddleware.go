@@ -62,6 +62,11 @@ func PanDomainMiddleware(svc *svc.ServiceContext) func(c *gin.Context) {
 				Token: domainFirst,
 				Flag:  domainArr[1],
 				UA:    c.Request.Header.Get("User-Agent"),
+				// carries the signature of signed links
+				Params: make(map[string]string),
+			}
+			for k, v := range c.Request.URL.Query() {
+				request.Params[k] = v[0]
 			}
 			l := subscribe.NewSubscribeLogic(c, svc)
 			resp, err := l.Handler(&request)
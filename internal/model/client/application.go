@@ -2,28 +2,62 @@ package client
 
 import (
 	"encoding/json"
+	"strings"
 	"time"
 )
 
 type SubscribeApplication struct {
-	Id                int64     `gorm:"primaryKey"`
-	Name              string    `gorm:"type:varchar(255);default:'';not null;comment:Application Name"`
-	Icon              string    `gorm:"type:MEDIUMTEXT;default:null;comment:Application Icon"`
-	Description       string    `gorm:"type:varchar(255);default:null;comment:Application Description"`
-	Scheme            string    `gorm:"type:varchar(255);default:'';not null;comment:Scheme"`
-	UserAgent         string    `gorm:"type:varchar(255);default:'';not null;comment:User Agent"`
-	IsDefault         bool      `gorm:"type:tinyint(1);not null;default:0;comment:Is Default Application"`
-	SubscribeTemplate string    `gorm:"type:MEDIUMTEXT;default:null;comment:Subscribe Template"`
-	OutputFormat      string    `gorm:"type:varchar(50);default:'yaml';not null;comment:Output Format"`
-	DownloadLink      string    `gorm:"type:text;not null;comment:Download Link"`
-	CreatedAt         time.Time `gorm:"<-:create;comment:Create Time"`
-	UpdatedAt         time.Time `gorm:"comment:Update Time"`
+	Id                 int64     `gorm:"primaryKey"`
+	Name               string    `gorm:"type:varchar(255);default:'';not null;comment:Application Name"`
+	Icon               string    `gorm:"type:MEDIUMTEXT;default:null;comment:Application Icon"`
+	Description        string    `gorm:"type:varchar(255);default:null;comment:Application Description"`
+	Scheme             string    `gorm:"type:varchar(255);default:'';not null;comment:Scheme"`
+	UserAgent          string    `gorm:"type:varchar(255);default:'';not null;comment:User Agent"`
+	IsDefault          bool      `gorm:"type:tinyint(1);not null;default:0;comment:Is Default Application"`
+	SubscribeTemplate  string    `gorm:"type:MEDIUMTEXT;default:null;comment:Subscribe Template"`
+	OutputFormat       string    `gorm:"type:varchar(50);default:'yaml';not null;comment:Output Format"`
+	SupportedProtocols string    `gorm:"type:varchar(255);default:'';not null;comment:Supported Protocols, empty means all"`
+	ProtocolPriority   string    `gorm:"type:varchar(255);default:'';not null;comment:Protocols Listed First, empty keeps the node order"`
+	RedirectMode       bool      `gorm:"type:tinyint(1);not null;default:0;comment:Redirect to the uploaded config"`
+	DownloadLink       string    `gorm:"type:text;not null;comment:Download Link"`
+	CreatedAt          time.Time `gorm:"<-:create;comment:Create Time"`
+	UpdatedAt          time.Time `gorm:"comment:Update Time"`
 }
 
 func (SubscribeApplication) TableName() string {
 	return "subscribe_application"
 }
 
+// GetSupportedProtocols returns the protocols the application can handle, nil means all protocols are supported.
+func (s *SubscribeApplication) GetSupportedProtocols() []string {
+	return splitProtocols(s.SupportedProtocols)
+}
+
+// SetSupportedProtocols stores the protocols the application can handle.
+func (s *SubscribeApplication) SetSupportedProtocols(protocols []string) {
+	s.SupportedProtocols = strings.Join(protocols, ",")
+}
+
+// GetProtocolPriority returns the protocols the application wants listed first for a server, highest first.
+func (s *SubscribeApplication) GetProtocolPriority() []string {
+	return splitProtocols(s.ProtocolPriority)
+}
+
+// SetProtocolPriority stores the protocols the application wants listed first.
+func (s *SubscribeApplication) SetProtocolPriority(protocols []string) {
+	s.ProtocolPriority = strings.Join(protocols, ",")
+}
+
+func splitProtocols(value string) []string {
+	var protocols []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			protocols = append(protocols, item)
+		}
+	}
+	return protocols
+}
+
 type DownloadLink struct {
 	IOS     string `json:"ios,omitempty"`
 	Android string `json:"android,omitempty"`
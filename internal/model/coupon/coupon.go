@@ -1,24 +1,107 @@
 package coupon
 
-import "time"
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/perfect-panel/server/pkg/random"
+	"github.com/perfect-panel/server/pkg/snowflake"
+	"github.com/perfect-panel/server/pkg/tool"
+)
 
 type Coupon struct {
 	Id         int64     `gorm:"primaryKey"`
 	Name       string    `gorm:"type:varchar(255);not null;default:'';comment:Coupon Name"`
 	Code       string    `gorm:"type:varchar(255);not null;default:'';unique;comment:Coupon Code"`
 	Count      int64     `gorm:"type:int;not null;default:0;comment:Count Limit"`
-	Type       uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Coupon Type: 1: Percentage 2: Fixed Amount"`
+	Type       uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Coupon Type: 1: Percentage 2: Fixed Amount 3: Bonus Days"`
 	Discount   int64     `gorm:"type:int;not null;default:0;comment:Coupon Discount"`
+	Tiers      string    `gorm:"type:text;comment:Quantity Discount Tiers"`
 	StartTime  int64     `gorm:"type:int;not null;default:0;comment:Start Time"`
 	ExpireTime int64     `gorm:"type:int;not null;default:0;comment:Expire Time"`
 	UserLimit  int64     `gorm:"type:int;not null;default:0;comment:User Limit"`
 	Subscribe  string    `gorm:"type:varchar(255);not null;default:'';comment:Subscribe Limit"`
+	Payment    string    `gorm:"type:varchar(255);not null;default:'';comment:Payment Limit"`
+	Users      string    `gorm:"type:text;comment:User Limit, empty for everyone"`
+	OrderTypes uint8     `gorm:"type:tinyint;not null;default:3;comment:Applicable Order Types: 1: Purchase 2: Renewal 3: Both"`
 	UsedCount  int64     `gorm:"type:int;not null;default:0;comment:Used Count"`
+	AutoApply  bool      `gorm:"type:tinyint(1);not null;default:0;comment:Offered as best coupon without its code"`
 	Enable     *bool     `gorm:"type:tinyint(1);not null;default:1;comment:Enable"`
 	CreatedAt  time.Time `gorm:"<-:create;comment:Create Time"`
 	UpdatedAt  time.Time `gorm:"comment:Update Time"`
 }
 
+// Applicable order type bits of Coupon.OrderTypes.
+const (
+	OrderTypePurchase uint8 = 1 << 0
+	OrderTypeRenewal  uint8 = 1 << 1
+	OrderTypeAll            = OrderTypePurchase | OrderTypeRenewal
+)
+
+// Coupon types, Discount is the percentage, the amount or the bonus days.
+const (
+	TypePercentage  uint8 = 1
+	TypeFixedAmount uint8 = 2
+	// TypeBonusDays takes nothing off the order amount, the paid subscription is extended by Discount days.
+	TypeBonusDays uint8 = 3
+)
+
 func (Coupon) TableName() string {
 	return "coupon"
 }
+
+// NewCode generates a unique coupon code
+func NewCode() string {
+	return random.KeyNew(4, 2) + "-" + random.StrToDashedString(random.EncodeBase36(snowflake.GetID()))
+}
+
+// AllowsUser reports whether the user may redeem the coupon, a coupon without users is public
+func (c *Coupon) AllowsUser(userId int64) bool {
+	if strings.TrimSpace(c.Users) == "" {
+		return true
+	}
+	return tool.Contains(tool.StringToInt64Slice(c.Users), userId)
+}
+
+// Applicable reports whether the coupon may be used for the given order type bit.
+func (c *Coupon) Applicable(orderType uint8) bool {
+	return c.OrderTypes&orderType != 0
+}
+
+// BonusDays returns the days a bonus days coupon adds to the subscription, 0 for the other types.
+func (c *Coupon) BonusDays() int64 {
+	if c == nil || c.Type != TypeBonusDays {
+		return 0
+	}
+	return c.Discount
+}
+
+// Tier is the coupon discount from an order quantity on, a percentage or an amount as the coupon type says.
+type Tier struct {
+	Quantity int64 `json:"quantity"`
+	Discount int64 `json:"discount"`
+}
+
+// ParseTiers returns the quantity tiers of the coupon, none for a flat coupon
+func (c *Coupon) ParseTiers() []Tier {
+	var tiers []Tier
+	if strings.TrimSpace(c.Tiers) == "" {
+		return tiers
+	}
+	_ = json.Unmarshal([]byte(c.Tiers), &tiers)
+	return tiers
+}
+
+// DiscountFor returns the discount of the coupon for the order quantity: that of the highest tier the quantity
+// reaches, the flat Discount below the first tier and for a coupon without tiers.
+func (c *Coupon) DiscountFor(quantity int64) int64 {
+	discount := c.Discount
+	var reached int64
+	for _, tier := range c.ParseTiers() {
+		if quantity >= tier.Quantity && tier.Quantity > reached {
+			discount, reached = tier.Discount, tier.Quantity
+		}
+	}
+	return discount
+}
@@ -2,17 +2,23 @@ package coupon
 
 import (
 	"context"
+	"errors"
 
 	"github.com/redis/go-redis/v9"
 	"gorm.io/gorm"
 )
 
 type customCouponLogicModel interface {
-	UpdateCount(ctx context.Context, code string) error
+	IncreaseUsedCount(ctx context.Context, code string, tx ...*gorm.DB) error
+	DecreaseUsedCount(ctx context.Context, code string, tx ...*gorm.DB) error
 	QueryCouponListByPage(ctx context.Context, page, size int, subscribe int64, search string) (total int64, list []*Coupon, err error)
 	BatchDelete(ctx context.Context, ids []int64) error
+	FindAutoApplyCoupons(ctx context.Context) ([]*Coupon, error)
 }
 
+// ErrCouponExhausted is returned when a coupon with a usage limit has no use left
+var ErrCouponExhausted = errors.New("coupon exhausted")
+
 // NewModel returns a model for the database table.
 func NewModel(conn *gorm.DB, c *redis.Client) Model {
 	return &customCouponModel{
@@ -45,11 +51,51 @@ func (m *customCouponModel) BatchDelete(ctx context.Context, ids []int64) error
 	return nil
 }
 
-func (m *customCouponModel) UpdateCount(ctx context.Context, code string) error {
+// FindAutoApplyCoupons returns the enabled coupons that may be offered without the user knowing the code
+func (m *customCouponModel) FindAutoApplyCoupons(ctx context.Context) ([]*Coupon, error) {
+	var list []*Coupon
+	err := m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&Coupon{}).Where("auto_apply = ? AND enable = ?", true, true).Find(v).Error
+	})
+	return list, err
+}
+
+// IncreaseUsedCount atomically takes one use of the coupon and returns ErrCouponExhausted when a coupon
+// with a usage limit has none left.
+func (m *customCouponModel) IncreaseUsedCount(ctx context.Context, code string, tx ...*gorm.DB) error {
+	data, err := m.FindOneByCode(ctx, code)
+	if err != nil {
+		return err
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Coupon{}).
+			Where("`id` = ? AND (`count` = 0 OR `used_count` < `count`)", data.Id).
+			UpdateColumn("used_count", gorm.Expr("`used_count` + 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCouponExhausted
+		}
+		return nil
+	}, m.getCacheKeys(data)...)
+}
+
+// DecreaseUsedCount atomically gives back a use taken by an order that was not paid.
+func (m *customCouponModel) DecreaseUsedCount(ctx context.Context, code string, tx ...*gorm.DB) error {
 	data, err := m.FindOneByCode(ctx, code)
 	if err != nil {
 		return err
 	}
-	data.UsedCount++
-	return m.Update(ctx, data)
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Model(&Coupon{}).
+			Where("`id` = ? AND `used_count` > 0", data.Id).
+			UpdateColumn("used_count", gorm.Expr("`used_count` - 1")).Error
+	}, m.getCacheKeys(data)...)
 }
@@ -0,0 +1,135 @@
+package coupon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// The usage tests are opt-in integration tests, the conditional update needs a real MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/model/coupon/
+func newUsageTestModel(t *testing.T, count, used int64) (Model, *Coupon) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Coupon{}); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	m := NewModel(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	ctx := context.Background()
+	data := &Coupon{
+		Name:       t.Name(),
+		Code:       NewCode(),
+		Count:      count,
+		UsedCount:  used,
+		ExpireTime: time.Now().Add(time.Hour).Unix(),
+	}
+	if err = m.Insert(ctx, data); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = m.Delete(ctx, data.Id)
+	})
+	return m, data
+}
+
+// TestIncreaseUsedCountLastUse lets two users redeem the last use of a limited coupon at the same time,
+// only one of the orders may be created.
+func TestIncreaseUsedCountLastUse(t *testing.T) {
+	m, data := newUsageTestModel(t, 3, 2)
+	ctx := context.Background()
+
+	var (
+		wg    sync.WaitGroup
+		start = make(chan struct{})
+		errs  = make([]error, 2)
+	)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = m.Transaction(ctx, func(tx *gorm.DB) error {
+				return m.IncreaseUsedCount(ctx, data.Code, tx)
+			})
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+
+	var success, exhausted int
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			success++
+		case errors.Is(err, ErrCouponExhausted):
+			exhausted++
+		default:
+			t.Error(err)
+		}
+	}
+	assert.Equal(t, 1, success)
+	assert.Equal(t, 1, exhausted)
+
+	got, err := m.FindOneByCode(ctx, data.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(3), got.UsedCount)
+}
+
+func TestUsedCountRollback(t *testing.T) {
+	m, data := newUsageTestModel(t, 1, 0)
+	ctx := context.Background()
+
+	// a failed order creation rolls the use back with its transaction
+	errOrderFailed := errors.New("order failed")
+	err := m.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := m.IncreaseUsedCount(ctx, data.Code, tx); err != nil {
+			return err
+		}
+		return errOrderFailed
+	})
+	assert.ErrorIs(t, err, errOrderFailed)
+
+	assert.NoError(t, m.IncreaseUsedCount(ctx, data.Code))
+	assert.ErrorIs(t, m.IncreaseUsedCount(ctx, data.Code), ErrCouponExhausted)
+	// closing the order gives the use back, it never goes below zero
+	assert.NoError(t, m.DecreaseUsedCount(ctx, data.Code))
+	assert.NoError(t, m.DecreaseUsedCount(ctx, data.Code))
+
+	got, err := m.FindOneByCode(ctx, data.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), got.UsedCount)
+}
+
+func TestIncreaseUsedCountUnlimited(t *testing.T) {
+	m, data := newUsageTestModel(t, 0, 5)
+	ctx := context.Background()
+
+	assert.NoError(t, m.IncreaseUsedCount(ctx, data.Code))
+
+	got, err := m.FindOneByCode(ctx, data.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(6), got.UsedCount)
+}
@@ -0,0 +1,61 @@
+package log
+
+import (
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AdminAudit represents an audit entry of an admin action, the ObjectID of its
+// system log is the acting admin so the entries can be filtered by actor.
+type AdminAudit struct {
+	Action          uint16 `json:"action"`
+	OrderNo         string `json:"order_no,omitempty"`
+	UserId          int64  `json:"user_id,omitempty"`
+	TargetUserId    int64  `json:"target_user_id,omitempty"`
+	UserSubscribeId int64  `json:"user_subscribe_id,omitempty"`
+	AmountBefore    int64  `json:"amount_before"`
+	AmountAfter     int64  `json:"amount_after"`
+	StatusBefore    uint8  `json:"status_before,omitempty"`
+	StatusAfter     uint8  `json:"status_after,omitempty"`
+	Coupon          string `json:"coupon,omitempty"`
+	Remark          string `json:"remark,omitempty"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for AdminAudit.
+func (a *AdminAudit) Marshal() ([]byte, error) {
+	type Alias AdminAudit
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(a),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for AdminAudit.
+func (a *AdminAudit) Unmarshal(data []byte) error {
+	type Alias AdminAudit
+	aux := (*Alias)(a)
+	return json.Unmarshal(data, aux)
+}
+
+// CreateAdminAudit writes the audit entry of an admin action. Pass the transaction of the
+// action itself, the entry is then only kept when the action commits.
+func CreateAdminAudit(tx *gorm.DB, actorId int64, audit *AdminAudit) error {
+	now := time.Now()
+	if audit.Timestamp == 0 {
+		audit.Timestamp = now.UnixMilli()
+	}
+	content, err := audit.Marshal()
+	if err != nil {
+		return err
+	}
+	return tx.Model(&SystemLog{}).Create(&SystemLog{
+		Type:     TypeAdminAudit.Uint8(),
+		Date:     Date(now),
+		ObjectID: actorId,
+		Content:  string(content),
+	}).Error
+}
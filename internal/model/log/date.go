@@ -0,0 +1,31 @@
+package log
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+var dateLocation atomic.Pointer[time.Location]
+
+// SetTimezone sets the timezone used to key system logs by date, an empty name means UTC.
+func SetTimezone(name string) error {
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return err
+	}
+	dateLocation.Store(loc)
+	return nil
+}
+
+// Date returns the value of the SystemLog Date column for an event at t.
+func Date(t time.Time) string {
+	return t.In(Location()).Format(time.DateOnly)
+}
+
+// Location returns the configured timezone, the site's local time for anything keyed by day or hour.
+func Location() *time.Location {
+	if loc := dateLocation.Load(); loc != nil {
+		return loc
+	}
+	return time.UTC
+}
@@ -0,0 +1,24 @@
+package log
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDate(t *testing.T) {
+	t.Cleanup(func() { _ = SetTimezone("UTC") })
+	ts := time.Date(2025, 4, 22, 20, 30, 0, 0, time.UTC)
+
+	assert.Equal(t, "2025-04-22", Date(ts))
+
+	assert.NoError(t, SetTimezone("Asia/Shanghai"))
+	assert.Equal(t, "2025-04-23", Date(ts))
+
+	assert.NoError(t, SetTimezone(""))
+	assert.Equal(t, "2025-04-22", Date(ts))
+
+	assert.Error(t, SetTimezone("Mars/Olympus"))
+	assert.Equal(t, "2025-04-22", Date(ts))
+}
@@ -0,0 +1,35 @@
+package log
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CreateOrderGift writes the gift log of an order at most once per log type, bucket and top-up. The log is
+// keyed by orderGiftKey, a retried write hits the unique key of the first one and is ignored.
+func CreateOrderGift(tx *gorm.DB, userId int64, gift *Gift) error {
+	now := time.Now()
+	if gift.Timestamp == 0 {
+		gift.Timestamp = now.UnixMilli()
+	}
+	content, err := gift.Marshal()
+	if err != nil {
+		return err
+	}
+	key := orderGiftKey(gift)
+	return tx.Model(&SystemLog{}).Clauses(clause.Insert{Modifier: "IGNORE"}).Create(&SystemLog{
+		Type:     TypeGift.Uint8(),
+		Date:     Date(now),
+		ObjectID: userId,
+		Content:  string(content),
+		LogKey:   &key,
+	}).Error
+}
+
+// orderGiftKey identifies the gift log of an order by its log type, bucket and top-up.
+func orderGiftKey(gift *Gift) string {
+	return fmt.Sprintf("%s:%d:%s:%d", gift.OrderNo, gift.Type, gift.Bucket, gift.TopUp)
+}
@@ -0,0 +1,83 @@
+package log
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// TestCreateOrderGiftRetried is an opt-in integration test, it needs a MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/model/log/
+func TestCreateOrderGiftRetried(t *testing.T) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&SystemLog{}); err != nil {
+		t.Fatal(err)
+	}
+	const userId = int64(-886)
+	t.Cleanup(func() {
+		db.Where("`object_id` = ?", userId).Delete(&SystemLog{})
+	})
+
+	orderNo := "GIFT-RETRY-1"
+	deduction := func() []Gift {
+		return []Gift{
+			{Type: GiftTypeReduce, OrderNo: orderNo, Amount: 300, Balance: 0, Bucket: GiftBucketPromo},
+			{Type: GiftTypeReduce, OrderNo: orderNo, Amount: 200, Balance: 800},
+			// a checkout top-up of the same order is a log of its own
+			{Type: GiftTypeReduce, OrderNo: orderNo, Amount: 100, Balance: 700, TopUp: 600},
+		}
+	}
+	// the retry runs the whole write again after the first attempt committed its logs
+	for attempt := 0; attempt < 2; attempt++ {
+		err = db.Transaction(func(tx *gorm.DB) error {
+			for _, gift := range deduction() {
+				if err := CreateOrderGift(tx, userId, &gift); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+	}
+	// another order of the same user still gets its own log
+	assert.NoError(t, CreateOrderGift(db, userId, &Gift{Type: GiftTypeReduce, OrderNo: "GIFT-RETRY-2", Amount: 100}))
+
+	var logs []SystemLog
+	assert.NoError(t, db.Where("`type` = ? AND `object_id` = ?", TypeGift.Uint8(), userId).Order("id ASC").Find(&logs).Error)
+	if assert.Len(t, logs, 4) {
+		var gifts []Gift
+		for _, item := range logs {
+			var gift Gift
+			assert.NoError(t, gift.Unmarshal([]byte(item.Content)))
+			gifts = append(gifts, gift)
+		}
+		assert.Equal(t, GiftBucketPromo, gifts[0].Bucket)
+		assert.Equal(t, int64(200), gifts[1].Amount)
+		assert.Equal(t, int64(600), gifts[2].TopUp)
+		assert.Equal(t, "GIFT-RETRY-2", gifts[3].OrderNo)
+	}
+}
+
+func TestOrderGiftKey(t *testing.T) {
+	reduce := &Gift{Type: GiftTypeReduce, OrderNo: "202601010001", Amount: 100}
+	promo := &Gift{Type: GiftTypeReduce, OrderNo: "202601010001", Amount: 100, Bucket: GiftBucketPromo}
+	topUp := &Gift{Type: GiftTypeReduce, OrderNo: "202601010001", Amount: 100, TopUp: 300}
+	increase := &Gift{Type: GiftTypeIncrease, OrderNo: "202601010001", Amount: 100}
+	keys := map[string]bool{}
+	for _, gift := range []*Gift{reduce, promo, topUp, increase} {
+		keys[orderGiftKey(gift)] = true
+	}
+	assert.Len(t, keys, 4)
+	// the amount and balance are not part of the key, a retry with other values is the same log
+	assert.Equal(t, orderGiftKey(reduce), orderGiftKey(&Gift{Type: GiftTypeReduce, OrderNo: "202601010001", Amount: 200, Balance: 50}))
+}
@@ -23,11 +23,17 @@ const (
 	TypeSubscribeTraffic  Type = 21 // Subscription traffic log
 	TypeServerTraffic     Type = 22 // Server traffic log
 	TypeResetSubscribe    Type = 23 // Reset subscription log
+	TypeRenewalRefund     Type = 24 // Renewal refund log
+	TypeSubscribeAnomaly  Type = 25 // Subscription multi-country anomaly log
+	TypeSubscribePause    Type = 26 // Subscription pause and resume log
+	TypeSubscribeExtend   Type = 27 // Subscription expiry extension log
 	TypeLogin             Type = 30 // Login log
 	TypeRegister          Type = 31 // Registration log
 	TypeBalance           Type = 32 // Balance log
 	TypeCommission        Type = 33 // Commission log
 	TypeGift              Type = 34 // Gift log
+	TypeLoyaltyCredit     Type = 35 // Loyalty credit log
+	TypeAdminAudit        Type = 36 // Admin audit log
 	TypeUserTrafficRank   Type = 40 // Top 10 User traffic rank log
 	TypeServerTrafficRank Type = 41 // Top 10 Server traffic rank log
 	TypeTrafficStat       Type = 42 // Daily traffic statistics log
@@ -37,6 +43,9 @@ const (
 	ResetSubscribeTypeAdvance    uint16 = 232 // Advance reset
 	ResetSubscribeTypePaid       uint16 = 233 // Paid reset
 	ResetSubscribeTypeQuota      uint16 = 234 // Quota reset
+	SubscribePauseTypePause      uint16 = 261 // Paused by the user
+	SubscribePauseTypeResume     uint16 = 262 // Resumed by the user
+	SubscribePauseTypeAutoResume uint16 = 263 // Resumed after the longest pause
 	BalanceTypeRecharge          uint16 = 321 // Recharge
 	BalanceTypeWithdraw          uint16 = 322 // Withdraw
 	BalanceTypePayment           uint16 = 323 // Payment
@@ -51,6 +60,24 @@ const (
 	CommissionTypeConvertBalance uint16 = 336 // Convert to Balance
 	GiftTypeIncrease             uint16 = 341 // Increase
 	GiftTypeReduce               uint16 = 342 // Reduce
+	GiftTypeExpire               uint16 = 343 // Expired promo credit
+	LoyaltyCreditTypeIncrease    uint16 = 351 // Increase
+	LoyaltyCreditTypeReduce      uint16 = 352 // Reduce
+	AdminAuditOrderCreate        uint16 = 361 // Admin created an order
+	AdminAuditOrderStatus        uint16 = 362 // Admin changed an order status
+	AdminAuditOrderRefund        uint16 = 363 // Admin refunded an order
+	AdminAuditSubscribeTransfer  uint16 = 364 // Admin transferred a user subscription to another user
+	AdminAuditOrderForceClose    uint16 = 365 // Admin force-closed the pending orders of a user
+	AdminAuditInvoicePaid        uint16 = 366 // Admin marked the invoice of an enterprise order paid
+	AdminAuditSubscribeExtend    uint16 = 367 // Admin extended a user subscription as compensation
+	AdminAuditSubscribeBonus     uint16 = 368 // Admin granted bonus nodes to a user subscription
+	AdminAuditSubscribeNodes     uint16 = 369 // Admin changed the node overrides of a user subscription
+	AdminAuditSubscribeStatus    uint16 = 370 // Admin stopped or resumed a user subscription
+	AdminAuditSandboxCreate      uint16 = 371 // Admin created a sandbox order
+	AdminAuditSandboxPay         uint16 = 372 // Admin paid a sandbox order
+	AdminAuditSandboxCancel      uint16 = 373 // Admin cancelled a sandbox order
+	AdminAuditEnterprise         uint16 = 374 // Admin changed the enterprise account of a user
+	AdminAuditMaintenance        uint16 = 375 // Admin switched the subscribe maintenance mode
 )
 
 // Uint8 converts Type to uint8.
@@ -61,10 +88,11 @@ func (t Type) Uint8() uint8 {
 // SystemLog represents a log entry in the system.
 type SystemLog struct {
 	Id        int64     `gorm:"primaryKey;AUTO_INCREMENT"`
-	Type      uint8     `gorm:"index:idx_type;type:tinyint(1);not null;default:0;comment:Log Type: 1: Email Message 2: Mobile Message 3: Subscribe 4: Subscribe Traffic 5: Server Traffic 6: Login 7: Register 8: Balance 9: Commission 10: Reset Subscribe 11: Gift"`
+	Type      uint8     `gorm:"index:idx_type;uniqueIndex:idx_type_object_key,priority:1;type:tinyint(1);not null;default:0;comment:Log Type: 1: Email Message 2: Mobile Message 3: Subscribe 4: Subscribe Traffic 5: Server Traffic 6: Login 7: Register 8: Balance 9: Commission 10: Reset Subscribe 11: Gift"`
 	Date      string    `gorm:"type:varchar(20);default:null;comment:Log Date"`
-	ObjectID  int64     `gorm:"index:idx_object_id;type:bigint(20);not null;default:0;comment:Object ID"`
+	ObjectID  int64     `gorm:"index:idx_object_id;uniqueIndex:idx_type_object_key,priority:2;type:bigint(20);not null;default:0;comment:Object ID"`
 	Content   string    `gorm:"type:text;not null;comment:Log Content"`
+	LogKey    *string   `gorm:"uniqueIndex:idx_type_object_key,priority:3;type:varchar(255);default:null;comment:Unique Key of Logs Written Once"`
 	CreatedAt time.Time `gorm:"<-:create;comment:Create Time"`
 }
 
@@ -182,6 +210,11 @@ type Subscribe struct {
 	UserAgent       string `json:"user_agent"`
 	ClientIP        string `json:"client_ip"`
 	UserSubscribeId int64  `json:"user_subscribe_id"`
+	Country         string `json:"country,omitempty"`
+	CountryCode     string `json:"country_code,omitempty"`
+	ASN             uint   `json:"asn,omitempty"`
+	ASOrganization  string `json:"as_organization,omitempty"`
+	Datacenter      bool   `json:"datacenter,omitempty"` // fetched from a datacenter network
 }
 
 // Marshal implements the json.Marshaler interface for Subscribe.
@@ -201,6 +234,88 @@ func (s *Subscribe) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, aux)
 }
 
+// SubscribeAnomaly represents a subscription token fetched from too many countries within a window.
+type SubscribeAnomaly struct {
+	Token           string   `json:"token"`
+	UserSubscribeId int64    `json:"user_subscribe_id"`
+	Countries       []string `json:"countries"`
+	Window          int64    `json:"window"`
+	Timestamp       int64    `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for SubscribeAnomaly.
+func (s *SubscribeAnomaly) Marshal() ([]byte, error) {
+	type Alias SubscribeAnomaly
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for SubscribeAnomaly.
+func (s *SubscribeAnomaly) Unmarshal(data []byte) error {
+	type Alias SubscribeAnomaly
+	aux := (*Alias)(s)
+	return json.Unmarshal(data, aux)
+}
+
+// SubscribePause represents a pause or resume of a user subscription, Remaining is the time in seconds
+// the subscription had left when it was paused.
+type SubscribePause struct {
+	Type            uint16 `json:"type"`
+	UserSubscribeId int64  `json:"user_subscribe_id"`
+	Remaining       int64  `json:"remaining"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for SubscribePause.
+func (s *SubscribePause) Marshal() ([]byte, error) {
+	type Alias SubscribePause
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for SubscribePause.
+func (s *SubscribePause) Unmarshal(data []byte) error {
+	type Alias SubscribePause
+	aux := (*Alias)(s)
+	return json.Unmarshal(data, aux)
+}
+
+// SubscribeExtend represents an extension of a user subscription granted by an admin, e.g. as compensation
+// for an outage. Duration is in seconds, the expire times are in milliseconds. A paused subscription keeps
+// its expire time and gets the duration added to the time it has left.
+type SubscribeExtend struct {
+	UserSubscribeId int64  `json:"user_subscribe_id"`
+	Duration        int64  `json:"duration"`
+	ExpireBefore    int64  `json:"expire_before"`
+	ExpireAfter     int64  `json:"expire_after"`
+	Reason          string `json:"reason,omitempty"`
+	Operator        int64  `json:"operator"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for SubscribeExtend.
+func (s *SubscribeExtend) Marshal() ([]byte, error) {
+	type Alias SubscribeExtend
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(s),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for SubscribeExtend.
+func (s *SubscribeExtend) Unmarshal(data []byte) error {
+	type Alias SubscribeExtend
+	aux := (*Alias)(s)
+	return json.Unmarshal(data, aux)
+}
+
 // ResetSubscribe represents a reset subscription log entry.
 type ResetSubscribe struct {
 	Type      uint16 `json:"type"`
@@ -226,6 +341,35 @@ func (r *ResetSubscribe) Unmarshal(data []byte) error {
 	return json.Unmarshal(data, aux)
 }
 
+// RenewalRefund represents a renewal refund log entry.
+type RenewalRefund struct {
+	OrderNo         string `json:"order_no"`
+	UserSubscribeId int64  `json:"user_subscribe_id"`
+	ExpireBefore    int64  `json:"expire_before"`
+	ExpireAfter     int64  `json:"expire_after"`
+	Amount          int64  `json:"amount"`
+	GiftAmount      int64  `json:"gift_amount"`
+	LoyaltyCredit   int64  `json:"loyalty_credit,omitempty"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for RenewalRefund.
+func (r *RenewalRefund) Marshal() ([]byte, error) {
+	type Alias RenewalRefund
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for RenewalRefund.
+func (r *RenewalRefund) Unmarshal(data []byte) error {
+	type Alias RenewalRefund
+	aux := (*Alias)(r)
+	return json.Unmarshal(data, aux)
+}
+
 // Balance represents a balance log entry.
 type Balance struct {
 	Type      uint16 `json:"type"`
@@ -284,10 +428,19 @@ type Gift struct {
 	SubscribeId int64  `json:"subscribe_id"`
 	Amount      int64  `json:"amount"`
 	Balance     int64  `json:"balance"`
+	RefereeId   int64  `json:"referee_id,omitempty"` // set on referral rewards
+	Bucket      string `json:"bucket,omitempty"`     // GiftBucketPromo for promo credit, empty for the gift amount
+	TopUp       int64  `json:"top_up,omitempty"`     // order gift amount after a checkout top-up, empty for the other logs
 	Remark      string `json:"remark,omitempty"`
 	Timestamp   int64  `json:"timestamp"`
 }
 
+// GiftBucketPromo marks the gift logs of the expiring promo credit, the logs of each bucket sum up to its balance.
+const GiftBucketPromo = "promo"
+
+// ReferralGiftSearch matches the content of gift logs written for referral rewards.
+const ReferralGiftSearch = `"referee_id":`
+
 // Marshal implements the json.Marshaler interface for Gift.
 func (g *Gift) Marshal() ([]byte, error) {
 	type Alias Gift
@@ -423,3 +576,30 @@ func (t *TrafficStat) Unmarshal(data []byte) error {
 	aux := (*Alias)(t)
 	return json.Unmarshal(data, aux)
 }
+
+// LoyaltyCredit represents a loyalty credit log entry.
+type LoyaltyCredit struct {
+	Type      uint16 `json:"type"`
+	OrderNo   string `json:"order_no"`
+	Amount    int64  `json:"amount"`
+	Balance   int64  `json:"balance"`
+	Remark    string `json:"remark,omitempty"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+// Marshal implements the json.Marshaler interface for LoyaltyCredit.
+func (c *LoyaltyCredit) Marshal() ([]byte, error) {
+	type Alias LoyaltyCredit
+	return json.Marshal(&struct {
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	})
+}
+
+// Unmarshal implements the json.Unmarshaler interface for LoyaltyCredit.
+func (c *LoyaltyCredit) Unmarshal(data []byte) error {
+	type Alias LoyaltyCredit
+	aux := (*Alias)(c)
+	return json.Unmarshal(data, aux)
+}
@@ -0,0 +1,28 @@
+package log
+
+import (
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// OrderLoyaltyCredit sums the loyalty credit the logs of the type recorded for the order,
+// e.g. the credit an order rewarded its user with.
+func OrderLoyaltyCredit(tx *gorm.DB, userId int64, orderNo string, creditType uint16) (int64, error) {
+	var contents []string
+	err := tx.Model(&SystemLog{}).
+		Where("`type` = ? AND `object_id` = ? AND `content` LIKE ?", TypeLoyaltyCredit.Uint8(), userId, fmt.Sprintf(`{"type":%d,"order_no":%q,%%`, creditType, orderNo)).
+		Pluck("content", &contents).Error
+	if err != nil {
+		return 0, err
+	}
+	var total int64
+	for _, content := range contents {
+		var credit LoyaltyCredit
+		if err = credit.Unmarshal([]byte(content)); err != nil {
+			return 0, err
+		}
+		total += credit.Amount
+	}
+	return total, nil
+}
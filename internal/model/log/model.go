@@ -23,6 +23,15 @@ type FilterParams struct {
 
 type customSystemLogLogicModel interface {
 	FilterSystemLog(ctx context.Context, filter *FilterParams) ([]*SystemLog, int64, error)
+	InsertBatch(ctx context.Context, data []*SystemLog) error
+}
+
+// InsertBatch inserts the logs in batches of at most 100 rows.
+func (m *customSystemLogModel) InsertBatch(ctx context.Context, data []*SystemLog) error {
+	if len(data) == 0 {
+		return nil
+	}
+	return m.WithContext(ctx).CreateInBatches(data, 100).Error
 }
 
 func (m *customSystemLogModel) FilterSystemLog(ctx context.Context, filter *FilterParams) ([]*SystemLog, int64, error) {
@@ -13,6 +13,8 @@ type customServerLogicModel interface {
 	FilterServerList(ctx context.Context, params *FilterParams) (int64, []*Server, error)
 	FilterNodeList(ctx context.Context, params *FilterNodeParams) (int64, []*Node, error)
 	ClearNodeCache(ctx context.Context, params *FilterNodeParams) error
+	FindTrafficRate(ctx context.Context, serverId int64, protocol string) (float64, error)
+	FindPlanNodes(ctx context.Context, nodeIds []int64, tags []string) ([]*Node, error)
 }
 
 const (
@@ -111,6 +113,48 @@ func (m *customServerModel) FilterNodeList(ctx context.Context, params *FilterNo
 	return total, nodes, err
 }
 
+// FindTrafficRate returns the traffic rate of the nodes of a server protocol. The server reports their traffic
+// together, so when the nodes disagree the lowest rate applies. A protocol without nodes counts as 1.
+func (m *customServerModel) FindTrafficRate(ctx context.Context, serverId int64, protocol string) (float64, error) {
+	var rate *float64
+	err := m.WithContext(ctx).Model(&Node{}).
+		Where("server_id = ? AND protocol = ? AND traffic_rate > 0", serverId, protocol).
+		Select("MIN(traffic_rate)").Scan(&rate).Error
+	if err != nil {
+		return 1, err
+	}
+	if rate == nil {
+		return 1, nil
+	}
+	return *rate, nil
+}
+
+// FindPlanNodes returns the nodes a subscribe plan serves, the nodes it names and the nodes carrying one of its tags.
+func (m *customServerModel) FindPlanNodes(ctx context.Context, nodeIds []int64, tags []string) ([]*Node, error) {
+	var nodes []*Node
+	if len(nodeIds) > 0 {
+		if err := m.WithContext(ctx).Model(&Node{}).Where("id IN ?", nodeIds).Find(&nodes).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(tags) > 0 {
+		var tagged []*Node
+		if err := m.WithContext(ctx).Model(&Node{}).Scopes(InSet("tags", tags)).Find(&tagged).Error; err != nil {
+			return nil, err
+		}
+		seen := make(map[int64]bool, len(nodes))
+		for _, n := range nodes {
+			seen[n.Id] = true
+		}
+		for _, n := range tagged {
+			if !seen[n.Id] {
+				nodes = append(nodes, n)
+			}
+		}
+	}
+	return nodes, nil
+}
+
 // ClearNodeCache Clear Node Cache
 func (m *customServerModel) ClearNodeCache(ctx context.Context, params *FilterNodeParams) error {
 	_, nodes, err := m.FilterNodeList(ctx, params)
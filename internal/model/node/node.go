@@ -8,24 +8,34 @@ import (
 )
 
 type Node struct {
-	Id        int64     `gorm:"primary_key"`
-	Name      string    `gorm:"type:varchar(100);not null;default:'';comment:Node Name"`
-	Tags      string    `gorm:"type:varchar(255);not null;default:'';comment:Tags"`
-	Port      uint16    `gorm:"not null;default:0;comment:Connect Port"`
-	Address   string    `gorm:"type:varchar(255);not null;default:'';comment:Connect Address"`
-	ServerId  int64     `gorm:"not null;default:0;comment:Server ID"`
-	Server    *Server   `gorm:"foreignKey:ServerId;references:Id"`
-	Protocol  string    `gorm:"type:varchar(100);not null;default:'';comment:Protocol"`
-	Enabled   *bool     `gorm:"type:boolean;not null;default:true;comment:Enabled"`
-	Sort      int       `gorm:"uniqueIndex;not null;default:0;comment:Sort"`
-	CreatedAt time.Time `gorm:"<-:create;comment:Creation Time"`
-	UpdatedAt time.Time `gorm:"comment:Update Time"`
+	Id          int64     `gorm:"primary_key"`
+	Name        string    `gorm:"type:varchar(100);not null;default:'';comment:Node Name"`
+	Tags        string    `gorm:"type:varchar(255);not null;default:'';comment:Tags"`
+	Port        uint16    `gorm:"not null;default:0;comment:Connect Port"`
+	Address     string    `gorm:"type:varchar(255);not null;default:'';comment:Connect Address"`
+	ServerId    int64     `gorm:"not null;default:0;comment:Server ID"`
+	Server      *Server   `gorm:"foreignKey:ServerId;references:Id"`
+	Protocol    string    `gorm:"type:varchar(100);not null;default:'';comment:Protocol"`
+	TrafficRate float64   `gorm:"type:decimal(6,2);not null;default:1;comment:Traffic Rate"`    // traffic through the node counts this many times toward the quota
+	Weight      int64     `gorm:"type:int;not null;default:0;comment:Subscription Sort Weight"` // plans sorting by weight serve heavier nodes first
+	Enabled     *bool     `gorm:"type:boolean;not null;default:true;comment:Enabled"`
+	Sort        int       `gorm:"uniqueIndex;not null;default:0;comment:Sort"`
+	CreatedAt   time.Time `gorm:"<-:create;comment:Creation Time"`
+	UpdatedAt   time.Time `gorm:"comment:Update Time"`
 }
 
 func (n *Node) TableName() string {
 	return "nodes"
 }
 
+// Rate returns the traffic rate of the node, rows written before the rate existed count as 1.
+func (n *Node) Rate() float64 {
+	if n.TrafficRate <= 0 {
+		return 1
+	}
+	return n.TrafficRate
+}
+
 func (n *Node) BeforeCreate(tx *gorm.DB) error {
 	if n.Sort == 0 {
 		var maxSort int
@@ -0,0 +1,126 @@
+package order
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PaymentAttempt is one checkout of an order through a payment method. An order can be
+// checked out through several methods, the first confirmed attempt pays it.
+type PaymentAttempt struct {
+	Id                 int64     `gorm:"primaryKey"`
+	OrderNo            string    `gorm:"index:idx_order_no;type:varchar(255);not null;default:'';comment:Order No"`
+	PaymentId          int64     `gorm:"type:bigint;not null;default:0;comment:Payment Method Id"`
+	Method             string    `gorm:"type:varchar(255);not null;default:'';comment:Payment Method"`
+	Amount             int64     `gorm:"type:int;not null;default:0;comment:Attempt Amount"`
+	FeeAmount          int64     `gorm:"type:int;not null;default:0;comment:Fee Amount"`
+	RoundingAdjustment int64     `gorm:"type:int;not null;default:0;comment:Rounding Adjustment"`
+	Status             uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Attempt Status: 1: Pending, 2: Paid, 3: Cancelled, 4: Refunded, 5: Duplicate"`
+	CreatedAt          time.Time `gorm:"<-:create;comment:Create Time"`
+	UpdatedAt          time.Time `gorm:"comment:Update Time"`
+}
+
+// Payment attempt status
+const (
+	AttemptStatusPending   uint8 = 1
+	AttemptStatusPaid      uint8 = 2
+	AttemptStatusCancelled uint8 = 3
+	AttemptStatusRefunded  uint8 = 4
+	// AttemptStatusDuplicate a confirmed attempt of an order another attempt already paid,
+	// it could not be refunded automatically and has to be refunded by hand.
+	AttemptStatusDuplicate uint8 = 5
+)
+
+func (PaymentAttempt) TableName() string {
+	return "order_payment_attempt"
+}
+
+// InsertPaymentAttempt records a checkout of an order
+func (m *customOrderModel) InsertPaymentAttempt(ctx context.Context, data *PaymentAttempt, tx ...*gorm.DB) error {
+	return m.ExecNoCacheCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Create(data).Error
+	})
+}
+
+// FindPaymentAttempt returns the latest attempt of the order through the payment method
+func (m *customOrderModel) FindPaymentAttempt(ctx context.Context, orderNo string, paymentId int64) (*PaymentAttempt, error) {
+	var data PaymentAttempt
+	err := m.QueryNoCacheCtx(ctx, &data, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&PaymentAttempt{}).Where("order_no = ? AND payment_id = ?", orderNo, paymentId).Order("id DESC").First(v).Error
+	})
+	return &data, err
+}
+
+// FindPaymentAttempts returns every attempt of the order, oldest first
+func (m *customOrderModel) FindPaymentAttempts(ctx context.Context, orderNo string) ([]*PaymentAttempt, error) {
+	var list []*PaymentAttempt
+	err := m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&PaymentAttempt{}).Where("order_no = ?", orderNo).Order("id ASC").Find(v).Error
+	})
+	return list, err
+}
+
+// UpdatePaymentAttemptStatusFrom updates the attempt status only when it is still in the given status,
+// ErrOrderStatusChanged is returned when another request changed it first.
+func (m *customOrderModel) UpdatePaymentAttemptStatusFrom(ctx context.Context, id int64, from, to uint8, tx ...*gorm.DB) error {
+	return m.ExecNoCacheCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&PaymentAttempt{}).Where("id = ? AND status = ?", id, from).Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		return nil
+	})
+}
+
+// PayOrderWithAttempt marks the order paid through the attempt when it is still in the given status, the order
+// takes over the payment method and amounts of the attempt. ErrOrderStatusChanged is returned when another
+// request changed the order first.
+func (m *customOrderModel) PayOrderWithAttempt(ctx context.Context, orderNo string, from uint8, attempt *PaymentAttempt, tx ...*gorm.DB) error {
+	orderInfo, err := m.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Order{}).Where("order_no = ? AND status = ?", orderNo, from).Updates(map[string]interface{}{
+			"status":              StatusPaid,
+			"payment_id":          attempt.PaymentId,
+			"method":              attempt.Method,
+			"amount":              attempt.Amount,
+			"fee_amount":          attempt.FeeAmount,
+			"rounding_adjustment": attempt.RoundingAdjustment,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		return conn.Model(&PaymentAttempt{}).Where("id = ?", attempt.Id).Update("status", AttemptStatusPaid).Error
+	}, m.getCacheKeys(orderInfo)...)
+}
+
+// CancelPaymentAttempts cancels the pending attempts of the order other than the given one
+func (m *customOrderModel) CancelPaymentAttempts(ctx context.Context, orderNo string, exceptId int64, tx ...*gorm.DB) error {
+	return m.ExecNoCacheCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Model(&PaymentAttempt{}).
+			Where("order_no = ? AND status = ? AND id != ?", orderNo, AttemptStatusPending, exceptId).
+			Update("status", AttemptStatusCancelled).Error
+	})
+}
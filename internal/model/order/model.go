@@ -2,6 +2,7 @@ package order
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/perfect-panel/server/internal/model/payment"
@@ -12,32 +13,40 @@ import (
 )
 
 type Details struct {
-	Id             int64                `gorm:"primaryKey"`
-	ParentId       int64                `gorm:"type:bigint;default:null;comment:Parent Order Id"`
-	SubOrders      []*Order             `gorm:"foreignKey:ParentId;references:Id"`
-	UserId         int64                `gorm:"type:bigint;not null;default:0;comment:User Id"`
-	OrderNo        string               `gorm:"type:varchar(255);not null;default:'';unique;comment:Order No"`
-	Type           uint8                `gorm:"type:tinyint(1);not null;default:1;comment:Order Type: 1: Subscribe, 2: Renewal, 3: ResetTraffic, 4: Recharge"`
-	Quantity       int64                `gorm:"type:bigint;not null;default:1;comment:Quantity"`
-	Price          int64                `gorm:"type:int;not null;default:0;comment:Original price"`
-	Amount         int64                `gorm:"type:int;not null;default:0;comment:Order Amount"`
-	Discount       int64                `gorm:"type:int;not null;default:0;comment:Order Discount"`
-	Coupon         string               `gorm:"type:varchar(255);default:null;comment:Coupon"`
-	CouponDiscount int64                `gorm:"type:int;not null;default:0;comment:Coupon Discount"`
-	PaymentId      int64                `gorm:"type:bigint;not null;default:0;comment:Payment Id"`
-	Payment        *payment.Payment     `gorm:"foreignKey:PaymentId;references:Id"`
-	Method         string               `gorm:"type:varchar(255);not null;default:'';comment:Payment Method"`
-	FeeAmount      int64                `gorm:"type:int;not null;default:0;comment:Fee Amount"`
-	TradeNo        string               `gorm:"type:varchar(255);default:null;comment:Trade No"`
-	GiftAmount     int64                `gorm:"type:int;not null;default:0;comment:User Gift Amount"`
-	Commission     int64                `gorm:"type:int;not null;default:0;comment:Order Commission"`
-	Status         uint8                `gorm:"type:tinyint(1);not null;default:1;comment:Order Status: 1: Pending, 2: Paid, 3: Failed"`
-	SubscribeId    int64                `gorm:"type:bigint;not null;default:0;comment:Subscribe Id"`
-	SubscribeToken string               `gorm:"type:varchar(255);default:null;comment:Renewal Subscribe Token"`
-	Subscribe      *subscribe.Subscribe `gorm:"foreignKey:SubscribeId;references:Id"`
-	IsNew          bool                 `gorm:"type:tinyint(1);not null;default:0;comment:Is New Order"`
-	CreatedAt      time.Time            `gorm:"<-:create;comment:Create Time"`
-	UpdatedAt      time.Time            `gorm:"comment:Update Time"`
+	Id                 int64                `gorm:"primaryKey"`
+	ParentId           int64                `gorm:"type:bigint;default:null;comment:Parent Order Id"`
+	SubOrders          []*Order             `gorm:"foreignKey:ParentId;references:Id"`
+	UserId             int64                `gorm:"type:bigint;not null;default:0;comment:User Id"`
+	OrderNo            string               `gorm:"type:varchar(255);not null;default:'';unique;comment:Order No"`
+	Type               uint8                `gorm:"type:tinyint(1);not null;default:1;comment:Order Type: 1: Subscribe, 2: Renewal, 3: ResetTraffic, 4: Recharge"`
+	Quantity           int64                `gorm:"type:bigint;not null;default:1;comment:Quantity"`
+	Price              int64                `gorm:"type:int;not null;default:0;comment:Original price"`
+	UnitPrice          int64                `gorm:"type:int;not null;default:0;comment:Plan Unit Price Snapshot"`
+	PlanDiscount       string               `gorm:"type:text;default:null;comment:Plan Discount Snapshot"`
+	Amount             int64                `gorm:"type:int;not null;default:0;comment:Order Amount"`
+	Discount           int64                `gorm:"type:int;not null;default:0;comment:Order Discount"`
+	Coupon             string               `gorm:"type:varchar(255);default:null;comment:Coupon"`
+	CouponDiscount     int64                `gorm:"type:int;not null;default:0;comment:Coupon Discount"`
+	PaymentDiscount    int64                `gorm:"type:int;not null;default:0;comment:Payment Method Discount"`
+	PaymentId          int64                `gorm:"type:bigint;not null;default:0;comment:Payment Id"`
+	Payment            *payment.Payment     `gorm:"foreignKey:PaymentId;references:Id"`
+	Method             string               `gorm:"type:varchar(255);not null;default:'';comment:Payment Method"`
+	FeeAmount          int64                `gorm:"type:int;not null;default:0;comment:Fee Amount"`
+	RoundingAdjustment int64                `gorm:"type:int;not null;default:0;comment:Rounding Adjustment"`
+	TradeNo            string               `gorm:"type:varchar(255);default:null;comment:Trade No"`
+	GiftAmount         int64                `gorm:"type:int;not null;default:0;comment:User Gift Amount"`
+	LoyaltyCredit      int64                `gorm:"type:int;not null;default:0;comment:Loyalty Credit Deduction"`
+	PromoCredit        int64                `gorm:"type:int;not null;default:0;comment:Promo Credit Part of the Gift Amount"`
+	Commission         int64                `gorm:"type:int;not null;default:0;comment:Order Commission"`
+	Status             uint8                `gorm:"type:tinyint(1);not null;default:1;comment:Order Status: 1: Pending, 2: Paid, 3: Failed"`
+	SubscribeId        int64                `gorm:"type:bigint;not null;default:0;comment:Subscribe Id"`
+	SubscribeToken     string               `gorm:"type:varchar(255);default:null;comment:Renewal Subscribe Token"`
+	Subscribe          *subscribe.Subscribe `gorm:"foreignKey:SubscribeId;references:Id"`
+	IsNew              bool                 `gorm:"type:tinyint(1);not null;default:0;comment:Is New Order"`
+	BulkOrderNo        string               `gorm:"type:varchar(255);default:null;comment:Bulk Renewal Order No"`
+	Metadata           string               `gorm:"type:text;default:null;comment:Informational Metadata"`
+	CreatedAt          time.Time            `gorm:"<-:create;comment:Create Time"`
+	UpdatedAt          time.Time            `gorm:"comment:Update Time"`
 }
 
 type OrdersTotalWithDate struct {
@@ -47,18 +56,73 @@ type OrdersTotalWithDate struct {
 	RenewalOrderAmount int64
 }
 
+// FilterSizeLimit the largest page size of the order filter
+const FilterSizeLimit = 100
+
+// FilterParams order list filter, zero values and nil pointers are ignored
+type FilterParams struct {
+	Page      int        // Page Number
+	Size      int        // Page Size
+	UserId    int64      // User ID
+	Type      uint8      // Order Type
+	Status    uint8      // Order Status
+	MinAmount *int64     // Min Order Amount
+	MaxAmount *int64     // Max Order Amount
+	StartTime *time.Time // Created At Start
+	EndTime   *time.Time // Created At End
+}
+
+func (p *FilterParams) Normalize() {
+	if p.Page <= 0 {
+		p.Page = 1
+	}
+	if p.Size <= 0 {
+		p.Size = 10
+	}
+	if p.Size > FilterSizeLimit {
+		p.Size = FilterSizeLimit
+	}
+}
+
+// FilterSummary aggregate sums of the filtered orders
+type FilterSummary struct {
+	Total               int64 `gorm:"column:total"`
+	TotalAmount         int64 `gorm:"column:total_amount"`
+	TotalFee            int64 `gorm:"column:total_fee"`
+	TotalCouponDiscount int64 `gorm:"column:total_coupon_discount"`
+}
+
+// ErrOrderStatusChanged is returned when the order is no longer in the expected status
+var ErrOrderStatusChanged = errors.New("order status changed")
+
 type customOrderLogicModel interface {
 	UpdateOrderStatus(ctx context.Context, orderNo string, status uint8, tx ...*gorm.DB) error
+	UpdateOrderStatusFrom(ctx context.Context, orderNo string, from, to uint8, tx ...*gorm.DB) error
+	UpdatePendingAmount(ctx context.Context, orderNo string, amount, feeAmount, roundingAdjustment, giftAmount int64, tx ...*gorm.DB) error
+	FindBulkItems(ctx context.Context, bulkOrderNo string) ([]*Order, error)
+	FindUnappliedRenewals(ctx context.Context, subscribeToken string) ([]*Order, error)
+	UpdateBulkItemsStatus(ctx context.Context, bulkOrderNo string, from, to uint8, tx ...*gorm.DB) error
+	RelinkSubscribeToken(ctx context.Context, oldToken, newToken string, tx ...*gorm.DB) error
+	UpdatePendingPayment(ctx context.Context, orderNo string, paymentId int64, method string, amount, feeAmount, roundingAdjustment int64, tx ...*gorm.DB) error
+	InsertPaymentAttempt(ctx context.Context, data *PaymentAttempt, tx ...*gorm.DB) error
+	NextSequence(ctx context.Context, day string) (int64, error)
+	FindPaymentAttempt(ctx context.Context, orderNo string, paymentId int64) (*PaymentAttempt, error)
+	FindPaymentAttempts(ctx context.Context, orderNo string) ([]*PaymentAttempt, error)
+	UpdatePaymentAttemptStatusFrom(ctx context.Context, id int64, from, to uint8, tx ...*gorm.DB) error
+	PayOrderWithAttempt(ctx context.Context, orderNo string, from uint8, attempt *PaymentAttempt, tx ...*gorm.DB) error
+	CancelPaymentAttempts(ctx context.Context, orderNo string, exceptId int64, tx ...*gorm.DB) error
 	QueryOrderListByPage(ctx context.Context, page, size int, status uint8, user, subscribe int64, search string) (int64, []*Details, error)
+	FilterOrderList(ctx context.Context, params *FilterParams) (*FilterSummary, []*Details, error)
 	FindOneDetails(ctx context.Context, id int64) (*Details, error)
 	FindOneDetailsByOrderNo(ctx context.Context, orderNo string) (*Details, error)
 	QueryMonthlyOrders(ctx context.Context, date time.Time) (OrdersTotal, error)
 	QueryDateOrders(ctx context.Context, date time.Time) (OrdersTotal, error)
 	QueryTotalOrders(ctx context.Context) (OrdersTotal, error)
+	QueryUserSpending(ctx context.Context, userId int64) (UserSpending, error)
 	QueryMonthlyUserCounts(ctx context.Context, date time.Time) (int64, int64, error)
 	QueryDateUserCounts(ctx context.Context, date time.Time) (int64, int64, error)
 	QueryTotalUserCounts(ctx context.Context) (int64, int64, error)
-	IsUserEligibleForNewOrder(ctx context.Context, userID int64) (bool, error)
+	IsUserEligibleForNewOrder(ctx context.Context, userID int64, window time.Duration) (bool, error)
 	QueryDailyOrdersList(ctx context.Context, date time.Time) ([]OrdersTotalWithDate, error)
 	QueryMonthlyOrdersList(ctx context.Context, date time.Time) ([]OrdersTotalWithDate, error)
 }
@@ -99,6 +163,65 @@ func (m *customOrderModel) QueryOrderListByPage(ctx context.Context, page, size
 	return total, list, err
 }
 
+// FilterOrderList Filter order list, the aggregate sums are calculated by the database over the whole filtered set
+func (m *customOrderModel) FilterOrderList(ctx context.Context, params *FilterParams) (*FilterSummary, []*Details, error) {
+	if params == nil {
+		params = &FilterParams{}
+	}
+	params.Normalize()
+
+	var list []*Details
+	var summary FilterSummary
+	buildQuery := func(conn *gorm.DB) *gorm.DB {
+		query := conn.Model(&Order{})
+		if params.UserId > 0 {
+			query = query.Where("user_id = ?", params.UserId)
+		}
+		if params.Type > 0 {
+			query = query.Where("type = ?", params.Type)
+		}
+		if params.Status > 0 {
+			query = query.Where("status = ?", params.Status)
+		}
+		if params.MinAmount != nil {
+			query = query.Where("amount >= ?", *params.MinAmount)
+		}
+		if params.MaxAmount != nil {
+			query = query.Where("amount <= ?", *params.MaxAmount)
+		}
+		if params.StartTime != nil {
+			query = query.Where("created_at >= ?", *params.StartTime)
+		}
+		if params.EndTime != nil {
+			query = query.Where("created_at <= ?", *params.EndTime)
+		}
+		return query
+	}
+	err := m.QueryNoCacheCtx(ctx, &summary, func(conn *gorm.DB, v interface{}) error {
+		return buildQuery(conn).
+			Select(
+				"COUNT(*) as total, " +
+					"COALESCE(SUM(amount), 0) as total_amount, " +
+					"COALESCE(SUM(fee_amount), 0) as total_fee, " +
+					"COALESCE(SUM(coupon_discount), 0) as total_coupon_discount",
+			).
+			Scan(v).Error
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	err = m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return buildQuery(conn).
+			Order("created_at desc, id desc").
+			Preload("Subscribe").
+			Preload("Payment").
+			Offset((params.Page - 1) * params.Size).
+			Limit(params.Size).
+			Find(v).Error
+	})
+	return &summary, list, err
+}
+
 // UpdateOrderStatus Update order status
 func (m *customOrderModel) UpdateOrderStatus(ctx context.Context, orderNo string, status uint8, tx ...*gorm.DB) error {
 	orderInfo, err := m.FindOneByOrderNo(ctx, orderNo)
@@ -113,6 +236,143 @@ func (m *customOrderModel) UpdateOrderStatus(ctx context.Context, orderNo string
 	}, m.getCacheKeys(orderInfo)...)
 }
 
+// UpdateOrderStatusFrom updates the order status only when it is still in the given status,
+// ErrOrderStatusChanged is returned when another request changed it first.
+func (m *customOrderModel) UpdateOrderStatusFrom(ctx context.Context, orderNo string, from, to uint8, tx ...*gorm.DB) error {
+	orderInfo, err := m.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Order{}).Where("order_no = ? AND status = ?", orderNo, from).Update("status", to)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		return nil
+	}, m.getCacheKeys(orderInfo)...)
+}
+
+// UpdatePendingAmount rewrites the amounts of an order that is still pending,
+// ErrOrderStatusChanged is returned when it was paid or closed in the meantime.
+func (m *customOrderModel) UpdatePendingAmount(ctx context.Context, orderNo string, amount, feeAmount, roundingAdjustment, giftAmount int64, tx ...*gorm.DB) error {
+	orderInfo, err := m.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Order{}).Where("order_no = ? AND status = ?", orderNo, StatusPending).Updates(map[string]interface{}{
+			"amount":              amount,
+			"fee_amount":          feeAmount,
+			"rounding_adjustment": roundingAdjustment,
+			"gift_amount":         giftAmount,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		return nil
+	}, m.getCacheKeys(orderInfo)...)
+}
+
+// UpdatePendingPayment moves an order that is still pending to another payment method with the amounts
+// priced for it, ErrOrderStatusChanged is returned when it was paid or closed in the meantime.
+func (m *customOrderModel) UpdatePendingPayment(ctx context.Context, orderNo string, paymentId int64, method string, amount, feeAmount, roundingAdjustment int64, tx ...*gorm.DB) error {
+	orderInfo, err := m.FindOneByOrderNo(ctx, orderNo)
+	if err != nil {
+		return err
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Order{}).Where("order_no = ? AND status = ?", orderNo, StatusPending).Updates(map[string]interface{}{
+			"payment_id":          paymentId,
+			"method":              method,
+			"amount":              amount,
+			"fee_amount":          feeAmount,
+			"rounding_adjustment": roundingAdjustment,
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOrderStatusChanged
+		}
+		return nil
+	}, m.getCacheKeys(orderInfo)...)
+}
+
+// FindBulkItems returns the renewal orders paid through the given bulk renewal order
+func (m *customOrderModel) FindBulkItems(ctx context.Context, bulkOrderNo string) ([]*Order, error) {
+	var list []*Order
+	err := m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&Order{}).Where("bulk_order_no = ?", bulkOrderNo).Order("id ASC").Find(v).Error
+	})
+	return list, err
+}
+
+// FindUnappliedRenewals returns the renewal orders of the user subscription that are not activated yet,
+// pending, held and paid ones still add their periods to the subscription.
+func (m *customOrderModel) FindUnappliedRenewals(ctx context.Context, subscribeToken string) ([]*Order, error) {
+	var list []*Order
+	err := m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&Order{}).
+			Where("type = ? AND subscribe_token = ? AND status IN ?", 2, subscribeToken, []uint8{StatusPending, StatusPaid, StatusHold}).
+			Order("id ASC").Find(v).Error
+	})
+	return list, err
+}
+
+// UpdateBulkItemsStatus moves the renewal orders of a bulk renewal order from one status to another,
+// items that already left the from status are left untouched.
+func (m *customOrderModel) UpdateBulkItemsStatus(ctx context.Context, bulkOrderNo string, from, to uint8, tx ...*gorm.DB) error {
+	items, err := m.FindBulkItems(ctx, bulkOrderNo)
+	if err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Model(&Order{}).Where("bulk_order_no = ? AND status = ?", bulkOrderNo, from).Update("status", to).Error
+	}, m.batchGetCacheKeys(items...)...)
+}
+
+// RelinkSubscribeToken points the orders of a user subscription at its new token, so a rotated token
+// keeps its order history and pending renewals still find the subscription on activation.
+func (m *customOrderModel) RelinkSubscribeToken(ctx context.Context, oldToken, newToken string, tx ...*gorm.DB) error {
+	var list []*Order
+	err := m.QueryNoCacheCtx(ctx, &list, func(conn *gorm.DB, v interface{}) error {
+		return conn.Model(&Order{}).Where("subscribe_token = ?", oldToken).Find(v).Error
+	})
+	if err != nil {
+		return err
+	}
+	if len(list) == 0 {
+		return nil
+	}
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Model(&Order{}).Where("subscribe_token = ?", oldToken).Update("subscribe_token", newToken).Error
+	}, m.batchGetCacheKeys(list...)...)
+}
+
 // FindOneDetailsByOrderNo Find order details by order number
 func (m *customOrderModel) FindOneDetailsByOrderNo(ctx context.Context, orderNo string) (*Details, error) {
 	var orderInfo Details
@@ -140,7 +400,7 @@ func (m *customOrderModel) QueryMonthlyOrders(ctx context.Context, date time.Tim
 	var result OrdersTotal
 	err := m.QueryNoCacheCtx(ctx, &result, func(conn *gorm.DB, v interface{}) error {
 		return conn.Model(&Order{}).
-			Where("status IN ? AND created_at BETWEEN ? AND ? AND method != ?", []int64{2, 5}, firstDay, lastDay, "balance").
+			Where("status IN ? AND created_at BETWEEN ? AND ? AND method != ? AND type != ?", []int64{2, 5}, firstDay, lastDay, "balance", TypeBulkRenewal).
 			Select(
 				"SUM(amount) as amount_total, " +
 					"SUM(CASE WHEN is_new = 1 THEN amount ELSE 0 END) as new_order_amount, " +
@@ -158,7 +418,7 @@ func (m *customOrderModel) QueryDateOrders(ctx context.Context, date time.Time)
 	var result OrdersTotal
 	err := m.QueryNoCacheCtx(ctx, &result, func(conn *gorm.DB, v interface{}) error {
 		return conn.Model(&Order{}).
-			Where("status IN ? AND created_at BETWEEN ? AND ? AND method != ?", []int64{2, 5}, start, end, "balance").
+			Where("status IN ? AND created_at BETWEEN ? AND ? AND method != ? AND type != ?", []int64{2, 5}, start, end, "balance", TypeBulkRenewal).
 			Select(
 				"SUM(amount) as amount_total, " +
 					"SUM(CASE WHEN is_new = 1 THEN amount ELSE 0 END) as new_order_amount, " +
@@ -179,13 +439,31 @@ func (m *customOrderModel) QueryTotalOrders(ctx context.Context) (OrdersTotal, e
 				SUM(CASE WHEN is_new = 1 THEN amount ELSE 0 END) AS new_order_amount,
 				SUM(CASE WHEN is_new = 0 THEN amount ELSE 0 END) AS renewal_order_amount
 			`).
-			Where("status IN ? AND method != ?", []int64{2, 5}, "balance").
+			Where("status IN ? AND method != ? AND type != ?", []int64{2, 5}, "balance", TypeBulkRenewal).
 			Scan(&result).Error
 	})
 
 	return result, err
 }
 
+// QueryUserSpending sums what the user paid for orders, counted like the revenue totals: orders paid from the
+// balance are left out since the recharge is counted, so is a bulk renewal, its renewal orders carry its amounts.
+// A refunded order counts as refunded in full. The query runs on idx_user_id.
+func (m *customOrderModel) QueryUserSpending(ctx context.Context, userId int64) (UserSpending, error) {
+	var result UserSpending
+	err := m.QueryNoCacheCtx(ctx, &result, func(conn *gorm.DB, _ interface{}) error {
+		return conn.Model(&Order{}).
+			Select(
+				"COALESCE(SUM(CASE WHEN status IN (?, ?) THEN amount ELSE 0 END), 0) AS spent, "+
+					"COALESCE(SUM(CASE WHEN status = ? THEN amount ELSE 0 END), 0) AS refunded",
+				StatusPaid, StatusFinished, StatusRefunded,
+			).
+			Where("user_id = ? AND status IN ? AND method != ? AND type != ?", userId, []uint8{StatusPaid, StatusFinished, StatusRefunded}, "balance", TypeBulkRenewal).
+			Scan(&result).Error
+	})
+	return result, err
+}
+
 func (m *customOrderModel) QueryMonthlyUserCounts(ctx context.Context, date time.Time) (int64, int64, error) {
 	// 获取当月第一天零点
 	firstDay := time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, date.Location())
@@ -201,8 +479,8 @@ func (m *customOrderModel) QueryMonthlyUserCounts(ctx context.Context, date time
 				COUNT(DISTINCT CASE WHEN is_new = 1 THEN user_id END) AS new_users,
 				COUNT(DISTINCT CASE WHEN is_new = 0 THEN user_id END) AS renewal_users
 			`).
-			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ?",
-				[]int64{2, 5}, firstDay, nextMonth, "balance").
+			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ? AND type != ?",
+				[]int64{2, 5}, firstDay, nextMonth, "balance", TypeBulkRenewal).
 			Scan(&counts).Error
 	})
 
@@ -222,8 +500,8 @@ func (m *customOrderModel) QueryDateUserCounts(ctx context.Context, date time.Ti
 				COUNT(DISTINCT CASE WHEN is_new = 1 THEN user_id END) AS new_users,
 				COUNT(DISTINCT CASE WHEN is_new = 0 THEN user_id END) AS renewal_users
 			`).
-			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ?",
-				[]int64{2, 5}, start, nextDay, "balance").
+			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ? AND type != ?",
+				[]int64{2, 5}, start, nextDay, "balance", TypeBulkRenewal).
 			Scan(&counts).Error
 	})
 
@@ -234,7 +512,7 @@ func (m *customOrderModel) QueryTotalUserCounts(ctx context.Context) (int64, int
 
 	err := m.QueryNoCacheCtx(ctx, nil, func(conn *gorm.DB, _ interface{}) error {
 		return conn.Model(&Order{}).
-			Where("status IN ? AND method != ?", []int64{2, 5}, "balance").
+			Where("status IN ? AND method != ? AND type != ?", []int64{2, 5}, "balance", TypeBulkRenewal).
 			Select(`
 				COUNT(DISTINCT CASE WHEN is_new = 1 THEN user_id END) AS new_users,
 				COUNT(DISTINCT CASE WHEN is_new = 0 THEN user_id END) AS renewal_users
@@ -245,12 +523,16 @@ func (m *customOrderModel) QueryTotalUserCounts(ctx context.Context) (int64, int
 	return counts.NewUsers, counts.RenewalUsers, err
 }
 
-func (m *customOrderModel) IsUserEligibleForNewOrder(ctx context.Context, userID int64) (bool, error) {
+func (m *customOrderModel) IsUserEligibleForNewOrder(ctx context.Context, userID int64, window time.Duration) (bool, error) {
 	var count int64
 	err := m.QueryNoCacheCtx(ctx, nil, func(conn *gorm.DB, _ interface{}) error {
-		return conn.Model(&Order{}).
-			Where("user_id = ? AND status IN ?", userID, []int64{2, 5}).
-			Count(&count).Error
+		query := conn.Model(&Order{}).
+			Where("user_id = ? AND status IN ?", userID, []int64{2, 5})
+		// only orders inside the window count, a user inactive for longer is new again
+		if window > 0 {
+			query = query.Where("created_at >= ?", time.Now().Add(-window))
+		}
+		return query.Count(&count).Error
 	})
 	return count == 0, err
 }
@@ -272,8 +554,8 @@ func (m *customOrderModel) QueryDailyOrdersList(ctx context.Context, date time.T
 				SUM(CASE WHEN is_new = 1 THEN amount ELSE 0 END) AS new_order_amount,
 				SUM(CASE WHEN is_new = 0 THEN amount ELSE 0 END) AS renewal_order_amount
 			`).
-			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ?",
-				[]int64{2, 5}, firstDay, nextDay, "balance").
+			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ? AND type != ?",
+				[]int64{2, 5}, firstDay, nextDay, "balance", TypeBulkRenewal).
 			Group("DATE_FORMAT(created_at, '%Y-%m-%d')").
 			Order("date ASC").
 			Scan(v).Error
@@ -298,8 +580,8 @@ func (m *customOrderModel) QueryMonthlyOrdersList(ctx context.Context, date time
 				SUM(CASE WHEN is_new = 1 THEN amount ELSE 0 END) AS new_order_amount,
 				SUM(CASE WHEN is_new = 0 THEN amount ELSE 0 END) AS renewal_order_amount
 			`).
-			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ?",
-				[]int64{2, 5}, start, end, "balance").
+			Where("status IN ? AND created_at >= ? AND created_at < ? AND method != ? AND type != ?",
+				[]int64{2, 5}, start, end, "balance", TypeBulkRenewal).
 			Group("DATE_FORMAT(created_at, '%Y-%m')").
 			Order("date ASC").
 			Scan(v).Error
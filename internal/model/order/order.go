@@ -1,39 +1,113 @@
 package order
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 type Order struct {
-	Id             int64     `gorm:"primaryKey"`
-	ParentId       int64     `gorm:"type:bigint;default:null;comment:Parent Order Id"`
-	UserId         int64     `gorm:"type:bigint;not null;default:0;comment:User Id"`
-	OrderNo        string    `gorm:"type:varchar(255);not null;default:'';unique;comment:Order No"`
-	Type           uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Order Type: 1: Subscribe, 2: Renewal, 3: ResetTraffic, 4: Recharge"`
-	Quantity       int64     `gorm:"type:bigint;not null;default:1;comment:Quantity"`
-	Price          int64     `gorm:"type:int;not null;default:0;comment:Original price"`
-	Amount         int64     `gorm:"type:int;not null;default:0;comment:Order Amount"`
-	GiftAmount     int64     `gorm:"type:int;not null;default:0;comment:User Gift Amount"`
-	Discount       int64     `gorm:"type:int;not null;default:0;comment:Discount Amount"`
-	Coupon         string    `gorm:"type:varchar(255);default:null;comment:Coupon"`
-	CouponDiscount int64     `gorm:"type:int;not null;default:0;comment:Coupon Discount Amount"`
-	Commission     int64     `gorm:"type:int;not null;default:0;comment:Order Commission"`
-	PaymentId      int64     `gorm:"type:bigint;not null;default:0;comment:Payment Method Id"`
-	Method         string    `gorm:"type:varchar(255);not null;default:'';comment:Payment Method"`
-	FeeAmount      int64     `gorm:"type:int;not null;default:0;comment:Fee Amount"`
-	TradeNo        string    `gorm:"type:varchar(255);default:null;comment:Trade No"`
-	Status         uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Order Status: 1: Pending, 2: Paid, 3:Close, 4: Failed, 5:Finished;"`
-	SubscribeId    int64     `gorm:"type:bigint;not null;default:0;comment:Subscribe Id"`
-	SubscribeToken string    `gorm:"type:varchar(255);default:null;comment:Renewal Subscribe Token"`
-	IsNew          bool      `gorm:"type:tinyint(1);not null;default:0;comment:Is New Order"`
-	CreatedAt      time.Time `gorm:"<-:create;comment:Create Time"`
-	UpdatedAt      time.Time `gorm:"comment:Update Time"`
+	Id                 int64     `gorm:"primaryKey"`
+	ParentId           int64     `gorm:"type:bigint;default:null;comment:Parent Order Id"`
+	UserId             int64     `gorm:"index:idx_user_id;type:bigint;not null;default:0;comment:User Id"`
+	OrderNo            string    `gorm:"type:varchar(255);not null;default:'';unique;comment:Order No"`
+	Type               uint8     `gorm:"type:tinyint(1);not null;default:1;comment:Order Type: 1: Subscribe, 2: Renewal, 3: ResetTraffic, 4: Recharge, 5: BulkRenewal, 6: Bundle, 7: TrafficTopUp"`
+	Quantity           int64     `gorm:"type:bigint;not null;default:1;comment:Quantity"`
+	Price              int64     `gorm:"type:int;not null;default:0;comment:Original price"`
+	UnitPrice          int64     `gorm:"type:int;not null;default:0;comment:Plan Unit Price Snapshot"`
+	PlanDiscount       string    `gorm:"type:text;default:null;comment:Plan Discount Snapshot"`
+	UnitTime           string    `gorm:"type:varchar(255);not null;default:'';comment:Plan Unit Time Snapshot"`
+	Periods            int64     `gorm:"type:bigint;not null;default:0;comment:Unit Time Periods Added Snapshot"`
+	Amount             int64     `gorm:"type:int;not null;default:0;comment:Order Amount"`
+	GiftAmount         int64     `gorm:"type:int;not null;default:0;comment:User Gift Amount"`
+	LoyaltyCredit      int64     `gorm:"type:int;not null;default:0;comment:Loyalty Credit Deduction"`
+	PromoCredit        int64     `gorm:"type:int;not null;default:0;comment:Promo Credit Part of the Gift Amount"`
+	Discount           int64     `gorm:"type:int;not null;default:0;comment:Discount Amount"`
+	Coupon             string    `gorm:"type:varchar(255);default:null;comment:Coupon"`
+	CouponDiscount     int64     `gorm:"type:int;not null;default:0;comment:Coupon Discount Amount"`
+	BonusDays          int64     `gorm:"type:int;not null;default:0;comment:Coupon Bonus Days"`
+	PaymentDiscount    int64     `gorm:"type:int;not null;default:0;comment:Payment Method Discount Amount"`
+	Commission         int64     `gorm:"type:int;not null;default:0;comment:Order Commission"`
+	PaymentId          int64     `gorm:"type:bigint;not null;default:0;comment:Payment Method Id"`
+	Method             string    `gorm:"type:varchar(255);not null;default:'';comment:Payment Method"`
+	FeeAmount          int64     `gorm:"type:int;not null;default:0;comment:Fee Amount"`
+	RoundingAdjustment int64     `gorm:"type:int;not null;default:0;comment:Rounding Adjustment"`
+	TradeNo            string    `gorm:"type:varchar(255);default:null;comment:Trade No"`
+	Status             uint8     `gorm:"index:idx_status_created_at,priority:1;type:tinyint(1);not null;default:1;comment:Order Status: 1: Pending, 2: Paid, 3:Close, 4: Failed, 5:Finished, 6:Refunded, 7:Hold, 8:Underpaid, 9:AwaitingInvoice, 10:Invoiced;"`
+	SubscribeId        int64     `gorm:"type:bigint;not null;default:0;comment:Subscribe Id"`
+	FromSubscribeId    int64     `gorm:"type:bigint;not null;default:0;comment:Plan Switched From By The Renewal"`
+	SubscribeToken     string    `gorm:"type:varchar(255);default:null;comment:Renewal Subscribe Token"`
+	IsNew              bool      `gorm:"type:tinyint(1);not null;default:0;comment:Is New Order"`
+	BulkOrderNo        string    `gorm:"index:idx_bulk_order_no;type:varchar(255);default:null;comment:Bulk Renewal Order No"`
+	BundleId           int64     `gorm:"type:bigint;not null;default:0;comment:Subscribe Bundle Id"`
+	BundleCredit       int64     `gorm:"type:int;not null;default:0;comment:Bundle Gift Amount Credit"`
+	Metadata           string    `gorm:"type:text;default:null;comment:Informational Metadata"`
+	CreatedAt          time.Time `gorm:"<-:create;index:idx_created_at;index:idx_status_created_at,priority:2;comment:Create Time"`
+	UpdatedAt          time.Time `gorm:"comment:Update Time"`
 }
 
+// Order status
+const (
+	StatusPending  uint8 = 1
+	StatusPaid     uint8 = 2
+	StatusClose    uint8 = 3
+	StatusFailed   uint8 = 4
+	StatusFinished uint8 = 5
+	StatusRefunded uint8 = 6
+	// StatusHold an unpaid order past its payment window that still accepts the payment
+	// until its hold expires, nothing is restored before it is closed.
+	StatusHold uint8 = 7
+	// StatusUnderpaid an order a gateway confirmed for less than it was charged, it is neither activated
+	// nor closed and waits for an admin to settle it.
+	StatusUnderpaid uint8 = 8
+	// StatusAwaitingInvoice an order of an enterprise account checked out on invoice and charged to the
+	// account's outstanding balance, it is activated right away and moves on to StatusInvoiced.
+	StatusAwaitingInvoice uint8 = 9
+	// StatusInvoiced an order on invoice that has been provisioned, it stays here until an admin marks the
+	// invoice paid. Its commission and rewards are granted then.
+	StatusInvoiced uint8 = 10
+)
+
+// TypeResetTraffic is the paid reset of a subscription's traffic, on payment it zeroes Upload and
+// Download and leaves the expire time as it is.
+const TypeResetTraffic uint8 = 3
+
+// TypeBulkRenewal is the payable order of a bulk renewal, the renewal orders it pays for
+// reference it through BulkOrderNo and carry their share of its amounts.
+const TypeBulkRenewal uint8 = 5
+
+// TypeBundle is the purchase of a subscribe bundle, on payment it provisions the subscription
+// like a purchase and credits BundleCredit to the user's gift amount.
+const TypeBundle uint8 = 6
+
+// TypeTrafficTopUp is the purchase of extra traffic for a subscription, Quantity is the GB bought. On
+// payment it raises the subscription's Traffic and leaves the expire time as it is.
+const TypeTrafficTopUp uint8 = 7
+
+// TrafficTopUpUnit the bytes of traffic in one GB of a top-up order
+const TrafficTopUpUnit int64 = 1 << 30
+
 type OrdersTotal struct {
 	AmountTotal        int64
 	NewOrderAmount     int64
 	RenewalOrderAmount int64
 }
 
+// UserSpending what a user paid for orders, Spent excludes the Refunded orders
+type UserSpending struct {
+	Spent    int64
+	Refunded int64
+}
+
 func (Order) TableName() string {
 	return "order"
 }
+
+// ParseMetadata decodes the informational metadata attached to an order, it never takes part in any amount.
+func ParseMetadata(metadata string) map[string]string {
+	if metadata == "" {
+		return nil
+	}
+	var result map[string]string
+	_ = json.Unmarshal([]byte(metadata), &result)
+	return result
+}
@@ -0,0 +1,30 @@
+package order
+
+import (
+	"context"
+
+	"gorm.io/gorm"
+)
+
+// Sequence is the order number counter of a day, used by the sequential trade number generator
+type Sequence struct {
+	Day   string `gorm:"primaryKey;type:varchar(8);comment:Day, YYYYMMDD"`
+	Value int64  `gorm:"type:bigint;not null;default:0;comment:Last Sequence Of The Day"`
+}
+
+func (Sequence) TableName() string {
+	return "order_sequence"
+}
+
+// NextSequence atomically takes the next sequence of the day, the first order of a day gets 1. The upsert
+// locks the row until the transaction ends, so the value read back is the one this call set.
+func (m *customOrderModel) NextSequence(ctx context.Context, day string) (int64, error) {
+	var seq int64
+	err := m.Transaction(ctx, func(tx *gorm.DB) error {
+		if err := tx.Exec("INSERT INTO `order_sequence` (`day`, `value`) VALUES (?, 1) ON DUPLICATE KEY UPDATE `value` = `value` + 1", day).Error; err != nil {
+			return err
+		}
+		return tx.Model(&Sequence{}).Where("`day` = ?", day).Pluck("value", &seq).Error
+	})
+	return seq, err
+}
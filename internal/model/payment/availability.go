@@ -0,0 +1,89 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// HourWindow is a daily time window in minutes after midnight, a window with End before Start
+// runs past midnight.
+type HourWindow struct {
+	Start int
+	End   int
+}
+
+// Contains reports whether the minute of the day falls within the window, the end is exclusive.
+func (w HourWindow) Contains(minute int) bool {
+	if w.Start <= w.End {
+		return minute >= w.Start && minute < w.End
+	}
+	return minute >= w.Start || minute < w.End
+}
+
+// ParseHours parses comma separated HH:MM-HH:MM windows, e.g. "09:00-18:00,22:00-02:00".
+// An empty string has no windows.
+func ParseHours(value string) ([]HourWindow, error) {
+	var windows []HourWindow
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		start, end, ok := strings.Cut(item, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid time window %q, expected HH:MM-HH:MM", item)
+		}
+		var w HourWindow
+		var err error
+		if w.Start, err = parseMinute(start); err != nil {
+			return nil, err
+		}
+		if w.End, err = parseMinute(end); err != nil {
+			return nil, err
+		}
+		if w.Start == w.End {
+			return nil, fmt.Errorf("empty time window %q", item)
+		}
+		windows = append(windows, w)
+	}
+	return windows, nil
+}
+
+func parseMinute(value string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return 0, fmt.Errorf("invalid time %q, expected HH:MM", value)
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// AvailableAt reports whether the method is enabled at now, in the location of now. Without
+// time windows it is enabled all day, windows that fail to parse never match.
+func (l *Payment) AvailableAt(now time.Time) bool {
+	if strings.TrimSpace(l.AvailableHours) == "" {
+		return true
+	}
+	windows, err := ParseHours(l.AvailableHours)
+	if err != nil {
+		return false
+	}
+	minute := now.Hour()*60 + now.Minute()
+	for _, w := range windows {
+		if w.Contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// AvailableFor reports whether the method accepts the amount, a bound of 0 is open.
+func (l *Payment) AvailableFor(amount int64) bool {
+	if l.MinAmount > 0 && amount < l.MinAmount {
+		return false
+	}
+	if l.MaxAmount > 0 && amount > l.MaxAmount {
+		return false
+	}
+	return true
+}
@@ -8,19 +8,34 @@ import (
 )
 
 type Payment struct {
-	Id          int64  `gorm:"primaryKey"`
-	Name        string `gorm:"type:varchar(100);not null;default:'';comment:Payment Name"`
-	Platform    string `gorm:"<-:create;type:varchar(100);not null;comment:Payment Platform"`
-	Icon        string `gorm:"type:varchar(255);default:'';comment:Payment Icon"`
-	Domain      string `gorm:"type:varchar(255);default:'';comment:Notification Domain"`
-	Config      string `gorm:"type:text;not null;comment:Payment Configuration"`
-	Description string `gorm:"type:text;comment:Payment Description"`
-	FeeMode     uint   `gorm:"type:tinyint(1);not null;default:0;comment:Fee Mode: 0: No Fee 1: Percentage 2: Fixed Amount 3: Percentage + Fixed Amount"`
-	FeePercent  int64  `gorm:"type:int;default:0;comment:Fee Percentage"`
-	FeeAmount   int64  `gorm:"type:int;default:0;comment:Fixed Fee Amount"`
-	Enable      *bool  `gorm:"type:tinyint(1);not null;default:0;comment:Is Enabled"`
-	Token       string `gorm:"type:varchar(255);unique;not null;default:'';comment:Payment Token"`
-}
+	Id              int64  `gorm:"primaryKey"`
+	Name            string `gorm:"type:varchar(100);not null;default:'';comment:Payment Name"`
+	Platform        string `gorm:"<-:create;type:varchar(100);not null;comment:Payment Platform"`
+	Icon            string `gorm:"type:varchar(255);default:'';comment:Payment Icon"`
+	Domain          string `gorm:"type:varchar(255);default:'';comment:Notification Domain"`
+	Config          string `gorm:"type:text;not null;comment:Payment Configuration"`
+	Description     string `gorm:"type:text;comment:Payment Description"`
+	FeeMode         uint   `gorm:"type:tinyint(1);not null;default:0;comment:Fee Mode: 0: No Fee 1: Percentage 2: Fixed Amount 3: Percentage + Fixed Amount"`
+	FeePercent      int64  `gorm:"type:int;default:0;comment:Fee Percentage"`
+	FeeAmount       int64  `gorm:"type:int;default:0;comment:Fixed Fee Amount"`
+	DiscountPercent int64  `gorm:"type:int;not null;default:0;comment:Payment Discount Percentage"`
+	CloseStrategy   uint8  `gorm:"type:tinyint(1);not null;default:0;comment:Unpaid Order Close Strategy: 0: Cancel 1: Hold"`
+	HoldMinutes     int64  `gorm:"type:int;not null;default:0;comment:Unpaid Order Hold Minutes"`
+	MinRecharge     int64  `gorm:"type:int;not null;default:0;comment:Minimum Recharge Amount"`
+	MaxRecharge     int64  `gorm:"type:int;not null;default:0;comment:Maximum Recharge Amount"`
+	MinAmount       int64  `gorm:"type:int;not null;default:0;comment:Minimum Order Amount"`
+	MaxAmount       int64  `gorm:"type:int;not null;default:0;comment:Maximum Order Amount"`
+	AvailableHours  string `gorm:"type:varchar(255);not null;default:'';comment:Enabled Time Windows"`
+	Enable          *bool  `gorm:"type:tinyint(1);not null;default:0;comment:Is Enabled"`
+	Token           string `gorm:"type:varchar(255);unique;not null;default:'';comment:Payment Token"`
+}
+
+// Close strategies of unpaid orders, a held order keeps waiting for the payment
+// (e.g. a bank transfer) and is only closed once the hold expires.
+const (
+	CloseStrategyCancel uint8 = 0
+	CloseStrategyHold   uint8 = 1
+)
 
 func (*Payment) TableName() string {
 	return "payment"
@@ -2,6 +2,8 @@ package subscribe
 
 import (
 	"context"
+	"errors"
+	"fmt"
 
 	"github.com/perfect-panel/server/pkg/tool"
 	"github.com/redis/go-redis/v9"
@@ -34,8 +36,15 @@ type customSubscribeLogicModel interface {
 	FilterList(ctx context.Context, params *FilterParams) (int64, []*Subscribe, error)
 	ClearCache(ctx context.Context, id ...int64) error
 	QuerySubscribeMinSortByIds(ctx context.Context, ids []int64) (int64, error)
+	DecreaseInventory(ctx context.Context, id int64, tx ...*gorm.DB) error
+	IncreaseInventory(ctx context.Context, id int64, tx ...*gorm.DB) error
+	SetInventory(ctx context.Context, id int64, inventory int64) error
+	AddInventory(ctx context.Context, id int64, delta int64) error
 }
 
+// ErrOutOfStock is returned when the subscribe plan has no inventory left
+var ErrOutOfStock = errors.New("subscribe out of stock")
+
 // NewModel returns a model for the database table.
 func NewModel(conn *gorm.DB, c *redis.Client) Model {
 	return &customSubscribeModel{
@@ -51,6 +60,69 @@ func (m *customSubscribeModel) QuerySubscribeMinSortByIds(ctx context.Context, i
 	return minSort, err
 }
 
+// DecreaseInventory atomically takes one unit of inventory and returns ErrOutOfStock when none is left.
+// Plans with unlimited inventory (-1) never match the update and also return ErrOutOfStock, callers skip them.
+func (m *customSubscribeModel) DecreaseInventory(ctx context.Context, id int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&Subscribe{}).
+			Where("`id` = ? AND `inventory` > 0", id).
+			UpdateColumn("inventory", gorm.Expr("`inventory` - 1"))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOutOfStock
+		}
+		return nil
+	}, fmt.Sprintf("%s%v", cacheSubscribeIdPrefix, id))
+}
+
+// IncreaseInventory atomically returns one unit of inventory, plans with unlimited inventory (-1) are left untouched.
+func (m *customSubscribeModel) IncreaseInventory(ctx context.Context, id int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		return conn.Model(&Subscribe{}).
+			Where("`id` = ? AND `inventory` >= 0", id).
+			UpdateColumn("inventory", gorm.Expr("`inventory` + 1")).Error
+	}, fmt.Sprintf("%s%v", cacheSubscribeIdPrefix, id))
+}
+
+// SetInventory overwrites the inventory of a plan, -1 makes it unlimited.
+func (m *customSubscribeModel) SetInventory(ctx context.Context, id int64, inventory int64) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		result := conn.Model(&Subscribe{}).Where("`id` = ?", id).UpdateColumn("inventory", inventory)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	}, fmt.Sprintf("%s%v", cacheSubscribeIdPrefix, id))
+}
+
+// AddInventory atomically adds delta to a limited plan's inventory, a negative delta never takes it below zero.
+// ErrOutOfStock is returned when the plan is unlimited or the stock is too low for the delta.
+func (m *customSubscribeModel) AddInventory(ctx context.Context, id int64, delta int64) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		result := conn.Model(&Subscribe{}).
+			Where("`id` = ? AND `inventory` >= 0 AND `inventory` + ? >= 0", id, delta).
+			UpdateColumn("inventory", gorm.Expr("`inventory` + ?", delta))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrOutOfStock
+		}
+		return nil
+	}, fmt.Sprintf("%s%v", cacheSubscribeIdPrefix, id))
+}
+
 func (m *customSubscribeModel) ClearCache(ctx context.Context, ids ...int64) error {
 	if len(ids) <= 0 {
 		return nil
@@ -0,0 +1,134 @@
+package subscribe
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// The inventory tests are opt-in integration tests, the conditional update needs a real MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/model/subscribe/
+func newInventoryTestModel(t *testing.T, inventory int64) (Model, *Subscribe) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&Subscribe{}); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	m := NewModel(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	ctx := context.Background()
+	sub := &Subscribe{Name: t.Name(), Inventory: inventory}
+	if err = m.Insert(ctx, sub); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = m.Delete(ctx, sub.Id)
+	})
+	return m, sub
+}
+
+// TestDecreaseInventoryConcurrent runs many purchases at once against a plan with little stock,
+// a purchase takes one unit of inventory inside its transaction and some of them fail afterwards and roll back.
+func TestDecreaseInventoryConcurrent(t *testing.T) {
+	const (
+		stock   = 5
+		workers = 50
+	)
+	m, sub := newInventoryTestModel(t, stock)
+	ctx := context.Background()
+	errPurchaseFailed := errors.New("purchase failed")
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		success int
+		failed  int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			err := m.Transaction(ctx, func(tx *gorm.DB) error {
+				if err := m.DecreaseInventory(ctx, sub.Id, tx); err != nil {
+					return err
+				}
+				// a failing purchase rolls back the inventory it took
+				if i%10 == 0 {
+					return errPurchaseFailed
+				}
+				return nil
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				success++
+			case errors.Is(err, ErrOutOfStock), errors.Is(err, errPurchaseFailed):
+				failed++
+			default:
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := m.FindOne(ctx, sub.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rolled back purchases may leave some stock unsold, but never more is sold than the stock
+	// and exactly what was sold is gone
+	assert.LessOrEqual(t, success, stock)
+	assert.Equal(t, workers-success, failed)
+	assert.Equal(t, int64(stock-success), data.Inventory)
+	assert.GreaterOrEqual(t, data.Inventory, int64(0))
+}
+
+func TestDecreaseInventoryUnlimited(t *testing.T) {
+	m, sub := newInventoryTestModel(t, -1)
+	ctx := context.Background()
+
+	// unlimited plans never match the conditional update and are skipped by callers
+	assert.ErrorIs(t, m.DecreaseInventory(ctx, sub.Id), ErrOutOfStock)
+	assert.NoError(t, m.IncreaseInventory(ctx, sub.Id))
+
+	data, err := m.FindOne(ctx, sub.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(-1), data.Inventory)
+}
+
+func TestAddInventory(t *testing.T) {
+	m, sub := newInventoryTestModel(t, 2)
+	ctx := context.Background()
+
+	assert.NoError(t, m.AddInventory(ctx, sub.Id, 3))
+	// a decrement larger than the stock is rejected instead of going negative
+	assert.ErrorIs(t, m.AddInventory(ctx, sub.Id, -6), ErrOutOfStock)
+	assert.NoError(t, m.AddInventory(ctx, sub.Id, -5))
+
+	data, err := m.FindOne(ctx, sub.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), data.Inventory)
+
+	assert.NoError(t, m.SetInventory(ctx, sub.Id, -1))
+	assert.ErrorIs(t, m.AddInventory(ctx, sub.Id, 1), ErrOutOfStock)
+}
@@ -3,35 +3,118 @@ package subscribe
 import (
 	"time"
 
+	"github.com/perfect-panel/server/pkg/tool"
 	"gorm.io/gorm"
 )
 
 type Subscribe struct {
-	Id                int64     `gorm:"primaryKey"`
-	Name              string    `gorm:"type:varchar(255);not null;default:'';comment:Subscribe Name"`
-	Language          string    `gorm:"type:varchar(255);not null;default:'';comment:Language"`
-	Description       string    `gorm:"type:text;comment:Subscribe Description"`
-	UnitPrice         int64     `gorm:"type:int;not null;default:0;comment:Unit Price"`
-	UnitTime          string    `gorm:"type:varchar(255);not null;default:'';comment:Unit Time"`
-	Discount          string    `gorm:"type:text;comment:Discount"`
-	Replacement       int64     `gorm:"type:int;not null;default:0;comment:Replacement"`
-	Inventory         int64     `gorm:"type:int;not null;default:-1;comment:Inventory"`
-	Traffic           int64     `gorm:"type:int;not null;default:0;comment:Traffic"`
-	SpeedLimit        int64     `gorm:"type:int;not null;default:0;comment:Speed Limit"`
-	DeviceLimit       int64     `gorm:"type:int;not null;default:0;comment:Device Limit"`
-	Quota             int64     `gorm:"type:int;not null;default:0;comment:Quota"`
-	Nodes             string    `gorm:"type:varchar(255);comment:Node Ids"`
-	NodeTags          string    `gorm:"type:varchar(255);comment:Node Tags"`
-	Show              *bool     `gorm:"type:tinyint(1);not null;default:0;comment:Show portal page"`
-	Sell              *bool     `gorm:"type:tinyint(1);not null;default:0;comment:Sell"`
-	Sort              int64     `gorm:"type:int;not null;default:0;comment:Sort"`
-	DeductionRatio    int64     `gorm:"type:int;default:0;comment:Deduction Ratio"`
-	AllowDeduction    *bool     `gorm:"type:tinyint(1);default:1;comment:Allow deduction"`
-	ResetCycle        int64     `gorm:"type:int;default:0;comment:Reset Cycle: 0: No Reset, 1: 1st, 2: Monthly, 3: Yearly"`
-	RenewalReset      *bool     `gorm:"type:tinyint(1);default:0;comment:Renew Reset"`
-	ShowOriginalPrice bool      `gorm:"type:tinyint(1);not null;default:1;comment:Show Original Price"`
-	CreatedAt         time.Time `gorm:"<-:create;comment:Create Time"`
-	UpdatedAt         time.Time `gorm:"comment:Update Time"`
+	Id                  int64     `gorm:"primaryKey"`
+	Name                string    `gorm:"type:varchar(255);not null;default:'';comment:Subscribe Name"`
+	Language            string    `gorm:"type:varchar(255);not null;default:'';comment:Language"`
+	Description         string    `gorm:"type:text;comment:Subscribe Description"`
+	UnitPrice           int64     `gorm:"type:int;not null;default:0;comment:Unit Price"`
+	UnitTime            string    `gorm:"type:varchar(255);not null;default:'';comment:Unit Time"`
+	Discount            string    `gorm:"type:text;comment:Discount"`
+	DiscountInterpolate bool      `gorm:"type:tinyint(1);not null;default:0;comment:Interpolate Discount Between Tiers"`
+	Replacement         int64     `gorm:"type:int;not null;default:0;comment:Replacement"`
+	TrafficReset        bool      `gorm:"type:tinyint(1);not null;default:0;comment:Allow Paid Traffic Reset"` // users may buy a reset for the Replacement fee
+	TrafficTopUp        bool      `gorm:"type:tinyint(1);not null;default:0;comment:Allow Traffic Top-Up"`     // users may buy extra traffic at TopUpPrice per GB
+	TopUpPrice          int64     `gorm:"type:int;not null;default:0;comment:Traffic Top-Up Price per GB"`
+	Inventory           int64     `gorm:"type:int;not null;default:-1;comment:Inventory"`
+	Traffic             int64     `gorm:"type:int;not null;default:0;comment:Traffic"`
+	SpeedLimit          int64     `gorm:"type:int;not null;default:0;comment:Speed Limit"`
+	DeviceLimit         int64     `gorm:"type:int;not null;default:0;comment:Device Limit"`
+	Quota               int64     `gorm:"type:int;not null;default:0;comment:Quota"`
+	Nodes               string    `gorm:"type:varchar(255);comment:Node Ids"`
+	NodeTags            string    `gorm:"type:varchar(255);comment:Node Tags"`
+	StickyNode          bool      `gorm:"type:tinyint(1);not null;default:0;comment:Sticky Node"`
+	DatacenterPolicy    uint8     `gorm:"type:tinyint(1);not null;default:0;comment:Datacenter Fetch Policy: 0: Allow, 1: Flag, 2: Block"`
+	NodeSort            string    `gorm:"type:varchar(16);not null;default:'';comment:Node Order In Configs: name, tag, weight or empty for the node sort"`
+	ExtraRules          string    `gorm:"type:text;comment:Extra Rules"`
+	SubscribeTemplate   string    `gorm:"type:text;comment:Subscribe Template Override"` // replaces the client template when set
+	Show                *bool     `gorm:"type:tinyint(1);not null;default:0;comment:Show portal page"`
+	Sell                *bool     `gorm:"type:tinyint(1);not null;default:0;comment:Sell"`
+	Sort                int64     `gorm:"type:int;not null;default:0;comment:Sort"`
+	DeductionRatio      int64     `gorm:"type:int;default:0;comment:Deduction Ratio"`
+	AllowDeduction      *bool     `gorm:"type:tinyint(1);default:1;comment:Allow deduction"`
+	ResetCycle          int64     `gorm:"type:int;default:0;comment:Reset Cycle: 0: No Reset, 1: 1st, 2: Monthly, 3: Yearly"`
+	RenewalReset        *bool     `gorm:"type:tinyint(1);default:0;comment:Renew Reset"`
+	MaxRenewalStack     int64     `gorm:"type:int;not null;default:0;comment:Max Renewal Periods Banked: 0: Unlimited"`
+	QuantityMode        uint8     `gorm:"type:tinyint(1);not null;default:0;comment:Quantity Mode: 0: Duration, 1: Count"`
+	Duration            int64     `gorm:"type:int;not null;default:1;comment:Unit Time Periods per Order in Count Mode"`
+	MinQuantity         int64     `gorm:"type:int;not null;default:0;comment:Minimum Order Quantity: 0: No Minimum"`
+	DefaultQuantity     int64     `gorm:"type:int;not null;default:0;comment:Order Quantity When None Given: 0: Minimum"`
+	ShowOriginalPrice   bool      `gorm:"type:tinyint(1);not null;default:1;comment:Show Original Price"`
+	CreatedAt           time.Time `gorm:"<-:create;comment:Create Time"`
+	UpdatedAt           time.Time `gorm:"comment:Update Time"`
+}
+
+// Quantity modes of a plan, the price is UnitPrice * quantity in both. A count mode renewal extends one copy.
+const (
+	QuantityModeDuration uint8 = 0 // quantity is the number of UnitTime periods bought
+	QuantityModeCount    uint8 = 1 // quantity is the number of copies, a subscription each, every order lasts Duration periods
+)
+
+// Datacenter policies of a plan, applied to subscription fetches from datacenter networks
+const (
+	DatacenterPolicyAllow uint8 = 0
+	DatacenterPolicyFlag  uint8 = 1 // serve the config and mark the subscribe log
+	DatacenterPolicyBlock uint8 = 2 // refuse the fetch
+)
+
+// Node orders of a plan's configs, the node sort of the admin applies without one
+const (
+	NodeSortName   = "name"   // by node name, ignoring case
+	NodeSortTag    = "tag"    // grouped by the first tag of the node, untagged nodes last
+	NodeSortWeight = "weight" // heaviest node weight first
+)
+
+// Periods returns how many UnitTime periods an order of the given quantity adds to the subscription
+func (s *Subscribe) Periods(quantity int64) int64 {
+	if s.QuantityMode == QuantityModeCount {
+		return max(s.Duration, 1)
+	}
+	return quantity
+}
+
+// Copies returns how many subscriptions an order of the given quantity provisions, one per copy in count mode
+func (s *Subscribe) Copies(quantity int64) int64 {
+	if s.QuantityMode == QuantityModeCount {
+		return max(quantity, 1)
+	}
+	return 1
+}
+
+// ConvertRemaining converts time left on the plan into time of the same value on another plan,
+// valued at the unit price of a period. The time is kept as it is when either plan has no priced period.
+func (s *Subscribe) ConvertRemaining(remaining time.Duration, to *Subscribe, now time.Time) time.Duration {
+	from, into := s.secondPrice(now), to.secondPrice(now)
+	if remaining <= 0 || from <= 0 || into <= 0 {
+		return remaining
+	}
+	return time.Duration(float64(remaining) * from / into)
+}
+
+// secondPrice returns the price of a second of the plan, 0 when its period has no price or length
+func (s *Subscribe) secondPrice(now time.Time) float64 {
+	if s.UnitPrice <= 0 || s.UnitTime == "NoLimit" {
+		return 0
+	}
+	period := tool.AddTime(s.UnitTime, 1, now).Sub(now)
+	if period <= 0 {
+		return 0
+	}
+	return float64(s.UnitPrice) / period.Seconds()
+}
+
+// MinOrderQuantity returns the smallest quantity an order of the plan may have, never less than 1
+func (s *Subscribe) MinOrderQuantity() int64 {
+	return max(s.MinQuantity, 1)
+}
+
+// DefaultOrderQuantity returns the quantity ordered when the request gives none
+func (s *Subscribe) DefaultOrderQuantity() int64 {
+	return max(s.DefaultQuantity, s.MinOrderQuantity())
 }
 
 func (*Subscribe) TableName() string {
@@ -87,3 +170,23 @@ type Group struct {
 func (Group) TableName() string {
 	return "subscribe_group"
 }
+
+// Bundle sells a quantity of a subscribe plan together with gift amount credited on payment,
+// priced as one total instead of the plan discount tiers.
+type Bundle struct {
+	Id          int64     `gorm:"primaryKey"`
+	Name        string    `gorm:"type:varchar(255);not null;default:'';comment:Bundle Name"`
+	Description string    `gorm:"type:text;comment:Bundle Description"`
+	SubscribeId int64     `gorm:"type:bigint;not null;default:0;comment:Subscribe Id"`
+	Quantity    int64     `gorm:"type:bigint;not null;default:1;comment:Subscribe Quantity"`
+	Price       int64     `gorm:"type:int;not null;default:0;comment:Bundle Price"`
+	Credit      int64     `gorm:"type:int;not null;default:0;comment:Gift Amount Credited on Payment"`
+	Sell        bool      `gorm:"type:tinyint(1);not null;default:0;comment:Sell"`
+	Sort        int64     `gorm:"type:int;not null;default:0;comment:Sort"`
+	CreatedAt   time.Time `gorm:"<-:create;comment:Create Time"`
+	UpdatedAt   time.Time `gorm:"comment:Update Time"`
+}
+
+func (Bundle) TableName() string {
+	return "subscribe_bundle"
+}
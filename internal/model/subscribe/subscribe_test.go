@@ -0,0 +1,56 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPeriods(t *testing.T) {
+	tests := []struct {
+		name     string
+		sub      Subscribe
+		quantity int64
+		want     int64
+	}{
+		{name: "duration mode", sub: Subscribe{}, quantity: 3, want: 3},
+		{name: "count mode", sub: Subscribe{QuantityMode: QuantityModeCount, Duration: 12}, quantity: 5, want: 12},
+		{name: "count mode without duration", sub: Subscribe{QuantityMode: QuantityModeCount}, quantity: 5, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.sub.Periods(tt.quantity))
+		})
+	}
+}
+
+func TestCopies(t *testing.T) {
+	assert.Equal(t, int64(1), (&Subscribe{}).Copies(3))
+	assert.Equal(t, int64(3), (&Subscribe{QuantityMode: QuantityModeCount}).Copies(3))
+	assert.Equal(t, int64(1), (&Subscribe{QuantityMode: QuantityModeCount}).Copies(0))
+}
+
+func TestConvertRemaining(t *testing.T) {
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	cheap := &Subscribe{UnitPrice: 100, UnitTime: "Day"}
+	dear := &Subscribe{UnitPrice: 200, UnitTime: "Day"}
+	unlimited := &Subscribe{UnitPrice: 100, UnitTime: "NoLimit"}
+	tests := []struct {
+		name      string
+		from, to  *Subscribe
+		remaining time.Duration
+		want      time.Duration
+	}{
+		{name: "into a dearer plan", from: cheap, to: dear, remaining: 48 * time.Hour, want: 24 * time.Hour},
+		{name: "into a cheaper plan", from: dear, to: cheap, remaining: 24 * time.Hour, want: 48 * time.Hour},
+		{name: "same price", from: cheap, to: cheap, remaining: 5 * time.Hour, want: 5 * time.Hour},
+		{name: "no priced period", from: unlimited, to: dear, remaining: 5 * time.Hour, want: 5 * time.Hour},
+		{name: "nothing left", from: cheap, to: dear, remaining: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.from.ConvertRemaining(tt.remaining, tt.to, now))
+		})
+	}
+}
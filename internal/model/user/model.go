@@ -2,6 +2,7 @@ package user
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"time"
 
@@ -20,25 +21,39 @@ const (
 	cacheUserDeviceIdPrefix       = "cache:user:device:id:"
 )
 
+// ErrInsufficientBalance is returned when a user balance no longer covers a deduction
+var ErrInsufficientBalance = errors.New("user balance insufficient")
+
+// ErrCreditLimitExceeded is returned when an invoiced order would take an enterprise account over its credit limit
+var ErrCreditLimitExceeded = errors.New("user credit limit exceeded")
+
 type SubscribeDetails struct {
-	Id          int64                `gorm:"primarykey"`
-	UserId      int64                `gorm:"index:idx_user_id;not null;comment:User ID"`
-	User        *User                `gorm:"foreignKey:UserId;references:Id"`
-	OrderId     int64                `gorm:"index:idx_order_id;not null;comment:Order ID"`
-	SubscribeId int64                `gorm:"index:idx_subscribe_id;not null;comment:Subscription ID"`
-	Subscribe   *subscribe.Subscribe `gorm:"foreignKey:SubscribeId;references:Id"`
-	StartTime   time.Time            `gorm:"default:CURRENT_TIMESTAMP(3);not null;comment:Subscription Start Time"`
-	ExpireTime  time.Time            `gorm:"default:NULL;comment:Subscription Expire Time"`
-	FinishedAt  *time.Time           `gorm:"default:NULL;comment:Finished Time"`
-	Traffic     int64                `gorm:"default:0;comment:Traffic"`
-	Download    int64                `gorm:"default:0;comment:Download Traffic"`
-	Upload      int64                `gorm:"default:0;comment:Upload Traffic"`
-	Token       string               `gorm:"index:idx_token;unique;type:varchar(255);default:'';comment:Token"`
-	UUID        string               `gorm:"type:varchar(255);unique;index:idx_uuid;default:'';comment:UUID"`
-	Status      uint8                `gorm:"type:tinyint(1);default:0;comment:Subscription Status: 0: Pending 1: Active 2: Finished 3: Expired; 4: Cancelled"`
-	Note        string               `gorm:"type:varchar(500);default:'';comment:User note for subscription"`
-	CreatedAt   time.Time            `gorm:"<-:create;comment:Creation Time"`
-	UpdatedAt   time.Time            `gorm:"comment:Update Time"`
+	Id           int64                `gorm:"primarykey"`
+	UserId       int64                `gorm:"index:idx_user_id;not null;comment:User ID"`
+	User         *User                `gorm:"foreignKey:UserId;references:Id"`
+	OrderId      int64                `gorm:"index:idx_order_id;not null;comment:Order ID"`
+	SubscribeId  int64                `gorm:"index:idx_subscribe_id;not null;comment:Subscription ID"`
+	Subscribe    *subscribe.Subscribe `gorm:"foreignKey:SubscribeId;references:Id"`
+	StartTime    time.Time            `gorm:"default:CURRENT_TIMESTAMP(3);not null;comment:Subscription Start Time"`
+	ExpireTime   time.Time            `gorm:"default:NULL;comment:Subscription Expire Time"`
+	FinishedAt   *time.Time           `gorm:"default:NULL;comment:Finished Time"`
+	Traffic      int64                `gorm:"default:0;comment:Traffic"`
+	Download     int64                `gorm:"default:0;comment:Download Traffic"`
+	Upload       int64                `gorm:"default:0;comment:Upload Traffic"`
+	Token        string               `gorm:"index:idx_token;unique;type:varchar(255);default:'';comment:Token"`
+	UUID         string               `gorm:"type:varchar(255);unique;index:idx_uuid;default:'';comment:UUID"`
+	Status       uint8                `gorm:"type:tinyint(1);default:0;comment:Subscription Status: 0: Pending 1: Active 2: Finished 3: Expired; 4: Cancelled"`
+	ExcludeNodes string               `gorm:"type:varchar(255);not null;default:'';comment:Excluded Node Ids"`
+	IncludeNodes string               `gorm:"type:varchar(255);not null;default:'';comment:Force Included Node Ids"`
+	Note         string               `gorm:"type:varchar(500);default:'';comment:User note for subscription"`
+	Alias        string               `gorm:"type:varchar(64);not null;default:'';comment:User Set Subscription Name"`
+	PausedAt     *time.Time           `gorm:"default:NULL;comment:Paused Time"`
+	PausedFor    int64                `gorm:"type:bigint;not null;default:0;comment:Remaining Seconds Kept While Paused"`
+	PauseCount   int64                `gorm:"type:int;not null;default:0;comment:Pause Count"`
+	BonusNodes   string               `gorm:"type:varchar(255);not null;default:'';comment:Bonus Node Ids"`
+	BonusExpire  *time.Time           `gorm:"default:NULL;comment:Bonus Nodes Expire Time"`
+	CreatedAt    time.Time            `gorm:"<-:create;comment:Creation Time"`
+	UpdatedAt    time.Time            `gorm:"comment:Update Time"`
 }
 
 type SubscribeLogFilterParams struct {
@@ -81,6 +96,11 @@ type customUserLogicModel interface {
 	FindOneUserSubscribe(ctx context.Context, id int64) (*SubscribeDetails, error)
 	FindUsersSubscribeBySubscribeId(ctx context.Context, subscribeId int64) ([]*Subscribe, error)
 	UpdateUserSubscribeWithTraffic(ctx context.Context, id, download, upload int64, tx ...*gorm.DB) error
+	DeductBalance(ctx context.Context, data *User, gift, promoCredit, loyaltyCredit int64, tx ...*gorm.DB) error
+	PayFromBalance(ctx context.Context, data *User, gift, balance int64, tx ...*gorm.DB) error
+	AddSubscribeTraffic(ctx context.Context, id, traffic int64, tx ...*gorm.DB) error
+	AddOutstandingBalance(ctx context.Context, data *User, amount int64, tx ...*gorm.DB) error
+	SettleOutstandingBalance(ctx context.Context, data *User, amount int64, tx ...*gorm.DB) error
 	QueryResisterUserTotalByDate(ctx context.Context, date time.Time) (int64, error)
 	QueryResisterUserTotalByMonthly(ctx context.Context, date time.Time) (int64, error)
 	QueryResisterUserTotal(ctx context.Context) (int64, error)
@@ -199,6 +219,120 @@ func (m *customUserModel) UpdateUserSubscribeWithTraffic(ctx context.Context, id
 	})
 }
 
+// AddSubscribeTraffic atomically adds traffic to a limited subscription, download and upload reported meanwhile
+// are kept. A subscription the traffic check finished (status 2) is active again when the new traffic covers
+// its usage and it has not expired.
+func (m *customUserModel) AddSubscribeTraffic(ctx context.Context, id, traffic int64, tx ...*gorm.DB) error {
+	sub, err := m.FindOneSubscribe(ctx, id)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = m.ClearSubscribeCacheByModels(ctx, sub)
+	}()
+
+	return m.ExecNoCacheCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		if err := conn.Model(&Subscribe{}).Where("`id` = ? AND `traffic` > 0", id).
+			UpdateColumn("traffic", gorm.Expr("`traffic` + ?", traffic)).Error; err != nil {
+			return err
+		}
+		return conn.Model(&Subscribe{}).
+			Where("`id` = ? AND `status` = 2 AND `finished_at` IS NOT NULL AND `upload` + `download` < `traffic`", id).
+			Where("(`expire_time` = ? OR `expire_time` > ?)", time.UnixMilli(0), time.Now()).
+			Updates(map[string]interface{}{
+				"status":      1,
+				"finished_at": nil,
+			}).Error
+	})
+}
+
+// DeductBalance atomically takes the gift amount, promo credit and loyalty credit of an order from the user and
+// returns ErrInsufficientBalance when one of them no longer covers its part, e.g. spent by a concurrent order.
+// On success the balances of data are set to what is left.
+func (m *customUserModel) DeductBalance(ctx context.Context, data *User, gift, promoCredit, loyaltyCredit int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&User{}).
+			Where("`id` = ? AND `gift_amount` >= ? AND `promo_credit` >= ? AND `loyalty_credit` >= ?", data.Id, gift, promoCredit, loyaltyCredit).
+			UpdateColumns(map[string]interface{}{
+				"gift_amount":    gorm.Expr("`gift_amount` - ?", gift),
+				"promo_credit":   gorm.Expr("`promo_credit` - ?", promoCredit),
+				"loyalty_credit": gorm.Expr("`loyalty_credit` - ?", loyaltyCredit),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientBalance
+		}
+		return conn.Model(&User{}).Select("gift_amount", "promo_credit", "loyalty_credit").Where("`id` = ?", data.Id).Take(data).Error
+	}, m.getCacheKeys(data)...)
+}
+
+// PayFromBalance atomically takes a balance payment from the gift amount and the balance of the user and
+// returns ErrInsufficientBalance when one of them no longer covers its part. On success the balances of data
+// are set to what is left.
+func (m *customUserModel) PayFromBalance(ctx context.Context, data *User, gift, balance int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&User{}).
+			Where("`id` = ? AND `gift_amount` >= ? AND `balance` >= ?", data.Id, gift, balance).
+			UpdateColumns(map[string]interface{}{
+				"gift_amount": gorm.Expr("`gift_amount` - ?", gift),
+				"balance":     gorm.Expr("`balance` - ?", balance),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrInsufficientBalance
+		}
+		return conn.Model(&User{}).Select("gift_amount", "balance").Where("`id` = ?", data.Id).Take(data).Error
+	}, m.getCacheKeys(data)...)
+}
+
+// AddOutstandingBalance atomically charges an invoiced order to an enterprise account, ErrCreditLimitExceeded is
+// returned when the account is not an enterprise one or the amount would take it over its credit limit.
+func (m *customUserModel) AddOutstandingBalance(ctx context.Context, data *User, amount int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		result := conn.Model(&User{}).
+			Where("`id` = ? AND `enterprise` = ? AND `outstanding_balance` + ? <= `credit_limit`", data.Id, true, amount).
+			UpdateColumn("outstanding_balance", gorm.Expr("`outstanding_balance` + ?", amount))
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrCreditLimitExceeded
+		}
+		return conn.Model(&User{}).Select("outstanding_balance").Where("`id` = ?", data.Id).Take(data).Error
+	}, m.getCacheKeys(data)...)
+}
+
+// SettleOutstandingBalance atomically takes a paid invoice off the outstanding balance, never below zero.
+func (m *customUserModel) SettleOutstandingBalance(ctx context.Context, data *User, amount int64, tx ...*gorm.DB) error {
+	return m.ExecCtx(ctx, func(conn *gorm.DB) error {
+		if len(tx) > 0 {
+			conn = tx[0]
+		}
+		err := conn.Model(&User{}).Where("`id` = ?", data.Id).
+			UpdateColumn("outstanding_balance", gorm.Expr("GREATEST(`outstanding_balance` - ?, 0)", amount)).Error
+		if err != nil {
+			return err
+		}
+		return conn.Model(&User{}).Select("outstanding_balance").Where("`id` = ?", data.Id).Take(data).Error
+	}, m.getCacheKeys(data)...)
+}
+
 func (m *customUserModel) QueryResisterUserTotalByDate(ctx context.Context, date time.Time) (int64, error) {
 	var total int64
 	start := date.Truncate(24 * time.Hour)
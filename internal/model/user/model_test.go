@@ -0,0 +1,169 @@
+package user
+
+import (
+	"context"
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+// The balance tests are opt-in integration tests, the conditional update needs a real MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./internal/model/user/
+func newBalanceTestModel(t *testing.T, gift, loyalty int64) (Model, *User) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = db.AutoMigrate(&User{}); err != nil {
+		t.Fatal(err)
+	}
+	mr := miniredis.RunT(t)
+	m := NewModel(db, redis.NewClient(&redis.Options{Addr: mr.Addr()}))
+
+	ctx := context.Background()
+	u := &User{Password: t.Name(), GiftAmount: gift, LoyaltyCredit: loyalty}
+	if err = m.Insert(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = db.Unscoped().Delete(&User{}, u.Id).Error
+	})
+	return m, u
+}
+
+// TestDeductBalanceConcurrent runs many orders at once draining the same gift amount, each one read the
+// full balance before deducting and some of them fail afterwards and roll back.
+func TestDeductBalanceConcurrent(t *testing.T) {
+	const (
+		balance   = 1000
+		deduction = 30
+		workers   = 50
+	)
+	m, u := newBalanceTestModel(t, balance, 0)
+	ctx := context.Background()
+	errOrderFailed := errors.New("order failed")
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		success int
+		failed  int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			stale := &User{Id: u.Id, GiftAmount: balance}
+			err := m.Transaction(ctx, func(tx *gorm.DB) error {
+				if err := m.DeductBalance(ctx, stale, deduction, 0, 0, tx); err != nil {
+					return err
+				}
+				// a failing order rolls back the deduction it took
+				if i%10 == 0 {
+					return errOrderFailed
+				}
+				return nil
+			})
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				success++
+			case errors.Is(err, ErrInsufficientBalance), errors.Is(err, errOrderFailed):
+				failed++
+			default:
+				t.Error(err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := m.FindOne(ctx, u.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// rolled back orders may leave some of the balance unspent, but no more deductions succeed than it
+	// covers and exactly what they took is gone
+	assert.LessOrEqual(t, success, balance/deduction)
+	assert.Equal(t, workers-success, failed)
+	assert.Equal(t, int64(balance-success*deduction), data.GiftAmount)
+	assert.GreaterOrEqual(t, data.GiftAmount, int64(0))
+}
+
+func TestDeductBalance(t *testing.T) {
+	m, u := newBalanceTestModel(t, 100, 50)
+	ctx := context.Background()
+
+	assert.NoError(t, m.DeductBalance(ctx, u, 60, 0, 20))
+	assert.Equal(t, int64(40), u.GiftAmount)
+	assert.Equal(t, int64(30), u.LoyaltyCredit)
+
+	// one bucket too low rejects the whole deduction
+	assert.ErrorIs(t, m.DeductBalance(ctx, u, 10, 0, 40), ErrInsufficientBalance)
+	assert.ErrorIs(t, m.DeductBalance(ctx, u, 10, 1, 0), ErrInsufficientBalance)
+
+	data, err := m.FindOne(ctx, u.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(40), data.GiftAmount)
+	assert.Equal(t, int64(30), data.LoyaltyCredit)
+}
+
+func TestPayFromBalance(t *testing.T) {
+	m, u := newBalanceTestModel(t, 100, 0)
+	ctx := context.Background()
+	u.Balance = 50
+	if err := m.Update(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.NoError(t, m.PayFromBalance(ctx, u, 100, 20))
+	assert.Equal(t, int64(0), u.GiftAmount)
+	assert.Equal(t, int64(30), u.Balance)
+	// a stale read that expects more than is left takes nothing
+	assert.ErrorIs(t, m.PayFromBalance(ctx, u, 10, 10), ErrInsufficientBalance)
+
+	data, err := m.FindOne(ctx, u.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, int64(0), data.GiftAmount)
+	assert.Equal(t, int64(30), data.Balance)
+}
+
+func TestOutstandingBalance(t *testing.T) {
+	m, u := newBalanceTestModel(t, 0, 0)
+	ctx := context.Background()
+
+	// only enterprise accounts are charged
+	assert.ErrorIs(t, m.AddOutstandingBalance(ctx, u, 10), ErrCreditLimitExceeded)
+
+	enterprise := true
+	u.Enterprise = &enterprise
+	u.CreditLimit = 100
+	if err := m.Update(ctx, u); err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, m.AddOutstandingBalance(ctx, u, 60))
+	assert.NoError(t, m.AddOutstandingBalance(ctx, u, 40))
+	assert.Equal(t, int64(100), u.OutstandingBalance)
+	assert.ErrorIs(t, m.AddOutstandingBalance(ctx, u, 1), ErrCreditLimitExceeded)
+
+	assert.NoError(t, m.SettleOutstandingBalance(ctx, u, 60))
+	assert.Equal(t, int64(40), u.OutstandingBalance)
+	// settling more than is owed leaves nothing, never a credit
+	assert.NoError(t, m.SettleOutstandingBalance(ctx, u, 60))
+	assert.Equal(t, int64(0), u.OutstandingBalance)
+}
@@ -3,6 +3,7 @@ package user
 import (
 	"time"
 
+	"github.com/perfect-panel/server/pkg/tool"
 	"gorm.io/gorm"
 )
 
@@ -19,6 +20,12 @@ type User struct {
 	ReferralPercentage    uint8          `gorm:"default:0;comment:Referral"`                        // Referral Percentage
 	OnlyFirstPurchase     *bool          `gorm:"default:true;not null;comment:Only First Purchase"` // Only First Purchase Referral
 	GiftAmount            int64          `gorm:"default:0;comment:User Gift Amount"`
+	LoyaltyCredit         int64          `gorm:"default:0;comment:User Loyalty Credit"` // Only usable on renewals
+	PromoCredit           int64          `gorm:"default:0;comment:User Promo Credit"`   // Spent before GiftAmount, zeroed when it expires
+	PromoCreditExpiredAt  *time.Time     `gorm:"default:null;comment:Promo Credit Expire Time"`
+	Enterprise            *bool          `gorm:"default:false;not null;comment:Enterprise Account, May Pay By Invoice"`
+	CreditLimit           int64          `gorm:"default:0;comment:Enterprise Credit Limit"`
+	OutstandingBalance    int64          `gorm:"default:0;comment:Enterprise Invoiced Amount Not Yet Paid"` // Orders awaiting their invoice
 	Enable                *bool          `gorm:"default:true;not null;comment:Is Account Enabled"`
 	IsAdmin               *bool          `gorm:"default:false;not null;comment:Is Admin"`
 	EnableBalanceNotify   *bool          `gorm:"default:false;not null;comment:Enable Balance Change Notifications"`
@@ -37,30 +44,90 @@ func (*User) TableName() string {
 	return "user"
 }
 
+// AvailablePromoCredit returns the promo credit that can still be spent, nothing once it expired
+// even before the expiry task zeroed it.
+func (u *User) AvailablePromoCredit(now time.Time) int64 {
+	if u.PromoCredit <= 0 || (u.PromoCreditExpiredAt != nil && !u.PromoCreditExpiredAt.After(now)) {
+		return 0
+	}
+	return u.PromoCredit
+}
+
 type Subscribe struct {
-	Id          int64      `gorm:"primaryKey"`
-	UserId      int64      `gorm:"index:idx_user_id;not null;comment:User ID"`
-	User        User       `gorm:"foreignKey:UserId;references:Id"`
-	OrderId     int64      `gorm:"index:idx_order_id;not null;comment:Order ID"`
-	SubscribeId int64      `gorm:"index:idx_subscribe_id;not null;comment:Subscription ID"`
-	StartTime   time.Time  `gorm:"default:CURRENT_TIMESTAMP(3);not null;comment:Subscription Start Time"`
-	ExpireTime  time.Time  `gorm:"default:NULL;comment:Subscription Expire Time"`
-	FinishedAt  *time.Time `gorm:"default:NULL;comment:Finished Time"`
-	Traffic     int64      `gorm:"default:0;comment:Traffic"`
-	Download    int64      `gorm:"default:0;comment:Download Traffic"`
-	Upload      int64      `gorm:"default:0;comment:Upload Traffic"`
-	Token       string     `gorm:"index:idx_token;unique;type:varchar(255);default:'';comment:Token"`
-	UUID        string     `gorm:"type:varchar(255);unique;index:idx_uuid;default:'';comment:UUID"`
-	Status      uint8      `gorm:"type:tinyint(1);default:0;comment:Subscription Status: 0: Pending 1: Active 2: Finished 3: Expired 4: Deducted 5: stopped"`
-	Note        string     `gorm:"type:varchar(500);default:'';comment:User note for subscription"`
-	CreatedAt   time.Time  `gorm:"<-:create;comment:Creation Time"`
-	UpdatedAt   time.Time  `gorm:"comment:Update Time"`
+	Id           int64      `gorm:"primaryKey"`
+	UserId       int64      `gorm:"index:idx_user_id;not null;comment:User ID"`
+	User         User       `gorm:"foreignKey:UserId;references:Id"`
+	OrderId      int64      `gorm:"index:idx_order_id;not null;comment:Order ID"`
+	SubscribeId  int64      `gorm:"index:idx_subscribe_id;not null;comment:Subscription ID"`
+	StartTime    time.Time  `gorm:"default:CURRENT_TIMESTAMP(3);not null;comment:Subscription Start Time"`
+	ExpireTime   time.Time  `gorm:"default:NULL;comment:Subscription Expire Time"`
+	FinishedAt   *time.Time `gorm:"default:NULL;comment:Finished Time"`
+	Traffic      int64      `gorm:"default:0;comment:Traffic"`
+	Download     int64      `gorm:"default:0;comment:Download Traffic"`
+	Upload       int64      `gorm:"default:0;comment:Upload Traffic"`
+	Token        string     `gorm:"index:idx_token;unique;type:varchar(255);default:'';comment:Token"`
+	UUID         string     `gorm:"type:varchar(255);unique;index:idx_uuid;default:'';comment:UUID"`
+	Status       uint8      `gorm:"type:tinyint(1);default:0;comment:Subscription Status: 0: Pending 1: Active 2: Finished 3: Expired 4: Deducted 5: stopped 6: Paused"`
+	ExcludeNodes string     `gorm:"type:varchar(255);not null;default:'';comment:Excluded Node Ids"`
+	IncludeNodes string     `gorm:"type:varchar(255);not null;default:'';comment:Force Included Node Ids"`
+	Note         string     `gorm:"type:varchar(500);default:'';comment:User note for subscription"`
+	Alias        string     `gorm:"type:varchar(64);not null;default:'';comment:User Set Subscription Name"`
+	PausedAt     *time.Time `gorm:"default:NULL;comment:Paused Time"`
+	PausedFor    int64      `gorm:"type:bigint;not null;default:0;comment:Remaining Seconds Kept While Paused"`
+	PauseCount   int64      `gorm:"type:int;not null;default:0;comment:Pause Count"`
+	BonusNodes   string     `gorm:"type:varchar(255);not null;default:'';comment:Bonus Node Ids"`
+	BonusExpire  *time.Time `gorm:"default:NULL;comment:Bonus Nodes Expire Time"`
+	CreatedAt    time.Time  `gorm:"<-:create;comment:Creation Time"`
+	UpdatedAt    time.Time  `gorm:"comment:Update Time"`
 }
 
 func (*Subscribe) TableName() string {
 	return "user_subscribe"
 }
 
+// Subscription status, see Subscribe.Status
+const (
+	// SubscribeStatusPending a subscription created for an order that is not activated yet
+	SubscribeStatusPending uint8 = 0
+	// SubscribeStatusActive a subscription in service
+	SubscribeStatusActive uint8 = 1
+	// SubscribeStatusFinished a subscription that used up its traffic, it is active again once the traffic
+	// is reset or topped up
+	SubscribeStatusFinished uint8 = 2
+	// SubscribeStatusExpired a subscription past its expire time
+	SubscribeStatusExpired uint8 = 3
+	// SubscribeStatusDeducted a subscription the user cancelled, its remaining value was refunded
+	SubscribeStatusDeducted uint8 = 4
+	// SubscribeStatusStopped a subscription an admin stopped
+	SubscribeStatusStopped uint8 = 5
+	// SubscribeStatusPaused a subscription the user paused, it keeps its remaining time until it is resumed
+	SubscribeStatusPaused uint8 = 6
+)
+
+// Pause takes the subscription out of service and keeps the time it had left.
+func (s *Subscribe) Pause(now time.Time) {
+	s.PausedFor = int64(s.ExpireTime.Sub(now).Seconds())
+	s.PausedAt = &now
+	s.PauseCount++
+	s.Status = SubscribeStatusPaused
+}
+
+// Resume puts a paused subscription back in service with the time it had left at its pause.
+func (s *Subscribe) Resume(now time.Time) {
+	s.ExpireTime = now.Add(time.Duration(s.PausedFor) * time.Second)
+	s.PausedAt = nil
+	s.PausedFor = 0
+	s.Status = 1
+}
+
+// ActiveBonusNodes returns the bonus node ids until the bonus expires, expired bonus nodes are kept but ignored.
+func (s *Subscribe) ActiveBonusNodes(now time.Time) []int64 {
+	if s.BonusExpire == nil || !now.Before(*s.BonusExpire) {
+		return nil
+	}
+	return tool.StringToInt64Slice(s.BonusNodes)
+}
+
 type AuthMethods struct {
 	Id             int64     `gorm:"primaryKey"`
 	UserId         int64     `gorm:"index:idx_user_id;not null;comment:User ID"`
@@ -130,6 +130,8 @@ func (m *Service) Stop() {
 	if err := m.server.Shutdown(ctx); err != nil {
 		logger.Errorf("server shutdown error: %s", err.Error())
 	}
+	// the handlers are done, write the buffered subscribe logs
+	m.svc.LogWriter.Close()
 	logger.Info("server shutdown")
 }
 
@@ -0,0 +1,80 @@
+package svc
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// DatacenterLookup reports whether an address belongs to a datacenter or hosting network,
+// asn is the AS number resolved for the address or 0 when unknown.
+type DatacenterLookup interface {
+	IsDatacenter(ip string, asn uint) bool
+}
+
+// DatacenterList is a DatacenterLookup backed by a list with one CIDR or AS number (AS16509) per line,
+// blank lines and lines starting with # are skipped.
+type DatacenterList struct {
+	nets []*net.IPNet
+	asns map[uint]struct{}
+}
+
+func LoadDatacenterList(path string) (*DatacenterList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseDatacenterList(f)
+}
+
+func ParseDatacenterList(r io.Reader) (*DatacenterList, error) {
+	list := &DatacenterList{asns: make(map[uint]struct{})}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if upper := strings.ToUpper(line); strings.HasPrefix(upper, "AS") {
+			asn, err := strconv.ParseUint(upper[2:], 10, 32)
+			if err != nil {
+				return nil, err
+			}
+			list.asns[uint(asn)] = struct{}{}
+			continue
+		}
+		if !strings.Contains(line, "/") {
+			if strings.Contains(line, ":") {
+				line += "/128"
+			} else {
+				line += "/32"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(line)
+		if err != nil {
+			return nil, err
+		}
+		list.nets = append(list.nets, ipNet)
+	}
+	return list, scanner.Err()
+}
+
+func (l *DatacenterList) IsDatacenter(ip string, asn uint) bool {
+	if _, ok := l.asns[asn]; ok && asn != 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipNet := range l.nets {
+		if ipNet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,35 @@
+package svc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDatacenterList(t *testing.T) {
+	list, err := ParseDatacenterList(strings.NewReader(`
+# hosting ranges
+10.0.0.0/8
+192.0.2.7
+2001:db8::/32
+AS16509
+as14061
+`))
+	require.NoError(t, err)
+
+	assert.True(t, list.IsDatacenter("10.1.2.3", 0))
+	assert.True(t, list.IsDatacenter("192.0.2.7", 0))
+	assert.False(t, list.IsDatacenter("192.0.2.8", 0))
+	assert.True(t, list.IsDatacenter("2001:db8::1", 0))
+	assert.True(t, list.IsDatacenter("203.0.113.1", 16509))
+	assert.True(t, list.IsDatacenter("203.0.113.1", 14061))
+	assert.False(t, list.IsDatacenter("203.0.113.1", 0))
+	assert.False(t, list.IsDatacenter("invalid", 0))
+
+	_, err = ParseDatacenterList(strings.NewReader("not-a-range"))
+	assert.Error(t, err)
+	_, err = ParseDatacenterList(strings.NewReader("ASxyz"))
+	assert.Error(t, err)
+}
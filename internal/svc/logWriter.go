@@ -0,0 +1,96 @@
+package svc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/pkg/logger"
+)
+
+// LogWriter buffers system logs written on hot paths and inserts them in batches,
+// a full batch is written at once and a partial one after the flush delay.
+// Without a batch size every log is inserted synchronously.
+type LogWriter struct {
+	model log.Model
+	size  int
+	delay time.Duration
+
+	mu     sync.RWMutex
+	closed bool
+	logs   chan *log.SystemLog
+	done   chan struct{}
+}
+
+func NewLogWriter(model log.Model, size int, delay time.Duration) *LogWriter {
+	w := &LogWriter{model: model, size: size, delay: delay}
+	if size <= 0 {
+		return w
+	}
+	if w.delay <= 0 {
+		w.delay = 5 * time.Second
+	}
+	w.logs = make(chan *log.SystemLog, size*4)
+	w.done = make(chan struct{})
+	go w.run()
+	return w
+}
+
+// Write queues the log, it is inserted right away when buffering is off, the writer is closed or the buffer is full.
+func (w *LogWriter) Write(ctx context.Context, data *log.SystemLog) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.logs != nil && !w.closed {
+		select {
+		case w.logs <- data:
+			return nil
+		default:
+		}
+	}
+	return w.model.Insert(ctx, data)
+}
+
+// Close writes the buffered logs and makes later writes synchronous.
+func (w *LogWriter) Close() {
+	w.mu.Lock()
+	if w.logs == nil || w.closed {
+		w.mu.Unlock()
+		return
+	}
+	w.closed = true
+	close(w.logs)
+	w.mu.Unlock()
+	<-w.done
+}
+
+func (w *LogWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.delay)
+	defer ticker.Stop()
+	batch := make([]*log.SystemLog, 0, w.size)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.model.InsertBatch(context.Background(), batch); err != nil {
+			logger.Errorw("[LogWriter] Insert log batch failed", logger.Field("error", err.Error()), logger.Field("count", len(batch)))
+		}
+		batch = make([]*log.SystemLog, 0, w.size)
+	}
+	for {
+		select {
+		case data, ok := <-w.logs:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, data)
+			if len(batch) >= w.size {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
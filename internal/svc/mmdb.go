@@ -2,6 +2,7 @@ package svc
 
 import (
 	"io"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,8 +14,22 @@ import (
 const GeoIPDBURL = "https://raw.githubusercontent.com/adysec/IP_database/main/geolite/GeoLite2-City.mmdb"
 
 type IPLocation struct {
-	Path string
-	DB   *geoip2.Reader
+	Path  string
+	DB    *geoip2.Reader
+	ASNDB *geoip2.Reader // optional GeoLite2-ASN database
+}
+
+// GeoInfo is the geographic information resolved for an IP address.
+type GeoInfo struct {
+	Country        string
+	CountryCode    string
+	ASN            uint
+	ASOrganization string
+}
+
+// GeoLookup resolves geographic information for an IP address.
+type GeoLookup interface {
+	Lookup(ip string) (*GeoInfo, error)
 }
 
 func NewIPLocation(path string) (*IPLocation, error) {
@@ -41,7 +56,49 @@ func NewIPLocation(path string) (*IPLocation, error) {
 	}, nil
 }
 
+// OpenASNDatabase attaches an ASN database to the location reader. An empty path leaves ASN lookups disabled.
+func (ipLoc *IPLocation) OpenASNDatabase(path string) error {
+	if path == "" {
+		return nil
+	}
+	db, err := geoip2.Open(path)
+	if err != nil {
+		return err
+	}
+	ipLoc.ASNDB = db
+	return nil
+}
+
+// Lookup implements GeoLookup. Missing databases or records yield empty fields rather than an error.
+func (ipLoc *IPLocation) Lookup(ip string) (*GeoInfo, error) {
+	info := &GeoInfo{}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return info, nil
+	}
+	if ipLoc.DB != nil {
+		record, err := ipLoc.DB.Country(addr)
+		if err != nil {
+			return info, err
+		}
+		info.Country = record.Country.Names["en"]
+		info.CountryCode = record.Country.IsoCode
+	}
+	if ipLoc.ASNDB != nil {
+		record, err := ipLoc.ASNDB.ASN(addr)
+		if err != nil {
+			return info, err
+		}
+		info.ASN = record.AutonomousSystemNumber
+		info.ASOrganization = record.AutonomousSystemOrganization
+	}
+	return info, nil
+}
+
 func (ipLoc *IPLocation) Close() error {
+	if ipLoc.ASNDB != nil {
+		_ = ipLoc.ASNDB.Close()
+	}
 	return ipLoc.DB.Close()
 }
 
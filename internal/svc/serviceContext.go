@@ -2,6 +2,7 @@ package svc
 
 import (
 	"context"
+	"time"
 
 	"github.com/perfect-panel/server/internal/model/client"
 	"github.com/perfect-panel/server/internal/model/node"
@@ -22,7 +23,9 @@ import (
 	"github.com/perfect-panel/server/internal/model/traffic"
 	"github.com/perfect-panel/server/internal/model/user"
 	"github.com/perfect-panel/server/pkg/limit"
+	"github.com/perfect-panel/server/pkg/logger"
 	"github.com/perfect-panel/server/pkg/nodeMultiplier"
+	"github.com/perfect-panel/server/pkg/objectstore"
 	"github.com/perfect-panel/server/pkg/orm"
 
 	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
@@ -38,11 +41,16 @@ type ServiceContext struct {
 	Queue        *asynq.Client
 	ExchangeRate float64
 	GeoIP        *IPLocation
+	GeoLookup    GeoLookup          // optional, nil when no geo database is available
+	Datacenter   DatacenterLookup   // optional, nil lets every fetch through
+	ObjectStore  *objectstore.Store // optional, nil when no object store is configured
+	TradeNo      TradeNoGenerator
 
 	//NodeCache   *cache.NodeCacheClient
 	AuthModel   auth.Model
 	AdsModel    ads.Model
 	LogModel    log.Model
+	LogWriter   *LogWriter // batches subscribe logs, closed on shutdown
 	NodeModel   node.Model
 	UserModel   user.Model
 	OrderModel  order.Model
@@ -74,10 +82,33 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		panic(err.Error())
 	}
 
-	// IP location initialize
+	// IP location initialize, geo enrichment is skipped when the database is unavailable
+	var geoLookup GeoLookup
 	geoIP, err := NewIPLocation("./cache/GeoLite2-City.mmdb")
 	if err != nil {
-		panic(err.Error())
+		logger.Errorf("[GeoIP] Failed to load database, geo lookup disabled: %v", err.Error())
+		geoIP = nil
+	} else {
+		if err = geoIP.OpenASNDatabase(c.GeoIP.ASNDatabase); err != nil {
+			logger.Errorf("[GeoIP] Failed to open ASN database, ASN lookup disabled: %v", err.Error())
+		}
+		geoLookup = geoIP
+	}
+
+	// datacenter checks fail open, they only run once an operator configured a list
+	var datacenter DatacenterLookup
+	if c.GeoIP.DatacenterList != "" {
+		list, err := LoadDatacenterList(c.GeoIP.DatacenterList)
+		if err != nil {
+			logger.Errorf("[GeoIP] Failed to load datacenter list, datacenter checks disabled: %v", err.Error())
+		} else {
+			datacenter = list
+		}
+	}
+
+	// system logs are keyed by the date in the configured timezone
+	if err = log.SetTimezone(c.Log.Timezone); err != nil {
+		logger.Errorf("[Log] Invalid timezone %q, falling back to UTC: %v", c.Log.Timezone, err.Error())
 	}
 
 	rds := redis.NewClient(&redis.Options{
@@ -99,6 +130,13 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		Queue:        NewAsynqClient(c),
 		ExchangeRate: 0,
 		GeoIP:        geoIP,
+		GeoLookup:    geoLookup,
+		Datacenter:   datacenter,
+		ObjectStore: objectstore.New(objectstore.Config{
+			Endpoint:      c.ObjectStore.Endpoint,
+			PublicURL:     c.ObjectStore.PublicURL,
+			Authorization: c.ObjectStore.Authorization,
+		}),
 		//NodeCache:   cache.NewNodeCacheClient(rds),
 		AuthLimiter: authLimiter,
 		AdsModel:    ads.NewModel(db, rds),
@@ -119,6 +157,8 @@ func NewServiceContext(c config.Config) *ServiceContext {
 		AnnouncementModel: announcement.NewModel(db, rds),
 	}
 	srv.DeviceManager = NewDeviceManager(srv)
+	srv.TradeNo = NewTradeNoGenerator(c.TradeNo, srv.OrderModel)
+	srv.LogWriter = NewLogWriter(srv.LogModel, c.Queue.SubscribeLogBatchSize, time.Duration(c.Queue.SubscribeLogFlushDelay)*time.Second)
 	return srv
 
 }
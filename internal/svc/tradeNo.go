@@ -0,0 +1,74 @@
+package svc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+)
+
+// TradeNoGenerator issues the numbers of new orders, they have to be unique across all instances.
+type TradeNoGenerator interface {
+	Generate(ctx context.Context) (string, error)
+}
+
+// NewTradeNoGenerator returns the generator configured in TradeNo.Generator, an unknown one falls back to random.
+func NewTradeNoGenerator(c config.TradeNo, orders order.Model) TradeNoGenerator {
+	switch c.Generator {
+	case config.TradeNoRandom, "":
+		return randomTradeNo{}
+	case config.TradeNoDaily:
+		return &dailyTradeNo{prefix: c.Prefix, orders: orders}
+	default:
+		logger.Errorf("[TradeNo] Unknown generator %q, falling back to %s", c.Generator, config.TradeNoRandom)
+		return randomTradeNo{}
+	}
+}
+
+// randomTradeNo is the default generator, see tool.GenerateTradeNo
+type randomTradeNo struct{}
+
+func (randomTradeNo) Generate(context.Context) (string, error) {
+	return tool.GenerateTradeNo(), nil
+}
+
+// dailyTradeNo numbers the orders of a day sequentially: the prefix, the date in the site's timezone, the
+// sequence padded to six digits and a Luhn check digit over date and sequence. The sequence is kept in the
+// database, so restarts and concurrent orders on several instances never reuse a number.
+type dailyTradeNo struct {
+	prefix string
+	orders order.Model
+}
+
+func (g *dailyTradeNo) Generate(ctx context.Context) (string, error) {
+	day := time.Now().In(log.Location()).Format("20060102")
+	seq, err := g.orders.NextSequence(ctx, day)
+	if err != nil {
+		return "", err
+	}
+	digits := fmt.Sprintf("%s%06d", day, seq)
+	return g.prefix + digits + string(luhnDigit(digits)), nil
+}
+
+// luhnDigit returns the Luhn check digit of a string of decimal digits
+func luhnDigit(digits string) byte {
+	var sum int
+	double := true
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return byte('0' + (10-sum%10)%10)
+}
@@ -0,0 +1,13 @@
+package svc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLuhnDigit(t *testing.T) {
+	assert.Equal(t, byte('3'), luhnDigit("7992739871"))
+	assert.Equal(t, byte('0'), luhnDigit("0"))
+	assert.Equal(t, byte('8'), luhnDigit("1"))
+}
@@ -6,10 +6,24 @@ type (
 		Token  string
 		Type   string
 		UA     string
+		Format string // client named by the format route, skips the user agent matching
 		Params map[string]string
+		// IfNoneMatch is the If-None-Match header, the entity tags of the configs the client holds
+		IfNoneMatch string
 	}
 	SubscribeResponse struct {
-		Config []byte
-		Header string
+		Config      []byte
+		Header      string
+		RedirectURL string // set for redirect mode clients, Config is empty then
+		NotModified bool   // the client holds the current config, Config is empty then
 	}
 )
+
+// SubscribeHealthResponse reports whether a subscription token would produce a usable config.
+type SubscribeHealthResponse struct {
+	Nodes      int64 `json:"nodes"`
+	Upload     int64 `json:"upload"`
+	Download   int64 `json:"download"`
+	Traffic    int64 `json:"traffic"`
+	ExpireTime int64 `json:"expire_time"`
+}
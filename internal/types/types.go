@@ -3,6 +3,22 @@
 
 package types
 
+type AdminAuditLog struct {
+	Action          uint16 `json:"action"`
+	ActorId         int64  `json:"actor_id"`
+	OrderNo         string `json:"order_no,omitempty"`
+	UserId          int64  `json:"user_id,omitempty"`
+	TargetUserId    int64  `json:"target_user_id,omitempty"`
+	UserSubscribeId int64  `json:"user_subscribe_id,omitempty"`
+	AmountBefore    int64  `json:"amount_before"`
+	AmountAfter     int64  `json:"amount_after"`
+	StatusBefore    uint8  `json:"status_before,omitempty"`
+	StatusAfter     uint8  `json:"status_after,omitempty"`
+	Coupon          string `json:"coupon,omitempty"`
+	Remark          string `json:"remark,omitempty"`
+	Timestamp       int64  `json:"timestamp"`
+}
+
 type Ads struct {
 	Id          int    `json:"id"`
 	Title       string `json:"title"`
@@ -197,6 +213,13 @@ type BindTelegramResponse struct {
 	ExpiredAt int64  `json:"expired_at"`
 }
 
+type BulkRenewalOrderRequest struct {
+	UserSubscribeIDs []int64 `json:"user_subscribe_ids" validate:"required"`
+	Quantity         int64   `json:"quantity" validate:"lte=1000"`
+	Payment          int64   `json:"payment"`
+	Coupon           string  `json:"coupon,omitempty"`
+}
+
 type CheckUserRequest struct {
 	Email string `form:"email" validate:"required"`
 }
@@ -218,6 +241,7 @@ type CheckVerificationCodeRespone struct {
 
 type CheckoutOrderRequest struct {
 	OrderNo   string `json:"orderNo"`
+	Payment   int64  `json:"payment,omitempty"`
 	ReturnUrl string `json:"returnUrl,omitempty"`
 }
 
@@ -231,6 +255,21 @@ type CloseOrderRequest struct {
 	OrderNo string `json:"orderNo" validate:"required"`
 }
 
+type ClosePendingOrderFailure struct {
+	OrderNo string `json:"order_no"`
+	Error   string `json:"error"`
+}
+
+type ClosePendingOrdersRequest struct {
+	UserId int64 `json:"user_id" validate:"required"`
+}
+
+type ClosePendingOrdersResponse struct {
+	Closed   int64                      `json:"closed"`
+	Skipped  int64                      `json:"skipped"`
+	Failures []ClosePendingOrderFailure `json:"failures"`
+}
+
 type CommissionLog struct {
 	Type      uint16 `json:"type"`
 	UserId    int64  `json:"user_id"`
@@ -244,21 +283,48 @@ type CommissionWithdrawRequest struct {
 	Content string `json:"content"`
 }
 
+type CompensateUserSubscribeRequest struct {
+	SubscribeIds  []int64 `json:"subscribe_ids"`
+	ExpireFrom    int64   `json:"expire_from"`
+	ExpireTo      int64   `json:"expire_to"`
+	Tag           string  `json:"tag"`
+	Duration      int64   `json:"duration" validate:"required,gt=0"`
+	ExpiredPolicy string  `json:"expired_policy" validate:"omitempty,oneof=expiry now"`
+	Reason        string  `json:"reason" validate:"max=255"`
+	DryRun        bool    `json:"dry_run"`
+}
+
+type CompensateUserSubscribeResponse struct {
+	Matched int64 `json:"matched"`
+	Updated int64 `json:"updated"`
+	Failed  int64 `json:"failed"`
+}
+
 type Coupon struct {
-	Id         int64   `json:"id"`
-	Name       string  `json:"name"`
-	Code       string  `json:"code"`
-	Count      int64   `json:"count"`
-	Type       uint8   `json:"type"`
-	Discount   int64   `json:"discount"`
-	StartTime  int64   `json:"start_time"`
-	ExpireTime int64   `json:"expire_time"`
-	UserLimit  int64   `json:"user_limit"`
-	Subscribe  []int64 `json:"subscribe"`
-	UsedCount  int64   `json:"used_count"`
-	Enable     bool    `json:"enable"`
-	CreatedAt  int64   `json:"created_at"`
-	UpdatedAt  int64   `json:"updated_at"`
+	Id         int64        `json:"id"`
+	Name       string       `json:"name"`
+	Code       string       `json:"code"`
+	Count      int64        `json:"count"`
+	Type       uint8        `json:"type"`
+	Discount   int64        `json:"discount"`
+	Tiers      []CouponTier `json:"tiers"`
+	StartTime  int64        `json:"start_time"`
+	ExpireTime int64        `json:"expire_time"`
+	UserLimit  int64        `json:"user_limit"`
+	Subscribe  []int64      `json:"subscribe"`
+	Payment    []int64      `json:"payment"`
+	Users      []int64      `json:"users"`
+	OrderTypes uint8        `json:"order_types"`
+	UsedCount  int64        `json:"used_count"`
+	Enable     bool         `json:"enable"`
+	AutoApply  bool         `json:"auto_apply"`
+	CreatedAt  int64        `json:"created_at"`
+	UpdatedAt  int64        `json:"updated_at"`
+}
+
+type CouponTier struct {
+	Quantity int64 `json:"quantity" validate:"gt=0"`
+	Discount int64 `json:"discount" validate:"gt=0"`
 }
 
 type CreateAdsRequest struct {
@@ -290,17 +356,22 @@ type CreateBatchSendEmailTaskRequest struct {
 }
 
 type CreateCouponRequest struct {
-	Name       string  `json:"name" validate:"required"`
-	Code       string  `json:"code,omitempty"`
-	Count      int64   `json:"count,omitempty"`
-	Type       uint8   `json:"type" validate:"required"`
-	Discount   int64   `json:"discount" validate:"required"`
-	StartTime  int64   `json:"start_time" validate:"required"`
-	ExpireTime int64   `json:"expire_time" validate:"required"`
-	UserLimit  int64   `json:"user_limit,omitempty"`
-	Subscribe  []int64 `json:"subscribe,omitempty"`
-	UsedCount  int64   `json:"used_count,omitempty"`
-	Enable     *bool   `json:"enable,omitempty"`
+	Name       string       `json:"name" validate:"required"`
+	Code       string       `json:"code,omitempty"`
+	Count      int64        `json:"count,omitempty"`
+	Type       uint8        `json:"type" validate:"required"`
+	Discount   int64        `json:"discount" validate:"required"`
+	Tiers      []CouponTier `json:"tiers,omitempty" validate:"omitempty,dive"`
+	StartTime  int64        `json:"start_time" validate:"required"`
+	ExpireTime int64        `json:"expire_time" validate:"required"`
+	UserLimit  int64        `json:"user_limit,omitempty"`
+	Subscribe  []int64      `json:"subscribe,omitempty"`
+	Payment    []int64      `json:"payment,omitempty"`
+	Users      []int64      `json:"users,omitempty"`
+	OrderTypes uint8        `json:"order_types" validate:"required,min=1,max=3"`
+	UsedCount  int64        `json:"used_count,omitempty"`
+	Enable     *bool        `json:"enable,omitempty"`
+	AutoApply  bool         `json:"auto_apply,omitempty"`
 }
 
 type CreateDocumentRequest struct {
@@ -311,13 +382,15 @@ type CreateDocumentRequest struct {
 }
 
 type CreateNodeRequest struct {
-	Name     string   `json:"name"`
-	Tags     []string `json:"tags,omitempty"`
-	Port     uint16   `json:"port"`
-	Address  string   `json:"address"`
-	ServerId int64    `json:"server_id"`
-	Protocol string   `json:"protocol"`
-	Enabled  *bool    `json:"enabled"`
+	Name        string   `json:"name"`
+	Tags        []string `json:"tags,omitempty"`
+	Port        uint16   `json:"port"`
+	Address     string   `json:"address"`
+	ServerId    int64    `json:"server_id"`
+	Protocol    string   `json:"protocol"`
+	TrafficRate float64  `json:"traffic_rate,omitempty" validate:"gte=0,lte=100"`
+	Weight      int64    `json:"weight"`
+	Enabled     *bool    `json:"enabled"`
 }
 
 type CreateOrderRequest struct {
@@ -338,16 +411,24 @@ type CreateOrderRequest struct {
 }
 
 type CreatePaymentMethodRequest struct {
-	Name        string      `json:"name" validate:"required"`
-	Platform    string      `json:"platform" validate:"required"`
-	Description string      `json:"description"`
-	Icon        string      `json:"icon,omitempty"`
-	Domain      string      `json:"domain,omitempty"`
-	Config      interface{} `json:"config" validate:"required"`
-	FeeMode     uint        `json:"fee_mode"`
-	FeePercent  int64       `json:"fee_percent,omitempty"`
-	FeeAmount   int64       `json:"fee_amount,omitempty"`
-	Enable      *bool       `json:"enable" validate:"required"`
+	Name            string      `json:"name" validate:"required"`
+	Platform        string      `json:"platform" validate:"required"`
+	Description     string      `json:"description"`
+	Icon            string      `json:"icon,omitempty"`
+	Domain          string      `json:"domain,omitempty"`
+	Config          interface{} `json:"config" validate:"required"`
+	FeeMode         uint        `json:"fee_mode"`
+	FeePercent      int64       `json:"fee_percent,omitempty"`
+	FeeAmount       int64       `json:"fee_amount,omitempty"`
+	DiscountPercent int64       `json:"discount_percent,omitempty" validate:"gte=0,lte=100"`
+	CloseStrategy   uint8       `json:"close_strategy,omitempty" validate:"oneof=0 1"`
+	HoldMinutes     int64       `json:"hold_minutes,omitempty" validate:"gte=0"`
+	MinRecharge     int64       `json:"min_recharge,omitempty" validate:"gte=0"`
+	MaxRecharge     int64       `json:"max_recharge,omitempty" validate:"gte=0"`
+	MinAmount       int64       `json:"min_amount,omitempty" validate:"gte=0"`
+	MaxAmount       int64       `json:"max_amount,omitempty" validate:"gte=0"`
+	AvailableHours  string      `json:"available_hours,omitempty"`
+	Enable          *bool       `json:"enable" validate:"required"`
 }
 
 type CreateQuotaTaskRequest struct {
@@ -361,6 +442,16 @@ type CreateQuotaTaskRequest struct {
 	GiftValue    uint64  `json:"gift_value"`
 }
 
+type CreateSandboxOrderRequest struct {
+	UserId          int64  `json:"user_id" validate:"required"`
+	Type            string `json:"type" validate:"required,oneof=purchase renewal recharge"`
+	SubscribeId     int64  `json:"subscribe_id,omitempty"`
+	UserSubscribeId int64  `json:"user_subscribe_id,omitempty"`
+	Quantity        int64  `json:"quantity,omitempty" validate:"omitempty,lte=1000"`
+	Amount          int64  `json:"amount,omitempty" validate:"omitempty,gte=0,lte=2000000000"`
+	Coupon          string `json:"coupon,omitempty"`
+}
+
 type CreateServerRequest struct {
 	Name      string     `json:"name"`
 	Country   string     `json:"country,omitempty"`
@@ -371,15 +462,35 @@ type CreateServerRequest struct {
 }
 
 type CreateSubscribeApplicationRequest struct {
-	Name              string       `json:"name"`
-	Description       string       `json:"description,omitempty"`
-	Icon              string       `json:"icon,omitempty"`
-	Scheme            string       `json:"scheme,omitempty"`
-	UserAgent         string       `json:"user_agent"`
-	IsDefault         bool         `json:"is_default"`
-	SubscribeTemplate string       `json:"template"`
-	OutputFormat      string       `json:"output_format"`
-	DownloadLink      DownloadLink `json:"download_link"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description,omitempty"`
+	Icon               string       `json:"icon,omitempty"`
+	Scheme             string       `json:"scheme,omitempty"`
+	UserAgent          string       `json:"user_agent"`
+	IsDefault          bool         `json:"is_default"`
+	SubscribeTemplate  string       `json:"template"`
+	OutputFormat       string       `json:"output_format"`
+	SupportedProtocols []string     `json:"supported_protocols,omitempty"`
+	ProtocolPriority   []string     `json:"protocol_priority,omitempty"`
+	RedirectMode       bool         `json:"redirect_mode"`
+	DownloadLink       DownloadLink `json:"download_link"`
+}
+
+type CreateSubscribeBundleRequest struct {
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	SubscribeId int64  `json:"subscribe_id" validate:"required"`
+	Quantity    int64  `json:"quantity" validate:"required,gt=0,lte=1000"`
+	Price       int64  `json:"price" validate:"gte=0"`
+	Credit      int64  `json:"credit" validate:"gte=0"`
+	Sell        bool   `json:"sell"`
+	Sort        int64  `json:"sort"`
+}
+
+type CreateSubscribeDemoResponse struct {
+	Token     string `json:"token"`
+	Url       string `json:"url"`
+	ExpiredAt int64  `json:"expired_at"`
 }
 
 type CreateSubscribeGroupRequest struct {
@@ -388,27 +499,41 @@ type CreateSubscribeGroupRequest struct {
 }
 
 type CreateSubscribeRequest struct {
-	Name              string              `json:"name" validate:"required"`
-	Language          string              `json:"language"`
-	Description       string              `json:"description"`
-	UnitPrice         int64               `json:"unit_price"`
-	UnitTime          string              `json:"unit_time"`
-	Discount          []SubscribeDiscount `json:"discount"`
-	Replacement       int64               `json:"replacement"`
-	Inventory         int64               `json:"inventory"`
-	Traffic           int64               `json:"traffic"`
-	SpeedLimit        int64               `json:"speed_limit"`
-	DeviceLimit       int64               `json:"device_limit"`
-	Quota             int64               `json:"quota"`
-	Nodes             []int64             `json:"nodes"`
-	NodeTags          []string            `json:"node_tags"`
-	Show              *bool               `json:"show"`
-	Sell              *bool               `json:"sell"`
-	DeductionRatio    int64               `json:"deduction_ratio"`
-	AllowDeduction    *bool               `json:"allow_deduction"`
-	ResetCycle        int64               `json:"reset_cycle"`
-	RenewalReset      *bool               `json:"renewal_reset"`
-	ShowOriginalPrice bool                `json:"show_original_price"`
+	Name                string              `json:"name" validate:"required"`
+	Language            string              `json:"language"`
+	Description         string              `json:"description"`
+	UnitPrice           int64               `json:"unit_price"`
+	UnitTime            string              `json:"unit_time"`
+	Discount            []SubscribeDiscount `json:"discount"`
+	DiscountInterpolate bool                `json:"discount_interpolate"`
+	Replacement         int64               `json:"replacement"`
+	TrafficReset        bool                `json:"traffic_reset"`
+	TrafficTopUp        bool                `json:"traffic_top_up"`
+	TopUpPrice          int64               `json:"top_up_price" validate:"gte=0"`
+	Inventory           int64               `json:"inventory"`
+	Traffic             int64               `json:"traffic"`
+	SpeedLimit          int64               `json:"speed_limit"`
+	DeviceLimit         int64               `json:"device_limit"`
+	Quota               int64               `json:"quota"`
+	Nodes               []int64             `json:"nodes"`
+	NodeTags            []string            `json:"node_tags"`
+	StickyNode          bool                `json:"sticky_node"`
+	DatacenterPolicy    uint8               `json:"datacenter_policy" validate:"lte=2"`
+	NodeSort            string              `json:"node_sort" validate:"omitempty,oneof=name tag weight"`
+	ExtraRules          string              `json:"extra_rules"`
+	SubscribeTemplate   string              `json:"subscribe_template"`
+	Show                *bool               `json:"show"`
+	Sell                *bool               `json:"sell"`
+	DeductionRatio      int64               `json:"deduction_ratio"`
+	AllowDeduction      *bool               `json:"allow_deduction"`
+	ResetCycle          int64               `json:"reset_cycle"`
+	RenewalReset        *bool               `json:"renewal_reset"`
+	MaxRenewalStack     int64               `json:"max_renewal_stack" validate:"gte=0"`
+	QuantityMode        uint8               `json:"quantity_mode" validate:"lte=1"`
+	Duration            int64               `json:"duration" validate:"gte=0"`
+	MinQuantity         int64               `json:"min_quantity" validate:"gte=0"`
+	DefaultQuantity     int64               `json:"default_quantity" validate:"gte=0"`
+	ShowOriginalPrice   bool                `json:"show_original_price"`
 }
 
 type CreateTicketFollowRequest struct {
@@ -466,9 +591,12 @@ type Currency struct {
 }
 
 type CurrencyConfig struct {
-	AccessKey      string `json:"access_key"`
-	CurrencyUnit   string `json:"currency_unit"`
-	CurrencySymbol string `json:"currency_symbol"`
+	AccessKey         string `json:"access_key"`
+	CurrencyUnit      string `json:"currency_unit"`
+	CurrencySymbol    string `json:"currency_symbol"`
+	RoundingIncrement int64  `json:"rounding_increment"`
+	MinRecharge       int64  `json:"min_recharge" validate:"gte=0"`
+	MaxRecharge       int64  `json:"max_recharge" validate:"gte=0"`
 }
 
 type DeleteAdsRequest struct {
@@ -503,6 +631,10 @@ type DeleteSubscribeApplicationRequest struct {
 	Id int64 `json:"id"`
 }
 
+type DeleteSubscribeBundleRequest struct {
+	Id int64 `json:"id" validate:"required"`
+}
+
 type DeleteSubscribeGroupRequest struct {
 	Id int64 `json:"id" validate:"required"`
 }
@@ -577,6 +709,16 @@ type EmailAuthticateConfig struct {
 	DomainSuffixList   string `json:"domain_suffix_list"`
 }
 
+type FilterAdminAuditLogRequest struct {
+	FilterLogParams
+	ActorId int64 `form:"actor_id,optional"`
+}
+
+type FilterAdminAuditLogResponse struct {
+	Total int64           `json:"total"`
+	List  []AdminAuditLog `json:"list"`
+}
+
 type FilterBalanceLogRequest struct {
 	FilterLogParams
 	UserId int64 `form:"user_id,optional"`
@@ -780,6 +922,10 @@ type GetAuthMethodListResponse struct {
 	List []AuthMethodConfig `json:"list"`
 }
 
+type GetAvailablePaymentMethodsRequest struct {
+	Amount int64 `form:"amount,omitempty" validate:"gte=0"`
+}
+
 type GetAvailablePaymentMethodsResponse struct {
 	List []PaymentMethod `json:"list"`
 }
@@ -966,6 +1112,11 @@ type GetSubscribeApplicationListResponse struct {
 	List  []SubscribeApplication `json:"list"`
 }
 
+type GetSubscribeBundleListResponse struct {
+	List  []SubscribeBundle `json:"list"`
+	Total int64             `json:"total"`
+}
+
 type GetSubscribeClientResponse struct {
 	Total int64             `json:"total"`
 	List  []SubscribeClient `json:"list"`
@@ -975,6 +1126,19 @@ type GetSubscribeDetailsRequest struct {
 	Id int64 `form:"id" validate:"required"`
 }
 
+type GetSubscribeDiscountRequest struct {
+	SubscribeId int64 `form:"subscribe_id" validate:"required"`
+	Quantity    int64 `form:"quantity,omitempty" validate:"omitempty,gt=0"`
+}
+
+type GetSubscribeDiscountResponse struct {
+	List      []SubscribeDiscount `json:"list"`
+	Quantity  int64               `json:"quantity,omitempty"`
+	Price     int64               `json:"price,omitempty"`
+	Amount    int64               `json:"amount,omitempty"`
+	UnitPrice int64               `json:"unit_price,omitempty"`
+}
+
 type GetSubscribeGroupListResponse struct {
 	List  []SubscribeGroup `json:"list"`
 	Total int64            `json:"total"`
@@ -1161,6 +1325,12 @@ type GoogleLoginCallbackRequest struct {
 	State string `form:"state"`
 }
 
+type GrantUserSubscribeBonusRequest struct {
+	UserSubscribeId int64   `json:"user_subscribe_id" validate:"required"`
+	NodeIds         []int64 `json:"node_ids" validate:"required,min=1"`
+	ExpireTime      int64   `json:"expire_time" validate:"required"`
+}
+
 type HasMigrateSeverNodeResponse struct {
 	HasMigrate bool `json:"has_migrate"`
 }
@@ -1180,15 +1350,36 @@ type Hysteria2 struct {
 }
 
 type InviteConfig struct {
-	ForcedInvite       bool  `json:"forced_invite"`
-	ReferralPercentage int64 `json:"referral_percentage"`
-	OnlyFirstPurchase  bool  `json:"only_first_purchase"`
+	ForcedInvite           bool  `json:"forced_invite"`
+	ReferralPercentage     int64 `json:"referral_percentage"`
+	OnlyFirstPurchase      bool  `json:"only_first_purchase"`
+	ReferralGiftPercentage int64 `json:"referral_gift_percentage" validate:"gte=0,lte=100"`
 }
 
 type KickOfflineRequest struct {
 	Id int64 `json:"id"`
 }
 
+type ListOrdersRequest struct {
+	Page      int64  `form:"page" validate:"required"`
+	Size      int64  `form:"size" validate:"required,lte=100"`
+	UserId    int64  `form:"user_id,omitempty"`
+	Type      uint8  `form:"type,omitempty"`
+	Status    uint8  `form:"status,omitempty"`
+	MinAmount *int64 `form:"min_amount,omitempty" validate:"omitempty,gte=0"`
+	MaxAmount *int64 `form:"max_amount,omitempty" validate:"omitempty,gte=0"`
+	StartTime int64  `form:"start_time,omitempty"`
+	EndTime   int64  `form:"end_time,omitempty"`
+}
+
+type ListOrdersResponse struct {
+	Total               int64   `json:"total"`
+	TotalAmount         int64   `json:"total_amount"`
+	TotalFee            int64   `json:"total_fee"`
+	TotalCouponDiscount int64   `json:"total_coupon_discount"`
+	List                []Order `json:"list"`
+}
+
 type LogResponse struct {
 	List interface{} `json:"list"`
 }
@@ -1211,6 +1402,32 @@ type LoginResponse struct {
 	Token string `json:"token"`
 }
 
+type MaintenanceConfig struct {
+	Maintenance       bool   `json:"maintenance"`
+	MaintenanceNotice string `json:"maintenance_notice"`
+}
+
+type MarkInvoicePaidRequest struct {
+	OrderNo string `json:"order_no" validate:"required"`
+}
+
+type MarkInvoicePaidResponse struct {
+	OrderNo            string `json:"order_no"`
+	OutstandingBalance int64  `json:"outstanding_balance"`
+}
+
+type MatchSubscribeApplicationRequest struct {
+	UserAgent string `form:"user_agent"`
+}
+
+type MatchSubscribeApplicationResponse struct {
+	Id           int64                            `json:"id"` // 匹配的客户端, 无匹配时为 0
+	Name         string                           `json:"name"`
+	Fallback     bool                             `json:"fallback"` // 是否使用默认客户端
+	OutputFormat string                           `json:"output_format"`
+	Evaluation   []SubscribeApplicationEvaluation `json:"evaluation"`
+}
+
 type MessageLog struct {
 	Id        int64       `json:"id"`
 	Type      uint8       `json:"type"`
@@ -1241,17 +1458,19 @@ type ModuleConfig struct {
 }
 
 type Node struct {
-	Id        int64    `json:"id"`
-	Name      string   `json:"name"`
-	Tags      []string `json:"tags"`
-	Port      uint16   `json:"port"`
-	Address   string   `json:"address"`
-	ServerId  int64    `json:"server_id"`
-	Protocol  string   `json:"protocol"`
-	Enabled   *bool    `json:"enabled"`
-	Sort      int      `json:"sort,omitempty"`
-	CreatedAt int64    `json:"created_at"`
-	UpdatedAt int64    `json:"updated_at"`
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Tags        []string `json:"tags"`
+	Port        uint16   `json:"port"`
+	Address     string   `json:"address"`
+	ServerId    int64    `json:"server_id"`
+	Protocol    string   `json:"protocol"`
+	TrafficRate float64  `json:"traffic_rate"`
+	Weight      int64    `json:"weight"`
+	Enabled     *bool    `json:"enabled"`
+	Sort        int      `json:"sort,omitempty"`
+	CreatedAt   int64    `json:"created_at"`
+	UpdatedAt   int64    `json:"updated_at"`
 }
 
 type NodeConfig struct {
@@ -1311,49 +1530,61 @@ type OnlineUsersRequest struct {
 }
 
 type Order struct {
-	Id             int64         `json:"id"`
-	UserId         int64         `json:"user_id"`
-	OrderNo        string        `json:"order_no"`
-	Type           uint8         `json:"type"`
-	Quantity       int64         `json:"quantity"`
-	Price          int64         `json:"price"`
-	Amount         int64         `json:"amount"`
-	GiftAmount     int64         `json:"gift_amount"`
-	Discount       int64         `json:"discount"`
-	Coupon         string        `json:"coupon"`
-	CouponDiscount int64         `json:"coupon_discount"`
-	Commission     int64         `json:"commission,omitempty"`
-	Payment        PaymentMethod `json:"payment"`
-	FeeAmount      int64         `json:"fee_amount"`
-	TradeNo        string        `json:"trade_no"`
-	Status         uint8         `json:"status"`
-	SubscribeId    int64         `json:"subscribe_id"`
-	CreatedAt      int64         `json:"created_at"`
-	UpdatedAt      int64         `json:"updated_at"`
+	Id                 int64             `json:"id"`
+	UserId             int64             `json:"user_id"`
+	OrderNo            string            `json:"order_no"`
+	Type               uint8             `json:"type"`
+	Quantity           int64             `json:"quantity"`
+	Price              int64             `json:"price"`
+	Amount             int64             `json:"amount"`
+	GiftAmount         int64             `json:"gift_amount"`
+	PromoCredit        int64             `json:"promo_credit"`
+	LoyaltyCredit      int64             `json:"loyalty_credit"`
+	Discount           int64             `json:"discount"`
+	Coupon             string            `json:"coupon"`
+	CouponDiscount     int64             `json:"coupon_discount"`
+	BonusDays          int64             `json:"bonus_days"`
+	PaymentDiscount    int64             `json:"payment_discount"`
+	Commission         int64             `json:"commission,omitempty"`
+	Payment            PaymentMethod     `json:"payment"`
+	FeeAmount          int64             `json:"fee_amount"`
+	RoundingAdjustment int64             `json:"rounding_adjustment"`
+	TradeNo            string            `json:"trade_no"`
+	Status             uint8             `json:"status"`
+	SubscribeId        int64             `json:"subscribe_id"`
+	Metadata           map[string]string `json:"metadata"`
+	CreatedAt          int64             `json:"created_at"`
+	UpdatedAt          int64             `json:"updated_at"`
 }
 
 type OrderDetail struct {
-	Id             int64         `json:"id"`
-	UserId         int64         `json:"user_id"`
-	OrderNo        string        `json:"order_no"`
-	Type           uint8         `json:"type"`
-	Quantity       int64         `json:"quantity"`
-	Price          int64         `json:"price"`
-	Amount         int64         `json:"amount"`
-	GiftAmount     int64         `json:"gift_amount"`
-	Discount       int64         `json:"discount"`
-	Coupon         string        `json:"coupon"`
-	CouponDiscount int64         `json:"coupon_discount"`
-	Commission     int64         `json:"commission,omitempty"`
-	Payment        PaymentMethod `json:"payment"`
-	Method         string        `json:"method"`
-	FeeAmount      int64         `json:"fee_amount"`
-	TradeNo        string        `json:"trade_no"`
-	Status         uint8         `json:"status"`
-	SubscribeId    int64         `json:"subscribe_id"`
-	Subscribe      Subscribe     `json:"subscribe"`
-	CreatedAt      int64         `json:"created_at"`
-	UpdatedAt      int64         `json:"updated_at"`
+	Id                 int64             `json:"id"`
+	UserId             int64             `json:"user_id"`
+	OrderNo            string            `json:"order_no"`
+	Type               uint8             `json:"type"`
+	Quantity           int64             `json:"quantity"`
+	Price              int64             `json:"price"`
+	Amount             int64             `json:"amount"`
+	GiftAmount         int64             `json:"gift_amount"`
+	PromoCredit        int64             `json:"promo_credit"`
+	LoyaltyCredit      int64             `json:"loyalty_credit"`
+	Discount           int64             `json:"discount"`
+	Coupon             string            `json:"coupon"`
+	CouponDiscount     int64             `json:"coupon_discount"`
+	BonusDays          int64             `json:"bonus_days"`
+	PaymentDiscount    int64             `json:"payment_discount"`
+	Commission         int64             `json:"commission,omitempty"`
+	Payment            PaymentMethod     `json:"payment"`
+	Method             string            `json:"method"`
+	FeeAmount          int64             `json:"fee_amount"`
+	RoundingAdjustment int64             `json:"rounding_adjustment"`
+	TradeNo            string            `json:"trade_no"`
+	Status             uint8             `json:"status"`
+	SubscribeId        int64             `json:"subscribe_id"`
+	Subscribe          Subscribe         `json:"subscribe"`
+	Metadata           map[string]string `json:"metadata"`
+	CreatedAt          int64             `json:"created_at"`
+	UpdatedAt          int64             `json:"updated_at"`
 }
 
 type OrdersStatistics struct {
@@ -1364,44 +1595,65 @@ type OrdersStatistics struct {
 	List               []OrdersStatistics `json:"list,omitempty"`
 }
 
+type PauseUserSubscribeRequest struct {
+	UserSubscribeId int64 `json:"user_subscribe_id" validate:"required"`
+}
+
 type PaymentConfig struct {
-	Id          int64       `json:"id" validate:"required"`
-	Name        string      `json:"name" validate:"required"`
-	Platform    string      `json:"platform" validate:"required"`
-	Description string      `json:"description"`
-	Icon        string      `json:"icon,omitempty"`
-	Domain      string      `json:"domain,omitempty"`
-	Config      interface{} `json:"config" validate:"required"`
-	FeeMode     uint        `json:"fee_mode"`
-	FeePercent  int64       `json:"fee_percent,omitempty"`
-	FeeAmount   int64       `json:"fee_amount,omitempty"`
-	Enable      *bool       `json:"enable" validate:"required"`
+	Id              int64       `json:"id" validate:"required"`
+	Name            string      `json:"name" validate:"required"`
+	Platform        string      `json:"platform" validate:"required"`
+	Description     string      `json:"description"`
+	Icon            string      `json:"icon,omitempty"`
+	Domain          string      `json:"domain,omitempty"`
+	Config          interface{} `json:"config" validate:"required"`
+	FeeMode         uint        `json:"fee_mode"`
+	FeePercent      int64       `json:"fee_percent,omitempty"`
+	FeeAmount       int64       `json:"fee_amount,omitempty"`
+	DiscountPercent int64       `json:"discount_percent,omitempty"`
+	CloseStrategy   uint8       `json:"close_strategy"`
+	HoldMinutes     int64       `json:"hold_minutes"`
+	MinRecharge     int64       `json:"min_recharge"`
+	MaxRecharge     int64       `json:"max_recharge"`
+	MinAmount       int64       `json:"min_amount"`
+	MaxAmount       int64       `json:"max_amount"`
+	AvailableHours  string      `json:"available_hours"`
+	Enable          *bool       `json:"enable" validate:"required"`
 }
 
 type PaymentMethod struct {
-	Id          int64  `json:"id"`
-	Name        string `json:"name"`
-	Platform    string `json:"platform"`
-	Description string `json:"description"`
-	Icon        string `json:"icon"`
-	FeeMode     uint   `json:"fee_mode"`
-	FeePercent  int64  `json:"fee_percent"`
-	FeeAmount   int64  `json:"fee_amount"`
+	Id              int64  `json:"id"`
+	Name            string `json:"name"`
+	Platform        string `json:"platform"`
+	Description     string `json:"description"`
+	Icon            string `json:"icon"`
+	FeeMode         uint   `json:"fee_mode"`
+	FeePercent      int64  `json:"fee_percent"`
+	FeeAmount       int64  `json:"fee_amount"`
+	DiscountPercent int64  `json:"discount_percent"`
 }
 
 type PaymentMethodDetail struct {
-	Id          int64       `json:"id"`
-	Name        string      `json:"name"`
-	Platform    string      `json:"platform"`
-	Description string      `json:"description"`
-	Icon        string      `json:"icon"`
-	Domain      string      `json:"domain"`
-	Config      interface{} `json:"config"`
-	FeeMode     uint        `json:"fee_mode"`
-	FeePercent  int64       `json:"fee_percent"`
-	FeeAmount   int64       `json:"fee_amount"`
-	Enable      bool        `json:"enable"`
-	NotifyURL   string      `json:"notify_url"`
+	Id              int64       `json:"id"`
+	Name            string      `json:"name"`
+	Platform        string      `json:"platform"`
+	Description     string      `json:"description"`
+	Icon            string      `json:"icon"`
+	Domain          string      `json:"domain"`
+	Config          interface{} `json:"config"`
+	FeeMode         uint        `json:"fee_mode"`
+	FeePercent      int64       `json:"fee_percent"`
+	FeeAmount       int64       `json:"fee_amount"`
+	DiscountPercent int64       `json:"discount_percent"`
+	CloseStrategy   uint8       `json:"close_strategy"`
+	HoldMinutes     int64       `json:"hold_minutes"`
+	MinRecharge     int64       `json:"min_recharge"`
+	MaxRecharge     int64       `json:"max_recharge"`
+	MinAmount       int64       `json:"min_amount"`
+	MaxAmount       int64       `json:"max_amount"`
+	AvailableHours  string      `json:"available_hours"`
+	Enable          bool        `json:"enable"`
+	NotifyURL       string      `json:"notify_url"`
 }
 
 type PlatformInfo struct {
@@ -1427,17 +1679,22 @@ type PortalPurchaseRequest struct {
 }
 
 type PortalPurchaseResponse struct {
-	OrderNo string `json:"order_no"`
+	OrderNo   string `json:"order_no"`
+	Inventory int64  `json:"inventory"`
 }
 
 type PreOrderResponse struct {
-	Price          int64  `json:"price"`
-	Amount         int64  `json:"amount"`
-	Discount       int64  `json:"discount"`
-	GiftAmount     int64  `json:"gift_amount"`
-	Coupon         string `json:"coupon"`
-	CouponDiscount int64  `json:"coupon_discount"`
-	FeeAmount      int64  `json:"fee_amount"`
+	Price              int64  `json:"price"`
+	Amount             int64  `json:"amount"`
+	Discount           int64  `json:"discount"`
+	GiftAmount         int64  `json:"gift_amount"`
+	Coupon             string `json:"coupon"`
+	CouponDiscount     int64  `json:"coupon_discount"`
+	BonusDays          int64  `json:"bonus_days"`
+	PaymentDiscount    int64  `json:"payment_discount"`
+	FeeAmount          int64  `json:"fee_amount"`
+	RoundingAdjustment int64  `json:"rounding_adjustment"`
+	IsNew              bool   `json:"is_new"`
 }
 
 type PrePurchaseOrderRequest struct {
@@ -1448,12 +1705,13 @@ type PrePurchaseOrderRequest struct {
 }
 
 type PrePurchaseOrderResponse struct {
-	Price          int64  `json:"price"`
-	Amount         int64  `json:"amount"`
-	Discount       int64  `json:"discount"`
-	Coupon         string `json:"coupon"`
-	CouponDiscount int64  `json:"coupon_discount"`
-	FeeAmount      int64  `json:"fee_amount"`
+	Price           int64  `json:"price"`
+	Amount          int64  `json:"amount"`
+	Discount        int64  `json:"discount"`
+	Coupon          string `json:"coupon"`
+	CouponDiscount  int64  `json:"coupon_discount"`
+	PaymentDiscount int64  `json:"payment_discount"`
+	FeeAmount       int64  `json:"fee_amount"`
 }
 
 type PreRenewalOrderResponse struct {
@@ -1547,14 +1805,28 @@ type PubilcVerifyCodeConfig struct {
 }
 
 type PurchaseOrderRequest struct {
-	SubscribeId int64  `json:"subscribe_id"`
-	Quantity    int64  `json:"quantity" validate:"required,gt=0,lte=1000"`
-	Payment     int64  `json:"payment,omitempty"`
-	Coupon      string `json:"coupon,omitempty"`
+	SubscribeId int64             `json:"subscribe_id"`
+	Quantity    int64             `json:"quantity" validate:"required,gt=0,lte=1000"`
+	Payment     int64             `json:"payment,omitempty"`
+	Coupon      string            `json:"coupon,omitempty"`
+	BundleId    int64             `json:"bundle_id,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
 type PurchaseOrderResponse struct {
-	OrderNo string `json:"order_no"`
+	OrderNo            string `json:"order_no"`
+	Inventory          int64  `json:"inventory"`
+	Price              int64  `json:"price"`
+	Amount             int64  `json:"amount"`
+	Discount           int64  `json:"discount"`
+	GiftAmount         int64  `json:"gift_amount"`
+	CouponDiscount     int64  `json:"coupon_discount"`
+	BonusDays          int64  `json:"bonus_days"`
+	PaymentDiscount    int64  `json:"payment_discount"`
+	FeeAmount          int64  `json:"fee_amount"`
+	RoundingAdjustment int64  `json:"rounding_adjustment"`
+	IsNew              bool   `json:"is_new"`
+	Status             uint8  `json:"status"`
 }
 
 type QueryAnnouncementRequest struct {
@@ -1569,6 +1841,30 @@ type QueryAnnouncementResponse struct {
 	List  []Announcement `json:"announcements"`
 }
 
+type QueryBestCouponRequest struct {
+	SubscribeId int64 `form:"subscribe_id" validate:"required"`
+	Quantity    int64 `form:"quantity"`
+	Payment     int64 `form:"payment"`
+}
+
+type QueryBestCouponResponse struct {
+	Code     string `json:"code"`
+	Discount int64  `json:"discount"`
+}
+
+type QueryCouponUsageRequest struct {
+	Code        string `form:"code" validate:"required"`
+	SubscribeId int64  `form:"subscribe_id"`
+}
+
+type QueryCouponUsageResponse struct {
+	Code          string `json:"code"`
+	Remaining     int64  `json:"remaining"`
+	UserUsed      int64  `json:"user_used"`
+	UserLimit     int64  `json:"user_limit"`
+	UserRemaining int64  `json:"user_remaining"`
+}
+
 type QueryDocumentDetailRequest struct {
 	Id int64 `form:"id" validate:"required"`
 }
@@ -1613,19 +1909,20 @@ type QueryPurchaseOrderRequest struct {
 }
 
 type QueryPurchaseOrderResponse struct {
-	OrderNo        string        `json:"order_no"`
-	Subscribe      Subscribe     `json:"subscribe"`
-	Quantity       int64         `json:"quantity"`
-	Price          int64         `json:"price"`
-	Amount         int64         `json:"amount"`
-	Discount       int64         `json:"discount"`
-	Coupon         string        `json:"coupon"`
-	CouponDiscount int64         `json:"coupon_discount"`
-	FeeAmount      int64         `json:"fee_amount"`
-	Payment        PaymentMethod `json:"payment"`
-	Status         uint8         `json:"status"`
-	CreatedAt      int64         `json:"created_at"`
-	Token          string        `json:"token,omitempty"`
+	OrderNo         string        `json:"order_no"`
+	Subscribe       Subscribe     `json:"subscribe"`
+	Quantity        int64         `json:"quantity"`
+	Price           int64         `json:"price"`
+	Amount          int64         `json:"amount"`
+	Discount        int64         `json:"discount"`
+	Coupon          string        `json:"coupon"`
+	CouponDiscount  int64         `json:"coupon_discount"`
+	PaymentDiscount int64         `json:"payment_discount"`
+	FeeAmount       int64         `json:"fee_amount"`
+	Payment         PaymentMethod `json:"payment"`
+	Status          uint8         `json:"status"`
+	CreatedAt       int64         `json:"created_at"`
+	Token           string        `json:"token,omitempty"`
 }
 
 type QueryQuotaTaskListRequest struct {
@@ -1661,6 +1958,15 @@ type QueryQuotaTaskStatusResponse struct {
 	Errors  string `json:"errors"`
 }
 
+type QueryRechargeLimitRequest struct {
+	Payment int64 `form:"payment,omitempty"`
+}
+
+type QueryRechargeLimitResponse struct {
+	MinAmount int64 `json:"min_amount"`
+	MaxAmount int64 `json:"max_amount"`
+}
+
 type QueryServerConfigRequest struct {
 	ServerID  int64    `path:"server_id"`
 	SecretKey string   `form:"secret_key"`
@@ -1677,6 +1983,10 @@ type QueryServerConfigResponse struct {
 	Total                  int64          `json:"total"`
 }
 
+type QuerySubscribeBundleListResponse struct {
+	List []SubscribeBundle `json:"list"`
+}
+
 type QuerySubscribeGroupListResponse struct {
 	List  []SubscribeGroup `json:"list"`
 	Total int64            `json:"total"`
@@ -1691,6 +2001,21 @@ type QuerySubscribeListResponse struct {
 	Total int64       `json:"total"`
 }
 
+type QuerySubscribeQRCodeRequest struct {
+	Id    int64  `form:"id" validate:"required"`
+	Size  int    `form:"size,omitempty" validate:"omitempty,min=64,max=1024"`
+	Level string `form:"level,omitempty" validate:"omitempty,oneof=L M Q H"`
+}
+
+type QuerySubscribeURLRequest struct {
+	Id int64 `form:"id" validate:"required"`
+}
+
+type QuerySubscribeURLResponse struct {
+	Url       string `json:"url"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
 type QueryUserAffiliateCountResponse struct {
 	Registers       int64 `json:"registers"`
 	TotalCommission int64 `json:"total_commission"`
@@ -1721,6 +2046,16 @@ type QueryUserCommissionLogListResponse struct {
 	Total int64           `json:"total"`
 }
 
+type QueryUserReferralEarningsRequest struct {
+	Page int `form:"page"`
+	Size int `form:"size"`
+}
+
+type QueryUserReferralEarningsResponse struct {
+	List  []ReferralEarning `json:"list"`
+	Total int64             `json:"total"`
+}
+
 type QueryUserSubscribeListResponse struct {
 	List  []UserSubscribe `json:"list"`
 	Total int64           `json:"total"`
@@ -1760,12 +2095,44 @@ type QuotaTask struct {
 }
 
 type RechargeOrderRequest struct {
-	Amount  int64 `json:"amount" validate:"required,gt=0,lte=2000000000"`
-	Payment int64 `json:"payment"`
+	Amount   int64             `json:"amount" validate:"required,gt=0,lte=2000000000"`
+	Payment  int64             `json:"payment"`
+	Metadata map[string]string `json:"metadata,omitempty"`
 }
 
 type RechargeOrderResponse struct {
-	OrderNo string `json:"order_no"`
+	OrderNo            string `json:"order_no"`
+	Amount             int64  `json:"amount"`
+	FeeAmount          int64  `json:"fee_amount"`
+	RoundingAdjustment int64  `json:"rounding_adjustment"`
+	IsNew              bool   `json:"is_new"`
+}
+
+type ReferralEarning struct {
+	OrderNo   string `json:"order_no"`
+	RefereeId int64  `json:"referee_id"`
+	Amount    int64  `json:"amount"`
+	Timestamp int64  `json:"timestamp"`
+}
+
+type RefundBundleOrderRequest struct {
+	Id    int64 `json:"id" validate:"required"`
+	Fraud bool  `json:"fraud,omitempty"`
+}
+
+type RefundBundleOrderResponse struct {
+	Amount        int64  `json:"amount"`
+	CreditRemoved int64  `json:"credit_removed"`
+	Coupon        string `json:"coupon,omitempty"`
+}
+
+type RefundRenewalOrderRequest struct {
+	Id    int64 `json:"id" validate:"required"`
+	Fraud bool  `json:"fraud,omitempty"`
+}
+
+type RefundRenewalOrderResponse struct {
+	Coupon string `json:"coupon,omitempty"`
 }
 
 type RegisterConfig struct {
@@ -1789,14 +2156,32 @@ type RegisterLog struct {
 }
 
 type RenewalOrderRequest struct {
-	UserSubscribeID int64  `json:"user_subscribe_id"`
-	Quantity        int64  `json:"quantity" validate:"lte=1000"`
-	Payment         int64  `json:"payment"`
-	Coupon          string `json:"coupon,omitempty"`
+	UserSubscribeID   int64  `json:"user_subscribe_id"`
+	Quantity          int64  `json:"quantity" validate:"lte=1000"`
+	Payment           int64  `json:"payment"`
+	Coupon            string `json:"coupon,omitempty"`
+	TargetSubscribeId int64  `json:"target_subscribe_id,omitempty"`
 }
 
 type RenewalOrderResponse struct {
-	OrderNo string `json:"order_no"`
+	OrderNo            string `json:"order_no"`
+	Price              int64  `json:"price"`
+	Amount             int64  `json:"amount"`
+	Discount           int64  `json:"discount"`
+	GiftAmount         int64  `json:"gift_amount"`
+	LoyaltyCredit      int64  `json:"loyalty_credit"`
+	CouponDiscount     int64  `json:"coupon_discount"`
+	BonusDays          int64  `json:"bonus_days"`
+	PaymentDiscount    int64  `json:"payment_discount"`
+	FeeAmount          int64  `json:"fee_amount"`
+	RoundingAdjustment int64  `json:"rounding_adjustment"`
+	Status             uint8  `json:"status"`
+}
+
+type ResendPaymentRequest struct {
+	OrderNo   string `json:"orderNo" validate:"required"`
+	ReturnUrl string `json:"returnUrl,omitempty"`
+	Extend    bool   `json:"extend,omitempty"`
 }
 
 type ResetAllSubscribeTokenResponse struct {
@@ -1851,12 +2236,32 @@ type ResetUserSubscribeTrafficRequest struct {
 	UserSubscribeId int64 `json:"user_subscribe_id"`
 }
 
+type ResumeUserSubscribeRequest struct {
+	UserSubscribeId int64 `json:"user_subscribe_id" validate:"required"`
+}
+
 type RevenueStatisticsResponse struct {
 	Today   OrdersStatistics `json:"today"`
 	Monthly OrdersStatistics `json:"monthly"`
 	All     OrdersStatistics `json:"all"`
 }
 
+type SandboxOrderRequest struct {
+	OrderNo string `json:"order_no" validate:"required"`
+	Wait    int64  `json:"wait,omitempty" validate:"omitempty,gte=0,lte=30"`
+}
+
+type SandboxOrderResponse struct {
+	OrderNo     string                   `json:"order_no"`
+	Status      uint8                    `json:"status"`
+	Transitions []SandboxOrderTransition `json:"transitions"`
+}
+
+type SandboxOrderTransition struct {
+	Status uint8 `json:"status"`
+	Time   int64 `json:"time"`
+}
+
 type SecurityConfig struct {
 	SNI               string `json:"sni"`
 	AllowInsecure     *bool  `json:"allow_insecure"`
@@ -2051,46 +2456,92 @@ type StripePayment struct {
 }
 
 type Subscribe struct {
-	Id                int64               `json:"id"`
-	Name              string              `json:"name"`
-	Language          string              `json:"language"`
-	Description       string              `json:"description"`
-	UnitPrice         int64               `json:"unit_price"`
-	UnitTime          string              `json:"unit_time"`
-	Discount          []SubscribeDiscount `json:"discount"`
-	Replacement       int64               `json:"replacement"`
-	Inventory         int64               `json:"inventory"`
-	Traffic           int64               `json:"traffic"`
-	SpeedLimit        int64               `json:"speed_limit"`
-	DeviceLimit       int64               `json:"device_limit"`
-	Quota             int64               `json:"quota"`
-	Nodes             []int64             `json:"nodes"`
-	NodeTags          []string            `json:"node_tags"`
-	Show              bool                `json:"show"`
-	Sell              bool                `json:"sell"`
-	Sort              int64               `json:"sort"`
-	DeductionRatio    int64               `json:"deduction_ratio"`
-	AllowDeduction    bool                `json:"allow_deduction"`
-	ResetCycle        int64               `json:"reset_cycle"`
-	RenewalReset      bool                `json:"renewal_reset"`
-	ShowOriginalPrice bool                `json:"show_original_price"`
-	CreatedAt         int64               `json:"created_at"`
-	UpdatedAt         int64               `json:"updated_at"`
+	Id                  int64               `json:"id"`
+	Name                string              `json:"name"`
+	Language            string              `json:"language"`
+	Description         string              `json:"description"`
+	UnitPrice           int64               `json:"unit_price"`
+	UnitTime            string              `json:"unit_time"`
+	Discount            []SubscribeDiscount `json:"discount"`
+	DiscountInterpolate bool                `json:"discount_interpolate"`
+	Replacement         int64               `json:"replacement"`
+	TrafficReset        bool                `json:"traffic_reset"`
+	TrafficTopUp        bool                `json:"traffic_top_up"`
+	TopUpPrice          int64               `json:"top_up_price"`
+	Inventory           int64               `json:"inventory"`
+	Traffic             int64               `json:"traffic"`
+	SpeedLimit          int64               `json:"speed_limit"`
+	DeviceLimit         int64               `json:"device_limit"`
+	Quota               int64               `json:"quota"`
+	Nodes               []int64             `json:"nodes"`
+	NodeTags            []string            `json:"node_tags"`
+	StickyNode          bool                `json:"sticky_node"`
+	DatacenterPolicy    uint8               `json:"datacenter_policy"`
+	NodeSort            string              `json:"node_sort"`
+	ExtraRules          string              `json:"extra_rules"`
+	SubscribeTemplate   string              `json:"subscribe_template"`
+	Show                bool                `json:"show"`
+	Sell                bool                `json:"sell"`
+	Sort                int64               `json:"sort"`
+	DeductionRatio      int64               `json:"deduction_ratio"`
+	AllowDeduction      bool                `json:"allow_deduction"`
+	ResetCycle          int64               `json:"reset_cycle"`
+	RenewalReset        bool                `json:"renewal_reset"`
+	MaxRenewalStack     int64               `json:"max_renewal_stack"`
+	QuantityMode        uint8               `json:"quantity_mode"`
+	Duration            int64               `json:"duration"`
+	MinQuantity         int64               `json:"min_quantity"`
+	DefaultQuantity     int64               `json:"default_quantity"`
+	ShowOriginalPrice   bool                `json:"show_original_price"`
+	CreatedAt           int64               `json:"created_at"`
+	UpdatedAt           int64               `json:"updated_at"`
 }
 
 type SubscribeApplication struct {
-	Id                int64        `json:"id"`
-	Name              string       `json:"name"`
-	Description       string       `json:"description,omitempty"`
-	Icon              string       `json:"icon,omitempty"`
-	Scheme            string       `json:"scheme,omitempty"`
-	UserAgent         string       `json:"user_agent"`
-	IsDefault         bool         `json:"is_default"`
-	SubscribeTemplate string       `json:"template"`
-	OutputFormat      string       `json:"output_format"`
-	DownloadLink      DownloadLink `json:"download_link,omitempty"`
-	CreatedAt         int64        `json:"created_at"`
-	UpdatedAt         int64        `json:"updated_at"`
+	Id                 int64        `json:"id"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description,omitempty"`
+	Icon               string       `json:"icon,omitempty"`
+	Scheme             string       `json:"scheme,omitempty"`
+	UserAgent          string       `json:"user_agent"`
+	IsDefault          bool         `json:"is_default"`
+	SubscribeTemplate  string       `json:"template"`
+	OutputFormat       string       `json:"output_format"`
+	SupportedProtocols []string     `json:"supported_protocols,omitempty"`
+	ProtocolPriority   []string     `json:"protocol_priority,omitempty"`
+	RedirectMode       bool         `json:"redirect_mode"`
+	DownloadLink       DownloadLink `json:"download_link,omitempty"`
+	CreatedAt          int64        `json:"created_at"`
+	UpdatedAt          int64        `json:"updated_at"`
+}
+
+type SubscribeApplicationEvaluation struct {
+	Id        int64  `json:"id"`
+	Name      string `json:"name"`
+	UserAgent string `json:"user_agent"`
+	IsDefault bool   `json:"is_default"`
+	Matched   bool   `json:"matched"`
+	Reason    string `json:"reason"`
+}
+
+type SubscribeBuildStatsResponse struct {
+	InFlight int64 `json:"in_flight"`
+	Limit    int64 `json:"limit"`
+	Rejected int64 `json:"rejected"`
+}
+
+type SubscribeBundle struct {
+	Id          int64  `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	SubscribeId int64  `json:"subscribe_id"`
+	Quantity    int64  `json:"quantity"`
+	Price       int64  `json:"price"`
+	Credit      int64  `json:"credit"`
+	Sell        bool   `json:"sell"`
+	Sort        int64  `json:"sort"`
+	CreatedAt   int64  `json:"created_at"`
+	UpdatedAt   int64  `json:"updated_at"`
 }
 
 type SubscribeClient struct {
@@ -2104,12 +2555,30 @@ type SubscribeClient struct {
 }
 
 type SubscribeConfig struct {
-	SingleModel     bool   `json:"single_model"`
-	SubscribePath   string `json:"subscribe_path"`
-	SubscribeDomain string `json:"subscribe_domain"`
-	PanDomain       bool   `json:"pan_domain"`
-	UserAgentLimit  bool   `json:"user_agent_limit"`
-	UserAgentList   string `json:"user_agent_list"`
+	SingleModel             bool   `json:"single_model"`
+	MaxSubscriptions        int64  `json:"max_subscriptions" validate:"gte=0"`
+	SubscribePath           string `json:"subscribe_path"`
+	SubscribeDomain         string `json:"subscribe_domain"`
+	PanDomain               bool   `json:"pan_domain"`
+	DedupNodes              bool   `json:"dedup_nodes"`
+	StrictQuantity          bool   `json:"strict_quantity"`
+	NewOrderWindow          int64  `json:"new_order_window" validate:"gte=0"`
+	DiscountStacking        string `json:"discount_stacking" validate:"omitempty,oneof=sequential best original"`
+	MaxPauses               int64  `json:"max_pauses" validate:"gte=0"`
+	MaxPauseDays            int64  `json:"max_pause_days" validate:"gte=0"`
+	BuildConcurrency        int64  `json:"build_concurrency" validate:"gte=0"`
+	BuildQueueWait          int64  `json:"build_queue_wait" validate:"gte=0"`
+	FetchCooldown           bool   `json:"fetch_cooldown"`
+	FetchCooldownInterval   int64  `json:"fetch_cooldown_interval" validate:"gte=0"`
+	UserAgentLimit          bool   `json:"user_agent_limit"`
+	UserAgentList           string `json:"user_agent_list"`
+	MaxGiftDeductionPercent int64  `json:"max_gift_deduction_percent" validate:"gte=0,lte=100"`
+	PromoCredit             bool   `json:"promo_credit"`
+	LoyaltyCreditPercent    int64  `json:"loyalty_credit_percent" validate:"gte=0,lte=100"`
+	MaxLoyaltyCreditPercent int64  `json:"max_loyalty_credit_percent" validate:"gte=0,lte=100"`
+	EmptyNodes              string `json:"empty_nodes" validate:"omitempty,oneof=placeholder error empty"`
+	EmptyNodesNotice        string `json:"empty_nodes_notice"`
+	StatusCheck             string `json:"status_check" validate:"omitempty,oneof=lenient strict"`
 }
 
 type SubscribeDiscount struct {
@@ -2147,6 +2616,17 @@ type SubscribeType struct {
 	SubscribeTypes []string `json:"subscribe_types"`
 }
 
+type SyncSubscribeInventoryRequest struct {
+	SubscribeId int64  `json:"subscribe_id" validate:"required"`
+	Mode        string `json:"mode" validate:"required,oneof=set increment"`
+	Inventory   int64  `json:"inventory"`
+}
+
+type SyncSubscribeInventoryResponse struct {
+	SubscribeId int64 `json:"subscribe_id"`
+	Inventory   int64 `json:"inventory"`
+}
+
 type TelegramConfig struct {
 	TelegramBotToken      string `json:"telegram_bot_token"`
 	TelegramGroupUrl      string `json:"telegram_group_url"`
@@ -2263,6 +2743,27 @@ type TrafficLogDetails struct {
 	Timestamp   int64 `json:"timestamp"`
 }
 
+type TrafficTopUpOrderRequest struct {
+	UserSubscribeID int64 `json:"user_subscribe_id" validate:"required"`
+	Traffic         int64 `json:"traffic" validate:"required,gte=1,lte=100000"`
+	Payment         int64 `json:"payment"`
+}
+
+type TrafficTopUpOrderResponse struct {
+	OrderNo    string `json:"order_no"`
+	Price      int64  `json:"price"`
+	Amount     int64  `json:"amount"`
+	GiftAmount int64  `json:"gift_amount"`
+	FeeAmount  int64  `json:"fee_amount"`
+	Status     uint8  `json:"status"`
+}
+
+type TransferSubscriptionRequest struct {
+	UserSubscribeId int64 `json:"user_subscribe_id" validate:"required"`
+	UserId          int64 `json:"user_id" validate:"required"`
+	Force           bool  `json:"force"`
+}
+
 type TransportConfig struct {
 	Path        string `json:"path"`
 	Host        string `json:"host"`
@@ -2351,18 +2852,23 @@ type UpdateBindMobileRequest struct {
 }
 
 type UpdateCouponRequest struct {
-	Id         int64   `json:"id" validate:"required"`
-	Name       string  `json:"name" validate:"required"`
-	Code       string  `json:"code,omitempty"`
-	Count      int64   `json:"count,omitempty"`
-	Type       uint8   `json:"type" validate:"required"`
-	Discount   int64   `json:"discount" validate:"required"`
-	StartTime  int64   `json:"start_time" validate:"required"`
-	ExpireTime int64   `json:"expire_time" validate:"required"`
-	UserLimit  int64   `json:"user_limit,omitempty"`
-	Subscribe  []int64 `json:"subscribe,omitempty"`
-	UsedCount  int64   `json:"used_count,omitempty"`
-	Enable     *bool   `json:"enable,omitempty"`
+	Id         int64        `json:"id" validate:"required"`
+	Name       string       `json:"name" validate:"required"`
+	Code       string       `json:"code,omitempty"`
+	Count      int64        `json:"count,omitempty"`
+	Type       uint8        `json:"type" validate:"required"`
+	Discount   int64        `json:"discount" validate:"required"`
+	Tiers      []CouponTier `json:"tiers,omitempty" validate:"omitempty,dive"`
+	StartTime  int64        `json:"start_time" validate:"required"`
+	ExpireTime int64        `json:"expire_time" validate:"required"`
+	UserLimit  int64        `json:"user_limit,omitempty"`
+	Subscribe  []int64      `json:"subscribe,omitempty"`
+	Payment    []int64      `json:"payment,omitempty"`
+	Users      []int64      `json:"users,omitempty"`
+	OrderTypes uint8        `json:"order_types" validate:"required,min=1,max=3"`
+	UsedCount  int64        `json:"used_count,omitempty"`
+	Enable     *bool        `json:"enable,omitempty"`
+	AutoApply  bool         `json:"auto_apply,omitempty"`
 }
 
 type UpdateDocumentRequest struct {
@@ -2374,14 +2880,16 @@ type UpdateDocumentRequest struct {
 }
 
 type UpdateNodeRequest struct {
-	Id       int64    `json:"id"`
-	Name     string   `json:"name"`
-	Tags     []string `json:"tags,omitempty"`
-	Port     uint16   `json:"port"`
-	Address  string   `json:"address"`
-	ServerId int64    `json:"server_id"`
-	Protocol string   `json:"protocol"`
-	Enabled  *bool    `json:"enabled"`
+	Id          int64    `json:"id"`
+	Name        string   `json:"name"`
+	Tags        []string `json:"tags,omitempty"`
+	Port        uint16   `json:"port"`
+	Address     string   `json:"address"`
+	ServerId    int64    `json:"server_id"`
+	Protocol    string   `json:"protocol"`
+	TrafficRate float64  `json:"traffic_rate,omitempty" validate:"gte=0,lte=100"`
+	Weight      int64    `json:"weight"`
+	Enabled     *bool    `json:"enabled"`
 }
 
 type UpdateOrderStatusRequest struct {
@@ -2392,17 +2900,25 @@ type UpdateOrderStatusRequest struct {
 }
 
 type UpdatePaymentMethodRequest struct {
-	Id          int64       `json:"id" validate:"required"`
-	Name        string      `json:"name" validate:"required"`
-	Platform    string      `json:"platform" validate:"required"`
-	Description string      `json:"description"`
-	Icon        string      `json:"icon,omitempty"`
-	Domain      string      `json:"domain,omitempty"`
-	Config      interface{} `json:"config" validate:"required"`
-	FeeMode     uint        `json:"fee_mode"`
-	FeePercent  int64       `json:"fee_percent,omitempty"`
-	FeeAmount   int64       `json:"fee_amount,omitempty"`
-	Enable      *bool       `json:"enable" validate:"required"`
+	Id              int64       `json:"id" validate:"required"`
+	Name            string      `json:"name" validate:"required"`
+	Platform        string      `json:"platform" validate:"required"`
+	Description     string      `json:"description"`
+	Icon            string      `json:"icon,omitempty"`
+	Domain          string      `json:"domain,omitempty"`
+	Config          interface{} `json:"config" validate:"required"`
+	FeeMode         uint        `json:"fee_mode"`
+	FeePercent      int64       `json:"fee_percent,omitempty"`
+	FeeAmount       int64       `json:"fee_amount,omitempty"`
+	DiscountPercent int64       `json:"discount_percent,omitempty" validate:"gte=0,lte=100"`
+	CloseStrategy   uint8       `json:"close_strategy,omitempty" validate:"oneof=0 1"`
+	HoldMinutes     int64       `json:"hold_minutes,omitempty" validate:"gte=0"`
+	MinRecharge     int64       `json:"min_recharge,omitempty" validate:"gte=0"`
+	MaxRecharge     int64       `json:"max_recharge,omitempty" validate:"gte=0"`
+	MinAmount       int64       `json:"min_amount,omitempty" validate:"gte=0"`
+	MaxAmount       int64       `json:"max_amount,omitempty" validate:"gte=0"`
+	AvailableHours  string      `json:"available_hours,omitempty"`
+	Enable          *bool       `json:"enable" validate:"required"`
 }
 
 type UpdateServerRequest struct {
@@ -2416,16 +2932,36 @@ type UpdateServerRequest struct {
 }
 
 type UpdateSubscribeApplicationRequest struct {
-	Id                int64        `json:"id"`
-	Name              string       `json:"name"`
-	Description       string       `json:"description,omitempty"`
-	Icon              string       `json:"icon,omitempty"`
-	Scheme            string       `json:"scheme,omitempty"`
-	UserAgent         string       `json:"user_agent"`
-	IsDefault         bool         `json:"is_default"`
-	SubscribeTemplate string       `json:"template"`
-	OutputFormat      string       `json:"output_format"`
-	DownloadLink      DownloadLink `json:"download_link,omitempty"`
+	Id                 int64        `json:"id"`
+	Name               string       `json:"name"`
+	Description        string       `json:"description,omitempty"`
+	Icon               string       `json:"icon,omitempty"`
+	Scheme             string       `json:"scheme,omitempty"`
+	UserAgent          string       `json:"user_agent"`
+	IsDefault          bool         `json:"is_default"`
+	SubscribeTemplate  string       `json:"template"`
+	OutputFormat       string       `json:"output_format"`
+	SupportedProtocols []string     `json:"supported_protocols,omitempty"`
+	ProtocolPriority   []string     `json:"protocol_priority,omitempty"`
+	RedirectMode       bool         `json:"redirect_mode"`
+	DownloadLink       DownloadLink `json:"download_link,omitempty"`
+}
+
+type UpdateSubscribeApplicationTemplateRequest struct {
+	Id                int64  `json:"id" validate:"required"`
+	SubscribeTemplate string `json:"template"`
+}
+
+type UpdateSubscribeBundleRequest struct {
+	Id          int64  `json:"id" validate:"required"`
+	Name        string `json:"name" validate:"required"`
+	Description string `json:"description"`
+	SubscribeId int64  `json:"subscribe_id" validate:"required"`
+	Quantity    int64  `json:"quantity" validate:"required,gt=0,lte=1000"`
+	Price       int64  `json:"price" validate:"gte=0"`
+	Credit      int64  `json:"credit" validate:"gte=0"`
+	Sell        bool   `json:"sell"`
+	Sort        int64  `json:"sort"`
 }
 
 type UpdateSubscribeGroupRequest struct {
@@ -2435,29 +2971,43 @@ type UpdateSubscribeGroupRequest struct {
 }
 
 type UpdateSubscribeRequest struct {
-	Id                int64               `json:"id" validate:"required"`
-	Name              string              `json:"name" validate:"required"`
-	Language          string              `json:"language"`
-	Description       string              `json:"description"`
-	UnitPrice         int64               `json:"unit_price"`
-	UnitTime          string              `json:"unit_time"`
-	Discount          []SubscribeDiscount `json:"discount"`
-	Replacement       int64               `json:"replacement"`
-	Inventory         int64               `json:"inventory"`
-	Traffic           int64               `json:"traffic"`
-	SpeedLimit        int64               `json:"speed_limit"`
-	DeviceLimit       int64               `json:"device_limit"`
-	Quota             int64               `json:"quota"`
-	Nodes             []int64             `json:"nodes"`
-	NodeTags          []string            `json:"node_tags"`
-	Show              *bool               `json:"show"`
-	Sell              *bool               `json:"sell"`
-	Sort              int64               `json:"sort"`
-	DeductionRatio    int64               `json:"deduction_ratio"`
-	AllowDeduction    *bool               `json:"allow_deduction"`
-	ResetCycle        int64               `json:"reset_cycle"`
-	RenewalReset      *bool               `json:"renewal_reset"`
-	ShowOriginalPrice bool                `json:"show_original_price"`
+	Id                  int64               `json:"id" validate:"required"`
+	Name                string              `json:"name" validate:"required"`
+	Language            string              `json:"language"`
+	Description         string              `json:"description"`
+	UnitPrice           int64               `json:"unit_price"`
+	UnitTime            string              `json:"unit_time"`
+	Discount            []SubscribeDiscount `json:"discount"`
+	DiscountInterpolate bool                `json:"discount_interpolate"`
+	Replacement         int64               `json:"replacement"`
+	TrafficReset        bool                `json:"traffic_reset"`
+	TrafficTopUp        bool                `json:"traffic_top_up"`
+	TopUpPrice          int64               `json:"top_up_price" validate:"gte=0"`
+	Inventory           int64               `json:"inventory"`
+	Traffic             int64               `json:"traffic"`
+	SpeedLimit          int64               `json:"speed_limit"`
+	DeviceLimit         int64               `json:"device_limit"`
+	Quota               int64               `json:"quota"`
+	Nodes               []int64             `json:"nodes"`
+	NodeTags            []string            `json:"node_tags"`
+	StickyNode          bool                `json:"sticky_node"`
+	DatacenterPolicy    uint8               `json:"datacenter_policy" validate:"lte=2"`
+	NodeSort            string              `json:"node_sort" validate:"omitempty,oneof=name tag weight"`
+	ExtraRules          string              `json:"extra_rules"`
+	SubscribeTemplate   string              `json:"subscribe_template"`
+	Show                *bool               `json:"show"`
+	Sell                *bool               `json:"sell"`
+	Sort                int64               `json:"sort"`
+	DeductionRatio      int64               `json:"deduction_ratio"`
+	AllowDeduction      *bool               `json:"allow_deduction"`
+	ResetCycle          int64               `json:"reset_cycle"`
+	RenewalReset        *bool               `json:"renewal_reset"`
+	MaxRenewalStack     int64               `json:"max_renewal_stack" validate:"gte=0"`
+	QuantityMode        uint8               `json:"quantity_mode" validate:"lte=1"`
+	Duration            int64               `json:"duration" validate:"gte=0"`
+	MinQuantity         int64               `json:"min_quantity" validate:"gte=0"`
+	DefaultQuantity     int64               `json:"default_quantity" validate:"gte=0"`
+	ShowOriginalPrice   bool                `json:"show_original_price"`
 }
 
 type UpdateTicketStatusRequest struct {
@@ -2472,19 +3022,27 @@ type UpdateUserAuthMethodRequest struct {
 }
 
 type UpdateUserBasiceInfoRequest struct {
-	UserId             int64  `json:"user_id" validate:"required"`
-	Password           string `json:"password"`
-	Avatar             string `json:"avatar"`
-	Balance            int64  `json:"balance"`
-	Commission         int64  `json:"commission"`
-	ReferralPercentage uint8  `json:"referral_percentage"`
-	OnlyFirstPurchase  bool   `json:"only_first_purchase"`
-	GiftAmount         int64  `json:"gift_amount"`
-	Telegram           int64  `json:"telegram"`
-	ReferCode          string `json:"refer_code"`
-	RefererId          int64  `json:"referer_id"`
-	Enable             bool   `json:"enable"`
-	IsAdmin            bool   `json:"is_admin"`
+	UserId               int64  `json:"user_id" validate:"required"`
+	Password             string `json:"password"`
+	Avatar               string `json:"avatar"`
+	Balance              int64  `json:"balance"`
+	Commission           int64  `json:"commission"`
+	ReferralPercentage   uint8  `json:"referral_percentage"`
+	OnlyFirstPurchase    bool   `json:"only_first_purchase"`
+	GiftAmount           int64  `json:"gift_amount"`
+	PromoCredit          int64  `json:"promo_credit"`
+	PromoCreditExpiredAt int64  `json:"promo_credit_expired_at"`
+	Telegram             int64  `json:"telegram"`
+	ReferCode            string `json:"refer_code"`
+	RefererId            int64  `json:"referer_id"`
+	Enable               bool   `json:"enable"`
+	IsAdmin              bool   `json:"is_admin"`
+}
+
+type UpdateUserEnterpriseRequest struct {
+	UserId      int64 `json:"user_id" validate:"required"`
+	Enterprise  bool  `json:"enterprise"`
+	CreditLimit int64 `json:"credit_limit" validate:"gte=0"`
 }
 
 type UpdateUserNotifyRequest struct {
@@ -2510,6 +3068,17 @@ type UpdateUserRulesRequest struct {
 	Rules []string `json:"rules" validate:"required"`
 }
 
+type UpdateUserSubscribeAliasRequest struct {
+	UserSubscribeId int64  `json:"user_subscribe_id" validate:"required"`
+	Alias           string `json:"alias"`
+}
+
+type UpdateUserSubscribeNodesRequest struct {
+	UserSubscribeId int64   `json:"user_subscribe_id" validate:"required"`
+	ExcludeNodes    []int64 `json:"exclude_nodes"`
+	IncludeNodes    []int64 `json:"include_nodes"`
+}
+
 type UpdateUserSubscribeNoteRequest struct {
 	UserSubscribeId int64  `json:"user_subscribe_id" validate:"required"`
 	Note            string `json:"note" validate:"max=500"`
@@ -2537,6 +3106,12 @@ type User struct {
 	ReferralPercentage    uint8            `json:"referral_percentage"`
 	OnlyFirstPurchase     bool             `json:"only_first_purchase"`
 	GiftAmount            int64            `json:"gift_amount"`
+	LoyaltyCredit         int64            `json:"loyalty_credit"`
+	PromoCredit           int64            `json:"promo_credit"`
+	PromoCreditExpiredAt  int64            `json:"promo_credit_expired_at"`
+	Enterprise            bool             `json:"enterprise"`
+	CreditLimit           int64            `json:"credit_limit"`
+	OutstandingBalance    int64            `json:"outstanding_balance"`
 	Telegram              int64            `json:"telegram"`
 	ReferCode             string           `json:"refer_code"`
 	RefererId             int64            `json:"referer_id"`
@@ -2548,6 +3123,7 @@ type User struct {
 	EnableTradeNotify     bool             `json:"enable_trade_notify"`
 	AuthMethods           []UserAuthMethod `json:"auth_methods"`
 	UserDevices           []UserDevice     `json:"user_devices"`
+	DeviceLimit           int64            `json:"device_limit"`
 	Rules                 []string         `json:"rules"`
 	CreatedAt             int64            `json:"created_at"`
 	UpdatedAt             int64            `json:"updated_at"`
@@ -2609,6 +3185,13 @@ type UserRegisterRequest struct {
 	CfToken    string `json:"cf_token,optional"`
 }
 
+type UserSpendingSummary struct {
+	TotalSpent          int64 `json:"total_spent"`
+	Refunded            int64 `json:"refunded"`
+	GiftAmount          int64 `json:"gift_amount"`
+	ActiveSubscriptions int64 `json:"active_subscriptions"`
+}
+
 type UserStatistics struct {
 	Date              string           `json:"date,omitempty"`
 	Register          int64            `json:"register"`
@@ -2637,29 +3220,37 @@ type UserSubscribe struct {
 	Download    int64     `json:"download"`
 	Upload      int64     `json:"upload"`
 	Token       string    `json:"token"`
+	Alias       string    `json:"alias"`
 	Status      uint8     `json:"status"`
+	PausedAt    int64     `json:"paused_at"`
+	PausedFor   int64     `json:"paused_for"`
+	PauseCount  int64     `json:"pause_count"`
 	Short       string    `json:"short"`
 	CreatedAt   int64     `json:"created_at"`
 	UpdatedAt   int64     `json:"updated_at"`
 }
 
 type UserSubscribeDetail struct {
-	Id          int64     `json:"id"`
-	UserId      int64     `json:"user_id"`
-	User        User      `json:"user"`
-	OrderId     int64     `json:"order_id"`
-	SubscribeId int64     `json:"subscribe_id"`
-	Subscribe   Subscribe `json:"subscribe"`
-	StartTime   int64     `json:"start_time"`
-	ExpireTime  int64     `json:"expire_time"`
-	ResetTime   int64     `json:"reset_time"`
-	Traffic     int64     `json:"traffic"`
-	Download    int64     `json:"download"`
-	Upload      int64     `json:"upload"`
-	Token       string    `json:"token"`
-	Status      uint8     `json:"status"`
-	CreatedAt   int64     `json:"created_at"`
-	UpdatedAt   int64     `json:"updated_at"`
+	Id           int64     `json:"id"`
+	UserId       int64     `json:"user_id"`
+	User         User      `json:"user"`
+	OrderId      int64     `json:"order_id"`
+	SubscribeId  int64     `json:"subscribe_id"`
+	Subscribe    Subscribe `json:"subscribe"`
+	StartTime    int64     `json:"start_time"`
+	ExpireTime   int64     `json:"expire_time"`
+	ResetTime    int64     `json:"reset_time"`
+	Traffic      int64     `json:"traffic"`
+	Download     int64     `json:"download"`
+	Upload       int64     `json:"upload"`
+	Token        string    `json:"token"`
+	Status       uint8     `json:"status"`
+	ExcludeNodes []int64   `json:"exclude_nodes"`
+	IncludeNodes []int64   `json:"include_nodes"`
+	BonusNodes   []int64   `json:"bonus_nodes"`
+	BonusExpire  int64     `json:"bonus_expire"`
+	CreatedAt    int64     `json:"created_at"`
+	UpdatedAt    int64     `json:"updated_at"`
 }
 
 type UserSubscribeInfo struct {
@@ -359,5 +359,89 @@ const (
       <div class="footer">此为系统邮件，请勿回复 / This is a system email, please do not reply</div>
     </div>
   </body>
+</html>`
+	DefaultOrderClosedEmailTemplate = `<!doctype html>
+<html>
+  <head>
+    <meta charset="UTF-8" />
+    <meta name="viewport" content="width=device-width, initial-scale=1.0" />
+    <title>订单关闭通知 / Order Closed Notice</title>
+    <style>
+      .container {
+        border-radius: 5px;
+        width: 500px;
+        margin: 20px auto 0;
+        border: 1px solid #cce7ff;
+        background-color: #f0f8ff;
+        padding: 25px 30px;
+      }
+      .header {
+        text-align: center;
+        display: flex;
+        align-items: center;
+        justify-content: center;
+      }
+      .logo {
+        width: 56px;
+        height: 56px;
+        object-fit: cover;
+        margin-right: 10px;
+      }
+      .site-name {
+        font-size: 18px;
+        font-weight: bold;
+        margin: 0;
+      }
+      .content {
+        margin: 20px 0;
+        font-size: 14px;
+      }
+      .greeting {
+        font-weight: 700;
+        margin: 5px 0;
+      }
+      .highlight {
+        margin: 0 2px;
+        font-weight: 700;
+        color: #007bff;
+      }
+      .footer {
+        border-top: #99ccff 1px solid;
+        margin-top: 20px;
+        padding-top: 5px;
+        font-size: 12px;
+        font-weight: 700;
+        color: #777;
+      }
+    </style>
+  </head>
+  <body>
+    <div class="container">
+      <div class="header">
+        <img src="{{.SiteLogo}}" class="logo" />
+        <p class="site-name">{{.SiteName}}</p>
+      </div>
+      <div class="content">
+        <p class="greeting">Hi, 尊敬的用户 / Dear User</p>
+        <p>
+          {{if eq .Reason "timeout"}}您的订单<span class="highlight">{{.OrderNo}}</span>（{{.SubscribeName}}，{{.OrderAmount}}）因超时未支付已关闭。{{else}}您的订单<span class="highlight">{{.OrderNo}}</span>（{{.SubscribeName}}，{{.OrderAmount}}）已取消。{{end}}
+          <br />
+          {{if eq .Reason "timeout"}}Your order <span class="highlight">{{.OrderNo}}</span> ({{.SubscribeName}}, {{.OrderAmount}}) was closed because it was not paid in time.{{else}}Your order <span class="highlight">{{.OrderNo}}</span> ({{.SubscribeName}}, {{.OrderAmount}}) has been cancelled.{{end}}
+        </p>
+        {{if .RepurchaseURL}}<p>
+          如仍需购买，请<a href="{{.RepurchaseURL}}">点击此处重新下单</a>。
+          <br />
+          To purchase it again, <a href="{{.RepurchaseURL}}">place a new order here</a>.
+        </p>{{end}}
+        <p>
+          如需帮助，请联系客服团队。感谢您的支持！
+          <br />
+          If you need assistance, please contact our support team. Thank you for your continued
+          support!
+        </p>
+      </div>
+      <div class="footer">此为系统邮件，请勿回复 / This is a system email, please do not reply</div>
+    </div>
+  </body>
 </html>`
 )
@@ -0,0 +1,67 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Config describes a bucket that accepts plain HTTP PUT uploads, e.g. an S3 compatible bucket
+// behind a signing proxy or a CDN storage zone, and serves the objects from PublicURL.
+type Config struct {
+	Endpoint      string        // upload base URL, objects are PUT to Endpoint/key
+	PublicURL     string        // download base URL, defaults to Endpoint
+	Authorization string        // optional Authorization header sent with uploads
+	Timeout       time.Duration // upload timeout, defaults to 10 seconds
+}
+
+type Store struct {
+	config Config
+	client *http.Client
+}
+
+// New returns nil when no endpoint is configured.
+func New(config Config) *Store {
+	if config.Endpoint == "" {
+		return nil
+	}
+	if config.PublicURL == "" {
+		config.PublicURL = config.Endpoint
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	config.Endpoint = strings.TrimSuffix(config.Endpoint, "/")
+	config.PublicURL = strings.TrimSuffix(config.PublicURL, "/")
+	return &Store{
+		config: config,
+		client: &http.Client{Timeout: config.Timeout},
+	}
+}
+
+// Put uploads the object and returns its public URL.
+func (s *Store) Put(ctx context.Context, key string, body []byte, contentType string) (string, error) {
+	key = strings.TrimPrefix(key, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.config.Endpoint+"/"+key, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.config.Authorization != "" {
+		req.Header.Set("Authorization", s.config.Authorization)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return "", fmt.Errorf("upload %s failed: %s", key, resp.Status)
+	}
+	return s.config.PublicURL + "/" + key, nil
+}
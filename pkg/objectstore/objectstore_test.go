@@ -0,0 +1,38 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStorePut(t *testing.T) {
+	var gotPath, gotAuth, gotBody string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/fail" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		gotPath, gotAuth, gotBody = r.URL.Path, r.Header.Get("Authorization"), string(body)
+	}))
+	defer srv.Close()
+
+	assert.Nil(t, New(Config{}))
+
+	store := New(Config{Endpoint: srv.URL + "/", PublicURL: "https://cdn.example.com/", Authorization: "Bearer secret"})
+	url, err := store.Put(context.Background(), "/subscribe/a.yaml", []byte("proxies: []"), "text/yaml")
+	require.NoError(t, err)
+	assert.Equal(t, "https://cdn.example.com/subscribe/a.yaml", url)
+	assert.Equal(t, "/subscribe/a.yaml", gotPath)
+	assert.Equal(t, "Bearer secret", gotAuth)
+	assert.Equal(t, "proxies: []", gotBody)
+
+	_, err = store.Put(context.Background(), "fail", nil, "text/plain")
+	assert.Error(t, err)
+}
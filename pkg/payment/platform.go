@@ -10,6 +10,9 @@ const (
 	EPay
 	Balance
 	CryptoSaaS
+	Test
+	// Enterprise checks the order out on invoice, only for accounts flagged as enterprise
+	Enterprise
 	UNSUPPORTED Platform = -1
 )
 
@@ -19,6 +22,8 @@ var platformNames = map[string]Platform{
 	"AlipayF2F":   AlipayF2F,
 	"EPay":        EPay,
 	"balance":     Balance,
+	"test":        Test,
+	"enterprise":  Enterprise,
 	"unsupported": UNSUPPORTED,
 }
 
@@ -31,6 +36,21 @@ func (p Platform) String() string {
 	return "unsupported"
 }
 
+// nextPlatform the value given to the next registered platform
+var nextPlatform = Enterprise + 1
+
+// RegisterPlatform adds a platform handled outside this package, e.g. by a plugin, so payment methods and
+// orders may use it. It must be called from an init function, a name already known keeps its value.
+func RegisterPlatform(name string) Platform {
+	if p, ok := platformNames[name]; ok {
+		return p
+	}
+	p := nextPlatform
+	nextPlatform++
+	platformNames[name] = p
+	return p
+}
+
 func ParsePlatform(s string) Platform {
 	if p, ok := platformNames[s]; ok {
 		return p
@@ -80,5 +100,20 @@ func GetSupportedPlatforms() []types.PlatformInfo {
 				"secret_key": "Secret Key",
 			},
 		},
+		{
+			Platform:                 Enterprise.String(),
+			PlatformUrl:              "",
+			PlatformFieldDescription: map[string]string{},
+		},
+	}
+}
+
+// GetSandboxPlatform the "test" platform, only offered while the payment sandbox is enabled.
+// Orders paid with it are marked paid at checkout without contacting any gateway.
+func GetSandboxPlatform() types.PlatformInfo {
+	return types.PlatformInfo{
+		Platform:                 Test.String(),
+		PlatformUrl:              "",
+		PlatformFieldDescription: map[string]string{},
 	}
 }
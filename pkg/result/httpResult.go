@@ -21,6 +21,7 @@ func HttpResult(ctx *gin.Context, resp interface{}, err error) {
 	// Init Error Code and Message
 	code := xerr.ERROR
 	msg := "Internal Server Error"
+	var data interface{}
 
 	// Get Error Type
 	var e *xerr.CodeError
@@ -28,8 +29,11 @@ func HttpResult(ctx *gin.Context, resp interface{}, err error) {
 		// Custom Code Error
 		code = e.GetErrCode()
 		msg = e.GetErrMsg()
+		data = e.GetErrData()
 	}
-	ctx.JSON(http.StatusOK, Error(code, msg))
+	bean := Error(code, msg)
+	bean.Data = data
+	ctx.JSON(http.StatusOK, bean)
 }
 
 // ParamErrorResult Param Error Result
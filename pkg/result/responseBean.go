@@ -12,10 +12,11 @@ func Success(data interface{}) *ResponseSuccessBean {
 }
 
 type ResponseErrorBean struct {
-	Code uint32 `json:"code"`
-	Msg  string `json:"msg"`
+	Code uint32      `json:"code"`
+	Msg  string      `json:"msg"`
+	Data interface{} `json:"data,omitempty"`
 }
 
 func Error(errCode uint32, errMsg string) *ResponseErrorBean {
-	return &ResponseErrorBean{errCode, errMsg}
+	return &ResponseErrorBean{Code: errCode, Msg: errMsg}
 }
@@ -71,6 +71,22 @@ const (
 	SingleSubscribeModeExceedsLimit uint32 = 60005
 	SubscribeQuotaLimit             uint32 = 60006
 	SubscribeOutOfStock             uint32 = 60007
+	SubscribeRenewalStackLimit      uint32 = 60008
+	UserSubscribeLimit              uint32 = 60009
+	SubscribeTrafficExhausted       uint32 = 60010
+	SubscribeNoNodes                uint32 = 60011
+	SubscribeDatacenterBlocked      uint32 = 60012
+	SubscribePauseLimit             uint32 = 60013
+	SubscribePaused                 uint32 = 60014
+	SubscribeBuildBusy              uint32 = 60015
+	SubscribeFormatUnknown          uint32 = 60016
+	SubscribeBundleNotAvailable     uint32 = 60017
+	SubscribeTrafficResetDisabled   uint32 = 60018
+	SubscribeLinkExpired            uint32 = 60019
+	SubscribeLinkInvalid            uint32 = 60020
+	SubscribeRegionBlocked          uint32 = 60021
+	SubscribeTrafficTopUpDisabled   uint32 = 60022
+	SubscribeSuspended              uint32 = 60023
 )
 
 // Auth error
@@ -119,9 +135,16 @@ const (
 )
 
 const (
-	OrderNotExist         uint32 = 61001
-	PaymentMethodNotFound uint32 = 61002
-	OrderStatusError      uint32 = 61003
-	InsufficientOfPeriod  uint32 = 61004
-	ExistAvailableTraffic uint32 = 61005
+	OrderNotExist            uint32 = 61001
+	PaymentMethodNotFound    uint32 = 61002
+	OrderStatusError         uint32 = 61003
+	InsufficientOfPeriod     uint32 = 61004
+	ExistAvailableTraffic    uint32 = 61005
+	QuantityExceedsLimit     uint32 = 61006
+	QuantityBelowMinimum     uint32 = 61007
+	RechargeOutOfRange       uint32 = 61008
+	PaymentMethodUnavailable uint32 = 61009
+	PaymentPlatformUnknown   uint32 = 61010
+	EnterprisePaymentDenied  uint32 = 61011
+	CreditLimitExceeded      uint32 = 61012
 )
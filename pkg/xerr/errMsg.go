@@ -56,6 +56,22 @@ func init() {
 		SingleSubscribeModeExceedsLimit: "Single subscribe mode exceeds limit",
 		SubscribeQuotaLimit:             "Subscribe quota limit",
 		SubscribeOutOfStock:             "Subscribe out of stock",
+		SubscribeRenewalStackLimit:      "Subscribe renewal exceeds the maximum banked time",
+		UserSubscribeLimit:              "User subscription limit reached",
+		SubscribeTrafficExhausted:       "Subscribe traffic is exhausted",
+		SubscribeNoNodes:                "Subscribe has no available nodes",
+		SubscribeDatacenterBlocked:      "Subscribe fetches from datacenter networks are blocked",
+		SubscribePauseLimit:             "Subscribe pause limit reached",
+		SubscribePaused:                 "Subscribe is paused",
+		SubscribeBuildBusy:              "Subscribe service is busy, please retry later",
+		SubscribeFormatUnknown:          "Unknown subscribe format",
+		SubscribeBundleNotAvailable:     "Subscribe bundle is not available",
+		SubscribeTrafficResetDisabled:   "Traffic reset is not available for this subscribe",
+		SubscribeLinkExpired:            "Subscribe link has expired",
+		SubscribeLinkInvalid:            "Subscribe link signature is invalid",
+		SubscribeRegionBlocked:          "Subscribe fetches from this region are blocked",
+		SubscribeTrafficTopUpDisabled:   "Traffic top-up is not available for this subscribe",
+		SubscribeSuspended:              "Subscribe is not active",
 
 		// auth error
 		VerifyCodeError: "Verify code error",
@@ -83,10 +99,17 @@ func init() {
 		UseridNotMatch:                     "Userid not match",
 
 		// Order error
-		OrderNotExist:         "Order does not exist",
-		PaymentMethodNotFound: "Payment method not found",
-		OrderStatusError:      "Order status error",
-		InsufficientOfPeriod:  "Insufficient number of period",
+		OrderNotExist:            "Order does not exist",
+		PaymentMethodNotFound:    "Payment method not found",
+		OrderStatusError:         "Order status error",
+		InsufficientOfPeriod:     "Insufficient number of period",
+		QuantityExceedsLimit:     "Quantity exceeds the limit",
+		QuantityBelowMinimum:     "Quantity is below the minimum",
+		RechargeOutOfRange:       "Recharge amount is out of the allowed range",
+		PaymentMethodUnavailable: "Payment method is not available for this order",
+		PaymentPlatformUnknown:   "Payment method uses an unknown platform",
+		EnterprisePaymentDenied:  "Invoice payment is only available to enterprise accounts",
+		CreditLimitExceeded:      "Outstanding invoices exceed the credit limit",
 	}
 
 }
@@ -12,6 +12,7 @@ General common fixed error
 type CodeError struct {
 	errCode uint32
 	errMsg  string
+	errData interface{}
 }
 
 var StatusNotModified = errors.New("304 Not Modified")
@@ -26,6 +27,12 @@ func (e *CodeError) GetErrMsg() string {
 	return e.errMsg
 }
 
+// GetErrData returns the structured details of the error displayed to the front end, e.g. a limit
+// the client needs to render a localized message
+func (e *CodeError) GetErrData() interface{} {
+	return e.errData
+}
+
 func (e *CodeError) Error() string {
 	return fmt.Sprintf("ErrCode:%d，ErrMsg:%s", e.errCode, e.errMsg)
 }
@@ -37,6 +44,11 @@ func NewErrCode(errCode uint32) *CodeError {
 	return &CodeError{errCode: errCode, errMsg: MapErrMsg(errCode)}
 }
 
+// NewErrCodeData returns the error of errCode with structured details for the front end
+func NewErrCodeData(errCode uint32, errData interface{}) *CodeError {
+	return &CodeError{errCode: errCode, errMsg: MapErrMsg(errCode), errData: errData}
+}
+
 func NewErrMsg(errMsg string) *CodeError {
 	return &CodeError{errCode: ERROR, errMsg: errMsg}
 }
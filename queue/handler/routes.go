@@ -22,6 +22,10 @@ func RegisterHandlers(mux *asynq.ServeMux, serverCtx *svc.ServiceContext) {
 	mux.Handle(types.DeferCloseOrder, orderLogic.NewDeferCloseOrderLogic(serverCtx))
 	// Forthwith activate order task
 	mux.Handle(types.ForthwithActivateOrder, orderLogic.NewActivateOrderLogic(serverCtx))
+	// Forthwith closed order notify task
+	mux.Handle(types.ForthwithOrderClosedNotify, orderLogic.NewClosedNotifyLogic(serverCtx))
+	// Forthwith invoice paid task
+	mux.Handle(types.ForthwithInvoicePaid, orderLogic.NewInvoicePaidLogic(serverCtx))
 
 	// Forthwith traffic statistics
 	mux.Handle(types.ForthwithTrafficStatistics, traffic.NewTrafficStatisticsLogic(serverCtx))
@@ -29,6 +33,15 @@ func RegisterHandlers(mux *asynq.ServeMux, serverCtx *svc.ServiceContext) {
 	// Schedule check subscription
 	mux.Handle(types.SchedulerCheckSubscription, subscription.NewCheckSubscriptionLogic(serverCtx))
 
+	// Forthwith subscribe geo anomaly check
+	mux.Handle(types.ForthwithSubscribeGeoCheck, subscription.NewGeoCheckLogic(serverCtx))
+
+	// Forthwith subscribe low stock webhook
+	mux.Handle(types.ForthwithSubscribeLowStock, subscription.NewLowStockLogic(serverCtx))
+
+	// Forthwith subscribe node backfill
+	mux.Handle(types.ForthwithSubscribeNodeBackfill, subscription.NewNodeBackfillLogic(serverCtx))
+
 	// Schedule total server data
 	mux.Handle(types.SchedulerTotalServerData, traffic.NewServerDataLogic(serverCtx))
 
@@ -41,6 +54,12 @@ func RegisterHandlers(mux *asynq.ServeMux, serverCtx *svc.ServiceContext) {
 	// ScheduledTrafficStat
 	mux.Handle(types.SchedulerTrafficStat, traffic.NewStatLogic(serverCtx))
 
+	// Schedule expire promo credit
+	mux.Handle(types.SchedulerExpirePromoCredit, task.NewPromoCreditLogic(serverCtx))
+
+	// Schedule resume paused subscriptions
+	mux.Handle(types.SchedulerResumePaused, task.NewResumePausedLogic(serverCtx))
+
 	// ForthwithQuotaTask
 	mux.Handle(types.ForthwithQuotaTask, task.NewQuotaTaskLogic(serverCtx))
 }
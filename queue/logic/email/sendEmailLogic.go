@@ -141,7 +141,7 @@ func (l *SendEmailLogic) ProcessTask(ctx context.Context, task *asynq.Task) erro
 
 	if err = l.svcCtx.LogModel.Insert(ctx, &log.SystemLog{
 		Type:     log.TypeEmailMessage.Uint8(),
-		Date:     time.Now().Format("2006-01-02"),
+		Date:     log.Date(time.Now()),
 		ObjectID: 0,
 		Content:  string(emailLog),
 	}); err != nil {
@@ -33,6 +33,9 @@ const (
 	OrderTypeRenewal      = 2 // Subscription renewal
 	OrderTypeResetTraffic = 3 // Traffic quota reset
 	OrderTypeRecharge     = 4 // Balance recharge
+	OrderTypeBulkRenewal  = 5 // Renewal of several subscriptions under one payment
+	OrderTypeBundle       = 6 // Subscription purchase with bundled gift amount credit
+	OrderTypeTrafficTopUp = 7 // Extra traffic for a subscription, the expire time is kept
 )
 
 // Order status constants define the lifecycle states of an order
@@ -81,7 +84,7 @@ func (l *ActivateOrderLogic) ProcessTask(ctx context.Context, task *asynq.Task)
 		return nil
 	}
 
-	l.finalizeCouponAndOrder(ctx, orderInfo)
+	l.finalizeOrder(ctx, orderInfo)
 	return nil
 }
 
@@ -110,7 +113,8 @@ func (l *ActivateOrderLogic) validateAndGetOrder(ctx context.Context, orderNo st
 		return nil, err
 	}
 
-	if orderInfo.Status != OrderStatusPaid {
+	// orders checked out on invoice are activated before they are paid
+	if orderInfo.Status != OrderStatusPaid && orderInfo.Status != order.StatusAwaitingInvoice {
 		logger.WithContext(ctx).Error("Order status error",
 			logger.Field("order_no", orderInfo.OrderNo),
 			logger.Field("status", orderInfo.Status),
@@ -124,7 +128,7 @@ func (l *ActivateOrderLogic) validateAndGetOrder(ctx context.Context, orderNo st
 // processOrderByType routes order processing based on the order type
 func (l *ActivateOrderLogic) processOrderByType(ctx context.Context, orderInfo *order.Order) error {
 	switch orderInfo.Type {
-	case OrderTypeSubscribe:
+	case OrderTypeSubscribe, OrderTypeBundle:
 		return l.NewPurchase(ctx, orderInfo)
 	case OrderTypeRenewal:
 		return l.Renewal(ctx, orderInfo)
@@ -132,27 +136,25 @@ func (l *ActivateOrderLogic) processOrderByType(ctx context.Context, orderInfo *
 		return l.ResetTraffic(ctx, orderInfo)
 	case OrderTypeRecharge:
 		return l.Recharge(ctx, orderInfo)
+	case OrderTypeBulkRenewal:
+		return l.BulkRenewal(ctx, orderInfo)
+	case OrderTypeTrafficTopUp:
+		return l.TrafficTopUp(ctx, orderInfo)
 	default:
 		logger.WithContext(ctx).Error("Order type is invalid", logger.Field("type", orderInfo.Type))
 		return ErrInvalidOrderType
 	}
 }
 
-// finalizeCouponAndOrder handles post-processing tasks including coupon updates
-// and order status finalization
-func (l *ActivateOrderLogic) finalizeCouponAndOrder(ctx context.Context, orderInfo *order.Order) {
-	// Update coupon if exists
-	if orderInfo.Coupon != "" {
-		if err := l.svc.CouponModel.UpdateCount(ctx, orderInfo.Coupon); err != nil {
-			logger.WithContext(ctx).Error("Update coupon status failed",
-				logger.Field("error", err.Error()),
-				logger.Field("coupon", orderInfo.Coupon),
-			)
-		}
+// finalizeOrder marks the order finished and saves what activation recorded on it, the coupon use was
+// already counted when the order was created. An order on invoice is finished when the invoice is marked paid.
+func (l *ActivateOrderLogic) finalizeOrder(ctx context.Context, orderInfo *order.Order) {
+	// Update order status, an order on invoice is marked provisioned so a redelivered task leaves it alone
+	if orderInfo.Status == order.StatusAwaitingInvoice {
+		orderInfo.Status = order.StatusInvoiced
+	} else {
+		orderInfo.Status = OrderStatusFinished
 	}
-
-	// Update order status
-	orderInfo.Status = OrderStatusFinished
 	if err := l.svc.OrderModel.Update(ctx, orderInfo); err != nil {
 		logger.WithContext(ctx).Error("Update order status failed",
 			logger.Field("error", err.Error()),
@@ -178,9 +180,12 @@ func (l *ActivateOrderLogic) NewPurchase(ctx context.Context, orderInfo *order.O
 	if err != nil {
 		return err
 	}
+	if err = l.creditBundle(ctx, userInfo, orderInfo, userSub); err != nil {
+		return err
+	}
 
 	// Handle commission in separate goroutine to avoid blocking
-	go l.handleCommission(context.Background(), userInfo, orderInfo)
+	l.grantRewards(userInfo, orderInfo)
 
 	// Clear cache
 	l.clearServerCache(ctx, sub)
@@ -325,29 +330,97 @@ func (l *ActivateOrderLogic) getSubscribeInfo(ctx context.Context, subscribeId i
 	return sub, nil
 }
 
-// createUserSubscription creates a new user subscription record based on order and subscription plan details
+// createUserSubscription creates the user subscriptions of the order based on order and subscription plan details.
+// A count mode plan gets a subscription per copy ordered, the first one is returned.
 func (l *ActivateOrderLogic) createUserSubscription(ctx context.Context, orderInfo *order.Order, sub *subscribe.Subscribe) (*user.Subscribe, error) {
 	now := time.Now()
-	userSub := &user.Subscribe{
-		UserId:      orderInfo.UserId,
-		OrderId:     orderInfo.Id,
-		SubscribeId: orderInfo.SubscribeId,
-		StartTime:   now,
-		ExpireTime:  tool.AddTime(sub.UnitTime, orderInfo.Quantity, now),
-		Traffic:     sub.Traffic,
-		Download:    0,
-		Upload:      0,
-		Token:       uuidx.SubscribeToken(orderInfo.OrderNo),
-		UUID:        uuid.New().String(),
-		Status:      1,
-	}
-
-	if err := l.svc.UserModel.InsertSubscribe(ctx, userSub); err != nil {
+	userSubs := make([]*user.Subscribe, sub.Copies(orderInfo.Quantity))
+	for i := range userSubs {
+		// the first copy keeps the token of the order, so a redelivered task finds it taken
+		token := uuidx.SubscribeToken(orderInfo.OrderNo)
+		if i > 0 {
+			token = uuidx.SubscribeToken(fmt.Sprintf("%s-%d", orderInfo.OrderNo, i+1))
+		}
+		userSubs[i] = &user.Subscribe{
+			UserId:      orderInfo.UserId,
+			OrderId:     orderInfo.Id,
+			SubscribeId: orderInfo.SubscribeId,
+			StartTime:   now,
+			ExpireTime:  addBonusDays(tool.AddTime(sub.UnitTime, sub.Periods(orderInfo.Quantity), now), orderInfo),
+			Traffic:     sub.Traffic,
+			Download:    0,
+			Upload:      0,
+			Token:       token,
+			UUID:        uuid.New().String(),
+			Status:      1,
+		}
+	}
+
+	err := l.svc.DB.Transaction(func(tx *gorm.DB) error {
+		for _, userSub := range userSubs {
+			if err := l.svc.UserModel.InsertSubscribe(ctx, userSub, tx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
 		logger.WithContext(ctx).Error("Insert user subscribe failed", logger.Field("error", err.Error()))
 		return nil, err
 	}
 
-	return userSub, nil
+	return userSubs[0], nil
+}
+
+// addBonusDays extends the expire time by the bonus days of the order's coupon, an unlimited subscription stays unlimited
+func addBonusDays(expireTime time.Time, orderInfo *order.Order) time.Time {
+	if orderInfo.BonusDays <= 0 || expireTime.UnixMilli() == 0 {
+		return expireTime
+	}
+	return expireTime.AddDate(0, 0, int(orderInfo.BonusDays))
+}
+
+// creditBundle credits the gift amount of a bundle order to the user
+func (l *ActivateOrderLogic) creditBundle(ctx context.Context, userInfo *user.User, orderInfo *order.Order, userSub *user.Subscribe) error {
+	if orderInfo.Type != OrderTypeBundle || orderInfo.BundleCredit <= 0 {
+		return nil
+	}
+	err := l.svc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.Id).Update("gift_amount", gorm.Expr("gift_amount + ?", orderInfo.BundleCredit)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.Id).Pluck("gift_amount", &userInfo.GiftAmount).Error; err != nil {
+			return err
+		}
+		giftLog := &log.Gift{
+			Type:        log.GiftTypeIncrease,
+			OrderNo:     orderInfo.OrderNo,
+			SubscribeId: userSub.Id,
+			Amount:      orderInfo.BundleCredit,
+			Balance:     userInfo.GiftAmount,
+			Remark:      "Bundle credit",
+			Timestamp:   time.Now().UnixMilli(),
+		}
+		content, _ := giftLog.Marshal()
+		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeGift.Uint8(),
+			Date:     log.Date(time.Now()),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		}).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Error("Credit bundle gift failed",
+			logger.Field("error", err.Error()),
+			logger.Field("user_id", userInfo.Id),
+			logger.Field("order_no", orderInfo.OrderNo),
+		)
+		return err
+	}
+	if err = l.svc.UserModel.UpdateUserCache(ctx, userInfo); err != nil {
+		logger.WithContext(ctx).Error("Update user cache failed", logger.Field("error", err.Error()), logger.Field("user_id", userInfo.Id))
+	}
+	return nil
 }
 
 // handleCommission processes referral commission for the referrer if applicable.
@@ -385,9 +458,9 @@ func (l *ActivateOrderLogic) handleCommission(ctx context.Context, userInfo *use
 
 		var commissionType uint16
 		switch orderInfo.Type {
-		case OrderTypeSubscribe:
+		case OrderTypeSubscribe, OrderTypeBundle:
 			commissionType = log.CommissionTypePurchase
-		case OrderTypeRenewal:
+		case OrderTypeRenewal, OrderTypeBulkRenewal:
 			commissionType = log.CommissionTypeRenewal
 		}
 
@@ -401,7 +474,7 @@ func (l *ActivateOrderLogic) handleCommission(ctx context.Context, userInfo *use
 		content, _ := commissionLog.Marshal()
 		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
 			Type:     log.TypeCommission.Uint8(),
-			Date:     time.Now().Format("2006-01-02"),
+			Date:     log.Date(time.Now()),
 			ObjectID: referer.Id,
 			Content:  string(content),
 		}).Error
@@ -421,6 +494,137 @@ func (l *ActivateOrderLogic) handleCommission(ctx context.Context, userInfo *use
 	}
 }
 
+// grantRewards pays the commission, loyalty credit and referral gift of the order in the background.
+// An order on invoice has not been paid yet, they are granted when its invoice is marked paid.
+func (l *ActivateOrderLogic) grantRewards(userInfo *user.User, orderInfo *order.Order) {
+	if orderInfo.Status == order.StatusAwaitingInvoice {
+		return
+	}
+	go l.handleCommission(context.Background(), userInfo, orderInfo)
+	go l.handleLoyaltyCredit(context.Background(), userInfo, orderInfo)
+	go l.handleReferralGift(context.Background(), userInfo, orderInfo)
+}
+
+// handleLoyaltyCredit grants the paying user loyalty credit as a percentage of the amount spent.
+// The credit can only be consumed on later renewals.
+func (l *ActivateOrderLogic) handleLoyaltyCredit(ctx context.Context, userInfo *user.User, orderInfo *order.Order) {
+	percent := l.svc.Config.Subscribe.LoyaltyCreditPercent
+	if userInfo == nil || percent <= 0 {
+		return
+	}
+	credit := l.calculateCommission(orderInfo.Amount-orderInfo.FeeAmount, uint8(min(percent, 100)))
+	if credit <= 0 {
+		return
+	}
+
+	var balance int64
+	err := l.svc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.Id).Update("loyalty_credit", gorm.Expr("loyalty_credit + ?", credit)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.Id).Pluck("loyalty_credit", &balance).Error; err != nil {
+			return err
+		}
+		creditLog := &log.LoyaltyCredit{
+			Type:      log.LoyaltyCreditTypeIncrease,
+			OrderNo:   orderInfo.OrderNo,
+			Amount:    credit,
+			Balance:   balance,
+			Remark:    "Order reward",
+			Timestamp: time.Now().UnixMilli(),
+		}
+		content, _ := creditLog.Marshal()
+		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeLoyaltyCredit.Uint8(),
+			Date:     log.Date(time.Now()),
+			ObjectID: userInfo.Id,
+			Content:  string(content),
+		}).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Error("Grant loyalty credit failed",
+			logger.Field("error", err.Error()),
+			logger.Field("user_id", userInfo.Id),
+			logger.Field("order_no", orderInfo.OrderNo),
+		)
+		return
+	}
+
+	if err = l.svc.UserModel.UpdateUserCache(ctx, userInfo); err != nil {
+		logger.WithContext(ctx).Error("Update user cache failed",
+			logger.Field("error", err.Error()),
+			logger.Field("user_id", userInfo.Id),
+		)
+	}
+}
+
+// handleReferralGift credits the referrer's gift amount with ReferralGiftPercentage of a referred
+// user's first paid order. It runs on the payment-success path only, so closed orders never pay out.
+func (l *ActivateOrderLogic) handleReferralGift(ctx context.Context, userInfo *user.User, orderInfo *order.Order) {
+	percent := l.svc.Config.Invite.ReferralGiftPercentage
+	if userInfo == nil || !orderInfo.IsNew || percent <= 0 || userInfo.RefererId == 0 {
+		return
+	}
+	// guard against self-referral
+	if userInfo.RefererId == userInfo.Id {
+		logger.WithContext(ctx).Info("Skip self-referral gift", logger.Field("user_id", userInfo.Id))
+		return
+	}
+	amount := l.calculateCommission(orderInfo.Amount-orderInfo.FeeAmount, uint8(min(percent, 100)))
+	if amount <= 0 {
+		return
+	}
+
+	var balance int64
+	err := l.svc.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.RefererId).Update("gift_amount", gorm.Expr("gift_amount + ?", amount)).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&user.User{}).Where("id = ?", userInfo.RefererId).Pluck("gift_amount", &balance).Error; err != nil {
+			return err
+		}
+		giftLog := &log.Gift{
+			Type:      log.GiftTypeIncrease,
+			OrderNo:   orderInfo.OrderNo,
+			Amount:    amount,
+			Balance:   balance,
+			RefereeId: userInfo.Id,
+			Remark:    "Referral reward",
+			Timestamp: time.Now().UnixMilli(),
+		}
+		content, _ := giftLog.Marshal()
+		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+			Type:     log.TypeGift.Uint8(),
+			Date:     log.Date(time.Now()),
+			ObjectID: userInfo.RefererId,
+			Content:  string(content),
+		}).Error
+	})
+	if err != nil {
+		logger.WithContext(ctx).Error("Credit referral gift failed",
+			logger.Field("error", err.Error()),
+			logger.Field("referer_id", userInfo.RefererId),
+			logger.Field("order_no", orderInfo.OrderNo),
+		)
+		return
+	}
+
+	referer, err := l.svc.UserModel.FindOne(ctx, userInfo.RefererId)
+	if err != nil {
+		logger.WithContext(ctx).Error("Find referer failed",
+			logger.Field("error", err.Error()),
+			logger.Field("referer_id", userInfo.RefererId),
+		)
+		return
+	}
+	if err = l.svc.UserModel.UpdateUserCache(ctx, referer); err != nil {
+		logger.WithContext(ctx).Error("Update referer cache failed",
+			logger.Field("error", err.Error()),
+			logger.Field("user_id", userInfo.RefererId),
+		)
+	}
+}
+
 // shouldProcessCommission determines if commission should be processed based on
 // referrer existence, commission settings, and order type
 func (l *ActivateOrderLogic) shouldProcessCommission(userInfo *user.User, isFirstPurchase bool) bool {
@@ -488,6 +692,7 @@ func (l *ActivateOrderLogic) Renewal(ctx context.Context, orderInfo *order.Order
 		return err
 	}
 
+	previousSubscribeId := userSub.SubscribeId
 	if err = l.updateSubscriptionForRenewal(ctx, userSub, sub, orderInfo); err != nil {
 		return err
 	}
@@ -504,9 +709,15 @@ func (l *ActivateOrderLogic) Renewal(ctx context.Context, orderInfo *order.Order
 
 	// Clear cache
 	l.clearServerCache(ctx, sub)
+	if previousSubscribeId != sub.Id {
+		// the user left the previous plan, its node user lists must drop them
+		if err = l.svc.SubscribeModel.ClearCache(ctx, previousSubscribeId); err != nil {
+			logger.WithContext(ctx).Error("[Order Queue] Clear subscribe cache failed", logger.Field("error", err.Error()))
+		}
+	}
 
 	// Handle commission
-	go l.handleCommission(context.Background(), userInfo, orderInfo)
+	l.grantRewards(userInfo, orderInfo)
 
 	// Send notifications
 	l.sendNotifications(ctx, orderInfo, userInfo, sub, userSub, telegram.RenewalNotify)
@@ -514,6 +725,65 @@ func (l *ActivateOrderLogic) Renewal(ctx context.Context, orderInfo *order.Order
 	return nil
 }
 
+// BulkRenewal extends every subscription paid through a bulk renewal order and finishes its renewal order.
+// Renewal orders that are no longer pending or paid were already applied and are skipped. Commission and
+// loyalty credit are granted once for the bulk order, the renewal orders only carry each subscription's share.
+func (l *ActivateOrderLogic) BulkRenewal(ctx context.Context, orderInfo *order.Order) error {
+	userInfo, err := l.getExistingUser(ctx, orderInfo.UserId)
+	if err != nil {
+		return err
+	}
+
+	items, err := l.svc.OrderModel.FindBulkItems(ctx, orderInfo.OrderNo)
+	if err != nil {
+		logger.WithContext(ctx).Error("Find bulk renewal orders failed",
+			logger.Field("error", err.Error()),
+			logger.Field("order_no", orderInfo.OrderNo),
+		)
+		return err
+	}
+
+	for _, item := range items {
+		// renewal orders of a bulk order created paid are paid themselves
+		if item.Status != OrderStatusPending && item.Status != OrderStatusPaid {
+			continue
+		}
+		userSub, err := l.getUserSubscription(ctx, item.SubscribeToken)
+		if err != nil {
+			return err
+		}
+		sub, err := l.getSubscribeInfo(ctx, item.SubscribeId)
+		if err != nil {
+			return err
+		}
+		if err = l.updateSubscriptionForRenewal(ctx, userSub, sub, item); err != nil {
+			return err
+		}
+		if err = l.svc.OrderModel.UpdateOrderStatusFrom(ctx, item.OrderNo, item.Status, OrderStatusFinished); err != nil {
+			logger.WithContext(ctx).Error("Update bulk renewal order status failed",
+				logger.Field("error", err.Error()),
+				logger.Field("order_no", item.OrderNo),
+			)
+			return err
+		}
+
+		if err = l.svc.UserModel.ClearSubscribeCache(ctx, userSub); err != nil {
+			logger.WithContext(ctx).Error("Clear user subscribe cache failed",
+				logger.Field("error", err.Error()),
+				logger.Field("subscribe_id", userSub.Id),
+				logger.Field("user_id", userInfo.Id),
+			)
+		}
+		l.clearServerCache(ctx, sub)
+
+		l.sendNotifications(ctx, item, userInfo, sub, userSub, telegram.RenewalNotify)
+	}
+
+	l.grantRewards(userInfo, orderInfo)
+
+	return nil
+}
+
 // getUserSubscription retrieves user subscription by token
 func (l *ActivateOrderLogic) getUserSubscription(ctx context.Context, token string) (*user.Subscribe, error) {
 	userSub, err := l.svc.UserModel.FindOneSubscribeByToken(ctx, token)
@@ -525,12 +795,32 @@ func (l *ActivateOrderLogic) getUserSubscription(ctx context.Context, token stri
 }
 
 // updateSubscriptionForRenewal updates subscription details for renewal including
-// expiration time extension and traffic reset if configured
+// expiration time extension and traffic reset if configured. A renewal ordered into
+// another plan moves the subscription to that plan, keeping the remaining time.
 func (l *ActivateOrderLogic) updateSubscriptionForRenewal(ctx context.Context, userSub *user.Subscribe, sub *subscribe.Subscribe, orderInfo *order.Order) error {
 	now := time.Now()
 	if userSub.ExpireTime.Before(now) {
 		userSub.ExpireTime = now
 	}
+	var previous *subscribe.Subscribe
+	if sub.Id != userSub.SubscribeId {
+		var err error
+		if previous, err = l.getSubscribeInfo(ctx, userSub.SubscribeId); err != nil {
+			return err
+		}
+		if userSub.ExpireTime.Unix() != 0 {
+			// the time left on the previous plan is converted into time of the same value on the new one
+			userSub.ExpireTime = now.Add(previous.ConvertRemaining(userSub.ExpireTime.Sub(now), sub, now))
+		}
+		orderInfo.FromSubscribeId = previous.Id
+		// the new plan brings its own traffic and nodes, per-user node overrides of the old plan no longer apply
+		userSub.SubscribeId = sub.Id
+		userSub.Traffic = sub.Traffic
+		userSub.Download = 0
+		userSub.Upload = 0
+		userSub.ExcludeNodes = ""
+		userSub.IncludeNodes = ""
+	}
 	today := time.Now().Day()
 	resetDay := userSub.ExpireTime.Day()
 
@@ -550,13 +840,24 @@ func (l *ActivateOrderLogic) updateSubscriptionForRenewal(ctx context.Context, u
 		userSub.FinishedAt = nil
 	}
 
-	userSub.ExpireTime = tool.AddTime(sub.UnitTime, orderInfo.Quantity, userSub.ExpireTime)
+	// the time the order was priced for, orders from before the snapshot fall back to the plan
+	unit, periods := orderInfo.UnitTime, orderInfo.Periods
+	if unit == "" {
+		unit, periods = sub.UnitTime, sub.Periods(orderInfo.Quantity)
+	}
+	userSub.ExpireTime = addBonusDays(tool.AddTime(unit, periods, userSub.ExpireTime), orderInfo)
 	userSub.Status = 1
 
 	if err := l.svc.UserModel.UpdateSubscribe(ctx, userSub); err != nil {
 		logger.WithContext(ctx).Error("Update user subscribe failed", logger.Field("error", err.Error()))
 		return err
 	}
+	if previous != nil {
+		// the seat of the previous plan is free again, the renewal order took one of the new plan
+		if err := l.svc.SubscribeModel.IncreaseInventory(ctx, previous.Id); err != nil {
+			logger.WithContext(ctx).Error("Restore subscribe inventory failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", previous.Id))
+		}
+	}
 
 	return nil
 }
@@ -612,7 +913,7 @@ func (l *ActivateOrderLogic) ResetTraffic(ctx context.Context, orderInfo *order.
 	content, _ := resetLog.Marshal()
 	if err = l.svc.LogModel.Insert(ctx, &log.SystemLog{
 		Type:     log.TypeResetSubscribe.Uint8(),
-		Date:     time.Now().Format(time.DateOnly),
+		Date:     log.Date(time.Now()),
 		ObjectID: userSub.Id,
 		Content:  string(content),
 	}); err != nil {
@@ -625,6 +926,39 @@ func (l *ActivateOrderLogic) ResetTraffic(ctx context.Context, orderInfo *order.
 	return nil
 }
 
+// TrafficTopUp adds the traffic bought by the order to the subscription, a subscription finished for its
+// exhausted traffic is active again when the top-up covers its usage
+func (l *ActivateOrderLogic) TrafficTopUp(ctx context.Context, orderInfo *order.Order) error {
+	userInfo, err := l.getExistingUser(ctx, orderInfo.UserId)
+	if err != nil {
+		return err
+	}
+
+	userSub, err := l.getUserSubscription(ctx, orderInfo.SubscribeToken)
+	if err != nil {
+		return err
+	}
+
+	if err = l.svc.UserModel.AddSubscribeTraffic(ctx, userSub.Id, orderInfo.Quantity*order.TrafficTopUpUnit); err != nil {
+		logger.WithContext(ctx).Error("Add user subscribe traffic failed",
+			logger.Field("error", err.Error()),
+			logger.Field("subscribe_id", userSub.Id),
+			logger.Field("order_no", orderInfo.OrderNo),
+		)
+		return err
+	}
+
+	sub, err := l.getSubscribeInfo(ctx, userSub.SubscribeId)
+	if err != nil {
+		return err
+	}
+	// a reactivated subscription is back in the node user lists
+	l.clearServerCache(ctx, sub)
+
+	l.sendNotifications(ctx, orderInfo, userInfo, sub, userSub, telegram.TrafficTopUpNotify)
+	return nil
+}
+
 // Recharge handles balance recharge orders including balance updates,
 // transaction logging, and notifications
 func (l *ActivateOrderLogic) Recharge(ctx context.Context, orderInfo *order.Order) error {
@@ -651,7 +985,7 @@ func (l *ActivateOrderLogic) Recharge(ctx context.Context, orderInfo *order.Orde
 
 		return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
 			Type:     log.TypeBalance.Uint8(),
-			Date:     time.Now().Format("2006-01-02"),
+			Date:     log.Date(time.Now()),
 			ObjectID: userInfo.Id,
 			Content:  string(content),
 		}).Error
@@ -740,8 +1074,11 @@ func (l *ActivateOrderLogic) buildUserNotificationData(orderInfo *order.Order, s
 // buildAdminNotificationData creates template data for admin notifications
 func (l *ActivateOrderLogic) buildAdminNotificationData(orderInfo *order.Order, sub *subscribe.Subscribe) map[string]string {
 	subscribeName := sub.Name
-	if orderInfo.Type == OrderTypeResetTraffic {
+	switch orderInfo.Type {
+	case OrderTypeResetTraffic:
 		subscribeName = "流量重置"
+	case OrderTypeTrafficTopUp:
+		subscribeName = "流量加购"
 	}
 
 	return map[string]string{
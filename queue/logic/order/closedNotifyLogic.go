@@ -0,0 +1,181 @@
+package orderLogic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"text/template"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/logic/telegram"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/email"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/queue/types"
+)
+
+// closedOrderNotifier delivers the closed order notice over one channel.
+type closedOrderNotifier interface {
+	Notify(ctx context.Context, u *user.User, data map[string]string) error
+}
+
+// closedOrderNotifiers are the channels CloseNotify.Channels can name.
+var closedOrderNotifiers = map[string]func(svc *svc.ServiceContext) closedOrderNotifier{
+	"email":    func(svc *svc.ServiceContext) closedOrderNotifier { return &emailClosedNotifier{svc: svc} },
+	"telegram": func(svc *svc.ServiceContext) closedOrderNotifier { return &telegramClosedNotifier{svc: svc} },
+}
+
+type ClosedNotifyLogic struct {
+	svc *svc.ServiceContext
+}
+
+func NewClosedNotifyLogic(svc *svc.ServiceContext) *ClosedNotifyLogic {
+	return &ClosedNotifyLogic{
+		svc: svc,
+	}
+}
+
+// ProcessTask sends the closed order notice over every configured channel. A channel that fails is
+// logged and skipped, retrying would send the notice again over the channels that succeeded.
+func (l *ClosedNotifyLogic) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload types.ForthwithOrderClosedNotifyPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.WithContext(ctx).Error("[ClosedNotify] Unmarshal payload failed",
+			logger.Field("error", err.Error()),
+			logger.Field("payload", string(task.Payload())),
+		)
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	channels := l.svc.Config.CloseNotify.Channels
+	if len(channels) == 0 {
+		return nil
+	}
+	orderInfo, err := l.svc.OrderModel.FindOneByOrderNo(ctx, payload.OrderNo)
+	if err != nil {
+		logger.WithContext(ctx).Error("[ClosedNotify] Find order failed", logger.Field("error", err.Error()), logger.Field("orderNo", payload.OrderNo))
+		return err
+	}
+	userInfo, err := l.svc.UserModel.FindOne(ctx, orderInfo.UserId)
+	if err != nil {
+		logger.WithContext(ctx).Error("[ClosedNotify] Find user failed", logger.Field("error", err.Error()), logger.Field("user_id", orderInfo.UserId))
+		return err
+	}
+	data := l.buildClosedNotifyData(ctx, orderInfo, payload.Reason)
+
+	for _, channel := range channels {
+		newNotifier, ok := closedOrderNotifiers[channel]
+		if !ok {
+			logger.WithContext(ctx).Error("[ClosedNotify] Unsupported channel", logger.Field("channel", channel))
+			continue
+		}
+		if err = newNotifier(l.svc).Notify(ctx, userInfo, data); err != nil {
+			logger.WithContext(ctx).Error("[ClosedNotify] Notify failed",
+				logger.Field("error", err.Error()),
+				logger.Field("channel", channel),
+				logger.Field("orderNo", orderInfo.OrderNo),
+			)
+		}
+	}
+	return nil
+}
+
+// buildClosedNotifyData creates the template data of the closed order notice
+func (l *ClosedNotifyLogic) buildClosedNotifyData(ctx context.Context, orderInfo *order.Order, reason string) map[string]string {
+	data := map[string]string{
+		"SiteName":      l.svc.Config.Site.SiteName,
+		"SiteLogo":      l.svc.Config.Site.SiteLogo,
+		"OrderNo":       orderInfo.OrderNo,
+		"OrderAmount":   fmt.Sprintf("%.2f", float64(orderInfo.Amount)/100),
+		"SubscribeId":   strconv.FormatInt(orderInfo.SubscribeId, 10),
+		"SubscribeName": "",
+		"Reason":        reason,
+		"CloseTime":     time.Now().Format("2006-01-02 15:04:05"),
+		"RepurchaseURL": "",
+	}
+	if orderInfo.SubscribeId != 0 {
+		if sub, err := l.svc.SubscribeModel.FindOne(ctx, orderInfo.SubscribeId); err == nil {
+			data["SubscribeName"] = sub.Name
+		}
+	}
+	if tpl := l.svc.Config.CloseNotify.RepurchaseURL; tpl != "" {
+		if link, err := renderClosedNotify(tpl, data); err == nil {
+			data["RepurchaseURL"] = link
+		} else {
+			logger.WithContext(ctx).Error("[ClosedNotify] Render repurchase URL failed", logger.Field("error", err.Error()))
+		}
+	}
+	return data
+}
+
+// emailClosedNotifier sends the notice through the email task, which logs the message like any other email
+type emailClosedNotifier struct {
+	svc *svc.ServiceContext
+}
+
+func (n *emailClosedNotifier) Notify(ctx context.Context, u *user.User, data map[string]string) error {
+	method, err := n.svc.UserModel.FindUserAuthMethodByUserId(ctx, "email", u.Id)
+	if err != nil {
+		return fmt.Errorf("find email of user %d: %w", u.Id, err)
+	}
+	tpl := n.svc.Config.CloseNotify.EmailTemplate
+	if tpl == "" {
+		tpl = email.DefaultOrderClosedEmailTemplate
+	}
+	content, err := renderClosedNotify(tpl, data)
+	if err != nil {
+		return err
+	}
+	val, err := json.Marshal(types.SendEmailPayload{
+		Type:    types.EmailTypeCustom,
+		Email:   method.AuthIdentifier,
+		Subject: n.svc.Config.CloseNotify.EmailSubject,
+		Content: map[string]interface{}{"content": content},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = n.svc.Queue.EnqueueContext(ctx, asynq.NewTask(types.ForthwithSendEmail, val, asynq.MaxRetry(3)))
+	return err
+}
+
+// telegramClosedNotifier messages the user's bound Telegram account, users without one are skipped
+type telegramClosedNotifier struct {
+	svc *svc.ServiceContext
+}
+
+func (n *telegramClosedNotifier) Notify(_ context.Context, u *user.User, data map[string]string) error {
+	telegramId, ok := findTelegram(u)
+	if !ok || n.svc.TelegramBot == nil {
+		return nil
+	}
+	tpl := n.svc.Config.CloseNotify.TelegramTemplate
+	if tpl == "" {
+		tpl = telegram.OrderClosedNotify
+	}
+	text, err := renderClosedNotify(tpl, data)
+	if err != nil {
+		return err
+	}
+	msg := tgbotapi.NewMessage(telegramId, text)
+	msg.ParseMode = "markdown"
+	_, err = n.svc.TelegramBot.Send(msg)
+	return err
+}
+
+func renderClosedNotify(tpl string, data map[string]string) (string, error) {
+	t, err := template.New("closed_notify").Parse(tpl)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err = t.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
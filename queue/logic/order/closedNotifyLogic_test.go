@@ -0,0 +1,31 @@
+package orderLogic
+
+import (
+	"testing"
+
+	"github.com/perfect-panel/server/internal/logic/telegram"
+	"github.com/perfect-panel/server/pkg/email"
+	"github.com/perfect-panel/server/queue/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderClosedNotifyReason(t *testing.T) {
+	data := map[string]string{
+		"OrderNo":       "202601010001",
+		"SubscribeName": "Pro",
+		"OrderAmount":   "9.90",
+		"RepurchaseURL": "https://example.com/purchase?id=1",
+	}
+	for _, tpl := range []string{email.DefaultOrderClosedEmailTemplate, telegram.OrderClosedNotify} {
+		data["Reason"] = types.OrderCloseReasonTimeout
+		timeout, err := renderClosedNotify(tpl, data)
+		assert.NoError(t, err)
+		data["Reason"] = types.OrderCloseReasonCancel
+		cancel, err := renderClosedNotify(tpl, data)
+		assert.NoError(t, err)
+
+		assert.NotEqual(t, timeout, cancel)
+		assert.Contains(t, timeout, data["OrderNo"])
+		assert.Contains(t, cancel, data["RepurchaseURL"])
+	}
+}
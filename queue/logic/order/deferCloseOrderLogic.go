@@ -3,6 +3,7 @@ package orderLogic
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 
 	"github.com/perfect-panel/server/pkg/logger"
 
@@ -30,17 +31,48 @@ func (l *DeferCloseOrderLogic) ProcessTask(ctx context.Context, task *asynq.Task
 			logger.Field("error", err.Error()),
 			logger.Field("payload", string(task.Payload())),
 		)
+		// a malformed payload will never succeed, don't retry
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	if payload.OrderNo == "" {
+		logger.WithContext(ctx).Error("[DeferCloseOrderLogic] Order number is empty", logger.Field("payload", string(task.Payload())))
+		return fmt.Errorf("order number is empty: %w", asynq.SkipRetry)
+	}
+
+	// A resent payment link extends the window of the order, the close task of the later deadline takes over
+	if !payload.Final && order.CloseSuperseded(ctx, l.svc, &payload) {
+		logger.WithContext(ctx).Info("[DeferCloseOrderLogic] Payment window extended, close skipped", logger.Field("orderNo", payload.OrderNo))
 		return nil
 	}
 
-	err := order.NewCloseOrderLogic(ctx, l.svc).CloseOrder(&internal.CloseOrderRequest{
+	closeLogic := order.NewCloseOrderLogic(ctx, l.svc)
+	// Unpaid orders of hold strategy payment methods are held first, a second task closes them once the hold expires
+	if !payload.Final {
+		held, err := closeLogic.HoldOrder(payload.OrderNo)
+		if err != nil {
+			logger.WithContext(ctx).Error("[DeferCloseOrderLogic] Hold order failed",
+				logger.Field("error", err.Error()),
+				logger.Field("orderNo", payload.OrderNo),
+			)
+			return err
+		}
+		if held {
+			return nil
+		}
+	}
+
+	// Orders that are missing, paid or already closed are skipped by CloseOrder,
+	// the remaining errors are transient (e.g. database timeout) and retried with backoff.
+	err := closeLogic.CloseExpiredOrder(&internal.CloseOrderRequest{
 		OrderNo: payload.OrderNo,
 	})
-	count, ok := asynq.GetRetryCount(ctx)
-	if !ok {
-		return nil
-	}
-	if err != nil && count < 3 {
+	if err != nil {
+		count, _ := asynq.GetRetryCount(ctx)
+		logger.WithContext(ctx).Error("[DeferCloseOrderLogic] Close order failed",
+			logger.Field("error", err.Error()),
+			logger.Field("orderNo", payload.OrderNo),
+			logger.Field("retry", count),
+		)
 		return err
 	}
 	return nil
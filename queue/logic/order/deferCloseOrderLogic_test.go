@@ -0,0 +1,165 @@
+package orderLogic
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/model/payment"
+	"github.com/perfect-panel/server/internal/model/subscribe"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/tool"
+	"github.com/perfect-panel/server/queue/types"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestDeferCloseOrderSkipRetry(t *testing.T) {
+	l := NewDeferCloseOrderLogic(nil)
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "malformed payload", payload: []byte("{invalid")},
+		{name: "empty order number", payload: []byte(`{"order_no":""}`)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := l.ProcessTask(context.Background(), asynq.NewTask(types.DeferCloseOrder, tt.payload))
+			assert.Error(t, err)
+			assert.True(t, errors.Is(err, asynq.SkipRetry))
+		})
+	}
+}
+
+// The hold tests are opt-in integration tests, the order status claims need a real MySQL database, e.g.
+// PPANEL_TEST_MYSQL_DSN="root:password@tcp(localhost:3306)/ppanel_test?charset=utf8mb4&parseTime=true" go test ./queue/logic/order/
+//
+// newCloseTestOrder inserts a pending order of a plan with limited stock, paid with a method of the close
+// strategy. The order reserved gift amount of the user and took one unit of the plan.
+func newCloseTestOrder(t *testing.T, strategy uint8) (*svc.ServiceContext, *order.Order) {
+	dsn := os.Getenv("PPANEL_TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skipf("skip %s test, PPANEL_TEST_MYSQL_DSN not set", t.Name())
+	}
+	db, err := gorm.Open(mysql.Open(dsn), &gorm.Config{})
+	require.NoError(t, err)
+	require.NoError(t, db.AutoMigrate(&order.Order{}, &subscribe.Subscribe{}, &user.User{}, &payment.Payment{}, &log.SystemLog{}))
+	mr := miniredis.RunT(t)
+	rds := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	queue := asynq.NewClient(asynq.RedisClientOpt{Addr: mr.Addr()})
+	t.Cleanup(func() { _ = queue.Close() })
+	svcCtx := &svc.ServiceContext{
+		DB:    db,
+		Redis: rds,
+		Queue: queue,
+		Config: config.Config{Queue: config.QueueConfig{
+			CloseOrderMaxRetry: 3,
+			HoldOrderMinutes:   1440,
+		}},
+		OrderModel:     order.NewModel(db, rds),
+		SubscribeModel: subscribe.NewModel(db, rds),
+		UserModel:      user.NewModel(db, rds),
+		PaymentModel:   payment.NewModel(db, rds),
+	}
+	ctx := context.Background()
+
+	method := &payment.Payment{Name: t.Name(), Platform: "EPay", Token: tool.GenerateTradeNo(), CloseStrategy: strategy, HoldMinutes: 60}
+	require.NoError(t, db.Create(method).Error)
+	sub := &subscribe.Subscribe{Name: t.Name(), Inventory: 4, UnitPrice: 1000, UnitTime: "Month"}
+	require.NoError(t, svcCtx.SubscribeModel.Insert(ctx, sub))
+	u := &user.User{Password: t.Name()}
+	require.NoError(t, svcCtx.UserModel.Insert(ctx, u))
+	orderInfo := &order.Order{
+		OrderNo:     tool.GenerateTradeNo(),
+		UserId:      u.Id,
+		Type:        1,
+		Quantity:    1,
+		Amount:      700,
+		GiftAmount:  300,
+		SubscribeId: sub.Id,
+		PaymentId:   method.Id,
+		Method:      method.Platform,
+		Status:      order.StatusPending,
+	}
+	require.NoError(t, svcCtx.OrderModel.Insert(ctx, orderInfo))
+	t.Cleanup(func() {
+		db.Unscoped().Where("order_no = ?", orderInfo.OrderNo).Delete(&order.Order{})
+		db.Where("`object_id` = ?", u.Id).Delete(&log.SystemLog{})
+		db.Unscoped().Delete(&user.User{}, u.Id)
+		_ = svcCtx.SubscribeModel.Delete(ctx, sub.Id)
+		db.Delete(&payment.Payment{}, method.Id)
+	})
+	return svcCtx, orderInfo
+}
+
+// closeTestState reads the order status, the plan inventory and the gift amount of the user.
+func closeTestState(t *testing.T, svcCtx *svc.ServiceContext, orderInfo *order.Order) (status uint8, inventory, gift int64) {
+	db := svcCtx.DB
+	require.NoError(t, db.Model(&order.Order{}).Where("order_no = ?", orderInfo.OrderNo).Pluck("status", &status).Error)
+	require.NoError(t, db.Model(&subscribe.Subscribe{}).Where("id = ?", orderInfo.SubscribeId).Pluck("inventory", &inventory).Error)
+	require.NoError(t, db.Model(&user.User{}).Where("id = ?", orderInfo.UserId).Pluck("gift_amount", &gift).Error)
+	return status, inventory, gift
+}
+
+func closeTask(t *testing.T, orderNo string, final bool) *asynq.Task {
+	val, err := json.Marshal(types.DeferCloseOrderPayload{OrderNo: orderNo, Final: final})
+	require.NoError(t, err)
+	return asynq.NewTask(types.DeferCloseOrder, val)
+}
+
+// TestDeferCloseOrderHold holds the unpaid order of a hold strategy method when its window runs out, the
+// reservation is only given back by the final close once the hold expires.
+func TestDeferCloseOrderHold(t *testing.T) {
+	svcCtx, orderInfo := newCloseTestOrder(t, payment.CloseStrategyHold)
+	ctx := context.Background()
+	l := NewDeferCloseOrderLogic(svcCtx)
+
+	require.NoError(t, l.ProcessTask(ctx, closeTask(t, orderInfo.OrderNo, false)))
+	status, inventory, gift := closeTestState(t, svcCtx, orderInfo)
+	assert.Equal(t, order.StatusHold, status)
+	assert.Equal(t, int64(4), inventory, "a held order keeps its unit")
+	assert.Equal(t, int64(0), gift, "a held order keeps the gift amount")
+
+	// the final close is scheduled for the end of the hold
+	info, err := asynq.NewInspector(asynq.RedisClientOpt{Addr: svcCtx.Redis.Options().Addr}).GetTaskInfo("default", "hold:"+orderInfo.OrderNo)
+	require.NoError(t, err)
+	assert.Equal(t, asynq.TaskStateScheduled, info.State)
+
+	// a repeated window close leaves the held order alone
+	require.NoError(t, l.ProcessTask(ctx, closeTask(t, orderInfo.OrderNo, false)))
+	status, inventory, gift = closeTestState(t, svcCtx, orderInfo)
+	assert.Equal(t, order.StatusHold, status)
+	assert.Equal(t, int64(4), inventory)
+	assert.Equal(t, int64(0), gift)
+
+	require.NoError(t, l.ProcessTask(ctx, closeTask(t, orderInfo.OrderNo, true)))
+	status, inventory, gift = closeTestState(t, svcCtx, orderInfo)
+	assert.Equal(t, order.StatusClose, status)
+	assert.Equal(t, int64(5), inventory)
+	assert.Equal(t, int64(300), gift)
+}
+
+// TestDeferCloseOrderCancel closes the unpaid order of a cancel strategy method right away.
+func TestDeferCloseOrderCancel(t *testing.T) {
+	svcCtx, orderInfo := newCloseTestOrder(t, payment.CloseStrategyCancel)
+	ctx := context.Background()
+
+	require.NoError(t, NewDeferCloseOrderLogic(svcCtx).ProcessTask(ctx, closeTask(t, orderInfo.OrderNo, false)))
+	status, inventory, gift := closeTestState(t, svcCtx, orderInfo)
+	assert.Equal(t, order.StatusClose, status)
+	assert.Equal(t, int64(5), inventory)
+	assert.Equal(t, int64(300), gift)
+}
@@ -0,0 +1,64 @@
+package orderLogic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/order"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/queue/types"
+)
+
+type InvoicePaidLogic struct {
+	svc *svc.ServiceContext
+}
+
+func NewInvoicePaidLogic(svc *svc.ServiceContext) *InvoicePaidLogic {
+	return &InvoicePaidLogic{
+		svc: svc,
+	}
+}
+
+// ProcessTask grants the commission, loyalty credit and referral gift of an order on invoice,
+// they were held back at activation until the invoice was marked paid.
+func (l *InvoicePaidLogic) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload types.ForthwithInvoicePaidPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.WithContext(ctx).Error("[InvoicePaid] Unmarshal payload failed",
+			logger.Field("error", err.Error()),
+			logger.Field("payload", string(task.Payload())),
+		)
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	orderInfo, err := l.svc.OrderModel.FindOneByOrderNo(ctx, payload.OrderNo)
+	if err != nil {
+		logger.WithContext(ctx).Error("[InvoicePaid] Find order failed",
+			logger.Field("error", err.Error()),
+			logger.Field("order_no", payload.OrderNo),
+		)
+		return err
+	}
+	// the task is enqueued by the transaction settling the invoice, it may run before that commits
+	if orderInfo.Status == order.StatusInvoiced {
+		return fmt.Errorf("invoice of order %s is not settled yet", orderInfo.OrderNo)
+	}
+	if orderInfo.Status != order.StatusFinished {
+		logger.WithContext(ctx).Error("[InvoicePaid] Order status error",
+			logger.Field("order_no", orderInfo.OrderNo),
+			logger.Field("status", orderInfo.Status),
+		)
+		return nil
+	}
+	activate := NewActivateOrderLogic(l.svc)
+	userInfo, err := activate.getExistingUser(ctx, orderInfo.UserId)
+	if err != nil {
+		return err
+	}
+	activate.handleCommission(ctx, userInfo, orderInfo)
+	activate.handleLoyaltyCredit(ctx, userInfo, orderInfo)
+	activate.handleReferralGift(ctx, userInfo, orderInfo)
+	return nil
+}
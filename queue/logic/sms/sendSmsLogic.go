@@ -68,7 +68,7 @@ func (l *SendSmsLogic) ProcessTask(ctx context.Context, task *asynq.Task) error
 	content, _ := createSms.Marshal()
 	err = l.svcCtx.LogModel.Insert(ctx, &log.SystemLog{
 		Type:     log.TypeMobileMessage.Uint8(),
-		Date:     time.Now().Format("2006-01-02"),
+		Date:     log.Date(time.Now()),
 		ObjectID: 0,
 		Content:  string(content),
 	})
@@ -0,0 +1,97 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/redis/go-redis/v9"
+)
+
+type GeoCheckLogic struct {
+	svc *svc.ServiceContext
+}
+
+func NewGeoCheckLogic(svc *svc.ServiceContext) *GeoCheckLogic {
+	return &GeoCheckLogic{
+		svc: svc,
+	}
+}
+
+func (l *GeoCheckLogic) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload queue.ForthwithSubscribeGeoCheckPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.WithContext(ctx).Error("[GeoCheck] Unmarshal payload failed",
+			logger.Field("error", err.Error()),
+			logger.Field("payload", string(task.Payload())),
+		)
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	limit := l.svc.Config.GeoIP.AnomalyCountries
+	window := l.svc.Config.GeoIP.AnomalyWindow
+	if limit <= 0 || window <= 0 || payload.Token == "" || payload.CountryCode == "" {
+		return nil
+	}
+
+	countries, err := recordCountry(ctx, l.svc.Redis, payload.Token, payload.CountryCode, payload.Timestamp, window)
+	if err != nil {
+		logger.WithContext(ctx).Error("[GeoCheck] Record country failed", logger.Field("error", err.Error()), logger.Field("token", payload.Token))
+		return err
+	}
+	if int64(len(countries)) <= limit {
+		return nil
+	}
+
+	// alert once per window for the same token
+	ok, err := l.svc.Redis.SetNX(ctx, config.SubscribeGeoAlertKeyPrefix+payload.Token, 1, time.Duration(window)*time.Second).Result()
+	if err != nil || !ok {
+		return err
+	}
+
+	logger.WithContext(ctx).Infow("[GeoCheck] Subscription fetched from too many countries",
+		logger.Field("token", payload.Token),
+		logger.Field("user_id", payload.UserId),
+		logger.Field("countries", countries),
+		logger.Field("window", window),
+	)
+	anomaly := log.SubscribeAnomaly{
+		Token:           payload.Token,
+		UserSubscribeId: payload.UserSubscribeId,
+		Countries:       countries,
+		Window:          window,
+		Timestamp:       payload.Timestamp,
+	}
+	content, _ := anomaly.Marshal()
+	if err = l.svc.LogModel.Insert(ctx, &log.SystemLog{
+		Type:     log.TypeSubscribeAnomaly.Uint8(),
+		ObjectID: payload.UserId,
+		Date:     log.Date(time.Unix(payload.Timestamp, 0)),
+		Content:  string(content),
+	}); err != nil {
+		logger.WithContext(ctx).Error("[GeoCheck] Insert anomaly log failed", logger.Field("error", err.Error()))
+		return err
+	}
+	return nil
+}
+
+// recordCountry stores the country in the token's sliding window and returns the distinct countries seen within it.
+func recordCountry(ctx context.Context, rds *redis.Client, token, country string, timestamp, window int64) ([]string, error) {
+	key := config.SubscribeGeoWindowKeyPrefix + token
+	pipe := rds.TxPipeline()
+	pipe.ZAdd(ctx, key, redis.Z{Score: float64(timestamp), Member: country})
+	pipe.ZRemRangeByScore(ctx, key, "-inf", "("+strconv.FormatInt(timestamp-window, 10))
+	pipe.Expire(ctx, key, time.Duration(window)*time.Second)
+	members := pipe.ZRange(ctx, key, 0, -1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, err
+	}
+	return members.Val(), nil
+}
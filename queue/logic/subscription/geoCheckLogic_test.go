@@ -0,0 +1,40 @@
+package subscription
+
+import (
+	"context"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordCountryWindow(t *testing.T) {
+	mr := miniredis.RunT(t)
+	rds := redis.NewClient(&redis.Options{Addr: mr.Addr()})
+	defer rds.Close()
+	ctx := context.Background()
+
+	countries, err := recordCountry(ctx, rds, "token", "US", 1000, 3600)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"US"}, countries)
+
+	// the same country is only counted once
+	countries, err = recordCountry(ctx, rds, "token", "US", 1100, 3600)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"US"}, countries)
+
+	countries, err = recordCountry(ctx, rds, "token", "DE", 1200, 3600)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"US", "DE"}, countries)
+
+	// US was last seen at 1100 and drops out of the window
+	countries, err = recordCountry(ctx, rds, "token", "JP", 4800, 3600)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"DE", "JP"}, countries)
+
+	// other tokens are tracked separately
+	countries, err = recordCountry(ctx, rds, "other", "FR", 4800, 3600)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"FR"}, countries)
+}
@@ -0,0 +1,88 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	queue "github.com/perfect-panel/server/queue/types"
+)
+
+type LowStockLogic struct {
+	svc *svc.ServiceContext
+}
+
+type lowStockNotification struct {
+	SubscribeId int64  `json:"subscribe_id"`
+	Name        string `json:"name"`
+	Inventory   int64  `json:"inventory"`
+	Threshold   int64  `json:"threshold"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+func NewLowStockLogic(svc *svc.ServiceContext) *LowStockLogic {
+	return &LowStockLogic{
+		svc: svc,
+	}
+}
+
+func (l *LowStockLogic) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload queue.ForthwithSubscribeLowStockPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.WithContext(ctx).Error("[LowStock] Unmarshal payload failed",
+			logger.Field("error", err.Error()),
+			logger.Field("payload", string(task.Payload())),
+		)
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	cfg := l.svc.Config.Inventory
+	if cfg.LowStockThreshold <= 0 || cfg.LowStockWebhook == "" {
+		return nil
+	}
+	sub, err := l.svc.SubscribeModel.FindOne(ctx, payload.SubscribeId)
+	if err != nil {
+		logger.WithContext(ctx).Error("[LowStock] Find subscribe failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", payload.SubscribeId))
+		return err
+	}
+
+	key := config.SubscribeLowStockKeyPrefix + strconv.FormatInt(sub.Id, 10)
+	if sub.Inventory < 0 || sub.Inventory >= cfg.LowStockThreshold {
+		// restocked or unlimited, arm the notification again
+		return l.svc.Redis.Del(ctx, key).Err()
+	}
+	// notify once until the plan is restocked
+	ok, err := l.svc.Redis.SetNX(ctx, key, sub.Inventory, 0).Result()
+	if err != nil || !ok {
+		return err
+	}
+
+	resp, err := resty.New().SetTimeout(10*time.Second).R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(lowStockNotification{
+			SubscribeId: sub.Id,
+			Name:        sub.Name,
+			Inventory:   sub.Inventory,
+			Threshold:   cfg.LowStockThreshold,
+			Timestamp:   time.Now().Unix(),
+		}).
+		Post(cfg.LowStockWebhook)
+	if err == nil && resp.IsError() {
+		err = fmt.Errorf("webhook responded %s", resp.Status())
+	}
+	if err != nil {
+		logger.WithContext(ctx).Error("[LowStock] Send webhook failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		// let the retry send it again
+		_ = l.svc.Redis.Del(ctx, key).Err()
+		return err
+	}
+	logger.WithContext(ctx).Infow("[LowStock] Webhook sent", logger.Field("subscribe_id", sub.Id), logger.Field("inventory", sub.Inventory))
+	return nil
+}
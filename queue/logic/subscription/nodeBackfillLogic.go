@@ -0,0 +1,228 @@
+package subscription
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/node"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/internal/types"
+	"github.com/perfect-panel/server/pkg/logger"
+	"github.com/perfect-panel/server/pkg/tool"
+	queue "github.com/perfect-panel/server/queue/types"
+	"github.com/redis/go-redis/v9"
+	"gorm.io/gorm"
+)
+
+const (
+	// nodeBackfillBatchSize subscriptions written to a node's user list at once
+	nodeBackfillBatchSize = 200
+	// nodeBackfillPause pause between two batches, so a large plan does not hold the user lists for long
+	nodeBackfillPause = 500 * time.Millisecond
+)
+
+type NodeBackfillLogic struct {
+	svc *svc.ServiceContext
+}
+
+func NewNodeBackfillLogic(svc *svc.ServiceContext) *NodeBackfillLogic {
+	return &NodeBackfillLogic{
+		svc: svc,
+	}
+}
+
+// ProcessTask adds the active subscriptions of a plan to the cached user lists of the nodes newly added to it.
+// Nodes pull their users from that list, one built before the node joined the plan misses the plan's users
+// until it is rebuilt. Users already in a list are left as they are, so running the task again is harmless.
+// A list that is not cached is skipped, the node builds it in full on its next pull.
+func (l *NodeBackfillLogic) ProcessTask(ctx context.Context, task *asynq.Task) error {
+	var payload queue.ForthwithSubscribeNodeBackfillPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		logger.WithContext(ctx).Error("[NodeBackfill] Unmarshal payload failed",
+			logger.Field("error", err.Error()),
+			logger.Field("payload", string(task.Payload())),
+		)
+		return fmt.Errorf("unmarshal payload error: %v: %w", err.Error(), asynq.SkipRetry)
+	}
+	sub, err := l.svc.SubscribeModel.FindOne(ctx, payload.SubscribeId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		logger.WithContext(ctx).Error("[NodeBackfill] Find subscribe failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", payload.SubscribeId))
+		return err
+	}
+	// the plan may have dropped some of the nodes again since the task was queued
+	nodes, err := l.svc.NodeModel.FindPlanNodes(ctx, tool.StringToInt64Slice(sub.Nodes), tool.StringMergeAndRemoveDuplicates(sub.NodeTags))
+	if err != nil {
+		logger.WithContext(ctx).Error("[NodeBackfill] Find plan nodes failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+		return err
+	}
+	// nodes of one server share its user list
+	servers := make(map[int64][]int64)
+	for _, n := range nodes {
+		if tool.Contains(payload.NodeIds, n.Id) {
+			servers[n.ServerId] = append(servers[n.ServerId], n.Id)
+		}
+	}
+	if len(servers) == 0 {
+		logger.WithContext(ctx).Infow("[NodeBackfill] Nodes no longer in plan", logger.Field("subscribe_id", sub.Id), logger.Field("node_ids", payload.NodeIds))
+		return nil
+	}
+	for serverId, nodeIds := range servers {
+		exists, err := l.svc.Redis.Exists(ctx, serverUserListKey(serverId)).Result()
+		if err != nil {
+			return err
+		}
+		if exists == 0 {
+			logger.WithContext(ctx).Infow("[NodeBackfill] User list not cached, built on next pull",
+				logger.Field("subscribe_id", sub.Id),
+				logger.Field("server_id", serverId),
+				logger.Field("node_ids", nodeIds),
+			)
+			delete(servers, serverId)
+		}
+	}
+
+	var (
+		lastId int64
+		added  = make(map[int64]int)
+		failed int
+	)
+	for len(servers) > 0 {
+		var list []*user.Subscribe
+		err = l.svc.DB.WithContext(ctx).Model(&user.Subscribe{}).
+			Where("subscribe_id = ? AND `status` IN ? AND id > ?", sub.Id, []int64{0, 1}, lastId).
+			Order("id ASC").Limit(nodeBackfillBatchSize).Find(&list).Error
+		if err != nil {
+			logger.WithContext(ctx).Error("[NodeBackfill] Query subscriptions failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", sub.Id))
+			return err
+		}
+		if len(list) == 0 {
+			break
+		}
+		lastId = list[len(list)-1].Id
+
+		users := make([]types.ServerUser, 0, len(list))
+		ids := make([]int64, 0, len(list))
+		for _, item := range list {
+			users = append(users, types.ServerUser{
+				Id:          item.Id,
+				UUID:        item.UUID,
+				SpeedLimit:  sub.SpeedLimit,
+				DeviceLimit: sub.DeviceLimit,
+			})
+			ids = append(ids, item.Id)
+		}
+		for serverId, nodeIds := range servers {
+			n, err := l.mergeServerUsers(ctx, serverId, users)
+			if err != nil {
+				logger.WithContext(ctx).Error("[NodeBackfill] Write user list failed",
+					logger.Field("error", err.Error()),
+					logger.Field("subscribe_id", sub.Id),
+					logger.Field("server_id", serverId),
+					logger.Field("node_ids", nodeIds),
+					logger.Field("user_subscribe_ids", ids),
+				)
+				failed++
+				// the rest of the plan is retried with the task
+				delete(servers, serverId)
+				continue
+			}
+			added[serverId] += n
+		}
+		logger.WithContext(ctx).Infow("[NodeBackfill] Batch done",
+			logger.Field("subscribe_id", sub.Id),
+			logger.Field("last_user_subscribe_id", lastId),
+			logger.Field("count", len(list)),
+		)
+		if len(list) < nodeBackfillBatchSize {
+			break
+		}
+		time.Sleep(nodeBackfillPause)
+	}
+	for serverId, nodeIds := range servers {
+		logger.WithContext(ctx).Infow("[NodeBackfill] Nodes backfilled",
+			logger.Field("subscribe_id", sub.Id),
+			logger.Field("server_id", serverId),
+			logger.Field("node_ids", nodeIds),
+			logger.Field("added", added[serverId]),
+		)
+	}
+	if failed > 0 {
+		return fmt.Errorf("backfill of subscribe %d failed on %d servers", sub.Id, failed)
+	}
+	return nil
+}
+
+// mergeServerUsers adds the users missing from the cached user list of the server and returns how many were
+// added. A pull rebuilding the list meanwhile makes the write fail, the list is read again then.
+func (l *NodeBackfillLogic) mergeServerUsers(ctx context.Context, serverId int64, users []types.ServerUser) (int, error) {
+	key := serverUserListKey(serverId)
+	var added int
+	txf := func(tx *redis.Tx) error {
+		added = 0
+		val, err := tx.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			// dropped since, the next pull builds it with the plan's users
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var list types.GetServerUserListResponse
+		if err = json.Unmarshal([]byte(val), &list); err != nil {
+			return err
+		}
+		// a list without subscriptions holds a single random user
+		if len(list.Users) == 1 && l.isPlaceholder(ctx, list.Users[0]) {
+			list.Users = list.Users[:0]
+		}
+		seen := make(map[int64]bool, len(list.Users))
+		for _, u := range list.Users {
+			seen[u.Id] = true
+		}
+		for _, u := range users {
+			if !seen[u.Id] {
+				list.Users = append(list.Users, u)
+				added++
+			}
+		}
+		if added == 0 {
+			return nil
+		}
+		data, _ := json.Marshal(list)
+		_, err = tx.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Set(ctx, key, string(data), redis.KeepTTL)
+			return nil
+		})
+		return err
+	}
+	var err error
+	for i := 0; i < 3; i++ {
+		if err = l.svc.Redis.Watch(ctx, txf, key); !errors.Is(err, redis.TxFailedErr) {
+			return added, err
+		}
+	}
+	return 0, err
+}
+
+func (l *NodeBackfillLogic) isPlaceholder(ctx context.Context, u types.ServerUser) bool {
+	if u.Id != 1 {
+		return false
+	}
+	sub, err := l.svc.UserModel.FindOneSubscribe(ctx, u.Id)
+	if err != nil {
+		return errors.Is(err, gorm.ErrRecordNotFound)
+	}
+	return sub.UUID != u.UUID
+}
+
+func serverUserListKey(serverId int64) string {
+	return fmt.Sprintf("%s%d", node.ServerUserListCacheKey, serverId)
+}
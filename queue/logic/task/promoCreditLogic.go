@@ -0,0 +1,69 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type PromoCreditLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewPromoCreditLogic(svcCtx *svc.ServiceContext) *PromoCreditLogic {
+	return &PromoCreditLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+// ProcessTask zeroes the promo credit of every user whose credit expired and logs the expired amount.
+func (l *PromoCreditLogic) ProcessTask(ctx context.Context, _ *asynq.Task) error {
+	now := time.Now()
+	var list []*user.User
+	err := l.svcCtx.DB.WithContext(ctx).Model(&user.User{}).
+		Where("promo_credit > 0 AND promo_credit_expired_at IS NOT NULL AND promo_credit_expired_at <= ?", now).
+		Find(&list).Error
+	if err != nil {
+		logger.Errorw("[PromoCredit] Query expired promo credit failed", logger.Field("error", err.Error()))
+		return err
+	}
+	for _, u := range list {
+		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+			// only expire the balance that was read, an order placed meanwhile is retried on the next run
+			result := tx.Model(&user.User{}).Where("id = ? AND promo_credit = ?", u.Id, u.PromoCredit).UpdateColumn("promo_credit", 0)
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			giftLog := log.Gift{
+				Type:      log.GiftTypeExpire,
+				Amount:    u.PromoCredit,
+				Balance:   0,
+				Bucket:    log.GiftBucketPromo,
+				Remark:    "Promo credit expired",
+				Timestamp: now.UnixMilli(),
+			}
+			content, _ := giftLog.Marshal()
+			return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeGift.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: u.Id,
+				Content:  string(content),
+			}).Error
+		})
+		if err != nil {
+			logger.Errorw("[PromoCredit] Expire promo credit failed", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+			continue
+		}
+		if err = l.svcCtx.UserModel.UpdateUserCache(ctx, u); err != nil {
+			logger.Errorw("[PromoCredit] Clear user cache failed", logger.Field("error", err.Error()), logger.Field("user_id", u.Id))
+		}
+	}
+	logger.Infow("[PromoCredit] Expired promo credit", logger.Field("count", len(list)))
+	return nil
+}
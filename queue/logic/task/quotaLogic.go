@@ -382,7 +382,7 @@ func (l *QuotaTaskLogic) createGiftLog(tx *gorm.DB, subscribeId, userId, amount,
 		Type:     log.TypeGift.Uint8(),
 		Content:  string(logString),
 		ObjectID: userId,
-		Date:     now.Format(time.DateOnly),
+		Date:     log.Date(now),
 	}).Error
 }
 
@@ -402,6 +402,6 @@ func (l *QuotaTaskLogic) createResetTrafficLog(tx *gorm.DB, subscribeId, userId
 		Type:     log.TypeResetSubscribe.Uint8(),
 		Content:  string(logString),
 		ObjectID: subscribeId,
-		Date:     now.Format(time.DateOnly),
+		Date:     log.Date(now),
 	}).Error
 }
@@ -0,0 +1,86 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/model/log"
+	"github.com/perfect-panel/server/internal/model/user"
+	"github.com/perfect-panel/server/internal/svc"
+	"github.com/perfect-panel/server/pkg/logger"
+	"gorm.io/gorm"
+)
+
+type ResumePausedLogic struct {
+	svcCtx *svc.ServiceContext
+}
+
+func NewResumePausedLogic(svcCtx *svc.ServiceContext) *ResumePausedLogic {
+	return &ResumePausedLogic{
+		svcCtx: svcCtx,
+	}
+}
+
+// ProcessTask resumes every subscription paused for longer than MaxPauseDays, its remaining time starts running again.
+func (l *ResumePausedLogic) ProcessTask(ctx context.Context, _ *asynq.Task) error {
+	days := l.svcCtx.Config.Subscribe.MaxPauseDays
+	if days <= 0 {
+		return nil
+	}
+	now := time.Now()
+	var list []*user.Subscribe
+	err := l.svcCtx.DB.WithContext(ctx).Model(&user.Subscribe{}).
+		Where("status = ? AND paused_at IS NOT NULL AND paused_at <= ?", user.SubscribeStatusPaused, now.AddDate(0, 0, -int(days))).
+		Find(&list).Error
+	if err != nil {
+		logger.Errorw("[ResumePaused] Query paused subscribe failed", logger.Field("error", err.Error()))
+		return err
+	}
+	subscribeIds := make(map[int64]struct{})
+	for _, sub := range list {
+		remaining := sub.PausedFor
+		sub.Resume(now)
+		err = l.svcCtx.DB.Transaction(func(tx *gorm.DB) error {
+			// skip a subscription the user resumed meanwhile
+			result := tx.Model(&user.Subscribe{}).Where("id = ? AND status = ?", sub.Id, user.SubscribeStatusPaused).
+				Updates(map[string]interface{}{
+					"status":      sub.Status,
+					"expire_time": sub.ExpireTime,
+					"paused_at":   nil,
+					"paused_for":  0,
+				})
+			if result.Error != nil || result.RowsAffected == 0 {
+				return result.Error
+			}
+			pauseLog := log.SubscribePause{
+				Type:            log.SubscribePauseTypeAutoResume,
+				UserSubscribeId: sub.Id,
+				Remaining:       remaining,
+				Timestamp:       now.UnixMilli(),
+			}
+			content, _ := pauseLog.Marshal()
+			return tx.Model(&log.SystemLog{}).Create(&log.SystemLog{
+				Type:     log.TypeSubscribePause.Uint8(),
+				Date:     log.Date(now),
+				ObjectID: sub.UserId,
+				Content:  string(content),
+			}).Error
+		})
+		if err != nil {
+			logger.Errorw("[ResumePaused] Resume subscribe failed", logger.Field("error", err.Error()), logger.Field("user_subscribe_id", sub.Id))
+			continue
+		}
+		if err = l.svcCtx.UserModel.ClearSubscribeCache(ctx, sub); err != nil {
+			logger.Errorw("[ResumePaused] Clear user subscribe cache failed", logger.Field("error", err.Error()), logger.Field("user_subscribe_id", sub.Id))
+		}
+		subscribeIds[sub.SubscribeId] = struct{}{}
+	}
+	for id := range subscribeIds {
+		if err = l.svcCtx.SubscribeModel.ClearCache(ctx, id); err != nil {
+			logger.Errorw("[ResumePaused] Clear subscribe cache failed", logger.Field("error", err.Error()), logger.Field("subscribe_id", id))
+		}
+	}
+	logger.Infow("[ResumePaused] Resumed paused subscribe", logger.Field("count", len(list)))
+	return nil
+}
@@ -617,7 +617,7 @@ func (l *ResetTrafficLogic) insertLog(ctx context.Context, subId, userId int64)
 	if err := l.svc.DB.WithContext(ctx).Model(&log.SystemLog{}).Create(&log.SystemLog{
 		Type:     log.TypeResetSubscribe.Uint8(),
 		ObjectID: subId,
-		Date:     time.Now().Format(time.DateOnly),
+		Date:     log.Date(time.Now()),
 		Content:  string(content),
 	}).Error; err != nil {
 		logger.Errorw("[ResetTraffic] Failed to create system log for subscription", logger.Field("error", err.Error()))
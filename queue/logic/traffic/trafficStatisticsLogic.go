@@ -76,6 +76,15 @@ func (l *TrafficStatisticsLogic) ProcessTask(ctx context.Context, task *asynq.Ta
 	if protocol.Ratio > 0 {
 		ratio = float32(protocol.Ratio)
 	}
+	// premium nodes count their traffic at the node rate on top of the protocol ratio
+	rate, err := l.svc.NodeModel.FindTrafficRate(ctx, payload.ServerId, protocol.Type)
+	if err != nil {
+		logger.WithContext(ctx).Error("[TrafficStatistics] Find node traffic rate failed",
+			logger.Field("serverId", payload.ServerId),
+			logger.Field("error", err.Error()),
+		)
+	}
+	ratio *= float32(rate)
 
 	now := time.Now()
 	realTimeMultiplier := l.svc.NodeMultiplierManager.GetMultiplier(now)
@@ -45,7 +45,8 @@ func initService(svc *svc.ServiceContext) *asynq.Server {
 				logger.Error("consumer service error", logger.Field("error", err.Error()))
 				return true
 			},
-			Concurrency: 20,
+			Concurrency:    20,
+			RetryDelayFunc: retryDelayFunc(svc.Config.Queue),
 		},
 	)
 }
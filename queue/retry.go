@@ -0,0 +1,38 @@
+package queue
+
+import (
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/perfect-panel/server/internal/config"
+	"github.com/perfect-panel/server/queue/types"
+)
+
+// retryDelayFunc returns the retry delay of the consumer service,
+// close order tasks use an exponential backoff and other tasks keep the asynq default.
+func retryDelayFunc(c config.QueueConfig) asynq.RetryDelayFunc {
+	return func(n int, err error, task *asynq.Task) time.Duration {
+		if task.Type() == types.DeferCloseOrder {
+			return exponentialBackoff(n, time.Duration(c.CloseOrderRetryDelay)*time.Second, time.Duration(c.CloseOrderMaxRetryDelay)*time.Second)
+		}
+		return asynq.DefaultRetryDelayFunc(n, err, task)
+	}
+}
+
+// exponentialBackoff returns base * 2^n, capped at maxDelay.
+func exponentialBackoff(n int, base, maxDelay time.Duration) time.Duration {
+	if base <= 0 {
+		base = time.Second
+	}
+	delay := base
+	for i := 0; i < n; i++ {
+		delay *= 2
+		if maxDelay > 0 && delay >= maxDelay {
+			return maxDelay
+		}
+	}
+	if maxDelay > 0 && delay > maxDelay {
+		return maxDelay
+	}
+	return delay
+}
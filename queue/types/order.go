@@ -3,13 +3,33 @@ package types
 const (
 	DeferCloseOrder        = "defer:order:close"
 	ForthwithActivateOrder = "forthwith:order:activate"
+	// ForthwithOrderClosedNotify notify the user that an unpaid order was closed
+	ForthwithOrderClosedNotify = "forthwith:order:closed_notify"
+	// ForthwithInvoicePaid grant the commission and rewards of an order on invoice once the invoice is paid
+	ForthwithInvoicePaid = "forthwith:order:invoice_paid"
+)
+
+// Reasons an unpaid order was closed for
+const (
+	OrderCloseReasonTimeout = "timeout" // the payment window ran out
+	OrderCloseReasonCancel  = "cancel"  // the user cancelled it
+	OrderCloseReasonAdmin   = "admin"   // an admin force-closed it
 )
 
 type (
 	DeferCloseOrderPayload struct {
-		OrderNo string `json:"order_no"`
+		OrderNo  string `json:"order_no"`
+		Final    bool   `json:"final,omitempty"`    // the close after a hold, never held again
+		Deadline int64  `json:"deadline,omitempty"` // the extended payment window the close belongs to, unix seconds
 	}
 	ForthwithActivateOrderPayload struct {
 		OrderNo string `json:"order_no"`
 	}
+	ForthwithOrderClosedNotifyPayload struct {
+		OrderNo string `json:"order_no"`
+		Reason  string `json:"reason"`
+	}
+	ForthwithInvoicePaidPayload struct {
+		OrderNo string `json:"order_no"`
+	}
 )
@@ -5,4 +5,6 @@ const (
 	SchedulerTotalServerData   = "scheduler:total:server"
 	SchedulerResetTraffic      = "scheduler:reset:traffic"
 	SchedulerTrafficStat       = "scheduler:traffic:stat"
+	SchedulerExpirePromoCredit = "scheduler:expire:promo_credit"
+	SchedulerResumePaused      = "scheduler:resume:paused"
 )
@@ -0,0 +1,27 @@
+package types
+
+const (
+	// ForthwithSubscribeGeoCheck check a subscription fetch for multi-country anomalies
+	ForthwithSubscribeGeoCheck = "forthwith:subscribe:geo_check"
+	// ForthwithSubscribeLowStock notify the low stock webhook when a plan's inventory drops below the threshold
+	ForthwithSubscribeLowStock = "forthwith:subscribe:low_stock"
+	// ForthwithSubscribeNodeBackfill add the plan's active subscriptions to the user lists of nodes newly added to it
+	ForthwithSubscribeNodeBackfill = "forthwith:subscribe:node_backfill"
+)
+
+type (
+	ForthwithSubscribeGeoCheckPayload struct {
+		Token           string `json:"token"`
+		UserId          int64  `json:"user_id"`
+		UserSubscribeId int64  `json:"user_subscribe_id"`
+		CountryCode     string `json:"country_code"`
+		Timestamp       int64  `json:"timestamp"`
+	}
+	ForthwithSubscribeLowStockPayload struct {
+		SubscribeId int64 `json:"subscribe_id"`
+	}
+	ForthwithSubscribeNodeBackfillPayload struct {
+		SubscribeId int64   `json:"subscribe_id"`
+		NodeIds     []int64 `json:"node_ids"`
+	}
+)
@@ -52,6 +52,18 @@ func (m *Service) Start() {
 		logger.Errorf("register update exchange rate task failed: %s", err.Error())
 	}
 
+	// schedule expire promo credit task: every hour
+	promoCreditTask := asynq.NewTask(types.SchedulerExpirePromoCredit, nil)
+	if _, err := m.server.Register("@every 1h", promoCreditTask, asynq.MaxRetry(3)); err != nil {
+		logger.Errorf("register expire promo credit task failed: %s", err.Error())
+	}
+
+	// schedule resume paused subscribe task: every hour
+	resumePausedTask := asynq.NewTask(types.SchedulerResumePaused, nil)
+	if _, err := m.server.Register("@every 1h", resumePausedTask, asynq.MaxRetry(3)); err != nil {
+		logger.Errorf("register resume paused subscribe task failed: %s", err.Error())
+	}
+
 	if err := m.server.Run(); err != nil {
 		logger.Errorf("run scheduler failed: %s", err.Error())
 	}